@@ -4,9 +4,19 @@ import "errors"
 
 var (
 	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUserNotFound is wrapped by providers that can tell an unknown
+	// username from a wrong password, so a chain can fall through to the
+	// next provider.
+	ErrUserNotFound = errors.New("user not found")
 
 	// HTTP API errors
 	ErrHTTPAPIConnection  = errors.New("failed to connect to authentication API")
 	ErrHTTPAPIAuthFailed  = errors.New("authentication API rejected credentials")
 	ErrHTTPAPIInvalidResp = errors.New("invalid response from authentication API")
+
+	// LDAP errors
+	ErrLDAPConnection    = errors.New("failed to connect to LDAP server")
+	ErrLDAPSearch        = errors.New("LDAP search failed")
+	ErrLDAPAmbiguousUser = errors.New("LDAP search matched more than one user")
+	ErrLDAPInvalidEntry  = errors.New("LDAP user entry is missing a required attribute")
 )
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 
 	retry "github.com/appleboy/go-httpretry"
 
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/util"
 )
 
@@ -43,6 +46,14 @@ type APIAuthResponse struct {
 	Email    string `json:"email,omitempty"`
 	FullName string `json:"full_name,omitempty"`
 	Message  string `json:"message,omitempty"`
+	// UserNotFound tells an unknown username apart from a wrong password
+	UserNotFound bool `json:"user_not_found,omitempty"`
+
+	// Role and Groups feed HTTP_API_ADMIN_ROLES / HTTP_API_ADMIN_GROUPS;
+	// all three are stored on the user and issued as claims.
+	Role       string         `json:"role,omitempty"`
+	Groups     []string       `json:"groups,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 // Authenticate verifies credentials against external HTTP API
@@ -81,6 +92,10 @@ func (p *HTTPAPIAuthProvider) Authenticate(
 	var authResp APIAuthResponse
 	unmarshalErr := json.Unmarshal(body, &authResp)
 
+	if unmarshalErr == nil && !authResp.Success && authResp.UserNotFound {
+		return nil, fmt.Errorf("%w: %w", ErrHTTPAPIAuthFailed, ErrUserNotFound)
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		if unmarshalErr == nil && authResp.Message != "" {
 			return nil, fmt.Errorf(
@@ -114,12 +129,36 @@ func (p *HTTPAPIAuthProvider) Authenticate(
 		)
 	}
 
-	return &Result{
-		Username:   username,
-		ExternalID: authResp.UserID,
-		Email:      authResp.Email,
-		FullName:   authResp.FullName,
-	}, nil
+	result := &Result{
+		Username:     username,
+		ExternalID:   authResp.UserID,
+		Email:        authResp.Email,
+		FullName:     authResp.FullName,
+		ExternalRole: strings.TrimSpace(authResp.Role),
+		Groups:       compactGroups(authResp.Groups),
+		Attributes:   authResp.Attributes,
+	}
+	if len(p.config.HTTPAPIAdminRoles) > 0 || len(p.config.HTTPAPIAdminGroups) > 0 {
+		result.Role = models.UserRoleUser
+		if slices.Contains(p.config.HTTPAPIAdminRoles, result.ExternalRole) ||
+			slices.ContainsFunc(result.Groups, func(g string) bool {
+				return slices.Contains(p.config.HTTPAPIAdminGroups, g)
+			}) {
+			result.Role = models.UserRoleAdmin
+		}
+	}
+	return result, nil
+}
+
+// compactGroups trims group names and drops empty and repeated ones.
+func compactGroups(groups []string) []string {
+	var out []string
+	for _, g := range groups {
+		if g = strings.TrimSpace(g); g != "" && !slices.Contains(out, g) {
+			out = append(out, g)
+		}
+	}
+	return out
 }
 
 // Name returns provider name for logging
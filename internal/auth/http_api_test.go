@@ -124,6 +124,75 @@ func TestHTTPAPIAuthProvider_Authenticate_AuthFailed(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func TestHTTPAPIAuthProvider_Authenticate_UserNotFound(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				_ = json.NewEncoder(w).Encode(APIAuthResponse{
+					Success:      false,
+					UserNotFound: true,
+				})
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			provider := createTestProvider(&config.Config{
+				HTTPAPIURL:     server.URL,
+				HTTPAPITimeout: 10 * time.Second,
+			})
+			_, err := provider.Authenticate(context.Background(), "ghost", "password123")
+			require.ErrorIs(t, err, ErrUserNotFound)
+			assert.ErrorIs(t, err, ErrHTTPAPIAuthFailed)
+		})
+	}
+}
+
+func TestHTTPAPIAuthProvider_Authenticate_RolesAndGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"success": true,
+			"user_id": "ext-user-123",
+			"role": "editor",
+			"groups": ["staff", " ops ", "", "staff"],
+			"attributes": {"department": "R&D", "level": 3}
+		}`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		adminRoles  []string
+		adminGroups []string
+		wantRole    string
+	}{
+		{name: "no rules leaves the role alone"},
+		{name: "admin role", adminRoles: []string{"editor"}, wantRole: "admin"},
+		{name: "admin group", adminGroups: []string{"ops"}, wantRole: "admin"},
+		{name: "no match", adminRoles: []string{"owner"}, wantRole: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := createTestProvider(&config.Config{
+				HTTPAPIURL:         server.URL,
+				HTTPAPITimeout:     10 * time.Second,
+				HTTPAPIAdminRoles:  tt.adminRoles,
+				HTTPAPIAdminGroups: tt.adminGroups,
+			})
+			result, err := provider.Authenticate(context.Background(), "testuser", "pass")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRole, result.Role)
+			assert.Equal(t, "editor", result.ExternalRole)
+			assert.Equal(t, []string{"staff", "ops"}, result.Groups)
+			assert.Equal(
+				t, map[string]any{"department": "R&D", "level": float64(3)}, result.Attributes,
+			)
+		})
+	}
+}
+
 func TestHTTPAPIAuthProvider_Authenticate_Non2xxStatus(t *testing.T) {
 	// Mock external API server that returns 401 status
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
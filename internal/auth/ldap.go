@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var _ core.AuthProvider = (*LDAPAuthProvider)(nil)
+
+// LDAPAuthProvider authenticates users against an LDAP directory such as
+// OpenLDAP or Active Directory. It either binds directly with a DN built
+// from LDAP_USER_DN_TEMPLATE, or searches for the user first and then binds
+// with the DN it found.
+type LDAPAuthProvider struct {
+	config *config.Config
+	pool   *ldapPool
+}
+
+// NewLDAPAuthProvider creates a new LDAP authentication provider
+func NewLDAPAuthProvider(cfg *config.Config) *LDAPAuthProvider {
+	p := &LDAPAuthProvider{config: cfg}
+	p.pool = &ldapPool{max: cfg.LDAPPoolSize, dial: p.dial}
+	return p
+}
+
+// Authenticate verifies credentials by binding to the directory as the user
+func (p *LDAPAuthProvider) Authenticate(
+	ctx context.Context,
+	username, password string,
+) (*Result, error) {
+	// A bind with an empty password is an unauthenticated bind, which most
+	// servers accept for any DN.
+	if strings.TrimSpace(username) == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, err := p.pool.get()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPConnection, err)
+	}
+	result, err := p.authenticate(conn, username, password)
+	// Keep the connection only when the server answered normally; after a
+	// timeout or protocol error its state is unknown.
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		p.pool.put(conn)
+	} else {
+		conn.Close()
+	}
+	return result, err
+}
+
+func (p *LDAPAuthProvider) authenticate(
+	conn *ldap.Conn,
+	username, password string,
+) (*Result, error) {
+	if p.config.LDAPUserDNTemplate != "" {
+		dn := strings.ReplaceAll(p.config.LDAPUserDNTemplate, "%s", ldap.EscapeDN(username))
+		if err := bindUser(conn, dn, password); err != nil {
+			return nil, err
+		}
+		entry, err := p.findUser(conn, dn, ldap.ScopeBaseObject, "(objectClass=*)")
+		if err != nil {
+			return nil, err
+		}
+		groups, err := p.groups(conn, entry)
+		if err != nil {
+			return nil, err
+		}
+		return p.result(entry, username, groups)
+	}
+
+	if err := p.bindService(conn); err != nil {
+		return nil, err
+	}
+	filter := strings.ReplaceAll(p.config.LDAPUserFilter, "%s", ldap.EscapeFilter(username))
+	entry, err := p.findUser(conn, p.config.LDAPUserBaseDN, ldap.ScopeWholeSubtree, filter)
+	if err != nil {
+		return nil, err
+	}
+	// Look up groups with the service account before the connection is
+	// bound as the user, who may not be allowed to read them.
+	groups, err := p.groups(conn, entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := bindUser(conn, entry.DN, password); err != nil {
+		return nil, err
+	}
+	return p.result(entry, username, groups)
+}
+
+// bindService binds as the search account, or anonymously when none is
+// configured.
+func (p *LDAPAuthProvider) bindService(conn *ldap.Conn) error {
+	var err error
+	if p.config.LDAPBindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(p.config.LDAPBindDN, p.config.LDAPBindPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: service account bind: %v", ErrLDAPConnection, err)
+	}
+	return nil
+}
+
+func bindUser(conn *ldap.Conn, dn, password string) error {
+	err := conn.Bind(dn, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return fmt.Errorf("LDAP bind failed: %w", err)
+	}
+	return nil
+}
+
+// findUser returns the single entry matching filter under baseDN.
+func (p *LDAPAuthProvider) findUser(
+	conn *ldap.Conn,
+	baseDN string,
+	scope int,
+	filter string,
+) (*ldap.Entry, error) {
+	attributes := []string{
+		p.config.LDAPUsernameAttribute,
+		p.config.LDAPEmailAttribute,
+		p.config.LDAPFullNameAttribute,
+	}
+	if p.config.LDAPExternalIDAttribute != "" {
+		attributes = append(attributes, p.config.LDAPExternalIDAttribute)
+	}
+	if p.config.LDAPMemberOfAttribute != "" {
+		attributes = append(attributes, p.config.LDAPMemberOfAttribute)
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		baseDN, scope, ldap.NeverDerefAliases,
+		2, 0, false, filter, attributes, nil,
+	))
+	switch {
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+		return nil, fmt.Errorf("%w: %w in directory", ErrInvalidCredentials, ErrUserNotFound)
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded):
+		return nil, ErrLDAPAmbiguousUser
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrLDAPSearch, err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, fmt.Errorf("%w: %w in directory", ErrInvalidCredentials, ErrUserNotFound)
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, ErrLDAPAmbiguousUser
+	}
+}
+
+// groups returns the DNs of the groups entry belongs to, read from its
+// memberOf-style attribute and, when a group filter is set, by searching.
+func (p *LDAPAuthProvider) groups(conn *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	var groups []string
+	if p.config.LDAPMemberOfAttribute != "" {
+		groups = append(groups, entry.GetAttributeValues(p.config.LDAPMemberOfAttribute)...)
+	}
+	if p.config.LDAPGroupFilter == "" {
+		return groups, nil
+	}
+
+	baseDN := p.config.LDAPGroupBaseDN
+	if baseDN == "" {
+		baseDN = p.config.LDAPUserBaseDN
+	}
+	filter := strings.ReplaceAll(p.config.LDAPGroupFilter, "%s", ldap.EscapeFilter(entry.DN))
+	res, err := conn.Search(ldap.NewSearchRequest(
+		baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, filter, []string{"1.1"}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("%w: group search: %v", ErrLDAPSearch, err)
+	}
+	for _, group := range res.Entries {
+		groups = append(groups, group.DN)
+	}
+	return groups, nil
+}
+
+// result maps a directory entry onto an AuthResult.
+func (p *LDAPAuthProvider) result(
+	entry *ldap.Entry,
+	username string,
+	groups []string,
+) (*Result, error) {
+	// Prefer the directory's spelling of the username so "Alice" and
+	// "alice" sign in as the same user.
+	if name := entry.GetAttributeValue(p.config.LDAPUsernameAttribute); name != "" {
+		username = name
+	}
+
+	externalID := entry.DN
+	if attr := p.config.LDAPExternalIDAttribute; attr != "" {
+		raw := entry.GetRawAttributeValue(attr)
+		if len(raw) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrLDAPInvalidEntry, attr)
+		}
+		externalID = string(raw)
+		if strings.EqualFold(attr, "objectGUID") && len(raw) == 16 {
+			externalID = formatObjectGUID(raw)
+		}
+	}
+
+	result := &Result{
+		Username:   username,
+		ExternalID: externalID,
+		Email:      entry.GetAttributeValue(p.config.LDAPEmailAttribute),
+		FullName:   entry.GetAttributeValue(p.config.LDAPFullNameAttribute),
+	}
+	if len(p.config.LDAPAdminGroups) > 0 {
+		result.Role = models.UserRoleUser
+		if memberOfAny(groups, p.config.LDAPAdminGroups) {
+			result.Role = models.UserRoleAdmin
+		}
+	}
+	return result, nil
+}
+
+// memberOfAny reports whether any of groups is one of wanted. DNs compare
+// case-insensitively and ignoring insignificant spacing.
+func memberOfAny(groups, wanted []string) bool {
+	for _, w := range wanted {
+		wantedDN, wantedErr := ldap.ParseDN(w)
+		for _, g := range groups {
+			if strings.EqualFold(g, w) {
+				return true
+			}
+			if wantedErr != nil {
+				continue
+			}
+			if dn, err := ldap.ParseDN(g); err == nil && dn.EqualFold(wantedDN) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatObjectGUID renders an Active Directory objectGUID in its usual
+// string form. The first three fields are stored little-endian.
+func formatObjectGUID(b []byte) string {
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%x-%x",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8:10], b[10:16])
+}
+
+// dial opens a new connection, upgrading it with StartTLS when configured.
+func (p *LDAPAuthProvider) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: p.config.LDAPInsecureSkipVerify, //nolint:gosec // opt-in for testing
+		MinVersion:         tls.VersionTLS12,
+	}
+	conn, err := ldap.DialURL(p.config.LDAPURL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.config.LDAPTimeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(p.config.LDAPTimeout)
+	if p.config.LDAPStartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Close closes the idle pooled connections.
+func (p *LDAPAuthProvider) Close() {
+	p.pool.close()
+}
+
+// Name returns provider name for logging
+func (p *LDAPAuthProvider) Name() string {
+	return "ldap"
+}
+
+// ldapPool keeps up to max idle connections so each login does not pay for
+// a new TCP and TLS handshake. Connections are rebound on every use.
+type ldapPool struct {
+	mu   sync.Mutex
+	idle []*ldap.Conn
+	max  int
+	dial func() (*ldap.Conn, error)
+}
+
+func (p *ldapPool) get() (*ldap.Conn, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !conn.IsClosing() {
+			p.mu.Unlock()
+			return conn, nil
+		}
+	}
+	p.mu.Unlock()
+	return p.dial()
+}
+
+func (p *ldapPool) put(conn *ldap.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if conn.IsClosing() || len(p.idle) >= p.max {
+		conn.Close()
+		return
+	}
+	p.idle = append(p.idle, conn)
+}
+
+func (p *ldapPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.idle {
+		conn.Close()
+	}
+	p.idle = nil
+}
@@ -0,0 +1,478 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLDAPBindDN       = "cn=search,dc=example,dc=com"
+	testLDAPBindPassword = "search-secret"
+	testLDAPAliceDN      = "uid=alice,ou=people,dc=example,dc=com"
+	testLDAPAdminsDN     = "cn=admins,ou=groups,dc=example,dc=com"
+)
+
+type testLDAPEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// testLDAPServer is a minimal in-process LDAP server. It understands simple
+// binds, searches with and/or/not/equality/presence filters, StartTLS and
+// unbind — enough to exercise LDAPAuthProvider.
+type testLDAPServer struct {
+	listener  net.Listener
+	entries   []testLDAPEntry
+	tlsConfig *tls.Config
+	accepted  atomic.Int32
+}
+
+func newTestLDAPServer(t *testing.T, ldaps bool) *testLDAPServer {
+	t.Helper()
+	s := &testLDAPServer{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{testCertificate(t)}},
+		entries: []testLDAPEntry{
+			{dn: testLDAPBindDN, password: testLDAPBindPassword},
+			{
+				dn:       testLDAPAliceDN,
+				password: "alice-secret",
+				attrs: map[string][]string{
+					"objectClass": {"person"},
+					"uid":         {"alice"},
+					"mail":        {"alice@example.com"},
+					"cn":          {"Alice Liddell"},
+					"entryUUID":   {"6b7b5c1e-0001-4d2a-9d53-1f0c1a2b3c4d"},
+					"memberOf":    {"CN=Admins, OU=Groups, DC=example, DC=com"},
+				},
+			},
+			{
+				dn:       "uid=bob,ou=people,dc=example,dc=com",
+				password: "bob-secret",
+				attrs: map[string][]string{
+					"objectClass": {"person"},
+					"uid":         {"bob"},
+					"mail":        {"bob@example.com"},
+					"cn":          {"Bob"},
+				},
+			},
+			{
+				dn: testLDAPAdminsDN,
+				attrs: map[string][]string{
+					"objectClass": {"groupOfNames"},
+					"cn":          {"admins"},
+					"member":      {testLDAPAliceDN},
+				},
+			},
+			{
+				dn: "cn=staff,ou=groups,dc=example,dc=com",
+				attrs: map[string][]string{
+					"objectClass": {"groupOfNames"},
+					"cn":          {"staff"},
+					"member":      {testLDAPAliceDN, "uid=bob,ou=people,dc=example,dc=com"},
+				},
+			},
+		},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if ldaps {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.accepted.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testLDAPServer) url(scheme string) string {
+	return scheme + "://" + s.listener.Addr().String()
+}
+
+func (s *testLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		msgID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			s.reply(conn, msgID, ldap.ApplicationBindResponse, s.bind(dn, password))
+		case ldap.ApplicationSearchRequest:
+			s.search(conn, msgID, op)
+		case ldap.ApplicationExtendedRequest: // StartTLS
+			s.reply(conn, msgID, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess)
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			defer tlsConn.Close()
+			conn = tlsConn
+		default: // unbind
+			return
+		}
+	}
+}
+
+func (s *testLDAPServer) bind(dn, password string) int64 {
+	if dn == "" && password == "" {
+		return ldap.LDAPResultSuccess
+	}
+	for _, e := range s.entries {
+		if strings.EqualFold(e.dn, dn) && e.password != "" && e.password == password {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+func (s *testLDAPServer) search(conn net.Conn, msgID int64, op *ber.Packet) {
+	base := op.Children[0].Value.(string)
+	scope := op.Children[1].Value.(int64)
+	sizeLimit := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var wanted []string
+	for _, attr := range op.Children[7].Children {
+		wanted = append(wanted, attr.Value.(string))
+	}
+
+	var matches []testLDAPEntry
+	baseFound := false
+	for _, e := range s.entries {
+		dn := strings.ToLower(e.dn)
+		isBase := dn == strings.ToLower(base)
+		isChild := strings.HasSuffix(dn, ","+strings.ToLower(base))
+		// Containers such as ou=people exist implicitly above their entries.
+		baseFound = baseFound || isBase || isChild
+		inScope := isBase || (isChild && scope != ldap.ScopeBaseObject)
+		if inScope && matchFilter(e, filter) {
+			matches = append(matches, e)
+		}
+	}
+	if !baseFound {
+		s.reply(conn, msgID, ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject)
+		return
+	}
+
+	code := int64(ldap.LDAPResultSuccess)
+	if sizeLimit > 0 && int64(len(matches)) > sizeLimit {
+		matches = matches[:sizeLimit]
+		code = ldap.LDAPResultSizeLimitExceeded
+	}
+	for _, e := range matches {
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed,
+			ldap.ApplicationSearchResultEntry, nil, "entry")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+			ber.TagOctetString, e.dn, "dn"))
+		attrs := ber.NewSequence("attributes")
+		for name, values := range e.attrs {
+			if !attributeWanted(name, wanted) {
+				continue
+			}
+			attr := ber.NewSequence("attribute")
+			attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+				ber.TagOctetString, name, "type"))
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+			for _, v := range values {
+				set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+					ber.TagOctetString, v, "value"))
+			}
+			attr.AppendChild(set)
+			attrs.AppendChild(attr)
+		}
+		entry.AppendChild(attrs)
+		s.write(conn, msgID, entry)
+	}
+	s.reply(conn, msgID, ldap.ApplicationSearchResultDone, code)
+}
+
+func attributeWanted(name string, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, w := range wanted {
+		if strings.EqualFold(w, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchFilter(e testLDAPEntry, f *ber.Packet) bool {
+	switch f.Tag {
+	case ldap.FilterAnd:
+		for _, child := range f.Children {
+			if !matchFilter(e, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range f.Children {
+			if matchFilter(e, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchFilter(e, f.Children[0])
+	case ldap.FilterPresent:
+		attr := f.Data.String()
+		if strings.EqualFold(attr, "objectClass") {
+			return true
+		}
+		return len(entryValues(e, attr)) > 0
+	case ldap.FilterEqualityMatch:
+		want := f.Children[1].Data.String()
+		for _, v := range entryValues(e, f.Children[0].Data.String()) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func entryValues(e testLDAPEntry, attr string) []string {
+	for name, values := range e.attrs {
+		if strings.EqualFold(name, attr) {
+			return values
+		}
+	}
+	return nil
+}
+
+func (s *testLDAPServer) reply(conn net.Conn, msgID int64, tag ber.Tag, code int64) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "response")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagEnumerated, code, "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagOctetString, "", "diagnosticMessage"))
+	s.write(conn, msgID, op)
+}
+
+func (s *testLDAPServer) write(conn net.Conn, msgID int64, op *ber.Packet) {
+	envelope := ber.NewSequence("LDAPMessage")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagInteger, msgID, "messageID"))
+	envelope.AppendChild(op)
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+// testCertificate creates a self-signed certificate for 127.0.0.1.
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newTestLDAPConfig(url string) *config.Config {
+	return &config.Config{
+		LDAPURL:               url,
+		LDAPTimeout:           5 * time.Second,
+		LDAPPoolSize:          2,
+		LDAPBindDN:            testLDAPBindDN,
+		LDAPBindPassword:      testLDAPBindPassword,
+		LDAPUserBaseDN:        "ou=people,dc=example,dc=com",
+		LDAPUserFilter:        "(&(objectClass=person)(uid=%s))",
+		LDAPUsernameAttribute: "uid",
+		LDAPEmailAttribute:    "mail",
+		LDAPFullNameAttribute: "cn",
+		LDAPMemberOfAttribute: "memberOf",
+	}
+}
+
+func newTestLDAPProvider(t *testing.T, cfg *config.Config) *LDAPAuthProvider {
+	t.Helper()
+	p := NewLDAPAuthProvider(cfg)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestLDAPAuthProvider_SearchThenBind(t *testing.T) {
+	server := newTestLDAPServer(t, false)
+	cfg := newTestLDAPConfig(server.url("ldap"))
+	cfg.LDAPAdminGroups = []string{testLDAPAdminsDN}
+	p := newTestLDAPProvider(t, cfg)
+
+	result, err := p.Authenticate(context.Background(), "Alice", "alice-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result.Username, "directory spelling wins")
+	assert.Equal(t, testLDAPAliceDN, result.ExternalID)
+	assert.Equal(t, "alice@example.com", result.Email)
+	assert.Equal(t, "Alice Liddell", result.FullName)
+	assert.Equal(t, models.UserRoleAdmin, result.Role, "memberOf DN matched loosely")
+
+	result, err = p.Authenticate(context.Background(), "bob", "bob-secret")
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleUser, result.Role)
+}
+
+func TestLDAPAuthProvider_Rejects(t *testing.T) {
+	server := newTestLDAPServer(t, false)
+	p := newTestLDAPProvider(t, newTestLDAPConfig(server.url("ldap")))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     error
+	}{
+		{"wrong password", "alice", "wrong", ErrInvalidCredentials},
+		{"unknown user", "mallory", "secret", ErrInvalidCredentials},
+		{"empty password", "alice", "", ErrInvalidCredentials},
+		{"filter injection", "*", "alice-secret", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Authenticate(ctx, tt.username, tt.password)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	t.Run("unknown user is told apart from a wrong password", func(t *testing.T) {
+		_, err := p.Authenticate(ctx, "mallory", "secret")
+		require.ErrorIs(t, err, ErrUserNotFound)
+		_, err = p.Authenticate(ctx, "alice", "wrong")
+		assert.NotErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("ambiguous filter", func(t *testing.T) {
+		cfg := newTestLDAPConfig(server.url("ldap"))
+		cfg.LDAPUserFilter = "(|(uid=%s)(objectClass=*))"
+		_, err := newTestLDAPProvider(t, cfg).Authenticate(ctx, "alice", "alice-secret")
+		assert.ErrorIs(t, err, ErrLDAPAmbiguousUser)
+	})
+
+	t.Run("wrong service password", func(t *testing.T) {
+		cfg := newTestLDAPConfig(server.url("ldap"))
+		cfg.LDAPBindPassword = "wrong"
+		_, err := newTestLDAPProvider(t, cfg).Authenticate(ctx, "alice", "alice-secret")
+		assert.ErrorIs(t, err, ErrLDAPConnection)
+	})
+}
+
+func TestLDAPAuthProvider_DirectBind(t *testing.T) {
+	server := newTestLDAPServer(t, false)
+	cfg := newTestLDAPConfig(server.url("ldap"))
+	cfg.LDAPBindDN = ""
+	cfg.LDAPBindPassword = ""
+	cfg.LDAPUserBaseDN = ""
+	cfg.LDAPUserDNTemplate = "uid=%s,ou=people,dc=example,dc=com"
+	cfg.LDAPExternalIDAttribute = "entryUUID"
+	cfg.LDAPMemberOfAttribute = ""
+	cfg.LDAPGroupBaseDN = "ou=groups,dc=example,dc=com"
+	cfg.LDAPGroupFilter = "(member=%s)"
+	cfg.LDAPAdminGroups = []string{testLDAPAdminsDN}
+	p := newTestLDAPProvider(t, cfg)
+
+	result, err := p.Authenticate(context.Background(), "alice", "alice-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "6b7b5c1e-0001-4d2a-9d53-1f0c1a2b3c4d", result.ExternalID)
+	assert.Equal(t, models.UserRoleAdmin, result.Role, "group found by search")
+
+	_, err = p.Authenticate(context.Background(), "alice", "wrong")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// Bob has no entryUUID, so there is no stable ID to link him by.
+	_, err = p.Authenticate(context.Background(), "bob", "bob-secret")
+	assert.ErrorIs(t, err, ErrLDAPInvalidEntry)
+}
+
+func TestLDAPAuthProvider_TLS(t *testing.T) {
+	t.Run("ldaps", func(t *testing.T) {
+		server := newTestLDAPServer(t, true)
+		cfg := newTestLDAPConfig(server.url("ldaps"))
+		cfg.LDAPInsecureSkipVerify = true
+		_, err := newTestLDAPProvider(t, cfg).
+			Authenticate(context.Background(), "alice", "alice-secret")
+		require.NoError(t, err)
+	})
+
+	t.Run("StartTLS", func(t *testing.T) {
+		server := newTestLDAPServer(t, false)
+		cfg := newTestLDAPConfig(server.url("ldap"))
+		cfg.LDAPStartTLS = true
+		cfg.LDAPInsecureSkipVerify = true
+		_, err := newTestLDAPProvider(t, cfg).
+			Authenticate(context.Background(), "alice", "alice-secret")
+		require.NoError(t, err)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := newTestLDAPServer(t, true)
+		_, err := newTestLDAPProvider(t, newTestLDAPConfig(server.url("ldaps"))).
+			Authenticate(context.Background(), "alice", "alice-secret")
+		assert.ErrorIs(t, err, ErrLDAPConnection)
+	})
+}
+
+func TestLDAPAuthProvider_ReusesConnections(t *testing.T) {
+	server := newTestLDAPServer(t, false)
+	p := newTestLDAPProvider(t, newTestLDAPConfig(server.url("ldap")))
+
+	for range 3 {
+		_, err := p.Authenticate(context.Background(), "alice", "alice-secret")
+		require.NoError(t, err)
+	}
+	_, err := p.Authenticate(context.Background(), "alice", "wrong")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = p.Authenticate(context.Background(), "bob", "bob-secret")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.accepted.Load())
+}
+
+func TestFormatObjectGUID(t *testing.T) {
+	raw := []byte{
+		0xff, 0x19, 0x96, 0x6f, 0x86, 0x8b, 0x11, 0xd0,
+		0xb4, 0x2d, 0x00, 0xc0, 0x4f, 0xc9, 0x64, 0xff,
+	}
+	assert.Equal(t, "6f9619ff-8b86-d011-b42d-00c04fc964ff", formatObjectGUID(raw))
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-authgate/authgate/internal/core"
 
@@ -27,7 +28,7 @@ func (p *LocalAuthProvider) Authenticate(
 ) (*Result, error) {
 	user, err := p.store.GetUserByUsername(username)
 	if err != nil {
-		return nil, ErrInvalidCredentials
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, ErrUserNotFound)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(
@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOIDCIssuer is a minimal OpenID Connect issuer. The token endpoint
+// checks the PKCE verifier against the last challenge seen by /authorize and
+// returns an ID token carrying idClaims plus the standard claims.
+type testOIDCIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey // published in the JWKS
+	signKey   *rsa.PrivateKey // used to sign ID tokens
+	challenge string
+	nonce     string
+	audience  string
+	idClaims  jwt.MapClaims
+	userinfo  map[string]any
+}
+
+func newTestOIDCIssuer(t *testing.T) *testOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	iss := &testOIDCIssuer{
+		key:      key,
+		signKey:  key,
+		audience: "authgate",
+		idClaims: jwt.MapClaims{
+			"sub":                "user-123",
+			"email":              "jane@example.com",
+			"email_verified":     true,
+			"preferred_username": "jane",
+		},
+		userinfo: map[string]any{
+			"sub":     "user-123",
+			"name":    "Jane Doe",
+			"picture": "https://example.com/jane.png",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(
+		w http.ResponseWriter, _ *http.Request,
+	) {
+		writeJSON(w, map[string]any{
+			"issuer":                                iss.server.URL,
+			"authorization_endpoint":                iss.server.URL + "/authorize",
+			"token_endpoint":                        iss.server.URL + "/token",
+			"userinfo_endpoint":                     iss.server.URL + "/userinfo",
+			"jwks_uri":                              iss.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		pub := iss.key.PublicKey
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != iss.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   iss.server.URL,
+			"aud":   iss.audience,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": iss.nonce,
+		}
+		for k, v := range iss.idClaims {
+			claims[k] = v
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		idToken.Header["kid"] = "test"
+		signed, err := idToken.SignedString(iss.signKey)
+		require.NoError(t, err)
+		writeJSON(w, map[string]any{
+			"access_token": "access-123",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     signed,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, iss.userinfo)
+	})
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (iss *testOIDCIssuer) provider(t *testing.T) *OAuthProvider {
+	t.Helper()
+	p, err := NewOIDCProvider(context.Background(), OIDCProviderConfig{
+		OAuthProviderConfig: OAuthProviderConfig{
+			ClientID:     "authgate",
+			ClientSecret: "secret",
+			RedirectURL:  "https://auth.example.com/auth/callback/keycloak",
+			Scopes:       []string{"openid", "profile", "email"},
+		},
+		Name:        "keycloak",
+		DisplayName: "Company SSO",
+		IssuerURL:   iss.server.URL,
+		Claims: OIDCClaimMapping{
+			Username:  "preferred_username",
+			Email:     "email",
+			FullName:  "name",
+			AvatarURL: "picture",
+		},
+		TrustEmailVerified: true,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+// authorize starts a login the way the browser would and records the PKCE
+// challenge and nonce the issuer receives.
+func (iss *testOIDCIssuer) authorize(t *testing.T, p *OAuthProvider, verifier, nonce string) {
+	t.Helper()
+	u, err := url.Parse(p.GetAuthURL("state-1", verifier, nonce))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(u.String(), iss.server.URL+"/authorize?"))
+	q := u.Query()
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	iss.challenge = q.Get("code_challenge")
+	iss.nonce = q.Get("nonce")
+}
+
+func TestOIDCProvider_Login(t *testing.T) {
+	iss := newTestOIDCIssuer(t)
+	p := iss.provider(t)
+	assert.Equal(t, "keycloak", p.GetProvider())
+	assert.Equal(t, "Company SSO", p.GetDisplayName())
+
+	iss.authorize(t, p, "verifier-0123456789-0123456789-0123456789", "nonce-1")
+	assert.Equal(t, "nonce-1", iss.nonce)
+
+	ctx := context.Background()
+	token, err := p.ExchangeCode(
+		ctx, "good-code", "verifier-0123456789-0123456789-0123456789", "nonce-1",
+	)
+	require.NoError(t, err)
+
+	info, err := p.GetUserInfo(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, &OAuthUserInfo{
+		ProviderUserID: "user-123",
+		Username:       "jane",
+		Email:          "jane@example.com",
+		FullName:       "Jane Doe",
+		AvatarURL:      "https://example.com/jane.png",
+		EmailVerified:  true,
+	}, info)
+}
+
+func TestOIDCProvider_ExchangeRejects(t *testing.T) {
+	const verifier = "verifier-0123456789-0123456789-0123456789"
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		modify   func(*testOIDCIssuer)
+		verifier string
+		nonce    string
+		wantErr  string
+	}{
+		{
+			name:     "wrong verifier",
+			verifier: "another-verifier",
+			nonce:    "n",
+			wantErr:  "invalid_grant",
+		},
+		{name: "wrong nonce", verifier: verifier, nonce: "other", wantErr: "nonce"},
+		{name: "empty nonce", verifier: verifier, wantErr: "nonce"},
+		{
+			name:     "wrong signing key",
+			modify:   func(iss *testOIDCIssuer) { iss.signKey = otherKey },
+			verifier: verifier,
+			nonce:    "n",
+			wantErr:  "invalid ID token",
+		},
+		{
+			name:     "wrong audience",
+			modify:   func(iss *testOIDCIssuer) { iss.audience = "someone-else" },
+			verifier: verifier,
+			nonce:    "n",
+			wantErr:  "invalid ID token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iss := newTestOIDCIssuer(t)
+			p := iss.provider(t)
+			if tt.modify != nil {
+				tt.modify(iss)
+			}
+			iss.authorize(t, p, verifier, "n")
+
+			_, err := p.ExchangeCode(context.Background(), "good-code", tt.verifier, tt.nonce)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestOIDCProvider_UserInfoClaims(t *testing.T) {
+	const verifier = "verifier-0123456789-0123456789-0123456789"
+	login := func(t *testing.T, iss *testOIDCIssuer, p *OAuthProvider) (*OAuthUserInfo, error) {
+		t.Helper()
+		iss.authorize(t, p, verifier, "n")
+		token, err := p.ExchangeCode(context.Background(), "good-code", verifier, "n")
+		require.NoError(t, err)
+		return p.GetUserInfo(context.Background(), token)
+	}
+
+	t.Run("userinfo for another subject", func(t *testing.T) {
+		iss := newTestOIDCIssuer(t)
+		iss.userinfo["sub"] = "someone-else"
+		_, err := login(t, iss, iss.provider(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "subject")
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		iss := newTestOIDCIssuer(t)
+		delete(iss.idClaims, "email")
+		_, err := login(t, iss, iss.provider(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no email")
+	})
+
+	t.Run("string email_verified", func(t *testing.T) {
+		iss := newTestOIDCIssuer(t)
+		iss.idClaims["email_verified"] = "true"
+		info, err := login(t, iss, iss.provider(t))
+		require.NoError(t, err)
+		assert.True(t, info.EmailVerified)
+	})
+
+	t.Run("email_verified from an untrusted issuer", func(t *testing.T) {
+		iss := newTestOIDCIssuer(t)
+		p := iss.provider(t)
+		p.oidc.trustEmailVerified = false
+		info, err := login(t, iss, p)
+		require.NoError(t, err)
+		assert.False(t, info.EmailVerified)
+	})
+
+	t.Run("custom email claim is not trusted as verified", func(t *testing.T) {
+		iss := newTestOIDCIssuer(t)
+		iss.userinfo["upn"] = "jane@corp.example.com"
+		p := iss.provider(t)
+		p.oidc.claims.Email = "upn"
+		info, err := login(t, iss, p)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@corp.example.com", info.Email)
+		assert.False(t, info.EmailVerified)
+	})
+}
+
+func TestNewOIDCProvider_DiscoveryFailure(t *testing.T) {
+	iss := newTestOIDCIssuer(t)
+
+	// The discovery document names a different issuer.
+	_, err := NewOIDCProvider(context.Background(), OIDCProviderConfig{
+		Name:      "keycloak",
+		IssuerURL: iss.server.URL + "/",
+	})
+	require.Error(t, err)
+
+	_, err = NewOIDCProvider(context.Background(), OIDCProviderConfig{
+		Name:      "keycloak",
+		IssuerURL: iss.server.URL + "/missing",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// SAMLAttributeMapping names the assertion attributes copied into
+// OAuthUserInfo. An attribute matches on either its Name or FriendlyName.
+type SAMLAttributeMapping struct {
+	UserID   string // Empty uses the assertion's NameID
+	Username string
+	Email    string
+	FullName string
+}
+
+// SAMLProviderConfig contains configuration for an upstream SAML 2.0
+// identity provider
+type SAMLProviderConfig struct {
+	Name        string // Provider name used in login URLs and OAuth connections
+	DisplayName string // Login button label; empty capitalizes Name
+	EntityID    string // Service provider entity ID, served as its metadata URL
+	ACSURL      string // Assertion consumer service: /auth/callback/<name>
+	IDPMetadata []byte // The identity provider's metadata document
+	Certificate *x509.Certificate
+	Key         *rsa.PrivateKey
+	Attributes  SAMLAttributeMapping
+	TrustEmail  bool // The IdP vouches for the asserted email address
+}
+
+// SAMLAuthnRequest is a SAML authentication request ready to send to the
+// identity provider. Exactly one of RedirectURL and PostURL is set.
+type SAMLAuthnRequest struct {
+	ID          string // Must be passed back to ParseSAMLResponse
+	RedirectURL string // HTTP-Redirect binding: send the browser here
+	PostURL     string // HTTP-POST binding: post SAMLRequest and RelayState here
+	SAMLRequest string
+}
+
+// samlUpstream holds the service provider side of an upstream SAML
+// identity provider.
+type samlUpstream struct {
+	sp         *saml.ServiceProvider
+	attributes SAMLAttributeMapping
+	trustEmail bool
+}
+
+// NewSAMLProvider creates a SAML 2.0 login provider. The IdP metadata must
+// list a signing certificate and an HTTP-Redirect or HTTP-POST single
+// sign-on endpoint.
+func NewSAMLProvider(cfg SAMLProviderConfig) (*OAuthProvider, error) {
+	idp, err := parseSAMLIDPMetadata(cfg.IDPMetadata)
+	if err != nil {
+		return nil, err
+	}
+	entityURL, err := url.Parse(cfg.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML entity ID %q: %w", cfg.EntityID, err)
+	}
+	acsURL, err := url.Parse(cfg.ACSURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML ACS URL %q: %w", cfg.ACSURL, err)
+	}
+	sp := &saml.ServiceProvider{
+		EntityID:    cfg.EntityID,
+		Key:         cfg.Key,
+		Certificate: cfg.Certificate,
+		MetadataURL: *entityURL,
+		AcsURL:      *acsURL,
+		IDPMetadata: idp,
+		// Let the IdP pick its NameID format; the library default asks for
+		// a transient one, which changes on every login.
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+		SignatureMethod:   dsig.RSASHA256SignatureMethod,
+	}
+	if sp.GetSSOBindingLocation(saml.HTTPRedirectBinding) == "" &&
+		sp.GetSSOBindingLocation(saml.HTTPPostBinding) == "" {
+		return nil, fmt.Errorf(
+			"SAML IdP %s has no HTTP-Redirect or HTTP-POST sign-on endpoint", idp.EntityID,
+		)
+	}
+	return &OAuthProvider{
+		provider:    cfg.Name,
+		displayName: cfg.DisplayName,
+		saml: &samlUpstream{
+			sp:         sp,
+			attributes: cfg.Attributes,
+			trustEmail: cfg.TrustEmail,
+		},
+	}, nil
+}
+
+// parseSAMLIDPMetadata decodes IdP metadata. Federation feeds wrap entities
+// in an EntitiesDescriptor; the first one with an IdP role is used.
+func parseSAMLIDPMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	var entity saml.EntityDescriptor
+	if err := xml.Unmarshal(data, &entity); err == nil && len(entity.IDPSSODescriptors) > 0 {
+		return &entity, nil
+	}
+	var entities saml.EntitiesDescriptor
+	if err := xml.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("failed to parse SAML IdP metadata: %w", err)
+	}
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, errors.New("SAML metadata contains no identity provider")
+}
+
+// IsSAML reports whether the provider is a SAML identity provider rather
+// than an OAuth 2.0 one.
+func (p *OAuthProvider) IsSAML() bool {
+	return p.saml != nil
+}
+
+// SAMLMetadata returns the service provider metadata to register with the
+// identity provider.
+func (p *OAuthProvider) SAMLMetadata() ([]byte, error) {
+	if p.saml == nil {
+		return nil, errors.New("not a SAML provider")
+	}
+	buf, err := xml.MarshalIndent(p.saml.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
+
+// NewSAMLAuthnRequest starts a service-provider-initiated login. The
+// HTTP-Redirect binding is preferred; requests sent with the HTTP-POST
+// binding carry an enveloped signature instead of a signed query string.
+func (p *OAuthProvider) NewSAMLAuthnRequest(relayState string) (*SAMLAuthnRequest, error) {
+	if p.saml == nil {
+		return nil, errors.New("not a SAML provider")
+	}
+	sp := p.saml.sp
+
+	if location := sp.GetSSOBindingLocation(saml.HTTPRedirectBinding); location != "" {
+		req, err := sp.MakeAuthenticationRequest(
+			location, saml.HTTPRedirectBinding, saml.HTTPPostBinding,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// Redirect appends RelayState to the query unescaped.
+		redirectURL, err := req.Redirect(url.QueryEscape(relayState), sp)
+		if err != nil {
+			return nil, err
+		}
+		return &SAMLAuthnRequest{ID: req.ID, RedirectURL: redirectURL.String()}, nil
+	}
+
+	location := sp.GetSSOBindingLocation(saml.HTTPPostBinding)
+	req, err := sp.MakeAuthenticationRequest(location, saml.HTTPPostBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	doc.SetRoot(req.Element())
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return &SAMLAuthnRequest{
+		ID:          req.ID,
+		PostURL:     location,
+		SAMLRequest: base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ParseSAMLResponse validates the SAMLResponse posted to the assertion
+// consumer service: the response or assertion must be signed by a
+// certificate in the IdP metadata, answer requestID, and be addressed to
+// this service provider.
+func (p *OAuthProvider) ParseSAMLResponse(
+	r *http.Request,
+	requestID string,
+) (*OAuthUserInfo, error) {
+	if p.saml == nil {
+		return nil, errors.New("not a SAML provider")
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("failed to parse SAML response form: %w", err)
+	}
+	if r.PostForm.Get("SAMLResponse") == "" {
+		return nil, errors.New("request carries no SAMLResponse")
+	}
+	assertion, err := p.saml.sp.ParseResponse(r, []string{requestID})
+	if err != nil {
+		// The library hides the cause behind a generic message.
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) && invalid.PrivateErr != nil {
+			err = invalid.PrivateErr
+		}
+		return nil, fmt.Errorf("invalid SAML response: %w", err)
+	}
+	return p.saml.userInfo(assertion)
+}
+
+// userInfo maps a validated assertion onto OAuthUserInfo.
+func (u *samlUpstream) userInfo(assertion *saml.Assertion) (*OAuthUserInfo, error) {
+	var nameID *saml.NameID
+	if assertion.Subject != nil {
+		nameID = assertion.Subject.NameID
+	}
+
+	userID := samlAttribute(assertion, u.attributes.UserID)
+	if u.attributes.UserID == "" && nameID != nil {
+		if nameID.Format == string(saml.TransientNameIDFormat) {
+			return nil, errors.New(
+				"SAML NameID is transient; configure a user ID attribute instead",
+			)
+		}
+		userID = nameID.Value
+	}
+	if userID == "" {
+		return nil, errors.New("SAML assertion has no user ID")
+	}
+
+	email := samlAttribute(assertion, u.attributes.Email)
+	if email == "" && nameID != nil && nameID.Format == string(saml.EmailAddressNameIDFormat) {
+		email = nameID.Value
+	}
+	if email == "" {
+		return nil, fmt.Errorf(
+			"SAML assertion has no email address (attribute %q)", u.attributes.Email,
+		)
+	}
+
+	return &OAuthUserInfo{
+		ProviderUserID: userID,
+		Username:       samlAttribute(assertion, u.attributes.Username),
+		Email:          email,
+		FullName:       samlAttribute(assertion, u.attributes.FullName),
+		// SAML has no standard claim for a verified address, so trust is
+		// configured per identity provider.
+		EmailVerified:  u.trustEmail,
+		ConnectionType: models.ConnectionTypeSAML,
+	}, nil
+}
+
+// samlAttribute returns the first value of the attribute whose Name or
+// FriendlyName is name.
+func samlAttribute(assertion *saml.Assertion, name string) string {
+	if name == "" {
+		return ""
+	}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+			for _, v := range attr.Values {
+				if value := strings.TrimSpace(v.Value); value != "" {
+					return value
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/auth/samltest"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/crewjam/saml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSAMLEntityID = "https://auth.example.com/auth/metadata/corp"
+	testSAMLACSURL   = "https://auth.example.com/auth/callback/corp"
+)
+
+// testSAMLIdP is an in-process identity provider that answers the
+// authentication requests of the service provider under test.
+type testSAMLIdP struct {
+	idp *saml.IdentityProvider
+	sp  *saml.EntityDescriptor
+}
+
+func newTestSAMLIdP(t *testing.T) *testSAMLIdP {
+	t.Helper()
+	cert, key := samltest.KeyPair(t)
+	i := &testSAMLIdP{}
+	i.idp = &saml.IdentityProvider{
+		Key:         key,
+		Certificate: cert,
+		MetadataURL: url.URL{
+			Scheme: "https", Host: "idp.example.com", Path: "/metadata",
+		},
+		SSOURL:                  url.URL{Scheme: "https", Host: "idp.example.com", Path: "/sso"},
+		ServiceProviderProvider: i,
+	}
+	return i
+}
+
+// GetServiceProvider implements saml.ServiceProviderProvider.
+func (i *testSAMLIdP) GetServiceProvider(*http.Request, string) (*saml.EntityDescriptor, error) {
+	return i.sp, nil
+}
+
+func (i *testSAMLIdP) metadata(t *testing.T) []byte {
+	t.Helper()
+	buf, err := xml.Marshal(i.idp.Metadata())
+	require.NoError(t, err)
+	return buf
+}
+
+func (i *testSAMLIdP) provider(t *testing.T) *OAuthProvider {
+	t.Helper()
+	cert, key := samltest.KeyPair(t)
+	p, err := NewSAMLProvider(SAMLProviderConfig{
+		Name:        "corp",
+		DisplayName: "Corporate SSO",
+		EntityID:    testSAMLEntityID,
+		ACSURL:      testSAMLACSURL,
+		IDPMetadata: i.metadata(t),
+		Certificate: cert,
+		Key:         key,
+		Attributes: SAMLAttributeMapping{
+			Username: "uid",
+			Email:    "mail",
+			FullName: "cn",
+		},
+	})
+	require.NoError(t, err)
+	i.sp = p.saml.sp.Metadata()
+	return p
+}
+
+// respond answers the authentication request behind redirectURL for session
+// and returns the form the browser posts to the assertion consumer service.
+func (i *testSAMLIdP) respond(t *testing.T, redirectURL string, session *saml.Session) url.Values {
+	t.Helper()
+	req, err := saml.NewIdpAuthnRequest(
+		i.idp, httptest.NewRequest(http.MethodGet, redirectURL, nil),
+	)
+	require.NoError(t, err)
+	require.NoError(t, req.Validate())
+	require.NoError(t, saml.DefaultAssertionMaker{}.MakeAssertion(req, session))
+	form, err := req.PostBinding()
+	require.NoError(t, err)
+	assert.Equal(t, testSAMLACSURL, form.URL)
+	return url.Values{"SAMLResponse": {form.SAMLResponse}, "RelayState": {form.RelayState}}
+}
+
+func testSAMLSession() *saml.Session {
+	return &saml.Session{
+		ID:             "session-1",
+		CreateTime:     time.Now(),
+		ExpireTime:     time.Now().Add(time.Hour),
+		NameID:         "jane-persistent-id",
+		NameIDFormat:   string(saml.PersistentNameIDFormat),
+		UserName:       "jane",
+		UserCommonName: "Jane Doe",
+		CustomAttributes: []saml.Attribute{{
+			Name:   "mail",
+			Values: []saml.AttributeValue{{Type: "xs:string", Value: "jane@example.com"}},
+		}},
+	}
+}
+
+func postSAMLResponse(form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, testSAMLACSURL, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestSAMLProvider_Login(t *testing.T) {
+	i := newTestSAMLIdP(t)
+	p := i.provider(t)
+	assert.True(t, p.IsSAML())
+	assert.Equal(t, "corp", p.GetProvider())
+	assert.Equal(t, "Corporate SSO", p.GetDisplayName())
+
+	metadata, err := p.SAMLMetadata()
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `entityID="`+testSAMLEntityID+`"`)
+	assert.Contains(t, string(metadata), `Location="`+testSAMLACSURL+`"`)
+
+	authn, err := p.NewSAMLAuthnRequest("state+1")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(authn.RedirectURL, "https://idp.example.com/sso?"))
+	u, err := url.Parse(authn.RedirectURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state+1", u.Query().Get("RelayState"))
+	assert.NotEmpty(t, u.Query().Get("Signature"))
+
+	form := i.respond(t, authn.RedirectURL, testSAMLSession())
+	assert.Equal(t, "state+1", form.Get("RelayState"))
+
+	info, err := p.ParseSAMLResponse(postSAMLResponse(form), authn.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &OAuthUserInfo{
+		ProviderUserID: "jane-persistent-id",
+		Username:       "jane",
+		Email:          "jane@example.com",
+		FullName:       "Jane Doe",
+		ConnectionType: models.ConnectionTypeSAML,
+	}, info)
+}
+
+func TestSAMLProvider_ParseResponseRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		session   func(*saml.Session)
+		signer    bool // answer with an IdP key missing from the metadata
+		requestID string
+		wantErr   string
+	}{
+		{name: "other request", requestID: "id-other", wantErr: "invalid SAML response"},
+		{name: "unknown signing key", signer: true, wantErr: "invalid SAML response"},
+		{
+			name:    "transient NameID",
+			session: func(s *saml.Session) { s.NameIDFormat = string(saml.TransientNameIDFormat) },
+			wantErr: "transient",
+		},
+		{
+			name:    "missing email",
+			session: func(s *saml.Session) { s.CustomAttributes = nil },
+			wantErr: "no email",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestSAMLIdP(t)
+			p := i.provider(t)
+			if tt.signer {
+				cert, key := samltest.KeyPair(t)
+				i.idp.Certificate, i.idp.Key = cert, key
+			}
+			authn, err := p.NewSAMLAuthnRequest("state")
+			require.NoError(t, err)
+			session := testSAMLSession()
+			if tt.session != nil {
+				tt.session(session)
+			}
+			form := i.respond(t, authn.RedirectURL, session)
+
+			requestID := authn.ID
+			if tt.requestID != "" {
+				requestID = tt.requestID
+			}
+			_, err = p.ParseSAMLResponse(postSAMLResponse(form), requestID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSAMLProvider_UserIDAndEmailFallbacks(t *testing.T) {
+	i := newTestSAMLIdP(t)
+	p := i.provider(t)
+	p.saml.attributes.UserID = "uid"
+	p.saml.trustEmail = true
+
+	authn, err := p.NewSAMLAuthnRequest("state")
+	require.NoError(t, err)
+	session := testSAMLSession()
+	// The user ID comes from an attribute, and an email-format NameID stands
+	// in for the missing email attribute.
+	session.NameID = "jane@corp.example.com"
+	session.NameIDFormat = string(saml.EmailAddressNameIDFormat)
+	session.CustomAttributes = nil
+
+	info, err := p.ParseSAMLResponse(
+		postSAMLResponse(i.respond(t, authn.RedirectURL, session)), authn.ID,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "jane", info.ProviderUserID)
+	assert.Equal(t, "jane@corp.example.com", info.Email)
+	assert.True(t, info.EmailVerified)
+}
+
+func TestSAMLProvider_PostBinding(t *testing.T) {
+	i := newTestSAMLIdP(t)
+	meta := i.idp.Metadata()
+	// Keep only the HTTP-POST sign-on endpoint.
+	sso := meta.IDPSSODescriptors[0].SingleSignOnServices
+	meta.IDPSSODescriptors[0].SingleSignOnServices = nil
+	for _, s := range sso {
+		if s.Binding == saml.HTTPPostBinding {
+			meta.IDPSSODescriptors[0].SingleSignOnServices = append(
+				meta.IDPSSODescriptors[0].SingleSignOnServices, s,
+			)
+		}
+	}
+	raw, err := xml.Marshal(meta)
+	require.NoError(t, err)
+	cert, key := samltest.KeyPair(t)
+	p, err := NewSAMLProvider(SAMLProviderConfig{
+		Name:        "corp",
+		EntityID:    testSAMLEntityID,
+		ACSURL:      testSAMLACSURL,
+		IDPMetadata: raw,
+		Certificate: cert,
+		Key:         key,
+	})
+	require.NoError(t, err)
+
+	authn, err := p.NewSAMLAuthnRequest("state")
+	require.NoError(t, err)
+	assert.Empty(t, authn.RedirectURL)
+	assert.Equal(t, "https://idp.example.com/sso", authn.PostURL)
+	assert.NotEmpty(t, authn.SAMLRequest)
+}
+
+func TestParseSAMLIDPMetadata(t *testing.T) {
+	i := newTestSAMLIdP(t)
+	entity := i.idp.Metadata()
+
+	t.Run("entities descriptor", func(t *testing.T) {
+		raw, err := xml.Marshal(saml.EntitiesDescriptor{
+			EntityDescriptors: []saml.EntityDescriptor{
+				{EntityID: "https://sp.example.com"},
+				*entity,
+			},
+		})
+		require.NoError(t, err)
+		got, err := parseSAMLIDPMetadata(raw)
+		require.NoError(t, err)
+		assert.Equal(t, entity.EntityID, got.EntityID)
+	})
+
+	t.Run("no identity provider", func(t *testing.T) {
+		raw, err := xml.Marshal(saml.EntityDescriptor{EntityID: "https://sp.example.com"})
+		require.NoError(t, err)
+		_, err = parseSAMLIDPMetadata(raw)
+		require.Error(t, err)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := parseSAMLIDPMetadata([]byte("not xml"))
+		require.Error(t, err)
+	})
+}
@@ -38,7 +38,11 @@ type Application struct {
 	ClientCacheCloser      func() error
 	TokenCache             core.Cache[models.AccessToken]
 	TokenCacheCloser       func() error
+	RotationGraceCache     core.Cache[services.RotationSuccessor]
+	RotationGraceCloser    func() error
 	RateLimitRedisClient   *redis.Client
+	DeviceNotifier         core.DeviceCodeNotifier
+	Mailer                 core.Mailer
 
 	// Services
 	AuditService core.AuditLogger
@@ -131,12 +135,32 @@ func (app *Application) initializeInfrastructure(ctx context.Context) error {
 		return err
 	}
 
+	// Rotation grace cache (refresh-token reuse grace window)
+	app.RotationGraceCache, app.RotationGraceCloser, err = initializeRotationGraceCache(
+		ctx, app.Config,
+	)
+	if err != nil {
+		return err
+	}
+
 	// Redis (for rate limiting)
 	app.RateLimitRedisClient, err = initializeRateLimitRedisClient(ctx, app.Config)
 	if err != nil {
 		return err
 	}
 
+	// Device wait notifier (wakes /oauth/device/wait on authorization)
+	app.DeviceNotifier, err = initializeDeviceNotifier(ctx, app.Config)
+	if err != nil {
+		return err
+	}
+
+	// Outgoing email (password reset links)
+	app.Mailer, err = initializeMailer(app.Config)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -169,15 +193,22 @@ func (app *Application) initializeBusinessLayer() {
 		app.ClientCache,
 		app.TokenProvider,
 		app.TokenCache,
+		app.RotationGraceCache,
+		app.DeviceNotifier,
+		app.Mailer,
+		initializeUserCodeGuard(app.Config, app.AuditService, app.RateLimitRedisClient),
+		initializeTwoFactorGuard(app.Config, app.AuditService, app.RateLimitRedisClient),
 	)
 }
 
 // initializeHTTPLayer sets up handlers, router, and server
 func (app *Application) initializeHTTPLayer() {
 	// OAuth setup
+	oauthHTTPClient := createOAuthHTTPClient(app.Config)
 	oauthProviders := initializeOAuthProviders(app.Config)
+	initializeOIDCProviders(app.Config, oauthHTTPClient, oauthProviders)
+	initializeSAMLProviders(app.Config, oauthHTTPClient, oauthProviders)
 	logOAuthProvidersStatus(oauthProviders)
-	oauthHTTPClient := createOAuthHTTPClient(app.Config)
 
 	// Handlers
 	app.handlerSet = initializeHandlers(handlerDeps{
@@ -216,14 +247,17 @@ func (app *Application) startWithGracefulShutdown() {
 	addServerShutdownJob(m, app.Server, app.Config)
 	addAuditServiceShutdownJob(m, app.AuditService, app.Config)
 	addRedisClientShutdownJob(m, app.RateLimitRedisClient, app.Config)
+	addDeviceNotifierShutdownJob(m, app.DeviceNotifier, app.Config)
 	addCacheCleanupJob(m, app.MetricsCache, app.Config)
 	addUserCacheCleanupJob(m, app.UserCache, app.Config)
 	addClientCountCacheCleanupJob(m, app.ClientCountCache, app.Config)
 	addClientCacheCleanupJob(m, app.ClientCache, app.Config)
 	addTokenCacheCleanupJob(m, app.TokenCache, app.Config)
+	addRotationGraceCacheCleanupJob(m, app.RotationGraceCache, app.Config)
 	addDatabaseShutdownJob(m, app.DB, app.Config)
 	addAuditLogCleanupJob(m, app.Config, app.AuditService)
 	addExpiredTokenCleanupJob(m, app.DB, app.Config)
+	addConsentExpiryJob(m, app.services.authorization, app.Config)
 	addMetricsGaugeUpdateJob(m, app.Config, app.DB, app.MetricsRecorder, app.MetricsCache)
 
 	// Wait for graceful shutdown
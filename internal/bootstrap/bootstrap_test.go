@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-authgate/authgate/internal/config"
@@ -31,6 +32,101 @@ func TestValidateAuthConfig(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid AUTH_MODE")
 }
 
+func TestAuthChain(t *testing.T) {
+	cfg := &config.Config{
+		AuthMode:   config.AuthModeLocal,
+		HTTPAPIURL: "http://auth.example.com",
+		AuthChain: []config.AuthChainEntryConfig{
+			{
+				Provider:          config.AuthModeHTTPAPI,
+				UsernamePattern:   "^[a-z]+$",
+				Sources:           []string{config.AuthModeHTTPAPI, config.AuthChainSourceNew},
+				OnNotFound:        config.AuthChainContinue,
+				OnInvalidPassword: config.AuthChainStop,
+				MigratePassword:   true,
+			},
+			{
+				Provider:          config.AuthModeLocal,
+				Sources:           []string{config.AuthModeLocal},
+				OnNotFound:        config.AuthChainStop,
+				OnInvalidPassword: config.AuthChainStop,
+			},
+		},
+	}
+	require.NoError(t, validateAuthConfig(cfg))
+
+	chain := initializeAuthChain(cfg, nil)
+	require.Len(t, chain, 2)
+	assert.Equal(t, config.AuthModeHTTPAPI, chain[0].Source)
+	assert.NotNil(t, chain[0].Provider)
+	assert.True(t, chain[0].UsernamePattern.MatchString("alice"))
+	assert.True(t, chain[0].ContinueOnNotFound)
+	assert.False(t, chain[0].ContinueOnInvalidPassword)
+	assert.True(t, chain[0].MigratePassword)
+	assert.Nil(t, chain[1].UsernamePattern)
+	assert.False(t, chain[1].ContinueOnNotFound)
+
+	// Backends in the chain are checked even when AUTH_MODE does not use them.
+	cfg.HTTPAPIURL = ""
+	err := validateAuthConfig(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP_API_URL is required")
+}
+
+func TestValidateAuthConfig_LDAP(t *testing.T) {
+	valid := func() *config.Config {
+		return &config.Config{
+			AuthMode:       config.AuthModeLDAP,
+			LDAPURL:        "ldaps://ldap.example.com",
+			LDAPUserBaseDN: "ou=people,dc=example,dc=com",
+			LDAPUserFilter: "(uid=%s)",
+			LDAPTimeout:    10 * time.Second,
+			LDAPPoolSize:   5,
+		}
+	}
+	require.NoError(t, validateAuthConfig(valid()))
+
+	direct := valid()
+	direct.LDAPUserBaseDN = ""
+	direct.LDAPUserDNTemplate = "uid=%s,ou=people,dc=example,dc=com"
+	require.NoError(t, validateAuthConfig(direct))
+
+	tests := []struct {
+		name   string
+		modify func(*config.Config)
+		want   string
+	}{
+		{"missing URL", func(c *config.Config) { c.LDAPURL = "" }, "LDAP_URL is required"},
+		{
+			"http URL",
+			func(c *config.Config) { c.LDAPURL = "http://ldap.example.com" },
+			"ldap:// or ldaps://",
+		},
+		{"StartTLS over ldaps", func(c *config.Config) { c.LDAPStartTLS = true }, "LDAP_START_TLS"},
+		{"no base DN", func(c *config.Config) { c.LDAPUserBaseDN = "" }, "LDAP_USER_BASE_DN"},
+		{
+			"filter without placeholder",
+			func(c *config.Config) { c.LDAPUserFilter = "(uid=alice)" },
+			"LDAP_USER_FILTER",
+		},
+		{
+			"bind DN without password",
+			func(c *config.Config) { c.LDAPBindDN = "cn=search" },
+			"LDAP_BIND_PASSWORD",
+		},
+		{"zero timeout", func(c *config.Config) { c.LDAPTimeout = 0 }, "LDAP_TIMEOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			err := validateAuthConfig(cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
 func TestInitializeMetrics(t *testing.T) {
 	for _, enabled := range []bool{true, false} {
 		cfg := &config.Config{MetricsEnabled: enabled}
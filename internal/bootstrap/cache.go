@@ -11,6 +11,7 @@ import (
 	"github.com/go-authgate/authgate/internal/core"
 	"github.com/go-authgate/authgate/internal/metrics"
 	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
 )
 
 // initializeMetrics initializes Prometheus metrics
@@ -149,6 +150,27 @@ func initializeTokenCache(
 	})
 }
 
+// initializeRotationGraceCache initializes the cache holding rotation successor
+// pairs for the refresh-token reuse grace window (disabled when the grace
+// period is zero). It shares the token cache backend settings so a Redis
+// deployment answers retries that land on another replica.
+func initializeRotationGraceCache(
+	ctx context.Context,
+	cfg *config.Config,
+) (core.Cache[services.RotationSuccessor], func() error, error) {
+	if !cfg.EnableTokenRotation || cfg.RefreshTokenReuseGracePeriod <= 0 {
+		return nil, nil, nil
+	}
+	return initializeCache[services.RotationSuccessor](ctx, cfg, cacheOpts{
+		cacheType:   cfg.TokenCacheType,
+		cacheName:   "rotation_grace",
+		keyPrefix:   "authgate:rotation-grace:",
+		clientTTL:   cfg.TokenCacheClientTTL,
+		sizePerConn: cfg.TokenCacheSizePerConn,
+		label:       "Rotation grace",
+	})
+}
+
 // initializeClientCache initializes the OAuth client cache (always enabled, defaults to memory)
 func initializeClientCache(
 	ctx context.Context,
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 
 	"github.com/go-authgate/authgate/internal/config"
 )
@@ -18,17 +20,71 @@ func validateAllConfiguration(cfg *config.Config) {
 	}
 }
 
-// validateAuthConfig checks that required config is present for selected auth mode
+// validateAuthConfig checks that required config is present for every
+// backend of the authentication chain (AUTH_MODE when AUTH_CHAIN is unset)
 func validateAuthConfig(cfg *config.Config) error {
 	switch cfg.AuthMode {
-	case config.AuthModeHTTPAPI:
-		if cfg.HTTPAPIURL == "" {
-			return errors.New("HTTP_API_URL is required when AUTH_MODE=http_api")
-		}
-	case config.AuthModeLocal:
-		// No additional validation needed
+	case config.AuthModeLocal, config.AuthModeHTTPAPI, config.AuthModeLDAP:
 	default:
-		return fmt.Errorf("invalid AUTH_MODE: %s (must be: local, http_api)", cfg.AuthMode)
+		return fmt.Errorf("invalid AUTH_MODE: %s (must be: local, http_api, ldap)", cfg.AuthMode)
+	}
+
+	backends := []string{cfg.AuthMode}
+	if len(cfg.AuthChain) > 0 {
+		backends = backends[:0]
+		for _, e := range cfg.AuthChain {
+			backends = append(backends, e.Provider)
+		}
+	}
+	for _, backend := range backends {
+		switch backend {
+		case config.AuthModeHTTPAPI:
+			if cfg.HTTPAPIURL == "" {
+				return errors.New("HTTP_API_URL is required when http_api authentication is used")
+			}
+		case config.AuthModeLDAP:
+			if err := validateLDAPConfig(cfg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateLDAPConfig checks the settings ldap authentication depends on
+func validateLDAPConfig(cfg *config.Config) error {
+	u, err := url.Parse(cfg.LDAPURL)
+	switch {
+	case cfg.LDAPURL == "":
+		return errors.New("LDAP_URL is required when ldap authentication is used")
+	case err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "":
+		return fmt.Errorf("LDAP_URL must be an ldap:// or ldaps:// URL (got %q)", cfg.LDAPURL)
+	case cfg.LDAPStartTLS && u.Scheme == "ldaps":
+		return errors.New("LDAP_START_TLS cannot be combined with an ldaps:// LDAP_URL")
+	case cfg.LDAPUserDNTemplate == "" && cfg.LDAPUserBaseDN == "":
+		return errors.New("LDAP_USER_BASE_DN or LDAP_USER_DN_TEMPLATE is required")
+	case cfg.LDAPUserDNTemplate != "" && !strings.Contains(cfg.LDAPUserDNTemplate, "%s"):
+		return errors.New("LDAP_USER_DN_TEMPLATE must contain %s for the username")
+	case cfg.LDAPUserDNTemplate == "" && !strings.Contains(cfg.LDAPUserFilter, "%s"):
+		return errors.New("LDAP_USER_FILTER must contain %s for the username")
+	case cfg.LDAPBindDN != "" && cfg.LDAPBindPassword == "":
+		return errors.New("LDAP_BIND_PASSWORD is required when LDAP_BIND_DN is set")
+	case cfg.LDAPGroupFilter != "" && cfg.LDAPGroupBaseDN == "" && cfg.LDAPUserBaseDN == "":
+		return errors.New("LDAP_GROUP_BASE_DN is required when LDAP_GROUP_FILTER is set")
+	case cfg.LDAPTimeout <= 0:
+		return fmt.Errorf("LDAP_TIMEOUT must be a positive duration (got %s)", cfg.LDAPTimeout)
+	case cfg.LDAPPoolSize < 0:
+		return fmt.Errorf("LDAP_POOL_SIZE must be non-negative (got %d)", cfg.LDAPPoolSize)
+	}
+	if cfg.LDAPInsecureSkipVerify {
+		log.Printf(
+			"WARNING: LDAP_INSECURE_SKIP_VERIFY is enabled; server certificates are not verified",
+		)
+	}
+	if u.Scheme == "ldap" && !cfg.LDAPStartTLS {
+		log.Printf(
+			"WARNING: LDAP passwords are sent unencrypted; use ldaps:// or LDAP_START_TLS=true",
+		)
 	}
 	return nil
 }
@@ -15,11 +15,16 @@ import (
 // handlerSet holds all HTTP handlers and required services
 type handlerSet struct {
 	auth          *handlers.AuthHandler
+	twoFactor     *handlers.TwoFactorHandler
+	passwordReset *handlers.PasswordResetHandler
+	signup        *handlers.SignupHandler
+	profile       *handlers.ProfileHandler
 	device        *handlers.DeviceHandler
 	token         *handlers.TokenHandler
 	client        *handlers.ClientHandler
 	userClient    *handlers.UserClientHandler
 	session       *handlers.SessionHandler
+	personalToken *handlers.PersonalTokenHandler
 	oauth         *handlers.OAuthHandler
 	audit         *handlers.AuditHandler
 	authorization *handlers.AuthorizationHandler
@@ -53,9 +58,18 @@ func initializeHandlers(deps handlerDeps) handlerSet {
 	return handlerSet{
 		auth: handlers.NewAuthHandler(
 			deps.services.user,
+			deps.services.token,
 			deps.cfg,
 			deps.metrics,
 		),
+		twoFactor: handlers.NewTwoFactorHandler(
+			deps.services.user,
+			deps.services.passkey,
+			deps.cfg,
+		),
+		passwordReset: handlers.NewPasswordResetHandler(deps.services.passwordReset),
+		signup:        handlers.NewSignupHandler(deps.services.signup, deps.services.user),
+		profile:       handlers.NewProfileHandler(deps.services.profile, deps.services.user),
 		device: handlers.NewDeviceHandler(
 			deps.services.device,
 			deps.services.user,
@@ -67,9 +81,10 @@ func initializeHandlers(deps handlerDeps) handlerSet {
 			deps.services.authorization,
 			deps.cfg,
 		),
-		client:     handlers.NewClientHandler(deps.services.client, deps.services.authorization),
-		userClient: handlers.NewUserClientHandler(deps.services.client),
-		session:    handlers.NewSessionHandler(deps.services.token),
+		client:        handlers.NewClientHandler(deps.services.client, deps.services.authorization),
+		userClient:    handlers.NewUserClientHandler(deps.services.client),
+		session:       handlers.NewSessionHandler(deps.services.token),
+		personalToken: handlers.NewPersonalTokenHandler(deps.services.token),
 		oauth: handlers.NewOAuthHandler(
 			deps.oauthProviders,
 			deps.services.user,
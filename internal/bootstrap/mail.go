@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/mail"
+)
+
+// initializeMailer creates the mailer for transactional email. Returns nil
+// when no feature needs to send mail.
+func initializeMailer(cfg *config.Config) (core.Mailer, error) {
+	if !cfg.PasswordResetEnabled && !cfg.SignupEnabled && !cfg.EmailChangeEnabled {
+		return nil, nil //nolint:nilnil // mailer not needed in this configuration
+	}
+
+	switch cfg.MailDriver {
+	case config.MailDriverSMTP:
+		log.Printf("Mail driver: smtp (host=%s:%d, tls=%s)", cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPTLS)
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+			From:     cfg.MailFrom,
+		})
+	case config.MailDriverFile:
+		log.Printf("Mail driver: file (dir=%s)", cfg.MailFileDir)
+		return mail.NewFileMailer(cfg.MailFrom, cfg.MailFileDir)
+	default:
+		log.Println("Mail driver: log (messages are written to the log, not delivered)")
+		return mail.NewLogMailer(cfg.MailFrom)
+	}
+}
@@ -0,0 +1,59 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/notify"
+
+	"github.com/appleboy/graceful"
+)
+
+// deviceNotifyChannel is the Redis pub/sub channel carrying device code
+// authorization events between replicas.
+const deviceNotifyChannel = "authgate:device:authorized"
+
+// initializeDeviceNotifier creates the notifier that wakes /oauth/device/wait
+// requests. Returns nil when the endpoint is disabled.
+func initializeDeviceNotifier(
+	ctx context.Context,
+	cfg *config.Config,
+) (core.DeviceCodeNotifier, error) {
+	if cfg.DeviceWaitTimeout <= 0 {
+		return nil, nil //nolint:nilnil // notifier not needed in this configuration
+	}
+
+	if cfg.DeviceNotifyStore != config.DeviceNotifyStoreRedis {
+		log.Println("Device wait notifier: memory")
+		return notify.NewMemoryNotifier(), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.RedisConnTimeout)
+	defer cancel()
+
+	n, err := notify.NewRedisNotifier(
+		ctx,
+		cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB,
+		deviceNotifyChannel,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize device wait notifier: %w", err)
+	}
+	log.Printf("Device wait notifier: redis (addr=%s)", cfg.RedisAddr)
+	return n, nil
+}
+
+// addDeviceNotifierShutdownJob closes the device wait notifier on shutdown.
+func addDeviceNotifierShutdownJob(
+	m *graceful.Manager,
+	notifier core.DeviceCodeNotifier,
+	cfg *config.Config,
+) {
+	if notifier == nil {
+		return
+	}
+	addNamedCacheShutdownJob(m, "device wait notifier", notifier.Close, cfg.RedisCloseTimeout)
+}
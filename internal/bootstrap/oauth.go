@@ -1,14 +1,24 @@
 package bootstrap
 
 import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/go-authgate/authgate/internal/auth"
 	"github.com/go-authgate/authgate/internal/client"
 	"github.com/go-authgate/authgate/internal/config"
 
 	"github.com/appleboy/go-httpclient"
+	"github.com/coreos/go-oidc/v3/oidc"
 )
 
 // initializeOAuthProviders initializes configured OAuth providers
@@ -94,6 +104,147 @@ func initializeOAuthProviders(cfg *config.Config) map[string]*auth.OAuthProvider
 	return providers
 }
 
+// initializeOIDCProviders adds the configured generic OpenID Connect
+// providers. Discovery runs once at startup through httpClient; a provider
+// whose issuer cannot be reached is skipped with a warning.
+func initializeOIDCProviders(
+	cfg *config.Config,
+	httpClient *http.Client,
+	providers map[string]*auth.OAuthProvider,
+) {
+	ctx := oidc.ClientContext(context.Background(), httpClient)
+	for _, p := range cfg.OIDCProviders {
+		provider, err := auth.NewOIDCProvider(ctx, auth.OIDCProviderConfig{
+			OAuthProviderConfig: auth.OAuthProviderConfig{
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  p.RedirectURL,
+				Scopes:       p.Scopes,
+			},
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			IssuerURL:   p.IssuerURL,
+			Claims: auth.OIDCClaimMapping{
+				Username:  p.UsernameClaim,
+				Email:     p.EmailClaim,
+				FullName:  p.NameClaim,
+				AvatarURL: p.AvatarClaim,
+			},
+			TrustEmailVerified: p.TrustEmailVerified,
+		})
+		if err != nil {
+			log.Printf("Warning: OIDC provider %s disabled: %v", p.Name, err)
+			continue
+		}
+		providers[p.Name] = provider
+		log.Printf(
+			"OIDC provider configured: name=%s issuer=%s redirect=%s",
+			p.Name,
+			p.IssuerURL,
+			p.RedirectURL,
+		)
+	}
+}
+
+// maxSAMLMetadataSize caps the IdP metadata read at startup. Federation
+// feeds listing many entities can run to several megabytes.
+const maxSAMLMetadataSize = 16 << 20
+
+// initializeSAMLProviders adds the configured SAML identity providers. IdP
+// metadata is loaded once at startup, from disk or through httpClient; a
+// provider whose metadata cannot be loaded is skipped with a warning.
+func initializeSAMLProviders(
+	cfg *config.Config,
+	httpClient *http.Client,
+	providers map[string]*auth.OAuthProvider,
+) {
+	if len(cfg.SAMLProviders) == 0 {
+		return
+	}
+	cert, key, err := loadSAMLKeyPair(cfg.SAMLSPCertFile, cfg.SAMLSPKeyFile)
+	if err != nil {
+		log.Printf("Warning: SAML providers disabled: %v", err)
+		return
+	}
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	for _, p := range cfg.SAMLProviders {
+		metadata, err := loadSAMLMetadata(httpClient, p)
+		if err != nil {
+			log.Printf("Warning: SAML provider %s disabled: %v", p.Name, err)
+			continue
+		}
+		acsURL := baseURL + "/auth/callback/" + p.Name
+		provider, err := auth.NewSAMLProvider(auth.SAMLProviderConfig{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			EntityID:    p.EntityID,
+			ACSURL:      acsURL,
+			IDPMetadata: metadata,
+			Certificate: cert,
+			Key:         key,
+			Attributes: auth.SAMLAttributeMapping{
+				UserID:   p.UserIDAttribute,
+				Username: p.UsernameAttribute,
+				Email:    p.EmailAttribute,
+				FullName: p.NameAttribute,
+			},
+			TrustEmail: p.TrustEmail,
+		})
+		if err != nil {
+			log.Printf("Warning: SAML provider %s disabled: %v", p.Name, err)
+			continue
+		}
+		providers[p.Name] = provider
+		log.Printf(
+			"SAML provider configured: name=%s entity_id=%s acs=%s",
+			p.Name,
+			p.EntityID,
+			acsURL,
+		)
+	}
+}
+
+// loadSAMLKeyPair reads the service provider's signing certificate and RSA
+// private key.
+func loadSAMLKeyPair(certFile, keyFile string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load SAML service provider key pair: %w", err)
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("SAML service provider key must be an RSA key")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SAML service provider certificate: %w", err)
+	}
+	return cert, key, nil
+}
+
+// loadSAMLMetadata reads an identity provider's metadata from its file or
+// URL.
+func loadSAMLMetadata(httpClient *http.Client, p config.SAMLProviderConfig) ([]byte, error) {
+	if p.IDPMetadataFile != "" {
+		return os.ReadFile(p.IDPMetadataFile)
+	}
+	req, err := http.NewRequestWithContext(
+		context.Background(), http.MethodGet, p.IDPMetadataURL, nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IdP metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch IdP metadata: HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSAMLMetadataSize))
+}
+
 // getProviderNames returns a list of provider names
 func getProviderNames(providers map[string]*auth.OAuthProvider) []string {
 	names := make([]string, 0, len(providers))
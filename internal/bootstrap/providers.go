@@ -3,19 +3,51 @@ package bootstrap
 import (
 	"crypto"
 	"log"
+	"regexp"
 
 	"github.com/go-authgate/authgate/internal/auth"
 	"github.com/go-authgate/authgate/internal/client"
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/token"
 )
 
-// initializeHTTPAPIAuthProvider creates HTTP API auth provider when configured.
-// Returns core.AuthProvider (not *auth.HTTPAPIAuthProvider) so that the nil
-// default case is an untyped nil interface, keeping == nil checks in UserService safe.
-func initializeHTTPAPIAuthProvider(cfg *config.Config) core.AuthProvider {
-	switch cfg.AuthMode {
+// initializeAuthChain creates the password backends of AUTH_CHAIN in order.
+func initializeAuthChain(
+	cfg *config.Config,
+	localProvider core.AuthProvider,
+) []services.AuthChainEntry {
+	chain := make([]services.AuthChainEntry, 0, len(cfg.AuthChain))
+	for _, e := range cfg.AuthChain {
+		provider := localProvider
+		if e.Provider != config.AuthModeLocal {
+			provider = initializeExternalAuthProvider(cfg, e.Provider)
+		}
+		var pattern *regexp.Regexp
+		if e.UsernamePattern != "" {
+			// Already compiled once by config.Validate
+			pattern = regexp.MustCompile(e.UsernamePattern)
+		}
+		chain = append(chain, services.AuthChainEntry{
+			Source:                    e.Provider,
+			Provider:                  provider,
+			UsernamePattern:           pattern,
+			Sources:                   e.Sources,
+			ContinueOnNotFound:        e.OnNotFound == config.AuthChainContinue,
+			ContinueOnInvalidPassword: e.OnInvalidPassword == config.AuthChainContinue,
+			MigratePassword:           e.MigratePassword,
+		})
+	}
+	return chain
+}
+
+// initializeExternalAuthProvider creates the HTTP API or LDAP auth provider
+// named by an AUTH_CHAIN entry. Returns core.AuthProvider (not a concrete type)
+// so that the nil default case is an untyped nil interface, keeping == nil
+// checks in UserService safe.
+func initializeExternalAuthProvider(cfg *config.Config, name string) core.AuthProvider {
+	switch name {
 	case config.AuthModeHTTPAPI:
 		authRetryClient, err := client.CreateRetryClient(client.RetryClientConfig{
 			AuthMode:           cfg.HTTPAPIAuthMode,
@@ -32,6 +64,13 @@ func initializeHTTPAPIAuthProvider(cfg *config.Config) core.AuthProvider {
 		}
 		log.Printf("HTTP API authentication enabled: %s", cfg.HTTPAPIURL)
 		return auth.NewHTTPAPIAuthProvider(cfg, authRetryClient)
+	case config.AuthModeLDAP:
+		mode := "search"
+		if cfg.LDAPUserDNTemplate != "" {
+			mode = "direct bind"
+		}
+		log.Printf("LDAP authentication enabled: %s (%s)", cfg.LDAPURL, mode)
+		return auth.NewLDAPAuthProvider(cfg)
 	default:
 		return nil
 	}
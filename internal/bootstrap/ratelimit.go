@@ -7,17 +7,22 @@ import (
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/core"
 	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/services"
 	"github.com/redis/go-redis/v9"
+	"github.com/ulule/limiter/v3"
+	limiterRedis "github.com/ulule/limiter/v3/drivers/store/redis"
 )
 
 // rateLimitMiddlewares holds rate limiting middlewares for different endpoints
 type rateLimitMiddlewares struct {
-	login        gin.HandlerFunc
-	deviceCode   gin.HandlerFunc
-	token        gin.HandlerFunc
-	deviceVerify gin.HandlerFunc
-	register     gin.HandlerFunc
-	introspect   gin.HandlerFunc
+	login         gin.HandlerFunc
+	deviceCode    gin.HandlerFunc
+	token         gin.HandlerFunc
+	deviceVerify  gin.HandlerFunc
+	register      gin.HandlerFunc
+	introspect    gin.HandlerFunc
+	passwordReset gin.HandlerFunc
+	signup        gin.HandlerFunc
 }
 
 // setupRateLimiting configures rate limiting middlewares based on configuration
@@ -30,12 +35,14 @@ func setupRateLimiting(
 	// Return no-op middlewares when rate limiting is disabled
 	noOpMiddleware := func(c *gin.Context) { c.Next() }
 	disabledLimiters := rateLimitMiddlewares{
-		login:        noOpMiddleware,
-		deviceCode:   noOpMiddleware,
-		token:        noOpMiddleware,
-		deviceVerify: noOpMiddleware,
-		register:     noOpMiddleware,
-		introspect:   noOpMiddleware,
+		login:         noOpMiddleware,
+		deviceCode:    noOpMiddleware,
+		token:         noOpMiddleware,
+		deviceVerify:  noOpMiddleware,
+		register:      noOpMiddleware,
+		introspect:    noOpMiddleware,
+		passwordReset: noOpMiddleware,
+		signup:        noOpMiddleware,
 	}
 
 	switch {
@@ -79,11 +86,55 @@ func createRateLimiters(
 	}
 
 	return rateLimitMiddlewares{
-		login:        createLimiter(cfg.LoginRateLimit, "/login"),
-		deviceCode:   createLimiter(cfg.DeviceCodeRateLimit, "/oauth/device/code"),
-		token:        createLimiter(cfg.TokenRateLimit, "/oauth/token"),
-		deviceVerify: createLimiter(cfg.DeviceVerifyRateLimit, "/device/verify"),
-		register:     createLimiter(cfg.DynamicClientRegistrationRateLimit, "/oauth/register"),
-		introspect:   createLimiter(cfg.IntrospectRateLimit, "/oauth/introspect"),
+		login:         createLimiter(cfg.LoginRateLimit, "/login"),
+		deviceCode:    createLimiter(cfg.DeviceCodeRateLimit, "/oauth/device/code"),
+		token:         createLimiter(cfg.TokenRateLimit, "/oauth/token"),
+		deviceVerify:  createLimiter(cfg.DeviceVerifyRateLimit, "/device/verify"),
+		register:      createLimiter(cfg.DynamicClientRegistrationRateLimit, "/oauth/register"),
+		introspect:    createLimiter(cfg.IntrospectRateLimit, "/oauth/introspect"),
+		passwordReset: createLimiter(cfg.PasswordResetRateLimit, "/password"),
+		signup:        createLimiter(cfg.SignupRateLimit, "/signup"),
 	}
 }
+
+// initializeUserCodeGuard creates the device user code brute-force guard. Its
+// counters share the rate limiter's Redis client when RATE_LIMIT_STORE=redis,
+// so thresholds hold across replicas; otherwise they are per instance.
+func initializeUserCodeGuard(
+	cfg *config.Config,
+	auditService core.AuditLogger,
+	redisClient *redis.Client,
+) *services.UserCodeGuard {
+	var store limiter.Store
+	if redisClient != nil {
+		var err error
+		store, err = limiterRedis.NewStoreWithOptions(redisClient, limiter.StoreOptions{
+			Prefix: "usercode",
+		})
+		if err != nil {
+			log.Fatalf("Failed to create user code guard store: %v", err)
+		}
+	}
+	return services.NewUserCodeGuard(store, cfg, auditService)
+}
+
+// initializeTwoFactorGuard creates the per-account second-factor lockout. Like
+// the user code guard, its counters share the rate limiter's Redis client
+// when RATE_LIMIT_STORE=redis.
+func initializeTwoFactorGuard(
+	cfg *config.Config,
+	auditService core.AuditLogger,
+	redisClient *redis.Client,
+) *services.TwoFactorGuard {
+	var store limiter.Store
+	if redisClient != nil {
+		var err error
+		store, err = limiterRedis.NewStoreWithOptions(redisClient, limiter.StoreOptions{
+			Prefix: "twofactor",
+		})
+		if err != nil {
+			log.Fatalf("Failed to create two-factor guard store: %v", err)
+		}
+	}
+	return services.NewTwoFactorGuard(store, cfg, auditService)
+}
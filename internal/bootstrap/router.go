@@ -212,6 +212,49 @@ func setupAllRoutes(
 	})
 	r.GET("/logout", h.auth.Logout)
 
+	// Second login step for users with 2FA (pending login, not yet signed in)
+	loginTwoFactor := r.Group("/login/2fa")
+	loginTwoFactor.Use(middleware.CSRFMiddleware())
+	{
+		loginTwoFactor.GET("", h.twoFactor.VerifyPage)
+		loginTwoFactor.POST("", rateLimiters.login, h.twoFactor.Verify)
+		if cfg.WebAuthnEnabled {
+			loginTwoFactor.POST("/passkey/options", h.twoFactor.PasskeySecondFactorOptions)
+			loginTwoFactor.POST("/passkey", rateLimiters.login, h.twoFactor.PasskeySecondFactor)
+		}
+	}
+
+	// Passwordless passkey login. Like POST /login these carry no CSRF token:
+	// the assertion is bound to this origin by the browser, so another site
+	// cannot produce one to sign the victim in.
+	if cfg.WebAuthnEnabled {
+		r.POST("/login/passkey/options", rateLimiters.login, h.twoFactor.PasskeyLoginOptions)
+		r.POST("/login/passkey", rateLimiters.login, h.twoFactor.PasskeyLogin)
+	}
+
+	// Self-service password reset for local users (public)
+	if cfg.PasswordResetEnabled {
+		password := r.Group("/password")
+		password.Use(middleware.CSRFMiddleware())
+		{
+			password.GET("/forgot", h.passwordReset.ForgotPasswordPage)
+			password.POST("/forgot", rateLimiters.passwordReset, h.passwordReset.ForgotPassword)
+			password.GET("/reset", h.passwordReset.ResetPasswordPage)
+			password.POST("/reset", rateLimiters.passwordReset, h.passwordReset.ResetPassword)
+		}
+	}
+
+	// Self-service signup with email verification (public)
+	if cfg.SignupEnabled {
+		signup := r.Group("/signup")
+		signup.Use(middleware.CSRFMiddleware())
+		{
+			signup.GET("", h.signup.SignupPage)
+			signup.POST("", rateLimiters.signup, h.signup.Signup)
+			signup.GET("/verify", rateLimiters.signup, h.signup.VerifyEmail)
+		}
+	}
+
 	// OAuth routes (public)
 	setupOAuthRoutes(r, oauthProviders, h.oauth)
 
@@ -253,6 +296,10 @@ func setupAllRoutes(
 	}
 	{
 		oauth.POST("/device/code", rateLimiters.deviceCode, h.device.DeviceCodeRequest)
+		oauth.GET("/device/qr", rateLimiters.deviceCode, h.device.DeviceQRCode)
+		if cfg.DeviceWaitTimeout > 0 {
+			oauth.POST("/device/wait", rateLimiters.token, h.device.DeviceWait)
+		}
 		oauth.POST("/token", rateLimiters.token, h.token.Token)
 		oauth.GET("/tokeninfo", h.token.TokenInfo)
 		oauth.POST("/revoke", h.token.Revoke)
@@ -263,9 +310,17 @@ func setupAllRoutes(
 		oauth.POST("/userinfo", h.oidc.UserInfo)
 	}
 
+	// requireTwoFactor sends users who must enroll in 2FA (TOTP_REQUIRED) to
+	// /account/security before anything else.
+	requireTwoFactor := middleware.RequireTwoFactorEnrollment(cfg.TOTPRequired, cfg.WebAuthnEnabled)
+
 	// OAuth Authorization Code Flow (browser, requires login + CSRF)
 	oauthProtected := r.Group("/oauth")
-	oauthProtected.Use(middleware.RequireAuth(h.userService), middleware.CSRFMiddleware())
+	oauthProtected.Use(
+		middleware.RequireAuth(h.userService),
+		requireTwoFactor,
+		middleware.CSRFMiddleware(),
+	)
 	{
 		oauthProtected.GET("/authorize", h.authorization.ShowAuthorizePage)
 		oauthProtected.POST("/authorize", h.authorization.HandleAuthorize)
@@ -275,9 +330,22 @@ func setupAllRoutes(
 	// Applied to all authenticated route groups so the navbar badge is visible site-wide.
 	injectPending := h.client.InjectPendingCount()
 
+	// injectPendingSignups does the same for the signup approval queue, which
+	// only exists when signups need approval.
+	injectPendingSignups := func(c *gin.Context) { c.Next() }
+	if cfg.SignupEnabled && cfg.SignupRequireApproval {
+		injectPendingSignups = h.signup.InjectPendingCount()
+	}
+
 	// Protected routes (require login)
 	protected := r.Group("")
-	protected.Use(middleware.RequireAuth(h.userService), middleware.CSRFMiddleware(), injectPending)
+	protected.Use(
+		middleware.RequireAuth(h.userService),
+		requireTwoFactor,
+		middleware.CSRFMiddleware(),
+		injectPending,
+		injectPendingSignups,
+	)
 	{
 		protected.GET("/device", h.device.DevicePage)
 		protected.POST("/device/verify", rateLimiters.deviceVerify, h.device.DeviceVerify)
@@ -285,21 +353,56 @@ func setupAllRoutes(
 
 	// Account routes (require login)
 	account := r.Group("/account")
-	account.Use(middleware.RequireAuth(h.userService), middleware.CSRFMiddleware(), injectPending)
+	account.Use(
+		middleware.RequireAuth(h.userService),
+		requireTwoFactor,
+		middleware.CSRFMiddleware(),
+		injectPending,
+		injectPendingSignups,
+	)
 	{
 		account.GET("/sessions", h.session.ListSessions)
 		account.POST("/sessions/:id/revoke", h.session.RevokeSession)
 		account.POST("/sessions/:id/disable", h.session.DisableSession)
 		account.POST("/sessions/:id/enable", h.session.EnableSession)
 		account.POST("/sessions/revoke-all", h.session.RevokeAllSessions)
+		account.POST("/sessions/login/:sid/revoke", h.session.RevokeLoginSession)
+		// Personal access tokens
+		account.GET("/tokens", h.personalToken.ListTokens)
+		account.POST("/tokens", h.personalToken.CreateToken)
+		account.POST("/tokens/:id/revoke", h.personalToken.RevokeToken)
 		// Authorization Code Flow consent management
 		account.GET("/authorizations", h.authorization.ListAuthorizations)
 		account.POST("/authorizations/:uuid/revoke", h.authorization.RevokeAuthorization)
+		// Profile, password, email and linked OAuth accounts
+		account.GET("/profile", h.profile.ProfilePage)
+		account.POST("/profile", h.profile.UpdateProfile)
+		account.POST("/profile/password", rateLimiters.login, h.profile.ChangePassword)
+		account.POST("/profile/email", rateLimiters.login, h.profile.RequestEmailChange)
+		account.GET("/profile/email/confirm", h.profile.ConfirmEmailChange)
+		account.POST("/profile/connections/:id/unlink", h.profile.UnlinkConnection)
+		// Two-factor authentication (exempt from the enrollment redirect)
+		account.GET("/security", h.twoFactor.SecurityPage)
+		account.GET("/security/totp/setup", h.twoFactor.SetupPage)
+		account.POST("/security/totp", h.twoFactor.EnableTOTP)
+		account.POST("/security/totp/disable", rateLimiters.login, h.twoFactor.DisableTOTP)
+		account.POST("/security/recovery-codes", rateLimiters.login, h.twoFactor.RegenerateRecoveryCodes)
+		if cfg.WebAuthnEnabled {
+			account.POST("/security/passkeys/options", h.twoFactor.PasskeyRegistrationOptions)
+			account.POST("/security/passkeys", h.twoFactor.RegisterPasskey)
+			account.POST("/security/passkeys/:id/delete", h.twoFactor.DeletePasskey)
+		}
 	}
 
 	// User apps area (all authenticated users, not admin-only)
 	apps := r.Group("/apps")
-	apps.Use(middleware.RequireAuth(h.userService), middleware.CSRFMiddleware(), injectPending)
+	apps.Use(
+		middleware.RequireAuth(h.userService),
+		requireTwoFactor,
+		middleware.CSRFMiddleware(),
+		injectPending,
+		injectPendingSignups,
+	)
 	{
 		apps.GET("", h.userClient.ShowMyAppsPage)
 		apps.GET("/new", h.userClient.ShowCreateAppPage)
@@ -316,8 +419,10 @@ func setupAllRoutes(
 	admin.Use(
 		middleware.RequireAuth(h.userService),
 		middleware.RequireAdmin(),
+		requireTwoFactor,
 		middleware.CSRFMiddleware(),
 		injectPending,
+		injectPendingSignups,
 	)
 	{
 		// Dashboard
@@ -345,6 +450,7 @@ func setupAllRoutes(
 		admin.GET("/users/:id/edit", h.userAdmin.ShowEditUserPage)
 		admin.POST("/users/:id", h.userAdmin.UpdateUser)
 		admin.POST("/users/:id/reset-password", h.userAdmin.ResetPassword)
+		admin.POST("/users/:id/reset-2fa", h.userAdmin.ResetTwoFactor)
 		admin.POST("/users/:id/delete", h.userAdmin.DeleteUser)
 		admin.POST("/users/:id/disable", h.userAdmin.DisableUser)
 		admin.POST("/users/:id/enable", h.userAdmin.EnableUser)
@@ -352,9 +458,16 @@ func setupAllRoutes(
 		admin.POST("/users/:id/connections/:conn_id/delete", h.userAdmin.DeleteUserConnection)
 		admin.GET("/users/:id/authorizations", h.userAdmin.ShowUserAuthorizations)
 		admin.POST("/users/:id/authorizations/:uuid/revoke", h.userAdmin.RevokeUserAuthorization)
+		if cfg.SignupEnabled && cfg.SignupRequireApproval {
+			admin.POST("/users/:id/approve", h.signup.ApproveSignup)
+			admin.POST("/users/:id/reject", h.signup.RejectSignup)
+		}
 
 		// Token management routes
 		admin.GET("/tokens", h.tokenAdmin.ShowTokensPage)
+		admin.POST("/tokens/bulk", h.tokenAdmin.BulkAction)
+		admin.GET("/tokens/:id/family", h.tokenAdmin.ShowTokenFamily)
+		admin.GET("/tokens/:id/family/api", h.tokenAdmin.GetTokenFamily)
 		admin.POST("/tokens/:id/revoke", h.tokenAdmin.RevokeToken)
 		admin.POST("/tokens/:id/disable", h.tokenAdmin.DisableToken)
 		admin.POST("/tokens/:id/enable", h.tokenAdmin.EnableToken)
@@ -369,7 +482,7 @@ func setupAllRoutes(
 	}
 }
 
-// setupOAuthRoutes configures OAuth authentication routes
+// setupOAuthRoutes configures OAuth and SAML authentication routes
 func setupOAuthRoutes(
 	r *gin.Engine,
 	providers map[string]*auth.OAuthProvider,
@@ -381,6 +494,9 @@ func setupOAuthRoutes(
 	oauthGroup := r.Group("/auth")
 	oauthGroup.GET("/login/:provider", handler.LoginWithProvider)
 	oauthGroup.GET("/callback/:provider", handler.OAuthCallback)
+	// SAML identity providers post their response to the same callback URL
+	oauthGroup.POST("/callback/:provider", handler.SAMLCallback)
+	oauthGroup.GET("/metadata/:provider", handler.SAMLMetadata)
 }
 
 // createFaviconHandler creates favicon endpoint handler
@@ -455,7 +571,11 @@ var ginModeLogMessage = map[bool]string{
 
 // logServerStartup logs server startup information
 func logServerStartup(cfg *config.Config) {
-	log.Printf("Authentication mode: %s", cfg.AuthMode)
+	backends := make([]string, 0, len(cfg.AuthChain))
+	for _, e := range cfg.AuthChain {
+		backends = append(backends, e.Provider)
+	}
+	log.Printf("Authentication chain: %s", strings.Join(backends, " -> "))
 	log.Printf("OAuth Device Flow server starting on %s", cfg.ServerAddr)
 	log.Printf("Verification URL: %s/device", cfg.BaseURL)
 	log.Printf("  (Tip: Add ?user_code=XXXX-XXXX to pre-fill the code)")
@@ -10,6 +10,7 @@ import (
 	"github.com/go-authgate/authgate/internal/core"
 	"github.com/go-authgate/authgate/internal/metrics"
 	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/store"
 
 	"github.com/appleboy/graceful"
@@ -302,34 +303,111 @@ func addTokenCacheCleanupJob(
 	addNamedCacheShutdownJob(m, "token cache", tokenCache.Close, cfg.CacheCloseTimeout)
 }
 
-// addExpiredTokenCleanupJob adds a periodic job that purges expired access tokens
-// and device codes from the database to prevent unbounded table growth.
+// addRotationGraceCacheCleanupJob adds rotation grace cache cleanup on shutdown
+func addRotationGraceCacheCleanupJob(
+	m *graceful.Manager,
+	graceCache core.Cache[services.RotationSuccessor],
+	cfg *config.Config,
+) {
+	if graceCache == nil {
+		return
+	}
+	addNamedCacheShutdownJob(m, "rotation grace cache", graceCache.Close, cfg.CacheCloseTimeout)
+}
+
+// addExpiredTokenCleanupJob adds a periodic job that purges expired access tokens,
+// idle refresh-token families and device codes from the database to prevent
+// unbounded table growth.
 func addExpiredTokenCleanupJob(m *graceful.Manager, db *store.Store, cfg *config.Config) {
 	if !cfg.EnableExpiredTokenCleanup {
 		return
 	}
 
+	idleTimeouts := cfg.RefreshIdleTimeouts()
+	idleCleanup := cfg.HasRefreshIdleTimeout()
+	cleanup := func() {
+		if err := db.DeleteExpiredTokens(); err != nil {
+			log.Printf("Failed to cleanup expired tokens: %v", err)
+		}
+		if idleCleanup {
+			deleted, err := db.DeleteIdleRefreshTokens(idleTimeouts)
+			if err != nil {
+				log.Printf("Failed to cleanup idle refresh tokens: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Cleaned up %d tokens from idle refresh token families", deleted)
+			}
+		}
+		if err := db.DeleteExpiredDeviceCodes(); err != nil {
+			log.Printf("Failed to cleanup expired device codes: %v", err)
+		}
+		if err := db.DeleteExpiredPasswordResetTokens(); err != nil {
+			log.Printf("Failed to cleanup expired password reset tokens: %v", err)
+		}
+		if err := db.DeleteExpiredEmailChangeTokens(); err != nil {
+			log.Printf("Failed to cleanup expired email change tokens: %v", err)
+		}
+		if cfg.SignupEnabled {
+			deleted, err := db.DeleteExpiredSignups(time.Now().Add(-cfg.SignupVerificationTTL))
+			if err != nil {
+				log.Printf("Failed to cleanup unverified signups: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Cleaned up %d unverified signups", deleted)
+			}
+		}
+	}
+
 	m.AddRunningJob(func(ctx context.Context) error {
 		ticker := time.NewTicker(cfg.ExpiredTokenCleanupInterval)
 		defer ticker.Stop()
 
 		// Run cleanup immediately on startup
-		if err := db.DeleteExpiredTokens(); err != nil {
-			log.Printf("Failed to cleanup expired tokens: %v", err)
+		cleanup()
+
+		for {
+			select {
+			case <-ticker.C:
+				cleanup()
+			case <-ctx.Done():
+				return nil
+			}
 		}
-		if err := db.DeleteExpiredDeviceCodes(); err != nil {
-			log.Printf("Failed to cleanup expired device codes: %v", err)
+	})
+}
+
+// addConsentExpiryJob periodically expires user consents past their
+// ExpiresAt, revoking the tokens issued under them. Requests that meet an
+// expired consent expire it on the spot; this job covers users who never
+// come back.
+func addConsentExpiryJob(
+	m *graceful.Manager,
+	authorizationService *services.AuthorizationService,
+	cfg *config.Config,
+) {
+	if cfg.ConsentExpiryCheckInterval <= 0 {
+		return
+	}
+
+	expire := func(ctx context.Context) {
+		expired, err := authorizationService.ExpireStaleAuthorizations(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to expire stale user authorizations: %v", err)
+		}
+		if expired > 0 {
+			log.Printf("Expired %d user authorizations", expired)
 		}
+	}
+
+	m.AddRunningJob(func(ctx context.Context) error {
+		ticker := time.NewTicker(cfg.ConsentExpiryCheckInterval)
+		defer ticker.Stop()
+
+		// Run immediately on startup
+		expire(ctx)
 
 		for {
 			select {
 			case <-ticker.C:
-				if err := db.DeleteExpiredTokens(); err != nil {
-					log.Printf("Failed to cleanup expired tokens: %v", err)
-				}
-				if err := db.DeleteExpiredDeviceCodes(); err != nil {
-					log.Printf("Failed to cleanup expired device codes: %v", err)
-				}
+				expire(ctx)
 			case <-ctx.Done():
 				return nil
 			}
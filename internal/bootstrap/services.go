@@ -7,6 +7,7 @@ import (
 	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/webauthn"
 )
 
 // serviceSet holds all initialized business logic services
@@ -17,6 +18,10 @@ type serviceSet struct {
 	client        *services.ClientService
 	authorization *services.AuthorizationService
 	dashboard     *services.DashboardService
+	profile       *services.ProfileService
+	passkey       *services.PasskeyService       // nil unless WEBAUTHN_ENABLED
+	passwordReset *services.PasswordResetService // nil unless PASSWORD_RESET_ENABLED
+	signup        *services.SignupService        // nil unless SIGNUP_ENABLED
 }
 
 // initializeServices creates all business logic services
@@ -30,21 +35,28 @@ func initializeServices(
 	clientCache core.Cache[models.OAuthApplication],
 	tokenProvider core.TokenProvider,
 	tokenCache core.Cache[models.AccessToken],
+	rotationGraceCache core.Cache[services.RotationSuccessor],
+	deviceNotifier core.DeviceCodeNotifier,
+	mailer core.Mailer,
+	userCodeGuard *services.UserCodeGuard,
+	twoFactorGuard *services.TwoFactorGuard,
 ) serviceSet {
 	// Initialize authentication providers
 	localProvider := auth.NewLocalAuthProvider(db)
-	httpAPIProvider := initializeHTTPAPIAuthProvider(cfg)
+	authChain := initializeAuthChain(cfg, localProvider)
 
 	// Initialize services
 	userService := services.NewUserService(
 		db,
 		localProvider,
-		httpAPIProvider,
+		nil,
 		cfg.AuthMode,
 		cfg.OAuthAutoRegister,
 		auditService,
 		userCache,
 		cfg.UserCacheTTL,
+		services.WithAuthChain(authChain),
+		services.WithTwoFactorGuard(twoFactorGuard),
 	)
 	clientService := services.NewClientService(
 		db, auditService,
@@ -52,13 +64,25 @@ func initializeServices(
 		clientCache, cfg.ClientCacheTTL,
 		services.WithStrictRedirectURIs(cfg.StrictRedirectURIs),
 	)
+	var deviceOpts []services.DeviceServiceOption
+	if deviceNotifier != nil {
+		deviceOpts = append(deviceOpts, services.WithDeviceCodeNotifier(deviceNotifier))
+	}
+	if userCodeGuard != nil {
+		deviceOpts = append(deviceOpts, services.WithUserCodeGuard(userCodeGuard))
+	}
 	deviceService := services.NewDeviceService(
 		db,
 		cfg,
 		auditService,
 		prometheusMetrics,
 		clientService,
+		deviceOpts...,
 	)
+	var tokenOpts []services.TokenServiceOption
+	if rotationGraceCache != nil {
+		tokenOpts = append(tokenOpts, services.WithRotationGraceCache(rotationGraceCache))
+	}
 	tokenService := services.NewTokenService(
 		db,
 		cfg,
@@ -68,6 +92,7 @@ func initializeServices(
 		prometheusMetrics,
 		tokenCache,
 		clientService,
+		tokenOpts...,
 	)
 	authorizationService := services.NewAuthorizationService(
 		db,
@@ -77,6 +102,31 @@ func initializeServices(
 		clientService,
 	)
 	dashboardService := services.NewDashboardService(db, auditService)
+	profileService := services.NewProfileService(
+		db, cfg, userService, tokenService, mailer, auditService,
+	)
+
+	var passkeyService *services.PasskeyService
+	if cfg.WebAuthnEnabled {
+		rpID, origins := cfg.WebAuthnRelyingParty()
+		passkeyService = services.NewPasskeyService(db, userService, &webauthn.RelyingParty{
+			ID:      rpID,
+			Name:    cfg.WebAuthnRPName,
+			Origins: origins,
+		}, auditService)
+	}
+
+	var passwordResetService *services.PasswordResetService
+	if cfg.PasswordResetEnabled {
+		passwordResetService = services.NewPasswordResetService(
+			db, cfg, userService, tokenService, mailer, auditService,
+		)
+	}
+
+	var signupService *services.SignupService
+	if cfg.SignupEnabled {
+		signupService = services.NewSignupService(db, cfg, userService, mailer, auditService)
+	}
 
 	return serviceSet{
 		user:          userService,
@@ -85,5 +135,9 @@ func initializeServices(
 		client:        clientService,
 		authorization: authorizationService,
 		dashboard:     dashboardService,
+		profile:       profileService,
+		passkey:       passkeyService,
+		passwordReset: passwordResetService,
+		signup:        signupService,
 	}
 }
@@ -3,8 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/mail"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,6 +21,38 @@ import (
 const (
 	AuthModeLocal   = "local"
 	AuthModeHTTPAPI = "http_api"
+	AuthModeLDAP    = "ldap"
+)
+
+// Authentication chain constants for AUTH_CHAIN_<NAME>_* settings.
+const (
+	// AuthChainSourceNew stands for usernames without a local account in
+	// AUTH_CHAIN_<NAME>_SOURCES.
+	AuthChainSourceNew = "new"
+
+	AuthChainContinue = "continue"
+	AuthChainStop     = "stop"
+)
+
+// Two-factor policy constants for TOTP_REQUIRED.
+const (
+	TwoFactorRequiredNone   = ""
+	TwoFactorRequiredAdmins = "admins"
+	TwoFactorRequiredAll    = "all"
+)
+
+// Mail driver constants for MAIL_DRIVER.
+const (
+	MailDriverLog  = "log"
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+// SMTP transport security constants for SMTP_TLS.
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
 )
 
 // Rate limit store constants
@@ -26,6 +61,15 @@ const (
 	RateLimitStoreRedis  = "redis"
 )
 
+// Device notify store constants
+const (
+	DeviceNotifyStoreMemory = "memory"
+	DeviceNotifyStoreRedis  = "redis"
+)
+
+// maxDeviceWaitTimeout bounds DEVICE_WAIT_TIMEOUT.
+const maxDeviceWaitTimeout = 5 * time.Minute
+
 // CacheType constants shared by metrics, user, and client count caches.
 const (
 	CacheTypeMemory     = "memory"
@@ -40,6 +84,9 @@ const (
 	AlgES256 = "ES256"
 )
 
+// maxRefreshTokenReuseGracePeriod bounds REFRESH_TOKEN_REUSE_GRACE_PERIOD.
+const maxRefreshTokenReuseGracePeriod = 5 * time.Minute
+
 // DefaultJWTPrivateClaimPrefix is the namespace token AuthGate prepends to
 // every AuthGate-emitted private JWT claim when JWT_PRIVATE_CLAIM_PREFIX is
 // unset. Composed keys: extra_domain, extra_project, extra_service_account.
@@ -52,6 +99,14 @@ const DefaultJWTPrivateClaimPrefix = "extra"
 // disallowing one in the configured value prevents accidental "extra__domain".
 var jwtPrivateClaimPrefixPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
 
+// oidcProviderNamePattern keeps OIDC and SAML provider names usable in URL
+// paths and CSS class names.
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// builtinOAuthProviders are the provider names taken by the hand-coded
+// OAuth integrations.
+var builtinOAuthProviders = []string{"github", "gitea", "gitlab", "microsoft"}
+
 // jwtPrivateClaimPrefixMaxLen is the upper bound on the configured prefix.
 // 15 keeps `<prefix>_<longest-logical-name>` well under practical JWT key-size
 // limits (e.g. extra_service_account is 21 chars, acme_service_account is 20).
@@ -79,6 +134,8 @@ var staticReservedClaimKeys = []string{
 	"type", "scope", "user_id", "client_id",
 	// OIDC Core 1.0 §2 (ID token)
 	"azp", "amr", "acr", "auth_time", "nonce", "at_hash",
+	// OIDC session ID, set server-side for login-session-bound tokens
+	"sid",
 }
 
 // StaticReservedClaimKeys returns a defensive copy of the canonical static
@@ -119,6 +176,61 @@ func PrivateClaimLogicalNames() []string {
 type TokenProfile struct {
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
+	// RefreshIdleTimeout revokes a refresh token that has not been used for
+	// this long, independent of its absolute expiry. Zero disables the check.
+	RefreshIdleTimeout time.Duration
+	// MaxRefreshFamilies caps the active refresh token families a user may
+	// hold per client on this profile. Zero inherits MaxRefreshFamilies from
+	// Config.
+	MaxRefreshFamilies int
+}
+
+// OIDCProviderConfig configures one generic OpenID Connect login provider.
+// Each name listed in OIDC_PROVIDERS is read from OIDC_<NAME>_* variables,
+// where <NAME> is the name upper-cased with "-" replaced by "_".
+type OIDCProviderConfig struct {
+	Name          string   // Used in /auth/login/<name> and stored on linked accounts
+	DisplayName   string   // Login button label (default: Name, capitalized)
+	IssuerURL     string   // Must match the issuer in the discovery document
+	ClientID      string   // OAuth client ID registered with the issuer
+	ClientSecret  string   // OAuth client secret; empty for public clients
+	RedirectURL   string   // default: BASE_URL/auth/callback/<name>
+	Scopes        []string // default: openid, profile, email
+	UsernameClaim string   // default: preferred_username
+	EmailClaim    string   // default: email
+	NameClaim     string   // default: name
+	AvatarClaim   string   // default: picture
+	// TrustEmailVerified honors the issuer's email_verified claim, which
+	// enables auto-linking to existing accounts (default: false)
+	TrustEmailVerified bool
+}
+
+// SAMLProviderConfig configures one upstream SAML 2.0 identity provider.
+// Each name listed in SAML_PROVIDERS is read from SAML_<NAME>_* variables,
+// where <NAME> is the name upper-cased with "-" replaced by "_".
+type SAMLProviderConfig struct {
+	Name              string // Used in /auth/login/<name> and stored on linked accounts
+	DisplayName       string // Login button label (default: Name, capitalized)
+	IDPMetadataURL    string // IdP metadata fetched at startup; or set IDPMetadataFile
+	IDPMetadataFile   string // IdP metadata read from disk at startup
+	EntityID          string // default: BASE_URL/auth/metadata/<name>
+	UserIDAttribute   string // default: empty, which uses the assertion's NameID
+	UsernameAttribute string // default: uid
+	EmailAttribute    string // default: mail
+	NameAttribute     string // default: displayName
+	TrustEmail        bool   // Treat asserted email addresses as verified (default: false)
+}
+
+// AuthChainEntryConfig configures one password backend of the
+// authentication chain. Each name listed in AUTH_CHAIN is read from
+// AUTH_CHAIN_<NAME>_* variables, where <NAME> is the name upper-cased.
+type AuthChainEntryConfig struct {
+	Provider          string   // "local", "http_api" or "ldap"
+	UsernamePattern   string   // Regexp the username must match; empty matches all
+	Sources           []string // Auth sources of existing users; "new" for unknown usernames
+	OnNotFound        string   // "continue" (default) or "stop"
+	OnInvalidPassword string   // "stop" (default) or "continue"
+	MigratePassword   bool     // Copy the password to a local account on success (external only)
 }
 
 type Config struct {
@@ -165,10 +277,60 @@ type Config struct {
 	SessionFingerprintIP     bool // Include IP address in fingerprint (default: false, due to dynamic IPs)
 	SessionRememberMeEnabled bool // Enable "Remember Me" checkbox on login (default: true)
 	SessionRememberMeMaxAge  int  // Remember Me session max age in seconds (default: 2592000 = 30 days)
+	SessionRevokeOnLogout    bool // Revoke tokens issued from a login session when it logs out (default: true)
+
+	// TOTP two-factor authentication for local users
+	TOTPIssuer   string // TOTP_ISSUER: issuer name shown in authenticator apps (default: "AuthGate")
+	TOTPRequired string // TOTP_REQUIRED: ""|admins|all — who must enroll before using the app (default: "")
+
+	// Wrong second-factor codes are counted per account, across logins and
+	// the account security page; 0 disables the lockout.
+	TwoFactorFailureWindow time.Duration // TWO_FACTOR_FAILURE_WINDOW: counting window, and how long an account stays locked (default: 15m)
+	TwoFactorMaxFailures   int           // TWO_FACTOR_MAX_FAILURES: wrong codes before the account's second factor is locked for the window (default: 10)
+
+	// WebAuthn passkeys, usable as a passwordless login or as a second factor
+	WebAuthnEnabled bool     // WEBAUTHN_ENABLED: offer passkey registration and login (default: false)
+	WebAuthnRPID    string   // WEBAUTHN_RP_ID: domain passkeys are bound to (default: host of BASE_URL)
+	WebAuthnRPName  string   // WEBAUTHN_RP_NAME: name shown by the authenticator (default: "AuthGate")
+	WebAuthnOrigins []string // WEBAUTHN_ORIGINS: origins allowed to use passkeys (default: origin of BASE_URL)
+
+	// Self-service password reset by email, for local users
+	PasswordResetEnabled    bool          // PASSWORD_RESET_ENABLED: offer "Forgot password?" on the login page (default: false)
+	PasswordResetTokenTTL   time.Duration // PASSWORD_RESET_TOKEN_TTL: how long a reset link stays valid (default: 30m)
+	PasswordResetMaxPerHour int           // PASSWORD_RESET_MAX_PER_HOUR: reset emails sent per account per hour (default: 3)
+
+	// Self-service signup for local accounts
+	SignupEnabled         bool          // SIGNUP_ENABLED: offer a registration page (default: false)
+	SignupEmailDomains    []string      // SIGNUP_ALLOWED_EMAIL_DOMAINS: email domains allowed to sign up (empty = any)
+	SignupRequireApproval bool          // SIGNUP_REQUIRE_APPROVAL: hold verified signups until an admin approves them (default: false)
+	SignupVerificationTTL time.Duration // SIGNUP_VERIFICATION_TTL: how long a verification link stays valid (default: 24h)
+
+	// Self-service email change from the profile page
+	EmailChangeEnabled  bool          // EMAIL_CHANGE_ENABLED: let users change their email after confirming the new address (default: false)
+	EmailChangeTokenTTL time.Duration // EMAIL_CHANGE_TOKEN_TTL: how long a confirmation link stays valid (default: 24h)
+
+	// Outgoing email
+	MailDriver   string // MAIL_DRIVER: log|file|smtp (default: log)
+	MailFrom     string // MAIL_FROM: sender address (default: "AuthGate <noreply@localhost>")
+	MailFileDir  string // MAIL_FILE_DIR: directory the file driver writes .eml files to (default: "mail")
+	SMTPHost     string // SMTP_HOST: SMTP server host (required for MAIL_DRIVER=smtp)
+	SMTPPort     int    // SMTP_PORT: SMTP server port (default: 587)
+	SMTPUsername string // SMTP_USERNAME: SMTP AUTH username (empty = no authentication)
+	SMTPPassword string // SMTP_PASSWORD: SMTP AUTH password
+	SMTPTLS      string // SMTP_TLS: starttls|tls|none (default: starttls)
 
 	// Device code settings
 	DeviceCodeExpiration time.Duration
-	PollingInterval      int // seconds
+	PollingInterval      int           // seconds
+	DeviceWaitTimeout    time.Duration // DEVICE_WAIT_TIMEOUT: max time /oauth/device/wait holds a request (0 = endpoint disabled, default: 30s)
+	DeviceNotifyStore    string        // DEVICE_NOTIFY_STORE: memory|redis — redis wakes waiters on every replica (default: memory)
+
+	// User code brute-force protection for /device and /device/verify. Wrong
+	// codes are counted over DeviceVerifyFailureWindow; 0 disables a threshold.
+	DeviceVerifyFailureWindow      time.Duration // DEVICE_VERIFY_FAILURE_WINDOW: counting window (default: 15m)
+	DeviceVerifyMaxSessionFailures int           // DEVICE_VERIFY_MAX_SESSION_FAILURES: wrong codes before the login session must sign in again (default: 5)
+	DeviceVerifyMaxUserFailures    int           // DEVICE_VERIFY_MAX_USER_FAILURES: wrong codes before the account is locked out of device verification for the window (default: 15)
+	DeviceVerifyMaxGlobalFailures  int           // DEVICE_VERIFY_MAX_GLOBAL_FAILURES: wrong codes across all users before a suspicious-activity alert (default: 200)
 
 	// Database
 	DatabaseDriver string // "sqlite" or "postgres"
@@ -185,7 +347,8 @@ type Config struct {
 	DefaultAdminPassword string // Default admin password (if empty, random password is generated)
 
 	// Authentication
-	AuthMode string // "local" or "http_api"
+	AuthMode  string                 // "local", "http_api" or "ldap"
+	AuthChain []AuthChainEntryConfig // Ordered password backends (default: AUTH_MODE, then local)
 
 	// HTTP API Authentication
 	HTTPAPIURL                string
@@ -197,11 +360,47 @@ type Config struct {
 	HTTPAPIMaxRetries         int    // Maximum retry attempts (default: 3)
 	HTTPAPIRetryDelay         time.Duration
 	HTTPAPIMaxRetryDelay      time.Duration
+	// With HTTPAPIAdminRoles or HTTPAPIAdminGroups set, the role and groups
+	// in each login response decide the user's role; otherwise roles stay
+	// managed in AuthGate.
+	HTTPAPIAdminRoles  []string // Reported roles that make the user an admin
+	HTTPAPIAdminGroups []string // Reported groups whose members become admins
+
+	// LDAP Authentication. With LDAPUserDNTemplate set, users bind directly
+	// with the DN it produces; otherwise the user is first found by searching
+	// LDAPUserBaseDN, bound as LDAPBindDN (or anonymously when empty).
+	LDAPURL                 string        // ldap://host:389 or ldaps://host:636
+	LDAPStartTLS            bool          // Upgrade ldap:// connections with StartTLS
+	LDAPInsecureSkipVerify  bool          // Skip TLS certificate verification (testing only)
+	LDAPTimeout             time.Duration // Dial and per-request timeout (default: 10s)
+	LDAPPoolSize            int           // Idle connections kept open (default: 5)
+	LDAPBindDN              string        // Service account DN for user and group searches
+	LDAPBindPassword        string        // Service account password
+	LDAPUserDNTemplate      string        // e.g. "uid=%s,ou=people,dc=example,dc=com"
+	LDAPUserBaseDN          string        // Search base for users
+	LDAPUserFilter          string        // %s is the escaped username (default: "(uid=%s)")
+	LDAPUsernameAttribute   string        // default: "uid"
+	LDAPEmailAttribute      string        // default: "mail"
+	LDAPFullNameAttribute   string        // default: "cn"
+	LDAPExternalIDAttribute string        // Empty uses the DN; e.g. "entryUUID", "objectGUID"
+	LDAPMemberOfAttribute   string        // User attribute listing group DNs (default: "memberOf")
+	LDAPGroupBaseDN         string        // Search base for groups (default: LDAPUserBaseDN)
+	LDAPGroupFilter         string        // %s is the escaped user DN; empty disables group search
+	LDAPAdminGroups         []string      // Group DNs whose members become admins
 
 	// Refresh Token settings
 	RefreshTokenExpiration time.Duration // Refresh token lifetime (default: 720h = 30 days)
 	EnableRefreshTokens    bool          // Feature flag to enable/disable refresh tokens (default: true)
 	EnableTokenRotation    bool          // Enable token rotation mode (default: false, fixed mode)
+	// RefreshTokenSlidingExpiration extends a refresh token's expiry by the
+	// profile's refresh TTL on every use, never past the family's absolute cap
+	// of RefreshTokenExpirationMax from original issuance.
+	RefreshTokenSlidingExpiration bool // env: REFRESH_TOKEN_SLIDING_EXPIRATION (default: false)
+	// RefreshTokenReuseGracePeriod lets a rotated-out refresh token be replayed
+	// for this long after rotation and receive the same successor pair, so a
+	// client retrying a refresh whose response was lost is not treated as a
+	// thief. Zero disables the grace window (strict replay detection).
+	RefreshTokenReuseGracePeriod time.Duration // env: REFRESH_TOKEN_REUSE_GRACE_PERIOD (default: 0)
 
 	// Token lifetime hard caps. Any TokenProfile value that exceeds these is rejected
 	// during Validate(). Prevents a misconfigured profile from silently extending token
@@ -214,6 +413,29 @@ type Config struct {
 	// vars; the "standard" profile falls back to JWTExpiration / RefreshTokenExpiration.
 	TokenProfiles map[string]TokenProfile
 
+	// TokenFormat is the default access-token encoding: "jwt" (self-contained
+	// signed JWT) or "opaque" (random reference token stored by hash and
+	// resolvable by resource servers only via /oauth/introspect). Clients may
+	// override it per OAuthApplication. Refresh tokens are always JWTs — they
+	// are only ever presented back to AuthGate.
+	TokenFormat string // env: TOKEN_FORMAT (default: jwt)
+
+	// MaxRefreshFamilies caps the active refresh token families (one per
+	// device or authorization code grant, kept alive across refreshes) a
+	// user may hold for a single client. Token profiles and clients may
+	// override it; 0 means unlimited. RefreshFamilyLimitPolicy picks what a
+	// grant over the limit does: "evict_oldest" revokes the oldest family,
+	// "reject" refuses the grant.
+	MaxRefreshFamilies       int    // env: MAX_REFRESH_FAMILIES (default: 0 = unlimited)
+	RefreshFamilyLimitPolicy string // env: REFRESH_FAMILY_LIMIT_POLICY (default: evict_oldest)
+
+	// Personal access tokens: long-lived, user-created opaque bearer tokens
+	// for scripts, managed at /account/tokens. Users pick scopes from
+	// PersonalAccessTokenScopes and an expiry no later than the max lifetime.
+	EnablePersonalAccessTokens     bool          // env: ENABLE_PERSONAL_ACCESS_TOKENS (default: false)
+	PersonalAccessTokenMaxLifetime time.Duration // env: PERSONAL_ACCESS_TOKEN_MAX_LIFETIME (default: 8760h / 1y)
+	PersonalAccessTokenScopes      []string      // env: PERSONAL_ACCESS_TOKEN_SCOPES (default: read,write)
+
 	// Client Credentials Flow settings (RFC 6749 §4.4)
 	ClientCredentialsTokenExpiration time.Duration // Access token lifetime for client_credentials grant (default: 1h, same as JWTExpiration)
 
@@ -258,6 +480,15 @@ type Config struct {
 	GitLabOAuthRedirectURL string
 	GitLabOAuthScopes      []string
 
+	// Generic OpenID Connect providers, one per name in OIDC_PROVIDERS
+	OIDCProviders []OIDCProviderConfig
+
+	// SAML 2.0 identity providers, one per name in SAML_PROVIDERS. All of
+	// them share the service provider's signing certificate and key.
+	SAMLProviders  []SAMLProviderConfig
+	SAMLSPCertFile string
+	SAMLSPKeyFile  string
+
 	// OAuth Auto Registration
 	OAuthAutoRegister bool // Allow OAuth to auto-create accounts (default: true)
 
@@ -274,6 +505,8 @@ type Config struct {
 	TokenRateLimit           int // Requests per minute for /oauth/token (default: 20)
 	DeviceVerifyRateLimit    int // Requests per minute for /device/verify (default: 10)
 	IntrospectRateLimit      int // Requests per minute for /oauth/introspect (default: 20)
+	PasswordResetRateLimit   int // Requests per minute for /password/forgot and /password/reset (default: 5)
+	SignupRateLimit          int // Requests per minute for /signup and /signup/verify (default: 3)
 
 	// Redis settings (only used when RateLimitStore = "redis")
 	RedisAddr     string // Redis address for rate limiting (e.g., "localhost:6379")
@@ -335,6 +568,12 @@ type Config struct {
 	StrictRedirectURIs bool          // Require redirect URIs to be loopback or HTTPS (OAuth 2.1 §1.5 / MCP); default: false
 	ConsentRemember    bool          // Skip consent page if user already authorized same scope (default: true)
 
+	// Consent expiration: a consent lapses ConsentLifetime after it was
+	// granted (clients may override it), revoking its tokens and prompting
+	// the user again.
+	ConsentLifetime            time.Duration // CONSENT_LIFETIME: 0 means consent never expires (default: 0)
+	ConsentExpiryCheckInterval time.Duration // CONSENT_EXPIRY_CHECK_INTERVAL: how often lapsed consents are expired (default: 1h, 0 disables the job)
+
 	// CORS settings
 	CORSEnabled        bool          // Enable CORS for API endpoints (default: false)
 	CORSAllowedOrigins []string      // Allowed origins (comma-separated via env, e.g. "http://localhost:3000")
@@ -375,6 +614,9 @@ func Load() *Config {
 		dsn = getEnv("DATABASE_DSN", "")
 	}
 
+	baseURL := getEnv("BASE_URL", "http://localhost:8080")
+	authMode := getEnv("AUTH_MODE", AuthModeLocal)
+
 	// Resolve base JWT settings first — the "standard" profile inherits these,
 	// so the map must be built after the values are known.
 	jwtExpiration := getEnvDuration("JWT_EXPIRATION", 10*time.Hour)
@@ -383,6 +625,11 @@ func Load() *Config {
 		models.TokenProfileShort: {
 			AccessTokenTTL:  getEnvDuration("TOKEN_PROFILE_SHORT_ACCESS_TTL", 15*time.Minute),
 			RefreshTokenTTL: getEnvDuration("TOKEN_PROFILE_SHORT_REFRESH_TTL", 24*time.Hour),
+			RefreshIdleTimeout: getEnvDuration(
+				"TOKEN_PROFILE_SHORT_REFRESH_IDLE_TIMEOUT",
+				0,
+			),
+			MaxRefreshFamilies: getEnvInt("TOKEN_PROFILE_SHORT_MAX_REFRESH_FAMILIES", 0),
 		},
 		models.TokenProfileStandard: {
 			AccessTokenTTL: getEnvDuration("TOKEN_PROFILE_STANDARD_ACCESS_TTL", jwtExpiration),
@@ -390,6 +637,11 @@ func Load() *Config {
 				"TOKEN_PROFILE_STANDARD_REFRESH_TTL",
 				refreshTokenExpiration,
 			),
+			RefreshIdleTimeout: getEnvDuration(
+				"TOKEN_PROFILE_STANDARD_REFRESH_IDLE_TIMEOUT",
+				0,
+			),
+			MaxRefreshFamilies: getEnvInt("TOKEN_PROFILE_STANDARD_MAX_REFRESH_FAMILIES", 0),
 		},
 		models.TokenProfileLong: {
 			AccessTokenTTL: getEnvDuration("TOKEN_PROFILE_LONG_ACCESS_TTL", 24*time.Hour),
@@ -397,12 +649,17 @@ func Load() *Config {
 				"TOKEN_PROFILE_LONG_REFRESH_TTL",
 				2160*time.Hour,
 			), // 90 days
+			RefreshIdleTimeout: getEnvDuration(
+				"TOKEN_PROFILE_LONG_REFRESH_IDLE_TIMEOUT",
+				0,
+			),
+			MaxRefreshFamilies: getEnvInt("TOKEN_PROFILE_LONG_MAX_REFRESH_FAMILIES", 0),
 		},
 	}
 
 	return &Config{
 		ServerAddr:  getEnv("SERVER_ADDR", ":8080"),
-		BaseURL:     getEnv("BASE_URL", "http://localhost:8080"),
+		BaseURL:     baseURL,
 		TLSCertFile: getEnv("TLS_CERT_FILE", ""),
 		TLSKeyFile:  getEnv("TLS_KEY_FILE", ""),
 		IsProduction: getEnvBool("ENVIRONMENT", false) ||
@@ -430,8 +687,36 @@ func Load() *Config {
 		), // Disabled by default (dynamic IPs)
 		SessionRememberMeEnabled: getEnvBool("SESSION_REMEMBER_ME_ENABLED", true),
 		SessionRememberMeMaxAge:  getEnvInt("SESSION_REMEMBER_ME_MAX_AGE", 2592000), // 30 days
+		SessionRevokeOnLogout:    getEnvBool("SESSION_REVOKE_TOKENS_ON_LOGOUT", true),
+		TOTPIssuer:               getEnv("TOTP_ISSUER", "AuthGate"),
+		TOTPRequired:             strings.ToLower(strings.TrimSpace(getEnv("TOTP_REQUIRED", ""))),
+		TwoFactorFailureWindow:   getEnvDuration("TWO_FACTOR_FAILURE_WINDOW", 15*time.Minute),
+		TwoFactorMaxFailures:     getEnvInt("TWO_FACTOR_MAX_FAILURES", 10),
+		WebAuthnEnabled:          getEnvBool("WEBAUTHN_ENABLED", false),
+		WebAuthnRPID:             strings.ToLower(getEnv("WEBAUTHN_RP_ID", "")),
+		WebAuthnRPName:           getEnv("WEBAUTHN_RP_NAME", "AuthGate"),
+		WebAuthnOrigins:          getEnvSlice("WEBAUTHN_ORIGINS", nil),
+		PasswordResetEnabled:     getEnvBool("PASSWORD_RESET_ENABLED", false),
+		PasswordResetTokenTTL:    getEnvDuration("PASSWORD_RESET_TOKEN_TTL", 30*time.Minute),
+		PasswordResetMaxPerHour:  getEnvInt("PASSWORD_RESET_MAX_PER_HOUR", 3),
+		SignupEnabled:            getEnvBool("SIGNUP_ENABLED", false),
+		SignupEmailDomains:       normalizeEmailDomains(getEnvSlice("SIGNUP_ALLOWED_EMAIL_DOMAINS", nil)),
+		SignupRequireApproval:    getEnvBool("SIGNUP_REQUIRE_APPROVAL", false),
+		SignupVerificationTTL:    getEnvDuration("SIGNUP_VERIFICATION_TTL", 24*time.Hour),
+		EmailChangeEnabled:       getEnvBool("EMAIL_CHANGE_ENABLED", false),
+		EmailChangeTokenTTL:      getEnvDuration("EMAIL_CHANGE_TOKEN_TTL", 24*time.Hour),
+		MailDriver:               strings.ToLower(getEnv("MAIL_DRIVER", MailDriverLog)),
+		MailFrom:                 getEnv("MAIL_FROM", "AuthGate <noreply@localhost>"),
+		MailFileDir:              getEnv("MAIL_FILE_DIR", "mail"),
+		SMTPHost:                 getEnv("SMTP_HOST", ""),
+		SMTPPort:                 getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:             getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:             getEnv("SMTP_PASSWORD", ""),
+		SMTPTLS:                  strings.ToLower(getEnv("SMTP_TLS", SMTPTLSStartTLS)),
 		DeviceCodeExpiration:     30 * time.Minute,
 		PollingInterval:          5,
+		DeviceWaitTimeout:        getEnvDuration("DEVICE_WAIT_TIMEOUT", 30*time.Second),
+		DeviceNotifyStore:        getEnv("DEVICE_NOTIFY_STORE", DeviceNotifyStoreMemory),
 		DatabaseDriver:           driver,
 		DatabaseDSN:              dsn,
 		DBMaxOpenConns:           getEnvInt("DB_MAX_OPEN_CONNS", 25),
@@ -440,11 +725,18 @@ func Load() *Config {
 		DBConnMaxIdleTime:        getEnvDuration("DB_CONN_MAX_IDLE_TIME", 10*time.Minute),
 		DBLogLevel:               getEnv("DB_LOG_LEVEL", "warn"),
 
+		// Device verification brute-force protection
+		DeviceVerifyFailureWindow:      getEnvDuration("DEVICE_VERIFY_FAILURE_WINDOW", 15*time.Minute),
+		DeviceVerifyMaxSessionFailures: getEnvInt("DEVICE_VERIFY_MAX_SESSION_FAILURES", 5),
+		DeviceVerifyMaxUserFailures:    getEnvInt("DEVICE_VERIFY_MAX_USER_FAILURES", 15),
+		DeviceVerifyMaxGlobalFailures:  getEnvInt("DEVICE_VERIFY_MAX_GLOBAL_FAILURES", 200),
+
 		// Default Admin User
 		DefaultAdminPassword: getEnv("DEFAULT_ADMIN_PASSWORD", ""),
 
 		// Authentication
-		AuthMode: getEnv("AUTH_MODE", AuthModeLocal),
+		AuthMode:  authMode,
+		AuthChain: loadAuthChain(authMode),
 
 		// HTTP API Authentication
 		HTTPAPIURL:                getEnv("HTTP_API_URL", ""),
@@ -456,14 +748,62 @@ func Load() *Config {
 		HTTPAPIMaxRetries:         getEnvInt("HTTP_API_MAX_RETRIES", 3),
 		HTTPAPIRetryDelay:         getEnvDuration("HTTP_API_RETRY_DELAY", 1*time.Second),
 		HTTPAPIMaxRetryDelay:      getEnvDuration("HTTP_API_MAX_RETRY_DELAY", 10*time.Second),
+		HTTPAPIAdminRoles:         splitAndTrim(getEnv("HTTP_API_ADMIN_ROLES", ""), ","),
+		HTTPAPIAdminGroups:        splitAndTrim(getEnv("HTTP_API_ADMIN_GROUPS", ""), ","),
+
+		// LDAP Authentication
+		LDAPURL:                 getEnv("LDAP_URL", ""),
+		LDAPStartTLS:            getEnvBool("LDAP_START_TLS", false),
+		LDAPInsecureSkipVerify:  getEnvBool("LDAP_INSECURE_SKIP_VERIFY", false),
+		LDAPTimeout:             getEnvDuration("LDAP_TIMEOUT", 10*time.Second),
+		LDAPPoolSize:            getEnvInt("LDAP_POOL_SIZE", 5),
+		LDAPBindDN:              getEnv("LDAP_BIND_DN", ""),
+		LDAPBindPassword:        getEnv("LDAP_BIND_PASSWORD", ""),
+		LDAPUserDNTemplate:      getEnv("LDAP_USER_DN_TEMPLATE", ""),
+		LDAPUserBaseDN:          getEnv("LDAP_USER_BASE_DN", ""),
+		LDAPUserFilter:          getEnv("LDAP_USER_FILTER", "(uid=%s)"),
+		LDAPUsernameAttribute:   getEnv("LDAP_USERNAME_ATTRIBUTE", "uid"),
+		LDAPEmailAttribute:      getEnv("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		LDAPFullNameAttribute:   getEnv("LDAP_FULL_NAME_ATTRIBUTE", "cn"),
+		LDAPExternalIDAttribute: getEnv("LDAP_EXTERNAL_ID_ATTRIBUTE", ""),
+		LDAPMemberOfAttribute:   getEnv("LDAP_MEMBER_OF_ATTRIBUTE", "memberOf"),
+		LDAPGroupBaseDN:         getEnv("LDAP_GROUP_BASE_DN", ""),
+		LDAPGroupFilter:         getEnv("LDAP_GROUP_FILTER", ""),
+		// Group DNs contain commas, so the list is semicolon-separated.
+		LDAPAdminGroups: splitAndTrim(getEnv("LDAP_ADMIN_GROUPS", ""), ";"),
 
 		// Refresh Token settings
-		RefreshTokenExpiration:    refreshTokenExpiration,
-		EnableRefreshTokens:       getEnvBool("ENABLE_REFRESH_TOKENS", true),
-		EnableTokenRotation:       getEnvBool("ENABLE_TOKEN_ROTATION", false),
+		RefreshTokenExpiration: refreshTokenExpiration,
+		EnableRefreshTokens:    getEnvBool("ENABLE_REFRESH_TOKENS", true),
+		EnableTokenRotation:    getEnvBool("ENABLE_TOKEN_ROTATION", false),
+		RefreshTokenSlidingExpiration: getEnvBool(
+			"REFRESH_TOKEN_SLIDING_EXPIRATION",
+			false,
+		),
+		RefreshTokenReuseGracePeriod: getEnvDuration(
+			"REFRESH_TOKEN_REUSE_GRACE_PERIOD",
+			0,
+		),
 		JWTExpirationMax:          getEnvDuration("JWT_EXPIRATION_MAX", 24*time.Hour),
 		RefreshTokenExpirationMax: getEnvDuration("REFRESH_TOKEN_EXPIRATION_MAX", 2160*time.Hour),
 		TokenProfiles:             tokenProfiles,
+		TokenFormat:               getEnv("TOKEN_FORMAT", models.TokenFormatJWT),
+		MaxRefreshFamilies:        getEnvInt("MAX_REFRESH_FAMILIES", 0),
+		RefreshFamilyLimitPolicy: getEnv(
+			"REFRESH_FAMILY_LIMIT_POLICY",
+			models.RefreshFamilyPolicyEvictOldest,
+		),
+
+		// Personal access tokens
+		EnablePersonalAccessTokens: getEnvBool("ENABLE_PERSONAL_ACCESS_TOKENS", false),
+		PersonalAccessTokenMaxLifetime: getEnvDuration(
+			"PERSONAL_ACCESS_TOKEN_MAX_LIFETIME",
+			8760*time.Hour,
+		),
+		PersonalAccessTokenScopes: getEnvSlice(
+			"PERSONAL_ACCESS_TOKEN_SCOPES",
+			[]string{"read", "write"},
+		),
 
 		// Client Credentials Flow settings
 		ClientCredentialsTokenExpiration: getEnvDuration(
@@ -514,6 +854,14 @@ func Load() *Config {
 		GitLabOAuthRedirectURL: getEnv("GITLAB_REDIRECT_URL", ""),
 		GitLabOAuthScopes:      getEnvSlice("GITLAB_SCOPES", []string{"read_user"}),
 
+		// Generic OpenID Connect providers
+		OIDCProviders: loadOIDCProviders(baseURL),
+
+		// SAML identity providers
+		SAMLProviders:  loadSAMLProviders(baseURL),
+		SAMLSPCertFile: getEnv("SAML_SP_CERT_FILE", ""),
+		SAMLSPKeyFile:  getEnv("SAML_SP_KEY_FILE", ""),
+
 		// OAuth Auto Registration
 		OAuthAutoRegister: getEnvBool("OAUTH_AUTO_REGISTER", true),
 
@@ -530,6 +878,8 @@ func Load() *Config {
 		TokenRateLimit:           getEnvInt("TOKEN_RATE_LIMIT", 20),
 		DeviceVerifyRateLimit:    getEnvInt("DEVICE_VERIFY_RATE_LIMIT", 10),
 		IntrospectRateLimit:      getEnvInt("INTROSPECT_RATE_LIMIT", 20),
+		PasswordResetRateLimit:   getEnvInt("PASSWORD_RESET_RATE_LIMIT", 5),
+		SignupRateLimit:          getEnvInt("SIGNUP_RATE_LIMIT", 3),
 
 		// Redis settings
 		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -594,6 +944,10 @@ func Load() *Config {
 		StrictRedirectURIs: getEnvBool("STRICT_REDIRECT_URIS", false),
 		ConsentRemember:    getEnvBool("CONSENT_REMEMBER", true),
 
+		// Consent expiration
+		ConsentLifetime:            getEnvDuration("CONSENT_LIFETIME", 0),
+		ConsentExpiryCheckInterval: getEnvDuration("CONSENT_EXPIRY_CHECK_INTERVAL", time.Hour),
+
 		// Bootstrap and shutdown timeout settings
 		DBInitTimeout:         getEnvDuration("DB_INIT_TIMEOUT", 30*time.Second),
 		RedisConnTimeout:      getEnvDuration("REDIS_CONN_TIMEOUT", 5*time.Second),
@@ -622,6 +976,98 @@ func Load() *Config {
 	}
 }
 
+// loadAuthChain reads the AUTH_CHAIN_<NAME>_* settings of every backend
+// named in AUTH_CHAIN. Without AUTH_CHAIN the chain is the AUTH_MODE backend
+// followed by local accounts, which is how AUTH_MODE has always behaved.
+func loadAuthChain(authMode string) []AuthChainEntryConfig {
+	names := splitAndTrim(getEnv("AUTH_CHAIN", ""), ",")
+	if len(names) == 0 {
+		if !slices.Contains([]string{AuthModeLocal, AuthModeHTTPAPI, AuthModeLDAP}, authMode) {
+			return nil // Reported as an invalid AUTH_MODE at startup
+		}
+		names = []string{authMode}
+		if authMode != AuthModeLocal {
+			names = append(names, AuthModeLocal)
+		}
+	}
+	chain := make([]AuthChainEntryConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		prefix := "AUTH_CHAIN_" + strings.ToUpper(name) + "_"
+		// Each backend owns the users it created; external ones also create
+		// users on first login.
+		sources := []string{name}
+		if name != AuthModeLocal {
+			sources = append(sources, AuthChainSourceNew)
+		}
+		chain = append(chain, AuthChainEntryConfig{
+			Provider:          name,
+			UsernamePattern:   getEnv(prefix+"USERNAME_PATTERN", ""),
+			Sources:           getEnvSlice(prefix+"SOURCES", sources),
+			OnNotFound:        getEnv(prefix+"ON_NOT_FOUND", AuthChainContinue),
+			OnInvalidPassword: getEnv(prefix+"ON_INVALID_PASSWORD", AuthChainStop),
+			MigratePassword:   getEnvBool(prefix+"MIGRATE_PASSWORD", false),
+		})
+	}
+	return chain
+}
+
+// loadOIDCProviders reads the OIDC_<NAME>_* settings of every provider named
+// in OIDC_PROVIDERS.
+func loadOIDCProviders(baseURL string) []OIDCProviderConfig {
+	names := splitAndTrim(getEnv("OIDC_PROVIDERS", ""), ",")
+	providers := make([]OIDCProviderConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", ""),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL: getEnv(
+				prefix+"REDIRECT_URL",
+				strings.TrimSuffix(baseURL, "/")+"/auth/callback/"+name,
+			),
+			Scopes:             getEnvSlice(prefix+"SCOPES", []string{"openid", "profile", "email"}),
+			UsernameClaim:      getEnv(prefix+"USERNAME_CLAIM", "preferred_username"),
+			EmailClaim:         getEnv(prefix+"EMAIL_CLAIM", "email"),
+			NameClaim:          getEnv(prefix+"NAME_CLAIM", "name"),
+			AvatarClaim:        getEnv(prefix+"AVATAR_CLAIM", "picture"),
+			TrustEmailVerified: getEnvBool(prefix+"TRUST_EMAIL_VERIFIED", false),
+		})
+	}
+	return providers
+}
+
+// loadSAMLProviders reads the SAML_<NAME>_* settings of every provider named
+// in SAML_PROVIDERS.
+func loadSAMLProviders(baseURL string) []SAMLProviderConfig {
+	names := splitAndTrim(getEnv("SAML_PROVIDERS", ""), ",")
+	providers := make([]SAMLProviderConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		prefix := "SAML_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, SAMLProviderConfig{
+			Name:            name,
+			DisplayName:     getEnv(prefix+"DISPLAY_NAME", ""),
+			IDPMetadataURL:  getEnv(prefix+"IDP_METADATA_URL", ""),
+			IDPMetadataFile: getEnv(prefix+"IDP_METADATA_FILE", ""),
+			EntityID: getEnv(
+				prefix+"ENTITY_ID",
+				strings.TrimSuffix(baseURL, "/")+"/auth/metadata/"+name,
+			),
+			UserIDAttribute:   getEnv(prefix+"USER_ID_ATTRIBUTE", ""),
+			UsernameAttribute: getEnv(prefix+"USERNAME_ATTRIBUTE", "uid"),
+			EmailAttribute:    getEnv(prefix+"EMAIL_ATTRIBUTE", "mail"),
+			NameAttribute:     getEnv(prefix+"NAME_ATTRIBUTE", "displayName"),
+			TrustEmail:        getEnvBool(prefix+"TRUST_EMAIL", false),
+		})
+	}
+	return providers
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -674,6 +1120,16 @@ func splitAndTrim(s, sep string) []string {
 	return out
 }
 
+// normalizeEmailDomains lowercases domains and drops a leading "@", so
+// "@Example.com" and "example.com" are the same entry.
+func normalizeEmailDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		out = append(out, strings.ToLower(strings.TrimPrefix(d, "@")))
+	}
+	return out
+}
+
 // validateCacheType checks that value is a recognised cache type and, when
 // redis-based, that a Redis address has been configured.
 func validateCacheType(name, value, redisAddr string) error {
@@ -689,6 +1145,249 @@ func validateCacheType(name, value, redisAddr string) error {
 	return nil
 }
 
+// WebAuthnRelyingParty returns the passkey RP ID and allowed origins,
+// defaulting to the host and origin of BASE_URL.
+func (c *Config) WebAuthnRelyingParty() (string, []string) {
+	rpID, origins := c.WebAuthnRPID, c.WebAuthnOrigins
+	if u, err := url.Parse(c.BaseURL); err == nil {
+		if rpID == "" {
+			rpID = strings.ToLower(u.Hostname())
+		}
+		if len(origins) == 0 {
+			origins = []string{u.Scheme + "://" + strings.ToLower(u.Host)}
+		}
+	}
+	return rpID, origins
+}
+
+// validateWebAuthn checks that every passkey origin is served from the RP ID
+// or one of its subdomains, which browsers enforce as well.
+func (c *Config) validateWebAuthn() error {
+	rpID, origins := c.WebAuthnRelyingParty()
+	if rpID == "" {
+		return errors.New("WEBAUTHN_RP_ID is required when WEBAUTHN_ENABLED=true")
+	}
+	for _, origin := range origins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") ||
+			u.Path != "" {
+			return fmt.Errorf("WEBAUTHN_ORIGINS entry %q is not a scheme://host[:port] origin", origin)
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != rpID && !strings.HasSuffix(host, "."+rpID) {
+			return fmt.Errorf(
+				"WEBAUTHN_ORIGINS entry %q is not on WEBAUTHN_RP_ID %q or a subdomain of it",
+				origin, rpID,
+			)
+		}
+		// Browsers only allow WebAuthn in secure contexts.
+		if u.Scheme == "http" && host != "localhost" {
+			return fmt.Errorf("WEBAUTHN_ORIGINS entry %q must use https", origin)
+		}
+	}
+	return nil
+}
+
+// validateAuthChain checks the password backends of AUTH_CHAIN. The
+// settings of the http_api and ldap backends themselves are checked at
+// startup, alongside AUTH_MODE.
+func (c *Config) validateAuthChain() error {
+	backends := []string{AuthModeLocal, AuthModeHTTPAPI, AuthModeLDAP}
+	seen := make(map[string]bool, len(c.AuthChain))
+	for _, e := range c.AuthChain {
+		if !slices.Contains(backends, e.Provider) {
+			return fmt.Errorf(
+				"AUTH_CHAIN entry %q must be one of: local, http_api, ldap", e.Provider,
+			)
+		}
+		if seen[e.Provider] {
+			return fmt.Errorf("AUTH_CHAIN lists %q more than once", e.Provider)
+		}
+		seen[e.Provider] = true
+
+		prefix := "AUTH_CHAIN_" + strings.ToUpper(e.Provider) + "_"
+		if _, err := regexp.Compile(e.UsernamePattern); err != nil {
+			return fmt.Errorf("%sUSERNAME_PATTERN is not a valid regexp: %w", prefix, err)
+		}
+		if len(e.Sources) == 0 {
+			return fmt.Errorf("%sSOURCES must not be empty", prefix)
+		}
+		for _, source := range e.Sources {
+			if source != AuthChainSourceNew && !slices.Contains(backends, source) {
+				return fmt.Errorf(
+					"%sSOURCES entry %q must be one of: local, http_api, ldap, new",
+					prefix, source,
+				)
+			}
+		}
+		for _, v := range []struct{ name, value string }{
+			{"ON_NOT_FOUND", e.OnNotFound},
+			{"ON_INVALID_PASSWORD", e.OnInvalidPassword},
+		} {
+			if v.value != AuthChainContinue && v.value != AuthChainStop {
+				return fmt.Errorf(
+					"%s%s must be %q or %q (got %q)",
+					prefix, v.name, AuthChainContinue, AuthChainStop, v.value,
+				)
+			}
+		}
+		if e.MigratePassword && e.Provider == AuthModeLocal {
+			return fmt.Errorf("%sMIGRATE_PASSWORD only applies to external backends", prefix)
+		}
+	}
+	return nil
+}
+
+// validateOIDCProviders checks the generic OpenID Connect providers.
+func (c *Config) validateOIDCProviders() error {
+	seen := make(map[string]bool, len(c.OIDCProviders))
+	for _, p := range c.OIDCProviders {
+		if !oidcProviderNamePattern.MatchString(p.Name) {
+			return fmt.Errorf(
+				"OIDC_PROVIDERS entry %q must be 1-32 lowercase letters, digits or dashes",
+				p.Name,
+			)
+		}
+		if slices.Contains(builtinOAuthProviders, p.Name) {
+			return fmt.Errorf(
+				"OIDC_PROVIDERS entry %q clashes with the built-in provider of that name",
+				p.Name,
+			)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("OIDC_PROVIDERS lists %q more than once", p.Name)
+		}
+		seen[p.Name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_"
+		u, err := url.Parse(p.IssuerURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%sISSUER_URL must be an http(s) URL (got %q)", prefix, p.IssuerURL)
+		}
+		if p.ClientID == "" {
+			return fmt.Errorf("%sCLIENT_ID is required", prefix)
+		}
+		if !slices.Contains(p.Scopes, "openid") {
+			return fmt.Errorf("%sSCOPES must include \"openid\"", prefix)
+		}
+		if p.EmailClaim == "" {
+			return fmt.Errorf("%sEMAIL_CLAIM must not be empty", prefix)
+		}
+	}
+	return nil
+}
+
+// validateSAMLProviders checks the SAML identity providers. Names share the
+// /auth/login/:provider namespace with the OAuth and OIDC providers.
+func (c *Config) validateSAMLProviders() error {
+	if len(c.SAMLProviders) == 0 {
+		return nil
+	}
+	if c.SAMLSPCertFile == "" || c.SAMLSPKeyFile == "" {
+		return errors.New(
+			"SAML_SP_CERT_FILE and SAML_SP_KEY_FILE are required when SAML_PROVIDERS is set",
+		)
+	}
+	seen := make(map[string]bool, len(c.SAMLProviders))
+	for _, p := range c.OIDCProviders {
+		seen[p.Name] = true
+	}
+	for _, p := range c.SAMLProviders {
+		if !oidcProviderNamePattern.MatchString(p.Name) {
+			return fmt.Errorf(
+				"SAML_PROVIDERS entry %q must be 1-32 lowercase letters, digits or dashes",
+				p.Name,
+			)
+		}
+		if slices.Contains(builtinOAuthProviders, p.Name) {
+			return fmt.Errorf(
+				"SAML_PROVIDERS entry %q clashes with the built-in provider of that name",
+				p.Name,
+			)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf(
+				"SAML_PROVIDERS entry %q is listed twice or is also an OIDC provider", p.Name,
+			)
+		}
+		seen[p.Name] = true
+
+		prefix := "SAML_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_"
+		if (p.IDPMetadataURL == "") == (p.IDPMetadataFile == "") {
+			return fmt.Errorf(
+				"exactly one of %sIDP_METADATA_URL and %sIDP_METADATA_FILE must be set",
+				prefix, prefix,
+			)
+		}
+		if p.IDPMetadataURL != "" {
+			u, err := url.Parse(p.IDPMetadataURL)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf(
+					"%sIDP_METADATA_URL must be an http(s) URL (got %q)",
+					prefix, p.IDPMetadataURL,
+				)
+			}
+		}
+		if p.EmailAttribute == "" {
+			return fmt.Errorf("%sEMAIL_ATTRIBUTE must not be empty", prefix)
+		}
+	}
+	return nil
+}
+
+// validateMail checks the outgoing email settings.
+func (c *Config) validateMail() error {
+	if _, err := mail.ParseAddress(c.MailFrom); err != nil {
+		return fmt.Errorf("MAIL_FROM %q is not a valid address: %w", c.MailFrom, err)
+	}
+	switch c.MailDriver {
+	case MailDriverLog, MailDriverFile:
+	case MailDriverSMTP:
+		if c.SMTPHost == "" {
+			return errors.New("SMTP_HOST is required when MAIL_DRIVER=smtp")
+		}
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			return fmt.Errorf("SMTP_PORT must be between 1 and 65535 (got %d)", c.SMTPPort)
+		}
+		switch c.SMTPTLS {
+		case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+		default:
+			return fmt.Errorf(
+				"SMTP_TLS must be %q, %q or %q (got %q)",
+				SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone, c.SMTPTLS,
+			)
+		}
+	default:
+		return fmt.Errorf(
+			"MAIL_DRIVER must be %q, %q or %q (got %q)",
+			MailDriverLog, MailDriverFile, MailDriverSMTP, c.MailDriver,
+		)
+	}
+	return nil
+}
+
+// RefreshIdleTimeouts returns the refresh idle timeout of every configured
+// token profile, keyed by profile name. A zero timeout disables the check
+// for that profile.
+func (c *Config) RefreshIdleTimeouts() map[string]time.Duration {
+	out := make(map[string]time.Duration, len(c.TokenProfiles))
+	for name, profile := range c.TokenProfiles {
+		out[name] = profile.RefreshIdleTimeout
+	}
+	return out
+}
+
+// HasRefreshIdleTimeout reports whether any token profile enables the
+// refresh idle timeout, i.e. whether idle cleanup has anything to do.
+func (c *Config) HasRefreshIdleTimeout() bool {
+	for _, profile := range c.TokenProfiles {
+		if profile.RefreshIdleTimeout > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // Validate checks the configuration for invalid values
 func (c *Config) Validate() error {
 	// Validate JWT expiration
@@ -789,6 +1488,56 @@ func (c *Config) Validate() error {
 		)
 	}
 
+	if c.DeviceWaitTimeout < 0 || c.DeviceWaitTimeout > maxDeviceWaitTimeout {
+		return fmt.Errorf(
+			"DEVICE_WAIT_TIMEOUT must be between 0 and %s, got %s",
+			maxDeviceWaitTimeout, c.DeviceWaitTimeout,
+		)
+	}
+
+	if c.DeviceNotifyStore != DeviceNotifyStoreMemory &&
+		c.DeviceNotifyStore != DeviceNotifyStoreRedis {
+		return fmt.Errorf(
+			"invalid DEVICE_NOTIFY_STORE value: %q (must be %q or %q)",
+			c.DeviceNotifyStore,
+			DeviceNotifyStoreMemory,
+			DeviceNotifyStoreRedis,
+		)
+	}
+	if c.DeviceNotifyStore == DeviceNotifyStoreRedis && c.RedisAddr == "" {
+		return errors.New("DEVICE_NOTIFY_STORE=redis requires REDIS_ADDR")
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"DEVICE_VERIFY_MAX_SESSION_FAILURES", c.DeviceVerifyMaxSessionFailures},
+		{"DEVICE_VERIFY_MAX_USER_FAILURES", c.DeviceVerifyMaxUserFailures},
+		{"DEVICE_VERIFY_MAX_GLOBAL_FAILURES", c.DeviceVerifyMaxGlobalFailures},
+	} {
+		if limit.value < 0 {
+			return fmt.Errorf("%s must be 0 (disabled) or positive, got %d", limit.name, limit.value)
+		}
+	}
+	if c.DeviceVerifyFailureWindow <= 0 && (c.DeviceVerifyMaxSessionFailures > 0 ||
+		c.DeviceVerifyMaxUserFailures > 0 || c.DeviceVerifyMaxGlobalFailures > 0) {
+		return errors.New("DEVICE_VERIFY_FAILURE_WINDOW must be positive when a device verification threshold is set")
+	}
+
+	if c.ConsentLifetime < 0 {
+		return fmt.Errorf(
+			"CONSENT_LIFETIME must be 0 (never expires) or positive, got %s",
+			c.ConsentLifetime,
+		)
+	}
+	if c.ConsentExpiryCheckInterval < 0 {
+		return fmt.Errorf(
+			"CONSENT_EXPIRY_CHECK_INTERVAL must be 0 (disabled) or positive, got %s",
+			c.ConsentExpiryCheckInterval,
+		)
+	}
+
 	if err := validateCacheType("METRICS_CACHE_TYPE", c.MetricsCacheType, c.RedisAddr); err != nil {
 		return err
 	}
@@ -883,6 +1632,141 @@ func (c *Config) Validate() error {
 		)
 	}
 
+	switch c.TOTPRequired {
+	case TwoFactorRequiredNone, TwoFactorRequiredAdmins, TwoFactorRequiredAll:
+	default:
+		return fmt.Errorf(
+			"TOTP_REQUIRED must be empty, %q or %q (got %q)",
+			TwoFactorRequiredAdmins, TwoFactorRequiredAll, c.TOTPRequired,
+		)
+	}
+	if c.TwoFactorMaxFailures < 0 {
+		return fmt.Errorf(
+			"TWO_FACTOR_MAX_FAILURES must be 0 (disabled) or positive, got %d",
+			c.TwoFactorMaxFailures,
+		)
+	}
+	if c.TwoFactorMaxFailures > 0 && c.TwoFactorFailureWindow <= 0 {
+		return errors.New("TWO_FACTOR_FAILURE_WINDOW must be positive when TWO_FACTOR_MAX_FAILURES is set")
+	}
+
+	if c.WebAuthnEnabled {
+		if err := c.validateWebAuthn(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.validateAuthChain(); err != nil {
+		return err
+	}
+
+	if err := c.validateOIDCProviders(); err != nil {
+		return err
+	}
+
+	if err := c.validateSAMLProviders(); err != nil {
+		return err
+	}
+
+	if c.PasswordResetEnabled {
+		if c.PasswordResetTokenTTL <= 0 || c.PasswordResetTokenTTL > 24*time.Hour {
+			return fmt.Errorf(
+				"PASSWORD_RESET_TOKEN_TTL must be between 0 and 24h (got %s)",
+				c.PasswordResetTokenTTL,
+			)
+		}
+		if c.PasswordResetMaxPerHour <= 0 {
+			return fmt.Errorf(
+				"PASSWORD_RESET_MAX_PER_HOUR must be positive (got %d)",
+				c.PasswordResetMaxPerHour,
+			)
+		}
+		if err := c.validateMail(); err != nil {
+			return err
+		}
+	}
+
+	if c.SignupEnabled {
+		if c.SignupVerificationTTL <= 0 || c.SignupVerificationTTL > 7*24*time.Hour {
+			return fmt.Errorf(
+				"SIGNUP_VERIFICATION_TTL must be between 0 and 168h (got %s)",
+				c.SignupVerificationTTL,
+			)
+		}
+		for _, domain := range c.SignupEmailDomains {
+			if strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+				return fmt.Errorf(
+					"SIGNUP_ALLOWED_EMAIL_DOMAINS entry %q is not a domain name", domain,
+				)
+			}
+		}
+		if err := c.validateMail(); err != nil {
+			return err
+		}
+	}
+
+	if c.EmailChangeEnabled {
+		if c.EmailChangeTokenTTL <= 0 || c.EmailChangeTokenTTL > 7*24*time.Hour {
+			return fmt.Errorf(
+				"EMAIL_CHANGE_TOKEN_TTL must be between 0 and 168h (got %s)",
+				c.EmailChangeTokenTTL,
+			)
+		}
+		if err := c.validateMail(); err != nil {
+			return err
+		}
+	}
+
+	// The reuse grace window exists to absorb network retries; anything much
+	// longer would let a stolen, already-rotated refresh token keep working.
+	if c.RefreshTokenReuseGracePeriod < 0 ||
+		c.RefreshTokenReuseGracePeriod > maxRefreshTokenReuseGracePeriod {
+		return fmt.Errorf(
+			"REFRESH_TOKEN_REUSE_GRACE_PERIOD must be between 0 and %s (got %s)",
+			maxRefreshTokenReuseGracePeriod, c.RefreshTokenReuseGracePeriod,
+		)
+	}
+
+	if c.EnablePersonalAccessTokens {
+		if c.PersonalAccessTokenMaxLifetime <= 0 {
+			return fmt.Errorf(
+				"PERSONAL_ACCESS_TOKEN_MAX_LIFETIME must be positive when ENABLE_PERSONAL_ACCESS_TOKENS=true (got %s)",
+				c.PersonalAccessTokenMaxLifetime,
+			)
+		}
+		if len(c.PersonalAccessTokenScopes) == 0 {
+			return errors.New(
+				"PERSONAL_ACCESS_TOKEN_SCOPES must list at least one scope when ENABLE_PERSONAL_ACCESS_TOKENS=true",
+			)
+		}
+	}
+
+	// An empty TokenFormat (hand-built Config) means "jwt"; anything else
+	// must be a recognised format so a typo cannot silently fall back.
+	if c.TokenFormat != "" && !models.IsValidTokenFormat(c.TokenFormat) {
+		return fmt.Errorf(
+			"invalid TOKEN_FORMAT value: %q (must be %q or %q)",
+			c.TokenFormat, models.TokenFormatJWT, models.TokenFormatOpaque,
+		)
+	}
+
+	if c.MaxRefreshFamilies < 0 {
+		return fmt.Errorf(
+			"MAX_REFRESH_FAMILIES must be >= 0 (got %d; 0 = unlimited)",
+			c.MaxRefreshFamilies,
+		)
+	}
+	// Empty (hand-built Config) means evict_oldest, as for TokenFormat.
+	if c.RefreshFamilyLimitPolicy != "" &&
+		!models.IsValidRefreshFamilyPolicy(c.RefreshFamilyLimitPolicy) {
+		return fmt.Errorf(
+			"invalid REFRESH_FAMILY_LIMIT_POLICY value: %q (must be %q or %q)",
+			c.RefreshFamilyLimitPolicy,
+			models.RefreshFamilyPolicyEvictOldest,
+			models.RefreshFamilyPolicyReject,
+		)
+	}
+
 	return c.validateTokenProfiles()
 }
 
@@ -999,6 +1883,18 @@ func (c *Config) validateTokenProfiles() error {
 				name, profile.RefreshTokenTTL,
 			)
 		}
+		if profile.RefreshIdleTimeout < 0 {
+			return fmt.Errorf(
+				"token profile %q refresh idle timeout must not be negative (got %s)",
+				name, profile.RefreshIdleTimeout,
+			)
+		}
+		if profile.MaxRefreshFamilies < 0 {
+			return fmt.Errorf(
+				"token profile %q max refresh families must not be negative (got %d)",
+				name, profile.MaxRefreshFamilies,
+			)
+		}
 		if profile.AccessTokenTTL > c.JWTExpirationMax {
 			return fmt.Errorf(
 				"token profile %q access TTL %s exceeds JWT_EXPIRATION_MAX %s",
@@ -47,6 +47,7 @@ func validBaseConfig() Config {
 		JWTSecret:             "test-secret-that-is-at-least-32b",
 		JWTExpiration:         time.Hour,
 		RateLimitStore:        RateLimitStoreMemory,
+		DeviceNotifyStore:     DeviceNotifyStoreMemory,
 		MetricsCacheType:      CacheTypeMemory,
 		UserCacheType:         CacheTypeMemory,
 		UserCacheTTL:          5 * time.Minute,
@@ -893,6 +894,8 @@ func TestAuthCodeFlowConfigDefaults(t *testing.T) {
 	assert.Equal(t, 10*time.Minute, cfg.AuthCodeExpiration)
 	assert.False(t, cfg.PKCERequired)
 	assert.True(t, cfg.ConsentRemember)
+	assert.Zero(t, cfg.ConsentLifetime)
+	assert.Equal(t, time.Hour, cfg.ConsentExpiryCheckInterval)
 }
 
 func TestConfig_Validate_JWTSecretMinLength(t *testing.T) {
@@ -1141,3 +1144,865 @@ func TestValidate_TLSPartialConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_TokenFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		expectErr bool
+	}{
+		{"empty defaults to jwt", "", false},
+		{"jwt passes", models.TokenFormatJWT, false},
+		{"opaque passes", models.TokenFormatOpaque, false},
+		{"unknown value fails", "paseto", true},
+		{"wrong case fails", "JWT", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.TokenFormat = tt.format
+			err := cfg.Validate()
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "TOKEN_FORMAT")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TokenProfileNegativeIdleTimeout(t *testing.T) {
+	cfg := validBaseConfig()
+	cfg.JWTExpirationMax = 24 * time.Hour
+	cfg.RefreshTokenExpirationMax = 2160 * time.Hour
+	cfg.TokenProfiles = map[string]TokenProfile{
+		models.TokenProfileShort: {
+			AccessTokenTTL:     15 * time.Minute,
+			RefreshTokenTTL:    24 * time.Hour,
+			RefreshIdleTimeout: -time.Hour,
+		},
+		models.TokenProfileStandard: {AccessTokenTTL: time.Hour, RefreshTokenTTL: 720 * time.Hour},
+		models.TokenProfileLong:     {AccessTokenTTL: 8 * time.Hour, RefreshTokenTTL: 2160 * time.Hour},
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"short" refresh idle timeout must not be negative`)
+}
+
+func TestRefreshIdleTimeouts(t *testing.T) {
+	cfg := &Config{TokenProfiles: map[string]TokenProfile{
+		models.TokenProfileShort:    {RefreshIdleTimeout: 12 * time.Hour},
+		models.TokenProfileStandard: {},
+		models.TokenProfileLong:     {RefreshIdleTimeout: 14 * 24 * time.Hour},
+	}}
+	assert.Equal(t, map[string]time.Duration{
+		models.TokenProfileShort:    12 * time.Hour,
+		models.TokenProfileStandard: 0,
+		models.TokenProfileLong:     14 * 24 * time.Hour,
+	}, cfg.RefreshIdleTimeouts())
+	assert.True(t, cfg.HasRefreshIdleTimeout())
+
+	cfg.TokenProfiles = map[string]TokenProfile{models.TokenProfileStandard: {}}
+	assert.False(t, cfg.HasRefreshIdleTimeout())
+	assert.False(t, (&Config{}).HasRefreshIdleTimeout())
+}
+
+func TestValidate_RefreshTokenReuseGracePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		grace     time.Duration
+		expectErr bool
+	}{
+		{"disabled", 0, false},
+		{"typical retry window", 10 * time.Second, false},
+		{"upper bound", 5 * time.Minute, false},
+		{"negative", -time.Second, true},
+		{"too long", 10 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.RefreshTokenReuseGracePeriod = tt.grace
+			err := cfg.Validate()
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "REFRESH_TOKEN_REUSE_GRACE_PERIOD")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_PersonalAccessTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		maxLifetime time.Duration
+		scopes      []string
+		errContains string
+	}{
+		{"disabled ignores settings", false, 0, nil, ""},
+		{"enabled with defaults", true, 8760 * time.Hour, []string{"read", "write"}, ""},
+		{"zero lifetime", true, 0, []string{"read"}, "PERSONAL_ACCESS_TOKEN_MAX_LIFETIME"},
+		{"no scopes", true, time.Hour, nil, "PERSONAL_ACCESS_TOKEN_SCOPES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.EnablePersonalAccessTokens = tt.enabled
+			cfg.PersonalAccessTokenMaxLifetime = tt.maxLifetime
+			cfg.PersonalAccessTokenScopes = tt.scopes
+			err := cfg.Validate()
+			if tt.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_RefreshFamilyLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		policy  string
+		wantErr string
+	}{
+		{"unlimited passes", 0, "", ""},
+		{"evict_oldest passes", 5, models.RefreshFamilyPolicyEvictOldest, ""},
+		{"reject passes", 5, models.RefreshFamilyPolicyReject, ""},
+		{"negative limit rejected", -1, "", "MAX_REFRESH_FAMILIES"},
+		{"unknown policy rejected", 5, "drop_newest", "REFRESH_FAMILY_LIMIT_POLICY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.MaxRefreshFamilies = tt.limit
+			cfg.RefreshFamilyLimitPolicy = tt.policy
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_DeviceWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		store   string
+		addr    string
+		wantErr string
+	}{
+		{"disabled passes", 0, DeviceNotifyStoreMemory, "", ""},
+		{"memory passes", 30 * time.Second, DeviceNotifyStoreMemory, "", ""},
+		{"redis passes", 30 * time.Second, DeviceNotifyStoreRedis, "localhost:6379", ""},
+		{"negative timeout rejected", -time.Second, DeviceNotifyStoreMemory, "", "DEVICE_WAIT_TIMEOUT"},
+		{"timeout above max rejected", 10 * time.Minute, DeviceNotifyStoreMemory, "", "DEVICE_WAIT_TIMEOUT"},
+		{"unknown store rejected", 30 * time.Second, "kafka", "", "DEVICE_NOTIFY_STORE"},
+		{"redis without address rejected", 30 * time.Second, DeviceNotifyStoreRedis, "", "REDIS_ADDR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.DeviceWaitTimeout = tt.timeout
+			cfg.DeviceNotifyStore = tt.store
+			cfg.RedisAddr = tt.addr
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_DeviceVerifyGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"disabled passes", func(c *Config) {}, ""},
+		{"thresholds with window pass", func(c *Config) {
+			c.DeviceVerifyFailureWindow = 15 * time.Minute
+			c.DeviceVerifyMaxSessionFailures = 5
+		}, ""},
+		{"negative threshold rejected", func(c *Config) {
+			c.DeviceVerifyFailureWindow = 15 * time.Minute
+			c.DeviceVerifyMaxUserFailures = -1
+		}, "DEVICE_VERIFY_MAX_USER_FAILURES"},
+		{"threshold without window rejected", func(c *Config) {
+			c.DeviceVerifyMaxGlobalFailures = 100
+		}, "DEVICE_VERIFY_FAILURE_WINDOW"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ConsentLifetime(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"never expires passes", func(c *Config) {}, ""},
+		{"lifetime passes", func(c *Config) {
+			c.ConsentLifetime = 90 * 24 * time.Hour
+			c.ConsentExpiryCheckInterval = time.Hour
+		}, ""},
+		{"negative lifetime rejected", func(c *Config) {
+			c.ConsentLifetime = -time.Hour
+		}, "CONSENT_LIFETIME"},
+		{"negative interval rejected", func(c *Config) {
+			c.ConsentExpiryCheckInterval = -time.Minute
+		}, "CONSENT_EXPIRY_CHECK_INTERVAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TOTPRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"not required", TwoFactorRequiredNone, false},
+		{"admins", TwoFactorRequiredAdmins, false},
+		{"all", TwoFactorRequiredAll, false},
+		{"unknown value", "everyone", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.TOTPRequired = tt.value
+			err := cfg.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "TOTP_REQUIRED")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TwoFactorGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"disabled passes", func(c *Config) {}, ""},
+		{"limit with window passes", func(c *Config) {
+			c.TwoFactorFailureWindow = 15 * time.Minute
+			c.TwoFactorMaxFailures = 10
+		}, ""},
+		{"negative limit rejected", func(c *Config) {
+			c.TwoFactorFailureWindow = 15 * time.Minute
+			c.TwoFactorMaxFailures = -1
+		}, "TWO_FACTOR_MAX_FAILURES"},
+		{"limit without window rejected", func(c *Config) {
+			c.TwoFactorMaxFailures = 10
+		}, "TWO_FACTOR_FAILURE_WINDOW"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_WebAuthn(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		rpID    string
+		origins []string
+		wantErr string
+	}{
+		{name: "defaults from BASE_URL", baseURL: "https://auth.example.com"},
+		{name: "localhost over http", baseURL: "http://localhost:8080"},
+		{
+			name:    "parent domain RP ID",
+			baseURL: "https://auth.example.com",
+			rpID:    "example.com",
+		},
+		{
+			name:    "extra subdomain origin",
+			baseURL: "https://auth.example.com",
+			rpID:    "example.com",
+			origins: []string{"https://auth.example.com", "https://login.example.com"},
+		},
+		{
+			name:    "origin outside RP ID",
+			baseURL: "https://auth.example.com",
+			origins: []string{"https://auth.example.org"},
+			wantErr: "not on WEBAUTHN_RP_ID",
+		},
+		{
+			name:    "suffix without dot boundary",
+			baseURL: "https://badexample.com",
+			rpID:    "example.com",
+			wantErr: "not on WEBAUTHN_RP_ID",
+		},
+		{
+			name:    "plain http off localhost",
+			baseURL: "http://auth.example.com",
+			wantErr: "must use https",
+		},
+		{
+			name:    "origin with path",
+			baseURL: "https://auth.example.com",
+			origins: []string{"https://auth.example.com/login"},
+			wantErr: "is not a scheme://host[:port] origin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.BaseURL = tt.baseURL
+			cfg.WebAuthnEnabled = true
+			cfg.WebAuthnRPID = tt.rpID
+			cfg.WebAuthnOrigins = tt.origins
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWebAuthnRelyingParty_Defaults(t *testing.T) {
+	cfg := &Config{BaseURL: "https://Auth.Example.com:8443/base"}
+	rpID, origins := cfg.WebAuthnRelyingParty()
+	assert.Equal(t, "auth.example.com", rpID)
+	assert.Equal(t, []string{"https://auth.example.com:8443"}, origins)
+}
+
+func TestValidate_PasswordReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "log driver", modify: func(*Config) {}},
+		{
+			name: "smtp driver",
+			modify: func(c *Config) {
+				c.MailDriver = MailDriverSMTP
+				c.SMTPHost = "smtp.example.com"
+			},
+		},
+		{
+			name:    "zero token TTL",
+			modify:  func(c *Config) { c.PasswordResetTokenTTL = 0 },
+			wantErr: "PASSWORD_RESET_TOKEN_TTL",
+		},
+		{
+			name:    "zero per-hour limit",
+			modify:  func(c *Config) { c.PasswordResetMaxPerHour = 0 },
+			wantErr: "PASSWORD_RESET_MAX_PER_HOUR",
+		},
+		{
+			name:    "invalid sender",
+			modify:  func(c *Config) { c.MailFrom = "not an address" },
+			wantErr: "MAIL_FROM",
+		},
+		{
+			name:    "unknown driver",
+			modify:  func(c *Config) { c.MailDriver = "sendmail" },
+			wantErr: "MAIL_DRIVER",
+		},
+		{
+			name:    "smtp without host",
+			modify:  func(c *Config) { c.MailDriver = MailDriverSMTP },
+			wantErr: "SMTP_HOST",
+		},
+		{
+			name: "unknown smtp tls mode",
+			modify: func(c *Config) {
+				c.MailDriver = MailDriverSMTP
+				c.SMTPHost = "smtp.example.com"
+				c.SMTPTLS = "ssl"
+			},
+			wantErr: "SMTP_TLS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.PasswordResetEnabled = true
+			cfg.PasswordResetTokenTTL = 30 * time.Minute
+			cfg.PasswordResetMaxPerHour = 3
+			cfg.MailDriver = MailDriverLog
+			cfg.MailFrom = "AuthGate <noreply@example.com>"
+			cfg.SMTPPort = 587
+			cfg.SMTPTLS = SMTPTLSStartTLS
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Signup(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "any domain", modify: func(*Config) {}},
+		{
+			name:   "allowed domains",
+			modify: func(c *Config) { c.SignupEmailDomains = []string{"example.com", "corp.example.org"} },
+		},
+		{
+			name:    "zero verification TTL",
+			modify:  func(c *Config) { c.SignupVerificationTTL = 0 },
+			wantErr: "SIGNUP_VERIFICATION_TTL",
+		},
+		{
+			name:    "verification TTL over a week",
+			modify:  func(c *Config) { c.SignupVerificationTTL = 8 * 24 * time.Hour },
+			wantErr: "SIGNUP_VERIFICATION_TTL",
+		},
+		{
+			name:    "address instead of domain",
+			modify:  func(c *Config) { c.SignupEmailDomains = []string{"admin@example.com"} },
+			wantErr: "SIGNUP_ALLOWED_EMAIL_DOMAINS",
+		},
+		{
+			name:    "mail settings checked",
+			modify:  func(c *Config) { c.MailDriver = "sendmail" },
+			wantErr: "MAIL_DRIVER",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validBaseConfig()
+			cfg.SignupEnabled = true
+			cfg.SignupVerificationTTL = 24 * time.Hour
+			cfg.MailDriver = MailDriverLog
+			cfg.MailFrom = "AuthGate <noreply@example.com>"
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmailDomains(t *testing.T) {
+	assert.Equal(t,
+		[]string{"example.com", "corp.example.org"},
+		normalizeEmailDomains([]string{"@Example.COM", "corp.example.org"}),
+	)
+	assert.Empty(t, normalizeEmailDomains(nil))
+}
+
+func TestValidate_EmailChange(t *testing.T) {
+	cfg := validBaseConfig()
+	cfg.EmailChangeEnabled = true
+	cfg.EmailChangeTokenTTL = 24 * time.Hour
+	cfg.MailDriver = MailDriverLog
+	cfg.MailFrom = "AuthGate <noreply@example.com>"
+	require.NoError(t, cfg.Validate())
+
+	cfg.EmailChangeTokenTTL = 0
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EMAIL_CHANGE_TOKEN_TTL")
+
+	cfg.EmailChangeTokenTTL = time.Hour
+	cfg.MailDriver = MailDriverSMTP
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SMTP_HOST")
+}
+
+func TestLoad_OIDCProviders(t *testing.T) {
+	t.Setenv("BASE_URL", "https://auth.example.com/")
+	t.Setenv("OIDC_PROVIDERS", "Google, company-sso")
+	t.Setenv("OIDC_GOOGLE_ISSUER_URL", "https://accounts.google.com")
+	t.Setenv("OIDC_COMPANY_SSO_CLIENT_ID", "authgate")
+	t.Setenv("OIDC_COMPANY_SSO_EMAIL_CLAIM", "upn")
+
+	cfg := Load()
+	require.Len(t, cfg.OIDCProviders, 2)
+	google := cfg.OIDCProviders[0]
+	assert.Equal(t, "google", google.Name)
+	assert.Equal(t, "https://accounts.google.com", google.IssuerURL)
+	assert.Equal(t, "https://auth.example.com/auth/callback/google", google.RedirectURL)
+	assert.Equal(t, []string{"openid", "profile", "email"}, google.Scopes)
+	assert.Equal(t, "preferred_username", google.UsernameClaim)
+	assert.Equal(t, "email", google.EmailClaim)
+
+	sso := cfg.OIDCProviders[1]
+	assert.Equal(t, "company-sso", sso.Name)
+	assert.Equal(t, "authgate", sso.ClientID)
+	assert.Equal(t, "upn", sso.EmailClaim)
+}
+
+func TestValidate_OIDCProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*OIDCProviderConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*OIDCProviderConfig) {}},
+		{
+			name:    "invalid name",
+			modify:  func(p *OIDCProviderConfig) { p.Name = "company sso" },
+			wantErr: "OIDC_PROVIDERS",
+		},
+		{
+			name:    "built-in name",
+			modify:  func(p *OIDCProviderConfig) { p.Name = "github" },
+			wantErr: "built-in",
+		},
+		{
+			name:    "relative issuer",
+			modify:  func(p *OIDCProviderConfig) { p.IssuerURL = "/realms/main" },
+			wantErr: "OIDC_KEYCLOAK_ISSUER_URL",
+		},
+		{
+			name:    "missing client ID",
+			modify:  func(p *OIDCProviderConfig) { p.ClientID = "" },
+			wantErr: "OIDC_KEYCLOAK_CLIENT_ID",
+		},
+		{
+			name:    "no openid scope",
+			modify:  func(p *OIDCProviderConfig) { p.Scopes = []string{"profile", "email"} },
+			wantErr: "OIDC_KEYCLOAK_SCOPES",
+		},
+		{
+			name:    "empty email claim",
+			modify:  func(p *OIDCProviderConfig) { p.EmailClaim = "" },
+			wantErr: "OIDC_KEYCLOAK_EMAIL_CLAIM",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := OIDCProviderConfig{
+				Name:       "keycloak",
+				IssuerURL:  "https://sso.example.com/realms/main",
+				ClientID:   "authgate",
+				Scopes:     []string{"openid", "profile", "email"},
+				EmailClaim: "email",
+			}
+			tt.modify(&provider)
+			cfg := validBaseConfig()
+			cfg.OIDCProviders = []OIDCProviderConfig{provider}
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("duplicate name", func(t *testing.T) {
+		provider := OIDCProviderConfig{
+			Name:       "keycloak",
+			IssuerURL:  "https://sso.example.com/realms/main",
+			ClientID:   "authgate",
+			Scopes:     []string{"openid"},
+			EmailClaim: "email",
+		}
+		cfg := validBaseConfig()
+		cfg.OIDCProviders = []OIDCProviderConfig{provider, provider}
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than once")
+	})
+}
+
+func TestLoad_SAMLProviders(t *testing.T) {
+	t.Setenv("BASE_URL", "https://auth.example.com/")
+	t.Setenv("SAML_PROVIDERS", "Okta, corp-adfs")
+	t.Setenv("SAML_SP_CERT_FILE", "/etc/authgate/saml.crt")
+	t.Setenv("SAML_SP_KEY_FILE", "/etc/authgate/saml.key")
+	t.Setenv("SAML_OKTA_IDP_METADATA_URL", "https://example.okta.com/app/abc/sso/saml/metadata")
+	t.Setenv("SAML_CORP_ADFS_IDP_METADATA_FILE", "/etc/authgate/adfs.xml")
+	t.Setenv("SAML_CORP_ADFS_ENTITY_ID", "urn:authgate")
+	t.Setenv("SAML_CORP_ADFS_EMAIL_ATTRIBUTE", "http://schemas.xmlsoap.org/claims/EmailAddress")
+	t.Setenv("SAML_CORP_ADFS_TRUST_EMAIL", "true")
+
+	cfg := Load()
+	assert.Equal(t, "/etc/authgate/saml.crt", cfg.SAMLSPCertFile)
+	assert.Equal(t, "/etc/authgate/saml.key", cfg.SAMLSPKeyFile)
+	require.Len(t, cfg.SAMLProviders, 2)
+	okta := cfg.SAMLProviders[0]
+	assert.Equal(t, "okta", okta.Name)
+	assert.Equal(t, "https://auth.example.com/auth/metadata/okta", okta.EntityID)
+	assert.Equal(t, "uid", okta.UsernameAttribute)
+	assert.Equal(t, "mail", okta.EmailAttribute)
+	assert.Equal(t, "displayName", okta.NameAttribute)
+	assert.Empty(t, okta.UserIDAttribute)
+	assert.False(t, okta.TrustEmail)
+
+	adfs := cfg.SAMLProviders[1]
+	assert.Equal(t, "corp-adfs", adfs.Name)
+	assert.Equal(t, "/etc/authgate/adfs.xml", adfs.IDPMetadataFile)
+	assert.Equal(t, "urn:authgate", adfs.EntityID)
+	assert.Equal(t, "http://schemas.xmlsoap.org/claims/EmailAddress", adfs.EmailAttribute)
+	assert.True(t, adfs.TrustEmail)
+}
+
+func TestValidate_SAMLProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config, *SAMLProviderConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*Config, *SAMLProviderConfig) {}},
+		{
+			name:    "missing key pair",
+			modify:  func(c *Config, _ *SAMLProviderConfig) { c.SAMLSPKeyFile = "" },
+			wantErr: "SAML_SP_KEY_FILE",
+		},
+		{
+			name:    "invalid name",
+			modify:  func(_ *Config, p *SAMLProviderConfig) { p.Name = "Okta SSO" },
+			wantErr: "SAML_PROVIDERS",
+		},
+		{
+			name:    "built-in name",
+			modify:  func(_ *Config, p *SAMLProviderConfig) { p.Name = "gitlab" },
+			wantErr: "built-in",
+		},
+		{
+			name: "OIDC name",
+			modify: func(c *Config, _ *SAMLProviderConfig) {
+				c.OIDCProviders = []OIDCProviderConfig{{
+					Name:       "okta",
+					IssuerURL:  "https://example.okta.com",
+					ClientID:   "authgate",
+					Scopes:     []string{"openid"},
+					EmailClaim: "email",
+				}}
+			},
+			wantErr: "OIDC provider",
+		},
+		{
+			name:    "no metadata",
+			modify:  func(_ *Config, p *SAMLProviderConfig) { p.IDPMetadataURL = "" },
+			wantErr: "SAML_OKTA_IDP_METADATA_URL",
+		},
+		{
+			name: "both metadata sources",
+			modify: func(_ *Config, p *SAMLProviderConfig) {
+				p.IDPMetadataFile = "/etc/authgate/okta.xml"
+			},
+			wantErr: "exactly one",
+		},
+		{
+			name:    "relative metadata URL",
+			modify:  func(_ *Config, p *SAMLProviderConfig) { p.IDPMetadataURL = "/metadata" },
+			wantErr: "SAML_OKTA_IDP_METADATA_URL",
+		},
+		{
+			name:    "empty email attribute",
+			modify:  func(_ *Config, p *SAMLProviderConfig) { p.EmailAttribute = "" },
+			wantErr: "SAML_OKTA_EMAIL_ATTRIBUTE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := SAMLProviderConfig{
+				Name:           "okta",
+				IDPMetadataURL: "https://example.okta.com/app/abc/sso/saml/metadata",
+				EmailAttribute: "mail",
+			}
+			cfg := validBaseConfig()
+			cfg.SAMLSPCertFile = "/etc/authgate/saml.crt"
+			cfg.SAMLSPKeyFile = "/etc/authgate/saml.key"
+			tt.modify(&cfg, &provider)
+			cfg.SAMLProviders = []SAMLProviderConfig{provider}
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad_AuthChain(t *testing.T) {
+	t.Run("defaults from AUTH_MODE", func(t *testing.T) {
+		t.Setenv("AUTH_MODE", AuthModeHTTPAPI)
+		cfg := Load()
+		assert.Equal(t, []AuthChainEntryConfig{
+			{
+				Provider:          AuthModeHTTPAPI,
+				Sources:           []string{AuthModeHTTPAPI, AuthChainSourceNew},
+				OnNotFound:        AuthChainContinue,
+				OnInvalidPassword: AuthChainStop,
+			},
+			{
+				Provider:          AuthModeLocal,
+				Sources:           []string{AuthModeLocal},
+				OnNotFound:        AuthChainContinue,
+				OnInvalidPassword: AuthChainStop,
+			},
+		}, cfg.AuthChain)
+	})
+
+	t.Run("explicit chain", func(t *testing.T) {
+		t.Setenv("AUTH_CHAIN", "LDAP, local")
+		t.Setenv("AUTH_CHAIN_LDAP_USERNAME_PATTERN", "^[a-z]+$")
+		t.Setenv("AUTH_CHAIN_LDAP_ON_INVALID_PASSWORD", "continue")
+		t.Setenv("AUTH_CHAIN_LDAP_MIGRATE_PASSWORD", "true")
+		t.Setenv("AUTH_CHAIN_LOCAL_SOURCES", "local, ldap")
+		t.Setenv("AUTH_CHAIN_LOCAL_ON_NOT_FOUND", "stop")
+		cfg := Load()
+		require.Len(t, cfg.AuthChain, 2)
+		ldap := cfg.AuthChain[0]
+		assert.Equal(t, AuthModeLDAP, ldap.Provider)
+		assert.Equal(t, "^[a-z]+$", ldap.UsernamePattern)
+		assert.Equal(t, []string{AuthModeLDAP, AuthChainSourceNew}, ldap.Sources)
+		assert.Equal(t, AuthChainContinue, ldap.OnInvalidPassword)
+		assert.True(t, ldap.MigratePassword)
+		local := cfg.AuthChain[1]
+		assert.Equal(t, []string{AuthModeLocal, AuthModeLDAP}, local.Sources)
+		assert.Equal(t, AuthChainStop, local.OnNotFound)
+	})
+}
+
+func TestValidate_AuthChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*AuthChainEntryConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*AuthChainEntryConfig) {}},
+		{
+			name:    "unknown backend",
+			modify:  func(e *AuthChainEntryConfig) { e.Provider = "kerberos" },
+			wantErr: "AUTH_CHAIN entry",
+		},
+		{
+			name:    "listed twice",
+			modify:  func(e *AuthChainEntryConfig) { e.Provider = AuthModeLocal },
+			wantErr: "more than once",
+		},
+		{
+			name:    "invalid username pattern",
+			modify:  func(e *AuthChainEntryConfig) { e.UsernamePattern = "([a-z" },
+			wantErr: "AUTH_CHAIN_HTTP_API_USERNAME_PATTERN",
+		},
+		{
+			name:    "no sources",
+			modify:  func(e *AuthChainEntryConfig) { e.Sources = nil },
+			wantErr: "AUTH_CHAIN_HTTP_API_SOURCES",
+		},
+		{
+			name:    "unknown source",
+			modify:  func(e *AuthChainEntryConfig) { e.Sources = []string{"github"} },
+			wantErr: "AUTH_CHAIN_HTTP_API_SOURCES",
+		},
+		{
+			name:    "unknown fallthrough",
+			modify:  func(e *AuthChainEntryConfig) { e.OnNotFound = "skip" },
+			wantErr: "AUTH_CHAIN_HTTP_API_ON_NOT_FOUND",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := AuthChainEntryConfig{
+				Provider:          AuthModeHTTPAPI,
+				Sources:           []string{AuthModeHTTPAPI, AuthChainSourceNew},
+				OnNotFound:        AuthChainContinue,
+				OnInvalidPassword: AuthChainStop,
+				MigratePassword:   true,
+			}
+			tt.modify(&entry)
+			cfg := validBaseConfig()
+			cfg.AuthChain = []AuthChainEntryConfig{
+				{
+					Provider:          AuthModeLocal,
+					Sources:           []string{AuthModeLocal},
+					OnNotFound:        AuthChainContinue,
+					OnInvalidPassword: AuthChainStop,
+				},
+				entry,
+			}
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	cfg := validBaseConfig()
+	cfg.AuthChain = []AuthChainEntryConfig{{
+		Provider:          AuthModeLocal,
+		Sources:           []string{AuthModeLocal},
+		OnNotFound:        AuthChainContinue,
+		OnInvalidPassword: AuthChainStop,
+		MigratePassword:   true,
+	}}
+	assert.ErrorContains(t, cfg.Validate(), "AUTH_CHAIN_LOCAL_MIGRATE_PASSWORD")
+}
@@ -8,6 +8,14 @@ type AuthResult struct {
 	ExternalID string // External user ID (e.g., LDAP DN, API user ID)
 	Email      string // Optional
 	FullName   string // Optional
+	Role       string // Optional; when set, replaces the user's role on every login
+
+	// ExternalRole, Groups and Attributes replace the user's stored values on
+	// every login and are exposed as token claims. ExternalRole is the
+	// backend's own role name, whatever Role it maps to.
+	ExternalRole string
+	Groups       []string
+	Attributes   map[string]any
 }
 
 // AuthProvider is the interface that password-based authentication
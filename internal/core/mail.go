@@ -0,0 +1,16 @@
+package core
+
+import "context"
+
+// MailMessage is a plain-text email addressed to a single recipient.
+type MailMessage struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer delivers transactional email such as password reset links.
+type Mailer interface {
+	// Send delivers msg or returns an error; it does not retry.
+	Send(ctx context.Context, msg MailMessage) error
+}
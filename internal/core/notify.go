@@ -0,0 +1,24 @@
+package core
+
+import "context"
+
+// DeviceCodeNotifier delivers "device code authorized" events to requests
+// waiting on /oauth/device/wait. Events are keyed by the device code's
+// primary key; implementations backed by a shared broker (Redis pub/sub)
+// deliver them to waiters on every replica.
+//
+// Delivery is best-effort: a waiter that misses an event falls back to its
+// timeout, so callers must re-read the device code after subscribing and
+// after every wake-up rather than trusting the event alone.
+type DeviceCodeNotifier interface {
+	// Publish announces that the device code with the given ID was authorized.
+	Publish(ctx context.Context, deviceCodeID int64) error
+
+	// Subscribe registers interest in the device code with the given ID.
+	// The returned channel receives a value after the next Publish for that
+	// ID; the returned function releases the subscription and must be called.
+	Subscribe(deviceCodeID int64) (<-chan struct{}, func())
+
+	// Close releases the notifier's resources.
+	Close() error
+}
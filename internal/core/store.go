@@ -40,6 +40,57 @@ type UserWriter interface {
 	) (*models.User, error)
 }
 
+// TwoFactorStore groups TOTP enrollment and recovery code operations.
+type TwoFactorStore interface {
+	SetUserTOTPSecret(userID, secret string) error
+	EnableUserTOTP(userID string, step int64, codeHashes []string) error
+	DisableUserTOTP(userID string) error
+	ConsumeTOTPStep(userID string, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	ConsumeRecoveryCode(userID, codeHash string, now time.Time) (bool, error)
+	CountUnusedRecoveryCodes(userID string) (int64, error)
+	DeleteRecoveryCodesByUserID(userID string) error
+}
+
+// WebAuthnStore groups passkey credential operations.
+type WebAuthnStore interface {
+	CreateWebAuthnCredential(cred *models.WebAuthnCredential) error
+	ListWebAuthnCredentials(userID string) ([]models.WebAuthnCredential, error)
+	GetWebAuthnCredentialByCredentialID(credentialID string) (*models.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(id string, oldCount, newCount int64, usedAt time.Time) (bool, error)
+	DeleteWebAuthnCredential(userID, id string) (bool, error)
+	DeleteWebAuthnCredentialsByUserID(userID string) error
+}
+
+// PasswordResetStore groups self-service password reset token operations.
+type PasswordResetStore interface {
+	CreatePasswordResetToken(token *models.PasswordResetToken) error
+	GetPasswordResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error)
+	CountPasswordResetTokensSince(userID string, since time.Time) (int64, error)
+	ConsumePasswordResetToken(id uint, now time.Time) (bool, error)
+	DeletePasswordResetTokensByUserID(userID string) error
+}
+
+// SignupStore groups self-service signup and email verification operations.
+type SignupStore interface {
+	CreateSignupUser(user *models.User, token *models.EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(tokenHash string) (*models.EmailVerificationToken, error)
+	ConsumeEmailVerificationToken(id uint, now time.Time) (bool, error)
+	DeleteEmailVerificationTokensByUserID(userID string) error
+	ListUsersBySignupStatus(status string) ([]models.User, error)
+	CountUsersBySignupStatus(status string) (int64, error)
+	DeleteUnverifiedSignup(userID string) error
+}
+
+// EmailChangeStore groups self-service email change token operations.
+type EmailChangeStore interface {
+	CreateEmailChangeToken(token *models.EmailChangeToken) error
+	GetEmailChangeTokenByHash(tokenHash string) (*models.EmailChangeToken, error)
+	CountEmailChangeTokensSince(userID string, since time.Time) (int64, error)
+	ConsumeEmailChangeToken(id uint, now time.Time) (bool, error)
+	DeleteEmailChangeTokensByUserID(userID string) error
+}
+
 // ── OAuth Client ────────────────────────────────────────────────────────
 
 // ClientReader groups read-only client operations.
@@ -74,6 +125,7 @@ type DeviceCodeStore interface {
 	GetDeviceCodeByUserCode(userCode string) (*models.DeviceCode, error)
 	UpdateDeviceCode(dc *models.DeviceCode) error
 	AuthorizeDeviceCode(id int64, userID string) error
+	RecordDeviceCodePoll(id int64, polledAt time.Time, interval int) error
 	DeleteDeviceCodeByID(id int64) error
 }
 
@@ -91,7 +143,14 @@ type TokenReader interface {
 	GetTokensPaginated(
 		params types.PaginationParams,
 	) ([]models.AccessToken, types.PaginationResult, error)
+	SearchTokensPaginated(
+		params types.PaginationParams,
+		filter types.TokenFilter,
+	) ([]models.AccessToken, types.PaginationResult, error)
+	CountTokensByFilter(filter types.TokenFilter) (int64, error)
 	GetTokensByCategoryAndStatus(userID, category, status string) ([]models.AccessToken, error)
+	GetActiveRefreshTokens(userID, clientID string) ([]models.AccessToken, error)
+	GetTokenFamilyTokens(rootID string) ([]models.AccessToken, error)
 	GetActiveTokenHashesByFamilyID(familyID string) ([]string, error)
 	GetActiveTokenHashesByAuthorizationID(authorizationID uint) ([]string, error)
 	GetActiveTokenHashesByClientID(clientID string) ([]string, error)
@@ -107,8 +166,15 @@ type TokenWriter interface {
 	RevokeTokenFamily(familyID string) (int64, error)
 	UpdateTokenStatus(tokenID, status string) error
 	UpdateTokenLastUsedAt(tokenID string, t time.Time) error
+	UpdateTokenExpiresAt(tokenID string, t time.Time) error
 	RevokeTokensByAuthorizationID(authorizationID uint) error
 	RevokeAllActiveTokensByClientID(clientID string) (int64, error)
+	UpdateTokenStatusByFilter(
+		filter types.TokenFilter,
+		fromStatuses []string,
+		newStatus string,
+	) ([]models.AccessToken, error)
+	LockUserTokenGrants(userID string) error
 }
 
 // ── Authorization Code ──────────────────────────────────────────────────
@@ -126,12 +192,15 @@ type AuthorizationCodeStore interface {
 type UserAuthorizationStore interface {
 	GetUserAuthorization(userID string, applicationID int64) (*models.UserAuthorization, error)
 	GetUserAuthorizationByUUID(authUUID, userID string) (*models.UserAuthorization, error)
+	GetUserAuthorizationByID(id uint) (*models.UserAuthorization, error)
 	UpsertUserAuthorization(auth *models.UserAuthorization) error
 	RevokeUserAuthorization(authUUID, userID string) (*models.UserAuthorization, error)
 	ListUserAuthorizations(userID string) ([]models.UserAuthorization, error)
 	GetClientAuthorizations(clientID string) ([]models.UserAuthorization, error)
 	RevokeAllUserAuthorizationsByClientID(clientID string) error
 	RevokeAllUserAuthorizationsByUserID(userID string) error
+	ListExpiredUserAuthorizations(now time.Time, limit int) ([]models.UserAuthorization, error)
+	ExpireUserAuthorization(id uint, now time.Time) (bool, error)
 }
 
 // ── OAuth Connection ────────────────────────────────────────────────────
@@ -160,6 +229,7 @@ type AuditStore interface {
 	) ([]models.AuditLog, types.PaginationResult, error)
 	DeleteOldAuditLogs(olderThan time.Time) (int64, error)
 	GetAuditLogStats(startTime, endTime time.Time) (types.AuditLogStats, error)
+	GetTokenAuditLogs(resourceIDs, mentions []string, limit int) ([]models.AuditLog, error)
 }
 
 // ── Dashboard ───────────────────────────────────────────────────────────
@@ -174,7 +244,11 @@ type DashboardStore interface {
 // CleanupStore groups expired-data cleanup operations.
 type CleanupStore interface {
 	DeleteExpiredTokens() error
+	DeleteIdleRefreshTokens(idleTimeouts map[string]time.Duration) (int64, error)
 	DeleteExpiredDeviceCodes() error
+	DeleteExpiredPasswordResetTokens() error
+	DeleteExpiredSignups(createdBefore time.Time) (int64, error)
+	DeleteExpiredEmailChangeTokens() error
 }
 
 // ── Transaction ─────────────────────────────────────────────────────────
@@ -199,6 +273,11 @@ type Infrastructure interface {
 type Store interface {
 	UserReader
 	UserWriter
+	TwoFactorStore
+	WebAuthnStore
+	PasswordResetStore
+	SignupStore
+	EmailChangeStore
 	ClientReader
 	ClientWriter
 	DeviceCodeStore
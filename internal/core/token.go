@@ -14,12 +14,20 @@ type IDTokenParams struct {
 	Nonce    string
 	Expiry   time.Duration
 	AtHash   string // base64url(SHA-256(access_token)[:16]) – optional
+	// SessionID is the login session (sid) the ID token was issued from – optional
+	SessionID string
+	// AuthMethods are the RFC 8176 authentication method references ("amr")
+	// used at login, e.g. ["pwd", "otp", "mfa"] – optional
+	AuthMethods []string
 
 	// Scope-gated profile claims (include when "profile" scope was granted)
 	Name              string
 	PreferredUsername string
 	Picture           string
 	UpdatedAt         *time.Time
+	Roles             []string       // Reported by the external auth backend
+	Groups            []string       // Reported by the external auth backend
+	Attributes        map[string]any // Reported by the external auth backend
 
 	// Scope-gated email claims (include when "email" scope was granted)
 	Email         string
@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/auth"
@@ -32,8 +33,20 @@ const formFieldRememberMe = "remember_me"
 
 // loginErrorMessages maps error query parameter keys to user-facing messages.
 var loginErrorMessages = map[string]string{
-	"session_timeout": "Your session has expired due to inactivity. Please sign in again.",
-	"session_invalid": "Your session is invalid or may have been accessed from a different device. Please sign in again.",
+	"session_timeout":      "Your session has expired due to inactivity. Please sign in again.",
+	"session_invalid":      "Your session is invalid or may have been accessed from a different device. Please sign in again.",
+	"device_code_attempts": "Too many incorrect device codes were entered. Please sign in again.",
+	"two_factor_expired":   "Your sign-in attempt expired. Please sign in again.",
+	"two_factor_attempts":  "Too many incorrect authentication codes were entered. Please sign in again.",
+	"two_factor_locked":    "Too many incorrect authentication codes were entered for this account. Please try again later.",
+}
+
+// loginNoticeMessages maps notice query parameter keys to user-facing messages.
+var loginNoticeMessages = map[string]string{
+	"password_reset": "Your password has been changed. Sign in with your new password.",
+	"email_verified": "Your email address is verified. You can now sign in.",
+	"signup_pending": "Your email address is verified. " +
+		"An administrator will review your account before you can sign in.",
 }
 
 // buildOAuthProviderList converts the OAuth providers map into template-friendly display objects.
@@ -49,20 +62,23 @@ func buildOAuthProviderList(providers map[string]*auth.OAuthProvider) []template
 }
 
 type AuthHandler struct {
-	userService *services.UserService
-	cfg         *config.Config
-	metrics     core.Recorder
+	userService  *services.UserService
+	tokenService *services.TokenService
+	cfg          *config.Config
+	metrics      core.Recorder
 }
 
 func NewAuthHandler(
 	us *services.UserService,
+	ts *services.TokenService,
 	cfg *config.Config,
 	m core.Recorder,
 ) *AuthHandler {
 	return &AuthHandler{
-		userService: us,
-		cfg:         cfg,
-		metrics:     m,
+		userService:  us,
+		tokenService: ts,
+		cfg:          cfg,
+		metrics:      m,
 	}
 }
 
@@ -101,6 +117,7 @@ func (h *AuthHandler) LoginPageWithOAuth(
 	}
 
 	errorMsg := loginErrorMessages[c.Query("error")]
+	notice := loginNoticeMessages[c.Query("notice")]
 
 	templates.RenderTempl(c, http.StatusOK, templates.LoginPage(templates.LoginPageProps{
 		BaseProps: templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
@@ -112,6 +129,10 @@ func (h *AuthHandler) LoginPageWithOAuth(
 		OAuthProviders:    buildOAuthProviderList(oauthProviders),
 		RememberMeEnabled: h.cfg.SessionRememberMeEnabled,
 		RememberMeDays:    h.rememberMeDays(),
+		PasskeyEnabled:    h.cfg.WebAuthnEnabled,
+		PasswordReset:     h.cfg.PasswordResetEnabled,
+		SignupEnabled:     h.cfg.SignupEnabled,
+		Notice:            notice,
 	}))
 }
 
@@ -142,6 +163,10 @@ func (h *AuthHandler) Login(c *gin.Context,
 		switch {
 		case errors.Is(err, services.ErrAccountDisabled):
 			errorMsg = "Your account has been disabled. Please contact your administrator."
+		case errors.Is(err, services.ErrEmailNotVerified):
+			errorMsg = "Please verify your email address using the link we sent you."
+		case errors.Is(err, services.ErrSignupPendingApproval):
+			errorMsg = "Your account is awaiting approval by an administrator."
 		case errors.Is(err, services.ErrUsernameConflict):
 			errorMsg = "Username conflict with existing user. Please contact administrator."
 		default:
@@ -161,6 +186,9 @@ func (h *AuthHandler) Login(c *gin.Context,
 				OAuthProviders:    buildOAuthProviderList(oauthProviders),
 				RememberMeEnabled: h.cfg.SessionRememberMeEnabled,
 				RememberMeDays:    h.rememberMeDays(),
+				PasskeyEnabled:    h.cfg.WebAuthnEnabled,
+				PasswordReset:     h.cfg.PasswordResetEnabled,
+				SignupEnabled:     h.cfg.SignupEnabled,
 			}),
 		)
 		return
@@ -175,27 +203,16 @@ func (h *AuthHandler) Login(c *gin.Context,
 	h.metrics.RecordLogin(authSource, true)
 	h.metrics.RecordAuthAttempt(authSource, true, duration)
 
-	// Set session
+	// Users with a second factor finish signing in at /login/2fa; until then
+	// the session only records the pending login.
 	session := sessions.Default(c)
-	session.Set(SessionUserID, user.ID)
-	session.Set(SessionUsername, user.Username)
-	session.Set(SessionLastActivity, time.Now().Unix()) // Set initial last activity time
-
-	// Set session fingerprint if enabled
-	if h.cfg.SessionFingerprint {
-		clientIP := c.GetString(middleware.ContextKeyClientIP) // Set by RequestContextMiddleware
-		userAgent := c.Request.UserAgent()
-		fingerprint := middleware.GenerateFingerprint(
-			clientIP,
-			userAgent,
-			h.cfg.SessionFingerprintIP,
-		)
-		session.Set(SessionFingerprint, fingerprint)
-	}
-
-	// Handle "Remember Me" — extend session to configured duration
-	if h.cfg.SessionRememberMeEnabled && c.PostForm(formFieldRememberMe) == "1" {
-		middleware.ApplyRememberMe(session, h.cfg.SessionRememberMeMaxAge, h.cfg.IsProduction)
+	rememberMe := h.cfg.SessionRememberMeEnabled && c.PostForm(formFieldRememberMe) == "1"
+	authMethods := []string{amrPassword}
+	if middleware.HasSecondFactor(user, h.cfg.WebAuthnEnabled) {
+		beginSecondFactor(session, user.ID, rememberMe, redirectTo, authMethods)
+		redirectTo = loginTwoFactorPath
+	} else {
+		startLoginSession(c, session, h.cfg, user, rememberMe, authMethods)
 	}
 
 	if err := session.Save(); err != nil {
@@ -210,6 +227,9 @@ func (h *AuthHandler) Login(c *gin.Context,
 				Error:             "Failed to create session",
 				RememberMeEnabled: h.cfg.SessionRememberMeEnabled,
 				RememberMeDays:    h.rememberMeDays(),
+				PasskeyEnabled:    h.cfg.WebAuthnEnabled,
+				PasswordReset:     h.cfg.PasswordResetEnabled,
+				SignupEnabled:     h.cfg.SignupEnabled,
 			}),
 		)
 		return
@@ -218,6 +238,49 @@ func (h *AuthHandler) Login(c *gin.Context,
 	c.Redirect(http.StatusFound, redirectTo)
 }
 
+// startLoginSession writes a signed-in session for user: a fresh login session
+// ID, the initial activity time, the fingerprint when enabled, the
+// authentication methods used, and an extended lifetime for "remember me".
+// The caller saves the session.
+func startLoginSession(
+	c *gin.Context,
+	session sessions.Session,
+	cfg *config.Config,
+	user *models.User,
+	rememberMe bool,
+	authMethods []string,
+) {
+	clearPendingSecondFactor(session)
+
+	session.Set(SessionUserID, user.ID)
+	session.Set(SessionUsername, user.Username)
+	session.Set(middleware.SessionID, middleware.NewSessionID())
+	session.Set(SessionLastActivity, time.Now().Unix()) // Set initial last activity time
+	session.Set(middleware.SessionAuthTime, time.Now().Unix())
+	if len(authMethods) > 0 {
+		session.Set(middleware.SessionAuthMethods, strings.Join(authMethods, " "))
+	} else {
+		session.Delete(middleware.SessionAuthMethods)
+	}
+
+	// Set session fingerprint if enabled
+	if cfg.SessionFingerprint {
+		clientIP := c.GetString(middleware.ContextKeyClientIP) // Set by RequestContextMiddleware
+		userAgent := c.Request.UserAgent()
+		fingerprint := middleware.GenerateFingerprint(
+			clientIP,
+			userAgent,
+			cfg.SessionFingerprintIP,
+		)
+		session.Set(SessionFingerprint, fingerprint)
+	}
+
+	// Handle "Remember Me" — extend session to configured duration
+	if rememberMe {
+		middleware.ApplyRememberMe(session, cfg.SessionRememberMeMaxAge, cfg.IsProduction)
+	}
+}
+
 // Logout clears the session and redirects to login
 func (h *AuthHandler) Logout(c *gin.Context) {
 	session := sessions.Default(c)
@@ -231,6 +294,14 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		}
 	}
 
+	// Tokens issued from this login session end with it. Failures are audited
+	// by the service and must not keep the user signed in.
+	if h.cfg.SessionRevokeOnLogout && h.tokenService != nil {
+		userID, _ := session.Get(SessionUserID).(string)
+		sessionID, _ := session.Get(middleware.SessionID).(string)
+		_, _ = h.tokenService.RevokeSessionTokens(c.Request.Context(), userID, sessionID, "logout")
+	}
+
 	session.Clear()
 	if err := session.Save(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -1,9 +1,21 @@
 package handlers
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/metrics"
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoginErrorMessages_KnownKeys(t *testing.T) {
@@ -36,3 +48,45 @@ func TestLoginErrorMessages_UnknownKeyReturnsEmpty(t *testing.T) {
 		assert.Empty(t, loginErrorMessages[key], "unknown key %q must return empty string", key)
 	}
 }
+
+func TestLogout_RevokesLoginSessionTokens(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		revoke bool
+		want   string
+	}{
+		{"Enabled", true, models.TokenStatusRevoked},
+		{"Disabled", false, models.TokenStatusActive},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, tokenSvc := setupSessionServices(t)
+			userID := uuid.New().String()
+			tok := createSessionBoundToken(t, s, userID, uuid.New().String(), "session-a")
+
+			handler := NewAuthHandler(
+				nil,
+				tokenSvc,
+				&config.Config{SessionRevokeOnLogout: tc.revoke},
+				metrics.NewNoopMetrics(),
+			)
+			r := gin.New()
+			r.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+			r.Use(func(c *gin.Context) {
+				sess := sessions.Default(c)
+				sess.Set(SessionUserID, userID)
+				sess.Set(middleware.SessionID, "session-a")
+				c.Next()
+			})
+			r.POST("/logout", handler.Logout)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusFound, w.Code)
+
+			stored, err := s.GetAccessTokenByID(tok.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, stored.Status)
+		})
+	}
+}
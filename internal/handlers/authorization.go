@@ -4,10 +4,10 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/templates"
 	"github.com/go-authgate/authgate/internal/util"
@@ -98,19 +98,42 @@ func (h *AuthorizationHandler) ShowAuthorizePage(c *gin.Context) {
 		return
 	}
 
-	// If ConsentRemember is enabled and the user has already consented to
-	// the same client+scopes+resource set, skip the consent page and issue
-	// a code immediately. The resource set must match EXACTLY — neither a
-	// no-resource request matching a resource-bound consent nor a
-	// resource-bound request matching a no-resource consent qualifies, since
-	// the user only ever approved a specific audience binding (or its
-	// absence) and silently widening/narrowing it would shift trust they
-	// never granted.
-	if h.config.ConsentRemember {
-		existing, _ := h.authorizationService.GetUserAuthorization(userIDStr, req.Client.ID)
-		if existing != nil &&
-			util.IsScopeSubset(existing.Scopes, req.Scopes) &&
-			util.IsStringSliceSetEqual([]string(existing.Resource), req.Resource) {
+	existing, _ := h.authorizationService.GetUserAuthorization(
+		c.Request.Context(), userIDStr, req.Client.ID,
+	)
+
+	// Trusted (first-party) clients never show the consent page, whatever
+	// CONSENT_REMEMBER says. The implicit grant is still recorded so the user
+	// can see and revoke it, and so the tokens carry an AuthorizationID.
+	if req.Client.Trusted {
+		if _, err := h.authorizationService.GrantTrustedConsent(
+			c.Request.Context(), userIDStr, req, existing,
+		); err != nil {
+			h.redirectWithError(
+				c,
+				req.RedirectURI,
+				state,
+				errServerError,
+				"Failed to save authorization",
+			)
+			return
+		}
+		h.issueCodeAndRedirect(c, req, userIDStr, state)
+		return
+	}
+
+	// If ConsentRemember is enabled and the user has already granted or
+	// declined every requested scope for the same client+resource set, skip
+	// the consent page and issue a code for the granted subset immediately.
+	// The resource set must match EXACTLY — neither a no-resource request
+	// matching a resource-bound consent nor a resource-bound request matching
+	// a no-resource consent qualifies, since the user only ever approved a
+	// specific audience binding (or its absence) and silently
+	// widening/narrowing it would shift trust they never granted.
+	if h.config.ConsentRemember && existing != nil &&
+		util.IsStringSliceSetEqual([]string(existing.Resource), req.Resource) {
+		if scopes, ok := services.RememberedConsentScopes(existing, req.Client, req.Scopes); ok {
+			req.Scopes = scopes
 			h.issueCodeAndRedirect(c, req, userIDStr, state)
 			return
 		}
@@ -126,7 +149,7 @@ func (h *AuthorizationHandler) ShowAuthorizePage(c *gin.Context) {
 		ClientDescription:   req.Client.Description,
 		RedirectURI:         req.RedirectURI,
 		Scopes:              req.Scopes,
-		ScopeList:           strings.Fields(req.Scopes),
+		ScopeList:           consentScopeList(req, existing),
 		State:               state,
 		Nonce:               req.Nonce,
 		CodeChallenge:       req.CodeChallenge,
@@ -201,6 +224,26 @@ func (h *AuthorizationHandler) HandleAuthorize(c *gin.Context) {
 	}
 	req.Resource = resource
 
+	// Granular consent: the consent page posts the optional scopes the user
+	// kept checked. Required scopes are always granted, and the narrowed set
+	// is what the code (and so the tokens) carries.
+	declinedScopes := ""
+	if c.PostForm("scope_selection") != "" {
+		req.Scopes, declinedScopes, err = services.NarrowConsentScopes(
+			req.Client, req.Scopes, c.PostFormArray("granted_scope"),
+		)
+		if err != nil {
+			h.redirectWithError(
+				c,
+				redirectURI,
+				state,
+				errAccessDenied,
+				"User declined all requested scopes",
+			)
+			return
+		}
+	}
+
 	userIDStr := getUserIDFromContext(c)
 
 	// Persist the consent record (with the approved resource set, if any).
@@ -218,6 +261,7 @@ func (h *AuthorizationHandler) HandleAuthorize(c *gin.Context) {
 		req.Client.ID,
 		req.Client.ClientID,
 		req.Scopes,
+		declinedScopes,
 		req.Resource,
 	); err != nil {
 		h.redirectWithError(
@@ -233,6 +277,28 @@ func (h *AuthorizationHandler) HandleAuthorize(c *gin.Context) {
 	h.issueCodeAndRedirect(c, req, userIDStr, state)
 }
 
+// consentScopeList builds the consent page's scope rows, required scopes
+// first. Optional scopes start checked unless the user declined them in an
+// earlier consent for this client.
+func consentScopeList(
+	req *services.AuthorizationRequest,
+	existing *models.UserAuthorization,
+) []templates.ConsentScope {
+	declined := map[string]bool{}
+	if existing != nil {
+		declined = util.ScopeSet(existing.DeclinedScopes)
+	}
+	required, optional := services.ConsentScopes(req.Client, req.Scopes)
+	list := make([]templates.ConsentScope, 0, len(required)+len(optional))
+	for _, scope := range required {
+		list = append(list, templates.ConsentScope{Name: scope, Required: true, Checked: true})
+	}
+	for _, scope := range optional {
+		list = append(list, templates.ConsentScope{Name: scope, Checked: !declined[scope]})
+	}
+	return list
+}
+
 // issueCodeAndRedirect generates an authorization code and redirects to the client's redirect_uri.
 func (h *AuthorizationHandler) issueCodeAndRedirect(
 	c *gin.Context,
@@ -252,6 +318,8 @@ func (h *AuthorizationHandler) issueCodeAndRedirect(
 			Nonce:               req.Nonce,
 			Resource:            req.Resource,
 			Client:              req.Client,
+			SessionID:           c.GetString(middleware.ContextKeySessionID),
+			AuthMethods:         c.GetStringSlice(middleware.ContextKeyAuthMethods),
 		},
 	)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-authgate/authgate/internal/cache"
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/services"
@@ -605,3 +606,179 @@ func TestAuthorize_DenyUnregisteredRedirectURI_NotReflected(t *testing.T) {
 		"unregistered redirect_uri must not be reflected on the deny path",
 	)
 }
+
+// ============================================================
+// Granular consent
+// ============================================================
+
+// setupGranularConsentEnv wires both consent routes with ConsentRemember on,
+// for a client whose scopes are "openid profile email offline_access" and
+// which requires "profile".
+func setupGranularConsentEnv(
+	t *testing.T,
+) (engine *gin.Engine, client *models.OAuthApplication, s *store.Store, userID string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		BaseURL:            "http://localhost:8080",
+		AuthCodeExpiration: 10 * time.Minute,
+		ConsentRemember:    true,
+	}
+
+	var err error
+	s, err = store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
+	require.NoError(t, err)
+
+	auditSvc := services.NewNoopAuditService()
+	clientSvc := services.NewClientService(s, auditSvc, nil, 0, nil, 0)
+	userSvc := services.NewUserService(
+		s, nil, nil, "local", false, auditSvc,
+		cache.NewNoopCache[models.User](), 0,
+	)
+	authzSvc := services.NewAuthorizationService(s, cfg, auditSvc, nil, clientSvc)
+	handler := NewAuthorizationHandler(authzSvc, nil, userSvc, cfg)
+
+	client = &models.OAuthApplication{
+		ClientID:           uuid.New().String(),
+		ClientSecret:       "test-secret-hash",
+		ClientName:         "Granular Consent Client",
+		UserID:             uuid.New().String(),
+		Scopes:             "openid profile email offline_access",
+		RequiredScopes:     "profile",
+		GrantTypes:         "authorization_code",
+		RedirectURIs:       models.StringArray{"https://app.example.com/callback"},
+		ClientType:         "confidential",
+		EnableAuthCodeFlow: true,
+		Status:             models.ClientStatusActive,
+	}
+	require.NoError(t, s.CreateClient(client))
+
+	user := &models.User{
+		ID:       uuid.New().String(),
+		Username: "consent-user",
+		Email:    "consent@example.com",
+		IsActive: true,
+	}
+	require.NoError(t, s.CreateUser(user))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		c.Set("user", user)
+		c.Next()
+	})
+	r.GET("/oauth/authorize", handler.ShowAuthorizePage)
+	r.POST("/oauth/authorize", handler.HandleAuthorize)
+	return r, client, s, user.ID
+}
+
+func getAuthorize(t *testing.T, r *gin.Engine, clientID, scope string) *httptest.ResponseRecorder {
+	t.Helper()
+	q := url.Values{
+		"client_id":     {clientID},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"response_type": {"code"},
+		"scope":         {scope},
+		"state":         {"st-1"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+q.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func postGranularApprove(
+	t *testing.T, r *gin.Engine, clientID, scope string, granted ...string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	form := url.Values{
+		"action":          {"approve"},
+		"client_id":       {clientID},
+		"redirect_uri":    {"https://app.example.com/callback"},
+		"scope":           {scope},
+		"state":           {"st-1"},
+		"scope_selection": {"1"},
+		"granted_scope":   granted,
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, "/oauth/authorize", strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// issuedCodeScopes returns the scopes bound to the authorization code in a
+// consent redirect.
+func issuedCodeScopes(t *testing.T, s *store.Store, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	require.Equal(t, http.StatusFound, w.Code)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	code := loc.Query().Get("code")
+	require.NotEmpty(t, code, "expected an authorization code, got %s", loc)
+	stored, err := s.GetAuthorizationCodeByHash(util.SHA256Hex(code))
+	require.NoError(t, err)
+	return stored.Scopes
+}
+
+func TestAuthorize_GranularConsent_NarrowsScopes(t *testing.T) {
+	r, client, s, userID := setupGranularConsentEnv(t)
+
+	// The user unchecks email; openid and profile are required.
+	w := postGranularApprove(t, r, client.ClientID, "openid profile email")
+	assert.Equal(t, "openid profile", issuedCodeScopes(t, s, w))
+
+	auth, err := s.GetUserAuthorization(userID, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "openid profile", auth.Scopes)
+	assert.Equal(t, "email", auth.DeclinedScopes)
+
+	// The same request is remembered and still omits the declined scope.
+	w = getAuthorize(t, r, client.ClientID, "openid profile email")
+	assert.Equal(t, "openid profile", issuedCodeScopes(t, s, w))
+
+	// A new scope brings the prompt back, with the declined scope unchecked.
+	w = getAuthorize(t, r, client.ClientID, "openid profile email offline_access")
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `value="offline_access" form="authorize-approve-form" checked`)
+	assert.NotContains(t, body, `value="email" form="authorize-approve-form" checked`)
+}
+
+func TestAuthorize_GranularConsent_RequiredScopesAlwaysGranted(t *testing.T) {
+	r, client, s, _ := setupGranularConsentEnv(t)
+
+	// Tampered form: granted_scope omits the required profile and adds a
+	// scope that was never requested.
+	w := postGranularApprove(t, r, client.ClientID, "profile email", "email", "offline_access")
+	assert.Equal(t, "profile email", issuedCodeScopes(t, s, w))
+}
+
+func TestAuthorize_GranularConsent_DecliningEverythingDenies(t *testing.T) {
+	r, client, _, _ := setupGranularConsentEnv(t)
+
+	w := postGranularApprove(t, r, client.ClientID, "email offline_access")
+	require.Equal(t, http.StatusFound, w.Code)
+	loc := w.Header().Get("Location")
+	assert.Contains(t, loc, "error=access_denied")
+	assert.NotContains(t, loc, "code=")
+}
+
+func TestAuthorize_TrustedClient_SkipsConsent(t *testing.T) {
+	r, client, s, userID := setupGranularConsentEnv(t)
+	client.Trusted = true
+	require.NoError(t, s.UpdateClient(client))
+
+	// No consent page: the code carries every requested scope.
+	w := getAuthorize(t, r, client.ClientID, "openid profile email")
+	assert.Equal(t, "openid profile email", issuedCodeScopes(t, s, w))
+
+	// The implicit grant is recorded like any other consent.
+	auth, err := s.GetUserAuthorization(userID, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "openid profile email", auth.Scopes)
+	assert.Empty(t, auth.DeclinedScopes)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-authgate/authgate/internal/core"
@@ -46,6 +47,22 @@ func parseRedirectURIs(input string) []string {
 	return parseURIList(input)
 }
 
+// parseOptionalInt parses an optional numeric form field such as
+// max_refresh_families or device_code_lifetime. Blank means 0 (inherit);
+// anything that is not a whole number maps to -1 so the service rejects it
+// with the field's validation error.
+func parseOptionalInt(input string) int {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
 // InjectPendingCount is a middleware that queries the pending client count for
 // admin users and stores it in the gin context so buildNavbarProps can show the
 // badge on every page. Non-admin users are skipped to avoid unnecessary queries.
@@ -122,6 +139,7 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 		Description:                 c.PostForm("description"),
 		UserID:                      userID,
 		Scopes:                      c.PostForm("scopes"),
+		RequiredScopes:              c.PostForm("required_scopes"),
 		RedirectURIs:                parseRedirectURIs(c.PostForm("redirect_uris")),
 		AllowedResources:            parseURIList(c.PostForm("allowed_resources")),
 		CreatedBy:                   userID,
@@ -130,6 +148,15 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 		EnableAuthCodeFlow:          c.PostForm("enable_auth_code_flow") == queryValueTrue,
 		EnableClientCredentialsFlow: c.PostForm("enable_client_credentials_flow") == queryValueTrue,
 		TokenProfile:                c.PostForm("token_profile"),
+		TokenFormat:                 c.PostForm("token_format"),
+		MaxRefreshFamilies:          parseOptionalInt(c.PostForm("max_refresh_families")),
+		RefreshFamilyPolicy:         c.PostForm("refresh_family_policy"),
+		DeviceUserCodeCharset:       c.PostForm("device_user_code_charset"),
+		DeviceUserCodeLength:        parseOptionalInt(c.PostForm("device_user_code_length")),
+		DeviceCodeLifetime:          parseOptionalInt(c.PostForm("device_code_lifetime")),
+		DevicePollingInterval:       parseOptionalInt(c.PostForm("device_polling_interval")),
+		ConsentLifetimeDays:         parseOptionalInt(c.PostForm("consent_lifetime_days")),
+		Trusted:                     c.PostForm("trusted") == queryValueTrue,
 		Project:                     c.PostForm("project"),
 		ServiceAccount:              c.PostForm("service_account"),
 		IsAdminCreated:              true, // admin-created clients are immediately active
@@ -144,6 +171,7 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 			ClientName:                  req.ClientName,
 			Description:                 req.Description,
 			Scopes:                      req.Scopes,
+			RequiredScopes:              req.RequiredScopes,
 			RedirectURIs:                strings.Join(req.RedirectURIs, ", "),
 			AllowedResources:            strings.Join(req.AllowedResources, ", "),
 			ClientType:                  req.ClientType.String(),
@@ -151,6 +179,15 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 			EnableAuthCodeFlow:          req.EnableAuthCodeFlow,
 			EnableClientCredentialsFlow: req.EnableClientCredentialsFlow,
 			TokenProfile:                req.TokenProfile,
+			TokenFormat:                 req.TokenFormat,
+			MaxRefreshFamilies:          req.MaxRefreshFamilies,
+			RefreshFamilyPolicy:         req.RefreshFamilyPolicy,
+			DeviceUserCodeCharset:       req.DeviceUserCodeCharset,
+			DeviceUserCodeLength:        req.DeviceUserCodeLength,
+			DeviceCodeLifetime:          req.DeviceCodeLifetime,
+			DevicePollingInterval:       req.DevicePollingInterval,
+			ConsentLifetimeDays:         req.ConsentLifetimeDays,
+			Trusted:                     req.Trusted,
 			Project:                     req.Project,
 			ServiceAccount:              req.ServiceAccount,
 		}
@@ -223,6 +260,7 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 		ClientName:                  c.PostForm("client_name"),
 		Description:                 c.PostForm("description"),
 		Scopes:                      c.PostForm("scopes"),
+		RequiredScopes:              c.PostForm("required_scopes"),
 		RedirectURIs:                parseRedirectURIs(c.PostForm("redirect_uris")),
 		AllowedResources:            parseURIList(c.PostForm("allowed_resources")),
 		Status:                      c.PostForm("status"),
@@ -231,6 +269,15 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 		EnableAuthCodeFlow:          c.PostForm("enable_auth_code_flow") == queryValueTrue,
 		EnableClientCredentialsFlow: c.PostForm("enable_client_credentials_flow") == queryValueTrue,
 		TokenProfile:                c.PostForm("token_profile"),
+		TokenFormat:                 c.PostForm("token_format"),
+		MaxRefreshFamilies:          parseOptionalInt(c.PostForm("max_refresh_families")),
+		RefreshFamilyPolicy:         c.PostForm("refresh_family_policy"),
+		DeviceUserCodeCharset:       c.PostForm("device_user_code_charset"),
+		DeviceUserCodeLength:        parseOptionalInt(c.PostForm("device_user_code_length")),
+		DeviceCodeLifetime:          parseOptionalInt(c.PostForm("device_code_lifetime")),
+		DevicePollingInterval:       parseOptionalInt(c.PostForm("device_polling_interval")),
+		ConsentLifetimeDays:         parseOptionalInt(c.PostForm("consent_lifetime_days")),
+		Trusted:                     c.PostForm("trusted") == queryValueTrue,
 		Project:                     c.PostForm("project"),
 		ServiceAccount:              c.PostForm("service_account"),
 	}
@@ -256,6 +303,7 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 			ClientName:                  req.ClientName,
 			Description:                 req.Description,
 			Scopes:                      req.Scopes,
+			RequiredScopes:              req.RequiredScopes,
 			RedirectURIs:                strings.Join(req.RedirectURIs, ", "),
 			AllowedResources:            strings.Join(req.AllowedResources, ", "),
 			ClientType:                  req.ClientType.String(),
@@ -264,6 +312,15 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 			EnableClientCredentialsFlow: req.EnableClientCredentialsFlow,
 			Status:                      req.Status,
 			TokenProfile:                req.TokenProfile,
+			TokenFormat:                 req.TokenFormat,
+			MaxRefreshFamilies:          req.MaxRefreshFamilies,
+			RefreshFamilyPolicy:         req.RefreshFamilyPolicy,
+			DeviceUserCodeCharset:       req.DeviceUserCodeCharset,
+			DeviceUserCodeLength:        req.DeviceUserCodeLength,
+			DeviceCodeLifetime:          req.DeviceCodeLifetime,
+			DevicePollingInterval:       req.DevicePollingInterval,
+			ConsentLifetimeDays:         req.ConsentLifetimeDays,
+			Trusted:                     req.Trusted,
 			Project:                     req.Project,
 			ServiceAccount:              req.ServiceAccount,
 			CreatedAt:                   client.CreatedAt,
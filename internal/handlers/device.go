@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/middleware"
 	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/qrcode"
 	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/templates"
 	"github.com/go-authgate/authgate/internal/util"
@@ -16,6 +21,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// QR code PNG scale bounds, in pixels per module.
+const (
+	deviceQRDefaultScale = 8
+	deviceQRMaxScale     = 20
+)
+
 // deviceCodeErrorMessage maps device code service errors to user-facing messages.
 func deviceCodeErrorMessage(err error) string {
 	switch {
@@ -25,11 +36,37 @@ func deviceCodeErrorMessage(err error) string {
 		return "Code has expired, please request a new one"
 	case errors.Is(err, services.ErrDeviceCodeAlreadyAuthorized):
 		return "This code has already been authorized"
+	case errors.Is(err, services.ErrUserCodeUserLocked):
+		return "Too many incorrect codes were entered. Please try again later"
 	default:
 		return "Invalid or expired code"
 	}
 }
 
+// userCodeAttempt identifies a user code submission for the brute-force
+// counters. Visitors who are not signed in have no session or user ID.
+func userCodeAttempt(c *gin.Context, user *models.User, userCode string) services.UserCodeAttempt {
+	sessionID, _ := sessions.Default(c).Get(middleware.SessionID).(string)
+	return services.UserCodeAttempt{
+		UserCode:  userCode,
+		SessionID: sessionID,
+		UserID:    user.ID,
+		Username:  user.Username,
+	}
+}
+
+// requireDeviceReLogin ends a session that entered too many wrong user codes
+// and sends the user to sign in again. The account-wide counter still
+// applies to the new session.
+func requireDeviceReLogin(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Clear()
+	if err := session.Save(); err != nil {
+		log.Printf("[device] failed to clear session after user code lockout: %v", err)
+	}
+	c.Redirect(http.StatusFound, "/login?redirect=%2Fdevice&error=device_code_attempts")
+}
+
 // renderDeviceErrorPage renders the device page with an error message.
 func renderDeviceErrorPage(
 	c *gin.Context,
@@ -171,17 +208,79 @@ func (h *DeviceHandler) DeviceCodeRequest(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"device_code":      dc.DeviceCode,
-		"user_code":        services.FormatUserCode(dc.UserCode),
-		"verification_uri": h.config.BaseURL + "/device",
-		"verification_uri_complete": h.config.BaseURL + "/device?user_code=" + services.FormatUserCode(
-			dc.UserCode,
-		),
-		"expires_in": int(h.config.DeviceCodeExpiration.Seconds()),
-		"interval":   dc.Interval,
+		"device_code":               dc.DeviceCode,
+		"user_code":                 services.FormatUserCode(dc.UserCode),
+		"verification_uri":          h.config.BaseURL + "/device",
+		"verification_uri_complete": h.verificationURIComplete(dc.UserCode),
+		"expires_in":                int(time.Until(dc.ExpiresAt).Round(time.Second).Seconds()),
+		"interval":                  dc.Interval,
 	})
 }
 
+// verificationURIComplete returns the RFC 8628 §3.3.1 verification URI with
+// the user code embedded, so the user only has to confirm it.
+func (h *DeviceHandler) verificationURIComplete(userCode string) string {
+	q := url.Values{"user_code": {services.FormatUserCode(userCode)}}
+	return h.config.BaseURL + "/device?" + q.Encode()
+}
+
+// DeviceQRCode godoc
+//
+//	@Summary		Device verification QR code
+//	@Description	Render verification_uri_complete for a user code as a QR code, for devices without their own QR library. The code is not looked up, so the endpoint reveals nothing about whether it exists.
+//	@Tags			OAuth
+//	@Produce		png
+//	@Produce		image/svg+xml
+//	@Param			user_code	query		string											true	"User code returned by /oauth/device/code"
+//	@Param			format		query		string											false	"Image format: png (default) or svg"
+//	@Param			scale		query		int												false	"PNG pixels per module (1-20, default 8)"
+//	@Success		200			{file}		binary											"QR code image"
+//	@Failure		400			{object}	object{error=string,error_description=string}	"Missing or malformed user_code, or unsupported format"
+//	@Router			/oauth/device/qr [get]
+func (h *DeviceHandler) DeviceQRCode(c *gin.Context) {
+	userCode := services.NormalizeUserCode(c.Query("user_code"))
+	if !services.IsWellFormedUserCode(userCode) {
+		respondOAuthError(c, http.StatusBadRequest, errInvalidRequest, "user_code is missing or malformed")
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "svg" {
+		respondOAuthError(c, http.StatusBadRequest, errInvalidRequest, "format must be png or svg")
+		return
+	}
+	scale := deviceQRDefaultScale
+	if v := c.Query("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > deviceQRMaxScale {
+			respondOAuthError(c, http.StatusBadRequest, errInvalidRequest,
+				fmt.Sprintf("scale must be between 1 and %d", deviceQRMaxScale))
+			return
+		}
+		scale = n
+	}
+
+	code, err := qrcode.Encode([]byte(h.verificationURIComplete(userCode)))
+	if err != nil {
+		log.Printf("[device] QR code encoding error: %v", err)
+		respondOAuthError(c, http.StatusInternalServerError, errServerError, "An internal error occurred")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", []byte(code.SVG()))
+		return
+	}
+	img, err := code.PNG(scale)
+	if err != nil {
+		log.Printf("[device] QR code rendering error: %v", err)
+		respondOAuthError(c, http.StatusInternalServerError, errServerError, "An internal error occurred")
+		return
+	}
+	c.Data(http.StatusOK, "image/png", img)
+}
+
 // DevicePage renders the device code input page
 func (h *DeviceHandler) DevicePage(c *gin.Context) {
 	session := sessions.Default(c)
@@ -199,11 +298,19 @@ func (h *DeviceHandler) DevicePage(c *gin.Context) {
 
 	clientName := ""
 	var resource []string
+	prefilled := false
 	if userCode != "" {
-		client, dc, err := h.deviceService.GetClientByUserCode(c.Request.Context(), userCode)
+		// A locked-out session simply gets the empty form; DeviceVerify
+		// enforces the lockout when the code is submitted.
+		client, dc, err := h.deviceService.LookupUserCode(
+			c.Request.Context(),
+			userCodeAttempt(c, user, userCode),
+		)
 		if err == nil {
 			clientName = client.ClientName
 			resource = []string(dc.Resource)
+			prefilled = true
+			userCode = services.FormatUserCode(dc.UserCode)
 		}
 	}
 
@@ -214,6 +321,7 @@ func (h *DeviceHandler) DevicePage(c *gin.Context) {
 		UserCode:    userCode,
 		ClientName:  clientName,
 		Resource:    resource,
+		Prefilled:   prefilled,
 		Error:       "",
 	}))
 }
@@ -239,17 +347,20 @@ func (h *DeviceHandler) DeviceVerify(c *gin.Context) {
 		return
 	}
 
-	// Get client and device code before authorizing
-	client, dc, err := h.deviceService.GetClientByUserCode(c.Request.Context(), userCode)
+	// Get client and device code before authorizing. Wrong codes count
+	// toward the session, account and global brute-force thresholds.
+	attempt := userCodeAttempt(c, user, userCode)
+	client, dc, err := h.deviceService.LookupUserCode(c.Request.Context(), attempt)
 	if err != nil {
-		renderDeviceErrorPage(
-			c,
-			user,
-			userCode,
-			"",
-			deviceCodeErrorMessage(err),
-			http.StatusBadRequest,
-		)
+		if errors.Is(err, services.ErrUserCodeSessionLocked) {
+			requireDeviceReLogin(c)
+			return
+		}
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrUserCodeUserLocked) {
+			status = http.StatusTooManyRequests
+		}
+		renderDeviceErrorPage(c, user, userCode, "", deviceCodeErrorMessage(err), status)
 		return
 	}
 
@@ -266,6 +377,24 @@ func (h *DeviceHandler) DeviceVerify(c *gin.Context) {
 	}
 	clientName := client.ClientName
 
+	// A code that arrived pre-filled via verification_uri_complete (a link or
+	// QR code the user did not type) must be explicitly matched against the
+	// code on the device, so a phished link cannot authorize an attacker's
+	// device with a single click (RFC 8628 §5.4).
+	if c.PostForm("prefilled") == "true" && c.PostForm("code_confirmed") != "true" {
+		templates.RenderTempl(c, http.StatusBadRequest, templates.DevicePage(templates.DevicePageProps{
+			BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+			NavbarProps: buildNavbarProps(c, user, "device"),
+			Username:    user.Username,
+			UserCode:    services.FormatUserCode(dc.UserCode),
+			ClientName:  clientName,
+			Resource:    []string(dc.Resource),
+			Prefilled:   true,
+			Error:       "Confirm that the code matches the one shown on your device",
+		}))
+		return
+	}
+
 	// Resource-bound device codes require an explicit confirmation step that
 	// surfaces both the client and the requested resource(s) before
 	// authorization. The verification_uri_complete flow already shows them on
@@ -343,6 +472,7 @@ func (h *DeviceHandler) DeviceVerify(c *gin.Context) {
 		return
 	}
 	h.deviceService.RecordDeviceCodeAuthorized(dc)
+	h.deviceService.NotifyDeviceCodeAuthorized(c.Request.Context(), dc)
 
 	templates.RenderTempl(c, http.StatusOK, templates.SuccessPage(templates.SuccessPageProps{
 		BaseProps:  templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
@@ -14,6 +14,7 @@ import (
 	"github.com/go-authgate/authgate/internal/cache"
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/metrics"
+	"github.com/go-authgate/authgate/internal/middleware"
 	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/services"
 	"github.com/go-authgate/authgate/internal/store"
@@ -33,6 +34,7 @@ func setupDeviceTestEnv(t *testing.T) (*gin.Engine, *store.Store) {
 	cfg := &config.Config{
 		DeviceCodeExpiration: 30 * time.Minute,
 		PollingInterval:      5,
+		DeviceWaitTimeout:    100 * time.Millisecond,
 		BaseURL:              "http://localhost:8080",
 	}
 
@@ -48,6 +50,8 @@ func setupDeviceTestEnv(t *testing.T) (*gin.Engine, *store.Store) {
 
 	r := gin.New()
 	r.POST("/oauth/device/code", handler.DeviceCodeRequest)
+	r.GET("/oauth/device/qr", handler.DeviceQRCode)
+	r.POST("/oauth/device/wait", handler.DeviceWait)
 
 	return r, s
 }
@@ -229,6 +233,9 @@ func TestDeviceCodeRequest_Success(t *testing.T) {
 	assert.NotEmpty(t, resp["device_code"])
 	assert.NotEmpty(t, resp["user_code"])
 	assert.Contains(t, resp["verification_uri"], "/device")
+	assert.Equal(t,
+		"http://localhost:8080/device?user_code="+resp["user_code"].(string),
+		resp["verification_uri_complete"])
 	assert.NotZero(t, resp["expires_in"])
 	assert.NotZero(t, resp["interval"])
 }
@@ -363,6 +370,7 @@ func TestDeviceCodeRequest_DefaultScope(t *testing.T) {
 func setupDeviceVerifyEnv(
 	t *testing.T,
 	resource []string,
+	configure ...func(*config.Config),
 ) (*gin.Engine, *store.Store, *models.OAuthApplication, *models.User, *models.DeviceCode) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
@@ -372,6 +380,9 @@ func setupDeviceVerifyEnv(
 		DeviceCodeExpiration: 30 * time.Minute,
 		PollingInterval:      5,
 	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
 
 	s, err := store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
 	require.NoError(t, err)
@@ -428,9 +439,11 @@ func setupDeviceVerifyEnv(
 	r.Use(func(c *gin.Context) {
 		sess := sessions.Default(c)
 		sess.Set(SessionUserID, user.ID)
+		sess.Set(middleware.SessionID, "test-login-session")
 		_ = sess.Save()
 		c.Next()
 	})
+	r.GET("/device", handler.DevicePage)
 	r.POST("/device/verify", handler.DeviceVerify)
 	return r, s, client, user, dc
 }
@@ -520,6 +533,11 @@ func TestDeviceCodeErrorMessage(t *testing.T) {
 			services.ErrDeviceCodeAlreadyAuthorized,
 			"This code has already been authorized",
 		},
+		{
+			"account locked",
+			services.ErrUserCodeUserLocked,
+			"Too many incorrect codes were entered. Please try again later",
+		},
 		{"unknown error", errors.New("unexpected failure"), "Invalid or expired code"},
 	}
 	for _, tc := range tests {
@@ -528,3 +546,121 @@ func TestDeviceCodeErrorMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestDeviceQRCode(t *testing.T) {
+	r, _ := setupDeviceTestEnv(t)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/device/qr?"+query, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("user_code=ABCD-EFGH")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "\x89PNG"))
+
+	w = get("user_code=abcdefgh&format=svg")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<svg")
+
+	for _, query := range []string{
+		"",
+		"user_code=ABCD",
+		"user_code=ABCD-EFG0",
+		"user_code=ABCD-EFGH&format=gif",
+		"user_code=ABCD-EFGH&scale=0",
+		"user_code=ABCD-EFGH&scale=100",
+	} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+	}
+}
+
+// TestDevicePage_Prefilled asserts that arriving via verification_uri_complete
+// shows the code for confirmation rather than a free-text input.
+func TestDevicePage_Prefilled(t *testing.T) {
+	r, _, client, _, dc := setupDeviceVerifyEnv(t, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/device?user_code="+strings.ToLower(services.FormatUserCode(dc.UserCode)),
+		nil,
+	)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, client.ClientName)
+	assert.Contains(t, body, "Confirm Device Code")
+	assert.Contains(t, body, services.FormatUserCode(dc.UserCode))
+	assert.Contains(t, body, `name="code_confirmed"`)
+}
+
+// TestDeviceVerify_PrefilledRequiresMatchConfirmation asserts a pre-filled
+// code is not authorized until the user confirms it matches their device.
+func TestDeviceVerify_PrefilledRequiresMatchConfirmation(t *testing.T) {
+	r, s, _, _, dc := setupDeviceVerifyEnv(t, nil)
+
+	w := postDeviceVerify(t, r, url.Values{
+		"user_code": {dc.UserCode},
+		"prefilled": {"true"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Confirm that the code matches")
+	dcAfter, err := s.GetDeviceCodeByUserCode(dc.UserCode)
+	require.NoError(t, err)
+	assert.False(t, dcAfter.Authorized)
+
+	w = postDeviceVerify(t, r, url.Values{
+		"user_code":      {dc.UserCode},
+		"prefilled":      {"true"},
+		"code_confirmed": {"true"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	dcAfter, err = s.GetDeviceCodeByUserCode(dc.UserCode)
+	require.NoError(t, err)
+	assert.True(t, dcAfter.Authorized)
+}
+
+// TestDeviceVerify_WrongCodesRequireReLogin asserts that a session entering
+// too many wrong user codes is signed out, and that the account is locked
+// out once its own threshold is reached.
+func TestDeviceVerify_WrongCodesRequireReLogin(t *testing.T) {
+	r, _, _, _, dc := setupDeviceVerifyEnv(t, nil, func(cfg *config.Config) {
+		cfg.DeviceVerifyFailureWindow = 15 * time.Minute
+		cfg.DeviceVerifyMaxSessionFailures = 2
+	})
+
+	w := postDeviceVerify(t, r, url.Values{"user_code": {"ZZZZ-ZZZZ"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "User code not found")
+
+	w = postDeviceVerify(t, r, url.Values{"user_code": {"YYYY-YYYY"}})
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?redirect=%2Fdevice&error=device_code_attempts", w.Header().Get("Location"))
+
+	// The lockout holds even for a valid code until the user signs in again.
+	w = postDeviceVerify(t, r, url.Values{"user_code": {dc.UserCode}})
+	assert.Equal(t, http.StatusFound, w.Code)
+}
+
+func TestDeviceVerify_AccountLockout(t *testing.T) {
+	r, s, _, _, dc := setupDeviceVerifyEnv(t, nil, func(cfg *config.Config) {
+		cfg.DeviceVerifyFailureWindow = 15 * time.Minute
+		cfg.DeviceVerifyMaxUserFailures = 2
+	})
+
+	postDeviceVerify(t, r, url.Values{"user_code": {"ZZZZ-ZZZZ"}})
+	w := postDeviceVerify(t, r, url.Values{"user_code": {"YYYY-YYYY"}})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "Too many incorrect codes")
+
+	w = postDeviceVerify(t, r, url.Values{"user_code": {dc.UserCode}})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	dcAfter, err := s.GetDeviceCodeByUserCode(dc.UserCode)
+	require.NoError(t, err)
+	assert.False(t, dcAfter.Authorized)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Device wait statuses reported by /oauth/device/wait.
+const (
+	deviceWaitStatusAuthorized = "authorized"
+	deviceWaitStatusPending    = errAuthorizationPending
+)
+
+const (
+	// deviceWaitHeartbeat is how often an event stream sends a keep-alive
+	// comment so proxies do not close an idle connection.
+	deviceWaitHeartbeat = 15 * time.Second
+
+	// deviceWaitWriteSlack extends the response write deadline past the wait
+	// timeout, leaving time to write the final response.
+	deviceWaitWriteSlack = 5 * time.Second
+)
+
+// DeviceWait godoc
+//
+//	@Summary		Wait for device authorization
+//	@Description	Long-poll alternative to polling /oauth/token with the device_code grant. Holds the request open until the user authorizes the device code, it expires, or DEVICE_WAIT_TIMEOUT elapses. Returns a status only — exchange the device code at /oauth/token once it reports "authorized". Send "Accept: text/event-stream" to receive Server-Sent Events instead (events: authorized, authorization_pending, error).
+//	@Tags			OAuth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Produce		text/event-stream
+//	@Param			device_code	formData	string											true	"Device code from /oauth/device/code"
+//	@Param			client_id	formData	string											true	"OAuth client ID the device code was issued to"
+//	@Success		200			{object}	object{status=string}							"authorized, or authorization_pending when the wait timed out"
+//	@Failure		400			{object}	object{error=string,error_description=string}	"Invalid request (invalid_request, expired_token, access_denied)"
+//	@Failure		429			{object}	object{error=string,error_description=string}	"Rate limit exceeded"
+//	@Failure		500			{object}	object{error=string,error_description=string}	"Internal server error"
+//	@Router			/oauth/device/wait [post]
+func (h *DeviceHandler) DeviceWait(c *gin.Context) {
+	deviceCode := c.PostForm("device_code")
+	clientID := c.PostForm("client_id")
+	if deviceCode == "" || clientID == "" {
+		respondOAuthError(
+			c,
+			http.StatusBadRequest,
+			errInvalidRequest,
+			"device_code and client_id are required",
+		)
+		return
+	}
+
+	// The server's WriteTimeout would otherwise cut a held request short.
+	// Not every ResponseWriter supports deadlines (e.g. test recorders).
+	timeout := h.config.DeviceWaitTimeout
+	_ = http.NewResponseController(c.Writer).
+		SetWriteDeadline(time.Now().Add(timeout + deviceWaitWriteSlack))
+
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		h.streamDeviceWait(c, deviceCode, clientID, timeout)
+		return
+	}
+
+	dc, err := h.deviceService.WaitForDeviceCodeAuthorization(
+		c.Request.Context(), deviceCode, clientID, timeout,
+	)
+	if err != nil {
+		respondDeviceWaitError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"status": deviceWaitStatus(dc)})
+}
+
+// streamDeviceWait serves DeviceWait as Server-Sent Events. Validation
+// errors found before the first event are returned as plain JSON errors;
+// once the stream has started, the outcome is sent as a final event and the
+// stream closes. When the wait times out the stream closes after an
+// authorization_pending event and the client reconnects.
+func (h *DeviceHandler) streamDeviceWait(
+	c *gin.Context,
+	deviceCode, clientID string,
+	timeout time.Duration,
+) {
+	ctx := c.Request.Context()
+	deadline := time.Now().Add(timeout)
+	started := false
+
+	for {
+		// The first check does not wait, so the stream (or the validation
+		// error) is sent right away.
+		wait := min(time.Until(deadline), deviceWaitHeartbeat)
+		if !started {
+			wait = 0
+		}
+		dc, err := h.deviceService.WaitForDeviceCodeAuthorization(
+			ctx, deviceCode, clientID, wait,
+		)
+		if err != nil && !started {
+			respondDeviceWaitError(c, err)
+			return
+		}
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-store")
+			c.Header("X-Accel-Buffering", "no") // disable nginx response buffering
+			c.Status(http.StatusOK)
+			started = true
+		}
+
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				c.SSEvent("error", gin.H{"error": deviceWaitErrorCode(err)})
+			}
+			return
+		case dc.Authorized:
+			c.SSEvent(deviceWaitStatusAuthorized, gin.H{"status": deviceWaitStatusAuthorized})
+			return
+		case !time.Now().Before(deadline):
+			c.SSEvent(deviceWaitStatusPending, gin.H{"status": deviceWaitStatusPending})
+			return
+		}
+		_, _ = c.Writer.WriteString(": keep-alive\n\n")
+		c.Writer.Flush()
+	}
+}
+
+// deviceWaitStatus returns the status reported for dc.
+func deviceWaitStatus(dc *models.DeviceCode) string {
+	if dc.Authorized {
+		return deviceWaitStatusAuthorized
+	}
+	return deviceWaitStatusPending
+}
+
+// deviceWaitErrorCode maps WaitForDeviceCodeAuthorization errors to the same
+// OAuth error codes the device_code grant uses.
+func deviceWaitErrorCode(err error) string {
+	switch {
+	case errors.Is(err, services.ErrDeviceCodeExpired):
+		return errExpiredToken
+	case errors.Is(err, services.ErrDeviceCodeNotFound):
+		return errAccessDenied
+	default:
+		return errServerError
+	}
+}
+
+// respondDeviceWaitError writes the JSON error response for a failed wait.
+// A canceled request (client went away) gets no response.
+func respondDeviceWaitError(c *gin.Context, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		c.Abort()
+		return
+	}
+	code := deviceWaitErrorCode(err)
+	if code == errServerError {
+		log.Printf("[device] device wait error: %v", err)
+		respondOAuthError(
+			c,
+			http.StatusInternalServerError,
+			errServerError,
+			"An internal error occurred",
+		)
+		return
+	}
+	respondOAuthError(c, http.StatusBadRequest, code, "")
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueDeviceCode requests a device code for client through the handler and
+// returns the stored row with its plaintext device code filled in.
+func issueDeviceCode(
+	t *testing.T,
+	r *gin.Engine,
+	s *store.Store,
+	client *models.OAuthApplication,
+) *models.DeviceCode {
+	t.Helper()
+	w := httptest.NewRecorder()
+	form := url.Values{"client_id": {client.ClientID}}
+	req, _ := http.NewRequest(
+		http.MethodPost,
+		"/oauth/device/code",
+		strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		DeviceCode string `json:"device_code"`
+		UserCode   string `json:"user_code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	dc, err := s.GetDeviceCodeByUserCode(services.NormalizeUserCode(resp.UserCode))
+	require.NoError(t, err)
+	dc.DeviceCode = resp.DeviceCode
+	return dc
+}
+
+func postDeviceWait(
+	t *testing.T,
+	r *gin.Engine,
+	form url.Values,
+	accept string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(
+		http.MethodPost,
+		"/oauth/device/wait",
+		strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeviceWait_MissingParams(t *testing.T) {
+	r, _ := setupDeviceTestEnv(t)
+
+	w := postDeviceWait(t, r, url.Values{"client_id": {"x"}}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errInvalidRequest)
+}
+
+func TestDeviceWait_TimeoutReportsPending(t *testing.T) {
+	r, s := setupDeviceTestEnv(t)
+	client := createDeviceFlowClient(t, s, true, true)
+	dc := issueDeviceCode(t, r, s, client)
+
+	w := postDeviceWait(t, r, url.Values{
+		"device_code": {dc.DeviceCode},
+		"client_id":   {client.ClientID},
+	}, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"authorization_pending"}`, w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestDeviceWait_Authorized(t *testing.T) {
+	r, s := setupDeviceTestEnv(t)
+	client := createDeviceFlowClient(t, s, true, true)
+	dc := issueDeviceCode(t, r, s, client)
+	require.NoError(t, s.AuthorizeDeviceCode(dc.ID, "user-1"))
+
+	w := postDeviceWait(t, r, url.Values{
+		"device_code": {dc.DeviceCode},
+		"client_id":   {client.ClientID},
+	}, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"authorized"}`, w.Body.String())
+}
+
+func TestDeviceWait_WrongClientDenied(t *testing.T) {
+	r, s := setupDeviceTestEnv(t)
+	client := createDeviceFlowClient(t, s, true, true)
+	other := createDeviceFlowClient(t, s, true, true)
+	dc := issueDeviceCode(t, r, s, client)
+
+	// Errors found before the stream starts are plain JSON, even for SSE.
+	for _, accept := range []string{"", "text/event-stream"} {
+		w := postDeviceWait(t, r, url.Values{
+			"device_code": {dc.DeviceCode},
+			"client_id":   {other.ClientID},
+		}, accept)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"access_denied"}`, w.Body.String())
+	}
+}
+
+func TestDeviceWait_EventStream(t *testing.T) {
+	r, s := setupDeviceTestEnv(t)
+	client := createDeviceFlowClient(t, s, true, true)
+	dc := issueDeviceCode(t, r, s, client)
+	form := url.Values{
+		"device_code": {dc.DeviceCode},
+		"client_id":   {client.ClientID},
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		w := postDeviceWait(t, r, form, "text/event-stream")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+		body := w.Body.String()
+		assert.Contains(t, body, ": keep-alive\n\n")
+		assert.Contains(t, body, "event:authorization_pending\n")
+		assert.NotContains(t, body, "event:authorized")
+	})
+
+	t.Run("authorized", func(t *testing.T) {
+		require.NoError(t, s.AuthorizeDeviceCode(dc.ID, "user-1"))
+		w := postDeviceWait(t, r, form, "text/event-stream")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "event:authorized\ndata:{\"status\":\"authorized\"}\n\n")
+	})
+}
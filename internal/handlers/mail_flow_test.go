@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// chanMailer hands every sent message to a channel.
+type chanMailer chan core.MailMessage
+
+func (m chanMailer) Send(_ context.Context, msg core.MailMessage) error {
+	m <- msg
+	return nil
+}
+
+// mailFlowFixture holds the dependencies shared by the handlers that send
+// account email: an in-memory store, the user and token services, and a
+// mailer.
+type mailFlowFixture struct {
+	store  *store.Store
+	cfg    *config.Config
+	users  *services.UserService
+	tokens *services.TokenService
+	mailer chanMailer
+}
+
+// newMailFlowFixture builds a fixture around cfg, defaulting BaseURL.
+func newMailFlowFixture(t *testing.T, cfg *config.Config) *mailFlowFixture {
+	t.Helper()
+	s, err := store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
+	require.NoError(t, err)
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://auth.example.com"
+	}
+	return &mailFlowFixture{
+		store: s,
+		cfg:   cfg,
+		users: services.NewUserService(
+			s, nil, nil, "local", false, services.NewNoopAuditService(),
+			cache.NewNoopCache[models.User](), 0,
+		),
+		tokens: services.NewTokenService(
+			s, cfg, nil, nil, nil, nil, cache.NewNoopCache[models.AccessToken](), nil,
+		),
+		mailer: make(chanMailer, 10),
+	}
+}
+
+// createAlice stores the local user alice@example.com with passwordHash.
+func (f *mailFlowFixture) createAlice(t *testing.T, passwordHash string) *models.User {
+	t.Helper()
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: passwordHash,
+		Role:         models.UserRoleUser,
+		AuthSource:   models.AuthSourceLocal,
+		IsActive:     true,
+	}
+	require.NoError(t, f.store.CreateUser(user))
+	return user
+}
+
+// receiveMailToken waits for the next email and returns it with the token
+// captured by the first group of pattern.
+func receiveMailToken(
+	t *testing.T,
+	mailer chanMailer,
+	pattern *regexp.Regexp,
+) (core.MailMessage, string) {
+	t.Helper()
+	select {
+	case msg := <-mailer:
+		m := pattern.FindStringSubmatch(msg.Text)
+		require.Len(t, m, 2, "email has no link matching %s: %s", pattern, msg.Text)
+		return msg, m[1]
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email sent")
+		return core.MailMessage{}, ""
+	}
+}
+
+func postForm(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/go-authgate/authgate/internal/auth"
 	"github.com/go-authgate/authgate/internal/config"
@@ -26,6 +25,9 @@ const (
 	sessionOAuthProvider   = "oauth_provider"
 	sessionOAuthRedirect   = "oauth_redirect"
 	sessionOAuthRememberMe = "oauth_remember_me"
+	sessionOAuthVerifier   = "oauth_verifier"
+	sessionOAuthNonce      = "oauth_nonce"
+	sessionSAMLRequestID   = "saml_request_id"
 )
 
 // OAuthHandler handles OAuth authentication
@@ -81,10 +83,26 @@ func (h *OAuthHandler) LoginWithProvider(c *gin.Context) {
 		return
 	}
 
+	// OpenID Connect providers bind the code to this browser with PKCE and
+	// the ID token with a nonce.
+	nonce, err := generateRandomState(32)
+	if err != nil {
+		log.Printf("[OAuth] Failed to generate nonce: %v", err)
+		renderErrorPage(
+			c,
+			http.StatusInternalServerError,
+			"Internal server error. Failed to initiate OAuth login.",
+		)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
 	// Save state and redirect URL in session
 	session := sessions.Default(c)
 	session.Set(sessionOAuthState, state)
 	session.Set(sessionOAuthProvider, provider)
+	session.Set(sessionOAuthVerifier, verifier)
+	session.Set(sessionOAuthNonce, nonce)
 
 	redirect := c.Query("redirect")
 	if redirect != "" && util.IsRedirectSafe(redirect, h.cfg.BaseURL) {
@@ -98,6 +116,11 @@ func (h *OAuthHandler) LoginWithProvider(c *gin.Context) {
 		session.Set(sessionOAuthRememberMe, true)
 	}
 
+	if oauthProvider.IsSAML() {
+		h.startSAMLLogin(c, session, oauthProvider, state)
+		return
+	}
+
 	if err := session.Save(); err != nil {
 		log.Printf("[OAuth] Failed to save session: %v", err)
 		renderErrorPage(
@@ -109,7 +132,7 @@ func (h *OAuthHandler) LoginWithProvider(c *gin.Context) {
 	}
 
 	// Redirect to OAuth provider
-	authURL := oauthProvider.GetAuthURL(state)
+	authURL := oauthProvider.GetAuthURL(state, verifier, nonce)
 	c.Redirect(http.StatusTemporaryRedirect, authURL)
 }
 
@@ -121,7 +144,7 @@ func (h *OAuthHandler) OAuthCallback(c *gin.Context) {
 
 	// Verify provider exists
 	oauthProvider, exists := h.providers[provider]
-	if !exists {
+	if !exists || oauthProvider.IsSAML() {
 		renderErrorPage(c, http.StatusBadRequest, "Invalid provider. OAuth provider not found.")
 		return
 	}
@@ -164,7 +187,9 @@ func (h *OAuthHandler) OAuthCallback(c *gin.Context) {
 	ctx := context.WithValue(c.Request.Context(), oauth2.HTTPClient, h.httpClient)
 
 	// Exchange code for token
-	token, err := oauthProvider.ExchangeCode(ctx, code)
+	verifier, _ := session.Get(sessionOAuthVerifier).(string)
+	nonce, _ := session.Get(sessionOAuthNonce).(string)
+	token, err := oauthProvider.ExchangeCode(ctx, code, verifier, nonce)
 	if err != nil {
 		log.Printf("[OAuth] Failed to exchange code: %v", err)
 		renderErrorPage(
@@ -187,6 +212,19 @@ func (h *OAuthHandler) OAuthCallback(c *gin.Context) {
 		return
 	}
 
+	h.completeOAuthLogin(c, session, provider, userInfo, token)
+}
+
+// completeOAuthLogin signs in the user an upstream provider vouched for,
+// linking or creating the account as needed. It is shared by the OAuth and
+// SAML callbacks.
+func (h *OAuthHandler) completeOAuthLogin(
+	c *gin.Context,
+	session sessions.Session,
+	provider string,
+	userInfo *auth.OAuthUserInfo,
+	token *oauth2.Token,
+) {
 	// Authenticate or create user
 	user, err := h.userService.AuthenticateWithOAuth(
 		c.Request.Context(),
@@ -236,30 +274,14 @@ func (h *OAuthHandler) OAuthCallback(c *gin.Context) {
 	// Clear OAuth session data
 	session.Delete(sessionOAuthState)
 	session.Delete(sessionOAuthProvider)
+	session.Delete(sessionOAuthVerifier)
+	session.Delete(sessionOAuthNonce)
+	session.Delete(sessionSAMLRequestID)
 
-	// Save user ID and username in session
-	session.Set(middleware.SessionUserID, user.ID)
-	session.Set(middleware.SessionUsername, user.Username)
-	session.Set(middleware.SessionLastActivity, time.Now().Unix()) // Set initial last activity time
-
-	if remember, _ := session.Get(sessionOAuthRememberMe).(bool); remember &&
-		h.cfg.SessionRememberMeEnabled {
-		middleware.ApplyRememberMe(session, h.cfg.SessionRememberMeMaxAge, h.cfg.IsProduction)
-	}
+	rememberMe, _ := session.Get(sessionOAuthRememberMe).(bool)
+	rememberMe = rememberMe && h.cfg.SessionRememberMeEnabled
 	session.Delete(sessionOAuthRememberMe)
 
-	// Set session fingerprint if enabled
-	if h.cfg.SessionFingerprint {
-		clientIP := c.GetString(middleware.ContextKeyClientIP) // Set by RequestContextMiddleware
-		userAgent := c.Request.UserAgent()
-		fingerprint := middleware.GenerateFingerprint(
-			clientIP,
-			userAgent,
-			h.cfg.SessionFingerprintIP,
-		)
-		session.Set(middleware.SessionFingerprint, fingerprint)
-	}
-
 	// Get redirect URL
 	redirectURL := "/account/sessions"
 	if savedRedirect := session.Get(sessionOAuthRedirect); savedRedirect != nil {
@@ -267,6 +289,15 @@ func (h *OAuthHandler) OAuthCallback(c *gin.Context) {
 		session.Delete(sessionOAuthRedirect)
 	}
 
+	// The upstream provider's own authentication methods are unknown, so an
+	// OAuth login records no method of its own; 2FA still applies on top.
+	if middleware.HasSecondFactor(user, h.cfg.WebAuthnEnabled) {
+		beginSecondFactor(session, user.ID, rememberMe, redirectURL, nil)
+		redirectURL = loginTwoFactorPath
+	} else {
+		startLoginSession(c, session, h.cfg, user, rememberMe, nil)
+	}
+
 	if err := session.Save(); err != nil {
 		log.Printf("[OAuth] Failed to save session: %v", err)
 		renderErrorPage(
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/templates"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// startSAMLLogin sends the browser to a SAML identity provider. The login
+// state travels as RelayState and the request ID stays in the session, so
+// the response can only complete the login this browser started.
+func (h *OAuthHandler) startSAMLLogin(
+	c *gin.Context,
+	session sessions.Session,
+	provider *auth.OAuthProvider,
+	state string,
+) {
+	req, err := provider.NewSAMLAuthnRequest(state)
+	if err != nil {
+		log.Printf("[SAML] Failed to create authentication request: %v", err)
+		renderErrorPage(
+			c,
+			http.StatusInternalServerError,
+			"Internal server error. Failed to initiate SAML login.",
+		)
+		return
+	}
+	session.Set(sessionSAMLRequestID, req.ID)
+	if err := session.Save(); err != nil {
+		log.Printf("[SAML] Failed to save session: %v", err)
+		renderErrorPage(
+			c,
+			http.StatusInternalServerError,
+			"Internal server error. Failed to save session.",
+		)
+		return
+	}
+
+	if req.RedirectURL != "" {
+		c.Redirect(http.StatusTemporaryRedirect, req.RedirectURL)
+		return
+	}
+	templates.RenderTempl(c, http.StatusOK, templates.SAMLPostPage(templates.SAMLPostPageProps{
+		Message:    "Redirecting to " + provider.GetDisplayName(),
+		Action:     req.PostURL,
+		FieldName:  "SAMLRequest",
+		FieldValue: req.SAMLRequest,
+		RelayState: state,
+	}))
+}
+
+// SAMLCallback is the assertion consumer service of a SAML identity
+// provider; it receives the HTTP-POST binding response.
+func (h *OAuthHandler) SAMLCallback(c *gin.Context) {
+	provider := c.Param("provider")
+
+	samlProvider, exists := h.providers[provider]
+	if !exists || !samlProvider.IsSAML() {
+		renderErrorPage(c, http.StatusBadRequest, "Invalid provider. SAML provider not found.")
+		return
+	}
+
+	relayState := c.PostForm("RelayState")
+	if len(relayState) > maxStateLength {
+		renderErrorPage(
+			c,
+			http.StatusBadRequest,
+			"Invalid state parameter. State parameter exceeds maximum length.",
+		)
+		return
+	}
+
+	session := sessions.Default(c)
+	savedState, _ := session.Get(sessionOAuthState).(string)
+	savedProvider, _ := session.Get(sessionOAuthProvider).(string)
+	requestID, _ := session.Get(sessionSAMLRequestID).(string)
+
+	if savedState == "" || requestID == "" {
+		// The IdP posts cross-site, so the SameSite=Lax session cookie is
+		// not sent. Re-post the response from this origin once; that
+		// request carries the cookie.
+		if samlResponse := c.PostForm("SAMLResponse"); samlResponse != "" &&
+			c.PostForm("resumed") != "1" {
+			templates.RenderTempl(c, http.StatusOK, templates.SAMLPostPage(
+				templates.SAMLPostPageProps{
+					Message:    "Completing sign-in",
+					Action:     "/auth/callback/" + provider,
+					FieldName:  "SAMLResponse",
+					FieldValue: samlResponse,
+					RelayState: relayState,
+					Resume:     true,
+				},
+			))
+			return
+		}
+		renderErrorPage(
+			c,
+			http.StatusBadRequest,
+			"Invalid session. SAML session expired or invalid. Please try again.",
+		)
+		return
+	}
+
+	if relayState != savedState || provider != savedProvider {
+		renderErrorPage(
+			c,
+			http.StatusBadRequest,
+			"Invalid state. CSRF validation failed. Please try again.",
+		)
+		return
+	}
+
+	userInfo, err := samlProvider.ParseSAMLResponse(c.Request, requestID)
+	if err != nil {
+		log.Printf("[SAML] Rejected response from provider=%s: %v", provider, err)
+		h.metrics.RecordOAuthCallback(provider, false)
+		renderErrorPage(
+			c,
+			http.StatusBadRequest,
+			"SAML error. The identity provider's response could not be verified.",
+		)
+		return
+	}
+
+	// SAML logins carry no upstream token to store on the connection.
+	h.completeOAuthLogin(c, session, provider, userInfo, &oauth2.Token{})
+}
+
+// SAMLMetadata serves the service provider metadata for a SAML identity
+// provider. The URL doubles as the service provider's entity ID.
+func (h *OAuthHandler) SAMLMetadata(c *gin.Context) {
+	samlProvider, exists := h.providers[c.Param("provider")]
+	if !exists || !samlProvider.IsSAML() {
+		renderErrorPage(c, http.StatusNotFound, "Invalid provider. SAML provider not found.")
+		return
+	}
+	metadata, err := samlProvider.SAMLMetadata()
+	if err != nil {
+		log.Printf("[SAML] Failed to build metadata: %v", err)
+		renderErrorPage(
+			c,
+			http.StatusInternalServerError,
+			"Internal server error. Failed to build SAML metadata.",
+		)
+		return
+	}
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/auth/samltest"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/metrics"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/crewjam/saml"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type samlTestEnv struct {
+	router *gin.Engine
+	store  *store.Store
+	idp    *saml.IdentityProvider
+	sp     *saml.EntityDescriptor
+}
+
+// GetServiceProvider implements saml.ServiceProviderProvider.
+func (e *samlTestEnv) GetServiceProvider(*http.Request, string) (*saml.EntityDescriptor, error) {
+	return e.sp, nil
+}
+
+func setupSAMLTest(t *testing.T) *samlTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	env := &samlTestEnv{}
+
+	idpCert, idpKey := samltest.KeyPair(t)
+	env.idp = &saml.IdentityProvider{
+		Key:         idpKey,
+		Certificate: idpCert,
+		MetadataURL: url.URL{
+			Scheme: "https", Host: "idp.example.com", Path: "/metadata",
+		},
+		SSOURL:                  url.URL{Scheme: "https", Host: "idp.example.com", Path: "/sso"},
+		ServiceProviderProvider: env,
+	}
+	idpMetadata, err := xml.Marshal(env.idp.Metadata())
+	require.NoError(t, err)
+
+	spCert, spKey := samltest.KeyPair(t)
+	provider, err := auth.NewSAMLProvider(auth.SAMLProviderConfig{
+		Name:        "corp",
+		EntityID:    "http://localhost:8080/auth/metadata/corp",
+		ACSURL:      "http://localhost:8080/auth/callback/corp",
+		IDPMetadata: idpMetadata,
+		Certificate: spCert,
+		Key:         spKey,
+		Attributes:  auth.SAMLAttributeMapping{Username: "uid", Email: "mail"},
+		TrustEmail:  true,
+	})
+	require.NoError(t, err)
+	env.sp = &saml.EntityDescriptor{}
+	spMetadata, err := provider.SAMLMetadata()
+	require.NoError(t, err)
+	require.NoError(t, xml.Unmarshal(spMetadata, env.sp))
+
+	env.store, err = store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
+	require.NoError(t, err)
+	userSvc := services.NewUserService(
+		env.store, nil, nil, services.AuthModeLocal, true, services.NewNoopAuditService(), nil, 0,
+	)
+	h := NewOAuthHandler(
+		map[string]*auth.OAuthProvider{"corp": provider},
+		userSvc,
+		http.DefaultClient,
+		&config.Config{BaseURL: "http://localhost:8080"},
+		metrics.NewNoopMetrics(),
+	)
+
+	env.router = gin.New()
+	env.router.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+	env.router.GET("/auth/login/:provider", h.LoginWithProvider)
+	env.router.GET("/auth/callback/:provider", h.OAuthCallback)
+	env.router.POST("/auth/callback/:provider", h.SAMLCallback)
+	env.router.GET("/auth/metadata/:provider", h.SAMLMetadata)
+	env.router.GET("/test-session", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": sessions.Default(c).Get("user_id")})
+	})
+	return env
+}
+
+// login starts a SAML login and returns the IdP's response form plus the
+// session cookies of the browser that started it.
+func (e *samlTestEnv) login(t *testing.T) (url.Values, []*http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/login/corp", nil))
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	location := w.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, "https://idp.example.com/sso?"))
+
+	req, err := saml.NewIdpAuthnRequest(e.idp, httptest.NewRequest(http.MethodGet, location, nil))
+	require.NoError(t, err)
+	require.NoError(t, req.Validate())
+	require.NoError(t, saml.DefaultAssertionMaker{}.MakeAssertion(req, &saml.Session{
+		ID:           "session-1",
+		CreateTime:   time.Now(),
+		ExpireTime:   time.Now().Add(time.Hour),
+		NameID:       "jane-persistent-id",
+		NameIDFormat: string(saml.PersistentNameIDFormat),
+		UserName:     "jane",
+		CustomAttributes: []saml.Attribute{{
+			Name:   "mail",
+			Values: []saml.AttributeValue{{Type: "xs:string", Value: "jane@example.com"}},
+		}},
+	}))
+	form, err := req.PostBinding()
+	require.NoError(t, err)
+	return url.Values{
+		"SAMLResponse": {form.SAMLResponse},
+		"RelayState":   {form.RelayState},
+	}, sessionCookies(w)
+}
+
+func (e *samlTestEnv) post(form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(
+		http.MethodPost, "/auth/callback/corp", strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSAMLCallback_ResumesCrossSitePost(t *testing.T) {
+	env := setupSAMLTest(t)
+	form, cookies := env.login(t)
+
+	// The IdP's cross-site POST arrives without the Lax session cookie.
+	w := env.post(form, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/auth/callback/corp"`)
+	assert.Contains(t, w.Body.String(), `name="resumed" value="1"`)
+
+	// A resumed post still without a session is not bounced again.
+	form.Set("resumed", "1")
+	w = env.post(form, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = env.post(form, cookies)
+	require.Equal(t, http.StatusFound, w.Code, "body=%s", w.Body.String())
+	assert.Equal(t, "/account/sessions", w.Header().Get("Location"))
+
+	conn, err := env.store.GetOAuthConnection("corp", "jane-persistent-id")
+	require.NoError(t, err)
+	assert.Equal(t, models.ConnectionTypeSAML, conn.ConnectionType)
+	assert.Equal(t, "jane@example.com", conn.ProviderEmail)
+
+	sess := readSession(t, env.router, sessionCookies(w))
+	assert.Equal(t, conn.UserID, sess["user_id"])
+}
+
+func TestSAMLCallback_Rejects(t *testing.T) {
+	env := setupSAMLTest(t)
+	form, cookies := env.login(t)
+
+	t.Run("relay state mismatch", func(t *testing.T) {
+		tampered := url.Values{
+			"SAMLResponse": {form.Get("SAMLResponse")},
+			"RelayState":   {"other-state"},
+		}
+		assert.Equal(t, http.StatusBadRequest, env.post(tampered, cookies).Code)
+	})
+
+	t.Run("unsigned garbage", func(t *testing.T) {
+		garbage := url.Values{
+			"SAMLResponse": {"PHNhbWw+PC9zYW1sPg=="},
+			"RelayState":   {form.Get("RelayState")},
+		}
+		assert.Equal(t, http.StatusBadRequest, env.post(garbage, cookies).Code)
+	})
+
+	t.Run("OAuth callback for a SAML provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, httptest.NewRequest(
+			http.MethodGet, "/auth/callback/corp?code=x&state=y", nil,
+		))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSAMLMetadata(t *testing.T) {
+	env := setupSAMLTest(t)
+
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/metadata/corp", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/samlmetadata+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `entityID="http://localhost:8080/auth/metadata/corp"`)
+
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/metadata/github", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -217,12 +217,17 @@ func (h *OIDCHandler) Discovery(c *gin.Context) {
 			"auth_time",
 			"nonce",
 			"at_hash",
+			"sid",
+			"amr",
 			"name",
 			"preferred_username",
 			"email",
 			"email_verified",
 			"picture",
 			"updated_at",
+			"roles",
+			"groups",
+			"attributes",
 		},
 		CodeChallengeMethodsSupported: base.CodeChallengeMethodsSupported,
 	}
@@ -342,6 +347,15 @@ func buildUserInfoClaims(userID, issuer, scopes string, user *models.User) map[s
 			claims["picture"] = user.AvatarURL
 		}
 		claims["updated_at"] = user.UpdatedAt.Unix()
+		if roles := user.ExternalRoles(); len(roles) > 0 {
+			claims["roles"] = roles
+		}
+		if len(user.Groups) > 0 {
+			claims["groups"] = user.Groups
+		}
+		if len(user.Attributes) > 0 {
+			claims["attributes"] = user.Attributes
+		}
 	}
 
 	if scopeSet["email"] {
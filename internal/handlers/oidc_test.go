@@ -139,6 +139,25 @@ func TestBuildUserInfoClaims_NoPictureWhenEmpty(t *testing.T) {
 	assert.False(t, hasPicture)
 }
 
+func TestBuildUserInfoClaims_GroupsAndAttributes(t *testing.T) {
+	user := &models.User{
+		Username:     "ext-user",
+		ExternalRole: "editor",
+		Groups:       models.StringArray{"staff", "ops"},
+		Attributes:   models.UserAttributes{"department": "R&D"},
+	}
+	claims := buildUserInfoClaims("user-002", "https://auth.example.com", "profile", user)
+	assert.Equal(t, []string{"editor"}, claims["roles"])
+	assert.Equal(t, models.StringArray{"staff", "ops"}, claims["groups"])
+	assert.Equal(t, models.UserAttributes{"department": "R&D"}, claims["attributes"])
+
+	// All are profile claims
+	claims = buildUserInfoClaims("user-002", "https://auth.example.com", "email", user)
+	assert.Nil(t, claims["roles"])
+	assert.Nil(t, claims["groups"])
+	assert.Nil(t, claims["attributes"])
+}
+
 // ============================================================
 // OIDCHandler.Discovery (HTTP handler test)
 // ============================================================
@@ -200,6 +219,7 @@ func TestDiscovery_ReturnsCorrectMetadata(t *testing.T) {
 	assert.Contains(t, claims, "auth_time")
 	assert.Contains(t, claims, "nonce")
 	assert.Contains(t, claims, "at_hash")
+	assert.Contains(t, claims, "amr")
 	assert.Contains(t, claims, "email_verified")
 	assert.Contains(t, claims, "name")
 	assert.Contains(t, claims, "preferred_username")
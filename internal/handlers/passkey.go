@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/util"
+	"github.com/go-authgate/authgate/internal/webauthn"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPasskeyRequestSize caps the JSON body of a passkey ceremony; real
+// responses are a few kilobytes at most.
+const maxPasskeyRequestSize = 64 << 10
+
+// Passkey ceremony session keys. The challenge handed to the browser is kept
+// server-side (in the signed session) together with what it was issued for,
+// and is consumed by the first attempt to finish the ceremony.
+const (
+	sessionPasskeyChallenge = "passkey_challenge"
+	sessionPasskeyPurpose   = "passkey_purpose"
+	sessionPasskeyStarted   = "passkey_started"
+)
+
+// Ceremony purposes, so a challenge issued for one flow cannot finish another.
+const (
+	passkeyPurposeRegister     = "register"
+	passkeyPurposeLogin        = "login"
+	passkeyPurposeSecondFactor = "second_factor"
+)
+
+// passkeyRegisterRequest is the body of POST /account/security/passkeys.
+type passkeyRegisterRequest struct {
+	Name       string                         `json:"name"`
+	Credential *webauthn.RegistrationResponse `json:"credential" binding:"required"`
+}
+
+// passkeyLoginRequest is the body of POST /login/passkey and
+// POST /login/2fa/passkey.
+type passkeyLoginRequest struct {
+	Credential *webauthn.AssertionResponse `json:"credential" binding:"required"`
+	Redirect   string                      `json:"redirect"`
+	RememberMe bool                        `json:"remember_me"`
+}
+
+// storePasskeyChallenge remembers the challenge of a ceremony just started.
+func storePasskeyChallenge(session sessions.Session, purpose, challenge string) {
+	session.Set(sessionPasskeyChallenge, challenge)
+	session.Set(sessionPasskeyPurpose, purpose)
+	session.Set(sessionPasskeyStarted, time.Now().Unix())
+}
+
+// takePasskeyChallenge removes and returns the pending challenge if it was
+// issued for purpose and has not expired. The caller saves the session.
+func takePasskeyChallenge(session sessions.Session, purpose string) (string, bool) {
+	challenge, _ := session.Get(sessionPasskeyChallenge).(string)
+	storedPurpose, _ := session.Get(sessionPasskeyPurpose).(string)
+	started, _ := session.Get(sessionPasskeyStarted).(int64)
+	session.Delete(sessionPasskeyChallenge)
+	session.Delete(sessionPasskeyPurpose)
+	session.Delete(sessionPasskeyStarted)
+	if challenge == "" || storedPurpose != purpose ||
+		time.Since(time.Unix(started, 0)) > webauthn.Timeout {
+		return "", false
+	}
+	return challenge, true
+}
+
+// passkeyError writes a JSON error for the passkey scripts. redirect, when
+// set, tells the page to navigate away instead of offering a retry.
+func passkeyError(c *gin.Context, status int, message, redirect string) {
+	body := gin.H{"error": message}
+	if redirect != "" {
+		body["redirect"] = redirect
+	}
+	c.JSON(status, body)
+}
+
+// bindPasskeyRequest decodes a size-limited JSON body into req.
+func bindPasskeyRequest(c *gin.Context, req any) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPasskeyRequestSize)
+	if err := c.ShouldBindJSON(req); err != nil {
+		passkeyError(c, http.StatusBadRequest, "Invalid request", "")
+		return false
+	}
+	return true
+}
+
+// abandonPasskeyLogin discards a pending login and tells the page to go back
+// to /login, the JSON counterpart of abandonLogin.
+func abandonPasskeyLogin(c *gin.Context, session sessions.Session, reason string) {
+	clearPendingSecondFactor(session)
+	if err := session.Save(); err != nil {
+		log.Printf("[Passkey] Failed to save session: %v", err)
+	}
+	passkeyError(c, http.StatusUnauthorized, loginErrorMessages[reason], "/login?error="+reason)
+}
+
+// savePasskeySession saves the session, answering with a JSON error on failure.
+func savePasskeySession(c *gin.Context, session sessions.Session) bool {
+	if err := session.Save(); err != nil {
+		log.Printf("[Passkey] Failed to save session: %v", err)
+		passkeyError(c, http.StatusInternalServerError, "Failed to save session", "")
+		return false
+	}
+	return true
+}
+
+// PasskeyRegistrationOptions starts registering a passkey for the signed-in
+// user.
+func (h *TwoFactorHandler) PasskeyRegistrationOptions(c *gin.Context) {
+	opts, err := h.passkeyService.BeginRegistration(getUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrPasskeyNotAllowed) ||
+			errors.Is(err, services.ErrPasskeyLimitReached) {
+			passkeyError(c, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+		log.Printf("[Passkey] Failed to start registration: %v", err)
+		passkeyError(c, http.StatusInternalServerError, "Failed to start passkey setup", "")
+		return
+	}
+
+	session := sessions.Default(c)
+	storePasskeyChallenge(session, passkeyPurposeRegister, opts.Challenge)
+	if !savePasskeySession(c, session) {
+		return
+	}
+	c.JSON(http.StatusOK, opts)
+}
+
+// RegisterPasskey stores the passkey the browser just created.
+func (h *TwoFactorHandler) RegisterPasskey(c *gin.Context) {
+	var req passkeyRegisterRequest
+	if !bindPasskeyRequest(c, &req) {
+		return
+	}
+
+	session := sessions.Default(c)
+	challenge, ok := takePasskeyChallenge(session, passkeyPurposeRegister)
+	if !savePasskeySession(c, session) {
+		return
+	}
+	if !ok {
+		passkeyError(c, http.StatusBadRequest, "Passkey setup expired. Please try again.", "")
+		return
+	}
+
+	_, err := h.passkeyService.FinishRegistration(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		challenge,
+		req.Name,
+		req.Credential,
+	)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"redirect": accountSecurityPath + "?success=passkey_added"})
+	case errors.Is(err, services.ErrInvalidPasskey):
+		log.Printf("[Passkey] Registration rejected: %v", err)
+		passkeyError(c, http.StatusBadRequest, "The passkey could not be verified. Please try again.", "")
+	case errors.Is(err, services.ErrPasskeyNotAllowed),
+		errors.Is(err, services.ErrPasskeyAlreadyRegistered):
+		passkeyError(c, http.StatusBadRequest, err.Error(), "")
+	default:
+		log.Printf("[Passkey] Failed to register passkey: %v", err)
+		passkeyError(c, http.StatusInternalServerError, "Failed to save passkey", "")
+	}
+}
+
+// DeletePasskey removes one of the signed-in user's passkeys.
+func (h *TwoFactorHandler) DeletePasskey(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	user, err := h.userService.AdminGetUserByID(userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load account")
+		return
+	}
+	passkeys, err := h.passkeyService.ListPasskeys(userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load passkeys")
+		return
+	}
+	if !h.passkeyRemovable(user, len(passkeys)) {
+		h.renderSecurityPage(c, http.StatusForbidden, nil, nil,
+			"Two-factor authentication is required for your account. Add another passkey or an authenticator app first.")
+		return
+	}
+
+	err = h.passkeyService.DeletePasskey(c.Request.Context(), userID, c.Param("id"))
+	switch {
+	case err == nil:
+		c.Redirect(http.StatusFound, accountSecurityPath+"?success=passkey_removed")
+	case errors.Is(err, services.ErrPasskeyNotFound):
+		h.renderSecurityPage(c, http.StatusNotFound, nil, nil, err.Error())
+	default:
+		log.Printf("[Passkey] Failed to delete passkey: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to remove passkey")
+	}
+}
+
+// PasskeyLoginOptions starts a passwordless login.
+func (h *TwoFactorHandler) PasskeyLoginOptions(c *gin.Context) {
+	opts, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		log.Printf("[Passkey] Failed to start login: %v", err)
+		passkeyError(c, http.StatusInternalServerError, "Failed to start passkey sign-in", "")
+		return
+	}
+
+	session := sessions.Default(c)
+	storePasskeyChallenge(session, passkeyPurposeLogin, opts.Challenge)
+	if !savePasskeySession(c, session) {
+		return
+	}
+	c.JSON(http.StatusOK, opts)
+}
+
+// PasskeyLogin signs in the owner of the presented passkey. The passkey
+// stands in for both factors, so no second step follows.
+func (h *TwoFactorHandler) PasskeyLogin(c *gin.Context) {
+	var req passkeyLoginRequest
+	if !bindPasskeyRequest(c, &req) {
+		return
+	}
+	redirectTo := req.Redirect
+	if !util.IsRedirectSafe(redirectTo, h.cfg.BaseURL) {
+		redirectTo = "/account/sessions"
+	}
+
+	session := sessions.Default(c)
+	challenge, ok := takePasskeyChallenge(session, passkeyPurposeLogin)
+	if !ok {
+		if savePasskeySession(c, session) {
+			passkeyError(c, http.StatusBadRequest, "Passkey sign-in expired. Please try again.", "")
+		}
+		return
+	}
+
+	// The session is saved once below, whatever the outcome, so the challenge
+	// is spent even when verification fails.
+	user, err := h.passkeyService.FinishLogin(c.Request.Context(), challenge, req.Credential)
+	if err == nil {
+		rememberMe := h.cfg.SessionRememberMeEnabled && req.RememberMe
+		startLoginSession(c, session, h.cfg, user, rememberMe, []string{amrHardwareKey, amrMFA})
+	}
+	if !savePasskeySession(c, session) {
+		return
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountDisabled):
+			passkeyError(c, http.StatusUnauthorized,
+				"Your account has been disabled. Please contact your administrator.", "")
+		case errors.Is(err, services.ErrInvalidPasskey):
+			log.Printf("[Passkey] Login rejected: %v", err)
+			passkeyError(c, http.StatusUnauthorized, "This passkey could not be verified.", "")
+		default:
+			log.Printf("[Passkey] Login error: %v", err)
+			passkeyError(c, http.StatusInternalServerError, "Unable to sign in right now. Please try again.", "")
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"redirect": redirectTo})
+}
+
+// PasskeySecondFactorOptions offers the pending user's passkeys as the
+// second login step.
+func (h *TwoFactorHandler) PasskeySecondFactorOptions(c *gin.Context) {
+	session := sessions.Default(c)
+	userID, ok := pendingSecondFactorUser(session)
+	if !ok {
+		abandonPasskeyLogin(c, session, "two_factor_expired")
+		return
+	}
+
+	opts, err := h.passkeyService.BeginSecondFactor(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrPasskeyNotFound) {
+			passkeyError(c, http.StatusBadRequest, "No passkey is registered for this account.", "")
+			return
+		}
+		log.Printf("[Passkey] Failed to start second factor: %v", err)
+		passkeyError(c, http.StatusInternalServerError, "Failed to start passkey verification", "")
+		return
+	}
+
+	storePasskeyChallenge(session, passkeyPurposeSecondFactor, opts.Challenge)
+	if !savePasskeySession(c, session) {
+		return
+	}
+	c.JSON(http.StatusOK, opts)
+}
+
+// PasskeySecondFactor completes a pending password login with one of the
+// user's passkeys. Failures count against the same attempt limit as codes.
+func (h *TwoFactorHandler) PasskeySecondFactor(c *gin.Context) {
+	var req passkeyLoginRequest
+	if !bindPasskeyRequest(c, &req) {
+		return
+	}
+
+	session := sessions.Default(c)
+	userID, ok := pendingSecondFactorUser(session)
+	if !ok {
+		abandonPasskeyLogin(c, session, "two_factor_expired")
+		return
+	}
+	challenge, ok := takePasskeyChallenge(session, passkeyPurposeSecondFactor)
+	if !ok {
+		if savePasskeySession(c, session) {
+			passkeyError(c, http.StatusBadRequest,
+				"Passkey verification expired. Please try again.", "")
+		}
+		return
+	}
+
+	// Each outcome below saves the session once, spending the challenge.
+
+	ctx := c.Request.Context()
+	if err := h.passkeyService.FinishSecondFactor(ctx, userID, challenge, req.Credential); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPasskey):
+			if !recordSecondFactorFailure(session) {
+				abandonPasskeyLogin(c, session, "two_factor_attempts")
+				return
+			}
+			passkeyError(c, http.StatusUnauthorized, "This passkey could not be verified.", "")
+		case errors.Is(err, services.ErrAccountDisabled):
+			abandonPasskeyLogin(c, session, "two_factor_expired")
+		default:
+			log.Printf("[Passkey] Second factor error: %v", err)
+			if !savePasskeySession(c, session) {
+				return
+			}
+			passkeyError(c, http.StatusInternalServerError,
+				"Unable to verify the passkey right now. Please try again.", "")
+		}
+		return
+	}
+
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		abandonPasskeyLogin(c, session, "two_factor_expired")
+		return
+	}
+
+	redirectTo := completeSecondFactor(c, session, h.cfg, user, amrHardwareKey)
+	if !savePasskeySession(c, session) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"redirect": redirectTo})
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/webauthn"
+	"github.com/go-authgate/authgate/internal/webauthn/webauthntest"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var passkeyTestRP = &webauthn.RelyingParty{
+	ID:      "auth.example.com",
+	Name:    "AuthGate",
+	Origins: []string{"https://auth.example.com"},
+}
+
+type passkeyTestEnv struct {
+	*twoFactorTestEnv
+	authn *webauthntest.Authenticator
+}
+
+// setupPasskeyTest registers a passkey for a local user and wires the
+// passkey login routes next to the /seed and /whoami helpers.
+func setupPasskeyTest(t *testing.T) *passkeyTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
+	require.NoError(t, err)
+	userSvc := services.NewUserService(
+		s, nil, nil, "local", false, services.NewNoopAuditService(),
+		cache.NewNoopCache[models.User](), 0,
+	)
+	passkeySvc := services.NewPasskeyService(s, userSvc, passkeyTestRP, nil)
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hash",
+		Role:         models.UserRoleUser,
+		AuthSource:   models.AuthSourceLocal,
+		IsActive:     true,
+	}
+	require.NoError(t, s.CreateUser(user))
+	opts, err := passkeySvc.BeginRegistration(user.ID)
+	require.NoError(t, err)
+	authn := webauthntest.New(passkeyTestRP.ID, passkeyTestRP.Origins[0], []byte(user.ID))
+	_, err = passkeySvc.FinishRegistration(
+		context.Background(), user.ID, opts.Challenge, "Laptop", authn.Create(opts.Challenge),
+	)
+	require.NoError(t, err)
+
+	handler := NewTwoFactorHandler(userSvc, passkeySvc, &config.Config{
+		BaseURL:         "https://auth.example.com",
+		WebAuthnEnabled: true,
+	})
+	r := gin.New()
+	r.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+	r.GET("/seed", func(c *gin.Context) {
+		session := sessions.Default(c)
+		beginSecondFactor(session, user.ID, false, "/oauth/authorize?client_id=x",
+			[]string{amrPassword})
+		require.NoError(t, session.Save())
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/whoami", func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, _ := session.Get(SessionUserID).(string)
+		methods, _ := session.Get(middleware.SessionAuthMethods).(string)
+		c.String(http.StatusOK, userID+"|"+methods)
+	})
+	r.POST("/login/passkey/options", handler.PasskeyLoginOptions)
+	r.POST("/login/passkey", handler.PasskeyLogin)
+	r.POST("/login/2fa/passkey/options", handler.PasskeySecondFactorOptions)
+	r.POST("/login/2fa/passkey", handler.PasskeySecondFactor)
+
+	return &passkeyTestEnv{
+		twoFactorTestEnv: &twoFactorTestEnv{router: r, user: user},
+		authn:            authn,
+	}
+}
+
+func postPasskeyJSON(t *testing.T, path string, body any) *http.Request {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// passkeyOptions starts a ceremony and returns its challenge.
+func (e *passkeyTestEnv) passkeyOptions(
+	t *testing.T,
+	path string,
+	cookies []*http.Cookie,
+) (string, []*http.Cookie) {
+	t.Helper()
+	w, cookies := e.do(t, postPasskeyJSON(t, path, map[string]any{}), cookies)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var opts webauthn.RequestOptions
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &opts))
+	return opts.Challenge, cookies
+}
+
+func decodePasskeyResult(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func TestPasskeyLogin_Success(t *testing.T) {
+	env := setupPasskeyTest(t)
+
+	challenge, cookies := env.passkeyOptions(t, "/login/passkey/options", nil)
+	w, cookies := env.do(t, postPasskeyJSON(t, "/login/passkey", passkeyLoginRequest{
+		Credential: env.authn.Get(challenge),
+		Redirect:   "/oauth/authorize?client_id=x",
+	}), cookies)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "/oauth/authorize?client_id=x", decodePasskeyResult(t, w)["redirect"])
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, env.user.ID+"|hwk mfa", w.Body.String())
+}
+
+func TestPasskeyLogin_UnsafeRedirect(t *testing.T) {
+	env := setupPasskeyTest(t)
+
+	challenge, cookies := env.passkeyOptions(t, "/login/passkey/options", nil)
+	w, _ := env.do(t, postPasskeyJSON(t, "/login/passkey", passkeyLoginRequest{
+		Credential: env.authn.Get(challenge),
+		Redirect:   "https://evil.example.com/",
+	}), cookies)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/account/sessions", decodePasskeyResult(t, w)["redirect"])
+}
+
+func TestPasskeyLogin_ChallengeUsedOnce(t *testing.T) {
+	env := setupPasskeyTest(t)
+
+	challenge, cookies := env.passkeyOptions(t, "/login/passkey/options", nil)
+	bad := webauthntest.New(passkeyTestRP.ID, passkeyTestRP.Origins[0], nil)
+	w, cookies := env.do(t, postPasskeyJSON(t, "/login/passkey", passkeyLoginRequest{
+		Credential: bad.Get(challenge),
+	}), cookies)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// The failed attempt consumed the challenge.
+	w, _ = env.do(t, postPasskeyJSON(t, "/login/passkey", passkeyLoginRequest{
+		Credential: env.authn.Get(challenge),
+	}), cookies)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPasskeyLogin_WrongCeremony(t *testing.T) {
+	env := setupPasskeyTest(t)
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+
+	// A challenge issued for the second factor cannot finish a passwordless login.
+	challenge, cookies := env.passkeyOptions(t, "/login/2fa/passkey/options", cookies)
+	w, _ := env.do(t, postPasskeyJSON(t, "/login/passkey", passkeyLoginRequest{
+		Credential: env.authn.Get(challenge),
+	}), cookies)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPasskeySecondFactor_Success(t *testing.T) {
+	env := setupPasskeyTest(t)
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+
+	challenge, cookies := env.passkeyOptions(t, "/login/2fa/passkey/options", cookies)
+	w, cookies := env.do(t, postPasskeyJSON(t, "/login/2fa/passkey", passkeyLoginRequest{
+		Credential: env.authn.Get(challenge),
+	}), cookies)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "/oauth/authorize?client_id=x", decodePasskeyResult(t, w)["redirect"])
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, env.user.ID+"|pwd hwk mfa", w.Body.String())
+}
+
+func TestPasskeySecondFactor_NoPendingLogin(t *testing.T) {
+	env := setupPasskeyTest(t)
+
+	w, _ := env.do(t, postPasskeyJSON(t, "/login/2fa/passkey/options", map[string]any{}), nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "/login?error=two_factor_expired", decodePasskeyResult(t, w)["redirect"])
+}
+
+func TestPasskeySecondFactor_TooManyAttempts(t *testing.T) {
+	env := setupPasskeyTest(t)
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+	bad := webauthntest.New(passkeyTestRP.ID, passkeyTestRP.Origins[0], nil)
+
+	var w *httptest.ResponseRecorder
+	for range maxTwoFactorAttempts {
+		var challenge string
+		challenge, cookies = env.passkeyOptions(t, "/login/2fa/passkey/options", cookies)
+		w, cookies = env.do(t, postPasskeyJSON(t, "/login/2fa/passkey", passkeyLoginRequest{
+			Credential: bad.Get(challenge),
+		}), cookies)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	assert.Equal(t, "/login?error=two_factor_attempts", decodePasskeyResult(t, w)["redirect"])
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, "|", w.Body.String())
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetHandler serves the self-service "forgot password" flow for
+// local users.
+type PasswordResetHandler struct {
+	passwordResetService *services.PasswordResetService
+}
+
+func NewPasswordResetHandler(s *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{passwordResetService: s}
+}
+
+func passwordResetNavbar(c *gin.Context) templates.NavbarProps {
+	return templates.NavbarProps{DocsNavEntries: NavbarDocsEntriesFor(resolveLocale(c))}
+}
+
+// ForgotPasswordPage shows the form asking for the account's email address.
+func (h *PasswordResetHandler) ForgotPasswordPage(c *gin.Context) {
+	templates.RenderTempl(c, http.StatusOK, templates.PasswordForgotPage(
+		templates.PasswordForgotPageProps{
+			BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+			NavbarProps: passwordResetNavbar(c),
+		},
+	))
+}
+
+// ForgotPassword emails a reset link. The response is the same whether or
+// not the address belongs to an account.
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	props := templates.PasswordForgotPageProps{
+		BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps: passwordResetNavbar(c),
+		Sent:        true,
+	}
+	if err := h.passwordResetService.RequestReset(
+		c.Request.Context(), c.PostForm("email"),
+	); err != nil {
+		log.Printf("[PasswordReset] Failed to handle reset request: %v", err)
+		props.Sent = false
+		props.Error = "We could not process your request. Please try again later."
+		templates.RenderTempl(c, http.StatusInternalServerError, templates.PasswordForgotPage(props))
+		return
+	}
+	templates.RenderTempl(c, http.StatusOK, templates.PasswordForgotPage(props))
+}
+
+// ResetPasswordPage shows the new password form for the link in the query
+// string, or explains that the link can no longer be used.
+func (h *PasswordResetHandler) ResetPasswordPage(c *gin.Context) {
+	// The token is in the URL; keep it out of Referer headers.
+	c.Header("Referrer-Policy", "no-referrer")
+
+	token := c.Query("token")
+	props := templates.PasswordResetPageProps{
+		BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps: passwordResetNavbar(c),
+		Token:       token,
+		MinLength:   services.MinPasswordLength,
+	}
+	if err := h.passwordResetService.ValidateToken(token); err != nil {
+		if !errors.Is(err, services.ErrPasswordResetTokenInvalid) {
+			log.Printf("[PasswordReset] Failed to validate reset token: %v", err)
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to load the password reset page")
+			return
+		}
+		props.Token = ""
+		props.Invalid = true
+	}
+	templates.RenderTempl(c, http.StatusOK, templates.PasswordResetPage(props))
+}
+
+// ResetPassword sets the new password and sends the user to sign in with it.
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	c.Header("Referrer-Policy", "no-referrer")
+
+	token := c.PostForm("token")
+	password := c.PostForm("password")
+	props := templates.PasswordResetPageProps{
+		BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps: passwordResetNavbar(c),
+		Token:       token,
+		MinLength:   services.MinPasswordLength,
+	}
+	if password != c.PostForm("confirm_password") {
+		props.Error = "The passwords do not match."
+		templates.RenderTempl(c, http.StatusBadRequest, templates.PasswordResetPage(props))
+		return
+	}
+
+	_, err := h.passwordResetService.ResetPassword(c.Request.Context(), token, password)
+	switch {
+	case err == nil:
+		c.Redirect(http.StatusFound, "/login?notice=password_reset")
+	case errors.Is(err, services.ErrPasswordResetTokenInvalid):
+		props.Token = ""
+		props.Invalid = true
+		templates.RenderTempl(c, http.StatusBadRequest, templates.PasswordResetPage(props))
+	case errors.Is(err, services.ErrPasswordTooShort), errors.Is(err, services.ErrPasswordTooLong):
+		props.Error = err.Error() + "."
+		templates.RenderTempl(c, http.StatusBadRequest, templates.PasswordResetPage(props))
+	default:
+		log.Printf("[PasswordReset] Failed to reset password: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to reset password")
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var resetTokenPattern = regexp.MustCompile(`/password/reset\?token=(\S+)`)
+
+func setupPasswordResetTest(t *testing.T) (*gin.Engine, chanMailer, *models.User) {
+	t.Helper()
+	f := newMailFlowFixture(t, &config.Config{
+		PasswordResetTokenTTL:   30 * time.Minute,
+		PasswordResetMaxPerHour: 3,
+	})
+	handler := NewPasswordResetHandler(
+		services.NewPasswordResetService(f.store, f.cfg, f.users, f.tokens, f.mailer, nil),
+	)
+	user := f.createAlice(t, "hash")
+
+	r := gin.New()
+	r.GET("/password/forgot", handler.ForgotPasswordPage)
+	r.POST("/password/forgot", handler.ForgotPassword)
+	r.GET("/password/reset", handler.ResetPasswordPage)
+	r.POST("/password/reset", handler.ResetPassword)
+	return r, f.mailer, user
+}
+
+func TestForgotPassword_SameResponseForUnknownEmail(t *testing.T) {
+	r, mailer, user := setupPasswordResetTest(t)
+
+	known := serve(r, postForm("/password/forgot", url.Values{"email": {user.Email}}))
+	unknown := serve(r, postForm("/password/forgot",
+		url.Values{"email": {"eve@example.com"}}))
+
+	assert.Equal(t, http.StatusOK, known.Code)
+	assert.Equal(t, http.StatusOK, unknown.Code)
+	assert.Contains(t, unknown.Body.String(), "a reset link is on its way")
+	assert.Equal(t, known.Body.String(), unknown.Body.String())
+
+	msg, _ := receiveMailToken(t, mailer, resetTokenPattern)
+	assert.Equal(t, user.Email, msg.To)
+}
+
+func TestResetPassword_Flow(t *testing.T) {
+	r, mailer, user := setupPasswordResetTest(t)
+
+	serve(r, postForm("/password/forgot", url.Values{"email": {user.Email}}))
+	_, token := receiveMailToken(t, mailer, resetTokenPattern)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/password/reset?token="+token, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Contains(t, w.Body.String(), `value="`+token+`"`)
+
+	w = serve(r, postForm("/password/reset", url.Values{
+		"token":            {token},
+		"password":         {"correct horse battery"},
+		"confirm_password": {"correct horse"},
+	}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "The passwords do not match.")
+
+	w = serve(r, postForm("/password/reset", url.Values{
+		"token":            {token},
+		"password":         {"short"},
+		"confirm_password": {"short"},
+	}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at least 8 characters")
+
+	w = serve(r, postForm("/password/reset", url.Values{
+		"token":            {token},
+		"password":         {"correct horse battery"},
+		"confirm_password": {"correct horse battery"},
+	}))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?notice=password_reset", w.Header().Get("Location"))
+
+	// The link cannot be used again.
+	w = serve(r, httptest.NewRequest(http.MethodGet, "/password/reset?token="+token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Request a new link")
+	assert.NotContains(t, w.Body.String(), token)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	r, _, _ := setupPasswordResetTest(t)
+
+	w := serve(r, postForm("/password/reset", url.Values{
+		"token":            {"bogus"},
+		"password":         {"correct horse battery"},
+		"confirm_password": {"correct horse battery"},
+	}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Request a new link")
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+const accountTokensPath = "/account/tokens"
+
+// personalTokenExpiryDays are the preset expiries offered on the create form,
+// filtered to those within PERSONAL_ACCESS_TOKEN_MAX_LIFETIME.
+var personalTokenExpiryDays = []int{7, 30, 90, 180, 365}
+
+// personalTokenSuccessMessages maps success codes to human-readable messages.
+var personalTokenSuccessMessages = map[string]string{
+	"revoked": "Personal access token has been revoked.",
+}
+
+type PersonalTokenHandler struct {
+	tokenService *services.TokenService
+}
+
+func NewPersonalTokenHandler(ts *services.TokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{tokenService: ts}
+}
+
+// personalTokenExpiryOptions builds the expiry dropdown. When the maximum
+// lifetime is shorter than every preset, the maximum itself is the only choice.
+func personalTokenExpiryOptions(maxLifetime time.Duration) []templates.PersonalTokenExpiryOption {
+	var opts []templates.PersonalTokenExpiryOption
+	for _, days := range personalTokenExpiryDays {
+		d := time.Duration(days) * 24 * time.Hour
+		if d > maxLifetime {
+			break
+		}
+		opts = append(opts, templates.PersonalTokenExpiryOption{
+			Value: d.String(),
+			Label: fmt.Sprintf("%d days", days),
+		})
+	}
+	if len(opts) == 0 && maxLifetime > 0 {
+		opts = append(opts, templates.PersonalTokenExpiryOption{
+			Value: maxLifetime.String(),
+			Label: maxLifetime.String(),
+		})
+	}
+	return opts
+}
+
+// renderTokensPage renders /account/tokens with the user's current tokens.
+// newToken, when set, is the just-created token whose raw value is shown once.
+func (h *PersonalTokenHandler) renderTokensPage(
+	c *gin.Context,
+	status int,
+	newToken *models.AccessToken,
+	errMsg string,
+) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	params := parsePaginationParams(c)
+	tokens, pagination, err := h.tokenService.ListPersonalAccessTokens(userID, params)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to retrieve tokens")
+		return
+	}
+
+	// Get user info for navbar (already loaded by RequireAuth middleware)
+	user := getUserFromContext(c)
+
+	templates.RenderTempl(c, status, templates.AccountTokens(templates.PersonalTokensPageProps{
+		BaseProps:       templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps:     buildNavbarProps(c, user, "personal-tokens"),
+		Enabled:         h.tokenService.PersonalAccessTokensEnabled(),
+		Tokens:          tokens,
+		Pagination:      pagination,
+		PageSize:        params.PageSize,
+		AvailableScopes: h.tokenService.PersonalAccessTokenScopes(),
+		ExpiryOptions: personalTokenExpiryOptions(
+			h.tokenService.PersonalAccessTokenMaxLifetime(),
+		),
+		NewToken: newToken,
+		Error:    errMsg,
+		Success:  personalTokenSuccessMessages[c.Query("success")],
+	}))
+}
+
+// ListTokens shows the current user's personal access tokens and the create form.
+func (h *PersonalTokenHandler) ListTokens(c *gin.Context) {
+	h.renderTokensPage(c, http.StatusOK, nil, "")
+}
+
+// CreateToken mints a personal access token and renders it once. The raw
+// token is never stored, so the response is rendered directly rather than
+// via a redirect.
+func (h *PersonalTokenHandler) CreateToken(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	expiresIn, err := time.ParseDuration(c.PostForm("expires_in"))
+	if err != nil {
+		h.renderTokensPage(c, http.StatusBadRequest, nil, "Please choose a valid expiration.")
+		return
+	}
+
+	pat, err := h.tokenService.CreatePersonalAccessToken(
+		c.Request.Context(),
+		userID,
+		services.CreatePersonalAccessTokenRequest{
+			Name:      c.PostForm("name"),
+			Scopes:    strings.Join(c.PostFormArray("scopes"), " "),
+			ExpiresIn: expiresIn,
+			Resource:  strings.Fields(c.PostForm("resource")),
+		},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPersonalAccessTokensDisabled):
+			h.renderTokensPage(c, http.StatusForbidden, nil, err.Error())
+		case errors.Is(err, services.ErrPersonalAccessTokenName),
+			errors.Is(err, services.ErrPersonalAccessTokenScope),
+			errors.Is(err, services.ErrPersonalAccessTokenExpiry):
+			h.renderTokensPage(c, http.StatusBadRequest, nil, err.Error())
+		case errors.Is(err, services.ErrInvalidTarget):
+			h.renderTokensPage(c, http.StatusBadRequest, nil,
+				"Resource must be an absolute http(s) URI without a fragment.")
+		default:
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to create token")
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	h.renderTokensPage(c, http.StatusCreated, pat, "")
+}
+
+// RevokeToken revokes one of the current user's personal access tokens.
+func (h *PersonalTokenHandler) RevokeToken(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tokenID := c.Param("id")
+	owned, err := h.tokenService.IsPersonalAccessTokenOwnedByUser(tokenID, userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to retrieve tokens")
+		return
+	}
+	if !owned {
+		renderErrorPage(c, http.StatusForbidden, "You don't have permission to revoke this token")
+		return
+	}
+
+	if err := h.tokenService.RevokeTokenByID(c.Request.Context(), tokenID, userID); err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+
+	c.Redirect(http.StatusFound, accountTokensPath+"?success=revoked")
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPersonalTokenRouter(handler *PersonalTokenHandler, userID string) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user", &models.User{ID: userID, Username: "testuser"})
+		c.Next()
+	})
+	r.GET("/account/tokens", handler.ListTokens)
+	r.POST("/account/tokens", handler.CreateToken)
+	r.POST("/account/tokens/:id/revoke", handler.RevokeToken)
+	return r
+}
+
+func TestPersonalTokenExpiryOptions(t *testing.T) {
+	opts := personalTokenExpiryOptions(90 * 24 * time.Hour)
+	require.Len(t, opts, 3)
+	assert.Equal(t, (7 * 24 * time.Hour).String(), opts[0].Value)
+	assert.Equal(t, "90 days", opts[2].Label)
+
+	opts = personalTokenExpiryOptions(12 * time.Hour)
+	require.Len(t, opts, 1, "maximum becomes the only choice")
+	assert.Equal(t, (12 * time.Hour).String(), opts[0].Value)
+}
+
+func TestCreatePersonalToken_Disabled(t *testing.T) {
+	_, tokenSvc := setupSessionServices(t)
+	userID := uuid.New().String()
+	r := newPersonalTokenRouter(NewPersonalTokenHandler(tokenSvc), userID)
+
+	// setupSessionServices leaves the feature off; creation is refused.
+	form := url.Values{
+		"name":       {"script"},
+		"scopes":     {"read"},
+		"expires_in": {(24 * time.Hour).String()},
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/account/tokens", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRevokePersonalToken_RejectsOAuthTokens(t *testing.T) {
+	s, tokenSvc := setupSessionServices(t)
+	userID := uuid.New().String()
+	oauthTok := createTestToken(t, s, userID, uuid.New().String())
+	r := newPersonalTokenRouter(NewPersonalTokenHandler(tokenSvc), userID)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/account/tokens/"+oauthTok.ID+"/revoke", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	_, err := s.GetAccessTokenByID(oauthTok.ID)
+	assert.NoError(t, err, "session tokens are managed on /account/sessions")
+}
+
+func TestRevokePersonalToken_Success(t *testing.T) {
+	s, tokenSvc := setupSessionServices(t)
+	userID := uuid.New().String()
+	pat := &models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     uuid.New().String(),
+		TokenCategory: models.TokenCategoryPersonal,
+		Status:        models.TokenStatusActive,
+		UserID:        userID,
+		Name:          "script",
+		Scopes:        "read",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	require.NoError(t, s.CreateAccessToken(pat))
+	r := newPersonalTokenRouter(NewPersonalTokenHandler(tokenSvc), userID)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/account/tokens/"+pat.ID+"/revoke", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/tokens?success=revoked", w.Header().Get("Location"))
+	_, err := s.GetAccessTokenByID(pat.ID)
+	assert.Error(t, err)
+
+	// Another user's token cannot be revoked.
+	other := *pat
+	other.ID = uuid.New().String()
+	other.TokenHash = uuid.New().String()
+	other.UserID = uuid.New().String()
+	require.NoError(t, s.CreateAccessToken(&other))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/account/tokens/"+other.ID+"/revoke", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
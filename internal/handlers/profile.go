@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/templates"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const accountProfilePath = "/account/profile"
+
+// profileSuccessMessages maps success codes to human-readable messages.
+var profileSuccessMessages = map[string]string{
+	"updated":          "Your profile has been updated.",
+	"password_changed": "Your password has been changed.",
+	"signed_out":       "Your password has been changed and your other sessions were signed out.",
+	"email_sent":       "If the new address can be used, we've sent it a confirmation link.",
+	"email_changed":    "Your email address has been changed.",
+	"unlinked":         "The account has been unlinked.",
+}
+
+// ProfileHandler serves the account profile page, where users edit their
+// name and avatar, change their password and email, and unlink OAuth
+// accounts.
+type ProfileHandler struct {
+	profileService *services.ProfileService
+	userService    *services.UserService
+}
+
+func NewProfileHandler(ps *services.ProfileService, us *services.UserService) *ProfileHandler {
+	return &ProfileHandler{profileService: ps, userService: us}
+}
+
+// renderProfilePage renders /account/profile. form, when set, holds the
+// profile values the user submitted so a failed edit keeps them.
+func (h *ProfileHandler) renderProfilePage(
+	c *gin.Context,
+	status int,
+	form *services.ProfileUpdate,
+	errMsg string,
+) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	// Read from the store rather than the request context: the page must
+	// reflect changes made earlier in this request.
+	user, err := h.userService.AdminGetUserByID(userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load account")
+		return
+	}
+	connections, err := h.userService.GetUserOAuthConnections(userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load linked accounts")
+		return
+	}
+
+	props := templates.ProfilePageProps{
+		BaseProps:          templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps:        buildNavbarProps(c, user, "profile"),
+		User:               user,
+		FullName:           user.FullName,
+		AvatarURL:          user.AvatarURL,
+		Editable:           !user.IsExternal(),
+		HasPassword:        services.HasPassword(user),
+		EmailChangeEnabled: h.profileService.EmailChangeEnabled(),
+		MinLength:          services.MinPasswordLength,
+		Connections:        connections,
+		CanUnlink:          h.profileService.CanUnlinkConnection(user, len(connections)),
+		Error:              errMsg,
+		Success:            profileSuccessMessages[c.Query("success")],
+	}
+	if form != nil {
+		props.FullName = form.FullName
+		props.AvatarURL = form.AvatarURL
+	}
+	templates.RenderTempl(c, status, templates.AccountProfile(props))
+}
+
+// ProfilePage shows the user's profile.
+func (h *ProfileHandler) ProfilePage(c *gin.Context) {
+	h.renderProfilePage(c, http.StatusOK, nil, "")
+}
+
+// UpdateProfile saves the user's full name and avatar.
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	form := services.ProfileUpdate{
+		FullName:  c.PostForm("full_name"),
+		AvatarURL: c.PostForm("avatar_url"),
+	}
+	err := h.profileService.UpdateProfile(c.Request.Context(), getUserIDFromContext(c), form)
+	if err != nil {
+		h.renderProfileError(c, &form, err)
+		return
+	}
+	c.Redirect(http.StatusFound, accountProfilePath+"?success=updated")
+}
+
+// ChangePassword replaces the user's password. When asked to sign out other
+// sessions it keeps the current one signed in.
+func (h *ProfileHandler) ChangePassword(c *gin.Context) {
+	newPassword := c.PostForm("new_password")
+	if newPassword != c.PostForm("confirm_password") {
+		h.renderProfilePage(c, http.StatusBadRequest, nil, "The new passwords do not match.")
+		return
+	}
+
+	signOutOthers := c.PostForm("sign_out_others") == "on"
+	user, err := h.profileService.ChangePassword(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.PostForm("current_password"),
+		newPassword,
+		signOutOthers,
+	)
+	if err != nil {
+		h.renderProfileError(c, nil, err)
+		return
+	}
+
+	if !signOutOthers {
+		c.Redirect(http.StatusFound, accountProfilePath+"?success=password_changed")
+		return
+	}
+	// Sessions signed in up to SessionsRevokedAt (compared in whole seconds)
+	// are now revoked; move this one just past it so it survives.
+	session := sessions.Default(c)
+	session.Set(middleware.SessionAuthTime, user.SessionsRevokedAt.Unix()+1)
+	if err := session.Save(); err != nil {
+		log.Printf("[Profile] Failed to save session: %v", err)
+	}
+	c.Redirect(http.StatusFound, accountProfilePath+"?success=signed_out")
+}
+
+// RequestEmailChange emails a confirmation link to the new address.
+func (h *ProfileHandler) RequestEmailChange(c *gin.Context) {
+	authTime, _ := sessions.Default(c).Get(middleware.SessionAuthTime).(int64)
+	if err := h.profileService.RequestEmailChange(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.PostForm("current_password"),
+		c.PostForm("email"),
+		time.Unix(authTime, 0),
+	); err != nil {
+		h.renderProfileError(c, nil, err)
+		return
+	}
+	c.Redirect(http.StatusFound, accountProfilePath+"?success=email_sent")
+}
+
+// ConfirmEmailChange handles the link from the confirmation email.
+func (h *ProfileHandler) ConfirmEmailChange(c *gin.Context) {
+	// The token is in the URL; keep it out of Referer headers.
+	c.Header("Referrer-Policy", "no-referrer")
+
+	_, err := h.profileService.ConfirmEmailChange(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.Query("token"),
+	)
+	switch {
+	case err == nil:
+		c.Redirect(http.StatusFound, accountProfilePath+"?success=email_changed")
+	case errors.Is(err, services.ErrEmailChangeTokenInvalid),
+		errors.Is(err, services.ErrProfileManagedExternally):
+		renderErrorPage(c, http.StatusBadRequest,
+			"This confirmation link is invalid, has already been used, has expired, "+
+				"or belongs to another account.")
+	case errors.Is(err, services.ErrEmailConflict):
+		renderErrorPage(c, http.StatusConflict,
+			"That email address is now used by another account.")
+	default:
+		log.Printf("[Profile] Failed to confirm email change: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to change email address")
+	}
+}
+
+// UnlinkConnection removes one of the user's linked OAuth accounts.
+func (h *ProfileHandler) UnlinkConnection(c *gin.Context) {
+	err := h.profileService.UnlinkOAuthConnection(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.Param("id"),
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrOAuthConnectionNotFound) {
+			renderErrorPage(c, http.StatusNotFound, "Linked account not found")
+			return
+		}
+		h.renderProfileError(c, nil, err)
+		return
+	}
+	c.Redirect(http.StatusFound, accountProfilePath+"?success=unlinked")
+}
+
+// renderProfileError maps profile service errors to the profile page.
+func (h *ProfileHandler) renderProfileError(
+	c *gin.Context,
+	form *services.ProfileUpdate,
+	err error,
+) {
+	switch {
+	case errors.Is(err, services.ErrProfileManagedExternally),
+		errors.Is(err, services.ErrNoPasswordSet),
+		errors.Is(err, services.ErrEmailChangeDisabled),
+		errors.Is(err, services.ErrRecentSignInRequired),
+		errors.Is(err, services.ErrLastLoginMethod):
+		h.renderProfilePage(c, http.StatusForbidden, form, errorSentence(err))
+	case errors.Is(err, services.ErrEmailChangeRateLimited):
+		h.renderProfilePage(c, http.StatusTooManyRequests, form, errorSentence(err))
+	case errors.Is(err, services.ErrFullNameTooLong),
+		errors.Is(err, services.ErrAvatarURLInvalid),
+		errors.Is(err, services.ErrCurrentPasswordIncorrect),
+		errors.Is(err, services.ErrPasswordTooShort),
+		errors.Is(err, services.ErrPasswordTooLong),
+		errors.Is(err, services.ErrEmailRequired),
+		errors.Is(err, services.ErrEmailInvalid),
+		errors.Is(err, services.ErrEmailUnchanged),
+		errors.Is(err, services.ErrEmailDomainNotAllowed):
+		h.renderProfilePage(c, http.StatusBadRequest, form, errorSentence(err))
+	default:
+		log.Printf("[Profile] Profile page error: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to update your account")
+	}
+}
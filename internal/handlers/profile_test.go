@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var emailChangeTokenPattern = regexp.MustCompile(`/account/profile/email/confirm\?token=(\S+)`)
+
+type profileTestEnv struct {
+	router *gin.Engine
+	mailer chanMailer
+	store  *store.Store
+	user   *models.User
+}
+
+// setupProfileTest signs every request in as a local user whose password is
+// "old password". GET /session reports the session's sign-in time.
+func setupProfileTest(t *testing.T) *profileTestEnv {
+	t.Helper()
+	f := newMailFlowFixture(t, &config.Config{
+		EmailChangeEnabled:  true,
+		EmailChangeTokenTTL: 24 * time.Hour,
+	})
+	handler := NewProfileHandler(
+		services.NewProfileService(f.store, f.cfg, f.users, f.tokens, f.mailer, nil), f.users,
+	)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("old password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := f.createAlice(t, string(hash))
+
+	r := gin.New()
+	r.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+	r.GET("/session", func(c *gin.Context) {
+		authTime := sessions.Default(c).Get(middleware.SessionAuthTime)
+		c.JSON(http.StatusOK, gin.H{"auth_time": authTime})
+	})
+	account := r.Group("/account", func(c *gin.Context) {
+		c.Set("user", user)
+		c.Set("user_id", user.ID)
+	})
+	account.GET("/profile", handler.ProfilePage)
+	account.POST("/profile", handler.UpdateProfile)
+	account.POST("/profile/password", handler.ChangePassword)
+	account.POST("/profile/email", handler.RequestEmailChange)
+	account.GET("/profile/email/confirm", handler.ConfirmEmailChange)
+	account.POST("/profile/connections/:id/unlink", handler.UnlinkConnection)
+
+	return &profileTestEnv{router: r, mailer: f.mailer, store: f.store, user: user}
+}
+
+func passwordForm(newPassword, confirm string) url.Values {
+	return url.Values{
+		"current_password": {"old password"},
+		"new_password":     {newPassword},
+		"confirm_password": {confirm},
+	}
+}
+
+func TestProfilePage(t *testing.T) {
+	env := setupProfileTest(t)
+
+	w := serve(env.router, httptest.NewRequest(http.MethodGet, "/account/profile", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "alice@example.com")
+	assert.Contains(t, body, `action="/account/profile/password"`)
+	assert.Contains(t, body, `action="/account/profile/email"`)
+}
+
+func TestProfile_UpdateKeepsValuesOnError(t *testing.T) {
+	env := setupProfileTest(t)
+
+	w := serve(env.router, postForm("/account/profile", url.Values{
+		"full_name":  {"Alice Liddell"},
+		"avatar_url": {"http://example.com/alice.png"},
+	}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `value="Alice Liddell"`)
+
+	w = serve(env.router, postForm("/account/profile", url.Values{
+		"full_name": {"Alice Liddell"},
+	}))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/profile?success=updated", w.Header().Get("Location"))
+}
+
+func TestProfile_ChangePassword(t *testing.T) {
+	env := setupProfileTest(t)
+
+	w := serve(env.router, postForm("/account/profile/password",
+		passwordForm("correct horse battery", "something else")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "The new passwords do not match.")
+
+	form := passwordForm("correct horse battery", "correct horse battery")
+	form.Set("current_password", "wrong password")
+	w = serve(env.router, postForm("/account/profile/password", form))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Current password is incorrect.")
+
+	w = serve(env.router, postForm("/account/profile/password",
+		passwordForm("correct horse battery", "correct horse battery")))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/profile?success=password_changed", w.Header().Get("Location"))
+}
+
+func TestProfile_SignOutOthersKeepsCurrentSession(t *testing.T) {
+	env := setupProfileTest(t)
+
+	form := passwordForm("correct horse battery", "correct horse battery")
+	form.Set("sign_out_others", "on")
+	w := serve(env.router, postForm("/account/profile/password", form))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/profile?success=signed_out", w.Header().Get("Location"))
+
+	user, err := env.store.GetUserByID(env.user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, user.SessionsRevokedAt)
+
+	req := httptest.NewRequest(http.MethodGet, "/session", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = serve(env.router, req)
+	want := user.SessionsRevokedAt.Unix() + 1
+	assert.JSONEq(t, `{"auth_time":`+strconv.FormatInt(want, 10)+`}`, w.Body.String())
+}
+
+func TestProfile_EmailChange(t *testing.T) {
+	env := setupProfileTest(t)
+
+	w := serve(env.router, postForm("/account/profile/email", url.Values{
+		"email":            {"alice@example.org"},
+		"current_password": {"old password"},
+	}))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/profile?success=email_sent", w.Header().Get("Location"))
+
+	_, token := receiveMailToken(t, env.mailer, emailChangeTokenPattern)
+
+	confirm := "/account/profile/email/confirm?token=" + token
+	w = serve(env.router, httptest.NewRequest(http.MethodGet, confirm, nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/profile?success=email_changed", w.Header().Get("Location"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+
+	user, err := env.store.GetUserByID(env.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", user.Email)
+	assert.True(t, user.EmailVerified)
+
+	w = serve(env.router, httptest.NewRequest(http.MethodGet, confirm, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestProfile_EmailChangeWithoutPasswordNeedsFreshSignIn(t *testing.T) {
+	env := setupProfileTest(t)
+	env.user.PasswordHash = ""
+	require.NoError(t, env.store.UpdateUser(env.user))
+
+	// The test session carries no sign-in time, so it counts as stale.
+	w := serve(env.router, postForm("/account/profile/email", url.Values{
+		"email": {"alice@example.org"},
+	}))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Sign out and sign in again")
+}
+
+func TestProfile_UnlinkMissingConnection(t *testing.T) {
+	env := setupProfileTest(t)
+
+	w := serve(env.router, httptest.NewRequest(http.MethodPost,
+		"/account/profile/connections/missing/unlink", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
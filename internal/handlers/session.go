@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/go-authgate/authgate/internal/middleware"
@@ -40,6 +41,12 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 		return
 	}
 
+	// The limit summary is informational; a failure must not hide the list.
+	familyUsage, err := h.tokenService.GetRefreshFamilyUsage(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[Session] Failed to load refresh family usage user_id=%s: %v", userID, err)
+	}
+
 	// Get user info for navbar (already loaded by RequireAuth middleware)
 	user := getUserFromContext(c)
 
@@ -52,6 +59,9 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 		PageSize:       params.PageSize,
 		StatusFilter:   params.StatusFilter,
 		CategoryFilter: params.CategoryFilter,
+
+		CurrentSessionID: c.GetString(middleware.ContextKeySessionID),
+		FamilyUsage:      familyUsage,
 	}))
 }
 
@@ -129,6 +139,35 @@ func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/account/sessions")
 }
 
+// RevokeLoginSession revokes every token the current user was issued from one
+// browser sign-in, identified by its login session ID.
+func (h *SessionHandler) RevokeLoginSession(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID := c.Param("sid")
+	if sessionID == "" {
+		renderErrorPage(c, http.StatusBadRequest, "Session ID is required")
+		return
+	}
+
+	// Scoped to userID in the store, so another user's session ID matches nothing.
+	if _, err := h.tokenService.RevokeSessionTokens(
+		c.Request.Context(),
+		userID,
+		sessionID,
+		"user_request",
+	); err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to revoke sign-in session")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/account/sessions")
+}
+
 // DisableSession temporarily disables a specific session by token ID
 func (h *SessionHandler) DisableSession(c *gin.Context) {
 	tokenID, valid := h.validateTokenOwnership(c, "disable")
@@ -81,6 +81,29 @@ func createTestToken(t *testing.T, s *store.Store, userID, clientID string) *mod
 	return tok
 }
 
+// createSessionBoundToken stores an active access token issued from the login
+// session sessionID.
+func createSessionBoundToken(
+	t *testing.T,
+	s *store.Store,
+	userID, clientID, sessionID string,
+) *models.AccessToken {
+	t.Helper()
+	tok := &models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     util.SHA256Hex(uuid.New().String()),
+		TokenCategory: models.TokenCategoryAccess,
+		Status:        models.TokenStatusActive,
+		UserID:        userID,
+		ClientID:      clientID,
+		Scopes:        "email profile",
+		SessionID:     sessionID,
+		ExpiresAt:     time.Now().Add(1 * time.Hour),
+	}
+	require.NoError(t, s.CreateAccessToken(tok))
+	return tok
+}
+
 // newSessionRouter creates a Gin router with the session handler and optional user_id injection.
 func newSessionRouter(handler *SessionHandler, userID string) *gin.Engine {
 	r := gin.New()
@@ -96,6 +119,7 @@ func newSessionRouter(handler *SessionHandler, userID string) *gin.Engine {
 	r.POST("/account/sessions/:id/disable", handler.DisableSession)
 	r.POST("/account/sessions/:id/enable", handler.EnableSession)
 	r.POST("/account/sessions/revoke-all", handler.RevokeAllSessions)
+	r.POST("/account/sessions/login/:sid/revoke", handler.RevokeLoginSession)
 	return r
 }
 
@@ -196,6 +220,36 @@ func TestRevokeAllSessions(t *testing.T) {
 	assert.Empty(t, tokens)
 }
 
+func TestRevokeLoginSession(t *testing.T) {
+	s, tokenSvc := setupSessionServices(t)
+	userID := uuid.New().String()
+	clientID := uuid.New().String()
+	bound := createSessionBoundToken(t, s, userID, clientID, "session-a")
+	other := createSessionBoundToken(t, s, userID, clientID, "session-b")
+	foreign := createSessionBoundToken(t, s, uuid.New().String(), clientID, "session-a")
+
+	handler := NewSessionHandler(tokenSvc)
+	r := newSessionRouter(handler, userID)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/account/sessions/login/session-a/revoke", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	for _, tc := range []struct {
+		id   string
+		want string
+	}{
+		{bound.ID, models.TokenStatusRevoked},
+		{other.ID, models.TokenStatusActive},
+		{foreign.ID, models.TokenStatusActive},
+	} {
+		stored, err := s.GetAccessTokenByID(tc.id)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, stored.Status)
+	}
+}
+
 func TestListSessions(t *testing.T) {
 	t.Run("DefaultNoFilters", func(t *testing.T) {
 		s, tokenSvc := setupSessionServices(t)
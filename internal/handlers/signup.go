@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ctxKeyPendingSignupsCount = "pending_signups_count"
+
+// SignupHandler serves self-service registration and the admin approval
+// queue for self-registered accounts.
+type SignupHandler struct {
+	signupService *services.SignupService
+	userService   *services.UserService
+}
+
+func NewSignupHandler(s *services.SignupService, us *services.UserService) *SignupHandler {
+	return &SignupHandler{signupService: s, userService: us}
+}
+
+func (h *SignupHandler) pageProps(c *gin.Context) templates.SignupPageProps {
+	return templates.SignupPageProps{
+		BaseProps:      templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps:    templates.NavbarProps{DocsNavEntries: NavbarDocsEntriesFor(resolveLocale(c))},
+		AllowedDomains: h.signupService.AllowedEmailDomains(),
+		MinLength:      services.MinPasswordLength,
+	}
+}
+
+// SignupPage shows the registration form.
+func (h *SignupHandler) SignupPage(c *gin.Context) {
+	templates.RenderTempl(c, http.StatusOK, templates.SignupPage(h.pageProps(c)))
+}
+
+// Signup creates an unverified account and emails its verification link.
+func (h *SignupHandler) Signup(c *gin.Context) {
+	props := h.pageProps(c)
+	props.Username = c.PostForm("username")
+	props.Email = c.PostForm("email")
+	props.FullName = c.PostForm("full_name")
+
+	password := c.PostForm("password")
+	if password != c.PostForm("confirm_password") {
+		props.Error = "The passwords do not match."
+		templates.RenderTempl(c, http.StatusBadRequest, templates.SignupPage(props))
+		return
+	}
+
+	err := h.signupService.Register(c.Request.Context(), services.SignupRequest{
+		Username: props.Username,
+		Email:    props.Email,
+		FullName: props.FullName,
+		Password: password,
+	})
+	switch {
+	case err == nil:
+		props.Sent = true
+		templates.RenderTempl(c, http.StatusOK, templates.SignupPage(props))
+	case errors.Is(err, services.ErrUsernameConflict):
+		props.Error = "That username is already taken."
+		templates.RenderTempl(c, http.StatusConflict, templates.SignupPage(props))
+	case errors.Is(err, services.ErrUsernameRequired),
+		errors.Is(err, services.ErrEmailRequired),
+		errors.Is(err, services.ErrSignupUsernameInvalid),
+		errors.Is(err, services.ErrEmailInvalid),
+		errors.Is(err, services.ErrSignupDomainNotAllowed),
+		errors.Is(err, services.ErrPasswordTooShort),
+		errors.Is(err, services.ErrPasswordTooLong):
+		props.Error = errorSentence(err)
+		templates.RenderTempl(c, http.StatusBadRequest, templates.SignupPage(props))
+	default:
+		log.Printf("[Signup] Failed to register user: %v", err)
+		props.Error = "We could not create your account. Please try again later."
+		templates.RenderTempl(c, http.StatusInternalServerError, templates.SignupPage(props))
+	}
+}
+
+// VerifyEmail handles the link from the verification email and sends the
+// user to the login page with a notice saying what happens next.
+func (h *SignupHandler) VerifyEmail(c *gin.Context) {
+	// The token is in the URL; keep it out of Referer headers.
+	c.Header("Referrer-Policy", "no-referrer")
+
+	user, err := h.signupService.VerifyEmail(c.Request.Context(), c.Query("token"))
+	switch {
+	case err == nil && user.SignupStatus == models.SignupStatusPending:
+		c.Redirect(http.StatusFound, "/login?notice=signup_pending")
+	case err == nil:
+		c.Redirect(http.StatusFound, "/login?notice=email_verified")
+	case errors.Is(err, services.ErrSignupTokenInvalid):
+		renderErrorPage(c, http.StatusBadRequest,
+			"This verification link is invalid, has already been used, or has expired.")
+	default:
+		log.Printf("[Signup] Failed to verify email: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to verify email address")
+	}
+}
+
+// InjectPendingCount is a middleware that stores the number of signups
+// awaiting approval in the gin context for admin users, so buildNavbarProps
+// can show the badge on the Users link.
+func (h *SignupHandler) InjectPendingCount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if u, exists := c.Get("user"); exists {
+			if user, ok := u.(*models.User); ok && user.IsAdmin() {
+				if count, err := h.userService.CountPendingSignups(); err == nil {
+					c.Set(ctxKeyPendingSignupsCount, int(count))
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// ApproveSignup activates a self-registered account awaiting approval.
+func (h *SignupHandler) ApproveSignup(c *gin.Context) {
+	h.reviewSignup(c, h.signupService.ApproveSignup, "Signup approved. The user can now sign in.")
+}
+
+// RejectSignup leaves a self-registered account awaiting approval disabled.
+func (h *SignupHandler) RejectSignup(c *gin.Context) {
+	h.reviewSignup(c, h.signupService.RejectSignup, "Signup rejected. The account stays disabled.")
+}
+
+func (h *SignupHandler) reviewSignup(
+	c *gin.Context,
+	review func(ctx context.Context, userID, adminUserID string) error,
+	successMsg string,
+) {
+	userID := c.Param("id")
+	if err := review(c.Request.Context(), userID, getUserIDFromContext(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			renderErrorPage(c, http.StatusNotFound, "User not found")
+		case errors.Is(err, services.ErrSignupNotPendingApproval):
+			renderErrorPage(c, http.StatusBadRequest, err.Error())
+		default:
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to update signup: "+err.Error())
+		}
+		return
+	}
+	flashAndRedirect(c, successMsg, "/admin/users")
+}
+
+// errorSentence turns a validation error into a sentence for the form.
+func errorSentence(err error) string {
+	msg := err.Error()
+	return strings.ToUpper(msg[:1]) + msg[1:] + "."
+}
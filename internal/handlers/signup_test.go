@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var verifyTokenPattern = regexp.MustCompile(`/signup/verify\?token=(\S+)`)
+
+type signupTestEnv struct {
+	router *gin.Engine
+	mailer chanMailer
+	store  *store.Store
+}
+
+func setupSignupTest(t *testing.T, requireApproval bool) *signupTestEnv {
+	t.Helper()
+	f := newMailFlowFixture(t, &config.Config{
+		SignupEmailDomains:    []string{"example.com"},
+		SignupRequireApproval: requireApproval,
+		SignupVerificationTTL: 24 * time.Hour,
+	})
+	handler := NewSignupHandler(
+		services.NewSignupService(f.store, f.cfg, f.users, f.mailer, nil), f.users,
+	)
+
+	admin, err := f.store.GetUserByUsername("admin") // seeded by store.New
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+	r.GET("/signup", handler.SignupPage)
+	r.POST("/signup", handler.Signup)
+	r.GET("/signup/verify", handler.VerifyEmail)
+	adminGroup := r.Group("/admin", func(c *gin.Context) {
+		c.Set("user", admin)
+		c.Set("user_id", admin.ID)
+	}, handler.InjectPendingCount())
+	adminGroup.GET("/pending", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"count": c.GetInt(ctxKeyPendingSignupsCount)})
+	})
+	adminGroup.POST("/users/:id/approve", handler.ApproveSignup)
+	adminGroup.POST("/users/:id/reject", handler.RejectSignup)
+
+	return &signupTestEnv{router: r, mailer: f.mailer, store: f.store}
+}
+
+func signupForm(username, email string) url.Values {
+	return url.Values{
+		"username":         {username},
+		"email":            {email},
+		"password":         {"correct horse battery"},
+		"confirm_password": {"correct horse battery"},
+	}
+}
+
+// signUp submits the form and returns the token from the verification email.
+func (e *signupTestEnv) signUp(t *testing.T, username string) string {
+	t.Helper()
+	w := serve(e.router, postForm("/signup", signupForm(username, username+"@example.com")))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "Check your inbox")
+	_, token := receiveMailToken(t, e.mailer, verifyTokenPattern)
+	return token
+}
+
+func TestSignupPage(t *testing.T) {
+	env := setupSignupTest(t, false)
+
+	w := serve(env.router, httptest.NewRequest(http.MethodGet, "/signup", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/signup"`)
+	assert.Contains(t, w.Body.String(), "Use an address at example.com.")
+}
+
+func TestSignup_FormErrors(t *testing.T) {
+	env := setupSignupTest(t, false)
+
+	form := signupForm("alice", "alice@example.com")
+	form.Set("confirm_password", "something else")
+	w := serve(env.router, postForm("/signup", form))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "The passwords do not match.")
+	assert.Contains(t, w.Body.String(), `value="alice"`, "entered values are kept")
+
+	w = serve(env.router, postForm("/signup", signupForm("alice", "alice@example.org")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Sign-up is not open to this email domain.")
+
+	w = serve(env.router, postForm("/signup", signupForm("admin", "alice@example.com")))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "That username is already taken.")
+}
+
+func TestSignup_VerifyActivatesAccount(t *testing.T) {
+	env := setupSignupTest(t, false)
+	token := env.signUp(t, "alice")
+
+	w := serve(env.router, httptest.NewRequest(http.MethodGet, "/signup/verify?token="+token, nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?notice=email_verified", w.Header().Get("Location"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+
+	user, err := env.store.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.True(t, user.IsActive)
+	assert.True(t, user.EmailVerified)
+
+	w = serve(env.router, httptest.NewRequest(http.MethodGet, "/signup/verify?token="+token, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid, has already been used, or has expired")
+}
+
+func TestSignup_ApprovalQueue(t *testing.T) {
+	env := setupSignupTest(t, true)
+	for _, name := range []string{"bob", "carol"} {
+		token := env.signUp(t, name)
+		w := serve(env.router,
+			httptest.NewRequest(http.MethodGet, "/signup/verify?token="+token, nil))
+		require.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "/login?notice=signup_pending", w.Header().Get("Location"))
+	}
+
+	w := serve(env.router, httptest.NewRequest(http.MethodGet, "/admin/pending", nil))
+	assert.JSONEq(t, `{"count":2}`, w.Body.String())
+
+	bob, err := env.store.GetUserByUsername("bob")
+	require.NoError(t, err)
+	w = serve(env.router, httptest.NewRequest(http.MethodPost, "/admin/users/"+bob.ID+"/approve", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/admin/users", w.Header().Get("Location"))
+	bob, err = env.store.GetUserByID(bob.ID)
+	require.NoError(t, err)
+	assert.True(t, bob.IsActive)
+
+	carol, err := env.store.GetUserByUsername("carol")
+	require.NoError(t, err)
+	w = serve(env.router, httptest.NewRequest(http.MethodPost, "/admin/users/"+carol.ID+"/reject", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	carol, err = env.store.GetUserByID(carol.ID)
+	require.NoError(t, err)
+	assert.False(t, carol.IsActive)
+	assert.Empty(t, carol.SignupStatus)
+
+	w = serve(env.router, httptest.NewRequest(http.MethodGet, "/admin/pending", nil))
+	assert.JSONEq(t, `{"count":0}`, w.Body.String())
+
+	// Only queued signups can be reviewed.
+	w = serve(env.router, httptest.NewRequest(http.MethodPost, "/admin/users/"+carol.ID+"/approve", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(env.router, httptest.NewRequest(http.MethodPost, "/admin/users/missing/approve", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
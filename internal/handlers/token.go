@@ -41,6 +41,11 @@ const (
 	errInvalidTarget        = "invalid_target"
 )
 
+// refreshFamilyLimitDescription is returned when a client's refresh token
+// family limit refuses a new grant (REFRESH_FAMILY_LIMIT_POLICY=reject).
+const refreshFamilyLimitDescription = "Too many active sessions for this client; " +
+	"sign out of another session and try again"
+
 type TokenHandler struct {
 	tokenService         *services.TokenService
 	authorizationService *services.AuthorizationService
@@ -116,8 +121,11 @@ func parseResourceParam(c *gin.Context) ([]string, bool) {
 // accept tokens they were not minted for. Legacy rows now lose `aud` in
 // introspection responses instead; this resolves naturally as those tokens
 // expire within JWT_EXPIRATION.
+//
+// Personal access tokens are treated like access tokens: their Resource is
+// the optional audience binding chosen at creation.
 func introspectAudience(tok *models.AccessToken) any {
-	if !tok.IsAccessToken() {
+	if !tok.IsAccessToken() && !tok.IsPersonalAccessToken() {
 		return nil
 	}
 	return util.AudienceClaim([]string(tok.Resource))
@@ -237,6 +245,8 @@ func (h *TokenHandler) handleDeviceCodeGrant(c *gin.Context) {
 				errInvalidTarget,
 				"Requested resource exceeds the audience granted at /oauth/device/code",
 			)
+		case errors.Is(err, services.ErrRefreshFamilyLimit):
+			respondOAuthError(c, http.StatusBadRequest, errInvalidGrant, refreshFamilyLimitDescription)
 		default:
 			log.Printf("[token] device code exchange error: %v", err)
 			respondOAuthError(
@@ -693,7 +703,7 @@ func (h *TokenHandler) handleAuthorizationCodeGrant(c *gin.Context) {
 	// Resolve the UserAuthorization ID so tokens can be cascade-revoked later
 	var authorizationID *uint
 	if ua, _ := h.authorizationService.GetUserAuthorization(
-		authCode.UserID, authCode.ApplicationID,
+		c.Request.Context(), authCode.UserID, authCode.ApplicationID,
 	); ua != nil {
 		id := ua.ID
 		authorizationID = &id
@@ -712,6 +722,10 @@ func (h *TokenHandler) handleAuthorizationCodeGrant(c *gin.Context) {
 		resource,
 	)
 	if err != nil {
+		if errors.Is(err, services.ErrRefreshFamilyLimit) {
+			respondOAuthError(c, http.StatusBadRequest, errInvalidGrant, refreshFamilyLimitDescription)
+			return
+		}
 		respondOAuthError(
 			c,
 			http.StatusInternalServerError,
@@ -3,12 +3,17 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/middleware"
 	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
 	"github.com/go-authgate/authgate/internal/templates"
 
 	"github.com/gin-gonic/gin"
@@ -24,8 +29,9 @@ var (
 		models.TokenStatusRevoked:  true,
 	}
 	validTokenCategories = map[string]bool{
-		models.TokenCategoryAccess:  true,
-		models.TokenCategoryRefresh: true,
+		models.TokenCategoryAccess:   true,
+		models.TokenCategoryRefresh:  true,
+		models.TokenCategoryPersonal: true,
 	}
 )
 
@@ -36,6 +42,37 @@ var tokenSuccessMessages = map[string]string{
 	"enabled":  "Token has been re-enabled.",
 }
 
+// tokenBulkSuccessMessages maps bulk-action success codes to message formats;
+// the affected token count is substituted in.
+var tokenBulkSuccessMessages = map[string]string{
+	"bulk_revoke":  "%d matching token(s) have been revoked.",
+	"bulk_disable": "%d matching token(s) have been disabled.",
+}
+
+// tokenAdvancedFilterFields are the advanced search inputs on /admin/tokens,
+// in addition to the status and category tabs.
+var tokenAdvancedFilterFields = []string{
+	"user_id",
+	"client_id",
+	"scope",
+	"resource",
+	"family_id",
+	"session_id",
+	"issued_after",
+	"issued_before",
+	"expires_after",
+	"expires_before",
+}
+
+// tokenFilterTimeLayouts are accepted for the issued/expires range inputs.
+// Values without a zone are read as UTC.
+var tokenFilterTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02", time.RFC3339}
+
+// errBulkTokenSearchActive explains why bulk actions are unavailable while a
+// free-text search narrows the list.
+const errBulkTokenSearchActive = "Clear the search box before running a bulk action; " +
+	"bulk actions apply to the filters only."
+
 // tokenWarningMessages maps warning codes to human-readable messages.
 var tokenWarningMessages = map[string]string{
 	"cannot_disable": "Token cannot be disabled (only active tokens can be disabled).",
@@ -50,36 +87,196 @@ func NewTokenAdminHandler(ts *services.TokenService) *TokenAdminHandler {
 	return &TokenAdminHandler{tokenService: ts}
 }
 
+// parseTokenFilter builds the advanced token filter from request values read
+// via get (c.Query for the listing, c.PostForm for bulk actions). It also
+// returns the raw non-empty values so the form and links can echo them back.
+// An unparseable date is reported as an error and left out of the filter.
+func parseTokenFilter(get func(string) string) (store.TokenFilter, map[string]string, error) {
+	fields := make(map[string]string)
+	for _, name := range tokenAdvancedFilterFields {
+		if v := strings.TrimSpace(get(name)); v != "" {
+			fields[name] = v
+		}
+	}
+
+	filter := store.TokenFilter{
+		UserID:    fields["user_id"],
+		ClientID:  fields["client_id"],
+		Scope:     fields["scope"],
+		Resource:  fields["resource"],
+		FamilyID:  fields["family_id"],
+		SessionID: fields["session_id"],
+	}
+	if s := get("status"); validTokenStatuses[s] {
+		filter.Status = s
+	}
+	if cat := get("category"); validTokenCategories[cat] {
+		filter.Category = cat
+	}
+
+	var firstErr error
+	for name, dst := range map[string]**time.Time{
+		"issued_after":   &filter.IssuedAfter,
+		"issued_before":  &filter.IssuedBefore,
+		"expires_after":  &filter.ExpiresAfter,
+		"expires_before": &filter.ExpiresBefore,
+	} {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		t, err := parseTokenFilterTime(v)
+		if err != nil {
+			delete(fields, name)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invalid %s date %q", strings.ReplaceAll(name, "_", " "), v)
+			}
+			continue
+		}
+		*dst = &t
+	}
+	return filter, fields, firstErr
+}
+
+func parseTokenFilterTime(v string) (time.Time, error) {
+	var err error
+	for _, layout := range tokenFilterTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, v, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// tokenBulkSuccessMessage renders the flash for a completed bulk action.
+func tokenBulkSuccessMessage(c *gin.Context) string {
+	format, ok := tokenBulkSuccessMessages[c.Query("success")]
+	if !ok {
+		return ""
+	}
+	count, _ := strconv.Atoi(c.Query("affected"))
+	return fmt.Sprintf(format, count)
+}
+
 func (h *TokenAdminHandler) ShowTokensPage(c *gin.Context) {
+	params := parseTokenPaginationParams(c)
+	filter, fields, err := parseTokenFilter(c.Query)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	h.renderTokensPage(c, http.StatusOK, params, filter, fields, nil, errMsg)
+}
+
+// renderTokensPage renders /admin/tokens for the given filter. preview, when
+// set, shows the dry-run result of a bulk action awaiting confirmation.
+func (h *TokenAdminHandler) renderTokensPage(
+	c *gin.Context,
+	status int,
+	params store.PaginationParams,
+	filter store.TokenFilter,
+	fields map[string]string,
+	preview *templates.TokenBulkPreview,
+	errMsg string,
+) {
 	user := getUserFromContext(c)
 	if user == nil {
 		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	params := parseTokenPaginationParams(c)
-
-	tokens, pagination, err := h.tokenService.ListAllTokensPaginated(params)
+	tokens, pagination, err := h.tokenService.ListAllTokensPaginated(params, filter)
 	if err != nil {
 		renderErrorPage(c, http.StatusInternalServerError, "Failed to retrieve tokens")
 		return
 	}
 
-	templates.RenderTempl(c, http.StatusOK, templates.AdminTokens(templates.TokensPageProps{
-		BaseProps:      templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
-		NavbarProps:    buildNavbarProps(c, user, "tokens"),
-		Tokens:         tokens,
-		Pagination:     pagination,
-		Search:         params.Search,
-		PageSize:       params.PageSize,
-		StatusFilter:   params.StatusFilter,
-		CategoryFilter: params.CategoryFilter,
-		Success:        tokenSuccessMessages[c.Query("success")],
-		Warning:        tokenWarningMessages[c.Query("warning")],
-		Now:            time.Now(),
+	success := tokenSuccessMessages[c.Query("success")]
+	if success == "" {
+		success = tokenBulkSuccessMessage(c)
+	}
+
+	templates.RenderTempl(c, status, templates.AdminTokens(templates.TokensPageProps{
+		BaseProps:       templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps:     buildNavbarProps(c, user, "tokens"),
+		Tokens:          tokens,
+		Pagination:      pagination,
+		Search:          params.Search,
+		PageSize:        params.PageSize,
+		StatusFilter:    filter.Status,
+		CategoryFilter:  filter.Category,
+		AdvancedFilters: fields,
+		BulkAvailable:   filter.IsScoped() && params.Search == "",
+		BulkPreview:     preview,
+		Success:         success,
+		Warning:         tokenWarningMessages[c.Query("warning")],
+		Error:           errMsg,
+		Now:             time.Now(),
 	}))
 }
 
+// BulkAction revokes or disables every token matching the submitted filter.
+// Without confirm=true it is a dry run: the page is re-rendered with the
+// number of tokens that would be affected and a confirmation button.
+func (h *TokenAdminHandler) BulkAction(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	params := store.NewPaginationParams(1, 10, "")
+	filter, fields, err := parseTokenFilter(c.PostForm)
+	if err != nil {
+		h.renderTokensPage(c, http.StatusBadRequest, params, filter, fields, nil, err.Error())
+		return
+	}
+	// Free-text search is not a bulk criterion; refuse rather than act on a
+	// different set of tokens than the list the admin was looking at.
+	if search := strings.TrimSpace(c.PostForm("search")); search != "" {
+		params.Search = search
+		h.renderTokensPage(c, http.StatusBadRequest, params, filter, fields, nil,
+			errBulkTokenSearchActive)
+		return
+	}
+
+	action := services.BulkTokenAction(c.PostForm("action"))
+	dryRun := c.PostForm("confirm") != "true"
+	count, err := h.tokenService.BulkUpdateTokens(
+		c.Request.Context(), userID, filter, action, dryRun,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrBulkTokenFilterUnscoped) ||
+			errors.Is(err, services.ErrBulkTokenAction) {
+			h.renderTokensPage(c, http.StatusBadRequest, params, filter, fields, nil, err.Error())
+			return
+		}
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to update tokens")
+		return
+	}
+
+	if dryRun {
+		h.renderTokensPage(c, http.StatusOK, params, filter, fields,
+			&templates.TokenBulkPreview{Action: string(action), Count: count}, "")
+		return
+	}
+
+	q := url.Values{}
+	for k, v := range fields {
+		q.Set(k, v)
+	}
+	if filter.Status != "" {
+		q.Set("status", filter.Status)
+	}
+	if filter.Category != "" {
+		q.Set("category", filter.Category)
+	}
+	q.Set("success", "bulk_"+string(action))
+	q.Set("affected", strconv.FormatInt(count, 10))
+	c.Redirect(http.StatusFound, adminTokensPath+"?"+q.Encode())
+}
+
 // tokenAction extracts tokenID + userID, calls the service method, and
 // redirects back to the token list with a success or warning code.
 func (h *TokenAdminHandler) tokenAction(
@@ -141,3 +338,45 @@ func (h *TokenAdminHandler) EnableToken(c *gin.Context) {
 		"cannot_enable",
 		"enabled")
 }
+
+// ShowTokenFamily renders the lineage timeline of the family the token
+// belongs to: the original grant, every rotation, the access tokens minted
+// from each refresh token, and the audit events that revoked them.
+func (h *TokenAdminHandler) ShowTokenFamily(c *gin.Context) {
+	user := getUserFromContext(c)
+	if user == nil {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	lineage, err := h.tokenService.GetTokenFamilyLineage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			renderErrorPage(c, http.StatusNotFound, "Token not found")
+			return
+		}
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load token family")
+		return
+	}
+
+	templates.RenderTempl(c, http.StatusOK, templates.AdminTokenFamily(templates.TokenFamilyPageProps{
+		BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps: buildNavbarProps(c, user, "tokens"),
+		Lineage:     lineage,
+		TokenID:     c.Param("id"),
+	}))
+}
+
+// GetTokenFamily returns the same lineage as ShowTokenFamily as JSON.
+func (h *TokenAdminHandler) GetTokenFamily(c *gin.Context) {
+	lineage, err := h.tokenService.GetTokenFamilyLineage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load token family"})
+		return
+	}
+	c.JSON(http.StatusOK, lineage)
+}
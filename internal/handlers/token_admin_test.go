@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenAdminRouter(handler *TokenAdminHandler) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-id")
+		c.Set("user", &models.User{ID: "admin-id", Username: "admin", Role: models.UserRoleAdmin})
+		c.Next()
+	})
+	r.GET("/admin/tokens", handler.ShowTokensPage)
+	r.POST("/admin/tokens/bulk", handler.BulkAction)
+	r.GET("/admin/tokens/:id/family", handler.ShowTokenFamily)
+	r.GET("/admin/tokens/:id/family/api", handler.GetTokenFamily)
+	return r
+}
+
+func TestParseTokenFilter(t *testing.T) {
+	values := url.Values{
+		"client_id":     {" app "},
+		"scope":         {"write"},
+		"status":        {"bogus"},
+		"category":      {models.TokenCategoryRefresh},
+		"issued_before": {"2026-01-02T15:04"},
+		"expires_after": {"2026-01-02"},
+	}
+	filter, fields, err := parseTokenFilter(values.Get)
+	require.NoError(t, err)
+	assert.Equal(t, "app", filter.ClientID)
+	assert.Equal(t, "write", filter.Scope)
+	assert.Empty(t, filter.Status, "unknown status is ignored")
+	assert.Equal(t, models.TokenCategoryRefresh, filter.Category)
+	require.NotNil(t, filter.IssuedBefore)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC), *filter.IssuedBefore)
+	require.NotNil(t, filter.ExpiresAfter)
+	assert.Equal(t, "app", fields["client_id"])
+
+	values.Set("issued_after", "yesterday")
+	filter, fields, err = parseTokenFilter(values.Get)
+	require.Error(t, err)
+	assert.Nil(t, filter.IssuedAfter)
+	assert.NotContains(t, fields, "issued_after")
+}
+
+func TestTokenAdminBulkAction(t *testing.T) {
+	s, tokenSvc := setupSessionServices(t)
+	r := newTokenAdminRouter(NewTokenAdminHandler(tokenSvc))
+	clientID := uuid.New().String()
+	target := createTestToken(t, s, uuid.New().String(), clientID)
+	bystander := createTestToken(t, s, uuid.New().String(), uuid.New().String())
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/tokens/bulk", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without a scoping filter the action is refused, even with a status tab.
+	w := post(url.Values{"action": {"revoke"}, "confirm": {"true"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = post(url.Values{
+		"action": {"revoke"}, "status": {models.TokenStatusActive}, "confirm": {"true"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A free-text search is not a bulk criterion, so it is refused too.
+	w = post(url.Values{
+		"action": {"revoke"}, "client_id": {clientID}, "search": {"alice"}, "confirm": {"true"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Clear the search box")
+	tok, err := s.GetAccessTokenByID(target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusActive, tok.Status)
+
+	// Preview does not change anything.
+	w = post(url.Values{"action": {"revoke"}, "client_id": {clientID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Revoke 1 token(s)")
+	tok, err = s.GetAccessTokenByID(target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusActive, tok.Status)
+
+	w = post(url.Values{"action": {"revoke"}, "client_id": {clientID}, "confirm": {"true"}})
+	assert.Equal(t, http.StatusFound, w.Code)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, adminTokensPath, loc.Path)
+	assert.Equal(t, "bulk_revoke", loc.Query().Get("success"))
+	assert.Equal(t, "1", loc.Query().Get("affected"))
+	assert.Equal(t, clientID, loc.Query().Get("client_id"), "filters survive the redirect")
+
+	tok, err = s.GetAccessTokenByID(target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, tok.Status)
+	tok, err = s.GetAccessTokenByID(bystander.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusActive, tok.Status)
+
+	// The redirect target renders the count.
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, loc.String(), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "1 matching token(s) have been revoked.")
+}
+
+func TestTokenAdminFamily(t *testing.T) {
+	s, tokenSvc := setupSessionServices(t)
+	r := newTokenAdminRouter(NewTokenAdminHandler(tokenSvc))
+	tok := createTestToken(t, s, uuid.New().String(), uuid.New().String())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/admin/tokens/" + tok.ID + "/family/api")
+	require.Equal(t, http.StatusOK, w.Code)
+	var lineage services.TokenFamilyLineage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lineage))
+	assert.Equal(t, tok.ID, lineage.FamilyID)
+	require.Len(t, lineage.OrphanTokens, 1)
+	assert.Equal(t, tok.ID, lineage.OrphanTokens[0].ID)
+	assert.NotContains(t, w.Body.String(), tok.TokenHash, "token hashes are never exposed")
+
+	w = get("/admin/tokens/" + tok.ID + "/family")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Token Family")
+	assert.Contains(t, w.Body.String(), tok.ID)
+
+	missing := uuid.New().String()
+	assert.Equal(t, http.StatusNotFound, get("/admin/tokens/"+missing+"/family/api").Code)
+	assert.Equal(t, http.StatusNotFound, get("/admin/tokens/"+missing+"/family").Code)
+}
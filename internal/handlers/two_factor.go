@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/qrcode"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/templates"
+	"github.com/go-authgate/authgate/internal/totp"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loginTwoFactorPath  = "/login/2fa"
+	accountSecurityPath = middleware.TwoFactorEnrollmentPath
+
+	// pendingTwoFactorTTL bounds how long a password-verified login may wait
+	// for its second factor before the user must start over.
+	pendingTwoFactorTTL = 5 * time.Minute
+	// maxTwoFactorAttempts is how many wrong codes a pending login may submit
+	// before it is discarded.
+	maxTwoFactorAttempts = 5
+)
+
+// Pending second-factor session keys. They hold a login whose first factor
+// succeeded until /login/2fa verifies the code; SessionUserID is not set
+// until then, so RequireAuth treats the browser as signed out.
+const (
+	sessionPending2FAUserID   = "pending_2fa_user_id"
+	sessionPending2FAStarted  = "pending_2fa_started"
+	sessionPending2FARemember = "pending_2fa_remember"
+	sessionPending2FARedirect = "pending_2fa_redirect"
+	sessionPending2FAMethods  = "pending_2fa_methods"
+	sessionPending2FAAttempts = "pending_2fa_attempts"
+)
+
+// Authentication method reference values (RFC 8176) recorded for a login and
+// emitted as the ID token "amr" claim.
+const (
+	amrPassword    = "pwd"
+	amrOTP         = "otp"
+	amrHardwareKey = "hwk"
+	amrMFA         = "mfa"
+)
+
+// securitySuccessMessages maps success codes to human-readable messages.
+var securitySuccessMessages = map[string]string{
+	"disabled":        "Two-factor authentication has been turned off.",
+	"passkey_added":   "Passkey added.",
+	"passkey_removed": "Passkey removed.",
+}
+
+// beginSecondFactor records a login that still needs its second factor.
+func beginSecondFactor(
+	session sessions.Session,
+	userID string,
+	rememberMe bool,
+	redirect string,
+	authMethods []string,
+) {
+	session.Set(sessionPending2FAUserID, userID)
+	session.Set(sessionPending2FAStarted, time.Now().Unix())
+	session.Set(sessionPending2FARemember, rememberMe)
+	session.Set(sessionPending2FARedirect, redirect)
+	session.Set(sessionPending2FAMethods, strings.Join(authMethods, " "))
+	session.Set(sessionPending2FAAttempts, 0)
+}
+
+// clearPendingSecondFactor removes any pending second-factor login.
+func clearPendingSecondFactor(session sessions.Session) {
+	session.Delete(sessionPending2FAUserID)
+	session.Delete(sessionPending2FAStarted)
+	session.Delete(sessionPending2FARemember)
+	session.Delete(sessionPending2FARedirect)
+	session.Delete(sessionPending2FAMethods)
+	session.Delete(sessionPending2FAAttempts)
+}
+
+// pendingSecondFactorUser returns the user ID of a pending login that has
+// not yet expired.
+func pendingSecondFactorUser(session sessions.Session) (string, bool) {
+	userID, _ := session.Get(sessionPending2FAUserID).(string)
+	started, _ := session.Get(sessionPending2FAStarted).(int64)
+	if userID == "" || time.Since(time.Unix(started, 0)) > pendingTwoFactorTTL {
+		return "", false
+	}
+	return userID, true
+}
+
+// withSecondFactor appends the second factor's method, and "mfa" once more
+// than one factor was used, to the methods recorded for the first factor.
+func withSecondFactor(methods []string, method string) []string {
+	methods = append(methods, method)
+	if len(methods) > 1 {
+		methods = append(methods, amrMFA)
+	}
+	return methods
+}
+
+// TwoFactorHandler serves the second login step, passkey ceremonies and the
+// account security page. passkeyService is nil when passkeys are disabled.
+type TwoFactorHandler struct {
+	userService    *services.UserService
+	passkeyService *services.PasskeyService
+	cfg            *config.Config
+}
+
+func NewTwoFactorHandler(
+	us *services.UserService,
+	ps *services.PasskeyService,
+	cfg *config.Config,
+) *TwoFactorHandler {
+	return &TwoFactorHandler{userService: us, passkeyService: ps, cfg: cfg}
+}
+
+// renderVerifyPage renders the second-factor prompt shown after the password,
+// offering whichever factors the pending user has enrolled.
+func (h *TwoFactorHandler) renderVerifyPage(
+	c *gin.Context,
+	status int,
+	userID string,
+	errMsg string,
+) {
+	props := templates.LoginTwoFactorPageProps{
+		BaseProps: templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps: templates.NavbarProps{
+			DocsNavEntries: NavbarDocsEntriesFor(resolveLocale(c)),
+		},
+		CodeEnabled: true,
+		Error:       errMsg,
+	}
+	if user, err := h.userService.GetUserByID(c.Request.Context(), userID); err == nil {
+		props.CodeEnabled = user.TOTPEnabled
+		props.PasskeyEnabled = h.passkeyService != nil && user.WebAuthnEnabled
+	}
+	templates.RenderTempl(c, status, templates.LoginTwoFactorPage(props))
+}
+
+// abandonLogin discards a pending login and sends the user back to /login.
+func abandonLogin(c *gin.Context, session sessions.Session, reason string) {
+	clearPendingSecondFactor(session)
+	if err := session.Save(); err != nil {
+		log.Printf("[2FA] Failed to save session: %v", err)
+	}
+	c.Redirect(http.StatusFound, "/login?error="+reason)
+}
+
+// VerifyPage shows the authentication code form for a pending login.
+func (h *TwoFactorHandler) VerifyPage(c *gin.Context) {
+	session := sessions.Default(c)
+	userID, ok := pendingSecondFactorUser(session)
+	if !ok {
+		abandonLogin(c, session, "two_factor_expired")
+		return
+	}
+	h.renderVerifyPage(c, http.StatusOK, userID, "")
+}
+
+// Verify checks the TOTP or recovery code for a pending login and, on
+// success, establishes the session the first factor was waiting for.
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	session := sessions.Default(c)
+	userID, ok := pendingSecondFactorUser(session)
+	if !ok {
+		abandonLogin(c, session, "two_factor_expired")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.userService.VerifyTwoFactor(ctx, userID, c.PostForm("code")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			if !recordSecondFactorFailure(session) {
+				abandonLogin(c, session, "two_factor_attempts")
+				return
+			}
+			h.renderVerifyPage(c, http.StatusUnauthorized, userID,
+				"Invalid authentication code. Please try again.")
+		case errors.Is(err, services.ErrTwoFactorLocked):
+			abandonLogin(c, session, "two_factor_locked")
+		case errors.Is(err, services.ErrTwoFactorNotEnabled),
+			errors.Is(err, services.ErrUserNotFound):
+			// 2FA was reset or the account removed mid-login: start over.
+			abandonLogin(c, session, "two_factor_expired")
+		default:
+			log.Printf("[2FA] Verification error: %v", err)
+			h.renderVerifyPage(c, http.StatusInternalServerError, userID,
+				"Unable to verify the code right now. Please try again.")
+		}
+		return
+	}
+
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		abandonLogin(c, session, "two_factor_expired")
+		return
+	}
+
+	redirectTo := completeSecondFactor(c, session, h.cfg, user, amrOTP)
+	if err := session.Save(); err != nil {
+		log.Printf("[2FA] Failed to save session: %v", err)
+		h.renderVerifyPage(c, http.StatusInternalServerError, userID, "Failed to create session")
+		return
+	}
+	c.Redirect(http.StatusFound, redirectTo)
+}
+
+// recordSecondFactorFailure counts a wrong second factor against the pending
+// login. It returns false once the login has used up its attempts.
+func recordSecondFactorFailure(session sessions.Session) bool {
+	attempts, _ := session.Get(sessionPending2FAAttempts).(int)
+	attempts++
+	if attempts >= maxTwoFactorAttempts {
+		return false
+	}
+	session.Set(sessionPending2FAAttempts, attempts)
+	if err := session.Save(); err != nil {
+		log.Printf("[2FA] Failed to save session: %v", err)
+	}
+	return true
+}
+
+// completeSecondFactor turns the pending login into a signed-in session,
+// recording method as the second factor, and returns where to send the
+// user. The caller saves the session.
+func completeSecondFactor(
+	c *gin.Context,
+	session sessions.Session,
+	cfg *config.Config,
+	user *models.User,
+	method string,
+) string {
+	rememberMe, _ := session.Get(sessionPending2FARemember).(bool)
+	redirectTo, _ := session.Get(sessionPending2FARedirect).(string)
+	methods, _ := session.Get(sessionPending2FAMethods).(string)
+	if redirectTo == "" {
+		redirectTo = "/account/sessions"
+	}
+	startLoginSession(c, session, cfg, user, rememberMe,
+		withSecondFactor(strings.Fields(methods), method))
+	return redirectTo
+}
+
+// renderSecurityPage renders /account/security. setup, when set, shows the
+// enrollment step; recoveryCodes, when set, are shown once.
+func (h *TwoFactorHandler) renderSecurityPage(
+	c *gin.Context,
+	status int,
+	setup *templates.TwoFactorSetup,
+	recoveryCodes []string,
+	errMsg string,
+) {
+	userID := getUserIDFromContext(c)
+	if userID == "" {
+		renderErrorPage(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	// Read from the store rather than the request context: the page must
+	// reflect changes made earlier in this request.
+	user, err := h.userService.AdminGetUserByID(userID)
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to load account")
+		return
+	}
+
+	var remaining int64
+	if user.TOTPEnabled {
+		if remaining, err = h.userService.CountRecoveryCodes(userID); err != nil {
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to load recovery codes")
+			return
+		}
+	}
+
+	var passkeys []templates.PasskeyItem
+	if h.passkeyService != nil {
+		creds, err := h.passkeyService.ListPasskeys(userID)
+		if err != nil {
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to load passkeys")
+			return
+		}
+		passkeys = make([]templates.PasskeyItem, 0, len(creds))
+		for _, cred := range creds {
+			item := templates.PasskeyItem{
+				ID:        cred.ID,
+				Name:      cred.Name,
+				Synced:    cred.BackupEligible,
+				CreatedAt: cred.CreatedAt.Format("2006-01-02 15:04"),
+			}
+			if cred.LastUsedAt != nil {
+				item.LastUsedAt = cred.LastUsedAt.Format("2006-01-02 15:04")
+			}
+			passkeys = append(passkeys, item)
+		}
+	}
+
+	templates.RenderTempl(c, status, templates.AccountSecurity(templates.SecurityPageProps{
+		BaseProps:         templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+		NavbarProps:       buildNavbarProps(c, user, "security"),
+		TwoFactorEnabled:  user.TOTPEnabled,
+		TwoFactorAllowed:  !user.IsExternal(),
+		TwoFactorRequired: middleware.TwoFactorRequired(h.cfg.TOTPRequired, user),
+		HasSecondFactor:   middleware.HasSecondFactor(user, h.passkeyService != nil),
+		TOTPRemovable:     h.totpRemovable(user),
+		RecoveryCodesLeft: remaining,
+		Setup:             setup,
+		RecoveryCodes:     recoveryCodes,
+		PasskeysEnabled:   h.passkeyService != nil,
+		Passkeys:          passkeys,
+		PasskeyRemovable:  h.passkeyRemovable(user, len(passkeys)),
+		Error:             errMsg,
+		Success:           securitySuccessMessages[c.Query("success")],
+	}))
+}
+
+// totpRemovable reports whether the user may turn off TOTP: TOTP_REQUIRED
+// must not apply, or a passkey must remain as the second factor.
+func (h *TwoFactorHandler) totpRemovable(user *models.User) bool {
+	return !middleware.TwoFactorRequired(h.cfg.TOTPRequired, user) ||
+		(h.passkeyService != nil && user.WebAuthnEnabled)
+}
+
+// passkeyRemovable reports whether the user may remove one of their
+// count passkeys without leaving TOTP_REQUIRED unsatisfied.
+func (h *TwoFactorHandler) passkeyRemovable(user *models.User, count int) bool {
+	return !middleware.TwoFactorRequired(h.cfg.TOTPRequired, user) ||
+		user.TOTPEnabled || count > 1
+}
+
+// buildSetup returns the enrollment details for the user's pending secret.
+func (h *TwoFactorHandler) buildSetup(c *gin.Context) (*templates.TwoFactorSetup, error) {
+	secret, err := h.userService.BeginTOTPEnrollment(getUserIDFromContext(c))
+	if err != nil {
+		return nil, err
+	}
+	user := getUserFromContext(c)
+	uri := totp.ProvisioningURI(h.cfg.TOTPIssuer, user.Username, secret)
+	setup := &templates.TwoFactorSetup{Secret: secret, URI: uri}
+	// Very long usernames can exceed the QR encoder's capacity; the secret
+	// can still be entered by hand.
+	if code, err := qrcode.Encode([]byte(uri)); err == nil {
+		setup.QRCodeSVG = code.SVG()
+	}
+	return setup, nil
+}
+
+// SecurityPage shows the user's two-factor status and actions.
+func (h *TwoFactorHandler) SecurityPage(c *gin.Context) {
+	h.renderSecurityPage(c, http.StatusOK, nil, nil, "")
+}
+
+// SetupPage starts TOTP enrollment and shows the QR code to scan.
+func (h *TwoFactorHandler) SetupPage(c *gin.Context) {
+	setup, err := h.buildSetup(c)
+	if err != nil {
+		h.renderSecurityError(c, err)
+		return
+	}
+	h.renderSecurityPage(c, http.StatusOK, setup, nil, "")
+}
+
+// EnableTOTP confirms enrollment with a code from the authenticator app and
+// shows the recovery codes once.
+func (h *TwoFactorHandler) EnableTOTP(c *gin.Context) {
+	codes, err := h.userService.EnableTOTP(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.PostForm("code"),
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			setup, setupErr := h.buildSetup(c)
+			if setupErr != nil {
+				h.renderSecurityError(c, setupErr)
+				return
+			}
+			h.renderSecurityPage(c, http.StatusBadRequest, setup, nil,
+				"That code didn't match. Check the time on your device and try again.")
+			return
+		}
+		h.renderSecurityError(c, err)
+		return
+	}
+	h.renderSecurityPage(c, http.StatusOK, nil, codes, "")
+}
+
+// DisableTOTP turns off two-factor authentication after checking a code.
+func (h *TwoFactorHandler) DisableTOTP(c *gin.Context) {
+	user := getUserFromContext(c)
+	if !h.totpRemovable(user) {
+		h.renderSecurityPage(c, http.StatusForbidden, nil, nil,
+			"Two-factor authentication is required for your account and cannot be turned off.")
+		return
+	}
+	if err := h.userService.DisableTOTP(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.PostForm("code"),
+	); err != nil {
+		h.renderSecurityError(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, accountSecurityPath+"?success=disabled")
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a TOTP
+// code and shows the new codes once.
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	codes, err := h.userService.RegenerateRecoveryCodes(
+		c.Request.Context(),
+		getUserIDFromContext(c),
+		c.PostForm("code"),
+	)
+	if err != nil {
+		h.renderSecurityError(c, err)
+		return
+	}
+	h.renderSecurityPage(c, http.StatusOK, nil, codes, "")
+}
+
+// renderSecurityError maps two-factor service errors to the security page.
+func (h *TwoFactorHandler) renderSecurityError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		h.renderSecurityPage(c, http.StatusBadRequest, nil, nil,
+			"Invalid authentication code. Please try again.")
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		h.renderSecurityPage(c, http.StatusTooManyRequests, nil, nil,
+			"Too many incorrect authentication codes. Please try again later.")
+	case errors.Is(err, services.ErrTwoFactorNotAllowed),
+		errors.Is(err, services.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrTwoFactorNotStarted):
+		h.renderSecurityPage(c, http.StatusBadRequest, nil, nil, err.Error())
+	default:
+		log.Printf("[2FA] Security page error: %v", err)
+		renderErrorPage(c, http.StatusInternalServerError, "Failed to update two-factor authentication")
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/middleware"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/services"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/totp"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoFactorTestMaxFailures is the per-account limit in tests; it is above
+// maxTwoFactorAttempts so a single pending login cannot reach it.
+const twoFactorTestMaxFailures = 8
+
+type twoFactorTestEnv struct {
+	router *gin.Engine
+	user   *models.User
+	secret string
+}
+
+// setupTwoFactorTest enrolls a local user in TOTP and wires /login/2fa next to
+// helper routes that seed a pending login and report the resulting session.
+func setupTwoFactorTest(t *testing.T) *twoFactorTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.New(context.Background(), "sqlite", ":memory:", &config.Config{})
+	require.NoError(t, err)
+	userSvc := services.NewUserService(
+		s, nil, nil, "local", false, services.NewNoopAuditService(),
+		cache.NewNoopCache[models.User](), 0,
+		services.WithTwoFactorGuard(services.NewTwoFactorGuard(nil, &config.Config{
+			TwoFactorFailureWindow: time.Minute,
+			TwoFactorMaxFailures:   twoFactorTestMaxFailures,
+		}, nil)),
+	)
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hash",
+		Role:         models.UserRoleUser,
+		AuthSource:   models.AuthSourceLocal,
+		IsActive:     true,
+	}
+	require.NoError(t, s.CreateUser(user))
+	secret, err := userSvc.BeginTOTPEnrollment(user.ID)
+	require.NoError(t, err)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	_, err = userSvc.EnableTOTP(context.Background(), user.ID, code)
+	require.NoError(t, err)
+
+	handler := NewTwoFactorHandler(userSvc, nil, &config.Config{})
+	r := gin.New()
+	r.Use(sessions.Sessions("test_session", cookie.NewStore([]byte("test-secret"))))
+	r.GET("/seed", func(c *gin.Context) {
+		session := sessions.Default(c)
+		beginSecondFactor(session, user.ID, false, "/oauth/authorize?client_id=x",
+			[]string{amrPassword})
+		require.NoError(t, session.Save())
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/whoami", func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, _ := session.Get(SessionUserID).(string)
+		methods, _ := session.Get(middleware.SessionAuthMethods).(string)
+		c.String(http.StatusOK, userID+"|"+methods)
+	})
+	r.POST(loginTwoFactorPath, handler.Verify)
+
+	return &twoFactorTestEnv{router: r, user: user, secret: secret}
+}
+
+// do sends a request carrying the given cookies and returns the recorder
+// together with the cookies to use for the next request.
+func (e *twoFactorTestEnv) do(
+	t *testing.T,
+	req *http.Request,
+	cookies []*http.Cookie,
+) (*httptest.ResponseRecorder, []*http.Cookie) {
+	t.Helper()
+	for _, ck := range cookies {
+		req.AddCookie(ck)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	if fresh := w.Result().Cookies(); len(fresh) > 0 {
+		return w, fresh
+	}
+	return w, cookies
+}
+
+func postTwoFactorCode(code string) *http.Request {
+	form := url.Values{"code": {code}}
+	req := httptest.NewRequest(http.MethodPost, loginTwoFactorPath,
+		strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestTwoFactorVerify_Success(t *testing.T) {
+	env := setupTwoFactorTest(t)
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+
+	// Enrollment consumed the current step, so use the next one.
+	code, err := totp.Code(env.secret, time.Now().Add(totp.Period))
+	require.NoError(t, err)
+	w, cookies := env.do(t, postTwoFactorCode(code), cookies)
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/oauth/authorize?client_id=x", w.Header().Get("Location"))
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, env.user.ID+"|pwd otp mfa", w.Body.String())
+}
+
+func TestTwoFactorVerify_NoPendingLogin(t *testing.T) {
+	env := setupTwoFactorTest(t)
+
+	w, _ := env.do(t, postTwoFactorCode("123456"), nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?error=two_factor_expired", w.Header().Get("Location"))
+}
+
+func TestTwoFactorVerify_TooManyAttempts(t *testing.T) {
+	env := setupTwoFactorTest(t)
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+
+	var w *httptest.ResponseRecorder
+	for range maxTwoFactorAttempts - 1 {
+		w, cookies = env.do(t, postTwoFactorCode("not-a-code"), cookies)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w, cookies = env.do(t, postTwoFactorCode("not-a-code"), cookies)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?error=two_factor_attempts", w.Header().Get("Location"))
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, "|", w.Body.String(), "abandoned login must not create a session")
+}
+
+func TestTwoFactorVerify_AccountLockedAcrossLogins(t *testing.T) {
+	env := setupTwoFactorTest(t)
+
+	// Starting a new login resets the per-login attempts but not the
+	// account's count.
+	var w *httptest.ResponseRecorder
+	_, cookies := env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), nil)
+	for range maxTwoFactorAttempts {
+		w, cookies = env.do(t, postTwoFactorCode("not-a-code"), cookies)
+	}
+	require.Equal(t, "/login?error=two_factor_attempts", w.Header().Get("Location"))
+
+	_, cookies = env.do(t, httptest.NewRequest(http.MethodGet, "/seed", nil), cookies)
+	for range twoFactorTestMaxFailures - maxTwoFactorAttempts {
+		w, cookies = env.do(t, postTwoFactorCode("not-a-code"), cookies)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	code, err := totp.Code(env.secret, time.Now().Add(totp.Period))
+	require.NoError(t, err)
+	w, cookies = env.do(t, postTwoFactorCode(code), cookies)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login?error=two_factor_locked", w.Header().Get("Location"))
+
+	w, _ = env.do(t, httptest.NewRequest(http.MethodGet, "/whoami", nil), cookies)
+	assert.Equal(t, "|", w.Body.String(), "locked account must not get a session")
+}
+
+func TestWithSecondFactor(t *testing.T) {
+	assert.Equal(t, []string{"pwd", "otp", "mfa"}, withSecondFactor([]string{amrPassword}, amrOTP))
+	// Upstream OAuth logins have no first-factor method of their own.
+	assert.Equal(t, []string{"otp"}, withSecondFactor(nil, amrOTP))
+}
@@ -66,6 +66,15 @@ func (h *UserAdminHandler) ShowUsersPage(c *gin.Context) {
 		return
 	}
 
+	// The pending count is only set when signups need approval.
+	var pendingSignups []models.User
+	if c.GetInt(ctxKeyPendingSignupsCount) > 0 {
+		if pendingSignups, err = h.userService.ListPendingSignups(); err != nil {
+			renderErrorPage(c, http.StatusInternalServerError, "Failed to load pending signups")
+			return
+		}
+	}
+
 	templates.RenderTempl(c, http.StatusOK, templates.AdminUsers(templates.UsersPageProps{
 		BaseProps:        templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
 		NavbarProps:      buildNavbarProps(c, user, "users"),
@@ -77,6 +86,7 @@ func (h *UserAdminHandler) ShowUsersPage(c *gin.Context) {
 		Success:          getFlashMessage(c),
 		RoleFilter:       params.StatusFilter,
 		AuthSourceFilter: params.CategoryFilter,
+		PendingSignups:   pendingSignups,
 	}))
 }
 
@@ -240,6 +250,41 @@ func (h *UserAdminHandler) ResetPassword(c *gin.Context) {
 	)
 }
 
+// ResetTwoFactor removes a user's authenticator and recovery codes so they can
+// sign in with their password and enroll again.
+func (h *UserAdminHandler) ResetTwoFactor(c *gin.Context) {
+	currentUser := getUserFromContext(c)
+	if currentUser == nil {
+		renderErrorPage(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	targetUser, ok := h.adminGetUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.AdminResetTwoFactor(
+		c.Request.Context(),
+		targetUser.ID,
+		currentUser.ID,
+	); err != nil {
+		templates.RenderTempl(
+			c,
+			http.StatusBadRequest,
+			templates.AdminUserDetail(templates.UserDetailPageProps{
+				BaseProps:   templates.BaseProps{CSRFToken: middleware.GetCSRFToken(c)},
+				NavbarProps: buildNavbarProps(c, currentUser, "users"),
+				TargetUser:  targetUser,
+				Error:       err.Error(),
+			}),
+		)
+		return
+	}
+
+	flashAndRedirect(c, "Two-factor authentication has been reset.", "/admin/users/"+targetUser.ID)
+}
+
 // DeleteUser handles user deletion.
 func (h *UserAdminHandler) DeleteUser(c *gin.Context) {
 	currentUser := getUserFromContext(c)
@@ -537,6 +582,7 @@ func (h *UserAdminHandler) toggleUserActive(c *gin.Context, active bool) {
 		case errors.Is(err, services.ErrCannotChangeOwnStatus),
 			errors.Is(err, services.ErrUserAlreadyActive),
 			errors.Is(err, services.ErrUserAlreadyDisabled),
+			errors.Is(err, services.ErrSignupIncomplete),
 			errors.Is(err, services.ErrCannotRemoveLastAdmin):
 			renderErrorPage(c, http.StatusBadRequest, err.Error())
 		case errors.Is(err, services.ErrUserNotFound):
@@ -77,6 +77,7 @@ func clientToDisplay(app *models.OAuthApplication) *templates.ClientDisplay {
 		Description:                 app.Description,
 		UserID:                      app.UserID,
 		Scopes:                      app.Scopes,
+		RequiredScopes:              app.RequiredScopes,
 		GrantTypes:                  app.GrantTypes,
 		RedirectURIs:                app.RedirectURIs.Join(", "),
 		AllowedResources:            app.AllowedResources.Join(", "),
@@ -86,6 +87,15 @@ func clientToDisplay(app *models.OAuthApplication) *templates.ClientDisplay {
 		EnableClientCredentialsFlow: app.EnableClientCredentialsFlow,
 		Status:                      app.Status,
 		TokenProfile:                app.TokenProfile,
+		TokenFormat:                 app.TokenFormat,
+		MaxRefreshFamilies:          app.MaxRefreshFamilies,
+		RefreshFamilyPolicy:         app.RefreshFamilyPolicy,
+		DeviceUserCodeCharset:       app.DeviceUserCodeCharset,
+		DeviceUserCodeLength:        app.DeviceUserCodeLength,
+		DeviceCodeLifetime:          app.DeviceCodeLifetime,
+		DevicePollingInterval:       app.DevicePollingInterval,
+		ConsentLifetimeDays:         app.ConsentLifetimeDays,
+		Trusted:                     app.Trusted,
 		Project:                     app.Project,
 		ServiceAccount:              app.ServiceAccount,
 		CreatedAt:                   app.CreatedAt,
@@ -100,8 +110,8 @@ const (
 )
 
 // buildNavbarProps creates NavbarProps from a user model and active link identifier.
-// If the gin context contains a pending_clients_count value (set by InjectPendingCount
-// middleware), it is included in the navbar badge for admin users.
+// If the gin context contains pending client or signup counts (set by the
+// InjectPendingCount middlewares), they are shown as navbar badges for admin users.
 func buildNavbarProps(c *gin.Context, user *models.User, activeLink string) templates.NavbarProps {
 	pendingCount := 0
 	if v, exists := c.Get(ctxKeyPendingClientsCount); exists {
@@ -109,6 +119,12 @@ func buildNavbarProps(c *gin.Context, user *models.User, activeLink string) temp
 			pendingCount = count
 		}
 	}
+	pendingSignups := 0
+	if v, exists := c.Get(ctxKeyPendingSignupsCount); exists {
+		if count, ok := v.(int); ok {
+			pendingSignups = count
+		}
+	}
 	swaggerEnabled := false
 	if v, exists := c.Get(middleware.ContextKeySwaggerEnabled); exists {
 		if enabled, ok := v.(bool); ok {
@@ -121,6 +137,7 @@ func buildNavbarProps(c *gin.Context, user *models.User, activeLink string) temp
 		IsAdmin:             user.IsAdmin(),
 		ActiveLink:          activeLink,
 		PendingClientsCount: pendingCount,
+		PendingSignupsCount: pendingSignups,
 		DocsNavEntries:      NavbarDocsEntriesFor(resolveLocale(c)),
 		SwaggerEnabled:      swaggerEnabled,
 	}
@@ -198,6 +215,7 @@ func toAuthorizationDisplaySlice(
 			Scopes:     a.Scopes,
 			Resource:   []string(a.Resource),
 			GrantedAt:  a.GrantedAt,
+			ExpiresAt:  a.ExpiresAt,
 			IsActive:   a.IsActive,
 		})
 	}
@@ -0,0 +1,165 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = core.MailMessage{
+	To:      "Alice <alice@example.com>",
+	Subject: "Réinitialiser",
+	Text:    "Open this link:\nhttps://auth.example.com/password/reset?token=abc\n",
+}
+
+func TestCompose(t *testing.T) {
+	from := &mail.Address{Name: "AuthGate", Address: "noreply@example.com"}
+	raw, rcpt, err := compose(from, testMessage, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", rcpt)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, testMessage.Subject, subject)
+	assert.Equal(t, `"AuthGate" <noreply@example.com>`, parsed.Header.Get("From"))
+	assert.Contains(t, parsed.Header.Get("Message-ID"), "@example.com>")
+	body, err := io.ReadAll(parsed.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "token=3Dabc\r\n")
+}
+
+func TestCompose_Rejects(t *testing.T) {
+	from := &mail.Address{Address: "noreply@example.com"}
+
+	_, _, err := compose(from, core.MailMessage{To: "not an address"}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidMessage)
+
+	_, _, err = compose(from, core.MailMessage{
+		To:      "alice@example.com",
+		Subject: "Hi\r\nBcc: eve@example.com",
+	}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewFileMailer("noreply@example.com", dir)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), testMessage))
+	require.NoError(t, m.Send(context.Background(), testMessage))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), ".eml"))
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestNewSMTPMailer_Validates(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{From: "noreply@example.com", TLS: TLSNone})
+	require.Error(t, err)
+	_, err = NewSMTPMailer(SMTPConfig{From: "noreply@example.com", Host: "h", TLS: "ssl"})
+	require.Error(t, err)
+}
+
+// fakeSMTPServer accepts one plain-text session and returns the envelope
+// and data it received.
+func fakeSMTPServer(t *testing.T, extensions ...string) (int, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	got := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var lines []string
+		_ = tp.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				break
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				for _, ext := range extensions {
+					_ = tp.PrintfLine("250-%s", ext)
+				}
+				_ = tp.PrintfLine("250 fake")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotLines()
+				lines = append(lines, "DATA", strings.Join(data, "\n"))
+				_ = tp.PrintfLine("250 queued")
+				continue
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				got <- lines
+				return
+			default:
+				_ = tp.PrintfLine("250 ok")
+			}
+			lines = append(lines, line)
+		}
+		got <- lines
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, got
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	port, got := fakeSMTPServer(t)
+	m, err := NewSMTPMailer(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: port,
+		TLS:  TLSNone,
+		From: "AuthGate <noreply@example.com>",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), testMessage))
+
+	lines := <-got
+	assert.Contains(t, lines, "MAIL FROM:<noreply@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<alice@example.com>")
+	require.Contains(t, lines, "DATA")
+	assert.Contains(t, lines[len(lines)-1], "https://auth.example.com/password/reset?token=3Dabc")
+}
+
+func TestSMTPMailer_RequiresStartTLS(t *testing.T) {
+	port, _ := fakeSMTPServer(t)
+	m, err := NewSMTPMailer(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: port,
+		TLS:  TLSStartTLS,
+		From: "noreply@example.com",
+	})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), testMessage)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support STARTTLS")
+}
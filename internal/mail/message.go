@@ -0,0 +1,64 @@
+// Package mail provides core.Mailer implementations: an SMTP client for
+// production and log and file sinks for development.
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+// ErrInvalidMessage is returned when a message cannot be encoded safely.
+var ErrInvalidMessage = errors.New("mail: invalid message")
+
+// compose renders msg as an RFC 5322 message from the given sender and
+// returns it with the bare recipient address for the SMTP envelope.
+func compose(from *mail.Address, msg core.MailMessage, now time.Time) ([]byte, string, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: recipient %q: %v", ErrInvalidMessage, msg.To, err)
+	}
+	// Header values are encoded below, but reject line breaks outright so a
+	// caller can never smuggle extra headers.
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, "", fmt.Errorf("%w: subject contains a line break", ErrInvalidMessage)
+	}
+
+	msgID, err := util.CryptoRandomString(16)
+	if err != nil {
+		return nil, "", err
+	}
+	domain := "localhost"
+	if at := strings.LastIndexByte(from.Address, '@'); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", msgID, domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, "", err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), to.Address, nil
+}
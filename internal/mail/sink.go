@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+// Compile-time interface checks.
+var (
+	_ core.Mailer = (*LogMailer)(nil)
+	_ core.Mailer = (*FileMailer)(nil)
+)
+
+// LogMailer writes each message to the process log instead of sending it.
+// Messages carry live reset links, so it is meant for development only.
+type LogMailer struct {
+	from *mail.Address
+}
+
+// NewLogMailer creates a mailer that logs messages sent from the given
+// address.
+func NewLogMailer(from string) (*LogMailer, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid sender %q: %w", from, err)
+	}
+	return &LogMailer{from: addr}, nil
+}
+
+// Send logs msg.
+func (m *LogMailer) Send(_ context.Context, msg core.MailMessage) error {
+	if _, _, err := compose(m.from, msg, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("[mail] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// FileMailer writes each message as an .eml file in a directory, where it
+// can be opened with any mail client.
+type FileMailer struct {
+	from *mail.Address
+	dir  string
+}
+
+// NewFileMailer creates a mailer that writes messages to dir, creating it
+// if needed.
+func NewFileMailer(from, dir string) (*FileMailer, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid sender %q: %w", from, err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("mail: create %s: %w", dir, err)
+	}
+	return &FileMailer{from: addr, dir: dir}, nil
+}
+
+// Send writes msg to a new file named after the current time.
+func (m *FileMailer) Send(_ context.Context, msg core.MailMessage) error {
+	now := time.Now()
+	raw, _, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	suffix, err := util.CryptoRandomString(8)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), suffix)
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		return fmt.Errorf("mail: write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+)
+
+// Compile-time interface check.
+var _ core.Mailer = (*SMTPMailer)(nil)
+
+// defaultSMTPTimeout bounds a delivery when ctx carries no deadline.
+const defaultSMTPTimeout = 30 * time.Second
+
+// TLS modes accepted by SMTPConfig.TLS.
+const (
+	TLSStartTLS = "starttls" // upgrade a plain connection; fail if unsupported
+	TLSImplicit = "tls"      // connect over TLS (usually port 465)
+	TLSNone     = "none"     // plain text; only for trusted local relays
+)
+
+// SMTPConfig configures an SMTPMailer.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // empty disables AUTH
+	Password string
+	TLS      string // TLSStartTLS, TLSImplicit or TLSNone
+	From     string
+
+	// TLSConfig overrides the client TLS settings; nil verifies Host.
+	TLSConfig *tls.Config
+}
+
+// SMTPMailer delivers messages through an SMTP server, opening one
+// connection per message.
+type SMTPMailer struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+// NewSMTPMailer validates cfg and creates an SMTP mailer.
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid sender %q: %w", cfg.From, err)
+	}
+	if cfg.Host == "" {
+		return nil, errors.New("mail: SMTP host is required")
+	}
+	switch cfg.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("mail: unknown SMTP TLS mode %q", cfg.TLS)
+	}
+	return &SMTPMailer{cfg: cfg, from: from}, nil
+}
+
+// Send delivers msg, honouring ctx's deadline for the whole exchange.
+func (m *SMTPMailer) Send(ctx context.Context, msg core.MailMessage) error {
+	raw, rcpt, err := compose(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mail: dial %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	if m.cfg.TLS == TLSImplicit {
+		conn = tls.Client(conn, m.tlsConfig())
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if m.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("mail: %s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(m.tlsConfig()); err != nil {
+			return fmt.Errorf("mail: STARTTLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("mail: AUTH: %w", err)
+		}
+	}
+	if err := c.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("mail: MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(rcpt); err != nil {
+		return fmt.Errorf("mail: RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.cfg.TLSConfig != nil {
+		return m.cfg.TLSConfig
+	}
+	return &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+}
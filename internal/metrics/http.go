@@ -95,7 +95,7 @@ func (m *Metrics) RecordOAuthDeviceCodeAuthorized(authorizationTime time.Duratio
 
 // RecordOAuthDeviceCodeValidation records device code validation result
 func (m *Metrics) RecordOAuthDeviceCodeValidation(result string) {
-	// result: success, expired, invalid, pending
+	// result: success, expired, invalid, pending, slow_down
 	m.DeviceCodeValidationTotal.WithLabelValues(result).Inc()
 
 	// Decrease active count when device code is consumed or expired
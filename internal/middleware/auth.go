@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/models"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 const (
@@ -21,6 +23,15 @@ const (
 	SessionLastActivity = "last_activity"
 	SessionFingerprint  = "session_fingerprint"
 	SessionRememberMe   = "remember_me"
+	// SessionID identifies one login. It is minted fresh at every sign-in
+	// and stamped as `sid` on tokens issued from that login.
+	SessionID = "sid"
+	// SessionAuthMethods holds the space-separated RFC 8176 authentication
+	// method references (e.g. "pwd otp mfa") for the current login.
+	SessionAuthMethods = "auth_methods"
+	// SessionAuthTime holds the Unix time of the sign-in, compared against
+	// User.SessionsRevokedAt to end sessions that predate a password reset.
+	SessionAuthTime = "auth_time"
 )
 
 // SessionOptions builds a sessions.Options with the project's standard cookie
@@ -43,6 +54,28 @@ func ApplyRememberMe(session sessions.Session, maxAge int, isProduction bool) {
 	session.Options(SessionOptions(maxAge, isProduction))
 }
 
+// NewSessionID returns a fresh login session ID. Call it on every successful
+// sign-in so a new login never inherits tokens bound to a previous one.
+func NewSessionID() string {
+	return uuid.New().String()
+}
+
+// EnsureSessionID returns the login session ID, minting and saving one for
+// sessions that were created before session IDs were recorded. Returns ""
+// if a new ID could not be persisted to the cookie.
+func EnsureSessionID(session sessions.Session) string {
+	if sid, ok := session.Get(SessionID).(string); ok && sid != "" {
+		return sid
+	}
+	sid := NewSessionID()
+	session.Set(SessionID, sid)
+	if err := session.Save(); err != nil {
+		log.Printf("[session] save error while recording session ID: %v", err)
+		return ""
+	}
+	return sid
+}
+
 // GenerateFingerprint creates a SHA256 hash from IP (optional) and User-Agent.
 func GenerateFingerprint(ip, userAgent string, includeIP bool) string {
 	data := userAgent
@@ -53,7 +86,7 @@ func GenerateFingerprint(ip, userAgent string, includeIP bool) string {
 }
 
 // loadUserFromSession reads the user_id from the session, fetches the user, and
-// populates "user_id", "user", and the request context.
+// populates "user_id", "user", the login session ID, and the request context.
 // Returns (true, nil) on success, (false, nil) when there is no session or the
 // user was deleted, and (false, err) on transient failures (DB down, etc.).
 func loadUserFromSession(c *gin.Context, userService *services.UserService) (bool, error) {
@@ -77,8 +110,8 @@ func loadUserFromSession(c *gin.Context, userService *services.UserService) (boo
 		// Transient DB error — don't clear the session
 		return false, err
 	}
-	// Check if user account is disabled
-	if !user.IsActive {
+	// Check if user account is disabled or the session predates a revocation
+	if !user.IsActive || sessionRevoked(session, user) {
 		session.Clear()
 		if saveErr := session.Save(); saveErr != nil {
 			return false, saveErr
@@ -88,10 +121,26 @@ func loadUserFromSession(c *gin.Context, userService *services.UserService) (boo
 
 	c.Set("user_id", userIDStr)
 	c.Set("user", user)
+	if sid := EnsureSessionID(session); sid != "" {
+		c.Set(ContextKeySessionID, sid)
+	}
+	if methods, ok := session.Get(SessionAuthMethods).(string); ok && methods != "" {
+		c.Set(ContextKeyAuthMethods, strings.Fields(methods))
+	}
 	c.Request = c.Request.WithContext(models.SetUserContext(c.Request.Context(), user))
 	return true, nil
 }
 
+// sessionRevoked reports whether the session was signed in at or before the
+// user's SessionsRevokedAt. Sessions without a sign-in time count as older.
+func sessionRevoked(session sessions.Session, user *models.User) bool {
+	if user.SessionsRevokedAt == nil {
+		return false
+	}
+	authTime, _ := session.Get(SessionAuthTime).(int64)
+	return authTime <= user.SessionsRevokedAt.Unix()
+}
+
 // OptionalAuth loads the user from session if logged in, but does not redirect if not.
 // Use for public pages that show richer UI when authenticated.
 func OptionalAuth(userService *services.UserService) gin.HandlerFunc {
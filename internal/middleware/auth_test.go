@@ -795,3 +795,51 @@ func TestRequireAuth_TransientDBError_Returns503(t *testing.T) {
 	// Should return 503 (not redirect, not clear session)
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
+
+func TestRequireAuth_RevokedSession(t *testing.T) {
+	userService, testStore := createTestUserServiceWithStore(t)
+	revokedAt := time.Now().Add(-time.Minute)
+	user := &models.User{
+		ID:                "revoked-user",
+		Username:          "alice",
+		Email:             "alice@example.com",
+		Role:              models.UserRoleUser,
+		IsActive:          true,
+		SessionsRevokedAt: &revokedAt,
+	}
+	require.NoError(t, testStore.CreateUser(user))
+
+	tests := []struct {
+		name     string
+		authTime any
+		wantCode int
+	}{
+		{name: "signed in after revocation", authTime: time.Now().Unix(), wantCode: http.StatusOK},
+		{name: "signed in before revocation", authTime: revokedAt.Add(-time.Hour).Unix(), wantCode: http.StatusFound},
+		{name: "no sign-in time", authTime: nil, wantCode: http.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Use(func(c *gin.Context) {
+				session := sessions.Default(c)
+				session.Set(SessionUserID, user.ID)
+				if tt.authTime != nil {
+					session.Set(SessionAuthTime, tt.authTime)
+				}
+				c.Next()
+			})
+			r.Use(RequireAuth(userService))
+			r.GET("/protected", func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(
+				context.Background(), http.MethodGet, "/protected", nil,
+			)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -8,6 +8,15 @@ import (
 // ContextKeyClientIP is the gin context key for the client IP address.
 const ContextKeyClientIP = "client_ip"
 
+// ContextKeySessionID is the gin context key for the login session ID (sid).
+// loadUserFromSession sets it for authenticated requests.
+const ContextKeySessionID = "session_id"
+
+// ContextKeyAuthMethods is the gin context key for the []string of
+// authentication methods used at login. loadUserFromSession sets it when the
+// session recorded them.
+const ContextKeyAuthMethods = "auth_methods"
+
 // ContextKeySwaggerEnabled is the gin context key for the Swagger UI feature flag.
 // InjectSwaggerEnabled sets it; buildNavbarProps reads it so templates can hide
 // the Swagger links when /swagger is not registered.
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorEnrollmentPath is where users who must enroll in 2FA are sent.
+const TwoFactorEnrollmentPath = "/account/security"
+
+// TwoFactorRequired reports whether the TOTP_REQUIRED policy obliges user to
+// enroll in two-factor authentication. External users authenticate elsewhere
+// and cannot enroll, so the policy never applies to them.
+func TwoFactorRequired(policy string, user *models.User) bool {
+	if user == nil || user.IsExternal() {
+		return false
+	}
+	switch policy {
+	case config.TwoFactorRequiredAll:
+		return true
+	case config.TwoFactorRequiredAdmins:
+		return user.IsAdmin()
+	default:
+		return false
+	}
+}
+
+// HasSecondFactor reports whether user has enrolled a second factor they can
+// sign in with: an authenticator app, or a passkey while passkeys are enabled.
+func HasSecondFactor(user *models.User, passkeysEnabled bool) bool {
+	return user.TOTPEnabled || (passkeysEnabled && user.WebAuthnEnabled)
+}
+
+// RequireTwoFactorEnrollment redirects users who must use 2FA but have not
+// enrolled yet to the security page, so they cannot use the rest of the app
+// until they do. Requests under TwoFactorEnrollmentPath pass through. Must
+// run after RequireAuth.
+func RequireTwoFactorEnrollment(policy string, passkeysEnabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy == config.TwoFactorRequiredNone ||
+			strings.HasPrefix(c.Request.URL.Path, TwoFactorEnrollmentPath) {
+			c.Next()
+			return
+		}
+		user, _ := c.Get("user")
+		u, ok := user.(*models.User)
+		if ok && !HasSecondFactor(u, passkeysEnabled) && TwoFactorRequired(policy, u) {
+			c.Redirect(http.StatusFound, TwoFactorEnrollmentPath)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorRequired(t *testing.T) {
+	admin := &models.User{Role: models.UserRoleAdmin, AuthSource: models.AuthSourceLocal}
+	user := &models.User{Role: models.UserRoleUser, AuthSource: models.AuthSourceLocal}
+	external := &models.User{Role: models.UserRoleAdmin, AuthSource: models.AuthSourceHTTPAPI}
+
+	tests := []struct {
+		name   string
+		policy string
+		user   *models.User
+		want   bool
+	}{
+		{"none policy", config.TwoFactorRequiredNone, admin, false},
+		{"admins policy admin", config.TwoFactorRequiredAdmins, admin, true},
+		{"admins policy user", config.TwoFactorRequiredAdmins, user, false},
+		{"all policy user", config.TwoFactorRequiredAll, user, true},
+		{"external user exempt", config.TwoFactorRequiredAll, external, false},
+		{"nil user", config.TwoFactorRequiredAll, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TwoFactorRequired(tt.policy, tt.user))
+		})
+	}
+}
+
+func TestRequireTwoFactorEnrollment(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		user     *models.User
+		passkeys bool
+		path     string
+		wantCode int
+	}{
+		{
+			name:     "policy off",
+			policy:   config.TwoFactorRequiredNone,
+			user:     &models.User{Role: models.UserRoleUser},
+			path:     "/account/sessions",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "not enrolled redirected",
+			policy:   config.TwoFactorRequiredAll,
+			user:     &models.User{Role: models.UserRoleUser},
+			path:     "/account/sessions",
+			wantCode: http.StatusFound,
+		},
+		{
+			name:     "enrollment page reachable",
+			policy:   config.TwoFactorRequiredAll,
+			user:     &models.User{Role: models.UserRoleUser},
+			path:     "/account/security/totp/setup",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "enrolled user passes",
+			policy:   config.TwoFactorRequiredAll,
+			user:     &models.User{Role: models.UserRoleUser, TOTPEnabled: true},
+			path:     "/account/sessions",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "passkey satisfies policy",
+			policy:   config.TwoFactorRequiredAll,
+			user:     &models.User{Role: models.UserRoleUser, WebAuthnEnabled: true},
+			passkeys: true,
+			path:     "/account/sessions",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "passkey ignored while passkeys are disabled",
+			policy:   config.TwoFactorRequiredAll,
+			user:     &models.User{Role: models.UserRoleUser, WebAuthnEnabled: true},
+			path:     "/account/sessions",
+			wantCode: http.StatusFound,
+		},
+		{
+			name:     "admins policy ignores regular users",
+			policy:   config.TwoFactorRequiredAdmins,
+			user:     &models.User{Role: models.UserRoleUser},
+			path:     "/account/sessions",
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupTestRouter()
+			r.Use(func(c *gin.Context) {
+				c.Set("user", tt.user)
+				c.Next()
+			})
+			r.Use(RequireTwoFactorEnrollment(tt.policy, tt.passkeys))
+			r.GET("/*path", func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusFound {
+				assert.Equal(t, TwoFactorEnrollmentPath, w.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -261,6 +261,577 @@ func (mr *MockUserWriterMockRecorder) UpsertExternalUser(username, externalID, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertExternalUser", reflect.TypeOf((*MockUserWriter)(nil).UpsertExternalUser), username, externalID, authSource, email, fullName)
 }
 
+// MockTwoFactorStore is a mock of TwoFactorStore interface.
+type MockTwoFactorStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockTwoFactorStoreMockRecorder is the mock recorder for MockTwoFactorStore.
+type MockTwoFactorStoreMockRecorder struct {
+	mock *MockTwoFactorStore
+}
+
+// NewMockTwoFactorStore creates a new mock instance.
+func NewMockTwoFactorStore(ctrl *gomock.Controller) *MockTwoFactorStore {
+	mock := &MockTwoFactorStore{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorStore) EXPECT() *MockTwoFactorStoreMockRecorder {
+	return m.recorder
+}
+
+// ConsumeRecoveryCode mocks base method.
+func (m *MockTwoFactorStore) ConsumeRecoveryCode(userID, codeHash string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", userID, codeHash, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeRecoveryCode indicates an expected call of ConsumeRecoveryCode.
+func (mr *MockTwoFactorStoreMockRecorder) ConsumeRecoveryCode(userID, codeHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*MockTwoFactorStore)(nil).ConsumeRecoveryCode), userID, codeHash, now)
+}
+
+// ConsumeTOTPStep mocks base method.
+func (m *MockTwoFactorStore) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeTOTPStep", userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeTOTPStep indicates an expected call of ConsumeTOTPStep.
+func (mr *MockTwoFactorStoreMockRecorder) ConsumeTOTPStep(userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeTOTPStep", reflect.TypeOf((*MockTwoFactorStore)(nil).ConsumeTOTPStep), userID, step)
+}
+
+// CountUnusedRecoveryCodes mocks base method.
+func (m *MockTwoFactorStore) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnusedRecoveryCodes", userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnusedRecoveryCodes indicates an expected call of CountUnusedRecoveryCodes.
+func (mr *MockTwoFactorStoreMockRecorder) CountUnusedRecoveryCodes(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnusedRecoveryCodes", reflect.TypeOf((*MockTwoFactorStore)(nil).CountUnusedRecoveryCodes), userID)
+}
+
+// DeleteRecoveryCodesByUserID mocks base method.
+func (m *MockTwoFactorStore) DeleteRecoveryCodesByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecoveryCodesByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecoveryCodesByUserID indicates an expected call of DeleteRecoveryCodesByUserID.
+func (mr *MockTwoFactorStoreMockRecorder) DeleteRecoveryCodesByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryCodesByUserID", reflect.TypeOf((*MockTwoFactorStore)(nil).DeleteRecoveryCodesByUserID), userID)
+}
+
+// DisableUserTOTP mocks base method.
+func (m *MockTwoFactorStore) DisableUserTOTP(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUserTOTP", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUserTOTP indicates an expected call of DisableUserTOTP.
+func (mr *MockTwoFactorStoreMockRecorder) DisableUserTOTP(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUserTOTP", reflect.TypeOf((*MockTwoFactorStore)(nil).DisableUserTOTP), userID)
+}
+
+// EnableUserTOTP mocks base method.
+func (m *MockTwoFactorStore) EnableUserTOTP(userID string, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTOTP", userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockTwoFactorStoreMockRecorder) EnableUserTOTP(userID, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockTwoFactorStore)(nil).EnableUserTOTP), userID, step, codeHashes)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockTwoFactorStore) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockTwoFactorStoreMockRecorder) ReplaceRecoveryCodes(userID, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockTwoFactorStore)(nil).ReplaceRecoveryCodes), userID, codeHashes)
+}
+
+// SetUserTOTPSecret mocks base method.
+func (m *MockTwoFactorStore) SetUserTOTPSecret(userID, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTOTPSecret", userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTOTPSecret indicates an expected call of SetUserTOTPSecret.
+func (mr *MockTwoFactorStoreMockRecorder) SetUserTOTPSecret(userID, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTOTPSecret", reflect.TypeOf((*MockTwoFactorStore)(nil).SetUserTOTPSecret), userID, secret)
+}
+
+// MockWebAuthnStore is a mock of WebAuthnStore interface.
+type MockWebAuthnStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockWebAuthnStoreMockRecorder is the mock recorder for MockWebAuthnStore.
+type MockWebAuthnStoreMockRecorder struct {
+	mock *MockWebAuthnStore
+}
+
+// NewMockWebAuthnStore creates a new mock instance.
+func NewMockWebAuthnStore(ctrl *gomock.Controller) *MockWebAuthnStore {
+	mock := &MockWebAuthnStore{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnStore) EXPECT() *MockWebAuthnStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateWebAuthnCredential mocks base method.
+func (m *MockWebAuthnStore) CreateWebAuthnCredential(cred *models.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCredential", cred)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebAuthnCredential indicates an expected call of CreateWebAuthnCredential.
+func (mr *MockWebAuthnStoreMockRecorder) CreateWebAuthnCredential(cred any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCredential", reflect.TypeOf((*MockWebAuthnStore)(nil).CreateWebAuthnCredential), cred)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockWebAuthnStore) DeleteWebAuthnCredential(userID, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", userID, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockWebAuthnStoreMockRecorder) DeleteWebAuthnCredential(userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockWebAuthnStore)(nil).DeleteWebAuthnCredential), userID, id)
+}
+
+// DeleteWebAuthnCredentialsByUserID mocks base method.
+func (m *MockWebAuthnStore) DeleteWebAuthnCredentialsByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredentialsByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredentialsByUserID indicates an expected call of DeleteWebAuthnCredentialsByUserID.
+func (mr *MockWebAuthnStoreMockRecorder) DeleteWebAuthnCredentialsByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredentialsByUserID", reflect.TypeOf((*MockWebAuthnStore)(nil).DeleteWebAuthnCredentialsByUserID), userID)
+}
+
+// GetWebAuthnCredentialByCredentialID mocks base method.
+func (m *MockWebAuthnStore) GetWebAuthnCredentialByCredentialID(credentialID string) (*models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentialByCredentialID", credentialID)
+	ret0, _ := ret[0].(*models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentialByCredentialID indicates an expected call of GetWebAuthnCredentialByCredentialID.
+func (mr *MockWebAuthnStoreMockRecorder) GetWebAuthnCredentialByCredentialID(credentialID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentialByCredentialID", reflect.TypeOf((*MockWebAuthnStore)(nil).GetWebAuthnCredentialByCredentialID), credentialID)
+}
+
+// ListWebAuthnCredentials mocks base method.
+func (m *MockWebAuthnStore) ListWebAuthnCredentials(userID string) ([]models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebAuthnCredentials", userID)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebAuthnCredentials indicates an expected call of ListWebAuthnCredentials.
+func (mr *MockWebAuthnStoreMockRecorder) ListWebAuthnCredentials(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebAuthnCredentials", reflect.TypeOf((*MockWebAuthnStore)(nil).ListWebAuthnCredentials), userID)
+}
+
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockWebAuthnStore) UpdateWebAuthnSignCount(id string, oldCount, newCount int64, usedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", id, oldCount, newCount, usedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockWebAuthnStoreMockRecorder) UpdateWebAuthnSignCount(id, oldCount, newCount, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockWebAuthnStore)(nil).UpdateWebAuthnSignCount), id, oldCount, newCount, usedAt)
+}
+
+// MockPasswordResetStore is a mock of PasswordResetStore interface.
+type MockPasswordResetStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordResetStoreMockRecorder is the mock recorder for MockPasswordResetStore.
+type MockPasswordResetStoreMockRecorder struct {
+	mock *MockPasswordResetStore
+}
+
+// NewMockPasswordResetStore creates a new mock instance.
+func NewMockPasswordResetStore(ctrl *gomock.Controller) *MockPasswordResetStore {
+	mock := &MockPasswordResetStore{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetStore) EXPECT() *MockPasswordResetStoreMockRecorder {
+	return m.recorder
+}
+
+// ConsumePasswordResetToken mocks base method.
+func (m *MockPasswordResetStore) ConsumePasswordResetToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordResetToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordResetToken indicates an expected call of ConsumePasswordResetToken.
+func (mr *MockPasswordResetStoreMockRecorder) ConsumePasswordResetToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordResetToken", reflect.TypeOf((*MockPasswordResetStore)(nil).ConsumePasswordResetToken), id, now)
+}
+
+// CountPasswordResetTokensSince mocks base method.
+func (m *MockPasswordResetStore) CountPasswordResetTokensSince(userID string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPasswordResetTokensSince", userID, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPasswordResetTokensSince indicates an expected call of CountPasswordResetTokensSince.
+func (mr *MockPasswordResetStoreMockRecorder) CountPasswordResetTokensSince(userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPasswordResetTokensSince", reflect.TypeOf((*MockPasswordResetStore)(nil).CountPasswordResetTokensSince), userID, since)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockPasswordResetStore) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockPasswordResetStoreMockRecorder) CreatePasswordResetToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockPasswordResetStore)(nil).CreatePasswordResetToken), token)
+}
+
+// DeletePasswordResetTokensByUserID mocks base method.
+func (m *MockPasswordResetStore) DeletePasswordResetTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasswordResetTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasswordResetTokensByUserID indicates an expected call of DeletePasswordResetTokensByUserID.
+func (mr *MockPasswordResetStoreMockRecorder) DeletePasswordResetTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasswordResetTokensByUserID", reflect.TypeOf((*MockPasswordResetStore)(nil).DeletePasswordResetTokensByUserID), userID)
+}
+
+// GetPasswordResetTokenByHash mocks base method.
+func (m *MockPasswordResetStore) GetPasswordResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetTokenByHash indicates an expected call of GetPasswordResetTokenByHash.
+func (mr *MockPasswordResetStoreMockRecorder) GetPasswordResetTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetTokenByHash", reflect.TypeOf((*MockPasswordResetStore)(nil).GetPasswordResetTokenByHash), tokenHash)
+}
+
+// MockSignupStore is a mock of SignupStore interface.
+type MockSignupStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignupStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockSignupStoreMockRecorder is the mock recorder for MockSignupStore.
+type MockSignupStoreMockRecorder struct {
+	mock *MockSignupStore
+}
+
+// NewMockSignupStore creates a new mock instance.
+func NewMockSignupStore(ctrl *gomock.Controller) *MockSignupStore {
+	mock := &MockSignupStore{ctrl: ctrl}
+	mock.recorder = &MockSignupStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignupStore) EXPECT() *MockSignupStoreMockRecorder {
+	return m.recorder
+}
+
+// ConsumeEmailVerificationToken mocks base method.
+func (m *MockSignupStore) ConsumeEmailVerificationToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerificationToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerificationToken indicates an expected call of ConsumeEmailVerificationToken.
+func (mr *MockSignupStoreMockRecorder) ConsumeEmailVerificationToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerificationToken", reflect.TypeOf((*MockSignupStore)(nil).ConsumeEmailVerificationToken), id, now)
+}
+
+// CountUsersBySignupStatus mocks base method.
+func (m *MockSignupStore) CountUsersBySignupStatus(status string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsersBySignupStatus", status)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsersBySignupStatus indicates an expected call of CountUsersBySignupStatus.
+func (mr *MockSignupStoreMockRecorder) CountUsersBySignupStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersBySignupStatus", reflect.TypeOf((*MockSignupStore)(nil).CountUsersBySignupStatus), status)
+}
+
+// CreateSignupUser mocks base method.
+func (m *MockSignupStore) CreateSignupUser(user *models.User, token *models.EmailVerificationToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSignupUser", user, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSignupUser indicates an expected call of CreateSignupUser.
+func (mr *MockSignupStoreMockRecorder) CreateSignupUser(user, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSignupUser", reflect.TypeOf((*MockSignupStore)(nil).CreateSignupUser), user, token)
+}
+
+// DeleteEmailVerificationTokensByUserID mocks base method.
+func (m *MockSignupStore) DeleteEmailVerificationTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailVerificationTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEmailVerificationTokensByUserID indicates an expected call of DeleteEmailVerificationTokensByUserID.
+func (mr *MockSignupStoreMockRecorder) DeleteEmailVerificationTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailVerificationTokensByUserID", reflect.TypeOf((*MockSignupStore)(nil).DeleteEmailVerificationTokensByUserID), userID)
+}
+
+// DeleteUnverifiedSignup mocks base method.
+func (m *MockSignupStore) DeleteUnverifiedSignup(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnverifiedSignup", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnverifiedSignup indicates an expected call of DeleteUnverifiedSignup.
+func (mr *MockSignupStoreMockRecorder) DeleteUnverifiedSignup(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnverifiedSignup", reflect.TypeOf((*MockSignupStore)(nil).DeleteUnverifiedSignup), userID)
+}
+
+// GetEmailVerificationTokenByHash mocks base method.
+func (m *MockSignupStore) GetEmailVerificationTokenByHash(tokenHash string) (*models.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailVerificationTokenByHash indicates an expected call of GetEmailVerificationTokenByHash.
+func (mr *MockSignupStoreMockRecorder) GetEmailVerificationTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationTokenByHash", reflect.TypeOf((*MockSignupStore)(nil).GetEmailVerificationTokenByHash), tokenHash)
+}
+
+// ListUsersBySignupStatus mocks base method.
+func (m *MockSignupStore) ListUsersBySignupStatus(status string) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersBySignupStatus", status)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersBySignupStatus indicates an expected call of ListUsersBySignupStatus.
+func (mr *MockSignupStoreMockRecorder) ListUsersBySignupStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersBySignupStatus", reflect.TypeOf((*MockSignupStore)(nil).ListUsersBySignupStatus), status)
+}
+
+// MockEmailChangeStore is a mock of EmailChangeStore interface.
+type MockEmailChangeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailChangeStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailChangeStoreMockRecorder is the mock recorder for MockEmailChangeStore.
+type MockEmailChangeStoreMockRecorder struct {
+	mock *MockEmailChangeStore
+}
+
+// NewMockEmailChangeStore creates a new mock instance.
+func NewMockEmailChangeStore(ctrl *gomock.Controller) *MockEmailChangeStore {
+	mock := &MockEmailChangeStore{ctrl: ctrl}
+	mock.recorder = &MockEmailChangeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailChangeStore) EXPECT() *MockEmailChangeStoreMockRecorder {
+	return m.recorder
+}
+
+// ConsumeEmailChangeToken mocks base method.
+func (m *MockEmailChangeStore) ConsumeEmailChangeToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailChangeToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailChangeToken indicates an expected call of ConsumeEmailChangeToken.
+func (mr *MockEmailChangeStoreMockRecorder) ConsumeEmailChangeToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailChangeToken", reflect.TypeOf((*MockEmailChangeStore)(nil).ConsumeEmailChangeToken), id, now)
+}
+
+// CountEmailChangeTokensSince mocks base method.
+func (m *MockEmailChangeStore) CountEmailChangeTokensSince(userID string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountEmailChangeTokensSince", userID, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountEmailChangeTokensSince indicates an expected call of CountEmailChangeTokensSince.
+func (mr *MockEmailChangeStoreMockRecorder) CountEmailChangeTokensSince(userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountEmailChangeTokensSince", reflect.TypeOf((*MockEmailChangeStore)(nil).CountEmailChangeTokensSince), userID, since)
+}
+
+// CreateEmailChangeToken mocks base method.
+func (m *MockEmailChangeStore) CreateEmailChangeToken(token *models.EmailChangeToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailChangeToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailChangeToken indicates an expected call of CreateEmailChangeToken.
+func (mr *MockEmailChangeStoreMockRecorder) CreateEmailChangeToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailChangeToken", reflect.TypeOf((*MockEmailChangeStore)(nil).CreateEmailChangeToken), token)
+}
+
+// DeleteEmailChangeTokensByUserID mocks base method.
+func (m *MockEmailChangeStore) DeleteEmailChangeTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailChangeTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEmailChangeTokensByUserID indicates an expected call of DeleteEmailChangeTokensByUserID.
+func (mr *MockEmailChangeStoreMockRecorder) DeleteEmailChangeTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailChangeTokensByUserID", reflect.TypeOf((*MockEmailChangeStore)(nil).DeleteEmailChangeTokensByUserID), userID)
+}
+
+// GetEmailChangeTokenByHash mocks base method.
+func (m *MockEmailChangeStore) GetEmailChangeTokenByHash(tokenHash string) (*models.EmailChangeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailChangeTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.EmailChangeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailChangeTokenByHash indicates an expected call of GetEmailChangeTokenByHash.
+func (mr *MockEmailChangeStoreMockRecorder) GetEmailChangeTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailChangeTokenByHash", reflect.TypeOf((*MockEmailChangeStore)(nil).GetEmailChangeTokenByHash), tokenHash)
+}
+
 // MockClientReader is a mock of ClientReader interface.
 type MockClientReader struct {
 	ctrl     *gomock.Controller
@@ -554,6 +1125,20 @@ func (mr *MockDeviceCodeStoreMockRecorder) GetDeviceCodesByID(deviceCodeID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceCodesByID", reflect.TypeOf((*MockDeviceCodeStore)(nil).GetDeviceCodesByID), deviceCodeID)
 }
 
+// RecordDeviceCodePoll mocks base method.
+func (m *MockDeviceCodeStore) RecordDeviceCodePoll(id int64, polledAt time.Time, interval int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordDeviceCodePoll", id, polledAt, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordDeviceCodePoll indicates an expected call of RecordDeviceCodePoll.
+func (mr *MockDeviceCodeStoreMockRecorder) RecordDeviceCodePoll(id, polledAt, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordDeviceCodePoll", reflect.TypeOf((*MockDeviceCodeStore)(nil).RecordDeviceCodePoll), id, polledAt, interval)
+}
+
 // UpdateDeviceCode mocks base method.
 func (m *MockDeviceCodeStore) UpdateDeviceCode(dc *models.DeviceCode) error {
 	m.ctrl.T.Helper()
@@ -592,6 +1177,21 @@ func (m *MockTokenReader) EXPECT() *MockTokenReaderMockRecorder {
 	return m.recorder
 }
 
+// CountTokensByFilter mocks base method.
+func (m *MockTokenReader) CountTokensByFilter(filter types.TokenFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTokensByFilter", filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTokensByFilter indicates an expected call of CountTokensByFilter.
+func (mr *MockTokenReaderMockRecorder) CountTokensByFilter(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTokensByFilter", reflect.TypeOf((*MockTokenReader)(nil).CountTokensByFilter), filter)
+}
+
 // GetAccessTokenByHash mocks base method.
 func (m *MockTokenReader) GetAccessTokenByHash(hash string) (*models.AccessToken, error) {
 	m.ctrl.T.Helper()
@@ -622,6 +1222,21 @@ func (mr *MockTokenReaderMockRecorder) GetAccessTokenByID(tokenID any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenByID", reflect.TypeOf((*MockTokenReader)(nil).GetAccessTokenByID), tokenID)
 }
 
+// GetActiveRefreshTokens mocks base method.
+func (m *MockTokenReader) GetActiveRefreshTokens(userID, clientID string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveRefreshTokens", userID, clientID)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveRefreshTokens indicates an expected call of GetActiveRefreshTokens.
+func (mr *MockTokenReaderMockRecorder) GetActiveRefreshTokens(userID, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveRefreshTokens", reflect.TypeOf((*MockTokenReader)(nil).GetActiveRefreshTokens), userID, clientID)
+}
+
 // GetActiveTokenHashesByAuthorizationID mocks base method.
 func (m *MockTokenReader) GetActiveTokenHashesByAuthorizationID(authorizationID uint) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -667,6 +1282,21 @@ func (mr *MockTokenReaderMockRecorder) GetActiveTokenHashesByFamilyID(familyID a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveTokenHashesByFamilyID", reflect.TypeOf((*MockTokenReader)(nil).GetActiveTokenHashesByFamilyID), familyID)
 }
 
+// GetTokenFamilyTokens mocks base method.
+func (m *MockTokenReader) GetTokenFamilyTokens(rootID string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenFamilyTokens", rootID)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenFamilyTokens indicates an expected call of GetTokenFamilyTokens.
+func (mr *MockTokenReaderMockRecorder) GetTokenFamilyTokens(rootID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenFamilyTokens", reflect.TypeOf((*MockTokenReader)(nil).GetTokenFamilyTokens), rootID)
+}
+
 // GetTokenHashesByUserID mocks base method.
 func (m *MockTokenReader) GetTokenHashesByUserID(userID string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -744,6 +1374,22 @@ func (mr *MockTokenReaderMockRecorder) GetTokensPaginated(params any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokensPaginated", reflect.TypeOf((*MockTokenReader)(nil).GetTokensPaginated), params)
 }
 
+// SearchTokensPaginated mocks base method.
+func (m *MockTokenReader) SearchTokensPaginated(params types.PaginationParams, filter types.TokenFilter) ([]models.AccessToken, types.PaginationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTokensPaginated", params, filter)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(types.PaginationResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchTokensPaginated indicates an expected call of SearchTokensPaginated.
+func (mr *MockTokenReaderMockRecorder) SearchTokensPaginated(params, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTokensPaginated", reflect.TypeOf((*MockTokenReader)(nil).SearchTokensPaginated), params, filter)
+}
+
 // MockTokenWriter is a mock of TokenWriter interface.
 type MockTokenWriter struct {
 	ctrl     *gomock.Controller
@@ -782,6 +1428,20 @@ func (mr *MockTokenWriterMockRecorder) CreateAccessToken(token any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockTokenWriter)(nil).CreateAccessToken), token)
 }
 
+// LockUserTokenGrants mocks base method.
+func (m *MockTokenWriter) LockUserTokenGrants(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUserTokenGrants", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockUserTokenGrants indicates an expected call of LockUserTokenGrants.
+func (mr *MockTokenWriterMockRecorder) LockUserTokenGrants(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUserTokenGrants", reflect.TypeOf((*MockTokenWriter)(nil).LockUserTokenGrants), userID)
+}
+
 // RevokeAllActiveTokensByClientID mocks base method.
 func (m *MockTokenWriter) RevokeAllActiveTokensByClientID(clientID string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -854,18 +1514,32 @@ func (mr *MockTokenWriterMockRecorder) RevokeTokensByClientID(clientID any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokensByClientID", reflect.TypeOf((*MockTokenWriter)(nil).RevokeTokensByClientID), clientID)
 }
 
-// RevokeTokensByUserID mocks base method.
-func (m *MockTokenWriter) RevokeTokensByUserID(userID string) error {
+// RevokeTokensByUserID mocks base method.
+func (m *MockTokenWriter) RevokeTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeTokensByUserID indicates an expected call of RevokeTokensByUserID.
+func (mr *MockTokenWriterMockRecorder) RevokeTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokensByUserID", reflect.TypeOf((*MockTokenWriter)(nil).RevokeTokensByUserID), userID)
+}
+
+// UpdateTokenExpiresAt mocks base method.
+func (m *MockTokenWriter) UpdateTokenExpiresAt(tokenID string, t time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RevokeTokensByUserID", userID)
+	ret := m.ctrl.Call(m, "UpdateTokenExpiresAt", tokenID, t)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RevokeTokensByUserID indicates an expected call of RevokeTokensByUserID.
-func (mr *MockTokenWriterMockRecorder) RevokeTokensByUserID(userID any) *gomock.Call {
+// UpdateTokenExpiresAt indicates an expected call of UpdateTokenExpiresAt.
+func (mr *MockTokenWriterMockRecorder) UpdateTokenExpiresAt(tokenID, t any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokensByUserID", reflect.TypeOf((*MockTokenWriter)(nil).RevokeTokensByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenExpiresAt", reflect.TypeOf((*MockTokenWriter)(nil).UpdateTokenExpiresAt), tokenID, t)
 }
 
 // UpdateTokenLastUsedAt mocks base method.
@@ -896,6 +1570,21 @@ func (mr *MockTokenWriterMockRecorder) UpdateTokenStatus(tokenID, status any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenStatus", reflect.TypeOf((*MockTokenWriter)(nil).UpdateTokenStatus), tokenID, status)
 }
 
+// UpdateTokenStatusByFilter mocks base method.
+func (m *MockTokenWriter) UpdateTokenStatusByFilter(filter types.TokenFilter, fromStatuses []string, newStatus string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokenStatusByFilter", filter, fromStatuses, newStatus)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTokenStatusByFilter indicates an expected call of UpdateTokenStatusByFilter.
+func (mr *MockTokenWriterMockRecorder) UpdateTokenStatusByFilter(filter, fromStatuses, newStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenStatusByFilter", reflect.TypeOf((*MockTokenWriter)(nil).UpdateTokenStatusByFilter), filter, fromStatuses, newStatus)
+}
+
 // MockAuthorizationCodeStore is a mock of AuthorizationCodeStore interface.
 type MockAuthorizationCodeStore struct {
 	ctrl     *gomock.Controller
@@ -987,6 +1676,21 @@ func (m *MockUserAuthorizationStore) EXPECT() *MockUserAuthorizationStoreMockRec
 	return m.recorder
 }
 
+// ExpireUserAuthorization mocks base method.
+func (m *MockUserAuthorizationStore) ExpireUserAuthorization(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireUserAuthorization", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireUserAuthorization indicates an expected call of ExpireUserAuthorization.
+func (mr *MockUserAuthorizationStoreMockRecorder) ExpireUserAuthorization(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireUserAuthorization", reflect.TypeOf((*MockUserAuthorizationStore)(nil).ExpireUserAuthorization), id, now)
+}
+
 // GetClientAuthorizations mocks base method.
 func (m *MockUserAuthorizationStore) GetClientAuthorizations(clientID string) ([]models.UserAuthorization, error) {
 	m.ctrl.T.Helper()
@@ -1017,6 +1721,21 @@ func (mr *MockUserAuthorizationStoreMockRecorder) GetUserAuthorization(userID, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuthorization", reflect.TypeOf((*MockUserAuthorizationStore)(nil).GetUserAuthorization), userID, applicationID)
 }
 
+// GetUserAuthorizationByID mocks base method.
+func (m *MockUserAuthorizationStore) GetUserAuthorizationByID(id uint) (*models.UserAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAuthorizationByID", id)
+	ret0, _ := ret[0].(*models.UserAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAuthorizationByID indicates an expected call of GetUserAuthorizationByID.
+func (mr *MockUserAuthorizationStoreMockRecorder) GetUserAuthorizationByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuthorizationByID", reflect.TypeOf((*MockUserAuthorizationStore)(nil).GetUserAuthorizationByID), id)
+}
+
 // GetUserAuthorizationByUUID mocks base method.
 func (m *MockUserAuthorizationStore) GetUserAuthorizationByUUID(authUUID, userID string) (*models.UserAuthorization, error) {
 	m.ctrl.T.Helper()
@@ -1032,6 +1751,21 @@ func (mr *MockUserAuthorizationStoreMockRecorder) GetUserAuthorizationByUUID(aut
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuthorizationByUUID", reflect.TypeOf((*MockUserAuthorizationStore)(nil).GetUserAuthorizationByUUID), authUUID, userID)
 }
 
+// ListExpiredUserAuthorizations mocks base method.
+func (m *MockUserAuthorizationStore) ListExpiredUserAuthorizations(now time.Time, limit int) ([]models.UserAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredUserAuthorizations", now, limit)
+	ret0, _ := ret[0].([]models.UserAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredUserAuthorizations indicates an expected call of ListExpiredUserAuthorizations.
+func (mr *MockUserAuthorizationStoreMockRecorder) ListExpiredUserAuthorizations(now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredUserAuthorizations", reflect.TypeOf((*MockUserAuthorizationStore)(nil).ListExpiredUserAuthorizations), now, limit)
+}
+
 // ListUserAuthorizations mocks base method.
 func (m *MockUserAuthorizationStore) ListUserAuthorizations(userID string) ([]models.UserAuthorization, error) {
 	m.ctrl.T.Helper()
@@ -1342,6 +2076,21 @@ func (mr *MockAuditStoreMockRecorder) GetAuditLogsPaginated(params, filters any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogsPaginated", reflect.TypeOf((*MockAuditStore)(nil).GetAuditLogsPaginated), params, filters)
 }
 
+// GetTokenAuditLogs mocks base method.
+func (m *MockAuditStore) GetTokenAuditLogs(resourceIDs, mentions []string, limit int) ([]models.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenAuditLogs", resourceIDs, mentions, limit)
+	ret0, _ := ret[0].([]models.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenAuditLogs indicates an expected call of GetTokenAuditLogs.
+func (mr *MockAuditStoreMockRecorder) GetTokenAuditLogs(resourceIDs, mentions, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenAuditLogs", reflect.TypeOf((*MockAuditStore)(nil).GetTokenAuditLogs), resourceIDs, mentions, limit)
+}
+
 // MockDashboardStore is a mock of DashboardStore interface.
 type MockDashboardStore struct {
 	ctrl     *gomock.Controller
@@ -1419,6 +2168,49 @@ func (mr *MockCleanupStoreMockRecorder) DeleteExpiredDeviceCodes() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDeviceCodes", reflect.TypeOf((*MockCleanupStore)(nil).DeleteExpiredDeviceCodes))
 }
 
+// DeleteExpiredEmailChangeTokens mocks base method.
+func (m *MockCleanupStore) DeleteExpiredEmailChangeTokens() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredEmailChangeTokens")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredEmailChangeTokens indicates an expected call of DeleteExpiredEmailChangeTokens.
+func (mr *MockCleanupStoreMockRecorder) DeleteExpiredEmailChangeTokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredEmailChangeTokens", reflect.TypeOf((*MockCleanupStore)(nil).DeleteExpiredEmailChangeTokens))
+}
+
+// DeleteExpiredPasswordResetTokens mocks base method.
+func (m *MockCleanupStore) DeleteExpiredPasswordResetTokens() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredPasswordResetTokens")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredPasswordResetTokens indicates an expected call of DeleteExpiredPasswordResetTokens.
+func (mr *MockCleanupStoreMockRecorder) DeleteExpiredPasswordResetTokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredPasswordResetTokens", reflect.TypeOf((*MockCleanupStore)(nil).DeleteExpiredPasswordResetTokens))
+}
+
+// DeleteExpiredSignups mocks base method.
+func (m *MockCleanupStore) DeleteExpiredSignups(createdBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSignups", createdBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSignups indicates an expected call of DeleteExpiredSignups.
+func (mr *MockCleanupStoreMockRecorder) DeleteExpiredSignups(createdBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSignups", reflect.TypeOf((*MockCleanupStore)(nil).DeleteExpiredSignups), createdBefore)
+}
+
 // DeleteExpiredTokens mocks base method.
 func (m *MockCleanupStore) DeleteExpiredTokens() error {
 	m.ctrl.T.Helper()
@@ -1433,6 +2225,21 @@ func (mr *MockCleanupStoreMockRecorder) DeleteExpiredTokens() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredTokens", reflect.TypeOf((*MockCleanupStore)(nil).DeleteExpiredTokens))
 }
 
+// DeleteIdleRefreshTokens mocks base method.
+func (m *MockCleanupStore) DeleteIdleRefreshTokens(idleTimeouts map[string]time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdleRefreshTokens", idleTimeouts)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdleRefreshTokens indicates an expected call of DeleteIdleRefreshTokens.
+func (mr *MockCleanupStoreMockRecorder) DeleteIdleRefreshTokens(idleTimeouts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdleRefreshTokens", reflect.TypeOf((*MockCleanupStore)(nil).DeleteIdleRefreshTokens), idleTimeouts)
+}
+
 // MockTransactor is a mock of Transactor interface.
 type MockTransactor struct {
 	ctrl     *gomock.Controller
@@ -1575,6 +2382,81 @@ func (mr *MockStoreMockRecorder) Close(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStore)(nil).Close), ctx)
 }
 
+// ConsumeEmailChangeToken mocks base method.
+func (m *MockStore) ConsumeEmailChangeToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailChangeToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailChangeToken indicates an expected call of ConsumeEmailChangeToken.
+func (mr *MockStoreMockRecorder) ConsumeEmailChangeToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailChangeToken", reflect.TypeOf((*MockStore)(nil).ConsumeEmailChangeToken), id, now)
+}
+
+// ConsumeEmailVerificationToken mocks base method.
+func (m *MockStore) ConsumeEmailVerificationToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerificationToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerificationToken indicates an expected call of ConsumeEmailVerificationToken.
+func (mr *MockStoreMockRecorder) ConsumeEmailVerificationToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerificationToken", reflect.TypeOf((*MockStore)(nil).ConsumeEmailVerificationToken), id, now)
+}
+
+// ConsumePasswordResetToken mocks base method.
+func (m *MockStore) ConsumePasswordResetToken(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordResetToken", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordResetToken indicates an expected call of ConsumePasswordResetToken.
+func (mr *MockStoreMockRecorder) ConsumePasswordResetToken(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordResetToken", reflect.TypeOf((*MockStore)(nil).ConsumePasswordResetToken), id, now)
+}
+
+// ConsumeRecoveryCode mocks base method.
+func (m *MockStore) ConsumeRecoveryCode(userID, codeHash string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", userID, codeHash, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeRecoveryCode indicates an expected call of ConsumeRecoveryCode.
+func (mr *MockStoreMockRecorder) ConsumeRecoveryCode(userID, codeHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*MockStore)(nil).ConsumeRecoveryCode), userID, codeHash, now)
+}
+
+// ConsumeTOTPStep mocks base method.
+func (m *MockStore) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeTOTPStep", userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeTOTPStep indicates an expected call of ConsumeTOTPStep.
+func (mr *MockStoreMockRecorder) ConsumeTOTPStep(userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeTOTPStep", reflect.TypeOf((*MockStore)(nil).ConsumeTOTPStep), userID, step)
+}
+
 // CountActiveTokensByCategory mocks base method.
 func (m *MockStore) CountActiveTokensByCategory(category string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -1620,6 +2502,36 @@ func (mr *MockStoreMockRecorder) CountClientsByStatus(status any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountClientsByStatus", reflect.TypeOf((*MockStore)(nil).CountClientsByStatus), status)
 }
 
+// CountEmailChangeTokensSince mocks base method.
+func (m *MockStore) CountEmailChangeTokensSince(userID string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountEmailChangeTokensSince", userID, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountEmailChangeTokensSince indicates an expected call of CountEmailChangeTokensSince.
+func (mr *MockStoreMockRecorder) CountEmailChangeTokensSince(userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountEmailChangeTokensSince", reflect.TypeOf((*MockStore)(nil).CountEmailChangeTokensSince), userID, since)
+}
+
+// CountPasswordResetTokensSince mocks base method.
+func (m *MockStore) CountPasswordResetTokensSince(userID string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPasswordResetTokensSince", userID, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPasswordResetTokensSince indicates an expected call of CountPasswordResetTokensSince.
+func (mr *MockStoreMockRecorder) CountPasswordResetTokensSince(userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPasswordResetTokensSince", reflect.TypeOf((*MockStore)(nil).CountPasswordResetTokensSince), userID, since)
+}
+
 // CountPendingDeviceCodes mocks base method.
 func (m *MockStore) CountPendingDeviceCodes() (int64, error) {
 	m.ctrl.T.Helper()
@@ -1635,6 +2547,21 @@ func (mr *MockStoreMockRecorder) CountPendingDeviceCodes() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPendingDeviceCodes", reflect.TypeOf((*MockStore)(nil).CountPendingDeviceCodes))
 }
 
+// CountTokensByFilter mocks base method.
+func (m *MockStore) CountTokensByFilter(filter types.TokenFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTokensByFilter", filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTokensByFilter indicates an expected call of CountTokensByFilter.
+func (mr *MockStoreMockRecorder) CountTokensByFilter(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTokensByFilter", reflect.TypeOf((*MockStore)(nil).CountTokensByFilter), filter)
+}
+
 // CountTotalDeviceCodes mocks base method.
 func (m *MockStore) CountTotalDeviceCodes() (int64, error) {
 	m.ctrl.T.Helper()
@@ -1650,6 +2577,21 @@ func (mr *MockStoreMockRecorder) CountTotalDeviceCodes() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalDeviceCodes", reflect.TypeOf((*MockStore)(nil).CountTotalDeviceCodes))
 }
 
+// CountUnusedRecoveryCodes mocks base method.
+func (m *MockStore) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnusedRecoveryCodes", userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnusedRecoveryCodes indicates an expected call of CountUnusedRecoveryCodes.
+func (mr *MockStoreMockRecorder) CountUnusedRecoveryCodes(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnusedRecoveryCodes", reflect.TypeOf((*MockStore)(nil).CountUnusedRecoveryCodes), userID)
+}
+
 // CountUsersByRole mocks base method.
 func (m *MockStore) CountUsersByRole(role string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -1665,6 +2607,21 @@ func (mr *MockStoreMockRecorder) CountUsersByRole(role any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersByRole", reflect.TypeOf((*MockStore)(nil).CountUsersByRole), role)
 }
 
+// CountUsersBySignupStatus mocks base method.
+func (m *MockStore) CountUsersBySignupStatus(status string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsersBySignupStatus", status)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsersBySignupStatus indicates an expected call of CountUsersBySignupStatus.
+func (mr *MockStoreMockRecorder) CountUsersBySignupStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersBySignupStatus", reflect.TypeOf((*MockStore)(nil).CountUsersBySignupStatus), status)
+}
+
 // CreateAccessToken mocks base method.
 func (m *MockStore) CreateAccessToken(token *models.AccessToken) error {
 	m.ctrl.T.Helper()
@@ -1749,6 +2706,20 @@ func (mr *MockStoreMockRecorder) CreateDeviceCode(dc any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeviceCode", reflect.TypeOf((*MockStore)(nil).CreateDeviceCode), dc)
 }
 
+// CreateEmailChangeToken mocks base method.
+func (m *MockStore) CreateEmailChangeToken(token *models.EmailChangeToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailChangeToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailChangeToken indicates an expected call of CreateEmailChangeToken.
+func (mr *MockStoreMockRecorder) CreateEmailChangeToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailChangeToken", reflect.TypeOf((*MockStore)(nil).CreateEmailChangeToken), token)
+}
+
 // CreateOAuthConnection mocks base method.
 func (m *MockStore) CreateOAuthConnection(conn *models.OAuthConnection) error {
 	m.ctrl.T.Helper()
@@ -1763,6 +2734,34 @@ func (mr *MockStoreMockRecorder) CreateOAuthConnection(conn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOAuthConnection", reflect.TypeOf((*MockStore)(nil).CreateOAuthConnection), conn)
 }
 
+// CreatePasswordResetToken mocks base method.
+func (m *MockStore) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockStoreMockRecorder) CreatePasswordResetToken(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockStore)(nil).CreatePasswordResetToken), token)
+}
+
+// CreateSignupUser mocks base method.
+func (m *MockStore) CreateSignupUser(user *models.User, token *models.EmailVerificationToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSignupUser", user, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSignupUser indicates an expected call of CreateSignupUser.
+func (mr *MockStoreMockRecorder) CreateSignupUser(user, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSignupUser", reflect.TypeOf((*MockStore)(nil).CreateSignupUser), user, token)
+}
+
 // CreateUser mocks base method.
 func (m *MockStore) CreateUser(user *models.User) error {
 	m.ctrl.T.Helper()
@@ -1777,46 +2776,131 @@ func (mr *MockStoreMockRecorder) CreateUser(user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), user)
 }
 
+// CreateWebAuthnCredential mocks base method.
+func (m *MockStore) CreateWebAuthnCredential(cred *models.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCredential", cred)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebAuthnCredential indicates an expected call of CreateWebAuthnCredential.
+func (mr *MockStoreMockRecorder) CreateWebAuthnCredential(cred any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCredential", reflect.TypeOf((*MockStore)(nil).CreateWebAuthnCredential), cred)
+}
+
 // DeleteClient mocks base method.
 func (m *MockStore) DeleteClient(clientID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteClient", clientID)
+	ret := m.ctrl.Call(m, "DeleteClient", clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockStoreMockRecorder) DeleteClient(clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockStore)(nil).DeleteClient), clientID)
+}
+
+// DeleteDeviceCodeByID mocks base method.
+func (m *MockStore) DeleteDeviceCodeByID(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceCodeByID", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceCodeByID indicates an expected call of DeleteDeviceCodeByID.
+func (mr *MockStoreMockRecorder) DeleteDeviceCodeByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceCodeByID", reflect.TypeOf((*MockStore)(nil).DeleteDeviceCodeByID), id)
+}
+
+// DeleteEmailChangeTokensByUserID mocks base method.
+func (m *MockStore) DeleteEmailChangeTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailChangeTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEmailChangeTokensByUserID indicates an expected call of DeleteEmailChangeTokensByUserID.
+func (mr *MockStoreMockRecorder) DeleteEmailChangeTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailChangeTokensByUserID", reflect.TypeOf((*MockStore)(nil).DeleteEmailChangeTokensByUserID), userID)
+}
+
+// DeleteEmailVerificationTokensByUserID mocks base method.
+func (m *MockStore) DeleteEmailVerificationTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailVerificationTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEmailVerificationTokensByUserID indicates an expected call of DeleteEmailVerificationTokensByUserID.
+func (mr *MockStoreMockRecorder) DeleteEmailVerificationTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailVerificationTokensByUserID", reflect.TypeOf((*MockStore)(nil).DeleteEmailVerificationTokensByUserID), userID)
+}
+
+// DeleteExpiredDeviceCodes mocks base method.
+func (m *MockStore) DeleteExpiredDeviceCodes() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDeviceCodes")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredDeviceCodes indicates an expected call of DeleteExpiredDeviceCodes.
+func (mr *MockStoreMockRecorder) DeleteExpiredDeviceCodes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDeviceCodes", reflect.TypeOf((*MockStore)(nil).DeleteExpiredDeviceCodes))
+}
+
+// DeleteExpiredEmailChangeTokens mocks base method.
+func (m *MockStore) DeleteExpiredEmailChangeTokens() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredEmailChangeTokens")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteClient indicates an expected call of DeleteClient.
-func (mr *MockStoreMockRecorder) DeleteClient(clientID any) *gomock.Call {
+// DeleteExpiredEmailChangeTokens indicates an expected call of DeleteExpiredEmailChangeTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredEmailChangeTokens() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockStore)(nil).DeleteClient), clientID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredEmailChangeTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredEmailChangeTokens))
 }
 
-// DeleteDeviceCodeByID mocks base method.
-func (m *MockStore) DeleteDeviceCodeByID(id int64) error {
+// DeleteExpiredPasswordResetTokens mocks base method.
+func (m *MockStore) DeleteExpiredPasswordResetTokens() error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteDeviceCodeByID", id)
+	ret := m.ctrl.Call(m, "DeleteExpiredPasswordResetTokens")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteDeviceCodeByID indicates an expected call of DeleteDeviceCodeByID.
-func (mr *MockStoreMockRecorder) DeleteDeviceCodeByID(id any) *gomock.Call {
+// DeleteExpiredPasswordResetTokens indicates an expected call of DeleteExpiredPasswordResetTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredPasswordResetTokens() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceCodeByID", reflect.TypeOf((*MockStore)(nil).DeleteDeviceCodeByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredPasswordResetTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredPasswordResetTokens))
 }
 
-// DeleteExpiredDeviceCodes mocks base method.
-func (m *MockStore) DeleteExpiredDeviceCodes() error {
+// DeleteExpiredSignups mocks base method.
+func (m *MockStore) DeleteExpiredSignups(createdBefore time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteExpiredDeviceCodes")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpiredSignups", createdBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteExpiredDeviceCodes indicates an expected call of DeleteExpiredDeviceCodes.
-func (mr *MockStoreMockRecorder) DeleteExpiredDeviceCodes() *gomock.Call {
+// DeleteExpiredSignups indicates an expected call of DeleteExpiredSignups.
+func (mr *MockStoreMockRecorder) DeleteExpiredSignups(createdBefore any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDeviceCodes", reflect.TypeOf((*MockStore)(nil).DeleteExpiredDeviceCodes))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSignups", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSignups), createdBefore)
 }
 
 // DeleteExpiredTokens mocks base method.
@@ -1833,6 +2917,21 @@ func (mr *MockStoreMockRecorder) DeleteExpiredTokens() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredTokens))
 }
 
+// DeleteIdleRefreshTokens mocks base method.
+func (m *MockStore) DeleteIdleRefreshTokens(idleTimeouts map[string]time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdleRefreshTokens", idleTimeouts)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdleRefreshTokens indicates an expected call of DeleteIdleRefreshTokens.
+func (mr *MockStoreMockRecorder) DeleteIdleRefreshTokens(idleTimeouts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdleRefreshTokens", reflect.TypeOf((*MockStore)(nil).DeleteIdleRefreshTokens), idleTimeouts)
+}
+
 // DeleteOAuthConnection mocks base method.
 func (m *MockStore) DeleteOAuthConnection(id string) error {
 	m.ctrl.T.Helper()
@@ -1876,6 +2975,48 @@ func (mr *MockStoreMockRecorder) DeleteOldAuditLogs(olderThan any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldAuditLogs", reflect.TypeOf((*MockStore)(nil).DeleteOldAuditLogs), olderThan)
 }
 
+// DeletePasswordResetTokensByUserID mocks base method.
+func (m *MockStore) DeletePasswordResetTokensByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasswordResetTokensByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasswordResetTokensByUserID indicates an expected call of DeletePasswordResetTokensByUserID.
+func (mr *MockStoreMockRecorder) DeletePasswordResetTokensByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasswordResetTokensByUserID", reflect.TypeOf((*MockStore)(nil).DeletePasswordResetTokensByUserID), userID)
+}
+
+// DeleteRecoveryCodesByUserID mocks base method.
+func (m *MockStore) DeleteRecoveryCodesByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecoveryCodesByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecoveryCodesByUserID indicates an expected call of DeleteRecoveryCodesByUserID.
+func (mr *MockStoreMockRecorder) DeleteRecoveryCodesByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryCodesByUserID", reflect.TypeOf((*MockStore)(nil).DeleteRecoveryCodesByUserID), userID)
+}
+
+// DeleteUnverifiedSignup mocks base method.
+func (m *MockStore) DeleteUnverifiedSignup(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnverifiedSignup", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnverifiedSignup indicates an expected call of DeleteUnverifiedSignup.
+func (mr *MockStoreMockRecorder) DeleteUnverifiedSignup(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnverifiedSignup", reflect.TypeOf((*MockStore)(nil).DeleteUnverifiedSignup), userID)
+}
+
 // DeleteUser mocks base method.
 func (m *MockStore) DeleteUser(id string) error {
 	m.ctrl.T.Helper()
@@ -1890,6 +3031,78 @@ func (mr *MockStoreMockRecorder) DeleteUser(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), id)
 }
 
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockStore) DeleteWebAuthnCredential(userID, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", userID, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockStoreMockRecorder) DeleteWebAuthnCredential(userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockStore)(nil).DeleteWebAuthnCredential), userID, id)
+}
+
+// DeleteWebAuthnCredentialsByUserID mocks base method.
+func (m *MockStore) DeleteWebAuthnCredentialsByUserID(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredentialsByUserID", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredentialsByUserID indicates an expected call of DeleteWebAuthnCredentialsByUserID.
+func (mr *MockStoreMockRecorder) DeleteWebAuthnCredentialsByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredentialsByUserID", reflect.TypeOf((*MockStore)(nil).DeleteWebAuthnCredentialsByUserID), userID)
+}
+
+// DisableUserTOTP mocks base method.
+func (m *MockStore) DisableUserTOTP(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUserTOTP", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUserTOTP indicates an expected call of DisableUserTOTP.
+func (mr *MockStoreMockRecorder) DisableUserTOTP(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUserTOTP", reflect.TypeOf((*MockStore)(nil).DisableUserTOTP), userID)
+}
+
+// EnableUserTOTP mocks base method.
+func (m *MockStore) EnableUserTOTP(userID string, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTOTP", userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockStoreMockRecorder) EnableUserTOTP(userID, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockStore)(nil).EnableUserTOTP), userID, step, codeHashes)
+}
+
+// ExpireUserAuthorization mocks base method.
+func (m *MockStore) ExpireUserAuthorization(id uint, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireUserAuthorization", id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireUserAuthorization indicates an expected call of ExpireUserAuthorization.
+func (mr *MockStoreMockRecorder) ExpireUserAuthorization(id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireUserAuthorization", reflect.TypeOf((*MockStore)(nil).ExpireUserAuthorization), id, now)
+}
+
 // FindUserByNormalizedEmail mocks base method.
 func (m *MockStore) FindUserByNormalizedEmail(email string) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -1935,6 +3148,21 @@ func (mr *MockStoreMockRecorder) GetAccessTokenByID(tokenID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenByID", reflect.TypeOf((*MockStore)(nil).GetAccessTokenByID), tokenID)
 }
 
+// GetActiveRefreshTokens mocks base method.
+func (m *MockStore) GetActiveRefreshTokens(userID, clientID string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveRefreshTokens", userID, clientID)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveRefreshTokens indicates an expected call of GetActiveRefreshTokens.
+func (mr *MockStoreMockRecorder) GetActiveRefreshTokens(userID, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveRefreshTokens", reflect.TypeOf((*MockStore)(nil).GetActiveRefreshTokens), userID, clientID)
+}
+
 // GetActiveTokenHashesByAuthorizationID mocks base method.
 func (m *MockStore) GetActiveTokenHashesByAuthorizationID(authorizationID uint) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -2131,6 +3359,36 @@ func (mr *MockStoreMockRecorder) GetDeviceCodesByID(deviceCodeID any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceCodesByID", reflect.TypeOf((*MockStore)(nil).GetDeviceCodesByID), deviceCodeID)
 }
 
+// GetEmailChangeTokenByHash mocks base method.
+func (m *MockStore) GetEmailChangeTokenByHash(tokenHash string) (*models.EmailChangeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailChangeTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.EmailChangeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailChangeTokenByHash indicates an expected call of GetEmailChangeTokenByHash.
+func (mr *MockStoreMockRecorder) GetEmailChangeTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailChangeTokenByHash", reflect.TypeOf((*MockStore)(nil).GetEmailChangeTokenByHash), tokenHash)
+}
+
+// GetEmailVerificationTokenByHash mocks base method.
+func (m *MockStore) GetEmailVerificationTokenByHash(tokenHash string) (*models.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailVerificationTokenByHash indicates an expected call of GetEmailVerificationTokenByHash.
+func (mr *MockStoreMockRecorder) GetEmailVerificationTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationTokenByHash", reflect.TypeOf((*MockStore)(nil).GetEmailVerificationTokenByHash), tokenHash)
+}
+
 // GetOAuthConnection mocks base method.
 func (m *MockStore) GetOAuthConnection(provider, providerUserID string) (*models.OAuthConnection, error) {
 	m.ctrl.T.Helper()
@@ -2191,6 +3449,51 @@ func (mr *MockStoreMockRecorder) GetOAuthConnectionsByUserID(userID any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOAuthConnectionsByUserID", reflect.TypeOf((*MockStore)(nil).GetOAuthConnectionsByUserID), userID)
 }
 
+// GetPasswordResetTokenByHash mocks base method.
+func (m *MockStore) GetPasswordResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetTokenByHash", tokenHash)
+	ret0, _ := ret[0].(*models.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetTokenByHash indicates an expected call of GetPasswordResetTokenByHash.
+func (mr *MockStoreMockRecorder) GetPasswordResetTokenByHash(tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetTokenByHash", reflect.TypeOf((*MockStore)(nil).GetPasswordResetTokenByHash), tokenHash)
+}
+
+// GetTokenAuditLogs mocks base method.
+func (m *MockStore) GetTokenAuditLogs(resourceIDs, mentions []string, limit int) ([]models.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenAuditLogs", resourceIDs, mentions, limit)
+	ret0, _ := ret[0].([]models.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenAuditLogs indicates an expected call of GetTokenAuditLogs.
+func (mr *MockStoreMockRecorder) GetTokenAuditLogs(resourceIDs, mentions, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenAuditLogs", reflect.TypeOf((*MockStore)(nil).GetTokenAuditLogs), resourceIDs, mentions, limit)
+}
+
+// GetTokenFamilyTokens mocks base method.
+func (m *MockStore) GetTokenFamilyTokens(rootID string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenFamilyTokens", rootID)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenFamilyTokens indicates an expected call of GetTokenFamilyTokens.
+func (mr *MockStoreMockRecorder) GetTokenFamilyTokens(rootID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenFamilyTokens", reflect.TypeOf((*MockStore)(nil).GetTokenFamilyTokens), rootID)
+}
+
 // GetTokenHashesByUserID mocks base method.
 func (m *MockStore) GetTokenHashesByUserID(userID string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -2283,6 +3586,21 @@ func (mr *MockStoreMockRecorder) GetUserAuthorization(userID, applicationID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuthorization", reflect.TypeOf((*MockStore)(nil).GetUserAuthorization), userID, applicationID)
 }
 
+// GetUserAuthorizationByID mocks base method.
+func (m *MockStore) GetUserAuthorizationByID(id uint) (*models.UserAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAuthorizationByID", id)
+	ret0, _ := ret[0].(*models.UserAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAuthorizationByID indicates an expected call of GetUserAuthorizationByID.
+func (mr *MockStoreMockRecorder) GetUserAuthorizationByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAuthorizationByID", reflect.TypeOf((*MockStore)(nil).GetUserAuthorizationByID), id)
+}
+
 // GetUserAuthorizationByUUID mocks base method.
 func (m *MockStore) GetUserAuthorizationByUUID(authUUID, userID string) (*models.UserAuthorization, error) {
 	m.ctrl.T.Helper()
@@ -2388,6 +3706,21 @@ func (mr *MockStoreMockRecorder) GetUsersByIDs(userIDs any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByIDs", reflect.TypeOf((*MockStore)(nil).GetUsersByIDs), userIDs)
 }
 
+// GetWebAuthnCredentialByCredentialID mocks base method.
+func (m *MockStore) GetWebAuthnCredentialByCredentialID(credentialID string) (*models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentialByCredentialID", credentialID)
+	ret0, _ := ret[0].(*models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentialByCredentialID indicates an expected call of GetWebAuthnCredentialByCredentialID.
+func (mr *MockStoreMockRecorder) GetWebAuthnCredentialByCredentialID(credentialID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentialByCredentialID", reflect.TypeOf((*MockStore)(nil).GetWebAuthnCredentialByCredentialID), credentialID)
+}
+
 // Health mocks base method.
 func (m *MockStore) Health() error {
 	m.ctrl.T.Helper()
@@ -2434,6 +3767,21 @@ func (mr *MockStoreMockRecorder) ListClientsPaginated(params any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClientsPaginated", reflect.TypeOf((*MockStore)(nil).ListClientsPaginated), params)
 }
 
+// ListExpiredUserAuthorizations mocks base method.
+func (m *MockStore) ListExpiredUserAuthorizations(now time.Time, limit int) ([]models.UserAuthorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredUserAuthorizations", now, limit)
+	ret0, _ := ret[0].([]models.UserAuthorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredUserAuthorizations indicates an expected call of ListExpiredUserAuthorizations.
+func (mr *MockStoreMockRecorder) ListExpiredUserAuthorizations(now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredUserAuthorizations", reflect.TypeOf((*MockStore)(nil).ListExpiredUserAuthorizations), now, limit)
+}
+
 // ListUserAuthorizations mocks base method.
 func (m *MockStore) ListUserAuthorizations(userID string) ([]models.UserAuthorization, error) {
 	m.ctrl.T.Helper()
@@ -2449,6 +3797,21 @@ func (mr *MockStoreMockRecorder) ListUserAuthorizations(userID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserAuthorizations", reflect.TypeOf((*MockStore)(nil).ListUserAuthorizations), userID)
 }
 
+// ListUsersBySignupStatus mocks base method.
+func (m *MockStore) ListUsersBySignupStatus(status string) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersBySignupStatus", status)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersBySignupStatus indicates an expected call of ListUsersBySignupStatus.
+func (mr *MockStoreMockRecorder) ListUsersBySignupStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersBySignupStatus", reflect.TypeOf((*MockStore)(nil).ListUsersBySignupStatus), status)
+}
+
 // ListUsersPaginated mocks base method.
 func (m *MockStore) ListUsersPaginated(params types.PaginationParams) ([]models.User, types.PaginationResult, error) {
 	m.ctrl.T.Helper()
@@ -2465,6 +3828,35 @@ func (mr *MockStoreMockRecorder) ListUsersPaginated(params any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersPaginated", reflect.TypeOf((*MockStore)(nil).ListUsersPaginated), params)
 }
 
+// ListWebAuthnCredentials mocks base method.
+func (m *MockStore) ListWebAuthnCredentials(userID string) ([]models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebAuthnCredentials", userID)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebAuthnCredentials indicates an expected call of ListWebAuthnCredentials.
+func (mr *MockStoreMockRecorder) ListWebAuthnCredentials(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebAuthnCredentials", reflect.TypeOf((*MockStore)(nil).ListWebAuthnCredentials), userID)
+}
+
+// LockUserTokenGrants mocks base method.
+func (m *MockStore) LockUserTokenGrants(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUserTokenGrants", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockUserTokenGrants indicates an expected call of LockUserTokenGrants.
+func (mr *MockStoreMockRecorder) LockUserTokenGrants(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUserTokenGrants", reflect.TypeOf((*MockStore)(nil).LockUserTokenGrants), userID)
+}
+
 // MarkAuthorizationCodeUsed mocks base method.
 func (m *MockStore) MarkAuthorizationCodeUsed(id uint) error {
 	m.ctrl.T.Helper()
@@ -2479,6 +3871,34 @@ func (mr *MockStoreMockRecorder) MarkAuthorizationCodeUsed(id any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAuthorizationCodeUsed", reflect.TypeOf((*MockStore)(nil).MarkAuthorizationCodeUsed), id)
 }
 
+// RecordDeviceCodePoll mocks base method.
+func (m *MockStore) RecordDeviceCodePoll(id int64, polledAt time.Time, interval int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordDeviceCodePoll", id, polledAt, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordDeviceCodePoll indicates an expected call of RecordDeviceCodePoll.
+func (mr *MockStoreMockRecorder) RecordDeviceCodePoll(id, polledAt, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordDeviceCodePoll", reflect.TypeOf((*MockStore)(nil).RecordDeviceCodePoll), id, polledAt, interval)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockStore) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockStoreMockRecorder) ReplaceRecoveryCodes(userID, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockStore)(nil).ReplaceRecoveryCodes), userID, codeHashes)
+}
+
 // RevokeAllActiveTokensByClientID mocks base method.
 func (m *MockStore) RevokeAllActiveTokensByClientID(clientID string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -2622,6 +4042,36 @@ func (mr *MockStoreMockRecorder) RunInTransaction(fn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTransaction", reflect.TypeOf((*MockStore)(nil).RunInTransaction), fn)
 }
 
+// SearchTokensPaginated mocks base method.
+func (m *MockStore) SearchTokensPaginated(params types.PaginationParams, filter types.TokenFilter) ([]models.AccessToken, types.PaginationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTokensPaginated", params, filter)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(types.PaginationResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchTokensPaginated indicates an expected call of SearchTokensPaginated.
+func (mr *MockStoreMockRecorder) SearchTokensPaginated(params, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTokensPaginated", reflect.TypeOf((*MockStore)(nil).SearchTokensPaginated), params, filter)
+}
+
+// SetUserTOTPSecret mocks base method.
+func (m *MockStore) SetUserTOTPSecret(userID, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTOTPSecret", userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTOTPSecret indicates an expected call of SetUserTOTPSecret.
+func (mr *MockStoreMockRecorder) SetUserTOTPSecret(userID, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTOTPSecret", reflect.TypeOf((*MockStore)(nil).SetUserTOTPSecret), userID, secret)
+}
+
 // UpdateClient mocks base method.
 func (m *MockStore) UpdateClient(client *models.OAuthApplication) error {
 	m.ctrl.T.Helper()
@@ -2664,6 +4114,20 @@ func (mr *MockStoreMockRecorder) UpdateOAuthConnection(conn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOAuthConnection", reflect.TypeOf((*MockStore)(nil).UpdateOAuthConnection), conn)
 }
 
+// UpdateTokenExpiresAt mocks base method.
+func (m *MockStore) UpdateTokenExpiresAt(tokenID string, t time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokenExpiresAt", tokenID, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTokenExpiresAt indicates an expected call of UpdateTokenExpiresAt.
+func (mr *MockStoreMockRecorder) UpdateTokenExpiresAt(tokenID, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenExpiresAt", reflect.TypeOf((*MockStore)(nil).UpdateTokenExpiresAt), tokenID, t)
+}
+
 // UpdateTokenLastUsedAt mocks base method.
 func (m *MockStore) UpdateTokenLastUsedAt(tokenID string, t time.Time) error {
 	m.ctrl.T.Helper()
@@ -2692,6 +4156,21 @@ func (mr *MockStoreMockRecorder) UpdateTokenStatus(tokenID, status any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenStatus", reflect.TypeOf((*MockStore)(nil).UpdateTokenStatus), tokenID, status)
 }
 
+// UpdateTokenStatusByFilter mocks base method.
+func (m *MockStore) UpdateTokenStatusByFilter(filter types.TokenFilter, fromStatuses []string, newStatus string) ([]models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokenStatusByFilter", filter, fromStatuses, newStatus)
+	ret0, _ := ret[0].([]models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTokenStatusByFilter indicates an expected call of UpdateTokenStatusByFilter.
+func (mr *MockStoreMockRecorder) UpdateTokenStatusByFilter(filter, fromStatuses, newStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenStatusByFilter", reflect.TypeOf((*MockStore)(nil).UpdateTokenStatusByFilter), filter, fromStatuses, newStatus)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(user *models.User) error {
 	m.ctrl.T.Helper()
@@ -2706,6 +4185,21 @@ func (mr *MockStoreMockRecorder) UpdateUser(user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), user)
 }
 
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockStore) UpdateWebAuthnSignCount(id string, oldCount, newCount int64, usedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", id, oldCount, newCount, usedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockStoreMockRecorder) UpdateWebAuthnSignCount(id, oldCount, newCount, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockStore)(nil).UpdateWebAuthnSignCount), id, oldCount, newCount, usedAt)
+}
+
 // UpsertExternalUser mocks base method.
 func (m *MockStore) UpsertExternalUser(username, externalID, authSource, email, fullName string) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -45,6 +45,37 @@ const (
 	EventUserRoleChanged   EventType = "USER_ROLE_CHANGED"
 	EventUserPasswordReset EventType = "USER_PASSWORD_RESET" //nolint:gosec // G101: false positive, event type constant
 
+	// Self-service password reset events
+	EventPasswordResetRequested EventType = "PASSWORD_RESET_REQUESTED" //nolint:gosec // G101: false positive, event type constant
+	EventPasswordResetCompleted EventType = "PASSWORD_RESET_COMPLETED" //nolint:gosec // G101: false positive, event type constant
+	EventPasswordResetFailed    EventType = "PASSWORD_RESET_FAILED"    //nolint:gosec // G101: false positive, event type constant
+
+	// Self-service signup events
+	EventSignupRequested EventType = "SIGNUP_REQUESTED"
+	EventSignupVerified  EventType = "SIGNUP_VERIFIED"
+	EventSignupApproved  EventType = "SIGNUP_APPROVED"
+	EventSignupRejected  EventType = "SIGNUP_REJECTED"
+
+	// Self-service profile events
+	EventProfileUpdated       EventType = "PROFILE_UPDATED"
+	EventPasswordChanged      EventType = "PASSWORD_CHANGED" //nolint:gosec // G101: false positive, event type constant
+	EventEmailChangeRequested EventType = "EMAIL_CHANGE_REQUESTED"
+	EventEmailChanged         EventType = "EMAIL_CHANGED"
+
+	// Two-factor authentication events
+	EventTwoFactorEnabled         EventType = "TWO_FACTOR_ENABLED"
+	EventTwoFactorDisabled        EventType = "TWO_FACTOR_DISABLED"
+	EventTwoFactorReset           EventType = "TWO_FACTOR_RESET"
+	EventTwoFactorFailure         EventType = "TWO_FACTOR_FAILURE"
+	EventRecoveryCodeUsed         EventType = "RECOVERY_CODE_USED"
+	EventRecoveryCodesRegenerated EventType = "RECOVERY_CODES_REGENERATED"
+
+	// Passkey (WebAuthn) events
+	EventPasskeyRegistered EventType = "PASSKEY_REGISTERED"
+	EventPasskeyRemoved    EventType = "PASSKEY_REMOVED"
+	EventPasskeyUsed       EventType = "PASSKEY_USED"
+	EventPasskeyFailure    EventType = "PASSKEY_FAILURE"
+
 	// OAuth connection events
 	EventOAuthConnectionDeleted EventType = "OAUTH_CONNECTION_DELETED"
 
@@ -58,8 +89,20 @@ const (
 	EventAuthorizationCodeDenied    EventType = "AUTHORIZATION_CODE_DENIED"
 	EventUserAuthorizationGranted   EventType = "USER_AUTHORIZATION_GRANTED"
 	EventUserAuthorizationRevoked   EventType = "USER_AUTHORIZATION_REVOKED"
+	EventUserAuthorizationExpired   EventType = "USER_AUTHORIZATION_EXPIRED"
 	EventClientTokensRevokedAll     EventType = "CLIENT_TOKENS_REVOKED_ALL" //nolint:gosec // G101: false positive, this is a const string describing an event type, not a credential
 
+	// Admin bulk token actions (one aggregated event per action)
+	EventTokensBulkRevoked  EventType = "TOKENS_BULK_REVOKED"  //nolint:gosec // G101: false positive
+	EventTokensBulkDisabled EventType = "TOKENS_BULK_DISABLED" //nolint:gosec // G101: false positive
+
+	// Login-session-bound tokens revoked on logout or by the user
+	EventSessionTokensRevoked EventType = "SESSION_TOKENS_REVOKED" //nolint:gosec // G101: false positive
+
+	// Per-user/client refresh token family limit enforcement
+	EventTokenFamilyEvicted      EventType = "TOKEN_FAMILY_EVICTED"
+	EventTokenFamilyLimitReached EventType = "TOKEN_FAMILY_LIMIT_REACHED"
+
 	// Client approval events (user self-service workflow)
 	EventClientApproved EventType = "CLIENT_APPROVED"
 	EventClientRejected EventType = "CLIENT_REJECTED"
@@ -70,6 +113,9 @@ const (
 	// Client Credentials Flow events (RFC 6749 §4.4)
 	EventClientCredentialsTokenIssued EventType = "CLIENT_CREDENTIALS_TOKEN_ISSUED" //nolint:gosec // G101: false positive
 
+	// Personal access token events
+	EventPersonalAccessTokenCreated EventType = "PERSONAL_ACCESS_TOKEN_CREATED" //nolint:gosec // G101: false positive
+
 	// Token Introspection events (RFC 7662)
 	EventTokenIntrospected EventType = "TOKEN_INTROSPECTED"
 
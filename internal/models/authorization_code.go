@@ -32,6 +32,13 @@ type AuthorizationCode struct {
 	// specific audience; falls back to the static JWTAudience config.
 	Resource StringArray `gorm:"type:json"`
 
+	// SessionID is the login session (sid) the user approved the request
+	// from; copied onto the issued tokens so logout can revoke them.
+	SessionID string `gorm:"not null;default:'';size:64"`
+	// AuthMethods are the authentication methods (RFC 8176 "amr" values)
+	// the user signed in with, echoed into the ID token.
+	AuthMethods StringArray `gorm:"type:json"`
+
 	ExpiresAt time.Time  `gorm:"index"`
 	UsedAt    *time.Time // Set immediately upon exchange; prevents replay attacks
 	CreatedAt time.Time
@@ -20,7 +20,8 @@ type DeviceCode struct {
 	// audience after the user has authorized the device code.
 	Resource     StringArray `gorm:"type:json"`
 	ExpiresAt    time.Time   `gorm:"index"`
-	Interval     int         // polling interval in seconds
+	Interval     int         // polling interval in seconds; raised by slow_down
+	LastPolledAt time.Time   // last device_code grant request while pending
 	UserID       string      // filled after authorization
 	Authorized   bool        `gorm:"default:false"`
 	AuthorizedAt time.Time
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// EmailChangeToken is a single-use link token emailed to the new address a
+// user asked to switch to. The address only replaces User.Email once the
+// link is followed. Only the SHA-256 hash is stored.
+type EmailChangeToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    string     `gorm:"not null;index"`       // FK → User.ID
+	NewEmail  string     `gorm:"not null"`             // Address to switch to once confirmed
+	TokenHash string     `gorm:"not null;uniqueIndex"` // SHA-256 hex of the token
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time // Set when the token is consumed
+	RequestIP string     // Client IP that requested the change
+	CreatedAt time.Time  `gorm:"index"`
+}
+
+// TableName overrides the table name used by EmailChangeToken to `email_change_tokens`
+func (EmailChangeToken) TableName() string {
+	return "email_change_tokens"
+}
+
+// IsExpired reports whether the token can no longer be used.
+func (t *EmailChangeToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// EmailVerificationToken is a single-use link token emailed to a user who
+// signed up, proving they own the address. Only the SHA-256 hash is stored.
+type EmailVerificationToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    string     `gorm:"not null;index"`       // FK → User.ID
+	TokenHash string     `gorm:"not null;uniqueIndex"` // SHA-256 hex of the token
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time // Set when the token is consumed
+	RequestIP string     // Client IP that submitted the signup
+	CreatedAt time.Time
+}
+
+// TableName overrides the table name used by EmailVerificationToken to `email_verification_tokens`
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+// IsExpired reports whether the token can no longer be used.
+func (t *EmailVerificationToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
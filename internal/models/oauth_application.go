@@ -53,6 +53,58 @@ func ResolveTokenProfile(v string) string {
 	return trimmed
 }
 
+// TokenFormat selects how access tokens are encoded for a client. An empty
+// value on the client row inherits the global TOKEN_FORMAT setting.
+const (
+	TokenFormatJWT    = "jwt"    // Self-contained signed JWT (default)
+	TokenFormatOpaque = "opaque" // Random reference token, resolvable only via introspection
+)
+
+// IsValidTokenFormat reports whether v is a recognised token format name.
+func IsValidTokenFormat(v string) bool {
+	switch v {
+	case TokenFormatJWT, TokenFormatOpaque:
+		return true
+	}
+	return false
+}
+
+// RefreshFamilyPolicy decides what happens when a user already holds the
+// maximum number of active refresh token families for a client. An empty
+// value on the client row inherits the global REFRESH_FAMILY_LIMIT_POLICY.
+const (
+	RefreshFamilyPolicyEvictOldest = "evict_oldest" // Revoke the oldest family to make room (default)
+	RefreshFamilyPolicyReject      = "reject"       // Refuse the new grant until a family is revoked
+)
+
+// IsValidRefreshFamilyPolicy reports whether v is a recognised refresh family
+// limit policy name.
+func IsValidRefreshFamilyPolicy(v string) bool {
+	switch v {
+	case RefreshFamilyPolicyEvictOldest, RefreshFamilyPolicyReject:
+		return true
+	}
+	return false
+}
+
+// UserCodeCharset selects the alphabet device flow user codes are drawn from.
+// An empty value on the client row means alphanumeric.
+const (
+	UserCodeCharsetAlphanumeric = "alphanumeric" // A–Z and 2–9 without look-alikes (default)
+	UserCodeCharsetDigits       = "digits"       // 0–9, for numeric keypads and TV remotes
+	UserCodeCharsetLetters      = "letters"      // A–Z without look-alikes
+)
+
+// IsValidUserCodeCharset reports whether v is a recognised user code charset
+// name.
+func IsValidUserCodeCharset(v string) bool {
+	switch v {
+	case UserCodeCharsetAlphanumeric, UserCodeCharsetDigits, UserCodeCharsetLetters:
+		return true
+	}
+	return false
+}
+
 // Base32 characters, but lowercased.
 const lowerBase32Chars = "abcdefghijklmnopqrstuvwxyz234567"
 
@@ -67,6 +119,7 @@ type OAuthApplication struct {
 	Description                 string      `gorm:"type:text"`
 	UserID                      string      `gorm:"not null"`
 	Scopes                      string      `gorm:"not null"`
+	RequiredScopes              string      `gorm:"not null;default:''"` // Space-separated scopes users cannot deselect on the consent page; "openid" is always required
 	GrantTypes                  string      `gorm:"not null;default:'device_code'"`
 	RedirectURIs                StringArray `gorm:"type:json"`
 	AllowedResources            StringArray `gorm:"type:json"`                 // RFC 8707 resource allowlist (exact match); empty = deny-all. Enforced in services.validateClientResource.
@@ -76,6 +129,15 @@ type OAuthApplication struct {
 	EnableClientCredentialsFlow bool        `gorm:"not null;default:false"`              // Client Credentials Grant (RFC 6749 §4.4); confidential clients only
 	Status                      string      `gorm:"not null;default:'active'"`           // ClientStatusPending / ClientStatusActive / ClientStatusInactive
 	TokenProfile                string      `gorm:"not null;default:'standard';size:20"` // "short" / "standard" / "long"; resolves to a TTL preset in config
+	TokenFormat                 string      `gorm:"not null;default:'';size:10"`         // "jwt" / "opaque"; empty inherits the global TOKEN_FORMAT
+	MaxRefreshFamilies          int         `gorm:"not null;default:0"`                  // Active refresh token families per user; 0 inherits the token profile / global limit
+	RefreshFamilyPolicy         string      `gorm:"not null;default:'';size:20"`         // "evict_oldest" / "reject"; empty inherits the global REFRESH_FAMILY_LIMIT_POLICY
+	DeviceUserCodeCharset       string      `gorm:"not null;default:'';size:20"`         // "alphanumeric" / "digits" / "letters"; empty means alphanumeric
+	DeviceUserCodeLength        int         `gorm:"not null;default:0"`                  // User code length without the dash; 0 uses the default (8)
+	DeviceCodeLifetime          int         `gorm:"not null;default:0"`                  // Device code lifetime in seconds; 0 inherits DEVICE_CODE_EXPIRATION
+	DevicePollingInterval       int         `gorm:"not null;default:0"`                  // Minimum polling interval in seconds; 0 inherits POLLING_INTERVAL
+	ConsentLifetimeDays         int         `gorm:"not null;default:0"`                  // Days before a user's consent expires and they are asked again; 0 inherits CONSENT_LIFETIME
+	Trusted                     bool        `gorm:"not null;default:false"`              // First-party client: consent is granted implicitly (no consent page). Admin-only; never set by dynamic registration or user-created apps.
 	Project                     string      `gorm:"size:64"`                             // Optional project identifier injected as JWT "project" claim. Format: a single alnum, or 2–64 chars matching ^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}[a-zA-Z0-9]$ (validated in services).
 	ServiceAccount              string      `gorm:"size:255"`                            // Optional service account identifier injected as JWT "service_account" claim.
 	CreatedBy                   string
@@ -4,12 +4,19 @@ import (
 	"time"
 )
 
+// ConnectionType constants record the protocol an upstream login uses.
+const (
+	ConnectionTypeOAuth = "oauth" // OAuth 2.0 and OpenID Connect
+	ConnectionTypeSAML  = "saml"  // SAML 2.0 identity provider
+)
+
 // OAuthConnection represents an OAuth provider connection for a user
 type OAuthConnection struct {
 	ID             string `gorm:"primaryKey"`
 	UserID         string `gorm:"not null;uniqueIndex:idx_oauth_user_provider,priority:1"`
 	Provider       string `gorm:"not null;uniqueIndex:idx_oauth_provider_user,priority:1;uniqueIndex:idx_oauth_user_provider,priority:2"` // "github", "gitea", "gitlab"
 	ProviderUserID string `gorm:"not null;uniqueIndex:idx_oauth_provider_user,priority:2"`                                                // Provider's user ID
+	ConnectionType string `gorm:"default:'oauth'"`                                                                                        // "oauth" or "saml"
 
 	// OAuth metadata (snapshot for audit/reference)
 	ProviderUsername string // Provider's username
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use link token emailed to a local user who
+// forgot their password. Only the SHA-256 hash is stored.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    string     `gorm:"not null;index"`       // FK → User.ID
+	TokenHash string     `gorm:"not null;uniqueIndex"` // SHA-256 hex of the token
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time // Set when the token is consumed
+	RequestIP string     // Client IP that requested the reset
+	CreatedAt time.Time  `gorm:"index"`
+}
+
+// TableName overrides the table name used by PasswordResetToken to `password_reset_tokens`
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// IsExpired reports whether the token can no longer be used.
+func (t *PasswordResetToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
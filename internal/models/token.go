@@ -13,12 +13,14 @@ const (
 	TokenStatusRevoked  TokenStatus = "revoked"
 )
 
-// TokenCategory distinguishes access tokens from refresh tokens.
+// TokenCategory distinguishes access tokens from refresh tokens and
+// user-created personal access tokens.
 type TokenCategory = string
 
 const (
-	TokenCategoryAccess  TokenCategory = "access"
-	TokenCategoryRefresh TokenCategory = "refresh"
+	TokenCategoryAccess   TokenCategory = "access"
+	TokenCategoryRefresh  TokenCategory = "refresh"
+	TokenCategoryPersonal TokenCategory = "personal"
 )
 
 type AccessToken struct {
@@ -26,7 +28,7 @@ type AccessToken struct {
 	TokenHash string `gorm:"uniqueIndex;not null"`
 	RawToken  string `gorm:"-"` // In-memory only; never persisted to DB
 	TokenType string `gorm:"not null;default:'Bearer'"`
-	// 'access' or 'refresh'
+	// 'access', 'refresh' or 'personal'
 	TokenCategory string `gorm:"not null;default:'access';index:idx_token_cat_status_exp,priority:1"`
 	// 'active', 'disabled', 'revoked'
 	Status        string    `gorm:"not null;default:'active';index:idx_token_client_status,priority:2;index:idx_token_family_status,priority:2;index:idx_token_auth_status,priority:2;index:idx_token_cat_status_exp,priority:2"`
@@ -38,6 +40,14 @@ type AccessToken struct {
 	LastUsedAt    *time.Time `gorm:"index"`                                                        // Last time token was used (for refresh tokens)
 	ParentTokenID string     `gorm:"index"`                                                        // Links access tokens to their refresh token
 	TokenFamilyID string     `gorm:"index:idx_token_family_status,priority:1;default:'';not null"` // Stable root ID for rotation replay detection
+	// Name is the user-chosen label of a personal access token; empty for
+	// tokens issued through OAuth grants.
+	Name string `gorm:"not null;default:'';size:100"`
+	// SessionID is the AuthGate login session (sid) whose browser sign-in
+	// produced this token through the authorization code flow. Inherited
+	// across refreshes so logging out of that session revokes the whole
+	// family. Empty for tokens not tied to a browser session.
+	SessionID string `gorm:"not null;default:'';size:64;index"`
 	// AuthorizationID is the FK → UserAuthorization.ID. Set for both
 	// authorization-code and device-code grants when a UserAuthorization
 	// exists at issuance time, so /account/authorizations and the admin
@@ -45,6 +55,11 @@ type AccessToken struct {
 	// for client_credentials (no user) and for device-code tokens issued
 	// against an older authorization that pre-dates consent persistence.
 	AuthorizationID *uint `gorm:"index:idx_token_auth_status,priority:1"`
+	// AbsoluteExpiresAt is the hard cap for sliding refresh-token renewal:
+	// sliding use may push ExpiresAt forward but never past this instant.
+	// Set on refresh tokens issued while REFRESH_TOKEN_SLIDING_EXPIRATION is
+	// enabled and inherited across rotations; nil elsewhere.
+	AbsoluteExpiresAt *time.Time
 	// Resource semantics differ by TokenCategory:
 	//
 	//   - Access tokens: this is the audience SNAPSHOT taken at issuance —
@@ -71,6 +86,20 @@ func (t *AccessToken) IsExpired() bool {
 	return time.Now().After(t.ExpiresAt)
 }
 
+// IsIdle reports whether the token has gone unused for longer than timeout.
+// A token never used since issuance is measured from CreatedAt. A
+// non-positive timeout disables the check.
+func (t *AccessToken) IsIdle(timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	lastUse := t.CreatedAt
+	if t.LastUsedAt != nil {
+		lastUse = *t.LastUsedAt
+	}
+	return time.Since(lastUse) > timeout
+}
+
 func (t *AccessToken) IsActive() bool {
 	return t.Status == TokenStatusActive
 }
@@ -90,3 +119,10 @@ func (t *AccessToken) IsAccessToken() bool {
 func (t *AccessToken) IsRefreshToken() bool {
 	return t.TokenCategory == TokenCategoryRefresh
 }
+
+// IsPersonalAccessToken reports whether the token was created by its owner
+// at /account/tokens rather than issued to an OAuth client. Personal access
+// tokens have no ClientID and authorize requests like access tokens.
+func (t *AccessToken) IsPersonalAccessToken() bool {
+	return t.TokenCategory == TokenCategoryPersonal
+}
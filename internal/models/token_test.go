@@ -139,3 +139,30 @@ func TestAccessToken_IsRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessToken_IsIdle(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-10 * time.Minute)
+	stale := now.Add(-3 * time.Hour)
+	tests := []struct {
+		name       string
+		createdAt  time.Time
+		lastUsedAt *time.Time
+		timeout    time.Duration
+		want       bool
+	}{
+		{"disabled timeout", stale, nil, 0, false},
+		{"never used, created recently", recent, nil, time.Hour, false},
+		{"never used, created long ago", stale, nil, time.Hour, true},
+		{"old token used recently", stale, &recent, time.Hour, false},
+		{"old token unused since", stale, &stale, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok := &AccessToken{CreatedAt: tt.createdAt, LastUsedAt: tt.lastUsedAt}
+			if got := tok.IsIdle(tt.timeout); got != tt.want {
+				t.Errorf("IsIdle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
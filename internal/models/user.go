@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -10,10 +13,18 @@ const (
 	UserRoleUser  = "user"
 )
 
+// SignupStatus constants track self-service signups that are not yet
+// complete. Accounts created any other way have an empty SignupStatus.
+const (
+	SignupStatusUnverified = "unverified" // waiting for the email verification link
+	SignupStatusPending    = "pending"    // email verified, waiting for admin approval
+)
+
 // AuthSource constants define where a user authenticates from.
 const (
 	AuthSourceLocal   = "local"
 	AuthSourceHTTPAPI = "http_api"
+	AuthSourceLDAP    = "ldap"
 )
 
 type User struct {
@@ -29,7 +40,34 @@ type User struct {
 
 	// External authentication support
 	ExternalID string `gorm:"index"`           // External user ID (e.g., from HTTP API)
-	AuthSource string `gorm:"default:'local'"` // "local" or "http_api"
+	AuthSource string `gorm:"default:'local'"` // "local", "http_api" or "ldap"
+
+	// ExternalRole, Groups and Attributes are reported by the external auth
+	// backend and refreshed on every login. Local users have none of them.
+	// ExternalRole is the backend's own role name, kept apart from Role.
+	ExternalRole string
+	Groups       StringArray    `gorm:"type:json"`
+	Attributes   UserAttributes `gorm:"type:json"`
+
+	// TOTP two-factor authentication. TOTPSecret is written when enrollment
+	// starts but only takes effect once TOTPEnabled is true. TOTPLastStep is
+	// the last accepted time step, so the same code cannot be replayed.
+	TOTPSecret   string
+	TOTPEnabled  bool  `gorm:"not null;default:false"`
+	TOTPLastStep int64 `gorm:"not null;default:0"`
+
+	// WebAuthnEnabled is true while the user has at least one registered
+	// passkey. It is kept in step with the webauthn_credentials table so the
+	// login flow can tell whether a second factor is needed without a query.
+	WebAuthnEnabled bool `gorm:"column:webauthn_enabled;not null;default:false"`
+
+	// SessionsRevokedAt invalidates browser sessions signed in at or before
+	// this time, e.g. after a password reset. Nil means no revocation.
+	SessionsRevokedAt *time.Time
+
+	// SignupStatus is set while a self-service signup is incomplete. Such
+	// accounts stay inactive until the status is cleared.
+	SignupStatus string `gorm:"index;not null;default:''"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -40,7 +78,55 @@ func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
 }
 
+// ExternalRoles returns the role reported by the external auth backend in the
+// shape of the "roles" claim, or nil when there is none.
+func (u *User) ExternalRoles() []string {
+	if u.ExternalRole == "" {
+		return nil
+	}
+	return []string{u.ExternalRole}
+}
+
+// IsSignupIncomplete reports whether the user signed up themselves and has
+// not yet verified their email or been approved.
+func (u *User) IsSignupIncomplete() bool {
+	return u.SignupStatus != ""
+}
+
 // IsExternal returns true if user authenticates via external provider
 func (u *User) IsExternal() bool {
 	return u.AuthSource != AuthSourceLocal && u.AuthSource != ""
 }
+
+// UserAttributes stores custom attributes reported by an external auth
+// backend as JSON
+type UserAttributes map[string]any
+
+// Value implements the driver.Valuer interface for database storage
+func (a UserAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil //nolint:nilnil // nil driver.Value represents SQL NULL, which is valid here
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (a *UserAttributes) Scan(value any) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal UserAttributes value: %v", value)
+	}
+
+	result := make(UserAttributes)
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	*a = result
+	return nil
+}
@@ -18,7 +18,12 @@ type UserAuthorization struct {
 	ApplicationID int64  `gorm:"not null;uniqueIndex:idx_user_app"` // FK → OAuthApplication.ID
 	ClientID      string `gorm:"not null;index"`                    // Denormalized ClientID UUID (for UI display and API responses)
 
-	Scopes string `gorm:"not null"`
+	// Scopes holds the scopes the user granted, which may be a subset of
+	// those requested. DeclinedScopes holds the optional scopes the user
+	// unchecked on the consent page, so remembered consent can tell a scope
+	// the user already turned down from one never asked about.
+	Scopes         string `gorm:"not null"`
+	DeclinedScopes string `gorm:"not null;default:''"`
 	// Resource holds the RFC 8707 Resource Indicator(s) the user approved
 	// at consent time. The remembered-consent shortcut on /oauth/authorize
 	// requires an EXACT resource-set match before auto-approving — a
@@ -28,6 +33,10 @@ type UserAuthorization struct {
 	// (i.e. the access token will fall back to the static JWT_AUDIENCE).
 	Resource  StringArray `gorm:"type:json"`
 	GrantedAt time.Time
+	// ExpiresAt is when the consent lapses and the user must approve the
+	// app again (CONSENT_LIFETIME or the client's override). Nil means the
+	// consent never expires.
+	ExpiresAt *time.Time `gorm:"index"`
 	RevokedAt *time.Time
 	IsActive  bool `gorm:"not null;default:true"`
 
@@ -38,3 +47,8 @@ type UserAuthorization struct {
 func (UserAuthorization) TableName() string {
 	return "user_authorizations"
 }
+
+// IsExpired reports whether the consent has passed its ExpiresAt.
+func (a *UserAuthorization) IsExpired() bool {
+	return a.ExpiresAt != nil && !time.Now().Before(*a.ExpiresAt)
+}
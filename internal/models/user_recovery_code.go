@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// UserRecoveryCode is a single-use code that stands in for a TOTP code when
+// the user has lost their authenticator. Only the SHA-256 hash is stored.
+type UserRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    string     `gorm:"not null;index"`       // FK → User.ID
+	CodeHash  string     `gorm:"not null;uniqueIndex"` // SHA-256 hex of the normalized code
+	UsedAt    *time.Time // Set when the code is consumed
+	CreatedAt time.Time
+}
+
+// TableName overrides the table name used by UserRecoveryCode to `user_recovery_codes`
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// WebAuthnCredential is a passkey or security key registered by a user. It
+// can sign in on its own (passkey) or serve as the second factor after a
+// password.
+type WebAuthnCredential struct {
+	ID           string      `gorm:"primaryKey"`
+	UserID       string      `gorm:"not null;index"`       // FK → User.ID
+	CredentialID string      `gorm:"not null;uniqueIndex"` // base64url credential ID chosen by the authenticator
+	PublicKey    []byte      `gorm:"not null"`             // COSE_Key
+	SignCount    int64       `gorm:"not null;default:0"`   // Last signature counter seen; 0 if the authenticator has none
+	Transports   StringArray `gorm:"type:json"`            // Hints for the browser, e.g. "internal", "usb"
+	Name         string      `gorm:"not null"`             // User-chosen label
+	// BackupEligible is true for synced passkeys (e.g. iCloud Keychain,
+	// Google Password Manager) and false for device-bound keys.
+	BackupEligible bool `gorm:"not null;default:false"`
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+// TableName overrides the table name used by WebAuthnCredential to `webauthn_credentials`
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
@@ -0,0 +1,83 @@
+// Package notify provides core.DeviceCodeNotifier implementations: an
+// in-process hub for single-instance deployments and a Redis pub/sub
+// notifier that fans events out to every replica.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-authgate/authgate/internal/core"
+)
+
+// Compile-time interface check.
+var _ core.DeviceCodeNotifier = (*MemoryNotifier)(nil)
+
+// MemoryNotifier delivers events to subscribers in the same process.
+// Suitable for single-instance deployments; it is also the local fan-out
+// used by RedisNotifier.
+type MemoryNotifier struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan struct{}]struct{}
+}
+
+// NewMemoryNotifier creates an in-process notifier.
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{subs: make(map[int64]map[chan struct{}]struct{})}
+}
+
+// Publish wakes every current subscriber of deviceCodeID.
+func (n *MemoryNotifier) Publish(_ context.Context, deviceCodeID int64) error {
+	n.broadcast(deviceCodeID)
+	return nil
+}
+
+// broadcast performs a non-blocking send to each subscriber; the channels
+// are buffered, so a subscriber that has not yet started waiting still sees
+// the event.
+func (n *MemoryNotifier) broadcast(deviceCodeID int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subs[deviceCodeID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a subscriber for deviceCodeID.
+func (n *MemoryNotifier) Subscribe(deviceCodeID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	n.mu.Lock()
+	if n.subs[deviceCodeID] == nil {
+		n.subs[deviceCodeID] = make(map[chan struct{}]struct{})
+	}
+	n.subs[deviceCodeID][ch] = struct{}{}
+	n.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			delete(n.subs[deviceCodeID], ch)
+			if len(n.subs[deviceCodeID]) == 0 {
+				delete(n.subs, deviceCodeID)
+			}
+		})
+	}
+}
+
+// Close is a no-op; subscribers release themselves.
+func (n *MemoryNotifier) Close() error {
+	return nil
+}
+
+// subscriberCount returns the number of live subscriptions for deviceCodeID.
+func (n *MemoryNotifier) subscriberCount(deviceCodeID int64) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.subs[deviceCodeID])
+}
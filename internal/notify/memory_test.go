@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryNotifier_PublishWakesSubscribers(t *testing.T) {
+	n := NewMemoryNotifier()
+	ch1, release1 := n.Subscribe(1)
+	defer release1()
+	ch2, release2 := n.Subscribe(1)
+	defer release2()
+	other, releaseOther := n.Subscribe(2)
+	defer releaseOther()
+
+	require.NoError(t, n.Publish(context.Background(), 1))
+
+	for _, ch := range []<-chan struct{}{ch1, ch2} {
+		select {
+		case <-ch:
+		default:
+			t.Fatal("subscriber was not notified")
+		}
+	}
+	select {
+	case <-other:
+		t.Fatal("subscriber of another device code was notified")
+	default:
+	}
+}
+
+func TestMemoryNotifier_PublishDoesNotBlock(t *testing.T) {
+	n := NewMemoryNotifier()
+	ch, release := n.Subscribe(1)
+	defer release()
+
+	// The second event finds the buffer full and is dropped, not blocked on.
+	require.NoError(t, n.Publish(context.Background(), 1))
+	require.NoError(t, n.Publish(context.Background(), 1))
+	<-ch
+	select {
+	case <-ch:
+		t.Fatal("expected a single buffered event")
+	default:
+	}
+}
+
+func TestMemoryNotifier_Release(t *testing.T) {
+	n := NewMemoryNotifier()
+	_, release := n.Subscribe(1)
+	assert.Equal(t, 1, n.subscriberCount(1))
+
+	release()
+	release() // idempotent
+	assert.Equal(t, 0, n.subscriberCount(1))
+	assert.Empty(t, n.subs)
+
+	// Publishing with no subscribers is a no-op.
+	require.NoError(t, n.Publish(context.Background(), 1))
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+
+	"github.com/redis/rueidis"
+)
+
+// Compile-time interface check.
+var _ core.DeviceCodeNotifier = (*RedisNotifier)(nil)
+
+// resubscribeDelay is how long the receive loop waits before re-subscribing
+// after the Redis connection drops.
+const resubscribeDelay = time.Second
+
+// RedisNotifier publishes events on a Redis pub/sub channel. Each replica
+// holds a single subscription to that channel and fans received events out
+// to its local waiters, so an authorization on one replica wakes a waiter
+// on any other. Events published while a replica is re-subscribing are lost;
+// its waiters then fall back to their timeout.
+type RedisNotifier struct {
+	local   *MemoryNotifier
+	client  rueidis.Client
+	channel string
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewRedisNotifier connects to Redis and starts the receive loop on channel.
+func NewRedisNotifier(
+	ctx context.Context,
+	addr, password string,
+	db int,
+	channel string,
+) (*RedisNotifier, error) {
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{addr},
+		Password:     password,
+		SelectDB:     db,
+		DisableCache: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redis client: %w", err)
+	}
+
+	if err := client.Do(ctx, client.B().Ping().Build()).Error(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping Redis: %w", err)
+	}
+
+	// The receive loop outlives the (init-scoped) ctx; Close stops it.
+	loopCtx, cancel := context.WithCancel(context.Background())
+	n := &RedisNotifier{
+		local:   NewMemoryNotifier(),
+		client:  client,
+		channel: channel,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go n.receive(loopCtx)
+	return n, nil
+}
+
+// receive keeps a subscription to the channel open until ctx is canceled.
+func (n *RedisNotifier) receive(ctx context.Context) {
+	defer close(n.done)
+	cmd := n.client.B().Subscribe().Channel(n.channel).Build()
+	for {
+		err := n.client.Receive(ctx, cmd, func(msg rueidis.PubSubMessage) {
+			id, err := strconv.ParseInt(msg.Message, 10, 64)
+			if err != nil {
+				return
+			}
+			n.local.broadcast(id)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("[DeviceNotify] Redis subscription to %s lost, retrying: %v", n.channel, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// Publish sends the event to every replica, including this one.
+func (n *RedisNotifier) Publish(ctx context.Context, deviceCodeID int64) error {
+	cmd := n.client.B().Publish().
+		Channel(n.channel).
+		Message(strconv.FormatInt(deviceCodeID, 10)).
+		Build()
+	if err := n.client.Do(ctx, cmd).Error(); err != nil {
+		return fmt.Errorf("failed to publish device code event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe registers a local subscriber for deviceCodeID.
+func (n *RedisNotifier) Subscribe(deviceCodeID int64) (<-chan struct{}, func()) {
+	return n.local.Subscribe(deviceCodeID)
+}
+
+// Close stops the receive loop and closes the Redis connection.
+func (n *RedisNotifier) Close() error {
+	n.cancel()
+	<-n.done
+	n.client.Close()
+	return nil
+}
@@ -0,0 +1,38 @@
+// Package qrcode renders short byte strings (such as device flow
+// verification URIs) as QR codes in PNG or SVG form. Symbol encoding is
+// delegated to github.com/skip2/go-qrcode at error correction level M; this
+// package only controls the quiet zone and the output formats.
+package qrcode
+
+import (
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+// Code is an encoded QR code symbol. Modules are addressed by column x and
+// row y, both in [0, Size).
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark. Coordinates
+// outside the symbol (the quiet zone) are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Encode encodes data at error correction level M using the smallest version
+// that fits. It fails if data exceeds the capacity of a version 40 symbol.
+func Encode(data []byte) (*Code, error) {
+	q, err := goqrcode.New(string(data), goqrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	// The quiet zone is added by the renderers below.
+	q.DisableBorder = true
+	modules := q.Bitmap()
+	return &Code{Size: len(modules), modules: modules}, nil
+}
@@ -0,0 +1,58 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	for _, payload := range []string{
+		"A",
+		"https://auth.example.com/device?user_code=ABCD-EFGH",
+		strings.Repeat("y", 213),
+	} {
+		t.Run(payload[:1], func(t *testing.T) {
+			code, err := Encode([]byte(payload))
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, code.Size, 21)
+			assert.Zero(t, (code.Size-17)%4, "size is 17+4*version")
+			// Finder pattern corners are dark, outside the symbol is light.
+			assert.True(t, code.Dark(0, 0))
+			assert.True(t, code.Dark(code.Size-1, 0))
+			assert.True(t, code.Dark(0, code.Size-1))
+			assert.False(t, code.Dark(-1, 0))
+			assert.False(t, code.Dark(code.Size, 0))
+		})
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := Encode(bytes.Repeat([]byte("z"), 4000))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode([]byte("https://auth.example.com/device?user_code=ABCD-EFGH"))
+	require.NoError(t, err)
+
+	data, err := code.PNG(4)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	dim := (code.Size + 2*QuietZone) * 4
+	assert.Equal(t, dim, img.Bounds().Dx())
+	// Quiet zone is light, the finder pattern corner is dark.
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	r, _, _, _ = img.At(QuietZone*4, QuietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r)
+
+	svg := code.SVG()
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, "M4,4h1v1h-1z", "top-left finder module")
+}
@@ -0,0 +1,59 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QuietZone is the light border, in modules, added around rendered symbols.
+const QuietZone = 4
+
+// PNG renders the symbol as a black-on-white PNG with scale pixels per
+// module and a QuietZone border.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	dim := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(
+		image.Rect(0, 0, dim, dim),
+		color.Palette{color.White, color.Black},
+	)
+	for py := range dim {
+		y := py/scale - QuietZone
+		for px := range dim {
+			if c.Dark(px/scale-QuietZone, y) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol as a scalable SVG document. Each module is one user
+// unit; dark modules are drawn as a single path.
+func (c *Code) SVG() string {
+	dim := c.Size + 2*QuietZone
+	var path strings.Builder
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		dim, dim, path.String(),
+	)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthChainSourceNew stands for usernames without a local account in
+// AuthChainEntry.Sources.
+const AuthChainSourceNew = "new"
+
+// AuthChainEntry is one password backend of the authentication chain.
+// Backends are tried in order; each one is skipped unless the username
+// matches UsernamePattern and the user's auth source is in Sources.
+type AuthChainEntry struct {
+	Source          string // Auth source the backend owns: "local", "http_api" or "ldap"
+	Provider        core.AuthProvider
+	UsernamePattern *regexp.Regexp // nil matches every username
+	Sources         []string       // Auth sources of existing users, or AuthChainSourceNew
+
+	// ContinueOnNotFound and ContinueOnInvalidPassword decide whether a
+	// rejection passes the attempt on to the next backend. Backend failures
+	// (unreachable, malformed response) always end the attempt.
+	ContinueOnNotFound        bool
+	ContinueOnInvalidPassword bool
+
+	// MigratePassword stores the password as a local bcrypt hash after a
+	// successful external login and moves the user to the local source.
+	MigratePassword bool
+}
+
+// UserServiceOption configures optional UserService behavior.
+type UserServiceOption func(*UserService)
+
+// WithAuthChain replaces the chain NewUserService derives from its auth mode.
+func WithAuthChain(chain []AuthChainEntry) UserServiceOption {
+	return func(s *UserService) {
+		s.authChain = chain
+	}
+}
+
+// defaultAuthChain mirrors AUTH_MODE: the external backend handles its own
+// and new users, and local accounts keep signing in locally.
+func defaultAuthChain(
+	localProvider, externalProvider core.AuthProvider,
+	authMode string,
+) []AuthChainEntry {
+	var chain []AuthChainEntry
+	if authMode == AuthModeHTTPAPI || authMode == AuthModeLDAP {
+		chain = append(chain, AuthChainEntry{
+			Source:             authMode,
+			Provider:           externalProvider,
+			Sources:            []string{authMode, AuthChainSourceNew},
+			ContinueOnNotFound: true,
+		})
+	}
+	return append(chain, AuthChainEntry{
+		Source:             AuthModeLocal,
+		Provider:           localProvider,
+		Sources:            []string{AuthModeLocal},
+		ContinueOnNotFound: true,
+	})
+}
+
+// appliesTo reports whether the backend handles username, whose existing
+// account (if any) has the given auth source.
+func (e *AuthChainEntry) appliesTo(username, source string) bool {
+	if e.UsernamePattern != nil && !e.UsernamePattern.MatchString(username) {
+		return false
+	}
+	return slices.Contains(e.Sources, source)
+}
+
+// vouchesFor reports whether a login this backend accepted identifies user,
+// whose account has the given auth source. A backend speaks for the users
+// of its own source, and the local backend checks the account's own
+// password. For anyone else the username alone is not enough: the account
+// must carry the external ID the backend returned, or an unrelated user of
+// the same name could sign in as it and, with MigratePassword, replace its
+// password.
+func (e *AuthChainEntry) vouchesFor(
+	user *models.User,
+	source string,
+	result *core.AuthResult,
+) bool {
+	if e.Source == source || e.Source == AuthModeLocal {
+		return true
+	}
+	return user.ExternalID != "" && user.ExternalID == result.ExternalID
+}
+
+// continueAfter reports whether a rejection by this backend passes the
+// attempt on to the next one.
+func (e *AuthChainEntry) continueAfter(err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		return e.ContinueOnNotFound
+	case errors.Is(err, auth.ErrInvalidCredentials), errors.Is(err, auth.ErrHTTPAPIAuthFailed):
+		return e.ContinueOnInvalidPassword
+	default:
+		return false
+	}
+}
+
+// migratePassword turns an externally authenticated user into a local one
+// holding the password that was just verified. Failures are logged and
+// leave the user on the external source.
+func (s *UserService) migratePassword(
+	ctx context.Context,
+	user *models.User,
+	password, from string,
+) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("[Auth] Password migration failed for user=%s: %v", user.Username, err)
+		return
+	}
+	migrated := *user
+	migrated.PasswordHash = string(hash)
+	migrated.AuthSource = AuthModeLocal
+	if err := s.store.UpdateUser(&migrated); err != nil {
+		log.Printf("[Auth] Password migration failed for user=%s: %v", user.Username, err)
+		return
+	}
+	s.InvalidateUserCache(user.ID)
+	*user = migrated
+
+	log.Printf("[Auth] Migrated user=%s from %s to local", user.Username, from)
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:     models.EventUserUpdated,
+		Severity:      models.SeverityInfo,
+		ActorUserID:   user.ID,
+		ActorUsername: user.Username,
+		ResourceType:  models.ResourceUser,
+		ResourceID:    user.ID,
+		Action: fmt.Sprintf(
+			"Password migrated from %s to local", externalProviderLabel(from),
+		),
+		Details: models.AuditDetails{
+			"previous_auth_source": from,
+			"auth_source":          AuthModeLocal,
+		},
+		Success: true,
+	})
+}
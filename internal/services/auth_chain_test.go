@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/mocks"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUserServiceWithChain builds a UserService that authenticates with chain.
+func newUserServiceWithChain(
+	t *testing.T,
+	db *store.Store,
+	chain ...AuthChainEntry,
+) *UserService {
+	t.Helper()
+	mockCache := mocks.NewMockCache[models.User](gomock.NewController(t))
+	mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return NewUserService(
+		db, nil, nil, AuthModeLocal, false, NewNoopAuditService(), mockCache, 5*time.Minute,
+		WithAuthChain(chain),
+	)
+}
+
+func TestAuthChain_MigratesPasswordToLocal(t *testing.T) {
+	db := setupTestStore(t)
+	legacy := mocks.NewMockAuthProvider(gomock.NewController(t))
+	u := makeTestHTTPAPIUser(t, db)
+
+	legacy.EXPECT().
+		Authenticate(gomock.Any(), u.Username, "legacy-pass").
+		Return(&core.AuthResult{Username: u.Username, ExternalID: u.ExternalID}, nil).
+		Times(1)
+
+	svc := newUserServiceWithChain(t, db,
+		AuthChainEntry{
+			Source:          AuthModeHTTPAPI,
+			Provider:        legacy,
+			Sources:         []string{AuthModeHTTPAPI, AuthChainSourceNew},
+			MigratePassword: true,
+		},
+		AuthChainEntry{
+			Source:   AuthModeLocal,
+			Provider: auth.NewLocalAuthProvider(db),
+			Sources:  []string{AuthModeLocal},
+		},
+	)
+
+	user, err := svc.Authenticate(context.Background(), u.Username, "legacy-pass")
+	require.NoError(t, err)
+	assert.Equal(t, AuthModeLocal, user.AuthSource)
+
+	stored, err := db.GetUserByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, AuthModeLocal, stored.AuthSource)
+	assert.Equal(t, u.ExternalID, stored.ExternalID)
+	require.NoError(
+		t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("legacy-pass")),
+	)
+
+	// The next login is local; the legacy backend is not asked again.
+	user, err = svc.Authenticate(context.Background(), u.Username, "legacy-pass")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, user.ID)
+	_, err = svc.Authenticate(context.Background(), u.Username, "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthChain_MigratesNewUser(t *testing.T) {
+	db := setupTestStore(t)
+	legacy := mocks.NewMockAuthProvider(gomock.NewController(t))
+	username := "new-" + uuid.New().String()[:8]
+
+	legacy.EXPECT().
+		Authenticate(gomock.Any(), username, "pass").
+		Return(&core.AuthResult{
+			Username:   username,
+			ExternalID: "ext-" + username,
+			Email:      username + "@example.com",
+		}, nil)
+
+	svc := newUserServiceWithChain(t, db, AuthChainEntry{
+		Source:          AuthModeHTTPAPI,
+		Provider:        legacy,
+		Sources:         []string{AuthChainSourceNew},
+		MigratePassword: true,
+	})
+	user, err := svc.Authenticate(context.Background(), username, "pass")
+	require.NoError(t, err)
+	assert.Equal(t, AuthModeLocal, user.AuthSource)
+	assert.Equal(t, "ext-"+username, user.ExternalID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("pass")))
+}
+
+func TestAuthChain_Fallthrough(t *testing.T) {
+	notFound := fmt.Errorf("%w: %w", auth.ErrInvalidCredentials, auth.ErrUserNotFound)
+	tests := []struct {
+		name       string
+		firstErr   error
+		onNotFound bool
+		onInvalid  bool
+		wantSecond bool
+	}{
+		{name: "not found continues", firstErr: notFound, onNotFound: true, wantSecond: true},
+		{name: "not found stops", firstErr: notFound},
+		{name: "wrong password stops", firstErr: auth.ErrInvalidCredentials, onNotFound: true},
+		{
+			name:       "wrong password continues",
+			firstErr:   auth.ErrHTTPAPIAuthFailed,
+			onInvalid:  true,
+			wantSecond: true,
+		},
+		{
+			name:       "backend failure always stops",
+			firstErr:   auth.ErrHTTPAPIConnection,
+			onNotFound: true,
+			onInvalid:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestStore(t)
+			ctrl := gomock.NewController(t)
+			first := mocks.NewMockAuthProvider(ctrl)
+			second := mocks.NewMockAuthProvider(ctrl)
+			username := "ldapuser-" + uuid.New().String()[:8]
+
+			first.EXPECT().Authenticate(gomock.Any(), username, "pass").Return(nil, tt.firstErr)
+			if tt.wantSecond {
+				second.EXPECT().
+					Authenticate(gomock.Any(), username, "pass").
+					Return(&core.AuthResult{
+						Username:   username,
+						ExternalID: "uid=" + username,
+						Email:      username + "@example.com",
+					}, nil)
+			}
+
+			svc := newUserServiceWithChain(t, db,
+				AuthChainEntry{
+					Source:                    AuthModeHTTPAPI,
+					Provider:                  first,
+					Sources:                   []string{AuthChainSourceNew},
+					ContinueOnNotFound:        tt.onNotFound,
+					ContinueOnInvalidPassword: tt.onInvalid,
+				},
+				AuthChainEntry{
+					Source:   AuthModeLDAP,
+					Provider: second,
+					Sources:  []string{AuthChainSourceNew},
+				},
+			)
+			user, err := svc.Authenticate(context.Background(), username, "pass")
+			if !tt.wantSecond {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, AuthModeLDAP, user.AuthSource)
+		})
+	}
+}
+
+func TestAuthChain_Rules(t *testing.T) {
+	db := setupTestStore(t)
+	ctrl := gomock.NewController(t)
+	ldap := mocks.NewMockAuthProvider(ctrl)
+	local := mocks.NewMockAuthProvider(ctrl)
+	admin := makeTestUser(t, db) // auth_source=local
+
+	svc := newUserServiceWithChain(t, db,
+		AuthChainEntry{
+			Source:          AuthModeLDAP,
+			Provider:        ldap,
+			UsernamePattern: regexp.MustCompile(`^[a-z]+$`),
+			Sources:         []string{AuthModeLDAP, AuthChainSourceNew},
+		},
+		AuthChainEntry{
+			Source:   AuthModeLocal,
+			Provider: local,
+			Sources:  []string{AuthModeLocal},
+		},
+	)
+
+	t.Run("source routes existing users", func(t *testing.T) {
+		local.EXPECT().
+			Authenticate(gomock.Any(), admin.Username, "pass").
+			Return(&core.AuthResult{Username: admin.Username}, nil)
+		user, err := svc.Authenticate(context.Background(), admin.Username, "pass")
+		require.NoError(t, err)
+		assert.Equal(t, admin.ID, user.ID)
+	})
+
+	t.Run("username pattern skips the backend", func(t *testing.T) {
+		_, err := svc.Authenticate(context.Background(), "svc-account-1", "pass")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("source without a backend", func(t *testing.T) {
+		u := makeTestHTTPAPIUser(t, db)
+		_, err := svc.Authenticate(context.Background(), u.Username, "pass")
+		assert.ErrorIs(t, err, ErrAuthProviderFailed)
+	})
+}
+
+func TestAuthChain_ForeignSourceIsNotSynced(t *testing.T) {
+	db := setupTestStore(t)
+	legacy := mocks.NewMockAuthProvider(gomock.NewController(t))
+	u := makeTestUser(t, db) // auth_source=local, imported without a usable password
+	u.ExternalID = "legacy-42"
+	require.NoError(t, db.UpdateUser(u))
+
+	legacy.EXPECT().
+		Authenticate(gomock.Any(), u.Username, "pass").
+		Return(&core.AuthResult{
+			Username:   u.Username,
+			ExternalID: "legacy-42",
+			Email:      "other@example.com",
+		}, nil)
+
+	svc := newUserServiceWithChain(t, db, AuthChainEntry{
+		Source:   AuthModeHTTPAPI,
+		Provider: legacy,
+		Sources:  []string{AuthModeLocal},
+	})
+	user, err := svc.Authenticate(context.Background(), u.Username, "pass")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, user.ID)
+	assert.Equal(t, u.Email, user.Email)
+	assert.Equal(t, AuthModeLocal, user.AuthSource)
+}
+
+func TestAuthChain_ForeignSourceNeedsMatchingExternalID(t *testing.T) {
+	db := setupTestStore(t)
+	ldap := mocks.NewMockAuthProvider(gomock.NewController(t))
+	hash, err := bcrypt.GenerateFromPassword([]byte("local-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	u := makeTestUser(t, db)
+	u.PasswordHash = string(hash)
+	require.NoError(t, db.UpdateUser(u))
+
+	// The directory has its own, unrelated account with the same username.
+	ldap.EXPECT().
+		Authenticate(gomock.Any(), u.Username, gomock.Any()).
+		Return(&core.AuthResult{Username: u.Username, ExternalID: "ldap-7"}, nil).
+		AnyTimes()
+
+	svc := newUserServiceWithChain(t, db,
+		AuthChainEntry{
+			Source:             AuthModeLDAP,
+			Provider:           ldap,
+			Sources:            []string{AuthModeLocal},
+			ContinueOnNotFound: true,
+			MigratePassword:    true,
+		},
+		AuthChainEntry{
+			Source:   AuthModeLocal,
+			Provider: auth.NewLocalAuthProvider(db),
+			Sources:  []string{AuthModeLocal},
+		},
+	)
+
+	_, err = svc.Authenticate(context.Background(), u.Username, "ldap-pass")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	stored, err := db.GetUserByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.PasswordHash, stored.PasswordHash)
+
+	// The local owner still signs in with their own password.
+	user, err := svc.Authenticate(context.Background(), u.Username, "local-pass")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, user.ID)
+
+	// Once the account is linked to the directory entry, the backend speaks for it.
+	u.ExternalID = "ldap-7"
+	require.NoError(t, db.UpdateUser(u))
+	_, err = svc.Authenticate(context.Background(), u.Username, "ldap-pass")
+	require.NoError(t, err)
+	stored, err = db.GetUserByID(u.ID)
+	require.NoError(t, err)
+	require.NoError(
+		t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("ldap-pass")),
+	)
+}
+
+func TestAuthChainEntry_ContinueAfter(t *testing.T) {
+	e := AuthChainEntry{ContinueOnNotFound: true}
+	assert.True(t, e.continueAfter(
+		fmt.Errorf("%w: %w", auth.ErrHTTPAPIAuthFailed, auth.ErrUserNotFound),
+	))
+	assert.False(t, e.continueAfter(auth.ErrInvalidCredentials))
+	assert.False(t, e.continueAfter(errors.New("connection refused")))
+}
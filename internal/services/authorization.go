@@ -37,6 +37,7 @@ var (
 	ErrPKCERequired            = errors.New("pkce required for public clients")
 	ErrAuthorizationNotFound   = errors.New("authorization not found")
 	ErrInvalidTarget           = errors.New("invalid_target")
+	ErrClientNotTrusted        = errors.New("client is not trusted")
 )
 
 // AuthorizationRequest holds validated parameters for an authorization request
@@ -184,6 +185,7 @@ func (s *AuthorizationService) SaveConsentAndAuthorizeDeviceCode(
 		Scopes:        scopes,
 		Resource:      models.StringArray(resource),
 		GrantedAt:     time.Now(),
+		ExpiresAt:     s.consentExpiresAt(ctx, clientID),
 		IsActive:      true,
 	}
 
@@ -227,6 +229,9 @@ func (s *AuthorizationService) SaveConsentAndAuthorizeDeviceCode(
 	if len(resource) > 0 {
 		consentDetails["resource"] = resource
 	}
+	if auth.ExpiresAt != nil {
+		consentDetails["expires_at"] = auth.ExpiresAt.UTC().Format(time.RFC3339)
+	}
 	s.auditService.Log(ctx, core.AuditLogEntry{
 		EventType:    models.EventUserAuthorizationGranted,
 		Severity:     models.SeverityInfo,
@@ -311,6 +316,11 @@ type CreateAuthorizationCodeParams struct {
 	// Resource is requested; a lookup is the fallback if a Resource is bound
 	// but Client was not supplied.
 	Client *models.OAuthApplication
+	// SessionID is the approving user's login session (sid). Tokens issued
+	// for this code are bound to it so logout revokes them. May be empty.
+	SessionID string
+	// AuthMethods are the "amr" values of the approving user's login. May be empty.
+	AuthMethods []string
 }
 
 // CreateAuthorizationCode generates a one-time authorization code and saves it to the database.
@@ -363,6 +373,8 @@ func (s *AuthorizationService) CreateAuthorizationCode(
 		CodeChallengeMethod: params.CodeChallengeMethod,
 		Nonce:               params.Nonce,
 		Resource:            models.StringArray(params.Resource),
+		SessionID:           params.SessionID,
+		AuthMethods:         models.StringArray(params.AuthMethods),
 		ExpiresAt:           time.Now().Add(s.config.AuthCodeExpiration),
 	}
 
@@ -493,8 +505,11 @@ func (s *AuthorizationService) ExchangeCode(
 }
 
 // GetUserAuthorization returns the active consent record for a (user, application) pair.
-// Returns nil, nil when no consent exists (not an error condition).
+// Returns nil, nil when no consent exists (not an error condition). A consent
+// past its ExpiresAt is expired on the spot — without waiting for the
+// background job — and reported as missing, so the user is asked again.
 func (s *AuthorizationService) GetUserAuthorization(
+	ctx context.Context,
 	userID string,
 	applicationID int64,
 ) (*models.UserAuthorization, error) {
@@ -502,31 +517,210 @@ func (s *AuthorizationService) GetUserAuthorization(
 	if err != nil {
 		return nil, nil //nolint:nilnil // nil UserAuthorization means "not found", which is not an error
 	}
+	if auth.IsExpired() {
+		if _, err := s.expireAuthorization(ctx, auth); err != nil {
+			log.Printf("[Authorization] %v", err)
+		}
+		return nil, nil //nolint:nilnil // an expired consent is treated as "not found"
+	}
 	return auth, nil
 }
 
+// consentExpiresAt returns when a consent granted now to clientID lapses:
+// the client's ConsentLifetimeDays when set, otherwise CONSENT_LIFETIME.
+// Nil means the consent never expires.
+func (s *AuthorizationService) consentExpiresAt(ctx context.Context, clientID string) *time.Time {
+	lifetime := s.config.ConsentLifetime
+	if client, err := s.clientService.GetClient(ctx, clientID); err == nil &&
+		client.ConsentLifetimeDays > 0 {
+		lifetime = time.Duration(client.ConsentLifetimeDays) * 24 * time.Hour
+	}
+	if lifetime <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(lifetime)
+	return &expiresAt
+}
+
+// consentExpiryBatchSize bounds how many lapsed consents one
+// ExpireStaleAuthorizations query loads at a time.
+const consentExpiryBatchSize = 100
+
+// ExpireStaleAuthorizations expires every active consent past its ExpiresAt,
+// revoking the tokens issued under it. Run periodically by the consent expiry
+// job. It returns the number of consents this call expired. The run stops at
+// the first consent it fails to expire: that row stays active, so listing
+// again would only return it once more.
+func (s *AuthorizationService) ExpireStaleAuthorizations(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		auths, err := s.store.ListExpiredUserAuthorizations(time.Now(), consentExpiryBatchSize)
+		if err != nil {
+			return expired, fmt.Errorf("failed to list expired authorizations: %w", err)
+		}
+		for i := range auths {
+			ok, err := s.expireAuthorization(ctx, &auths[i])
+			if err != nil {
+				return expired, err
+			}
+			if ok {
+				expired++
+			}
+		}
+		if len(auths) < consentExpiryBatchSize || ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+	}
+}
+
+// expireAuthorization deactivates a lapsed consent, revokes its tokens and
+// records the expiry. It reports false when the consent was already expired
+// (or renewed) by someone else, in which case nothing else is done.
+func (s *AuthorizationService) expireAuthorization(
+	ctx context.Context,
+	auth *models.UserAuthorization,
+) (bool, error) {
+	return expireUserAuthorization(ctx, s.store, s.auditService, s.tokenService, auth)
+}
+
+// expireUserAuthorization implements expireAuthorization for both the
+// authorization service and the refresh grant, which meets lapsed consents
+// of its own. tokens may be nil, in which case the token cache is left to
+// its TTL.
+func expireUserAuthorization(
+	ctx context.Context,
+	st core.Store,
+	auditService core.AuditLogger,
+	tokens *TokenService,
+	auth *models.UserAuthorization,
+) (bool, error) {
+	ok, err := st.ExpireUserAuthorization(auth.ID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to expire authorization=%d: %w", auth.ID, err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	revokeAuthorizationTokens(ctx, st, tokens, auth.ID)
+
+	auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventUserAuthorizationExpired,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  auth.UserID,
+		ResourceType: models.ResourceAuthorization,
+		ResourceID:   auth.UUID,
+		Action:       "User authorization for application expired",
+		Details: models.AuditDetails{
+			"client_id":  auth.ClientID,
+			"scopes":     auth.Scopes,
+			"granted_at": auth.GrantedAt.UTC().Format(time.RFC3339),
+			"expires_at": auth.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Success: true,
+	})
+	return true, nil
+}
+
+// revokeAuthorizationTokens revokes every active token issued under a
+// consent and drops them from the token cache. Failures are logged: the
+// consent itself is already gone, and cached tokens expire with their TTL.
+func revokeAuthorizationTokens(
+	ctx context.Context,
+	st core.Store,
+	tokens *TokenService,
+	authorizationID uint,
+) {
+	hashes, err := st.GetActiveTokenHashesByAuthorizationID(authorizationID)
+	if err != nil {
+		log.Printf(
+			"[TokenCache] WARNING: failed to collect token hashes for authorization=%d, "+
+				"revoked tokens may remain cached until TTL expires: %v",
+			authorizationID,
+			err,
+		)
+	}
+
+	// Cascade-revoke all tokens tied to this authorization
+	if revokeErr := st.RevokeTokensByAuthorizationID(authorizationID); revokeErr != nil {
+		log.Printf(
+			"[Authorization] failed to revoke tokens for authorization=%d: %v",
+			authorizationID,
+			revokeErr,
+		)
+	}
+
+	if len(hashes) > 0 && tokens != nil {
+		tokens.InvalidateTokenCacheByHashes(ctx, hashes)
+	}
+}
+
 // SaveUserAuthorization creates or updates the consent record for a
 // user+application pair. resource (RFC 8707) is persisted on the record so
 // the GET-side remembered-consent shortcut can require an EXACT resource-set
 // match before auto-approving — empty `resource` means "no audience binding
 // approved", and a later resource-bound request must NOT auto-approve off
-// that record (and vice versa).
+// that record (and vice versa). declinedScopes lists the optional scopes the
+// user unchecked, so a later request for them does not re-prompt.
 func (s *AuthorizationService) SaveUserAuthorization(
 	ctx context.Context,
 	userID string,
 	applicationID int64,
-	clientID, scopes string,
+	clientID, scopes, declinedScopes string,
+	resource []string,
+) (*models.UserAuthorization, error) {
+	return s.saveUserAuthorization(
+		ctx, userID, applicationID, clientID, scopes, declinedScopes, resource, false,
+	)
+}
+
+// GrantTrustedConsent records the consent a user implicitly gives a trusted
+// (first-party) client, which never shows a consent page. existing is the
+// user's current consent for the client, if any: when it already covers the
+// requested scopes and resource set it is returned unchanged, so signing in
+// again does not rewrite the record or the audit log. Otherwise a consent
+// for every requested scope is saved and audited as an implicit grant, so it
+// can still be listed and revoked like any other.
+func (s *AuthorizationService) GrantTrustedConsent(
+	ctx context.Context,
+	userID string,
+	req *AuthorizationRequest,
+	existing *models.UserAuthorization,
+) (*models.UserAuthorization, error) {
+	if !req.Client.Trusted {
+		return nil, ErrClientNotTrusted
+	}
+	if existing != nil && existing.IsActive &&
+		util.IsStringSliceSetEqual([]string(existing.Resource), req.Resource) &&
+		util.IsScopeSubset(existing.Scopes, req.Scopes) {
+		return existing, nil
+	}
+	return s.saveUserAuthorization(
+		ctx, userID, req.Client.ID, req.Client.ClientID, req.Scopes, "", req.Resource, true,
+	)
+}
+
+// saveUserAuthorization upserts a consent record and audits the grant.
+// implicit marks a grant made on the user's behalf for a trusted client.
+func (s *AuthorizationService) saveUserAuthorization(
+	ctx context.Context,
+	userID string,
+	applicationID int64,
+	clientID, scopes, declinedScopes string,
 	resource []string,
+	implicit bool,
 ) (*models.UserAuthorization, error) {
 	auth := &models.UserAuthorization{
-		UUID:          uuid.New().String(),
-		UserID:        userID,
-		ApplicationID: applicationID,
-		ClientID:      clientID,
-		Scopes:        scopes,
-		Resource:      models.StringArray(resource),
-		GrantedAt:     time.Now(),
-		IsActive:      true,
+		UUID:           uuid.New().String(),
+		UserID:         userID,
+		ApplicationID:  applicationID,
+		ClientID:       clientID,
+		Scopes:         scopes,
+		DeclinedScopes: declinedScopes,
+		Resource:       models.StringArray(resource),
+		GrantedAt:      time.Now(),
+		ExpiresAt:      s.consentExpiresAt(ctx, clientID),
+		IsActive:       true,
 	}
 
 	if err := s.store.UpsertUserAuthorization(auth); err != nil {
@@ -543,16 +737,27 @@ func (s *AuthorizationService) SaveUserAuthorization(
 		"client_id": clientID,
 		"scopes":    scopes,
 	}
+	if declinedScopes != "" {
+		details["declined_scopes"] = declinedScopes
+	}
 	if len(resource) > 0 {
 		details["resource"] = resource
 	}
+	if auth.ExpiresAt != nil {
+		details["expires_at"] = auth.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	action := "User granted authorization to application"
+	if implicit {
+		action = "Authorization implicitly granted to trusted application"
+		details["trusted_client"] = true
+	}
 	s.auditService.Log(ctx, core.AuditLogEntry{
 		EventType:    models.EventUserAuthorizationGranted,
 		Severity:     models.SeverityInfo,
 		ActorUserID:  userID,
 		ResourceType: models.ResourceAuthorization,
 		ResourceID:   stored.UUID,
-		Action:       "User granted authorization to application",
+		Action:       action,
 		Details:      details,
 		Success:      true,
 	})
@@ -571,28 +776,7 @@ func (s *AuthorizationService) RevokeUserAuthorization(
 		return ErrAuthorizationNotFound
 	}
 
-	hashes, err := s.store.GetActiveTokenHashesByAuthorizationID(revoked.ID)
-	if err != nil {
-		log.Printf(
-			"[TokenCache] WARNING: failed to collect token hashes for authorization=%d, "+
-				"revoked tokens may remain cached until TTL expires: %v",
-			revoked.ID,
-			err,
-		)
-	}
-
-	// Cascade-revoke all tokens tied to this authorization
-	if revokeErr := s.store.RevokeTokensByAuthorizationID(revoked.ID); revokeErr != nil {
-		log.Printf(
-			"[Authorization] failed to revoke tokens for authorization=%d: %v",
-			revoked.ID,
-			revokeErr,
-		)
-	}
-
-	if len(hashes) > 0 && s.tokenService != nil {
-		s.tokenService.InvalidateTokenCacheByHashes(ctx, hashes)
-	}
+	revokeAuthorizationTokens(ctx, s.store, s.tokenService, revoked.ID)
 
 	s.auditService.Log(ctx, core.AuditLogEntry{
 		EventType:    models.EventUserAuthorizationRevoked,
@@ -4,16 +4,21 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/mocks"
 	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -570,7 +575,7 @@ func TestSaveUserAuthorization_CreateAndUpsert(t *testing.T) {
 
 	// First save – creates record
 	auth, err := svc.SaveUserAuthorization(
-		context.Background(), userID, client.ID, client.ClientID, "read", nil,
+		context.Background(), userID, client.ID, client.ClientID, "read", "", nil,
 	)
 	require.NoError(t, err)
 	assert.True(t, auth.IsActive)
@@ -579,7 +584,7 @@ func TestSaveUserAuthorization_CreateAndUpsert(t *testing.T) {
 
 	// Second save with expanded scopes – should update, not duplicate
 	auth2, err := svc.SaveUserAuthorization(
-		context.Background(), userID, client.ID, client.ClientID, "read write", nil,
+		context.Background(), userID, client.ID, client.ClientID, "read write", "", nil,
 	)
 	require.NoError(t, err)
 	assert.Equal(t, "read write", auth2.Scopes)
@@ -600,13 +605,13 @@ func TestSaveUserAuthorization_PersistsResource(t *testing.T) {
 
 	resource := []string{"https://mcp.example.com"}
 	saved, err := svc.SaveUserAuthorization(
-		context.Background(), userID, client.ID, client.ClientID, "read", resource,
+		context.Background(), userID, client.ID, client.ClientID, "read", "", resource,
 	)
 	require.NoError(t, err)
 	assert.Equal(t, models.StringArray(resource), saved.Resource)
 
 	// Re-load to confirm the resource survives the DB round-trip.
-	loaded, err := svc.GetUserAuthorization(userID, client.ID)
+	loaded, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	require.NotNil(t, loaded)
 	assert.Equal(t, models.StringArray(resource), loaded.Resource)
@@ -618,17 +623,17 @@ func TestGetUserAuthorization_ExistsAndMissing(t *testing.T) {
 	userID := uuid.New().String()
 
 	// Before saving: should return nil, nil
-	auth, err := svc.GetUserAuthorization(userID, client.ID)
+	auth, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	assert.Nil(t, auth)
 
 	// After saving
 	_, err = svc.SaveUserAuthorization(
-		context.Background(), userID, client.ID, client.ClientID, "read", nil,
+		context.Background(), userID, client.ID, client.ClientID, "read", "", nil,
 	)
 	require.NoError(t, err)
 
-	auth, err = svc.GetUserAuthorization(userID, client.ID)
+	auth, err = svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	require.NotNil(t, auth)
 	assert.Equal(t, "read", auth.Scopes)
@@ -640,7 +645,7 @@ func TestRevokeUserAuthorization_Success(t *testing.T) {
 	userID := uuid.New().String()
 
 	auth, err := svc.SaveUserAuthorization(
-		context.Background(), userID, client.ID, client.ClientID, "read write", nil,
+		context.Background(), userID, client.ID, client.ClientID, "read write", "", nil,
 	)
 	require.NoError(t, err)
 
@@ -648,7 +653,7 @@ func TestRevokeUserAuthorization_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	// After revoke, GetUserAuthorization should return nil (no active record)
-	found, err := svc.GetUserAuthorization(userID, client.ID)
+	found, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	assert.Nil(t, found)
 }
@@ -660,7 +665,7 @@ func TestRevokeUserAuthorization_WrongUser(t *testing.T) {
 	otherID := uuid.New().String()
 
 	auth, err := svc.SaveUserAuthorization(
-		context.Background(), ownerID, client.ID, client.ClientID, "read", nil,
+		context.Background(), ownerID, client.ID, client.ClientID, "read", "", nil,
 	)
 	require.NoError(t, err)
 
@@ -688,7 +693,7 @@ func TestListUserAuthorizations_MultipleClients(t *testing.T) {
 		}
 		require.NoError(t, svc.store.CreateClient(c))
 		_, err := svc.SaveUserAuthorization(
-			context.Background(), userID, c.ID, c.ClientID, "read", nil,
+			context.Background(), userID, c.ID, c.ClientID, "read", "", nil,
 		)
 		require.NoError(t, err)
 	}
@@ -698,6 +703,220 @@ func TestListUserAuthorizations_MultipleClients(t *testing.T) {
 	assert.Len(t, auths, 3)
 }
 
+// ============================================================
+// Consent expiry
+// ============================================================
+
+// createConsentExpiryEnv builds an authorization service whose consents last
+// lifetime, returning the concrete store so tests can backdate records.
+func createConsentExpiryEnv(
+	t *testing.T,
+	lifetime time.Duration,
+) (*AuthorizationService, *store.Store) {
+	t.Helper()
+	s := setupTestStore(t)
+	cfg := &config.Config{
+		AuthCodeExpiration: 10 * time.Minute,
+		ConsentRemember:    true,
+		ConsentLifetime:    lifetime,
+	}
+	svc := NewAuthorizationService(
+		s,
+		cfg,
+		NewNoopAuditService(),
+		nil,
+		NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0),
+	)
+	return svc, s
+}
+
+// backdateConsent moves a consent's ExpiresAt into the past.
+func backdateConsent(t *testing.T, s *store.Store, auth *models.UserAuthorization) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, s.DB().Model(&models.UserAuthorization{}).
+		Where("id = ?", auth.ID).
+		Update("expires_at", past).Error)
+}
+
+// createConsentToken issues an active access token under a consent.
+func createConsentToken(
+	t *testing.T,
+	s *store.Store,
+	auth *models.UserAuthorization,
+) *models.AccessToken {
+	t.Helper()
+	authID := auth.ID
+	tok := &models.AccessToken{
+		ID:              uuid.New().String(),
+		TokenHash:       uuid.New().String(),
+		TokenCategory:   models.TokenCategoryAccess,
+		Status:          models.TokenStatusActive,
+		UserID:          auth.UserID,
+		ClientID:        auth.ClientID,
+		Scopes:          auth.Scopes,
+		ExpiresAt:       time.Now().Add(time.Hour),
+		AuthorizationID: &authID,
+	}
+	require.NoError(t, s.CreateAccessToken(tok))
+	return tok
+}
+
+func TestSaveUserAuthorization_NoLifetimeNeverExpires(t *testing.T) {
+	svc, _ := createConsentExpiryEnv(t, 0)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+
+	auth, err := svc.SaveUserAuthorization(
+		context.Background(), uuid.New().String(), client.ID, client.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	assert.Nil(t, auth.ExpiresAt)
+}
+
+func TestSaveUserAuthorization_LifetimeSetsExpiresAt(t *testing.T) {
+	svc, s := createConsentExpiryEnv(t, 24*time.Hour)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+
+	auth, err := svc.SaveUserAuthorization(
+		context.Background(), uuid.New().String(), client.ID, client.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, auth.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *auth.ExpiresAt, time.Minute)
+
+	// A per-client lifetime overrides the global one.
+	override := createAuthCodeFlowClient(t, svc, "confidential")
+	override.ConsentLifetimeDays = 7
+	require.NoError(t, s.UpdateClient(override))
+	auth, err = svc.SaveUserAuthorization(
+		context.Background(), uuid.New().String(), override.ID, override.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, auth.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *auth.ExpiresAt, time.Minute)
+}
+
+func TestGetUserAuthorization_ExpiresLapsedConsent(t *testing.T) {
+	svc, s := createConsentExpiryEnv(t, time.Hour)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+	userID := uuid.New().String()
+
+	auth, err := svc.SaveUserAuthorization(
+		context.Background(), userID, client.ID, client.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	tok := createConsentToken(t, s, auth)
+	backdateConsent(t, s, auth)
+
+	found, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	stored, err := s.GetAccessTokenByID(tok.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, stored.Status)
+	assert.Empty(t, mustUserAuthorizations(t, svc, userID))
+}
+
+func TestExpireStaleAuthorizations_ExpiresOnlyLapsedConsents(t *testing.T) {
+	svc, s := createConsentExpiryEnv(t, time.Hour)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+	lapsedUser := uuid.New().String()
+	currentUser := uuid.New().String()
+
+	lapsed, err := svc.SaveUserAuthorization(
+		context.Background(), lapsedUser, client.ID, client.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	_, err = svc.SaveUserAuthorization(
+		context.Background(), currentUser, client.ID, client.ClientID, "read", "", nil,
+	)
+	require.NoError(t, err)
+	tok := createConsentToken(t, s, lapsed)
+	backdateConsent(t, s, lapsed)
+
+	expired, err := svc.ExpireStaleAuthorizations(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	stored, err := s.GetAccessTokenByID(tok.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, stored.Status)
+	assert.Empty(t, mustUserAuthorizations(t, svc, lapsedUser))
+	assert.Len(t, mustUserAuthorizations(t, svc, currentUser), 1)
+
+	// A second run finds nothing left to expire.
+	expired, err = svc.ExpireStaleAuthorizations(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestRefreshAccessToken_LapsedConsentWithoutExpiryJob(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := configWithRefreshLifecycle(false, false)
+	cfg.ConsentRemember = true
+	cfg.ConsentLifetime = time.Hour
+	cfg.ConsentExpiryCheckInterval = 0
+	tokenService := createTestTokenService(t, s, cfg)
+	svc := NewAuthorizationService(s, cfg, NewNoopAuditService(), tokenService,
+		NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0))
+
+	client := createTestClientWithProfile(t, tokenService, models.TokenProfileStandard)
+	dc := authorizedDeviceCodeWithResource(t, s, client.ClientID, nil)
+	auth, err := svc.SaveUserAuthorization(
+		context.Background(), dc.UserID, client.ID, client.ClientID, dc.Scopes, "", nil,
+	)
+	require.NoError(t, err)
+	_, refresh, err := tokenService.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+	backdateConsent(t, s, auth)
+
+	_, _, err = tokenService.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, dc.Scopes, nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrExpiredRefreshToken)
+
+	stored, err := s.GetAccessTokenByID(refresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, stored.Status)
+	assert.Empty(t, mustUserAuthorizations(t, svc, dc.UserID))
+}
+
+func TestExpireStaleAuthorizations_StopsOnWriteFailure(t *testing.T) {
+	mockStore := mocks.NewMockStore(gomock.NewController(t))
+	svc := NewAuthorizationService(mockStore, &config.Config{}, nil, nil, nil)
+
+	// A full batch that cannot be written would be listed again and again.
+	batch := make([]models.UserAuthorization, consentExpiryBatchSize)
+	for i := range batch {
+		batch[i].ID = uint(i + 1)
+	}
+	mockStore.EXPECT().
+		ListExpiredUserAuthorizations(gomock.Any(), consentExpiryBatchSize).
+		Return(batch, nil).
+		Times(1)
+	mockStore.EXPECT().
+		ExpireUserAuthorization(uint(1), gomock.Any()).
+		Return(false, errors.New("read-only transaction"))
+
+	expired, err := svc.ExpireStaleAuthorizations(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func mustUserAuthorizations(
+	t *testing.T,
+	svc *AuthorizationService,
+	userID string,
+) []UserAuthorizationWithClient {
+	t.Helper()
+	auths, err := svc.ListUserAuthorizations(context.Background(), userID)
+	require.NoError(t, err)
+	return auths
+}
+
 // ============================================================
 // RevokeAllApplicationTokens (admin P6)
 // ============================================================
@@ -710,7 +929,7 @@ func TestRevokeAllApplicationTokens_RevokesConsentRecords(t *testing.T) {
 	for range 2 {
 		userID := uuid.New().String()
 		_, err := svc.SaveUserAuthorization(
-			context.Background(), userID, client.ID, client.ClientID, "read", nil,
+			context.Background(), userID, client.ID, client.ClientID, "read", "", nil,
 		)
 		require.NoError(t, err)
 	}
@@ -925,7 +1144,7 @@ func TestListClientAuthorizations_MultipleUsers(t *testing.T) {
 	for i := range userIDs {
 		userIDs[i] = uuid.New().String()
 		_, err := svc.SaveUserAuthorization(
-			context.Background(), userIDs[i], client.ID, client.ClientID, "read", nil,
+			context.Background(), userIDs[i], client.ID, client.ClientID, "read", "", nil,
 		)
 		require.NoError(t, err)
 	}
@@ -1047,7 +1266,7 @@ func TestSaveConsentAndAuthorizeDeviceCode_HappyPath(t *testing.T) {
 	assert.Equal(t, userID, authorized.UserID)
 
 	// Consent is queryable by (user, app).
-	loaded, err := svc.GetUserAuthorization(userID, client.ID)
+	loaded, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	require.NotNil(t, loaded)
 	assert.Equal(t, ua.UUID, loaded.UUID)
@@ -1078,7 +1297,7 @@ func TestSaveConsentAndAuthorizeDeviceCode_RollsBackOnExpiry(t *testing.T) {
 	require.ErrorIs(t, err, ErrDeviceCodeExpired)
 
 	// Critical invariant: the upsert is rolled back — no stale consent left.
-	leaked, err := svc.GetUserAuthorization(userID, client.ID)
+	leaked, err := svc.GetUserAuthorization(context.Background(), userID, client.ID)
 	require.NoError(t, err)
 	assert.Nil(t, leaked,
 		"consent must roll back when expiry check inside the txn fails")
@@ -1121,7 +1340,55 @@ func TestSaveConsentAndAuthorizeDeviceCode_RollsBackOnAlreadyAuthorized(t *testi
 	// Critical invariant: the loser's consent must NOT exist. If the upsert
 	// were allowed to commit before AuthorizeDeviceCode failed, this lookup
 	// would return a stale row.
-	leaked, err := svc.GetUserAuthorization(loserID, client.ID)
+	leaked, err := svc.GetUserAuthorization(context.Background(), loserID, client.ID)
 	require.NoError(t, err)
 	assert.Nil(t, leaked, "consent must roll back when AuthorizeDeviceCode fails")
 }
+
+// ============================================================
+// Trusted (first-party) clients
+// ============================================================
+
+func TestGrantTrustedConsent_RejectsUntrustedClient(t *testing.T) {
+	svc := createTestAuthorizationService(t)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+
+	_, err := svc.GrantTrustedConsent(
+		context.Background(), uuid.New().String(),
+		&AuthorizationRequest{Client: client, Scopes: "read"}, nil,
+	)
+	require.ErrorIs(t, err, ErrClientNotTrusted)
+}
+
+func TestGrantTrustedConsent_RecordsAndReusesConsent(t *testing.T) {
+	svc := createTestAuthorizationService(t)
+	client := createAuthCodeFlowClient(t, svc, "confidential")
+	client.Trusted = true
+	userID := uuid.New().String()
+	ctx := context.Background()
+
+	first, err := svc.GrantTrustedConsent(
+		ctx, userID, &AuthorizationRequest{Client: client, Scopes: "read write"}, nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "read write", first.Scopes)
+	assert.True(t, first.IsActive)
+
+	// A request the consent already covers reuses it as-is.
+	again, err := svc.GrantTrustedConsent(
+		ctx, userID, &AuthorizationRequest{Client: client, Scopes: "read"}, first,
+	)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	// A different resource set records a new grant.
+	resource := []string{"https://mcp.example.com"}
+	bound, err := svc.GrantTrustedConsent(
+		ctx, userID,
+		&AuthorizationRequest{Client: client, Scopes: "read", Resource: resource},
+		first,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray(resource), bound.Resource)
+	assert.Equal(t, "read", bound.Scopes)
+}
@@ -77,6 +77,47 @@ var (
 		models.TokenProfileStandard,
 		models.TokenProfileLong,
 	)
+	ErrInvalidTokenFormat = fmt.Errorf(
+		"token format must be empty, %q, or %q",
+		models.TokenFormatJWT,
+		models.TokenFormatOpaque,
+	)
+	ErrInvalidRefreshFamilyLimit  = errors.New("max refresh families must be 0 (inherit) or a positive number")
+	ErrInvalidRefreshFamilyPolicy = fmt.Errorf(
+		"refresh family policy must be empty, %q, or %q",
+		models.RefreshFamilyPolicyEvictOldest,
+		models.RefreshFamilyPolicyReject,
+	)
+	ErrInvalidUserCodeCharset = fmt.Errorf(
+		"device user code charset must be empty, %q, %q, or %q",
+		models.UserCodeCharsetAlphanumeric,
+		models.UserCodeCharsetDigits,
+		models.UserCodeCharsetLetters,
+	)
+	ErrInvalidUserCodeLength = fmt.Errorf(
+		"device user code length must be 0 (default) or %d–%d characters (at least %d for digits)",
+		minUserCodeLength, maxUserCodeLength, minDigitsUserCodeLength,
+	)
+	ErrInvalidDeviceCodeLifetime = fmt.Errorf(
+		"device code lifetime must be 0 (inherit) or %d–%d seconds",
+		minDeviceCodeLifetime, maxDeviceCodeLifetime,
+	)
+	ErrInvalidDevicePollingInterval = fmt.Errorf(
+		"device polling interval must be 0 (inherit) or %d–%d seconds",
+		minDevicePollingInterval, maxDevicePollingInterval,
+	)
+	ErrInvalidConsentLifetime = errors.New(
+		"consent lifetime must be 0 (inherit) or a positive number of days",
+	)
+	ErrInvalidRequiredScopes = errors.New(
+		"required scopes must be a subset of the client's scopes",
+	)
+	ErrTrustedClientAdminOnly = errors.New(
+		"only administrators can mark a client as trusted",
+	)
+	ErrTrustedClientUserOwned = errors.New(
+		"only clients owned by an administrator can be marked as trusted",
+	)
 	ErrInvalidProject = errors.New(
 		"project must be empty or 1–64 characters of letters, digits, underscore, dot, or hyphen, " +
 			"and start/end with a letter or digit",
@@ -233,6 +274,7 @@ type CreateClientRequest struct {
 	Description                 string
 	UserID                      string
 	Scopes                      string
+	RequiredScopes              string // Scopes users cannot deselect on the consent page; must be a subset of Scopes
 	RedirectURIs                []string
 	AllowedResources            []string // RFC 8707 allowlist; each entry validated via util.ValidateResourceIndicators. Empty = deny-all.
 	CreatedBy                   string
@@ -242,6 +284,15 @@ type CreateClientRequest struct {
 	EnableClientCredentialsFlow bool   // Enable Client Credentials Grant (RFC 6749 §4.4); confidential clients only
 	IsAdminCreated              bool   // When true: Status=active; when false: Status=pending
 	TokenProfile                string // "short" / "standard" / "long"; empty = standard
+	TokenFormat                 string // "jwt" / "opaque"; empty inherits the global TOKEN_FORMAT
+	MaxRefreshFamilies          int    // Active refresh token families per user; 0 inherits the profile / global limit
+	RefreshFamilyPolicy         string // "evict_oldest" / "reject"; empty inherits the global REFRESH_FAMILY_LIMIT_POLICY
+	DeviceUserCodeCharset       string // "alphanumeric" / "digits" / "letters"; empty = alphanumeric
+	DeviceUserCodeLength        int    // User code length; 0 = default (8)
+	DeviceCodeLifetime          int    // Seconds; 0 inherits DEVICE_CODE_EXPIRATION
+	DevicePollingInterval       int    // Seconds; 0 inherits POLLING_INTERVAL
+	ConsentLifetimeDays         int    // Days before user consent expires; 0 inherits CONSENT_LIFETIME
+	Trusted                     bool   // First-party client that skips the consent page; requires IsAdminCreated
 	Project                     string // Optional; injected as JWT "project" claim. Validated by util.IsValidProjectIdentifier.
 	ServiceAccount              string // Optional; injected as JWT "service_account" claim. Validated by serviceAccountPattern.
 }
@@ -250,6 +301,7 @@ type UpdateClientRequest struct {
 	ClientName                  string
 	Description                 string
 	Scopes                      string
+	RequiredScopes              string // Scopes users cannot deselect on the consent page; must be a subset of Scopes
 	RedirectURIs                []string
 	AllowedResources            []string // RFC 8707 allowlist; each entry validated via util.ValidateResourceIndicators. Empty = deny-all.
 	Status                      string   // "active" or "inactive"
@@ -258,6 +310,15 @@ type UpdateClientRequest struct {
 	EnableAuthCodeFlow          bool
 	EnableClientCredentialsFlow bool   // Enable Client Credentials Grant (RFC 6749 §4.4); confidential clients only
 	TokenProfile                string // "short" / "standard" / "long"; empty = standard
+	TokenFormat                 string // "jwt" / "opaque"; empty inherits the global TOKEN_FORMAT
+	MaxRefreshFamilies          int    // Active refresh token families per user; 0 inherits the profile / global limit
+	RefreshFamilyPolicy         string // "evict_oldest" / "reject"; empty inherits the global REFRESH_FAMILY_LIMIT_POLICY
+	DeviceUserCodeCharset       string // "alphanumeric" / "digits" / "letters"; empty = alphanumeric
+	DeviceUserCodeLength        int    // User code length; 0 = default (8)
+	DeviceCodeLifetime          int    // Seconds; 0 inherits DEVICE_CODE_EXPIRATION
+	DevicePollingInterval       int    // Seconds; 0 inherits POLLING_INTERVAL
+	ConsentLifetimeDays         int    // Days before user consent expires; 0 inherits CONSENT_LIFETIME
+	Trusted                     bool   // First-party client that skips the consent page; the client's owner must be an administrator
 	Project                     string // Optional; injected as JWT "project" claim. Validated by util.IsValidProjectIdentifier.
 	ServiceAccount              string // Optional; injected as JWT "service_account" claim. Validated by serviceAccountPattern.
 }
@@ -273,6 +334,89 @@ func normalizeTokenProfile(p string) (string, error) {
 	return resolved, nil
 }
 
+// normalizeTokenFormat trims and validates an incoming token format value.
+// Unlike TokenProfile, empty is kept as-is: it means "inherit the global
+// TOKEN_FORMAT" so a later change to the deployment default still applies.
+func normalizeTokenFormat(f string) (string, error) {
+	f = strings.TrimSpace(f)
+	if f != "" && !models.IsValidTokenFormat(f) {
+		return "", ErrInvalidTokenFormat
+	}
+	return f, nil
+}
+
+// normalizeRefreshFamilyLimit validates an incoming per-client refresh family
+// limit and policy. Empty policy is kept as-is so the client keeps following
+// the deployment default.
+func normalizeRefreshFamilyLimit(limit int, policy string) (string, error) {
+	if limit < 0 {
+		return "", ErrInvalidRefreshFamilyLimit
+	}
+	policy = strings.TrimSpace(policy)
+	if policy != "" && !models.IsValidRefreshFamilyPolicy(policy) {
+		return "", ErrInvalidRefreshFamilyPolicy
+	}
+	return policy, nil
+}
+
+// normalizeDeviceFlowPolicy validates an incoming per-client device flow
+// policy. Zero values (and an empty charset) are kept as-is so the client
+// keeps following the deployment defaults.
+func normalizeDeviceFlowPolicy(charset string, length, lifetime, interval int) (string, error) {
+	charset = strings.TrimSpace(charset)
+	if charset != "" && !models.IsValidUserCodeCharset(charset) {
+		return "", ErrInvalidUserCodeCharset
+	}
+	if length != 0 {
+		minLength := minUserCodeLength
+		if charset == models.UserCodeCharsetDigits {
+			minLength = minDigitsUserCodeLength
+		}
+		if length < minLength || length > maxUserCodeLength {
+			return "", ErrInvalidUserCodeLength
+		}
+	}
+	if lifetime != 0 && (lifetime < minDeviceCodeLifetime || lifetime > maxDeviceCodeLifetime) {
+		return "", ErrInvalidDeviceCodeLifetime
+	}
+	if interval != 0 &&
+		(interval < minDevicePollingInterval || interval > maxDevicePollingInterval) {
+		return "", ErrInvalidDevicePollingInterval
+	}
+	return charset, nil
+}
+
+// normalizeRequiredScopes validates the scopes a client marks as required
+// on the consent page against its allowed scopes, dropping duplicates.
+func normalizeRequiredScopes(required, scopes string) (string, error) {
+	allowed := util.ScopeSet(scopes)
+	seen := make(map[string]bool)
+	var fields []string
+	for scope := range strings.FieldsSeq(required) {
+		if !allowed[scope] {
+			return "", ErrInvalidRequiredScopes
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			fields = append(fields, scope)
+		}
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// retainScopes returns the scopes of required that are still in scopes, for
+// edits that narrow a client's scopes without touching its required scopes.
+func retainScopes(required, scopes string) string {
+	allowed := util.ScopeSet(scopes)
+	var kept []string
+	for scope := range strings.FieldsSeq(required) {
+		if allowed[scope] {
+			kept = append(kept, scope)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
 type ClientResponse struct {
 	*models.OAuthApplication
 	ClientSecretPlain string // Only populated on creation
@@ -310,6 +454,23 @@ func (s *ClientService) CreateClient(
 	if err != nil {
 		return nil, err
 	}
+	tokenFormat, err := normalizeTokenFormat(req.TokenFormat)
+	if err != nil {
+		return nil, err
+	}
+	familyPolicy, err := normalizeRefreshFamilyLimit(req.MaxRefreshFamilies, req.RefreshFamilyPolicy)
+	if err != nil {
+		return nil, err
+	}
+	userCodeCharset, err := normalizeDeviceFlowPolicy(
+		req.DeviceUserCodeCharset,
+		req.DeviceUserCodeLength,
+		req.DeviceCodeLifetime,
+		req.DevicePollingInterval,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	project := strings.TrimSpace(req.Project)
 	if err := validateProject(project); err != nil {
@@ -331,6 +492,16 @@ func (s *ClientService) CreateClient(
 	if scopes == "" {
 		scopes = "email profile"
 	}
+	requiredScopes, err := normalizeRequiredScopes(req.RequiredScopes, scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ConsentLifetimeDays < 0 {
+		return nil, ErrInvalidConsentLifetime
+	}
+	if req.Trusted && !req.IsAdminCreated {
+		return nil, ErrTrustedClientAdminOnly
+	}
 
 	enableClientCredentials := req.EnableClientCredentialsFlow
 
@@ -357,6 +528,7 @@ func (s *ClientService) CreateClient(
 		Description:                 strings.TrimSpace(req.Description),
 		UserID:                      req.UserID,
 		Scopes:                      scopes,
+		RequiredScopes:              requiredScopes,
 		GrantTypes:                  grantTypes,
 		RedirectURIs:                models.StringArray(req.RedirectURIs),
 		AllowedResources:            models.StringArray(req.AllowedResources),
@@ -366,10 +538,22 @@ func (s *ClientService) CreateClient(
 		EnableClientCredentialsFlow: enableClientCredentials,
 		Status:                      clientStatus,
 		TokenProfile:                tokenProfile,
+		TokenFormat:                 tokenFormat,
+		MaxRefreshFamilies:          req.MaxRefreshFamilies,
+		RefreshFamilyPolicy:         familyPolicy,
+		DeviceUserCodeCharset:       userCodeCharset,
+		DeviceUserCodeLength:        req.DeviceUserCodeLength,
+		DeviceCodeLifetime:          req.DeviceCodeLifetime,
+		DevicePollingInterval:       req.DevicePollingInterval,
+		ConsentLifetimeDays:         req.ConsentLifetimeDays,
+		Trusted:                     req.Trusted,
 		Project:                     project,
 		ServiceAccount:              serviceAccount,
 		CreatedBy:                   req.CreatedBy,
 	}
+	if req.Trusted && !s.isAdminOwnedClient(client) {
+		return nil, ErrTrustedClientUserOwned
+	}
 
 	// Generate client secret
 	clientSecret, err := client.GenerateClientSecret(ctx)
@@ -396,10 +580,23 @@ func (s *ClientService) CreateClient(
 		ResourceName: client.ClientName,
 		Action:       "OAuth client created",
 		Details: models.AuditDetails{
-			"client_name":   client.ClientName,
-			"grant_types":   client.GrantTypes,
-			"scopes":        client.Scopes,
-			"token_profile": client.TokenProfile,
+			"client_name":     client.ClientName,
+			"grant_types":     client.GrantTypes,
+			"scopes":          client.Scopes,
+			"required_scopes": client.RequiredScopes,
+			"token_profile":   client.TokenProfile,
+			"token_format":    client.TokenFormat,
+
+			"max_refresh_families":  client.MaxRefreshFamilies,
+			"refresh_family_policy": client.RefreshFamilyPolicy,
+
+			"device_user_code_charset": client.DeviceUserCodeCharset,
+			"device_user_code_length":  client.DeviceUserCodeLength,
+			"device_code_lifetime":     client.DeviceCodeLifetime,
+			"device_polling_interval":  client.DevicePollingInterval,
+
+			"consent_lifetime_days": client.ConsentLifetimeDays,
+			"trusted":               client.Trusted,
 		},
 		Success: true,
 	})
@@ -441,6 +638,23 @@ func (s *ClientService) UpdateClient(
 	if err != nil {
 		return err
 	}
+	tokenFormat, err := normalizeTokenFormat(req.TokenFormat)
+	if err != nil {
+		return err
+	}
+	familyPolicy, err := normalizeRefreshFamilyLimit(req.MaxRefreshFamilies, req.RefreshFamilyPolicy)
+	if err != nil {
+		return err
+	}
+	userCodeCharset, err := normalizeDeviceFlowPolicy(
+		req.DeviceUserCodeCharset,
+		req.DeviceUserCodeLength,
+		req.DeviceCodeLifetime,
+		req.DevicePollingInterval,
+	)
+	if err != nil {
+		return err
+	}
 
 	project := strings.TrimSpace(req.Project)
 	if err := validateProject(project); err != nil {
@@ -453,11 +667,21 @@ func (s *ClientService) UpdateClient(
 	if err := validateAllowedResources(req.AllowedResources); err != nil {
 		return err
 	}
+	requiredScopes, err := normalizeRequiredScopes(req.RequiredScopes, req.Scopes)
+	if err != nil {
+		return err
+	}
+	if req.ConsentLifetimeDays < 0 {
+		return ErrInvalidConsentLifetime
+	}
 
 	client, err := s.store.GetClient(clientID)
 	if err != nil {
 		return ErrClientNotFound
 	}
+	if req.Trusted && !s.isAdminOwnedClient(client) {
+		return ErrTrustedClientUserOwned
+	}
 
 	switch req.Status {
 	case models.ClientStatusActive, models.ClientStatusInactive, models.ClientStatusPending:
@@ -471,15 +695,27 @@ func (s *ClientService) UpdateClient(
 		req.Status == models.ClientStatusPending
 
 	previousTokenProfile := client.TokenProfile
+	previousTokenFormat := client.TokenFormat
+	previousTrusted := client.Trusted
 
 	client.ClientName = strings.TrimSpace(req.ClientName)
 	client.Description = strings.TrimSpace(req.Description)
 	client.Scopes = strings.TrimSpace(req.Scopes)
+	client.RequiredScopes = requiredScopes
 	client.RedirectURIs = models.StringArray(req.RedirectURIs)
 	client.AllowedResources = models.StringArray(req.AllowedResources)
 	client.Status = req.Status
 	client.ClientType = clientType.String()
 	client.TokenProfile = tokenProfile
+	client.TokenFormat = tokenFormat
+	client.MaxRefreshFamilies = req.MaxRefreshFamilies
+	client.RefreshFamilyPolicy = familyPolicy
+	client.DeviceUserCodeCharset = userCodeCharset
+	client.DeviceUserCodeLength = req.DeviceUserCodeLength
+	client.DeviceCodeLifetime = req.DeviceCodeLifetime
+	client.DevicePollingInterval = req.DevicePollingInterval
+	client.ConsentLifetimeDays = req.ConsentLifetimeDays
+	client.Trusted = req.Trusted
 	client.Project = project
 	client.ServiceAccount = serviceAccount
 
@@ -509,20 +745,43 @@ func (s *ClientService) UpdateClient(
 	// every future token issued to this client — so flag it at WARNING when
 	// the effective value changes. Normalize the previous value the same way
 	// as the rest of the system so a pre-migration row moving "" → short/long
-	// still audits cleanly.
+	// still audits cleanly. A TokenFormat change is flagged the same way: it
+	// decides whether resource servers can verify this client's tokens offline.
+	// So is a Trusted change, since it decides whether users see a consent page.
 	previousNormalized := models.ResolveTokenProfile(previousTokenProfile)
 	severity := models.SeverityInfo
 	details := models.AuditDetails{
-		"client_name":   client.ClientName,
-		"status":        client.Status,
-		"grant_types":   client.GrantTypes,
-		"scopes":        client.Scopes,
-		"token_profile": client.TokenProfile,
+		"client_name":     client.ClientName,
+		"status":          client.Status,
+		"grant_types":     client.GrantTypes,
+		"scopes":          client.Scopes,
+		"required_scopes": client.RequiredScopes,
+		"token_profile":   client.TokenProfile,
+		"token_format":    client.TokenFormat,
+
+		"max_refresh_families":  client.MaxRefreshFamilies,
+		"refresh_family_policy": client.RefreshFamilyPolicy,
+
+		"device_user_code_charset": client.DeviceUserCodeCharset,
+		"device_user_code_length":  client.DeviceUserCodeLength,
+		"device_code_lifetime":     client.DeviceCodeLifetime,
+		"device_polling_interval":  client.DevicePollingInterval,
+
+		"consent_lifetime_days": client.ConsentLifetimeDays,
+		"trusted":               client.Trusted,
 	}
 	if previousNormalized != client.TokenProfile {
 		severity = models.SeverityWarning
 		details["previous_token_profile"] = previousNormalized
 	}
+	if previousTokenFormat != client.TokenFormat {
+		severity = models.SeverityWarning
+		details["previous_token_format"] = previousTokenFormat
+	}
+	if previousTrusted != client.Trusted {
+		severity = models.SeverityWarning
+		details["previous_trusted"] = previousTrusted
+	}
 
 	s.auditService.Log(ctx, core.AuditLogEntry{
 		EventType:    models.EventClientUpdated,
@@ -539,6 +798,17 @@ func (s *ClientService) UpdateClient(
 	return nil
 }
 
+// isAdminOwnedClient reports whether client belongs to an administrator.
+// Clients created through self-service or dynamic registration belong to a
+// regular user or to nobody, and must never skip the consent page.
+func (s *ClientService) isAdminOwnedClient(client *models.OAuthApplication) bool {
+	if client.UserID == "" {
+		return false
+	}
+	owner, err := s.store.GetUserByID(client.UserID)
+	return err == nil && owner.IsAdmin()
+}
+
 func (s *ClientService) DeleteClient(ctx context.Context, clientID, actorUserID string) error {
 	// Check if client exists
 	client, err := s.store.GetClient(clientID)
@@ -474,6 +474,208 @@ func TestCreateClient_NameRequired(t *testing.T) {
 	assert.ErrorIs(t, err, ErrClientNameRequired)
 }
 
+func TestCreateClient_RefreshFamilyLimit(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+
+	resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:          "Limited",
+		UserID:              uuid.New().String(),
+		MaxRefreshFamilies:  5,
+		RefreshFamilyPolicy: " reject ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.MaxRefreshFamilies)
+	assert.Equal(t, models.RefreshFamilyPolicyReject, resp.RefreshFamilyPolicy)
+
+	_, err = svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:         "Negative",
+		UserID:             uuid.New().String(),
+		MaxRefreshFamilies: -1,
+	})
+	require.ErrorIs(t, err, ErrInvalidRefreshFamilyLimit)
+
+	_, err = svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:          "Bad Policy",
+		UserID:              uuid.New().String(),
+		RefreshFamilyPolicy: "drop_newest",
+	})
+	require.ErrorIs(t, err, ErrInvalidRefreshFamilyPolicy)
+}
+
+func TestCreateClient_DeviceFlowPolicy(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+
+	resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:            "TV App",
+		UserID:                uuid.New().String(),
+		EnableDeviceFlow:      true,
+		DeviceUserCodeCharset: " digits ",
+		DeviceUserCodeLength:  8,
+		DeviceCodeLifetime:    600,
+		DevicePollingInterval: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserCodeCharsetDigits, resp.DeviceUserCodeCharset)
+	assert.Equal(t, 8, resp.DeviceUserCodeLength)
+	assert.Equal(t, 600, resp.DeviceCodeLifetime)
+	assert.Equal(t, 10, resp.DevicePollingInterval)
+
+	tests := []struct {
+		name string
+		req  CreateClientRequest
+		want error
+	}{
+		{"unknown charset", CreateClientRequest{DeviceUserCodeCharset: "emoji"}, ErrInvalidUserCodeCharset},
+		{"code too short", CreateClientRequest{DeviceUserCodeLength: 5}, ErrInvalidUserCodeLength},
+		{"code too long", CreateClientRequest{DeviceUserCodeLength: 13}, ErrInvalidUserCodeLength},
+		{
+			"short digits code",
+			CreateClientRequest{
+				DeviceUserCodeCharset: models.UserCodeCharsetDigits,
+				DeviceUserCodeLength:  6,
+			},
+			ErrInvalidUserCodeLength,
+		},
+		{"lifetime too short", CreateClientRequest{DeviceCodeLifetime: 30}, ErrInvalidDeviceCodeLifetime},
+		{"lifetime too long", CreateClientRequest{DeviceCodeLifetime: 7200}, ErrInvalidDeviceCodeLifetime},
+		{"negative interval", CreateClientRequest{DevicePollingInterval: -1}, ErrInvalidDevicePollingInterval},
+		{"interval too long", CreateClientRequest{DevicePollingInterval: 61}, ErrInvalidDevicePollingInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ClientName = "Bad Device Policy"
+			tt.req.UserID = uuid.New().String()
+			_, err := svc.CreateClient(context.Background(), tt.req)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestCreateClient_RequiredScopes(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+
+	resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:     "Consent App",
+		UserID:         uuid.New().String(),
+		Scopes:         "openid profile email",
+		RequiredScopes: " profile profile ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "profile", resp.RequiredScopes)
+
+	_, err = svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:     "Consent App",
+		UserID:         uuid.New().String(),
+		Scopes:         "openid profile",
+		RequiredScopes: "email",
+	})
+	require.ErrorIs(t, err, ErrInvalidRequiredScopes)
+}
+
+func TestCreateClient_ConsentLifetimeDays(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+
+	resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:          "Expiring Consent App",
+		UserID:              uuid.New().String(),
+		ConsentLifetimeDays: 30,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 30, resp.ConsentLifetimeDays)
+
+	_, err = svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:          "Expiring Consent App",
+		UserID:              uuid.New().String(),
+		ConsentLifetimeDays: -1,
+	})
+	require.ErrorIs(t, err, ErrInvalidConsentLifetime)
+}
+
+func TestCreateClient_TrustedRequiresAdmin(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+	admin := makeTestAdmin(t, s)
+
+	// User-created and dynamically registered clients are never admin-created.
+	_, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName: "Self-Trusted App",
+		UserID:     admin.ID,
+		Trusted:    true,
+	})
+	require.ErrorIs(t, err, ErrTrustedClientAdminOnly)
+
+	// An admin may only trust clients that an admin owns.
+	for name, ownerID := range map[string]string{
+		"non-admin owner": makeTestUser(t, s).ID,
+		"unknown owner":   uuid.New().String(),
+		"no owner":        "",
+	} {
+		_, err = svc.CreateClient(context.Background(), CreateClientRequest{
+			ClientName:     "Internal App",
+			UserID:         ownerID,
+			Trusted:        true,
+			IsAdminCreated: true,
+		})
+		require.ErrorIs(t, err, ErrTrustedClientUserOwned, name)
+	}
+
+	resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+		ClientName:     "Internal App",
+		UserID:         admin.ID,
+		Trusted:        true,
+		IsAdminCreated: true,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Trusted)
+}
+
+func TestUpdateClient_TrustedRequiresAdminOwner(t *testing.T) {
+	s := setupTestStore(t)
+	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+	admin := makeTestAdmin(t, s)
+	owner := makeTestUser(t, s)
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr error
+	}{
+		{"user-created", owner.ID, ErrTrustedClientUserOwned},
+		{"dynamically registered", "", ErrTrustedClientUserOwned},
+		{"admin-created", admin.ID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+				ClientName:       "Candidate App",
+				UserID:           tt.userID,
+				EnableDeviceFlow: true,
+			})
+			require.NoError(t, err)
+
+			err = svc.UpdateClient(context.Background(), resp.ClientID, admin.ID, UpdateClientRequest{
+				ClientName:       "Candidate App",
+				Status:           models.ClientStatusActive,
+				EnableDeviceFlow: true,
+				Trusted:          true,
+			})
+			reloaded, getErr := s.GetClient(resp.ClientID)
+			require.NoError(t, getErr)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, reloaded.Trusted)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, reloaded.Trusted)
+		})
+	}
+}
+
 func TestCreateClient_AuthCodeFlowRequiresRedirectURI(t *testing.T) {
 	s := setupTestStore(t)
 	svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/go-authgate/authgate/internal/core"
@@ -95,9 +96,20 @@ func (s *ClientService) UserUpdateClient(
 		return ErrClientOwnershipRequired
 	}
 
+	// A trusted client skips the consent page, so changing where codes are
+	// sent or what they grant drops that status until an administrator
+	// reviews the client again.
+	scopes := strings.TrimSpace(req.Scopes)
+	trustRevoked := client.Trusted &&
+		(scopes != client.Scopes || !slices.Equal(req.RedirectURIs, []string(client.RedirectURIs)))
+	if trustRevoked {
+		client.Trusted = false
+	}
+
 	client.ClientName = strings.TrimSpace(req.ClientName)
 	client.Description = strings.TrimSpace(req.Description)
-	client.Scopes = strings.TrimSpace(req.Scopes)
+	client.Scopes = scopes
+	client.RequiredScopes = retainScopes(client.RequiredScopes, client.Scopes)
 	client.RedirectURIs = models.StringArray(req.RedirectURIs)
 	client.ClientType = clientType.String()
 	client.Project = project
@@ -119,20 +131,27 @@ func (s *ClientService) UserUpdateClient(
 
 	s.invalidateClientCache(ctx, clientID)
 
+	severity := models.SeverityInfo
+	details := models.AuditDetails{
+		"client_name": client.ClientName,
+		"grant_types": client.GrantTypes,
+		"scopes":      client.Scopes,
+	}
+	if trustRevoked {
+		severity = models.SeverityWarning
+		details["trusted"] = false
+		details["previous_trusted"] = true
+	}
 	s.auditService.Log(ctx, core.AuditLogEntry{
 		EventType:    models.EventClientUpdated,
-		Severity:     models.SeverityInfo,
+		Severity:     severity,
 		ActorUserID:  actorUserID,
 		ResourceType: models.ResourceClient,
 		ResourceID:   clientID,
 		ResourceName: client.ClientName,
 		Action:       "OAuth client updated by owner",
-		Details: models.AuditDetails{
-			"client_name": client.ClientName,
-			"grant_types": client.GrantTypes,
-			"scopes":      client.Scopes,
-		},
-		Success: true,
+		Details:      details,
+		Success:      true,
 	})
 
 	return nil
@@ -147,6 +147,57 @@ func TestUserUpdateClient_OwnerCanUpdate(t *testing.T) {
 	assert.Equal(t, "My App Updated", updated.ClientName)
 }
 
+func TestUserUpdateClient_ClearsTrusted(t *testing.T) {
+	tests := []struct {
+		name         string
+		scopes       string
+		redirectURIs []string
+		wantTrusted  bool
+	}{
+		{"unchanged", "email profile", []string{"https://app.example.com/cb"}, true},
+		{"scopes changed", "email", []string{"https://app.example.com/cb"}, false},
+		{"redirect URI added", "email profile", []string{
+			"https://app.example.com/cb", "https://evil.example.net/cb",
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setupTestStore(t)
+			svc := NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0)
+			ownerID := makeTestAdmin(t, s).ID
+
+			resp, err := svc.CreateClient(context.Background(), CreateClientRequest{
+				ClientName:         "First-Party App",
+				UserID:             ownerID,
+				CreatedBy:          ownerID,
+				Scopes:             "email profile",
+				RedirectURIs:       []string{"https://app.example.com/cb"},
+				EnableAuthCodeFlow: true,
+				IsAdminCreated:     true,
+				Trusted:            true,
+			})
+			require.NoError(t, err)
+
+			err = svc.UserUpdateClient(
+				context.Background(),
+				resp.ClientID,
+				ownerID,
+				UserUpdateClientRequest{
+					ClientName:         "First-Party App",
+					Scopes:             tt.scopes,
+					RedirectURIs:       tt.redirectURIs,
+					EnableAuthCodeFlow: true,
+				},
+			)
+			require.NoError(t, err)
+
+			updated, err := s.GetClient(resp.ClientID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTrusted, updated.Trusted)
+		})
+	}
+}
+
 // ============================================================
 // UserUpdateClient – scope validation
 // ============================================================
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+// essentialScopes are scopes a user can never deselect on the consent page
+// because the request makes no sense without them: an OIDC client that
+// asked for "openid" needs the ID token to sign the user in at all.
+var essentialScopes = map[string]bool{
+	"openid": true,
+}
+
+// ConsentScopes splits the requested scopes into those the user must grant
+// (essential scopes and the client's RequiredScopes) and those the user may
+// decline. Both keep the order of the request.
+func ConsentScopes(client *models.OAuthApplication, scopes string) (required, optional []string) {
+	clientRequired := util.ScopeSet(client.RequiredScopes)
+	for _, scope := range strings.Fields(scopes) {
+		if essentialScopes[scope] || clientRequired[scope] {
+			required = append(required, scope)
+		} else {
+			optional = append(optional, scope)
+		}
+	}
+	return required, optional
+}
+
+// NarrowConsentScopes applies the user's consent choices to a request. It
+// returns the granted scopes (the required scopes plus every optional scope
+// the user kept checked) and the optional scopes the user declined, both as
+// space-separated strings in request order. Granted values that were never
+// requested are ignored. Declining every scope is a denial and returns
+// ErrAccessDeniedConsent.
+func NarrowConsentScopes(
+	client *models.OAuthApplication,
+	requested string,
+	granted []string,
+) (grantedScopes, declinedScopes string, err error) {
+	keep := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		keep[scope] = true
+	}
+	required, optional := ConsentScopes(client, requested)
+	for _, scope := range required {
+		keep[scope] = true
+	}
+	var grantedList, declined []string
+	for scope := range strings.FieldsSeq(requested) {
+		if keep[scope] {
+			grantedList = append(grantedList, scope)
+		}
+	}
+	for _, scope := range optional {
+		if !keep[scope] {
+			declined = append(declined, scope)
+		}
+	}
+	if len(grantedList) == 0 {
+		return "", "", ErrAccessDeniedConsent
+	}
+	return strings.Join(grantedList, " "), strings.Join(declined, " "), nil
+}
+
+// RememberedConsentScopes reports whether an earlier consent already covers
+// a request, and if so which scopes to issue. The request is covered when
+// the user has already either granted or declined every requested scope and
+// has granted every scope now required; the prompt reappears as soon as the
+// client asks for a scope the user has not seen. The issued scopes are the
+// requested ones the user granted, so earlier declines stay in force.
+func RememberedConsentScopes(
+	auth *models.UserAuthorization,
+	client *models.OAuthApplication,
+	requested string,
+) (string, bool) {
+	grantedSet := util.ScopeSet(auth.Scopes)
+	declinedSet := util.ScopeSet(auth.DeclinedScopes)
+	required, _ := ConsentScopes(client, requested)
+	for _, scope := range required {
+		if !grantedSet[scope] {
+			return "", false
+		}
+	}
+	var issued []string
+	for scope := range strings.FieldsSeq(requested) {
+		switch {
+		case grantedSet[scope]:
+			issued = append(issued, scope)
+		case !declinedSet[scope]:
+			return "", false
+		}
+	}
+	if len(issued) == 0 {
+		return "", false
+	}
+	return strings.Join(issued, " "), true
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentScopes(t *testing.T) {
+	client := &models.OAuthApplication{RequiredScopes: "read"}
+
+	required, optional := ConsentScopes(client, "email openid read write")
+	assert.Equal(t, []string{"openid", "read"}, required)
+	assert.Equal(t, []string{"email", "write"}, optional)
+}
+
+func TestNarrowConsentScopes(t *testing.T) {
+	client := &models.OAuthApplication{RequiredScopes: "read"}
+
+	tests := []struct {
+		name         string
+		requested    string
+		granted      []string
+		wantGranted  string
+		wantDeclined string
+		wantErr      error
+	}{
+		{
+			name:        "all kept",
+			requested:   "openid read email",
+			granted:     []string{"email"},
+			wantGranted: "openid read email",
+		},
+		{
+			name:         "optional declined",
+			requested:    "openid read email write",
+			granted:      []string{"write"},
+			wantGranted:  "openid read write",
+			wantDeclined: "email",
+		},
+		{
+			name:         "unrequested grant ignored",
+			requested:    "email write",
+			granted:      []string{"write", "admin"},
+			wantGranted:  "write",
+			wantDeclined: "email",
+		},
+		{
+			name:      "everything declined",
+			requested: "email write",
+			wantErr:   ErrAccessDeniedConsent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted, declined, err := NarrowConsentScopes(client, tt.requested, tt.granted)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGranted, granted)
+			assert.Equal(t, tt.wantDeclined, declined)
+		})
+	}
+}
+
+func TestRememberedConsentScopes(t *testing.T) {
+	client := &models.OAuthApplication{RequiredScopes: "read"}
+	auth := &models.UserAuthorization{Scopes: "openid read", DeclinedScopes: "email"}
+
+	tests := []struct {
+		name      string
+		client    *models.OAuthApplication
+		requested string
+		want      string
+		wantOK    bool
+	}{
+		{"same request", client, "openid read email", "openid read", true},
+		{"subset", client, "read", "read", true},
+		{"new scope prompts", client, "openid read email write", "", false},
+		{"only declined scopes prompts", client, "email", "", false},
+		{
+			"newly required declined scope prompts",
+			&models.OAuthApplication{RequiredScopes: "read email"},
+			"read email", "", false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RememberedConsentScopes(auth, tt.client, tt.requested)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
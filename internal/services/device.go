@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-authgate/authgate/internal/config"
 	"github.com/go-authgate/authgate/internal/core"
 	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/notify"
 	"github.com/go-authgate/authgate/internal/store"
 	"github.com/go-authgate/authgate/internal/util"
 )
@@ -34,6 +36,30 @@ type DeviceService struct {
 	auditService  core.AuditLogger
 	metrics       core.Recorder
 	clientService *ClientService
+	notifier      core.DeviceCodeNotifier
+	guard         *UserCodeGuard
+}
+
+// DeviceServiceOption configures a DeviceService at construction.
+type DeviceServiceOption func(*DeviceService)
+
+// WithDeviceCodeNotifier sets the notifier that wakes /oauth/device/wait
+// requests when a device code is authorized. Multi-instance deployments must
+// pass a shared (Redis) notifier so the authorization can land on any
+// replica; when omitted an in-process notifier is used.
+func WithDeviceCodeNotifier(n core.DeviceCodeNotifier) DeviceServiceOption {
+	return func(s *DeviceService) {
+		s.notifier = n
+	}
+}
+
+// WithUserCodeGuard sets the guard that counts wrong user codes. Multi-instance
+// deployments should pass a guard backed by a shared (Redis) store; when
+// omitted an in-memory guard is built from the config.
+func WithUserCodeGuard(g *UserCodeGuard) DeviceServiceOption {
+	return func(s *DeviceService) {
+		s.guard = g
+	}
 }
 
 func NewDeviceService(
@@ -42,17 +68,28 @@ func NewDeviceService(
 	auditService core.AuditLogger,
 	m core.Recorder,
 	clientService *ClientService,
+	opts ...DeviceServiceOption,
 ) *DeviceService {
 	if auditService == nil {
 		auditService = NewNoopAuditService()
 	}
-	return &DeviceService{
+	ds := &DeviceService{
 		store:         s,
 		config:        cfg,
 		auditService:  auditService,
 		metrics:       m,
 		clientService: clientService,
 	}
+	for _, opt := range opts {
+		opt(ds)
+	}
+	if ds.notifier == nil {
+		ds.notifier = notify.NewMemoryNotifier()
+	}
+	if ds.guard == nil {
+		ds.guard = NewUserCodeGuard(nil, cfg, auditService)
+	}
+	return ds
 }
 
 // GenerateDeviceCode creates a new device code request.
@@ -108,17 +145,19 @@ func (s *DeviceService) GenerateDeviceCode(
 	deviceCodeHash := util.HashToken(deviceCodePlaintext, salt)
 	deviceCodeID := deviceCodePlaintext[len(deviceCodePlaintext)-8:] // Last 8 chars for indexing
 
+	alphabet, codeLength, lifetime, interval := s.deviceFlowPolicy(client)
+
 	deviceCode := &models.DeviceCode{
 		DeviceCode:     deviceCodePlaintext, // Set in struct but not saved to DB (gorm:"-")
 		DeviceCodeHash: deviceCodeHash,
 		DeviceCodeSalt: salt,
 		DeviceCodeID:   deviceCodeID,
-		UserCode:       generateUserCode(),
+		UserCode:       generateUserCode(alphabet, codeLength),
 		ClientID:       clientID,
 		Scopes:         scope,
 		Resource:       models.StringArray(resource),
-		ExpiresAt:      time.Now().Add(s.config.DeviceCodeExpiration),
-		Interval:       s.config.PollingInterval,
+		ExpiresAt:      time.Now().Add(lifetime),
+		Interval:       interval,
 		Authorized:     false,
 	}
 
@@ -199,8 +238,7 @@ func (s *DeviceService) GetDeviceCode(deviceCode string) (*models.DeviceCode, er
 
 // GetDeviceCodeByUserCode retrieves a device code by user code
 func (s *DeviceService) GetDeviceCodeByUserCode(userCode string) (*models.DeviceCode, error) {
-	// Normalize user code (uppercase, remove dashes)
-	userCode = strings.ToUpper(strings.ReplaceAll(userCode, "-", ""))
+	userCode = NormalizeUserCode(userCode)
 
 	dc, err := s.store.GetDeviceCodeByUserCode(userCode)
 	if err != nil {
@@ -254,6 +292,7 @@ func (s *DeviceService) AuthorizeDeviceCode(
 		Success: true,
 	})
 
+	s.NotifyDeviceCodeAuthorized(ctx, dc)
 	return nil
 }
 
@@ -267,6 +306,132 @@ func (s *DeviceService) RecordDeviceCodeAuthorized(dc *models.DeviceCode) {
 	s.metrics.RecordOAuthDeviceCodeAuthorized(time.Since(dc.CreatedAt))
 }
 
+// NotifyDeviceCodeAuthorized wakes /oauth/device/wait requests for dc. Like
+// RecordDeviceCodeAuthorized, callers that authorize outside
+// AuthorizeDeviceCode must invoke this after a successful commit. A publish
+// failure only delays waiters until their timeout, so it is logged rather
+// than returned.
+func (s *DeviceService) NotifyDeviceCodeAuthorized(ctx context.Context, dc *models.DeviceCode) {
+	if err := s.notifier.Publish(ctx, dc.ID); err != nil {
+		log.Printf("[DeviceNotify] Failed to notify waiters for device_code_id=%s: %v",
+			dc.DeviceCodeID, err)
+	}
+}
+
+// WaitForDeviceCodeAuthorization blocks until the device code is authorized,
+// it expires, timeout elapses or ctx is canceled, and returns the device
+// code's latest state. A nil error with dc.Authorized == false means the
+// wait timed out while authorization is still pending. It validates the
+// device code and client_id the same way the device_code grant does, but
+// never issues tokens: the client still exchanges the code at /oauth/token.
+func (s *DeviceService) WaitForDeviceCodeAuthorization(
+	ctx context.Context,
+	deviceCode, clientID string,
+	timeout time.Duration,
+) (*models.DeviceCode, error) {
+	dc, err := s.GetDeviceCode(deviceCode)
+	if err != nil {
+		return nil, err
+	}
+	if dc.ClientID != clientID {
+		return nil, ErrDeviceCodeNotFound
+	}
+	if dc.Authorized {
+		return dc, nil
+	}
+
+	events, release := s.notifier.Subscribe(dc.ID)
+	defer release()
+
+	// Re-read after subscribing: an authorization that committed between the
+	// first read and Subscribe published its event before we were listening.
+	if dc, err = s.reloadDeviceCode(dc); err != nil || dc.Authorized {
+		return dc, err
+	}
+
+	timer := time.NewTimer(min(timeout, time.Until(dc.ExpiresAt)))
+	defer timer.Stop()
+
+	select {
+	case <-events:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.reloadDeviceCode(dc)
+}
+
+// reloadDeviceCode re-reads dc from the store without re-hashing the
+// plaintext device code. A code that was exchanged (and deleted) in the
+// meantime reports ErrDeviceCodeNotFound.
+func (s *DeviceService) reloadDeviceCode(dc *models.DeviceCode) (*models.DeviceCode, error) {
+	candidates, err := s.store.GetDeviceCodesByID(dc.DeviceCodeID)
+	if err != nil {
+		return nil, ErrDeviceCodeNotFound
+	}
+	for _, fresh := range candidates {
+		if fresh.ID != dc.ID {
+			continue
+		}
+		if fresh.IsExpired() {
+			return nil, ErrDeviceCodeExpired
+		}
+		fresh.DeviceCode = dc.DeviceCode
+		return fresh, nil
+	}
+	return nil, ErrDeviceCodeNotFound
+}
+
+// LookupUserCode is GetClientByUserCode for a code a person typed or followed
+// a link with. It refuses the lookup once the session or account has used up
+// its wrong codes (ErrUserCodeSessionLocked, ErrUserCodeUserLocked) and
+// counts each unknown code; the failure that reaches a threshold returns the
+// lockout instead of ErrUserCodeNotFound.
+func (s *DeviceService) LookupUserCode(
+	ctx context.Context,
+	a UserCodeAttempt,
+) (*models.OAuthApplication, *models.DeviceCode, error) {
+	if err := s.guard.Check(ctx, a); err != nil {
+		return nil, nil, err
+	}
+	client, dc, err := s.GetClientByUserCode(ctx, a.UserCode)
+	if errors.Is(err, ErrUserCodeNotFound) {
+		if lockErr := s.guard.RecordFailure(ctx, a); lockErr != nil {
+			return nil, nil, lockErr
+		}
+	}
+	return client, dc, err
+}
+
+// RecordPendingPoll records a device_code grant request for a device code
+// that is still awaiting authorization. A client polling sooner than the
+// code's interval gets ErrSlowDown, and the interval grows by
+// slowDownIncrement seconds for every later request (RFC 8628 §3.5).
+// dc is updated in place with the new poll time and interval.
+func (s *DeviceService) RecordPendingPoll(dc *models.DeviceCode) error {
+	now := time.Now()
+	tooFast := !dc.LastPolledAt.IsZero() &&
+		now.Sub(dc.LastPolledAt) < time.Duration(dc.Interval)*time.Second
+	interval := dc.Interval
+	if tooFast {
+		interval += slowDownIncrement
+	}
+
+	// Losing the poll record only makes the next check more lenient, so a
+	// store failure must not turn a pending poll into an error.
+	if err := s.store.RecordDeviceCodePoll(dc.ID, now, interval); err != nil {
+		log.Printf("[Device] Failed to record poll for device_code_id=%s: %v",
+			dc.DeviceCodeID, err)
+	}
+	dc.LastPolledAt = now
+	dc.Interval = interval
+
+	if tooFast {
+		return ErrSlowDown
+	}
+	return nil
+}
+
 // GetClientByUserCode retrieves the OAuth client and device code associated with a user code
 func (s *DeviceService) GetClientByUserCode(
 	ctx context.Context,
@@ -285,25 +450,124 @@ func (s *DeviceService) GetClientByUserCode(
 	return client, dc, nil
 }
 
-// generateUserCode creates a user-friendly code like "ABCD-EFGH"
-// Avoids confusing characters: 0, O, 1, I, L
-func generateUserCode() string {
-	const charset = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
-	code := make([]byte, 8)
+// User code alphabets. The letter sets avoid confusing characters: O, I, L,
+// and (in the alphanumeric set) 0 and 1.
+const (
+	userCodeAlphanumeric = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	userCodeLetters      = "ABCDEFGHJKMNPQRSTUVWXYZ"
+	userCodeDigits       = "0123456789"
+)
+
+// userCodeAlphabets lists every alphabet a client may choose. A code is
+// checked against all of them before the client is known.
+var userCodeAlphabets = []string{userCodeAlphanumeric, userCodeLetters, userCodeDigits}
+
+// User code lengths, without the dash. Digits-only codes need more
+// characters to stay hard to guess while the code is valid.
+const (
+	defaultUserCodeLength   = 8
+	minUserCodeLength       = 6
+	maxUserCodeLength       = 12
+	minDigitsUserCodeLength = 8
+)
+
+// Bounds for the per-client device code lifetime and polling interval, in
+// seconds.
+const (
+	minDeviceCodeLifetime    = 60
+	maxDeviceCodeLifetime    = 3600
+	minDevicePollingInterval = 1
+	maxDevicePollingInterval = 60
+)
+
+// slowDownIncrement is how much a device code's polling interval grows, in
+// seconds, each time its client polls too fast (RFC 8628 §3.5).
+const slowDownIncrement = 5
+
+// userCodeAlphabet returns the alphabet for a client's user code charset.
+func userCodeAlphabet(charset string) string {
+	switch charset {
+	case models.UserCodeCharsetDigits:
+		return userCodeDigits
+	case models.UserCodeCharsetLetters:
+		return userCodeLetters
+	default:
+		return userCodeAlphanumeric
+	}
+}
+
+// deviceFlowPolicy resolves the client's device flow settings, falling back
+// to the deployment defaults for any that are unset.
+func (s *DeviceService) deviceFlowPolicy(
+	client *models.OAuthApplication,
+) (alphabet string, length int, lifetime time.Duration, interval int) {
+	alphabet = userCodeAlphabet(client.DeviceUserCodeCharset)
+	length = defaultUserCodeLength
+	if client.DeviceUserCodeLength > 0 {
+		length = client.DeviceUserCodeLength
+	}
+	lifetime = s.config.DeviceCodeExpiration
+	if client.DeviceCodeLifetime > 0 {
+		lifetime = time.Duration(client.DeviceCodeLifetime) * time.Second
+	}
+	interval = s.config.PollingInterval
+	if client.DevicePollingInterval > 0 {
+		interval = client.DevicePollingInterval
+	}
+	return alphabet, length, lifetime, interval
+}
+
+// NormalizeUserCode uppercases a user code and removes dashes, as users may
+// type it either way.
+func NormalizeUserCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+}
+
+// IsWellFormedUserCode reports whether the normalized code has the shape of a
+// code generateUserCode could have issued for some client. It does not check
+// that the code exists.
+func IsWellFormedUserCode(code string) bool {
+	if len(code) < minUserCodeLength || len(code) > maxUserCodeLength {
+		return false
+	}
+	for _, alphabet := range userCodeAlphabets {
+		if strings.Trim(code, alphabet) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateUserCode creates a random code of length characters drawn from
+// alphabet. It is stored without dashes; see FormatUserCode.
+func generateUserCode(alphabet string, length int) string {
+	code := make([]byte, length)
 
 	for i := range code {
-		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		code[i] = charset[n.Int64()]
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		code[i] = alphabet[n.Int64()]
 	}
 
-	// Format as XXXX-XXXX but store without dash
 	return string(code)
 }
 
-// FormatUserCode formats a user code for display (e.g., "ABCDEFGH" -> "ABCD-EFGH")
+// FormatUserCode formats a user code for display in dash-separated groups
+// (e.g., "ABCDEFGH" -> "ABCD-EFGH", "123456" -> "123-456"). Codes split into
+// groups of four unless groups of three divide them evenly.
 func FormatUserCode(code string) string {
-	if len(code) != 8 {
+	if len(code) < minUserCodeLength {
 		return code
 	}
-	return code[:4] + "-" + code[4:]
+	group := 4
+	if len(code)%4 != 0 && len(code)%3 == 0 {
+		group = 3
+	}
+	var b strings.Builder
+	for i := 0; i < len(code); i += group {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(code[i:min(i+group, len(code))])
+	}
+	return b.String()
 }
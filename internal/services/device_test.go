@@ -354,3 +354,191 @@ func TestUserCodeNormalization(t *testing.T) {
 	assert.Equal(t, dc.UserCode, dc2.UserCode)
 	assert.Equal(t, dc1.ID, dc2.ID)
 }
+
+func TestIsWellFormedUserCode(t *testing.T) {
+	assert.True(t, IsWellFormedUserCode(NormalizeUserCode("abcd-efgh")))
+	assert.True(t, IsWellFormedUserCode(generateUserCode(userCodeAlphanumeric, defaultUserCodeLength)))
+	assert.True(t, IsWellFormedUserCode(generateUserCode(userCodeDigits, maxUserCodeLength)))
+	assert.True(t, IsWellFormedUserCode("123456"), "digits-only codes may contain 0 and 1")
+	assert.False(t, IsWellFormedUserCode(""))
+	assert.False(t, IsWellFormedUserCode("ABCDE"), "too short")
+	assert.False(t, IsWellFormedUserCode("ABCDEFGHJKMNP"), "too long")
+	assert.False(t, IsWellFormedUserCode("ABCDEFGO"), "O is not in the charset")
+	assert.False(t, IsWellFormedUserCode("ABCDEFG0"), "0 only appears in digits-only codes")
+	assert.False(t, IsWellFormedUserCode("ABCD-EFG"), "not normalized")
+}
+
+func TestFormatUserCode(t *testing.T) {
+	assert.Equal(t, "ABCD-EFGH", FormatUserCode("ABCDEFGH"))
+	assert.Equal(t, "123-456", FormatUserCode("123456"))
+	assert.Equal(t, "123-456-789", FormatUserCode("123456789"))
+	assert.Equal(t, "1234-5678-90", FormatUserCode("1234567890"))
+	assert.Equal(t, "ABCD-EFGH-JKMN", FormatUserCode("ABCDEFGHJKMN"))
+	assert.Equal(t, "ABCDE", FormatUserCode("ABCDE"))
+}
+
+func TestGenerateDeviceCode_ClientPolicy(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := &config.Config{
+		DeviceCodeExpiration: 30 * time.Minute,
+		PollingInterval:      5,
+	}
+	deviceService := NewDeviceService(
+		s,
+		cfg,
+		NewNoopAuditService(),
+		metrics.NewNoopMetrics(),
+		NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0),
+	)
+	client := createTestClient(t, s, true)
+	client.DeviceUserCodeCharset = models.UserCodeCharsetDigits
+	client.DeviceUserCodeLength = 10
+	client.DeviceCodeLifetime = 300
+	client.DevicePollingInterval = 10
+	require.NoError(t, s.UpdateClient(client))
+
+	dc, err := deviceService.GenerateDeviceCode(
+		context.Background(), client.ClientID, "read write", nil,
+	)
+	require.NoError(t, err)
+
+	assert.Len(t, dc.UserCode, 10)
+	for _, r := range dc.UserCode {
+		assert.Contains(t, userCodeDigits, string(r))
+	}
+	assert.Equal(t, 10, dc.Interval)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), dc.ExpiresAt, 5*time.Second)
+
+	// The code is found however the user groups it.
+	found, err := deviceService.GetDeviceCodeByUserCode(FormatUserCode(dc.UserCode))
+	require.NoError(t, err)
+	assert.Equal(t, dc.ID, found.ID)
+}
+
+func TestRecordPendingPoll_SlowDown(t *testing.T) {
+	deviceService, _, dc := newWaitTestDeviceService(t)
+	require.Equal(t, 5, dc.Interval)
+
+	// The first poll only records the time.
+	require.NoError(t, deviceService.RecordPendingPoll(dc))
+	assert.Equal(t, 5, dc.Interval)
+
+	// Polling again right away is too fast: slow_down, interval +5s.
+	require.ErrorIs(t, deviceService.RecordPendingPoll(dc), ErrSlowDown)
+	assert.Equal(t, 10, dc.Interval)
+
+	stored, err := deviceService.reloadDeviceCode(dc)
+	require.NoError(t, err)
+	assert.Equal(t, 10, stored.Interval)
+	assert.False(t, stored.LastPolledAt.IsZero())
+
+	// A client that respects the raised interval is back to pending.
+	stored.LastPolledAt = time.Now().Add(-11 * time.Second)
+	require.NoError(t, deviceService.RecordPendingPoll(stored))
+	assert.Equal(t, 10, stored.Interval)
+}
+
+// newWaitTestDeviceService returns a DeviceService and a freshly issued
+// device code for the WaitForDeviceCodeAuthorization tests.
+func newWaitTestDeviceService(
+	t *testing.T,
+) (*DeviceService, *models.OAuthApplication, *models.DeviceCode) {
+	t.Helper()
+	s := setupTestStore(t)
+	cfg := &config.Config{
+		DeviceCodeExpiration: 30 * time.Minute,
+		PollingInterval:      5,
+	}
+	deviceService := NewDeviceService(
+		s,
+		cfg,
+		NewNoopAuditService(),
+		metrics.NewNoopMetrics(),
+		NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0),
+	)
+	client := createTestClient(t, s, true)
+	dc, err := deviceService.GenerateDeviceCode(
+		context.Background(), client.ClientID, "read write", nil,
+	)
+	require.NoError(t, err)
+	return deviceService, client, dc
+}
+
+func TestWaitForDeviceCodeAuthorization_WakesOnAuthorize(t *testing.T) {
+	deviceService, client, dc := newWaitTestDeviceService(t)
+
+	type result struct {
+		dc  *models.DeviceCode
+		err error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		got, err := deviceService.WaitForDeviceCodeAuthorization(
+			context.Background(), dc.DeviceCode, client.ClientID, 10*time.Second,
+		)
+		done <- result{got, err}
+	}()
+
+	// Give the waiter time to subscribe; an earlier authorization is still
+	// picked up by its re-read, just not through the event.
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, deviceService.AuthorizeDeviceCode(
+		context.Background(), dc.UserCode, uuid.New().String(), "testuser",
+	))
+
+	select {
+	case r := <-done:
+		require.NoError(t, r.err)
+		assert.True(t, r.dc.Authorized)
+		assert.Equal(t, dc.DeviceCode, r.dc.DeviceCode)
+		assert.Less(t, time.Since(start), 5*time.Second, "woken by the event, not the timeout")
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter was not woken by the authorization")
+	}
+}
+
+func TestWaitForDeviceCodeAuthorization_AlreadyAuthorized(t *testing.T) {
+	deviceService, client, dc := newWaitTestDeviceService(t)
+	require.NoError(t, deviceService.AuthorizeDeviceCode(
+		context.Background(), dc.UserCode, uuid.New().String(), "testuser",
+	))
+
+	got, err := deviceService.WaitForDeviceCodeAuthorization(
+		context.Background(), dc.DeviceCode, client.ClientID, time.Minute,
+	)
+	require.NoError(t, err)
+	assert.True(t, got.Authorized)
+}
+
+func TestWaitForDeviceCodeAuthorization_TimeoutReportsPending(t *testing.T) {
+	deviceService, client, dc := newWaitTestDeviceService(t)
+
+	got, err := deviceService.WaitForDeviceCodeAuthorization(
+		context.Background(), dc.DeviceCode, client.ClientID, 20*time.Millisecond,
+	)
+	require.NoError(t, err)
+	assert.False(t, got.Authorized)
+}
+
+func TestWaitForDeviceCodeAuthorization_Errors(t *testing.T) {
+	deviceService, client, dc := newWaitTestDeviceService(t)
+	ctx := context.Background()
+
+	_, err := deviceService.WaitForDeviceCodeAuthorization(
+		ctx, dc.DeviceCode, "other-client", time.Second,
+	)
+	require.ErrorIs(t, err, ErrDeviceCodeNotFound, "client_id must match")
+
+	_, err = deviceService.WaitForDeviceCodeAuthorization(
+		ctx, strings.Repeat("a", 40), client.ClientID, time.Second,
+	)
+	require.ErrorIs(t, err, ErrDeviceCodeNotFound)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = deviceService.WaitForDeviceCodeAuthorization(
+		canceled, dc.DeviceCode, client.ClientID, time.Minute,
+	)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/stretchr/testify/require"
+)
+
+// chanMailer hands every sent message to a channel.
+type chanMailer chan core.MailMessage
+
+func (m chanMailer) Send(_ context.Context, msg core.MailMessage) error {
+	m <- msg
+	return nil
+}
+
+// mailTestFixture holds the dependencies shared by the services that send
+// account email: a store, a user service with local passwords, and a mailer.
+type mailTestFixture struct {
+	db     *store.Store
+	cfg    *config.Config
+	users  *UserService
+	mailer chanMailer
+}
+
+// newMailTestFixture builds a fixture around cfg, defaulting BaseURL.
+func newMailTestFixture(t *testing.T, cfg *config.Config) *mailTestFixture {
+	t.Helper()
+	db := setupTestStore(t)
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://auth.example.com/"
+	}
+	users := NewUserService(
+		db, auth.NewLocalAuthProvider(db), nil, AuthModeLocal, false,
+		NewNoopAuditService(), cache.NewNoopCache[models.User](), 0,
+	)
+	return &mailTestFixture{db: db, cfg: cfg, users: users, mailer: make(chanMailer, 10)}
+}
+
+// receiveMailToken waits for the next email and returns it with the token
+// from the first link matching pattern.
+func receiveMailToken(
+	t *testing.T,
+	mailer chanMailer,
+	pattern *regexp.Regexp,
+) (core.MailMessage, string) {
+	t.Helper()
+	select {
+	case msg := <-mailer:
+		link := pattern.FindString(msg.Text)
+		require.NotEmpty(t, link, "email has no link matching %s: %s", pattern, msg.Text)
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		return msg, u.Query().Get("token")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email sent")
+		return core.MailMessage{}, ""
+	}
+}
+
+func assertNoEmail(t *testing.T, mailer chanMailer) {
+	t.Helper()
+	select {
+	case msg := <-mailer:
+		t.Fatalf("unexpected email to %s", msg.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
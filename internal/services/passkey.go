@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/webauthn"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxPasskeysPerUser caps how many passkeys one account can register.
+	maxPasskeysPerUser = 10
+	// maxPasskeyNameLength bounds the user-chosen passkey label.
+	maxPasskeyNameLength = 64
+	// defaultPasskeyName labels a passkey registered without a name.
+	defaultPasskeyName = "Passkey"
+)
+
+var (
+	ErrPasskeyNotAllowed        = errors.New("passkeys are only available for local users")
+	ErrPasskeyLimitReached      = errors.New("you have registered the maximum number of passkeys")
+	ErrPasskeyAlreadyRegistered = errors.New("this passkey is already registered")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrInvalidPasskey           = errors.New("passkey verification failed")
+)
+
+// PasskeyService registers WebAuthn passkeys and verifies them either as a
+// passwordless login or as the second factor after a password.
+type PasskeyService struct {
+	store        core.Store
+	userService  *UserService
+	rp           *webauthn.RelyingParty
+	auditService core.AuditLogger
+}
+
+func NewPasskeyService(
+	s core.Store,
+	userService *UserService,
+	rp *webauthn.RelyingParty,
+	auditService core.AuditLogger,
+) *PasskeyService {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	return &PasskeyService{
+		store:        s,
+		userService:  userService,
+		rp:           rp,
+		auditService: auditService,
+	}
+}
+
+// BeginRegistration returns the options for registering a new passkey. The
+// caller keeps options.Challenge until FinishRegistration.
+func (s *PasskeyService) BeginRegistration(userID string) (*webauthn.CreationOptions, error) {
+	user, err := s.userService.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsExternal() {
+		return nil, ErrPasskeyNotAllowed
+	}
+	creds, err := s.store.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+	if len(creds) >= maxPasskeysPerUser {
+		return nil, ErrPasskeyLimitReached
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	displayName := user.FullName
+	if displayName == "" {
+		displayName = user.Username
+	}
+	opts := s.rp.CreationOptions(challenge, webauthn.UserEntity{
+		ID:          webauthn.EncodeID([]byte(user.ID)),
+		Name:        user.Username,
+		DisplayName: displayName,
+	}, credentialDescriptors(creds))
+	return &opts, nil
+}
+
+// FinishRegistration verifies the browser's response to BeginRegistration
+// and stores the new passkey under name.
+func (s *PasskeyService) FinishRegistration(
+	ctx context.Context,
+	userID, challenge, name string,
+	resp *webauthn.RegistrationResponse,
+) (*models.WebAuthnCredential, error) {
+	user, err := s.userService.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsExternal() {
+		return nil, ErrPasskeyNotAllowed
+	}
+
+	verified, err := s.rp.VerifyRegistration(challenge, resp, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	if _, err := s.store.GetWebAuthnCredentialByCredentialID(verified.ID); err == nil {
+		return nil, ErrPasskeyAlreadyRegistered
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up passkey: %w", err)
+	}
+
+	cred := &models.WebAuthnCredential{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		CredentialID:   verified.ID,
+		PublicKey:      verified.PublicKey,
+		SignCount:      int64(verified.SignCount),
+		Transports:     verified.Transports,
+		Name:           normalizePasskeyName(name),
+		BackupEligible: verified.BackupEligible,
+	}
+	if err := s.store.CreateWebAuthnCredential(cred); err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	s.userService.InvalidateUserCache(userID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasskeyRegistered,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: user.Username,
+		Action:       "Passkey registered",
+		Details:      models.AuditDetails{"passkey_name": cred.Name},
+		Success:      true,
+	})
+	return cred, nil
+}
+
+// BeginLogin returns the options for a passwordless login, letting the user
+// pick any passkey they hold for this site.
+func (s *PasskeyService) BeginLogin() (*webauthn.RequestOptions, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	opts := s.rp.RequestOptions(challenge, nil, webauthn.UserVerificationRequired)
+	return &opts, nil
+}
+
+// FinishLogin verifies a passwordless login and returns the signed-in user.
+// The authenticator must have verified the user (PIN or biometric), since
+// the passkey stands in for both password and second factor.
+func (s *PasskeyService) FinishLogin(
+	ctx context.Context,
+	challenge string,
+	resp *webauthn.AssertionResponse,
+) (*models.User, error) {
+	return s.verifyAssertion(ctx, "", challenge, resp, true)
+}
+
+// BeginSecondFactor returns the options for confirming a password login
+// with one of the user's passkeys.
+func (s *PasskeyService) BeginSecondFactor(userID string) (*webauthn.RequestOptions, error) {
+	creds, err := s.store.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+	if len(creds) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	opts := s.rp.RequestOptions(
+		challenge,
+		credentialDescriptors(creds),
+		webauthn.UserVerificationPreferred,
+	)
+	return &opts, nil
+}
+
+// FinishSecondFactor verifies that the user whose password was accepted
+// also holds one of their passkeys.
+func (s *PasskeyService) FinishSecondFactor(
+	ctx context.Context,
+	userID, challenge string,
+	resp *webauthn.AssertionResponse,
+) error {
+	_, err := s.verifyAssertion(ctx, userID, challenge, resp, false)
+	return err
+}
+
+// ListPasskeys returns the user's registered passkeys.
+func (s *PasskeyService) ListPasskeys(userID string) ([]models.WebAuthnCredential, error) {
+	return s.store.ListWebAuthnCredentials(userID)
+}
+
+// DeletePasskey removes one of the user's passkeys.
+func (s *PasskeyService) DeletePasskey(ctx context.Context, userID, id string) error {
+	deleted, err := s.store.DeleteWebAuthnCredential(userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+
+	s.userService.InvalidateUserCache(userID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasskeyRemoved,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		Action:       "Passkey removed",
+		Details:      models.AuditDetails{"passkey_id": id},
+		Success:      true,
+	})
+	return nil
+}
+
+// verifyAssertion checks an authentication response against the stored
+// passkey it names. expectedUserID, when set, restricts the passkey to that
+// user's; otherwise the passkey's owner is signed in.
+func (s *PasskeyService) verifyAssertion(
+	ctx context.Context,
+	expectedUserID, challenge string,
+	resp *webauthn.AssertionResponse,
+	requireUserVerification bool,
+) (*models.User, error) {
+	cred, err := s.store.GetWebAuthnCredentialByCredentialID(resp.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logFailure(ctx, expectedUserID, "Unknown passkey presented")
+			return nil, ErrInvalidPasskey
+		}
+		return nil, fmt.Errorf("failed to look up passkey: %w", err)
+	}
+	if expectedUserID != "" && cred.UserID != expectedUserID {
+		s.logFailure(ctx, expectedUserID, "Passkey belongs to another account")
+		return nil, ErrInvalidPasskey
+	}
+	if handle, err := resp.UserHandle(); err != nil ||
+		(handle != nil && string(handle) != cred.UserID) {
+		s.logFailure(ctx, cred.UserID, "Passkey user handle mismatch")
+		return nil, ErrInvalidPasskey
+	}
+
+	assertion, err := s.rp.VerifyAssertion(challenge, resp, cred.PublicKey, requireUserVerification)
+	if err != nil {
+		s.logFailure(ctx, cred.UserID, "Passkey verification failed")
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	// Authenticators that keep a signature counter must always increase it; a
+	// counter that goes backwards suggests the key was cloned.
+	newCount := int64(assertion.SignCount)
+	if (newCount != 0 || cred.SignCount != 0) && newCount <= cred.SignCount {
+		s.logFailure(ctx, cred.UserID, "Passkey signature counter did not increase")
+		return nil, ErrInvalidPasskey
+	}
+	updated, err := s.store.UpdateWebAuthnSignCount(cred.ID, cred.SignCount, newCount, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record passkey use: %w", err)
+	}
+	if !updated {
+		// Another login used this passkey concurrently with the same counter.
+		return nil, ErrInvalidPasskey
+	}
+
+	user, err := s.userService.GetUserByID(ctx, cred.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasskeyUsed,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Signed in with passkey",
+		Details:      models.AuditDetails{"passkey_name": cred.Name},
+		Success:      true,
+	})
+	return user, nil
+}
+
+// logFailure records a failed passkey assertion.
+func (s *PasskeyService) logFailure(ctx context.Context, userID, action string) {
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasskeyFailure,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		Action:       action,
+		Success:      false,
+	})
+}
+
+// credentialDescriptors lists stored passkeys for the browser.
+func credentialDescriptors(creds []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, c := range creds {
+		out = append(out, webauthn.NewCredentialDescriptor(c.CredentialID, c.Transports))
+	}
+	return out
+}
+
+// normalizePasskeyName trims the label and falls back to a default.
+func normalizePasskeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultPasskeyName
+	}
+	if utf8.RuneCountInString(name) > maxPasskeyNameLength {
+		name = string([]rune(name)[:maxPasskeyNameLength])
+	}
+	return name
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/webauthn"
+	"github.com/go-authgate/authgate/internal/webauthn/webauthntest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPasskeyRP = &webauthn.RelyingParty{
+	ID:      "auth.example.com",
+	Name:    "AuthGate",
+	Origins: []string{"https://auth.example.com"},
+}
+
+func newPasskeyTestService(t *testing.T) (*PasskeyService, *UserService, *store.Store) {
+	t.Helper()
+	db := setupTestStore(t)
+	userSvc := newUserServiceWithStore(db, cache.NewNoopCache[models.User]())
+	return NewPasskeyService(db, userSvc, testPasskeyRP, nil), userSvc, db
+}
+
+// registerPasskey registers a fresh authenticator for the user and returns it.
+func registerPasskey(
+	t *testing.T,
+	svc *PasskeyService,
+	user *models.User,
+) *webauthntest.Authenticator {
+	t.Helper()
+	opts, err := svc.BeginRegistration(user.ID)
+	require.NoError(t, err)
+	authn := webauthntest.New(testPasskeyRP.ID, testPasskeyRP.Origins[0], []byte(user.ID))
+	_, err = svc.FinishRegistration(
+		context.Background(), user.ID, opts.Challenge, "Laptop", authn.Create(opts.Challenge),
+	)
+	require.NoError(t, err)
+	return authn
+}
+
+func TestPasskeyRegistration(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+	user := makeTestUser(t, db)
+
+	authn := registerPasskey(t, svc, user)
+
+	creds, err := svc.ListPasskeys(user.ID)
+	require.NoError(t, err)
+	require.Len(t, creds, 1)
+	assert.Equal(t, authn.ID(), creds[0].CredentialID)
+	assert.Equal(t, "Laptop", creds[0].Name)
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.WebAuthnEnabled)
+
+	// The registered passkey is excluded from the next registration.
+	opts, err := svc.BeginRegistration(user.ID)
+	require.NoError(t, err)
+	require.Len(t, opts.ExcludeCredentials, 1)
+	assert.Equal(t, authn.ID(), opts.ExcludeCredentials[0].ID)
+
+	// Registering the same authenticator again is rejected.
+	_, err = svc.FinishRegistration(
+		context.Background(), user.ID, opts.Challenge, "", authn.Create(opts.Challenge),
+	)
+	assert.ErrorIs(t, err, ErrPasskeyAlreadyRegistered)
+}
+
+func TestPasskeyRegistration_Rejects(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+
+	t.Run("external user", func(t *testing.T) {
+		user := makeTestHTTPAPIUser(t, db)
+		_, err := svc.BeginRegistration(user.ID)
+		assert.ErrorIs(t, err, ErrPasskeyNotAllowed)
+	})
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		opts, err := svc.BeginRegistration(user.ID)
+		require.NoError(t, err)
+		authn := webauthntest.New(testPasskeyRP.ID, testPasskeyRP.Origins[0], nil)
+		other, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		_, err = svc.FinishRegistration(
+			context.Background(), user.ID, opts.Challenge, "", authn.Create(other),
+		)
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+	})
+
+	t.Run("limit reached", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		for range maxPasskeysPerUser {
+			registerPasskey(t, svc, user)
+		}
+		_, err := svc.BeginRegistration(user.ID)
+		assert.ErrorIs(t, err, ErrPasskeyLimitReached)
+	})
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+	user := makeTestUser(t, db)
+	authn := registerPasskey(t, svc, user)
+
+	opts, err := svc.BeginLogin()
+	require.NoError(t, err)
+	assert.Empty(t, opts.AllowCredentials, "passwordless login lets the user pick a passkey")
+
+	got, err := svc.FinishLogin(context.Background(), opts.Challenge, authn.Get(opts.Challenge))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	creds, err := svc.ListPasskeys(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(authn.SignCount), creds[0].SignCount)
+	assert.NotNil(t, creds[0].LastUsedAt)
+}
+
+func TestPasskeyLogin_Rejects(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+	ctx := context.Background()
+
+	t.Run("unknown passkey", func(t *testing.T) {
+		authn := webauthntest.New(testPasskeyRP.ID, testPasskeyRP.Origins[0], nil)
+		opts, err := svc.BeginLogin()
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, authn.Get(opts.Challenge))
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+	})
+
+	t.Run("user not verified", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		authn := registerPasskey(t, svc, user)
+		authn.Flags = webauthntest.FlagUserPresent
+		opts, err := svc.BeginLogin()
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, authn.Get(opts.Challenge))
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+	})
+
+	t.Run("user handle mismatch", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		authn := registerPasskey(t, svc, user)
+		authn.UserHandle = []byte("someone-else")
+		opts, err := svc.BeginLogin()
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, authn.Get(opts.Challenge))
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+	})
+
+	t.Run("replayed assertion", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		authn := registerPasskey(t, svc, user)
+		opts, err := svc.BeginLogin()
+		require.NoError(t, err)
+		resp := authn.Get(opts.Challenge)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, resp)
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, resp)
+		assert.ErrorIs(t, err, ErrInvalidPasskey, "a counter that does not advance is rejected")
+	})
+
+	t.Run("disabled account", func(t *testing.T) {
+		user := makeTestUser(t, db)
+		authn := registerPasskey(t, svc, user)
+		user.IsActive = false
+		require.NoError(t, db.UpdateUser(user))
+		opts, err := svc.BeginLogin()
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, opts.Challenge, authn.Get(opts.Challenge))
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+	})
+}
+
+func TestPasskeySecondFactor(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	other := makeTestUser(t, db)
+	authn := registerPasskey(t, svc, user)
+	otherAuthn := registerPasskey(t, svc, other)
+
+	opts, err := svc.BeginSecondFactor(user.ID)
+	require.NoError(t, err)
+	require.Len(t, opts.AllowCredentials, 1)
+
+	// Presence alone is enough after a password.
+	authn.Flags = webauthntest.FlagUserPresent
+	require.NoError(t, svc.FinishSecondFactor(ctx, user.ID, opts.Challenge, authn.Get(opts.Challenge)))
+
+	// Another account's passkey does not satisfy this user's second factor.
+	opts, err = svc.BeginSecondFactor(user.ID)
+	require.NoError(t, err)
+	err = svc.FinishSecondFactor(ctx, user.ID, opts.Challenge, otherAuthn.Get(opts.Challenge))
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+
+	_, err = svc.BeginSecondFactor(makeTestUser(t, db).ID)
+	assert.ErrorIs(t, err, ErrPasskeyNotFound)
+}
+
+func TestDeletePasskey(t *testing.T) {
+	svc, _, db := newPasskeyTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	other := makeTestUser(t, db)
+	registerPasskey(t, svc, user)
+
+	creds, err := svc.ListPasskeys(user.ID)
+	require.NoError(t, err)
+	require.Len(t, creds, 1)
+
+	assert.ErrorIs(t, svc.DeletePasskey(ctx, other.ID, creds[0].ID), ErrPasskeyNotFound)
+
+	require.NoError(t, svc.DeletePasskey(ctx, user.ID, creds[0].ID))
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.WebAuthnEnabled)
+}
+
+func TestAdminResetTwoFactor_RemovesPasskeys(t *testing.T) {
+	svc, userSvc, db := newPasskeyTestService(t)
+	admin := makeTestUser(t, db)
+	user := makeTestUser(t, db)
+	registerPasskey(t, svc, user)
+
+	require.NoError(t, userSvc.AdminResetTwoFactor(context.Background(), user.ID, admin.ID))
+
+	creds, err := svc.ListPasskeys(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, creds)
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.WebAuthnEnabled)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// passwordResetTokenBytes is the entropy of a reset link token.
+	passwordResetTokenBytes = 32
+	// passwordResetMailTimeout bounds delivery of one reset email.
+	passwordResetMailTimeout = 30 * time.Second
+
+	// MinPasswordLength is the shortest password a user may choose.
+	MinPasswordLength = 8
+	// MaxPasswordLength is bcrypt's input limit in bytes; longer passwords
+	// would be silently truncated.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrPasswordResetTokenInvalid = errors.New("this password reset link is invalid or has expired")
+	ErrPasswordTooShort          = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	ErrPasswordTooLong           = fmt.Errorf("password must be at most %d bytes", MaxPasswordLength)
+)
+
+// PasswordResetService lets local users who forgot their password set a new
+// one through a single-use link sent to their email address.
+type PasswordResetService struct {
+	store        core.Store
+	cfg          *config.Config
+	userService  *UserService
+	tokenService *TokenService
+	mailer       core.Mailer
+	auditService core.AuditLogger
+}
+
+func NewPasswordResetService(
+	s core.Store,
+	cfg *config.Config,
+	userService *UserService,
+	tokenService *TokenService,
+	mailer core.Mailer,
+	auditService core.AuditLogger,
+) *PasswordResetService {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	return &PasswordResetService{
+		store:        s,
+		cfg:          cfg,
+		userService:  userService,
+		tokenService: tokenService,
+		mailer:       mailer,
+		auditService: auditService,
+	}
+}
+
+// ValidatePassword checks a user-chosen password against the password policy.
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordLength {
+		return ErrPasswordTooLong
+	}
+	return nil
+}
+
+// RequestReset emails a reset link to the local account registered under
+// email. To avoid revealing which addresses have accounts it returns nil for
+// unknown, external, disabled and rate-limited accounts alike, and sends the
+// email in the background so response time does not differ either. Only
+// storage failures are returned.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.store.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logRequestRefused(ctx, nil, email, "unknown email")
+			return nil
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	switch {
+	case user.IsExternal():
+		s.logRequestRefused(ctx, user, email, "external account")
+		return nil
+	case !user.IsActive:
+		s.logRequestRefused(ctx, user, email, "account disabled")
+		return nil
+	}
+
+	now := time.Now()
+	count, err := s.store.CountPasswordResetTokensSince(user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count reset requests: %w", err)
+	}
+	if count >= int64(s.cfg.PasswordResetMaxPerHour) {
+		s.logRequestRefused(ctx, user, email, "rate limited")
+		return nil
+	}
+
+	raw, err := util.CryptoRandomBytes(passwordResetTokenBytes)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	if err := s.store.CreatePasswordResetToken(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: util.SHA256Hex(token),
+		ExpiresAt: now.Add(s.cfg.PasswordResetTokenTTL),
+		RequestIP: util.GetIPFromContext(ctx),
+	}); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasswordResetRequested,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Password reset link requested",
+		Success:      true,
+	})
+
+	msg := s.resetMessage(user, token)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("[PasswordReset] Failed to send reset email to user %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ValidateToken reports whether token is a usable reset link, so the reset
+// form is only shown for links that can still succeed.
+func (s *PasswordResetService) ValidateToken(token string) error {
+	_, err := s.lookupToken(token)
+	return err
+}
+
+// ResetPassword sets a new password for the owner of token and consumes it.
+// Every other reset link, browser session and OAuth token of the user is
+// revoked, so whoever knew the old password loses access.
+func (s *PasswordResetService) ResetPassword(
+	ctx context.Context,
+	token, newPassword string,
+) (*models.User, error) {
+	if err := ValidatePassword(newPassword); err != nil {
+		return nil, err
+	}
+	resetToken, err := s.lookupToken(token)
+	if err != nil {
+		s.logResetFailure(ctx, "", "invalid or expired token")
+		return nil, err
+	}
+	user, err := s.userService.AdminGetUserByID(resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrPasswordResetTokenInvalid
+		}
+		return nil, err
+	}
+	if user.IsExternal() || !user.IsActive {
+		s.logResetFailure(ctx, user.ID, "account not eligible")
+		return nil, ErrPasswordResetTokenInvalid
+	}
+
+	now := time.Now()
+	ok, err := s.store.ConsumePasswordResetToken(resetToken.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	if !ok {
+		s.logResetFailure(ctx, user.ID, "token already used")
+		return nil, ErrPasswordResetTokenInvalid
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = string(hash)
+	user.SessionsRevokedAt = &now
+	if err := s.store.UpdateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to update user password: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	if err := s.store.DeletePasswordResetTokensByUserID(user.ID); err != nil {
+		log.Printf("[PasswordReset] Failed to delete reset tokens for user %s: %v", user.ID, err)
+	}
+	if err := s.tokenService.RevokeAllUserTokens(user.ID); err != nil {
+		log.Printf("[PasswordReset] Failed to revoke tokens for user %s: %v", user.ID, err)
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasswordResetCompleted,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Password reset by user via email link",
+		Success:      true,
+	})
+	return user, nil
+}
+
+func (s *PasswordResetService) lookupToken(token string) (*models.PasswordResetToken, error) {
+	if token == "" {
+		return nil, ErrPasswordResetTokenInvalid
+	}
+	resetToken, err := s.store.GetPasswordResetTokenByHash(util.SHA256Hex(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasswordResetTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to look up reset token: %w", err)
+	}
+	if resetToken.UsedAt != nil || resetToken.IsExpired() {
+		return nil, ErrPasswordResetTokenInvalid
+	}
+	return resetToken, nil
+}
+
+func (s *PasswordResetService) resetMessage(user *models.User, token string) core.MailMessage {
+	link := strings.TrimRight(s.cfg.BaseURL, "/") + "/password/reset?token=" + token
+	return core.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your AuthGate password",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Someone asked to reset the password for your AuthGate account %q.\n"+
+				"Open the link below within %d minutes to choose a new password:\n\n"+
+				"%s\n\n"+
+				"The link works once. If you did not ask for this, ignore this email;\n"+
+				"your password will not change.\n",
+			displayName(user), user.Username, int(s.cfg.PasswordResetTokenTTL.Minutes()), link,
+		),
+	}
+}
+
+// logRequestRefused records a reset request that did not send an email.
+// user is nil when no account matches the address.
+func (s *PasswordResetService) logRequestRefused(
+	ctx context.Context,
+	user *models.User,
+	email, reason string,
+) {
+	entry := core.AuditLogEntry{
+		EventType:    models.EventPasswordResetRequested,
+		Severity:     models.SeverityWarning,
+		ResourceType: models.ResourceUser,
+		ResourceName: email,
+		Action:       "Password reset link not sent",
+		Details:      models.AuditDetails{"reason": reason},
+		Success:      false,
+	}
+	if user != nil {
+		entry.ActorUserID = user.ID
+		entry.ResourceID = user.ID
+		entry.ResourceName = user.Username
+	}
+	s.auditService.Log(ctx, entry)
+}
+
+func (s *PasswordResetService) logResetFailure(ctx context.Context, userID, reason string) {
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPasswordResetFailed,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		Action:       "Password reset rejected",
+		Details:      models.AuditDetails{"reason": reason},
+		Success:      false,
+	})
+}
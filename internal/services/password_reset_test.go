@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var resetLinkPattern = regexp.MustCompile(`https://\S+/password/reset\?token=\S+`)
+
+func newPasswordResetTestService(t *testing.T) (*PasswordResetService, chanMailer, *store.Store) {
+	t.Helper()
+	f := newMailTestFixture(t, &config.Config{
+		PasswordResetTokenTTL:   30 * time.Minute,
+		PasswordResetMaxPerHour: 2,
+	})
+	svc := NewPasswordResetService(
+		f.db, f.cfg, f.users, createTestTokenService(t, f.db, f.cfg), f.mailer, nil,
+	)
+	return svc, f.mailer, f.db
+}
+
+func TestPasswordReset_Flow(t *testing.T) {
+	svc, mailer, db := newPasswordResetTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	client := createTestClient(t, db, true)
+	require.NoError(t, db.CreateAccessToken(&models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     uuid.New().String(),
+		TokenCategory: models.TokenCategoryAccess,
+		Status:        models.TokenStatusActive,
+		UserID:        user.ID,
+		ClientID:      client.ClientID,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}))
+
+	require.NoError(t, svc.RequestReset(ctx, "  "+user.Email+" "))
+	msg, token := receiveMailToken(t, mailer, resetLinkPattern)
+	assert.Equal(t, user.Email, msg.To)
+	assert.True(t, strings.HasPrefix(
+		resetLinkPattern.FindString(msg.Text), "https://auth.example.com/password/reset?token=",
+	))
+	require.NoError(t, svc.ValidateToken(token))
+
+	got, err := svc.ResetPassword(ctx, token, "correct horse battery")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	require.NoError(t, bcrypt.CompareHashAndPassword(
+		[]byte(stored.PasswordHash), []byte("correct horse battery"),
+	))
+	require.NotNil(t, stored.SessionsRevokedAt)
+
+	tokens, err := db.GetTokensByUserID(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens, "OAuth tokens are revoked")
+
+	// The link works once.
+	_, err = svc.ResetPassword(ctx, token, "another password")
+	require.ErrorIs(t, err, ErrPasswordResetTokenInvalid)
+	assert.ErrorIs(t, svc.ValidateToken(token), ErrPasswordResetTokenInvalid)
+}
+
+func TestPasswordReset_OtherLinksRevoked(t *testing.T) {
+	svc, mailer, db := newPasswordResetTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+
+	require.NoError(t, svc.RequestReset(ctx, user.Email))
+	_, first := receiveMailToken(t, mailer, resetLinkPattern)
+	require.NoError(t, svc.RequestReset(ctx, user.Email))
+	_, second := receiveMailToken(t, mailer, resetLinkPattern)
+
+	_, err := svc.ResetPassword(ctx, second, "correct horse battery")
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.ValidateToken(first), ErrPasswordResetTokenInvalid)
+}
+
+func TestPasswordReset_RequestSilentlyIgnored(t *testing.T) {
+	svc, mailer, db := newPasswordResetTestService(t)
+	ctx := context.Background()
+
+	disabled := makeTestUser(t, db)
+	disabled.IsActive = false
+	require.NoError(t, db.UpdateUser(disabled))
+
+	for name, email := range map[string]string{
+		"unknown email": "nobody@example.com",
+		"external user": makeTestHTTPAPIUser(t, db).Email,
+		"disabled user": disabled.Email,
+		"empty":         "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, svc.RequestReset(ctx, email))
+			assertNoEmail(t, mailer)
+		})
+	}
+}
+
+func TestPasswordReset_PerAccountLimit(t *testing.T) {
+	svc, mailer, db := newPasswordResetTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+
+	for range 2 {
+		require.NoError(t, svc.RequestReset(ctx, user.Email))
+		receiveMailToken(t, mailer, resetLinkPattern)
+	}
+	require.NoError(t, svc.RequestReset(ctx, user.Email))
+	assertNoEmail(t, mailer)
+}
+
+func TestPasswordReset_Rejects(t *testing.T) {
+	svc, mailer, db := newPasswordResetTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	require.NoError(t, svc.RequestReset(ctx, user.Email))
+	_, token := receiveMailToken(t, mailer, resetLinkPattern)
+
+	t.Run("short password keeps the link usable", func(t *testing.T) {
+		_, err := svc.ResetPassword(ctx, token, "short")
+		require.ErrorIs(t, err, ErrPasswordTooShort)
+		assert.NoError(t, svc.ValidateToken(token))
+	})
+
+	t.Run("password over bcrypt limit", func(t *testing.T) {
+		_, err := svc.ResetPassword(ctx, token, strings.Repeat("a", MaxPasswordLength+1))
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := svc.ResetPassword(ctx, "not-a-token", "correct horse battery")
+		assert.ErrorIs(t, err, ErrPasswordResetTokenInvalid)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		other := makeTestUser(t, db)
+		svc.cfg.PasswordResetTokenTTL = 0
+		defer func() { svc.cfg.PasswordResetTokenTTL = 30 * time.Minute }()
+		require.NoError(t, svc.RequestReset(ctx, other.Email))
+		_, expired := receiveMailToken(t, mailer, resetLinkPattern)
+
+		_, err := svc.ResetPassword(ctx, expired, "correct horse battery")
+		assert.ErrorIs(t, err, ErrPasswordResetTokenInvalid)
+	})
+
+	t.Run("account disabled after request", func(t *testing.T) {
+		user.IsActive = false
+		require.NoError(t, db.UpdateUser(user))
+		_, err := svc.ResetPassword(ctx, token, "correct horse battery")
+		assert.ErrorIs(t, err, ErrPasswordResetTokenInvalid)
+	})
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// emailChangeTokenBytes is the entropy of an email change link token.
+	emailChangeTokenBytes = 32
+	// emailChangeMaxPerHour caps confirmation emails sent per account.
+	emailChangeMaxPerHour = 3
+	// emailChangeSignInWindow is how recently a user without a password
+	// must have signed in to change their email address.
+	emailChangeSignInWindow = 10 * time.Minute
+	// profileMailTimeout bounds delivery of one profile email.
+	profileMailTimeout = 30 * time.Second
+
+	maxFullNameLength  = 255
+	maxAvatarURLLength = 2048
+)
+
+var (
+	ErrProfileManagedExternally = errors.New("your profile is managed by your sign-in provider")
+	ErrFullNameTooLong          = fmt.Errorf(
+		"full name must be at most %d characters", maxFullNameLength,
+	)
+	ErrAvatarURLInvalid         = errors.New("avatar URL must be an https:// address")
+	ErrCurrentPasswordIncorrect = errors.New("current password is incorrect")
+	ErrNoPasswordSet            = errors.New("your account signs in without a password")
+	ErrEmailChangeDisabled      = errors.New("email address changes are not enabled")
+	ErrEmailUnchanged           = errors.New("that is already your email address")
+	ErrEmailDomainNotAllowed    = errors.New("email addresses at this domain are not allowed")
+	ErrEmailChangeRateLimited   = errors.New("too many email change requests, try again later")
+	ErrEmailChangeTokenInvalid  = errors.New(
+		"this email confirmation link is invalid or has expired",
+	)
+	ErrRecentSignInRequired = errors.New(
+		"sign out and sign in again before changing your email address",
+	)
+	ErrLastLoginMethod = errors.New("cannot unlink your only way to sign in")
+)
+
+// ProfileUpdate carries the profile fields a user may edit themselves.
+type ProfileUpdate struct {
+	FullName  string
+	AvatarURL string
+}
+
+// ProfileService lets signed-in users manage their own profile, password,
+// email address and linked OAuth accounts. Users from an external password
+// provider (http_api) get their profile from that provider and cannot edit
+// it here.
+type ProfileService struct {
+	store        core.Store
+	cfg          *config.Config
+	userService  *UserService
+	tokenService *TokenService
+	mailer       core.Mailer // nil unless EMAIL_CHANGE_ENABLED
+	auditService core.AuditLogger
+}
+
+func NewProfileService(
+	s core.Store,
+	cfg *config.Config,
+	userService *UserService,
+	tokenService *TokenService,
+	mailer core.Mailer,
+	auditService core.AuditLogger,
+) *ProfileService {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	return &ProfileService{
+		store:        s,
+		cfg:          cfg,
+		userService:  userService,
+		tokenService: tokenService,
+		mailer:       mailer,
+		auditService: auditService,
+	}
+}
+
+// EmailChangeEnabled reports whether users may change their email address.
+func (s *ProfileService) EmailChangeEnabled() bool {
+	return s.cfg.EmailChangeEnabled && s.mailer != nil
+}
+
+// HasPassword reports whether user signs in with a password: local accounts
+// that have one set, and every account of an external password provider.
+// Accounts created through OAuth have none.
+func HasPassword(user *models.User) bool {
+	return user.IsExternal() || user.PasswordHash != ""
+}
+
+// CanUnlinkConnection reports whether user, who has connections linked OAuth
+// accounts, keeps a way to sign in after unlinking one of them.
+func (s *ProfileService) CanUnlinkConnection(user *models.User, connections int) bool {
+	return HasPassword(user) || connections > 1 ||
+		(s.cfg.WebAuthnEnabled && user.WebAuthnEnabled)
+}
+
+// UpdateProfile changes the user's full name and avatar.
+func (s *ProfileService) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	req ProfileUpdate,
+) error {
+	user, err := s.editableUser(userID)
+	if err != nil {
+		return err
+	}
+
+	fullName := strings.TrimSpace(req.FullName)
+	avatarURL := strings.TrimSpace(req.AvatarURL)
+	if len([]rune(fullName)) > maxFullNameLength {
+		return ErrFullNameTooLong
+	}
+	if avatarURL != "" {
+		u, err := url.Parse(avatarURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" ||
+			len(avatarURL) > maxAvatarURLLength {
+			return ErrAvatarURLInvalid
+		}
+	}
+	if fullName == user.FullName && avatarURL == user.AvatarURL {
+		return nil
+	}
+
+	user.FullName = fullName
+	user.AvatarURL = avatarURL
+	if err := s.store.UpdateUser(user); err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	s.logProfile(ctx, models.EventProfileUpdated, models.SeverityInfo, user,
+		"Profile updated by user", models.AuditDetails{
+			"full_name":  fullName,
+			"avatar_url": avatarURL,
+		}, true)
+	return nil
+}
+
+// ChangePassword replaces the user's password after checking the current
+// one. Outstanding reset links stop working. With signOutOthers, every
+// browser session signed in until now ends and all OAuth tokens are revoked;
+// the caller keeps its own session by stamping it with a sign-in time after
+// the returned user's SessionsRevokedAt.
+func (s *ProfileService) ChangePassword(
+	ctx context.Context,
+	userID, currentPassword, newPassword string,
+	signOutOthers bool,
+) (*models.User, error) {
+	user, err := s.editableUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !HasPassword(user) {
+		return nil, ErrNoPasswordSet
+	}
+	if err := s.checkPassword(ctx, user, currentPassword, models.EventPasswordChanged); err != nil {
+		return nil, err
+	}
+	if err := ValidatePassword(newPassword); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = string(hash)
+	if signOutOthers {
+		now := time.Now()
+		user.SessionsRevokedAt = &now
+	}
+	if err := s.store.UpdateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to update user password: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	if err := s.store.DeletePasswordResetTokensByUserID(user.ID); err != nil {
+		log.Printf("[Profile] Failed to delete reset tokens for user %s: %v", user.ID, err)
+	}
+	if signOutOthers {
+		if err := s.tokenService.RevokeAllUserTokens(user.ID); err != nil {
+			log.Printf("[Profile] Failed to revoke tokens for user %s: %v", user.ID, err)
+		}
+	}
+
+	s.logProfile(ctx, models.EventPasswordChanged, models.SeverityWarning, user,
+		"Password changed by user", models.AuditDetails{
+			"signed_out_other_sessions": signOutOthers,
+		}, true)
+	return user, nil
+}
+
+// RequestEmailChange emails a confirmation link to newEmail and tells the
+// current address about the request. The address replaces the current one
+// only when the link is followed. Users with a password must enter it;
+// users without one must have signed in (signedInAt) within the last few
+// minutes, so a stolen session cannot be used to move the account to an
+// address its holder controls. As with signup, an address that already
+// belongs to another account gets no email and no error, so the form cannot
+// be used to discover who has an account.
+func (s *ProfileService) RequestEmailChange(
+	ctx context.Context,
+	userID, currentPassword, newEmail string,
+	signedInAt time.Time,
+) error {
+	if !s.EmailChangeEnabled() {
+		return ErrEmailChangeDisabled
+	}
+	user, err := s.editableUser(userID)
+	if err != nil {
+		return err
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if newEmail == "" {
+		return ErrEmailRequired
+	}
+	if !validEmail(newEmail) {
+		return ErrEmailInvalid
+	}
+	if strings.EqualFold(newEmail, strings.TrimSpace(user.Email)) {
+		return ErrEmailUnchanged
+	}
+	if !emailDomainAllowed(s.cfg.SignupEmailDomains, newEmail) {
+		return ErrEmailDomainNotAllowed
+	}
+	if HasPassword(user) {
+		err := s.checkPassword(ctx, user, currentPassword, models.EventEmailChangeRequested)
+		if err != nil {
+			return err
+		}
+	} else if time.Since(signedInAt) > emailChangeSignInWindow {
+		s.logProfile(ctx, models.EventEmailChangeRequested, models.SeverityWarning, user,
+			"Email change refused: sign-in is not recent", models.AuditDetails{
+				"new_email": newEmail,
+			}, false)
+		return ErrRecentSignInRequired
+	}
+
+	now := time.Now()
+	count, err := s.store.CountEmailChangeTokensSince(user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count email change requests: %w", err)
+	}
+	if count >= emailChangeMaxPerHour {
+		return ErrEmailChangeRateLimited
+	}
+
+	if existing, err := s.store.GetUserByEmail(newEmail); err == nil {
+		if existing.ID != user.ID {
+			s.logProfile(ctx, models.EventEmailChangeRequested, models.SeverityWarning, user,
+				"Email change link not sent", models.AuditDetails{
+					"new_email": newEmail,
+					"reason":    "email already registered",
+				}, false)
+			return nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check email uniqueness: %w", err)
+	}
+
+	raw, err := util.CryptoRandomBytes(emailChangeTokenBytes)
+	if err != nil {
+		return fmt.Errorf("failed to generate email change token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	if err := s.store.CreateEmailChangeToken(&models.EmailChangeToken{
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		TokenHash: util.SHA256Hex(token),
+		ExpiresAt: now.Add(s.cfg.EmailChangeTokenTTL),
+		RequestIP: util.GetIPFromContext(ctx),
+	}); err != nil {
+		return fmt.Errorf("failed to store email change token: %w", err)
+	}
+
+	s.logProfile(ctx, models.EventEmailChangeRequested, models.SeverityInfo, user,
+		"Email change link requested", models.AuditDetails{"new_email": newEmail}, true)
+	msgs := []core.MailMessage{s.confirmationMessage(user, newEmail, token)}
+	if user.Email != "" {
+		msgs = append(msgs, s.requestedNoticeMessage(user, newEmail))
+	}
+	s.send(ctx, user, msgs...)
+	return nil
+}
+
+// ConfirmEmailChange switches the user's email to the address token was
+// sent to and marks it verified. The link only works for the account that
+// requested it; the previous address is told about the change.
+func (s *ProfileService) ConfirmEmailChange(
+	ctx context.Context,
+	userID, token string,
+) (*models.User, error) {
+	if token == "" {
+		return nil, ErrEmailChangeTokenInvalid
+	}
+	changeToken, err := s.store.GetEmailChangeTokenByHash(util.SHA256Hex(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmailChangeTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to look up email change token: %w", err)
+	}
+	if changeToken.UserID != userID || changeToken.UsedAt != nil || changeToken.IsExpired() {
+		return nil, ErrEmailChangeTokenInvalid
+	}
+	user, err := s.editableUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.store.ConsumeEmailChangeToken(changeToken.ID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume email change token: %w", err)
+	}
+	if !ok {
+		return nil, ErrEmailChangeTokenInvalid
+	}
+
+	// Someone may have taken the address since the link was sent.
+	if existing, err := s.store.GetUserByEmail(changeToken.NewEmail); err == nil {
+		if existing.ID != user.ID {
+			return nil, ErrEmailConflict
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check email uniqueness: %w", err)
+	}
+
+	oldEmail := user.Email
+	user.Email = changeToken.NewEmail
+	user.EmailVerified = true
+	if err := s.store.UpdateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	if err := s.store.DeleteEmailChangeTokensByUserID(user.ID); err != nil {
+		log.Printf("[Profile] Failed to delete email change tokens for user %s: %v", user.ID, err)
+	}
+
+	s.logProfile(ctx, models.EventEmailChanged, models.SeverityWarning, user,
+		"Email changed by user", models.AuditDetails{
+			"old_email": oldEmail,
+			"new_email": user.Email,
+		}, true)
+	if oldEmail != "" {
+		s.send(ctx, user, s.changedNoticeMessage(user, oldEmail))
+	}
+	return user, nil
+}
+
+// UnlinkOAuthConnection removes one of the user's linked OAuth accounts,
+// provided another way to sign in remains.
+func (s *ProfileService) UnlinkOAuthConnection(
+	ctx context.Context,
+	userID, connectionID string,
+) error {
+	user, err := s.userService.AdminGetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	target, err := s.store.GetOAuthConnectionByUserAndID(userID, connectionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOAuthConnectionNotFound
+		}
+		return fmt.Errorf("failed to look up OAuth connection: %w", err)
+	}
+	connections, err := s.store.GetOAuthConnectionsByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to list OAuth connections: %w", err)
+	}
+	if !s.CanUnlinkConnection(user, len(connections)) {
+		return ErrLastLoginMethod
+	}
+
+	if err := s.store.DeleteOAuthConnection(connectionID); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventOAuthConnectionDeleted,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: target.Provider + ":" + target.ProviderUsername,
+		Action:       "OAuth connection unlinked by user",
+		Details: models.AuditDetails{
+			"provider":          target.Provider,
+			"provider_username": target.ProviderUsername,
+			"connection_id":     connectionID,
+		},
+		Success: true,
+	})
+	return nil
+}
+
+// editableUser loads a user whose profile can be edited here.
+func (s *ProfileService) editableUser(userID string) (*models.User, error) {
+	user, err := s.userService.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsExternal() {
+		return nil, ErrProfileManagedExternally
+	}
+	return user, nil
+}
+
+// checkPassword verifies the password a user entered to confirm a change,
+// recording a wrong guess in the audit log as a failed event.
+func (s *ProfileService) checkPassword(
+	ctx context.Context,
+	user *models.User,
+	password string,
+	event models.EventType,
+) error {
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil {
+		return nil
+	}
+	s.logProfile(ctx, event, models.SeverityWarning, user,
+		"Current password rejected", nil, false)
+	return ErrCurrentPasswordIncorrect
+}
+
+// send delivers msgs in order in the background so a slow mail server does
+// not hold up the request.
+func (s *ProfileService) send(ctx context.Context, user *models.User, msgs ...core.MailMessage) {
+	go func() {
+		for _, msg := range msgs {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), profileMailTimeout)
+			if err := s.mailer.Send(ctx, msg); err != nil {
+				log.Printf("[Profile] Failed to send email to user %s: %v", user.ID, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+func (s *ProfileService) confirmationMessage(
+	user *models.User,
+	newEmail, token string,
+) core.MailMessage {
+	link := strings.TrimRight(s.cfg.BaseURL, "/") + "/account/profile/email/confirm?token=" + token
+	return core.MailMessage{
+		To:      newEmail,
+		Subject: "Confirm your new email for AuthGate",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"You asked to use this address for your AuthGate account %q.\n"+
+				"Open the link below within %s, signed in as %s, to confirm it:\n\n"+
+				"%s\n\n"+
+				"If you did not ask for this, ignore this email; nothing will change.\n",
+			displayName(user), user.Username, s.cfg.EmailChangeTokenTTL, user.Username, link,
+		),
+	}
+}
+
+// requestedNoticeMessage warns the current address that a change to
+// newEmail is pending, while there is still time to stop it.
+func (s *ProfileService) requestedNoticeMessage(
+	user *models.User,
+	newEmail string,
+) core.MailMessage {
+	return core.MailMessage{
+		To:      user.Email,
+		Subject: "An email address change was requested for your AuthGate account",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Someone signed in to your AuthGate account %q asked to change its\n"+
+				"email address to %s.\n"+
+				"Nothing changes unless the link sent to that address is opened within %s.\n"+
+				"If you did not ask for this, contact your administrator now.\n",
+			displayName(user), user.Username, newEmail, s.cfg.EmailChangeTokenTTL,
+		),
+	}
+}
+
+func (s *ProfileService) changedNoticeMessage(user *models.User, oldEmail string) core.MailMessage {
+	return core.MailMessage{
+		To:      oldEmail,
+		Subject: "Your AuthGate email address was changed",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"The email address of your AuthGate account %q was changed to %s.\n"+
+				"If you did not make this change, contact your administrator.\n",
+			displayName(user), user.Username, user.Email,
+		),
+	}
+}
+
+// logProfile records a change a user made to their own account.
+func (s *ProfileService) logProfile(
+	ctx context.Context,
+	event models.EventType,
+	severity models.EventSeverity,
+	user *models.User,
+	action string,
+	details models.AuditDetails,
+	success bool,
+) {
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    event,
+		Severity:     severity,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       action,
+		Details:      details,
+		Success:      success,
+	})
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var emailChangeLinkPattern = regexp.MustCompile(
+	`https://\S+/account/profile/email/confirm\?token=\S+`,
+)
+
+func newProfileTestService(t *testing.T) (*ProfileService, chanMailer, *store.Store) {
+	t.Helper()
+	f := newMailTestFixture(t, &config.Config{
+		EmailChangeEnabled:  true,
+		EmailChangeTokenTTL: 24 * time.Hour,
+	})
+	svc := NewProfileService(
+		f.db, f.cfg, f.users, createTestTokenService(t, f.db, f.cfg), f.mailer, nil,
+	)
+	return svc, f.mailer, f.db
+}
+
+// makeProfileTestUser creates a local user whose password is "old password".
+func makeProfileTestUser(t *testing.T, db *store.Store) *models.User {
+	t.Helper()
+	user := makeTestUser(t, db)
+	hash, err := bcrypt.GenerateFromPassword([]byte("old password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user.PasswordHash = string(hash)
+	require.NoError(t, db.UpdateUser(user))
+	return user
+}
+
+func TestUpdateProfile(t *testing.T) {
+	svc, _, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+
+	require.NoError(t, svc.UpdateProfile(ctx, user.ID, ProfileUpdate{
+		FullName:  "  Alice Liddell ",
+		AvatarURL: "https://example.com/alice.png",
+	}))
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Liddell", stored.FullName)
+	assert.Equal(t, "https://example.com/alice.png", stored.AvatarURL)
+
+	for name, avatar := range map[string]string{
+		"http":     "http://example.com/alice.png",
+		"no host":  "https:///alice.png",
+		"relative": "/alice.png",
+		"too long": "https://example.com/" + strings.Repeat("a", maxAvatarURLLength),
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := svc.UpdateProfile(ctx, user.ID, ProfileUpdate{AvatarURL: avatar})
+			assert.ErrorIs(t, err, ErrAvatarURLInvalid)
+		})
+	}
+
+	err = svc.UpdateProfile(ctx, user.ID, ProfileUpdate{
+		FullName: strings.Repeat("a", maxFullNameLength+1),
+	})
+	require.ErrorIs(t, err, ErrFullNameTooLong)
+
+	external := makeTestHTTPAPIUser(t, db)
+	err = svc.UpdateProfile(ctx, external.ID, ProfileUpdate{FullName: "Mallory"})
+	assert.ErrorIs(t, err, ErrProfileManagedExternally)
+}
+
+func TestChangePassword(t *testing.T) {
+	svc, _, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+
+	_, err := svc.ChangePassword(ctx, user.ID, "wrong password", "correct horse battery", false)
+	require.ErrorIs(t, err, ErrCurrentPasswordIncorrect)
+	_, err = svc.ChangePassword(ctx, user.ID, "old password", "short", false)
+	require.ErrorIs(t, err, ErrPasswordTooShort)
+
+	got, err := svc.ChangePassword(ctx, user.ID, "old password", "correct horse battery", false)
+	require.NoError(t, err)
+	assert.Nil(t, got.SessionsRevokedAt, "other sessions are kept")
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(
+		[]byte(stored.PasswordHash), []byte("correct horse battery"),
+	))
+}
+
+func TestChangePassword_SignOutOthers(t *testing.T) {
+	svc, _, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+	client := createTestClient(t, db, true)
+	require.NoError(t, db.CreateAccessToken(&models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     uuid.New().String(),
+		TokenCategory: models.TokenCategoryAccess,
+		Status:        models.TokenStatusActive,
+		UserID:        user.ID,
+		ClientID:      client.ClientID,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}))
+
+	got, err := svc.ChangePassword(ctx, user.ID, "old password", "correct horse battery", true)
+	require.NoError(t, err)
+	require.NotNil(t, got.SessionsRevokedAt)
+
+	tokens, err := db.GetTokensByUserID(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens, "OAuth tokens are revoked")
+}
+
+func TestChangePassword_Refused(t *testing.T) {
+	svc, _, db := newProfileTestService(t)
+	ctx := context.Background()
+
+	oauthUser := makeTestUser(t, db)
+	oauthUser.PasswordHash = ""
+	require.NoError(t, db.UpdateUser(oauthUser))
+	_, err := svc.ChangePassword(ctx, oauthUser.ID, "", "correct horse battery", false)
+	require.ErrorIs(t, err, ErrNoPasswordSet)
+
+	external := makeTestHTTPAPIUser(t, db)
+	_, err = svc.ChangePassword(ctx, external.ID, "x", "correct horse battery", false)
+	assert.ErrorIs(t, err, ErrProfileManagedExternally)
+}
+
+func TestEmailChange_Flow(t *testing.T) {
+	svc, mailer, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+	oldEmail := user.Email
+
+	require.NoError(t, svc.RequestEmailChange(
+		ctx, user.ID, "old password", " New@Example.org ", time.Now(),
+	))
+	msg, token := receiveMailToken(t, mailer, emailChangeLinkPattern)
+	assert.Equal(t, "New@Example.org", msg.To)
+	select {
+	case notice := <-mailer:
+		assert.Equal(t, oldEmail, notice.To, "old address hears of the request first")
+		assert.Contains(t, notice.Text, "New@Example.org")
+	case <-time.After(5 * time.Second):
+		t.Fatal("old address was not told about the request")
+	}
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, oldEmail, stored.Email, "email is unchanged until confirmed")
+
+	other := makeTestUser(t, db)
+	_, err = svc.ConfirmEmailChange(ctx, other.ID, token)
+	require.ErrorIs(t, err, ErrEmailChangeTokenInvalid, "link belongs to another account")
+
+	got, err := svc.ConfirmEmailChange(ctx, user.ID, token)
+	require.NoError(t, err)
+	assert.Equal(t, "New@Example.org", got.Email)
+	assert.True(t, got.EmailVerified)
+
+	select {
+	case notice := <-mailer:
+		assert.Equal(t, oldEmail, notice.To)
+		assert.Contains(t, notice.Text, "New@Example.org")
+	case <-time.After(5 * time.Second):
+		t.Fatal("old address was not notified")
+	}
+
+	// The link works once.
+	_, err = svc.ConfirmEmailChange(ctx, user.ID, token)
+	assert.ErrorIs(t, err, ErrEmailChangeTokenInvalid)
+}
+
+func TestEmailChange_Rejects(t *testing.T) {
+	svc, mailer, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     error
+	}{
+		{"empty", "old password", " ", ErrEmailRequired},
+		{"invalid", "old password", "not-an-email", ErrEmailInvalid},
+		{"unchanged", "old password", strings.ToUpper(user.Email), ErrEmailUnchanged},
+		{"wrong password", "wrong password", "new@example.org", ErrCurrentPasswordIncorrect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.RequestEmailChange(ctx, user.ID, tt.password, tt.email, time.Now())
+			require.ErrorIs(t, err, tt.want)
+			assertNoEmail(t, mailer)
+		})
+	}
+
+	t.Run("domain not allowed", func(t *testing.T) {
+		svc.cfg.SignupEmailDomains = []string{"example.com"}
+		defer func() { svc.cfg.SignupEmailDomains = nil }()
+		err := svc.RequestEmailChange(ctx, user.ID, "old password", "new@example.org", time.Now())
+		assert.ErrorIs(t, err, ErrEmailDomainNotAllowed)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		svc.cfg.EmailChangeEnabled = false
+		defer func() { svc.cfg.EmailChangeEnabled = true }()
+		err := svc.RequestEmailChange(ctx, user.ID, "old password", "new@example.org", time.Now())
+		assert.ErrorIs(t, err, ErrEmailChangeDisabled)
+	})
+}
+
+func TestEmailChange_TakenAddressIsSilent(t *testing.T) {
+	svc, mailer, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+	other := makeTestUser(t, db)
+
+	err := svc.RequestEmailChange(ctx, user.ID, "old password", other.Email, time.Now())
+	require.NoError(t, err)
+	assertNoEmail(t, mailer)
+}
+
+func TestEmailChange_RateLimited(t *testing.T) {
+	svc, mailer, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeProfileTestUser(t, db)
+
+	for i := range emailChangeMaxPerHour {
+		email := "new" + string(rune('a'+i)) + "@example.org"
+		require.NoError(t, svc.RequestEmailChange(ctx, user.ID, "old password", email, time.Now()))
+		receiveMailToken(t, mailer, emailChangeLinkPattern)
+		<-mailer // notice to the current address
+	}
+	err := svc.RequestEmailChange(ctx, user.ID, "old password", "another@example.org", time.Now())
+	require.ErrorIs(t, err, ErrEmailChangeRateLimited)
+	assertNoEmail(t, mailer)
+}
+
+func TestEmailChange_NoPasswordNeedsRecentSignIn(t *testing.T) {
+	svc, mailer, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	user.PasswordHash = ""
+	require.NoError(t, db.UpdateUser(user))
+
+	for name, signedInAt := range map[string]time.Time{
+		"stale sign-in":   time.Now().Add(-emailChangeSignInWindow - time.Minute),
+		"no sign-in time": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := svc.RequestEmailChange(ctx, user.ID, "", "new@example.org", signedInAt)
+			require.ErrorIs(t, err, ErrRecentSignInRequired)
+			assertNoEmail(t, mailer)
+		})
+	}
+
+	signedInAt := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.RequestEmailChange(ctx, user.ID, "", "new@example.org", signedInAt))
+	msg, _ := receiveMailToken(t, mailer, emailChangeLinkPattern)
+	assert.Equal(t, "new@example.org", msg.To)
+}
+
+func TestUnlinkOAuthConnection(t *testing.T) {
+	svc, _, db := newProfileTestService(t)
+	ctx := context.Background()
+	user := makeTestUser(t, db)
+	user.PasswordHash = ""
+	require.NoError(t, db.UpdateUser(user))
+
+	link := func(provider string) *models.OAuthConnection {
+		conn := &models.OAuthConnection{
+			ID:             uuid.New().String(),
+			UserID:         user.ID,
+			Provider:       provider,
+			ProviderUserID: uuid.New().String(),
+		}
+		require.NoError(t, db.CreateOAuthConnection(conn))
+		return conn
+	}
+	github := link("github")
+	gitlab := link("gitlab")
+
+	require.NoError(t, svc.UnlinkOAuthConnection(ctx, user.ID, github.ID))
+
+	err := svc.UnlinkOAuthConnection(ctx, user.ID, gitlab.ID)
+	require.ErrorIs(t, err, ErrLastLoginMethod)
+
+	other := makeTestUser(t, db)
+	err = svc.UnlinkOAuthConnection(ctx, other.ID, gitlab.ID)
+	assert.ErrorIs(t, err, ErrOAuthConnectionNotFound)
+}
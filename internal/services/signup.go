@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// signupTokenBytes is the entropy of an email verification link token.
+	signupTokenBytes = 32
+	// signupMailTimeout bounds delivery of one signup email.
+	signupMailTimeout = 30 * time.Second
+
+	minSignupUsernameLength = 3
+	maxSignupUsernameLength = 32
+)
+
+var (
+	ErrSignupUsernameInvalid = fmt.Errorf(
+		"username must be %d to %d characters of letters, digits, '-' or '_'",
+		minSignupUsernameLength, maxSignupUsernameLength,
+	)
+	ErrEmailInvalid             = errors.New("enter a valid email address")
+	ErrSignupDomainNotAllowed   = errors.New("sign-up is not open to this email domain")
+	ErrSignupTokenInvalid       = errors.New("this verification link is invalid or has expired")
+	ErrSignupNotPendingApproval = errors.New("user is not awaiting approval")
+)
+
+// SignupRequest carries the fields of the public registration form.
+type SignupRequest struct {
+	Username string
+	Email    string
+	FullName string
+	Password string
+}
+
+// SignupService lets visitors create local accounts. An account stays
+// inactive until its owner follows the link emailed to them and, when
+// SIGNUP_REQUIRE_APPROVAL is set, until an admin approves it.
+type SignupService struct {
+	store        core.Store
+	cfg          *config.Config
+	userService  *UserService
+	mailer       core.Mailer
+	auditService core.AuditLogger
+}
+
+func NewSignupService(
+	s core.Store,
+	cfg *config.Config,
+	userService *UserService,
+	mailer core.Mailer,
+	auditService core.AuditLogger,
+) *SignupService {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	return &SignupService{
+		store:        s,
+		cfg:          cfg,
+		userService:  userService,
+		mailer:       mailer,
+		auditService: auditService,
+	}
+}
+
+// Register creates an unverified account and emails its verification link.
+// Validation errors are returned so the form can explain them, but an email
+// address that already has an account is not: Register returns nil without
+// sending anything, so the form cannot be used to discover who has an
+// account.
+func (s *SignupService) Register(ctx context.Context, req SignupRequest) error {
+	username := strings.TrimSpace(req.Username)
+	email := strings.TrimSpace(req.Email)
+	fullName := strings.TrimSpace(req.FullName)
+
+	if username == "" {
+		return ErrUsernameRequired
+	}
+	if len(username) < minSignupUsernameLength || len(username) > maxSignupUsernameLength ||
+		sanitizeUsername(username) != strings.ToLower(username) {
+		return ErrSignupUsernameInvalid
+	}
+	username = strings.ToLower(username)
+	if email == "" {
+		return ErrEmailRequired
+	}
+	if !validEmail(email) {
+		return ErrEmailInvalid
+	}
+	if !emailDomainAllowed(s.cfg.SignupEmailDomains, email) {
+		return ErrSignupDomainNotAllowed
+	}
+	if err := ValidatePassword(req.Password); err != nil {
+		return err
+	}
+
+	// Free names and addresses held by signups that were never verified.
+	now := time.Now()
+	if _, err := s.store.DeleteExpiredSignups(now.Add(-s.cfg.SignupVerificationTTL)); err != nil {
+		return fmt.Errorf("failed to delete expired signups: %w", err)
+	}
+
+	if _, err := s.store.GetUserByUsername(username); err == nil {
+		return ErrUsernameConflict
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check username uniqueness: %w", err)
+	}
+	if existing, err := s.store.GetUserByEmail(email); err == nil {
+		s.logSignup(ctx, models.EventSignupRequested, existing,
+			"Signup refused", "email already registered")
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check email uniqueness: %w", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	raw, err := util.CryptoRandomBytes(signupTokenBytes)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		Email:        email,
+		FullName:     fullName,
+		Role:         models.UserRoleUser,
+		PasswordHash: string(hash),
+		AuthSource:   models.AuthSourceLocal,
+		SignupStatus: models.SignupStatusUnverified,
+	}
+	if err := s.store.CreateSignupUser(user, &models.EmailVerificationToken{
+		TokenHash: util.SHA256Hex(token),
+		ExpiresAt: now.Add(s.cfg.SignupVerificationTTL),
+		RequestIP: util.GetIPFromContext(ctx),
+	}); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// Lost a race with a concurrent signup or admin create.
+			if _, lookupErr := s.store.GetUserByUsername(username); lookupErr == nil {
+				return ErrUsernameConflict
+			}
+			return nil
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logSignup(ctx, models.EventSignupRequested, user, "User signed up", "")
+	s.send(ctx, user, s.verificationMessage(user, token))
+	return nil
+}
+
+// VerifyEmail consumes a verification link and marks the owner's email as
+// verified. The account becomes active unless admin approval is required,
+// in which case it joins the approval queue.
+func (s *SignupService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrSignupTokenInvalid
+	}
+	verifyToken, err := s.store.GetEmailVerificationTokenByHash(util.SHA256Hex(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSignupTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to look up verification token: %w", err)
+	}
+	if verifyToken.UsedAt != nil || verifyToken.IsExpired() {
+		return nil, ErrSignupTokenInvalid
+	}
+	user, err := s.userService.AdminGetUserByID(verifyToken.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrSignupTokenInvalid
+		}
+		return nil, err
+	}
+	if user.SignupStatus != models.SignupStatusUnverified {
+		return nil, ErrSignupTokenInvalid
+	}
+
+	ok, err := s.store.ConsumeEmailVerificationToken(verifyToken.ID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume verification token: %w", err)
+	}
+	if !ok {
+		return nil, ErrSignupTokenInvalid
+	}
+
+	user.EmailVerified = true
+	if s.cfg.SignupRequireApproval {
+		user.SignupStatus = models.SignupStatusPending
+	} else {
+		user.SignupStatus = ""
+		user.IsActive = true
+	}
+	if err := s.store.UpdateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+	if err := s.store.DeleteEmailVerificationTokensByUserID(user.ID); err != nil {
+		log.Printf("[Signup] Failed to delete verification tokens for user %s: %v", user.ID, err)
+	}
+
+	s.logSignup(ctx, models.EventSignupVerified, user, "Signup email verified", "")
+	return user, nil
+}
+
+// ApproveSignup activates a verified account waiting in the approval queue
+// and tells its owner they can sign in.
+func (s *SignupService) ApproveSignup(ctx context.Context, userID, adminUserID string) error {
+	user, err := s.pendingUser(userID)
+	if err != nil {
+		return err
+	}
+	user.SignupStatus = ""
+	user.IsActive = true
+	if err := s.store.UpdateUser(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventSignupApproved,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  adminUserID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Signup approved by admin",
+		Details:      models.AuditDetails{"email": user.Email},
+		Success:      true,
+	})
+	s.send(ctx, user, s.approvedMessage(user))
+	return nil
+}
+
+// RejectSignup removes an account from the approval queue and leaves it
+// disabled. The account is kept so the username and address cannot simply
+// be registered again.
+func (s *SignupService) RejectSignup(ctx context.Context, userID, adminUserID string) error {
+	user, err := s.pendingUser(userID)
+	if err != nil {
+		return err
+	}
+	user.SignupStatus = ""
+	user.IsActive = false
+	if err := s.store.UpdateUser(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	s.userService.InvalidateUserCache(user.ID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventSignupRejected,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  adminUserID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Signup rejected by admin",
+		Details:      models.AuditDetails{"email": user.Email},
+		Success:      true,
+	})
+	return nil
+}
+
+// AllowedEmailDomains returns the domains sign-up is limited to, or nil
+// when any domain may sign up.
+func (s *SignupService) AllowedEmailDomains() []string {
+	return s.cfg.SignupEmailDomains
+}
+
+func (s *SignupService) pendingUser(userID string) (*models.User, error) {
+	user, err := s.userService.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.SignupStatus != models.SignupStatusPending {
+		return nil, ErrSignupNotPendingApproval
+	}
+	return user, nil
+}
+
+// validEmail reports whether email is a bare address such as
+// "alice@example.com", without a display name or angle brackets.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// emailDomainAllowed reports whether email is at one of domains
+// (SIGNUP_ALLOWED_EMAIL_DOMAINS). An empty list allows every domain.
+func emailDomainAllowed(domains []string, email string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(email, '@')
+	return slices.Contains(domains, strings.ToLower(email[at+1:]))
+}
+
+// send delivers msg in the background so a slow mail server does not hold
+// up the request.
+func (s *SignupService) send(ctx context.Context, user *models.User, msg core.MailMessage) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), signupMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("[Signup] Failed to send email to user %s: %v", user.ID, err)
+		}
+	}()
+}
+
+func (s *SignupService) verificationMessage(user *models.User, token string) core.MailMessage {
+	link := strings.TrimRight(s.cfg.BaseURL, "/") + "/signup/verify?token=" + token
+	return core.MailMessage{
+		To:      user.Email,
+		Subject: "Verify your email for AuthGate",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Thanks for signing up for AuthGate as %q.\n"+
+				"Open the link below within %s to verify your email address:\n\n"+
+				"%s\n\n"+
+				"If you did not sign up, ignore this email; the account will be\n"+
+				"removed automatically.\n",
+			displayName(user), user.Username, s.cfg.SignupVerificationTTL, link,
+		),
+	}
+}
+
+func (s *SignupService) approvedMessage(user *models.User) core.MailMessage {
+	return core.MailMessage{
+		To:      user.Email,
+		Subject: "Your AuthGate account is ready",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"An administrator approved your AuthGate account %q.\n"+
+				"You can now sign in at %s\n",
+			displayName(user), user.Username, strings.TrimRight(s.cfg.BaseURL, "/")+"/login",
+		),
+	}
+}
+
+func displayName(user *models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Username
+}
+
+// logSignup records a signup event for user. reason explains a refused
+// attempt and is empty on success.
+func (s *SignupService) logSignup(
+	ctx context.Context,
+	event models.EventType,
+	user *models.User,
+	action, reason string,
+) {
+	entry := core.AuditLogEntry{
+		EventType:    event,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       action,
+		Details:      models.AuditDetails{"email": user.Email},
+		Success:      reason == "",
+	}
+	if reason != "" {
+		// The visitor is not the account owner.
+		entry.Severity = models.SeverityWarning
+		entry.ActorUserID = ""
+		entry.Details["reason"] = reason
+	}
+	s.auditService.Log(ctx, entry)
+}
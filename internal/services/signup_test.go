@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/auth"
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`https://\S+/signup/verify\?token=\S+`)
+
+func newSignupTestService(t *testing.T) (*SignupService, chanMailer, *store.Store) {
+	t.Helper()
+	f := newMailTestFixture(t, &config.Config{SignupVerificationTTL: 24 * time.Hour})
+	return NewSignupService(f.db, f.cfg, f.users, f.mailer, nil), f.mailer, f.db
+}
+
+func aliceSignup() SignupRequest {
+	return SignupRequest{
+		Username: "Alice",
+		Email:    "alice@example.com",
+		FullName: "Alice Liddell",
+		Password: "correct horse battery",
+	}
+}
+
+func TestSignup_Flow(t *testing.T) {
+	svc, mailer, db := newSignupTestService(t)
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, aliceSignup()))
+	msg, token := receiveMailToken(t, mailer, verifyLinkPattern)
+	assert.Equal(t, "alice@example.com", msg.To)
+	assert.True(t, strings.HasPrefix(
+		verifyLinkPattern.FindString(msg.Text), "https://auth.example.com/signup/verify?token=",
+	))
+
+	user, err := db.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+	assert.False(t, user.EmailVerified)
+	assert.Equal(t, models.SignupStatusUnverified, user.SignupStatus)
+	assert.Equal(t, models.AuthSourceLocal, user.AuthSource)
+	assert.Equal(t, models.UserRoleUser, user.Role)
+
+	_, err = svc.userService.Authenticate(ctx, "alice", "correct horse battery")
+	require.ErrorIs(t, err, ErrEmailNotVerified)
+	_, err = svc.userService.Authenticate(ctx, "alice", "wrong password")
+	require.ErrorIs(t, err, ErrInvalidCredentials, "status is only revealed with the password")
+
+	verified, err := svc.VerifyEmail(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, verified.IsActive)
+	assert.True(t, verified.EmailVerified)
+	assert.Empty(t, verified.SignupStatus)
+
+	authed, err := svc.userService.Authenticate(ctx, "alice", "correct horse battery")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authed.ID)
+
+	// The link works once.
+	_, err = svc.VerifyEmail(ctx, token)
+	assert.ErrorIs(t, err, ErrSignupTokenInvalid)
+}
+
+func TestSignup_ApprovalQueue(t *testing.T) {
+	svc, mailer, db := newSignupTestService(t)
+	svc.cfg.SignupRequireApproval = true
+	ctx := context.Background()
+	admin := makeTestUser(t, db)
+
+	register := func(username string) *models.User {
+		req := aliceSignup()
+		req.Username = username
+		req.Email = username + "@example.com"
+		require.NoError(t, svc.Register(ctx, req))
+		_, token := receiveMailToken(t, mailer, verifyLinkPattern)
+		user, err := svc.VerifyEmail(ctx, token)
+		require.NoError(t, err)
+		return user
+	}
+
+	bob := register("bob")
+	assert.Equal(t, models.SignupStatusPending, bob.SignupStatus)
+	assert.False(t, bob.IsActive)
+	assert.True(t, bob.EmailVerified)
+	_, err := svc.userService.Authenticate(ctx, "bob", "correct horse battery")
+	require.ErrorIs(t, err, ErrSignupPendingApproval)
+	carol := register("carol")
+
+	count, err := svc.userService.CountPendingSignups()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	t.Run("approve", func(t *testing.T) {
+		require.NoError(t, svc.ApproveSignup(ctx, bob.ID, admin.ID))
+		select {
+		case msg := <-mailer:
+			assert.Equal(t, "bob@example.com", msg.To)
+			assert.Contains(t, msg.Text, "https://auth.example.com/login")
+		case <-time.After(5 * time.Second):
+			t.Fatal("no approval email sent")
+		}
+		_, err := svc.userService.Authenticate(ctx, "bob", "correct horse battery")
+		require.NoError(t, err)
+		assert.ErrorIs(t, svc.ApproveSignup(ctx, bob.ID, admin.ID), ErrSignupNotPendingApproval)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		require.NoError(t, svc.RejectSignup(ctx, carol.ID, admin.ID))
+		_, err := svc.userService.Authenticate(ctx, "carol", "correct horse battery")
+		require.ErrorIs(t, err, ErrAccountDisabled)
+		assert.NoError(t,
+			svc.userService.ValidateSetUserActiveStatus(carol.ID, admin.ID, true),
+			"a rejected signup is an ordinary disabled account",
+		)
+	})
+
+	count, err = svc.userService.CountPendingSignups()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSignup_Rejects(t *testing.T) {
+	svc, _, db := newSignupTestService(t)
+	svc.cfg.SignupEmailDomains = []string{"example.com"}
+	existing := makeTestUser(t, db)
+
+	tests := []struct {
+		name    string
+		modify  func(*SignupRequest)
+		wantErr error
+	}{
+		{"empty username", func(r *SignupRequest) { r.Username = " " }, ErrUsernameRequired},
+		{"short username", func(r *SignupRequest) { r.Username = "al" }, ErrSignupUsernameInvalid},
+		{"username with space", func(r *SignupRequest) { r.Username = "al ice" }, ErrSignupUsernameInvalid},
+		{"empty email", func(r *SignupRequest) { r.Email = "" }, ErrEmailRequired},
+		{"display name", func(r *SignupRequest) { r.Email = "A <a@example.com>" }, ErrEmailInvalid},
+		{"other domain", func(r *SignupRequest) { r.Email = "a@example.org" }, ErrSignupDomainNotAllowed},
+		{"short password", func(r *SignupRequest) { r.Password = "short" }, ErrPasswordTooShort},
+		{"username taken", func(r *SignupRequest) { r.Username = existing.Username }, ErrUsernameConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := aliceSignup()
+			tt.modify(&req)
+			assert.ErrorIs(t, svc.Register(context.Background(), req), tt.wantErr)
+		})
+	}
+}
+
+func TestSignup_AllowedDomainIgnoresCase(t *testing.T) {
+	svc, mailer, _ := newSignupTestService(t)
+	svc.cfg.SignupEmailDomains = []string{"example.com"}
+
+	req := aliceSignup()
+	req.Email = "alice@EXAMPLE.com"
+	require.NoError(t, svc.Register(context.Background(), req))
+	receiveMailToken(t, mailer, verifyLinkPattern)
+}
+
+func TestSignup_ExistingEmailSilentlyIgnored(t *testing.T) {
+	svc, mailer, db := newSignupTestService(t)
+	existing := makeTestUser(t, db)
+
+	req := aliceSignup()
+	req.Email = existing.Email
+	require.NoError(t, svc.Register(context.Background(), req))
+	assertNoEmail(t, mailer)
+
+	_, err := db.GetUserByUsername("alice")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestSignup_ExpiredSignupFreesUsername(t *testing.T) {
+	svc, mailer, _ := newSignupTestService(t)
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, aliceSignup()))
+	_, stale := receiveMailToken(t, mailer, verifyLinkPattern)
+
+	// Once the link has expired the name can be taken again.
+	svc.cfg.SignupVerificationTTL = time.Nanosecond
+	require.NoError(t, svc.Register(ctx, aliceSignup()))
+	receiveMailToken(t, mailer, verifyLinkPattern)
+
+	_, err := svc.VerifyEmail(ctx, stale)
+	assert.ErrorIs(t, err, ErrSignupTokenInvalid)
+}
+
+func TestSignup_VerifiedOAuthLoginReplacesUnverifiedSignup(t *testing.T) {
+	svc, mailer, db := newSignupTestService(t)
+	ctx := context.Background()
+	svc.userService.oauthAutoRegister = true
+
+	// Someone signs up with an address they do not own...
+	req := aliceSignup()
+	req.Username = "squatter"
+	require.NoError(t, svc.Register(ctx, req))
+	_, token := receiveMailToken(t, mailer, verifyLinkPattern)
+
+	// ...and the owner later signs in through a provider that verified it.
+	user, err := svc.userService.AuthenticateWithOAuth(ctx, "github", &auth.OAuthUserInfo{
+		ProviderUserID: uuid.New().String(),
+		Username:       "alice",
+		Email:          "alice@example.com",
+		EmailVerified:  true,
+	}, newOAuthToken())
+	require.NoError(t, err)
+	assert.True(t, user.IsActive)
+	assert.NotEqual(t, "squatter", user.Username)
+
+	_, err = db.GetUserByUsername("squatter")
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.VerifyEmail(ctx, token)
+	assert.ErrorIs(t, err, ErrSignupTokenInvalid)
+}
+
+func TestSetUserActiveStatus_RefusesIncompleteSignup(t *testing.T) {
+	svc, mailer, db := newSignupTestService(t)
+	admin := makeTestUser(t, db)
+	require.NoError(t, svc.Register(context.Background(), aliceSignup()))
+	receiveMailToken(t, mailer, verifyLinkPattern)
+	user, err := db.GetUserByUsername("alice")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t,
+		svc.userService.ValidateSetUserActiveStatus(user.ID, admin.ID, true),
+		ErrSignupIncomplete,
+	)
+	assert.ErrorIs(t,
+		svc.userService.SetUserActiveStatus(context.Background(), user.ID, admin.ID, true),
+		ErrSignupIncomplete,
+	)
+}
@@ -63,6 +63,23 @@ type TokenService struct {
 	// and NewLocalTokenProvider apply, ensuring every layer sees the same
 	// prefix.
 	privateClaimPrefix string
+	// rotationGraceCache parks the successor pair of each rotated refresh
+	// token for REFRESH_TOKEN_REUSE_GRACE_PERIOD, keyed by the rotated-out
+	// token's hash. See replayRotationSuccessor.
+	rotationGraceCache core.Cache[RotationSuccessor]
+}
+
+// TokenServiceOption configures a TokenService at construction.
+type TokenServiceOption func(*TokenService)
+
+// WithRotationGraceCache sets the cache backing the refresh-token reuse
+// grace window. Multi-instance deployments must pass a shared (Redis) cache
+// so a retry landing on another replica still finds the successor pair;
+// when omitted an in-memory cache is used.
+func WithRotationGraceCache(c core.Cache[RotationSuccessor]) TokenServiceOption {
+	return func(s *TokenService) {
+		s.rotationGraceCache = c
+	}
 }
 
 func NewTokenService(
@@ -74,6 +91,7 @@ func NewTokenService(
 	m core.Recorder,
 	tokenCache core.Cache[models.AccessToken],
 	clientService *ClientService,
+	opts ...TokenServiceOption,
 ) *TokenService {
 	if auditService == nil {
 		auditService = NewNoopAuditService()
@@ -82,7 +100,7 @@ func NewTokenService(
 	if prefix == "" {
 		prefix = config.DefaultJWTPrivateClaimPrefix
 	}
-	return &TokenService{
+	svc := &TokenService{
 		store:              s,
 		config:             cfg,
 		deviceService:      ds,
@@ -93,6 +111,13 @@ func NewTokenService(
 		clientService:      clientService,
 		privateClaimPrefix: prefix,
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if svc.rotationGraceCache == nil && cfg.RefreshTokenReuseGracePeriod > 0 {
+		svc.rotationGraceCache = cache.NewMemoryCache[RotationSuccessor]()
+	}
+	return svc
 }
 
 // getAccessTokenByHash looks up a token, using cache if available.
@@ -193,6 +218,10 @@ type tokenPairParams struct {
 	// re-narrow against the original grant rather than the already-narrowed
 	// access-token audience. When nil, the refresh row falls back to Resource.
 	RefreshResource []string
+	// SessionID is the login session (sid) the grant came from. Stamped as
+	// the `sid` claim and on both rows; empty for grants without a browser
+	// session.
+	SessionID string
 }
 
 // ttlForClient returns the access/refresh TTLs dictated by the given client's
@@ -259,6 +288,74 @@ func (s *TokenService) resolveClientTTL(
 	return s.ttlForClient(client)
 }
 
+// refreshIdleTimeoutForClient returns the refresh idle timeout from the
+// client's token profile. A nil client or an unknown profile name resolves
+// to the standard profile, mirroring ttlForClient's fallback.
+func (s *TokenService) refreshIdleTimeoutForClient(client *models.OAuthApplication) time.Duration {
+	name := models.TokenProfileStandard
+	if client != nil {
+		name = models.ResolveTokenProfile(client.TokenProfile)
+	}
+	profile, ok := s.config.TokenProfiles[name]
+	if !ok {
+		profile = s.config.TokenProfiles[models.TokenProfileStandard]
+	}
+	return profile.RefreshIdleTimeout
+}
+
+// slidingRefreshEnabled reports whether refresh tokens renew on use. The
+// absolute cap comes from RefreshTokenExpirationMax, so sliding is inert
+// without one (hand-built test configs).
+func (s *TokenService) slidingRefreshEnabled() bool {
+	return s.config.RefreshTokenSlidingExpiration && s.config.RefreshTokenExpirationMax > 0
+}
+
+// refreshWindow returns the sliding window for a resolved refresh TTL: the
+// TTL itself, or the base REFRESH_TOKEN_EXPIRATION when the profile defers
+// to the provider default (ttl == 0).
+func (s *TokenService) refreshWindow(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+	return s.config.RefreshTokenExpiration
+}
+
+// tokenFormatForClient returns the access-token encoding for the client: its
+// own TokenFormat when set, otherwise the global TOKEN_FORMAT, otherwise JWT.
+// An unrecognised value on the client row is logged and ignored so a bad row
+// degrades to the deployment default instead of failing issuance.
+func (s *TokenService) tokenFormatForClient(client *models.OAuthApplication) string {
+	if client != nil && client.TokenFormat != "" {
+		if models.IsValidTokenFormat(client.TokenFormat) {
+			return client.TokenFormat
+		}
+		log.Printf(
+			"[Token] client %s has unknown token_format=%q; using global default",
+			client.ClientID,
+			client.TokenFormat,
+		)
+	}
+	if s.config.TokenFormat != "" {
+		return s.config.TokenFormat
+	}
+	return models.TokenFormatJWT
+}
+
+// applyTokenFormat re-encodes a freshly minted access token as an opaque
+// reference token when the client's effective format is "opaque". The
+// provider has already resolved the expiry (profile TTL, jitter, client
+// credentials lifetime), so both formats share identical lifetime semantics
+// and only the wire encoding differs. JWT-format results pass through as-is.
+func (s *TokenService) applyTokenFormat(
+	client *models.OAuthApplication,
+	result *token.Result,
+) (*token.Result, error) {
+	if s.tokenFormatForClient(client) != models.TokenFormatOpaque {
+		return result, nil
+	}
+	return token.GenerateOpaqueToken(result.ExpiresAt)
+}
+
 // buildClientClaims returns the JWT extra claims sourced from the OAuth
 // application: project and service_account, emitted under the configured
 // private-claim prefix (e.g. "extra_project", "extra_service_account" with
@@ -362,6 +459,16 @@ func (s *TokenService) composeIssuanceClaims(
 	return applyServerClaims(claims, buildServerClaims(s.config.JWTDomain, username, prefix))
 }
 
+// withSessionClaim adds the OIDC `sid` claim for tokens bound to a login
+// session. Like other server-attested claims it overrides anything the caller
+// supplied; "sid" is reserved, so extra_claims cannot set it in the first place.
+func withSessionClaim(claims map[string]any, sessionID string) map[string]any {
+	if sessionID == "" {
+		return claims
+	}
+	return applyServerClaims(claims, map[string]any{"sid": sessionID})
+}
+
 // effectiveAudience snapshots the audience that will be written into a
 // freshly issued access token's persisted Resource column: the per-request
 // RFC 8707 binding when supplied, otherwise the static JWTAudience config
@@ -450,7 +557,9 @@ func (s *TokenService) generateAndPersistTokenPair(
 	if client != nil {
 		accessTTL, refreshTTL = s.ttlForClient(client)
 	}
-	extraClaims = s.composeIssuanceClaims(client, p.UserID, p.ExtraClaims)
+	extraClaims = withSessionClaim(
+		s.composeIssuanceClaims(client, p.UserID, p.ExtraClaims), p.SessionID,
+	)
 
 	accessResult, err := s.tokenProvider.GenerateToken(
 		ctx, p.UserID, p.ClientID, p.Scopes, accessTTL, extraClaims, p.Resource,
@@ -463,6 +572,10 @@ func (s *TokenService) generateAndPersistTokenPair(
 		)
 		return nil, nil, fmt.Errorf("token generation failed: %w", err)
 	}
+	accessResult, err = s.applyTokenFormat(client, accessResult)
+	if err != nil {
+		return nil, nil, fmt.Errorf("token generation failed: %w", err)
+	}
 	// Refresh tokens never carry the per-request RFC 8707 resource as `aud` —
 	// they're presented to the AS, not the RS. Pass nil so the JWT audience
 	// falls back to the static JWTAudience config (deployments must point
@@ -470,8 +583,22 @@ func (s *TokenService) generateAndPersistTokenPair(
 	// core/token.go on RefreshAccessToken for why). The persisted Resource
 	// column on the refresh-token row still records the granted resource
 	// set, so future refresh requests can subset-check against it.
+	//
+	// With sliding expiration the family gets an absolute cap. Fixed mode
+	// reuses one refresh JWT for the family's whole life, so it is signed out
+	// to that cap; the row's ExpiresAt carries the sliding window and is what
+	// the refresh grant enforces.
+	refreshJWTTTL := refreshTTL
+	var absoluteExpiresAt *time.Time
+	if s.slidingRefreshEnabled() {
+		abs := time.Now().Add(s.config.RefreshTokenExpirationMax)
+		absoluteExpiresAt = &abs
+		if !s.config.EnableTokenRotation {
+			refreshJWTTTL = s.config.RefreshTokenExpirationMax
+		}
+	}
 	refreshResult, err := s.tokenProvider.GenerateRefreshToken(
-		ctx, p.UserID, p.ClientID, p.Scopes, refreshTTL, extraClaims, nil,
+		ctx, p.UserID, p.ClientID, p.Scopes, refreshJWTTTL, extraClaims, nil,
 	)
 	if err != nil {
 		log.Printf(
@@ -502,6 +629,7 @@ func (s *TokenService) generateAndPersistTokenPair(
 		Scopes:          p.Scopes,
 		ExpiresAt:       accessResult.ExpiresAt,
 		AuthorizationID: p.AuthorizationID,
+		SessionID:       p.SessionID,
 		Resource:        models.StringArray(effectiveAudience(p.Resource, s.config.JWTAudience)),
 	}
 
@@ -525,20 +653,32 @@ func (s *TokenService) generateAndPersistTokenPair(
 	if len(refreshDBResource) == 0 {
 		refreshDBResource = effectiveAudience(nil, s.config.JWTAudience)
 	}
+	refreshExpiresAt := refreshResult.ExpiresAt
+	if absoluteExpiresAt != nil {
+		window := time.Now().Add(s.refreshWindow(refreshTTL))
+		if window.After(*absoluteExpiresAt) {
+			window = *absoluteExpiresAt
+		}
+		if window.Before(refreshExpiresAt) {
+			refreshExpiresAt = window
+		}
+	}
 	refreshTokenID := uuid.New().String()
 	refreshToken := &models.AccessToken{
-		ID:              refreshTokenID,
-		TokenHash:       util.SHA256Hex(refreshResult.TokenString),
-		RawToken:        refreshResult.TokenString,
-		TokenType:       refreshResult.TokenType,
-		TokenCategory:   models.TokenCategoryRefresh,
-		Status:          models.TokenStatusActive,
-		UserID:          p.UserID,
-		ClientID:        p.ClientID,
-		Scopes:          p.Scopes,
-		ExpiresAt:       refreshResult.ExpiresAt,
-		AuthorizationID: p.AuthorizationID,
-		Resource:        models.StringArray(refreshDBResource),
+		ID:                refreshTokenID,
+		TokenHash:         util.SHA256Hex(refreshResult.TokenString),
+		RawToken:          refreshResult.TokenString,
+		TokenType:         refreshResult.TokenType,
+		TokenCategory:     models.TokenCategoryRefresh,
+		Status:            models.TokenStatusActive,
+		UserID:            p.UserID,
+		ClientID:          p.ClientID,
+		Scopes:            p.Scopes,
+		ExpiresAt:         refreshExpiresAt,
+		AuthorizationID:   p.AuthorizationID,
+		SessionID:         p.SessionID,
+		Resource:          models.StringArray(refreshDBResource),
+		AbsoluteExpiresAt: absoluteExpiresAt,
 	}
 
 	// In rotation mode, set TokenFamilyID to the refresh token's own ID (family root)
@@ -547,8 +687,14 @@ func (s *TokenService) generateAndPersistTokenPair(
 		accessToken.TokenFamilyID = refreshTokenID
 	}
 
-	// Persist both tokens atomically
-	if err := s.store.RunInTransaction(func(tx core.Store) error {
+	// Persist both tokens atomically, making room under the client's refresh
+	// family limit in the same transaction.
+	var evicted []models.AccessToken
+	err = s.store.RunInTransaction(func(tx core.Store) error {
+		var err error
+		if evicted, err = s.enforceRefreshFamilyLimit(tx, client, p.UserID, p.ClientID); err != nil {
+			return err
+		}
 		if err := tx.CreateAccessToken(accessToken); err != nil {
 			return fmt.Errorf("failed to save access token: %w", err)
 		}
@@ -556,7 +702,9 @@ func (s *TokenService) generateAndPersistTokenPair(
 			return fmt.Errorf("failed to save refresh token: %w", err)
 		}
 		return nil
-	}); err != nil {
+	})
+	s.recordRefreshFamilyLimit(ctx, client, p.UserID, p.ClientID, evicted, err)
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkUpdateTokens_RevokeWithPreview(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	ctx := context.Background()
+	userID := uuid.New().String()
+
+	writer := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "writer", Scopes: "read write", ExpiresIn: time.Hour,
+	})
+	reader := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "reader", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	_, err := svc.ValidateToken(ctx, writer.RawToken)
+	require.NoError(t, err)
+
+	filter := store.TokenFilter{UserID: userID, Scope: "write"}
+
+	count, err := svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionRevoke, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assertTokenStatus(t, s, writer.ID, models.TokenStatusActive)
+
+	count, err = svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionRevoke, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assertTokenStatus(t, s, writer.ID, models.TokenStatusRevoked)
+	assertTokenStatus(t, s, reader.ID, models.TokenStatusActive)
+
+	_, err = svc.ValidateToken(ctx, writer.RawToken)
+	require.Error(t, err, "revoked token must not be served from cache")
+	_, err = svc.ValidateToken(ctx, reader.RawToken)
+	require.NoError(t, err)
+
+	// Nothing left to revoke.
+	count, err = svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionRevoke, true)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestBulkUpdateTokens_DisableOnlyActive(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	a := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "a", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	b := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "b", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	require.NoError(t, svc.DisableToken(ctx, b.ID, userID))
+
+	filter := store.TokenFilter{UserID: userID}
+	count, err := svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionDisable, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A status filter outside the action's eligible set previews nothing.
+	revoked := filter
+	revoked.Status = models.TokenStatusRevoked
+	count, err = svc.BulkUpdateTokens(ctx, "admin", revoked, BulkTokenActionRevoke, true)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionDisable, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assertTokenStatus(t, s, a.ID, models.TokenStatusDisabled)
+}
+
+func TestBulkUpdateTokens_Rejects(t *testing.T) {
+	svc, _ := newPersonalTokenService(t)
+	ctx := context.Background()
+
+	_, err := svc.BulkUpdateTokens(ctx, "admin", store.TokenFilter{}, BulkTokenActionRevoke, false)
+	require.ErrorIs(t, err, ErrBulkTokenFilterUnscoped)
+
+	// Status, category, scope and resource still span every user and client.
+	for _, filter := range []store.TokenFilter{
+		{Status: models.TokenStatusActive},
+		{Category: models.TokenCategoryAccess},
+		{Status: models.TokenStatusActive, Category: models.TokenCategoryRefresh},
+		{Scope: "read", Resource: "https://api.example.com"},
+	} {
+		_, err = svc.BulkUpdateTokens(ctx, "admin", filter, BulkTokenActionRevoke, true)
+		require.ErrorIs(t, err, ErrBulkTokenFilterUnscoped, "%+v", filter)
+	}
+
+	_, err = svc.BulkUpdateTokens(ctx, "admin", store.TokenFilter{UserID: "u"}, "delete", false)
+	require.ErrorIs(t, err, ErrBulkTokenAction)
+}
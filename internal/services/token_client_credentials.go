@@ -133,6 +133,10 @@ func (s *TokenService) IssueClientCredentialsToken(
 		)
 		return nil, fmt.Errorf("token generation failed: %w", providerErr)
 	}
+	accessTokenResult, err = s.applyTokenFormat(client, accessTokenResult)
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
 	// 7. Persist the token record (no AuthorizationID — no user consent).
 	// Resource is the audience the JWT was actually signed with (per-request
 	// RFC 8707 binding, or the static JWTAudience fallback) — snapshotting at
@@ -58,8 +58,12 @@ func (s *TokenService) ExchangeDeviceCode(
 		return nil, nil, ErrAccessDenied
 	}
 
-	// Check if authorized
+	// Check if authorized; a client polling too fast is told to slow down
 	if !dc.Authorized {
+		if err := s.deviceService.RecordPendingPoll(dc); err != nil {
+			s.metrics.RecordOAuthDeviceCodeValidation("slow_down")
+			return nil, nil, err
+		}
 		s.metrics.RecordOAuthDeviceCodeValidation("pending")
 		return nil, nil, ErrAuthorizationPending
 	}
@@ -212,6 +216,7 @@ func (s *TokenService) ExchangeAuthorizationCode(
 		ExtraClaims:     extraClaims,
 		Resource:        accessResource,
 		RefreshResource: refreshResource,
+		SessionID:       authCode.SessionID,
 	})
 	if err != nil {
 		return nil, nil, "", err
@@ -225,12 +230,14 @@ func (s *TokenService) ExchangeAuthorizationCode(
 		scopeSet := util.ScopeSet(authCode.Scopes)
 		if scopeSet["openid"] {
 			params := token.IDTokenParams{
-				Issuer:   strings.TrimRight(s.config.BaseURL, "/"),
-				Subject:  authCode.UserID,
-				Audience: authCode.ClientID,
-				AuthTime: authCode.CreatedAt,
-				Nonce:    authCode.Nonce,
-				AtHash:   token.ComputeAtHash(accessToken.RawToken),
+				Issuer:      strings.TrimRight(s.config.BaseURL, "/"),
+				Subject:     authCode.UserID,
+				Audience:    authCode.ClientID,
+				AuthTime:    authCode.CreatedAt,
+				Nonce:       authCode.Nonce,
+				AtHash:      token.ComputeAtHash(accessToken.RawToken),
+				SessionID:   authCode.SessionID,
+				AuthMethods: authCode.AuthMethods,
 			}
 
 			// Fetch user profile only when scope-gated claims are needed
@@ -245,6 +252,9 @@ func (s *TokenService) ExchangeAuthorizationCode(
 						params.Picture = user.AvatarURL
 						updatedAt := user.UpdatedAt
 						params.UpdatedAt = &updatedAt
+						params.Roles = user.ExternalRoles()
+						params.Groups = user.Groups
+						params.Attributes = user.Attributes
 					}
 					if scopeSet["email"] {
 						params.Email = user.Email
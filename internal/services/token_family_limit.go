@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+)
+
+// ErrRefreshFamilyLimit is returned by issuance when the user already holds
+// the maximum number of active refresh token families for the client and the
+// effective policy is "reject".
+var ErrRefreshFamilyLimit = errors.New(
+	"active refresh token family limit reached for this client",
+)
+
+// RefreshFamilyUsage reports how many active refresh token families a user
+// holds for a client that has a family limit.
+type RefreshFamilyUsage struct {
+	ClientID   string
+	ClientName string
+	Active     int
+	Limit      int
+	Policy     string
+}
+
+// refreshFamilyLimitForClient resolves the family limit and policy for client:
+// a non-zero client limit wins, then the client's token profile, then the
+// global MAX_REFRESH_FAMILIES. A limit of 0 means unlimited. A nil client
+// resolves through the standard profile, mirroring ttlForClient's fallback.
+func (s *TokenService) refreshFamilyLimitForClient(
+	client *models.OAuthApplication,
+) (limit int, policy string) {
+	policy = s.config.RefreshFamilyLimitPolicy
+	if policy == "" {
+		policy = models.RefreshFamilyPolicyEvictOldest
+	}
+	if client != nil && client.RefreshFamilyPolicy != "" {
+		policy = client.RefreshFamilyPolicy
+	}
+
+	if client != nil && client.MaxRefreshFamilies > 0 {
+		return client.MaxRefreshFamilies, policy
+	}
+	name := models.TokenProfileStandard
+	if client != nil {
+		name = models.ResolveTokenProfile(client.TokenProfile)
+	}
+	profile, ok := s.config.TokenProfiles[name]
+	if !ok {
+		profile = s.config.TokenProfiles[models.TokenProfileStandard]
+	}
+	if profile.MaxRefreshFamilies > 0 {
+		return profile.MaxRefreshFamilies, policy
+	}
+	return s.config.MaxRefreshFamilies, policy
+}
+
+// enforceRefreshFamilyLimit makes room for one new refresh token family for
+// userID on client. It runs inside the issuance transaction so an eviction
+// only sticks if the new pair is persisted too, and locks the user's grants
+// first so concurrent issuance cannot all count below the limit. Under
+// "reject" it returns ErrRefreshFamilyLimit; under "evict_oldest" it revokes
+// the families whose refresh token was issued or rotated longest ago and
+// returns the revoked rows so the caller can invalidate caches and audit once
+// the transaction commits.
+func (s *TokenService) enforceRefreshFamilyLimit(
+	tx core.Store,
+	client *models.OAuthApplication,
+	userID, clientID string,
+) ([]models.AccessToken, error) {
+	limit, policy := s.refreshFamilyLimitForClient(client)
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	if err := tx.LockUserTokenGrants(userID); err != nil {
+		return nil, err
+	}
+	active, err := tx.GetActiveRefreshTokens(userID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	excess := len(active) - limit + 1
+	if excess <= 0 {
+		return nil, nil
+	}
+	if policy == models.RefreshFamilyPolicyReject {
+		return nil, ErrRefreshFamilyLimit
+	}
+
+	var evicted []models.AccessToken
+	for _, tok := range active[:excess] {
+		// Fixed mode has no family ID: the refresh token is the whole family.
+		if tok.TokenFamilyID == "" {
+			if err := tx.UpdateTokenStatus(tok.ID, models.TokenStatusRevoked); err != nil {
+				return nil, err
+			}
+			evicted = append(evicted, tok)
+			continue
+		}
+		revoked, err := tx.UpdateTokenStatusByFilter(
+			store.TokenFilter{UserID: userID, FamilyID: tok.TokenFamilyID},
+			[]string{models.TokenStatusActive, models.TokenStatusDisabled},
+			models.TokenStatusRevoked,
+		)
+		if err != nil {
+			return nil, err
+		}
+		evicted = append(evicted, revoked...)
+	}
+	return evicted, nil
+}
+
+// recordRefreshFamilyLimit reports the outcome of enforceRefreshFamilyLimit
+// after the issuance transaction has finished: evicted tokens are dropped from
+// the cache and audited, and a rejected grant is audited as a warning.
+func (s *TokenService) recordRefreshFamilyLimit(
+	ctx context.Context,
+	client *models.OAuthApplication,
+	userID, clientID string,
+	evicted []models.AccessToken,
+	issueErr error,
+) {
+	limit, policy := s.refreshFamilyLimitForClient(client)
+
+	if errors.Is(issueErr, ErrRefreshFamilyLimit) {
+		s.auditService.Log(ctx, core.AuditLogEntry{
+			EventType:    models.EventTokenFamilyLimitReached,
+			Severity:     models.SeverityWarning,
+			ActorUserID:  userID,
+			ResourceType: models.ResourceToken,
+			ResourceID:   clientID,
+			Action:       "Token grant refused: refresh token family limit reached",
+			Details: models.AuditDetails{
+				"client_id": clientID,
+				"limit":     limit,
+				"policy":    policy,
+			},
+			Success:      false,
+			ErrorMessage: issueErr.Error(),
+		})
+		return
+	}
+	if issueErr != nil || len(evicted) == 0 {
+		return
+	}
+
+	hashes := make([]string, 0, len(evicted))
+	familyIDs := make([]string, 0, len(evicted))
+	seen := make(map[string]bool, len(evicted))
+	for _, tok := range evicted {
+		hashes = append(hashes, tok.TokenHash)
+		s.metrics.RecordTokenRevoked(tok.TokenCategory, "family_limit")
+		family := tok.TokenFamilyID
+		if family == "" {
+			family = tok.ID
+		}
+		if !seen[family] {
+			seen[family] = true
+			familyIDs = append(familyIDs, family)
+		}
+	}
+	s.InvalidateTokenCacheByHashes(ctx, hashes)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTokenFamilyEvicted,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   clientID,
+		Action:       "Oldest refresh token family revoked to stay within the family limit",
+		Details: models.AuditDetails{
+			"client_id":     clientID,
+			"limit":         limit,
+			"family_ids":    familyIDs,
+			"revoked_count": len(evicted),
+		},
+		Success: true,
+	})
+}
+
+// GetRefreshFamilyUsage returns, for every client with a family limit on
+// which userID holds active refresh tokens, how many families are in use.
+// Clients without a limit are omitted. Results are sorted by client name.
+func (s *TokenService) GetRefreshFamilyUsage(
+	ctx context.Context,
+	userID string,
+) ([]RefreshFamilyUsage, error) {
+	active, err := s.store.GetActiveRefreshTokens(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, tok := range active {
+		counts[tok.ClientID]++
+	}
+
+	usage := make([]RefreshFamilyUsage, 0, len(counts))
+	for clientID, n := range counts {
+		var client *models.OAuthApplication
+		name := clientID
+		if s.clientService != nil {
+			if c, err := s.clientService.GetClient(ctx, clientID); err == nil {
+				client = c
+				name = c.ClientName
+			} else {
+				log.Printf("[Token] Family usage: client lookup failed client_id=%s: %v", clientID, err)
+			}
+		}
+		limit, policy := s.refreshFamilyLimitForClient(client)
+		if limit <= 0 {
+			continue
+		}
+		usage = append(usage, RefreshFamilyUsage{
+			ClientID:   clientID,
+			ClientName: name,
+			Active:     n,
+			Limit:      limit,
+			Policy:     policy,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].ClientName != usage[j].ClientName {
+			return usage[i].ClientName < usage[j].ClientName
+		}
+		return usage[i].ClientID < usage[j].ClientID
+	})
+	return usage, nil
+}
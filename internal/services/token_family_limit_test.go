@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func familyLimitConfig(limit int, policy string) *config.Config {
+	cfg := sessionTestConfig()
+	cfg.EnableTokenRotation = true
+	cfg.MaxRefreshFamilies = limit
+	cfg.RefreshFamilyLimitPolicy = policy
+	return cfg
+}
+
+func TestRefreshFamilyLimitForClient(t *testing.T) {
+	s := &TokenService{config: &config.Config{
+		MaxRefreshFamilies: 10,
+		TokenProfiles: map[string]config.TokenProfile{
+			models.TokenProfileShort:    {MaxRefreshFamilies: 3},
+			models.TokenProfileStandard: {},
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		client     *models.OAuthApplication
+		wantLimit  int
+		wantPolicy string
+	}{
+		{"nil client uses global", nil, 10, models.RefreshFamilyPolicyEvictOldest},
+		{
+			"standard profile inherits global",
+			&models.OAuthApplication{TokenProfile: models.TokenProfileStandard},
+			10, models.RefreshFamilyPolicyEvictOldest,
+		},
+		{
+			"profile overrides global",
+			&models.OAuthApplication{TokenProfile: models.TokenProfileShort},
+			3, models.RefreshFamilyPolicyEvictOldest,
+		},
+		{
+			"client overrides profile",
+			&models.OAuthApplication{
+				TokenProfile:        models.TokenProfileShort,
+				MaxRefreshFamilies:  1,
+				RefreshFamilyPolicy: models.RefreshFamilyPolicyReject,
+			},
+			1, models.RefreshFamilyPolicyReject,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, policy := s.refreshFamilyLimitForClient(tt.client)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantPolicy, policy)
+		})
+	}
+}
+
+func TestRefreshFamilyLimit_EvictOldest(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(
+		t, s, familyLimitConfig(2, models.RefreshFamilyPolicyEvictOldest),
+	)
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	firstAccess, firstRefresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+	time.Sleep(2 * time.Millisecond)
+	_, secondRefresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+	time.Sleep(2 * time.Millisecond)
+	_, thirdRefresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+
+	// The whole oldest family goes, including its access token.
+	assertTokenStatus(t, s, firstRefresh.ID, models.TokenStatusRevoked)
+	assertTokenStatus(t, s, firstAccess.ID, models.TokenStatusRevoked)
+	assertTokenStatus(t, s, secondRefresh.ID, models.TokenStatusActive)
+	assertTokenStatus(t, s, thirdRefresh.ID, models.TokenStatusActive)
+
+	t.Run("OtherClientsUnaffected", func(t *testing.T) {
+		other := createTestClient(t, s, true)
+		_, otherRefresh := exchangeSessionBoundCode(t, s, tokenService, other, userID, "")
+		assertTokenStatus(t, s, secondRefresh.ID, models.TokenStatusActive)
+		assertTokenStatus(t, s, otherRefresh.ID, models.TokenStatusActive)
+	})
+}
+
+func TestRefreshFamilyLimit_Reject(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(
+		t, s, familyLimitConfig(1, models.RefreshFamilyPolicyReject),
+	)
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	_, refresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+
+	authCode := &models.AuthorizationCode{
+		UUID:          "test-uuid-" + uuid.New().String(),
+		CodeHash:      "hash-" + uuid.New().String(),
+		CodePrefix:    "famlim01",
+		ApplicationID: client.ID,
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   "https://app.example.com/callback",
+		Scopes:        "read",
+		ExpiresAt:     time.Now().Add(10 * time.Minute),
+	}
+	require.NoError(t, s.CreateAuthorizationCode(authCode))
+	_, _, _, err := tokenService.ExchangeAuthorizationCode(
+		context.Background(), authCode, nil, nil, nil,
+	)
+	require.ErrorIs(t, err, ErrRefreshFamilyLimit)
+
+	assertTokenStatus(t, s, refresh.ID, models.TokenStatusActive)
+	active, err := s.GetActiveRefreshTokens(userID, client.ClientID)
+	require.NoError(t, err)
+	assert.Len(t, active, 1, "a refused grant must not persist tokens")
+
+	t.Run("RefreshStillAllowed", func(t *testing.T) {
+		// Rotation replaces the family's refresh token rather than adding a
+		// family, so it is never limited.
+		_, _, err := tokenService.RefreshAccessToken(
+			context.Background(), refresh.RawToken, client.ClientID, "", nil, nil,
+		)
+		require.NoError(t, err)
+	})
+}
+
+func TestRefreshFamilyLimit_ConcurrentGrants(t *testing.T) {
+	// A file database, unlike :memory:, is shared by every pooled connection,
+	// so the grants below really run in parallel transactions.
+	dsn := filepath.Join(t.TempDir(), "grants.db") + "?_busy_timeout=10000"
+	s, err := store.New(context.Background(), "sqlite", dsn, &config.Config{})
+	require.NoError(t, err)
+	tokenService := createTestTokenService(
+		t, s, familyLimitConfig(2, models.RefreshFamilyPolicyEvictOldest),
+	)
+	client := createTestClient(t, s, true)
+	// The grant lock is the user's row, so the user must exist.
+	userID := makeTestUser(t, s).ID
+
+	const grants = 8
+	codes := make([]*models.AuthorizationCode, grants)
+	for i := range codes {
+		codes[i] = &models.AuthorizationCode{
+			UUID:          "test-uuid-" + uuid.New().String(),
+			CodeHash:      "hash-" + uuid.New().String(),
+			CodePrefix:    "famlim02",
+			ApplicationID: client.ID,
+			ClientID:      client.ClientID,
+			UserID:        userID,
+			RedirectURI:   "https://app.example.com/callback",
+			Scopes:        "read",
+			ExpiresAt:     time.Now().Add(10 * time.Minute),
+		}
+		require.NoError(t, s.CreateAuthorizationCode(codes[i]))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, grants)
+	for i, code := range codes {
+		wg.Go(func() {
+			_, _, _, errs[i] = tokenService.ExchangeAuthorizationCode(
+				context.Background(), code, nil, nil, nil,
+			)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	active, err := s.GetActiveRefreshTokens(userID, client.ClientID)
+	require.NoError(t, err)
+	assert.Len(t, active, 2, "concurrent grants must not exceed the family limit")
+}
+
+func TestGetRefreshFamilyUsage(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(
+		t, s, familyLimitConfig(3, models.RefreshFamilyPolicyReject),
+	)
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+	exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+
+	usage, err := tokenService.GetRefreshFamilyUsage(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, RefreshFamilyUsage{
+		ClientID:   client.ClientID,
+		ClientName: client.ClientName,
+		Active:     2,
+		Limit:      3,
+		Policy:     models.RefreshFamilyPolicyReject,
+	}, usage[0])
+
+	t.Run("NoLimitNoUsage", func(t *testing.T) {
+		unlimited := createTestTokenService(t, s, familyLimitConfig(0, ""))
+		usage, err := unlimited.GetRefreshFamilyUsage(context.Background(), userID)
+		require.NoError(t, err)
+		assert.Empty(t, usage)
+	})
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+)
+
+// tokenLineageEventLimit caps the audit events loaded for one family timeline.
+const tokenLineageEventLimit = 1000
+
+// Revocation causes reported on the token family timeline.
+const (
+	RevocationCauseRotated     = "rotated"
+	RevocationCauseReplay      = "replay_detection"
+	RevocationCauseUserRevoke  = "user_revoke"
+	RevocationCauseAdminRevoke = "admin_revoke"
+	RevocationCauseAdminBulk   = "admin_bulk_revoke"
+	RevocationCauseIdle        = "idle_timeout"
+	RevocationCauseLogout      = "logout"
+	RevocationCauseSession     = "session_revoke"
+	RevocationCauseFamilyLimit = "family_limit"
+	RevocationCauseUnknown     = "unknown"
+)
+
+// TokenRevocation explains why a token in a family timeline is revoked.
+// AuditLogID is empty when the cause was inferred rather than recorded,
+// e.g. a refresh token revoked by its own rotation.
+type TokenRevocation struct {
+	Cause         string    `json:"cause"`
+	At            time.Time `json:"at"`
+	ActorUserID   string    `json:"actor_user_id,omitempty"`
+	ActorUsername string    `json:"actor_username,omitempty"`
+	AuditLogID    string    `json:"audit_log_id,omitempty"`
+}
+
+// TokenLineageToken is one token in a family timeline. It deliberately omits
+// the token hash and raw value.
+type TokenLineageToken struct {
+	ID            string           `json:"id"`
+	Category      string           `json:"category"`
+	Status        string           `json:"status"`
+	ParentTokenID string           `json:"parent_token_id,omitempty"`
+	Scopes        string           `json:"scopes"`
+	CreatedAt     time.Time        `json:"created_at"`
+	ExpiresAt     time.Time        `json:"expires_at"`
+	LastUsedAt    *time.Time       `json:"last_used_at,omitempty"`
+	Replayed      bool             `json:"replayed"` // presented again after rotation
+	Revocation    *TokenRevocation `json:"revocation,omitempty"`
+}
+
+// TokenLineageGeneration is one refresh token of a family together with the
+// access tokens minted from it: the grant's access token for the original
+// grant, then one per refresh request presenting it (in rotation mode, each
+// issued alongside the next generation's refresh token). Rotation 0 is the
+// original grant.
+type TokenLineageGeneration struct {
+	Rotation     int                 `json:"rotation"`
+	RefreshToken TokenLineageToken   `json:"refresh_token"`
+	AccessTokens []TokenLineageToken `json:"access_tokens"`
+}
+
+// TokenLineageEvent is an audit log entry relevant to a token family.
+// Cause is set when the event revoked tokens.
+type TokenLineageEvent struct {
+	AuditLogID    string               `json:"audit_log_id"`
+	EventType     models.EventType     `json:"event_type"`
+	EventTime     time.Time            `json:"event_time"`
+	Severity      models.EventSeverity `json:"severity"`
+	Action        string               `json:"action"`
+	ActorUserID   string               `json:"actor_user_id,omitempty"`
+	ActorUsername string               `json:"actor_username,omitempty"`
+	ResourceID    string               `json:"resource_id,omitempty"`
+	Cause         string               `json:"cause,omitempty"`
+	Success       bool                 `json:"success"`
+	Details       models.AuditDetails  `json:"details,omitempty"`
+}
+
+// TokenFamilyLineage is the timeline of a token family: the original grant,
+// every rotation, the access tokens minted from each refresh token, and the
+// audit events that touched the family.
+//
+// Tokens revoked through RevokeTokenByID are deleted, so they only appear in
+// Events. OrphanTokens holds access tokens whose refresh token is gone, or the
+// looked-up token itself when it never had a refresh token (client
+// credentials, personal access tokens).
+type TokenFamilyLineage struct {
+	FamilyID        string                   `json:"family_id"`
+	UserID          string                   `json:"user_id"`
+	Username        string                   `json:"username"`
+	ClientID        string                   `json:"client_id"`
+	ClientName      string                   `json:"client_name"`
+	SessionID       string                   `json:"session_id,omitempty"`
+	Generations     []TokenLineageGeneration `json:"generations"`
+	OrphanTokens    []TokenLineageToken      `json:"orphan_tokens"`
+	Events          []TokenLineageEvent      `json:"events"`
+	EventsTruncated bool                     `json:"events_truncated"`
+}
+
+// tokenFamilyRootID returns the ID identifying tok's family: its family ID in
+// rotation mode, otherwise the refresh token it belongs to.
+func tokenFamilyRootID(tok *models.AccessToken) string {
+	switch {
+	case tok.TokenFamilyID != "":
+		return tok.TokenFamilyID
+	case tok.IsRefreshToken():
+		return tok.ID
+	case tok.ParentTokenID != "":
+		return tok.ParentTokenID
+	default:
+		return tok.ID
+	}
+}
+
+// GetTokenFamilyLineage builds the family timeline for the family tokenID
+// belongs to. tokenID may be any token of the family, including the family ID
+// itself. Returns gorm.ErrRecordNotFound when the token does not exist.
+func (s *TokenService) GetTokenFamilyLineage(
+	ctx context.Context,
+	tokenID string,
+) (*TokenFamilyLineage, error) {
+	tok, err := s.store.GetAccessTokenByID(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	rootID := tokenFamilyRootID(tok)
+	tokens, err := s.store.GetTokenFamilyTokens(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := &TokenFamilyLineage{
+		FamilyID:     rootID,
+		UserID:       tok.UserID,
+		Username:     tok.UserID,
+		ClientID:     tok.ClientID,
+		ClientName:   tok.ClientID,
+		SessionID:    tok.SessionID,
+		Generations:  []TokenLineageGeneration{},
+		OrphanTokens: []TokenLineageToken{},
+		Events:       []TokenLineageEvent{},
+	}
+	if user, err := s.store.GetUserByID(tok.UserID); err == nil && user != nil {
+		lineage.Username = user.Username
+	}
+	if tok.IsPersonalAccessToken() {
+		lineage.ClientName = tok.Name
+	} else if clients, err := s.store.GetClientsByIDs([]string{tok.ClientID}); err == nil {
+		if client, ok := clients[tok.ClientID]; ok && client != nil {
+			lineage.ClientName = client.ClientName
+		}
+	}
+
+	resourceIDs := make([]string, 0, len(tokens)+2)
+	resourceIDs = append(resourceIDs, rootID)
+	for _, t := range tokens {
+		if t.ID != rootID {
+			resourceIDs = append(resourceIDs, t.ID)
+		}
+	}
+	if tok.SessionID != "" {
+		resourceIDs = append(resourceIDs, tok.SessionID)
+	}
+	logs, err := s.store.GetTokenAuditLogs(
+		resourceIDs, []string{rootID}, tokenLineageEventLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	lineage.EventsTruncated = len(logs) >= tokenLineageEventLimit
+	for _, l := range logs {
+		lineage.Events = append(lineage.Events, TokenLineageEvent{
+			AuditLogID:    l.ID,
+			EventType:     l.EventType,
+			EventTime:     l.EventTime,
+			Severity:      l.Severity,
+			Action:        l.Action,
+			ActorUserID:   l.ActorUserID,
+			ActorUsername: l.ActorUsername,
+			ResourceID:    l.ResourceID,
+			Cause:         revocationCause(l),
+			Success:       l.Success,
+			Details:       l.Details,
+		})
+	}
+
+	// Refresh tokens that were rotated away have a refresh token child.
+	successor := make(map[string]time.Time)
+	for _, t := range tokens {
+		if t.IsRefreshToken() && t.ParentTokenID != "" {
+			if _, ok := successor[t.ParentTokenID]; !ok {
+				successor[t.ParentTokenID] = t.CreatedAt
+			}
+		}
+	}
+
+	generationIndex := make(map[string]int)
+	for _, t := range tokens {
+		if !t.IsRefreshToken() {
+			continue
+		}
+		generationIndex[t.ID] = len(lineage.Generations)
+		lineage.Generations = append(lineage.Generations, TokenLineageGeneration{
+			Rotation:     len(lineage.Generations),
+			RefreshToken: lineage.lineageToken(t, rootID, successor),
+			AccessTokens: []TokenLineageToken{},
+		})
+	}
+	for _, t := range tokens {
+		if t.IsRefreshToken() {
+			continue
+		}
+		entry := lineage.lineageToken(t, rootID, successor)
+		parent := t.ParentTokenID
+		if parent == "" {
+			// The access token issued alongside the original grant.
+			parent = rootID
+		}
+		if i, ok := generationIndex[parent]; ok {
+			g := &lineage.Generations[i]
+			g.AccessTokens = append(g.AccessTokens, entry)
+		} else {
+			lineage.OrphanTokens = append(lineage.OrphanTokens, entry)
+		}
+	}
+
+	return lineage, nil
+}
+
+// lineageToken converts t for the timeline and attributes its revocation.
+func (l *TokenFamilyLineage) lineageToken(
+	t models.AccessToken,
+	rootID string,
+	successor map[string]time.Time,
+) TokenLineageToken {
+	entry := TokenLineageToken{
+		ID:            t.ID,
+		Category:      t.TokenCategory,
+		Status:        t.Status,
+		ParentTokenID: t.ParentTokenID,
+		Scopes:        t.Scopes,
+		CreatedAt:     t.CreatedAt,
+		ExpiresAt:     t.ExpiresAt,
+		LastUsedAt:    t.LastUsedAt,
+	}
+	for _, e := range l.Events {
+		if e.Cause == RevocationCauseReplay && e.ResourceID == t.ID {
+			entry.Replayed = true
+		}
+	}
+	if !t.IsRevoked() {
+		return entry
+	}
+
+	// The earliest event that applies to the token is the one that revoked it.
+	for _, e := range l.Events {
+		if e.Cause == "" || e.EventTime.Before(t.CreatedAt) || !e.appliesTo(t, rootID) {
+			continue
+		}
+		entry.Revocation = &TokenRevocation{
+			Cause:         e.Cause,
+			At:            e.EventTime,
+			ActorUserID:   e.ActorUserID,
+			ActorUsername: e.ActorUsername,
+			AuditLogID:    e.AuditLogID,
+		}
+		break
+	}
+	if at, ok := successor[t.ID]; ok &&
+		(entry.Revocation == nil || at.Before(entry.Revocation.At)) {
+		entry.Revocation = &TokenRevocation{Cause: RevocationCauseRotated, At: at}
+	}
+	if entry.Revocation == nil {
+		entry.Revocation = &TokenRevocation{Cause: RevocationCauseUnknown}
+	}
+	return entry
+}
+
+// appliesTo reports whether the revocation recorded by e covers t. Events
+// of an unrecognised cause never apply, so they cannot be blamed for every
+// revoked token in the family.
+func (e TokenLineageEvent) appliesTo(t models.AccessToken, rootID string) bool {
+	switch e.Cause {
+	case RevocationCauseUserRevoke, RevocationCauseAdminRevoke:
+		return e.ResourceID == t.ID
+	case RevocationCauseIdle:
+		// Rotation mode revokes the whole family; fixed mode only the token.
+		return e.ResourceID == t.ID || fmt.Sprint(e.Details["family_id"]) == rootID
+	case RevocationCauseLogout, RevocationCauseSession:
+		return t.SessionID != "" && e.ResourceID == t.SessionID
+	case RevocationCauseReplay:
+		return fmt.Sprint(e.Details["family_id"]) == rootID
+	case RevocationCauseFamilyLimit, RevocationCauseAdminBulk:
+		return slices.Contains(auditDetailStrings(e.Details["family_ids"]), rootID)
+	default:
+		return false
+	}
+}
+
+// auditDetailStrings returns a list-valued audit detail as strings. Details
+// read back from the database hold []any rather than []string.
+func auditDetailStrings(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []any:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	}
+	return nil
+}
+
+// revocationCause classifies an audit log entry that revoked tokens. It
+// returns "" for events that did not revoke anything.
+func revocationCause(l models.AuditLog) string {
+	if !l.Success {
+		return ""
+	}
+	switch l.EventType {
+	case models.EventSuspiciousActivity:
+		if _, ok := l.Details["reused_token_id"]; ok {
+			return RevocationCauseReplay
+		}
+	case models.EventTokenRevoked:
+		if l.Action == idleRefreshTokenRevokedAction {
+			return RevocationCauseIdle
+		}
+		if fmt.Sprint(l.Details["token_user_id"]) == l.ActorUserID {
+			return RevocationCauseUserRevoke
+		}
+		return RevocationCauseAdminRevoke
+	case models.EventSessionTokensRevoked:
+		if l.Details["reason"] == "logout" {
+			return RevocationCauseLogout
+		}
+		return RevocationCauseSession
+	case models.EventTokenFamilyEvicted:
+		return RevocationCauseFamilyLimit
+	case models.EventTokensBulkRevoked:
+		return RevocationCauseAdminBulk
+	}
+	return ""
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRevocationCause(t *testing.T) {
+	tests := []struct {
+		name string
+		log  models.AuditLog
+		want string
+	}{
+		{
+			"replay",
+			models.AuditLog{
+				EventType: models.EventSuspiciousActivity,
+				Details:   models.AuditDetails{"reused_token_id": "t1"},
+				Success:   true,
+			},
+			RevocationCauseReplay,
+		},
+		{
+			"user revoke",
+			models.AuditLog{
+				EventType:   models.EventTokenRevoked,
+				Action:      "Token revoked",
+				ActorUserID: "u1",
+				Details:     models.AuditDetails{"token_user_id": "u1"},
+				Success:     true,
+			},
+			RevocationCauseUserRevoke,
+		},
+		{
+			"admin revoke",
+			models.AuditLog{
+				EventType:   models.EventTokenRevoked,
+				Action:      "Token revoked",
+				ActorUserID: "admin",
+				Details:     models.AuditDetails{"token_user_id": "u1"},
+				Success:     true,
+			},
+			RevocationCauseAdminRevoke,
+		},
+		{
+			"idle timeout",
+			models.AuditLog{
+				EventType: models.EventTokenRevoked,
+				Action:    idleRefreshTokenRevokedAction,
+				Success:   true,
+			},
+			RevocationCauseIdle,
+		},
+		{
+			"logout",
+			models.AuditLog{
+				EventType: models.EventSessionTokensRevoked,
+				Details:   models.AuditDetails{"reason": "logout"},
+				Success:   true,
+			},
+			RevocationCauseLogout,
+		},
+		{
+			"family eviction",
+			models.AuditLog{EventType: models.EventTokenFamilyEvicted, Success: true},
+			RevocationCauseFamilyLimit,
+		},
+		{
+			"bulk revoke",
+			models.AuditLog{EventType: models.EventTokensBulkRevoked, Success: true},
+			RevocationCauseAdminBulk,
+		},
+		{
+			"failed revocation",
+			models.AuditLog{EventType: models.EventTokenRevoked, Success: false},
+			"",
+		},
+		{
+			"refresh",
+			models.AuditLog{EventType: models.EventTokenRefreshed, Success: true},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, revocationCause(tt.log))
+		})
+	}
+}
+
+func TestTokenLineageEventAppliesTo(t *testing.T) {
+	tok := models.AccessToken{ID: "t1", SessionID: "sid-1"}
+	tests := []struct {
+		name  string
+		event TokenLineageEvent
+		want  bool
+	}{
+		{
+			"bulk revoke naming the family",
+			TokenLineageEvent{
+				Cause:   RevocationCauseAdminBulk,
+				Details: models.AuditDetails{"family_ids": []any{"other", "root"}},
+			},
+			true,
+		},
+		{
+			"bulk revoke of other families",
+			TokenLineageEvent{
+				Cause:   RevocationCauseAdminBulk,
+				Details: models.AuditDetails{"family_ids": []any{"other"}},
+			},
+			false,
+		},
+		{
+			"family eviction",
+			TokenLineageEvent{
+				Cause:   RevocationCauseFamilyLimit,
+				Details: models.AuditDetails{"family_ids": []string{"root"}},
+			},
+			true,
+		},
+		{
+			"replay of another family",
+			TokenLineageEvent{
+				Cause:   RevocationCauseReplay,
+				Details: models.AuditDetails{"family_id": "other"},
+			},
+			false,
+		},
+		{
+			"unrecognised cause",
+			TokenLineageEvent{
+				Cause:   "something_new",
+				Details: models.AuditDetails{"family_id": "root"},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.event.appliesTo(tok, "root"))
+		})
+	}
+}
+
+func TestGetTokenFamilyLineage(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := sessionTestConfig()
+	cfg.EnableTokenRotation = true
+	tokenService := createTestTokenService(t, s, cfg)
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	access0, refresh0 := exchangeSessionBoundCode(t, s, tokenService, client, userID, "sid-1")
+	time.Sleep(2 * time.Millisecond)
+	access1, refresh1, err := tokenService.RefreshAccessToken(
+		context.Background(), refresh0.RawToken, client.ClientID, "", nil, nil,
+	)
+	require.NoError(t, err)
+
+	// Simulate a replay of the rotated refresh token revoking the family.
+	_, err = s.RevokeTokenFamily(refresh0.ID)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateAuditLog(&models.AuditLog{
+		ID:           uuid.New().String(),
+		EventType:    models.EventSuspiciousActivity,
+		EventTime:    time.Now(),
+		Severity:     models.SeverityCritical,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   refresh0.ID,
+		Action:       "Refresh token reuse detected — token family revoked",
+		Details: models.AuditDetails{
+			"family_id":       refresh0.ID,
+			"reused_token_id": refresh0.ID,
+		},
+		Success: true,
+	}))
+
+	for _, id := range []string{refresh0.ID, access1.ID, refresh1.ID} {
+		lineage, err := tokenService.GetTokenFamilyLineage(context.Background(), id)
+		require.NoError(t, err)
+
+		assert.Equal(t, refresh0.ID, lineage.FamilyID)
+		assert.Equal(t, "sid-1", lineage.SessionID)
+		assert.Equal(t, client.ClientName, lineage.ClientName)
+		assert.Empty(t, lineage.OrphanTokens)
+		require.Len(t, lineage.Generations, 2)
+
+		original := lineage.Generations[0]
+		assert.Equal(t, refresh0.ID, original.RefreshToken.ID)
+		assert.True(t, original.RefreshToken.Replayed)
+		require.NotNil(t, original.RefreshToken.Revocation)
+		assert.Equal(t, RevocationCauseRotated, original.RefreshToken.Revocation.Cause)
+		// The grant's access token plus the one minted by presenting refresh0.
+		require.Len(t, original.AccessTokens, 2)
+		assert.Equal(t, access0.ID, original.AccessTokens[0].ID)
+		assert.Equal(t, access1.ID, original.AccessTokens[1].ID)
+		assert.Equal(t, RevocationCauseReplay, original.AccessTokens[0].Revocation.Cause)
+
+		rotation := lineage.Generations[1]
+		assert.Equal(t, 1, rotation.Rotation)
+		assert.Equal(t, refresh1.ID, rotation.RefreshToken.ID)
+		assert.False(t, rotation.RefreshToken.Replayed)
+		assert.Equal(t, RevocationCauseReplay, rotation.RefreshToken.Revocation.Cause)
+		assert.Empty(t, rotation.AccessTokens, "refresh1 was never presented")
+
+		require.Len(t, lineage.Events, 1)
+		assert.Equal(t, RevocationCauseReplay, lineage.Events[0].Cause)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := tokenService.GetTokenFamilyLineage(context.Background(), uuid.New().String())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestGetTokenFamilyLineage_AdminRevokedAccessToken(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(t, s, sessionTestConfig())
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	_, refresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+	time.Sleep(2 * time.Millisecond)
+	access, _, err := tokenService.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "", nil, nil,
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdateTokenStatus(access.ID, models.TokenStatusRevoked))
+	require.NoError(t, s.CreateAuditLog(&models.AuditLog{
+		ID:           uuid.New().String(),
+		EventType:    models.EventTokenRevoked,
+		EventTime:    time.Now(),
+		Severity:     models.SeverityInfo,
+		ActorUserID:  "admin-1",
+		ResourceType: models.ResourceToken,
+		ResourceID:   access.ID,
+		Action:       "Token revoked",
+		Details:      models.AuditDetails{"token_user_id": userID},
+		Success:      true,
+	}))
+
+	lineage, err := tokenService.GetTokenFamilyLineage(context.Background(), access.ID)
+	require.NoError(t, err)
+
+	// Fixed mode: the refresh token is the family root and has no family ID.
+	assert.Equal(t, refresh.ID, lineage.FamilyID)
+	require.Len(t, lineage.Generations, 1)
+	gen := lineage.Generations[0]
+	assert.Nil(t, gen.RefreshToken.Revocation)
+	// The grant's own access token is not linked to the refresh token in fixed
+	// mode, so only the one minted by the refresh shows up.
+	require.Len(t, gen.AccessTokens, 1)
+	revoked := gen.AccessTokens[0]
+	assert.Equal(t, access.ID, revoked.ID)
+	require.NotNil(t, revoked.Revocation)
+	assert.Equal(t, RevocationCauseAdminRevoke, revoked.Revocation.Cause)
+	assert.Equal(t, "admin-1", revoked.Revocation.ActorUserID)
+}
+
+func TestGetTokenFamilyLineage_BulkRevoke(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := sessionTestConfig()
+	cfg.EnableTokenRotation = true
+	tokenService := createTestTokenService(t, s, cfg)
+	auditService := NewAuditService(s, 100)
+	tokenService.auditService = auditService
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	access, refresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "")
+	time.Sleep(2 * time.Millisecond)
+	count, err := tokenService.BulkUpdateTokens(
+		context.Background(), "admin-1",
+		store.TokenFilter{UserID: userID}, BulkTokenActionRevoke, false,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	require.NoError(t, auditService.Shutdown(context.Background()))
+
+	lineage, err := tokenService.GetTokenFamilyLineage(context.Background(), access.ID)
+	require.NoError(t, err)
+	require.Len(t, lineage.Generations, 1)
+	gen := lineage.Generations[0]
+	assert.Equal(t, refresh.ID, gen.RefreshToken.ID)
+	require.Len(t, gen.AccessTokens, 1)
+	for _, entry := range []TokenLineageToken{gen.RefreshToken, gen.AccessTokens[0]} {
+		require.NotNil(t, entry.Revocation)
+		assert.Equal(t, RevocationCauseAdminBulk, entry.Revocation.Cause)
+		assert.Equal(t, "admin-1", entry.Revocation.ActorUserID)
+		assert.NotEmpty(t, entry.Revocation.AuditLogID)
+	}
+}
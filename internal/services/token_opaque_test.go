@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOpaqueTokenService builds a TokenService whose global TOKEN_FORMAT is
+// "opaque", optionally with refresh-token rotation enabled.
+func newOpaqueTokenService(t *testing.T, rotation bool) (*TokenService, *store.Store) {
+	t.Helper()
+	cfg := configWithTokenProfiles()
+	cfg.TokenFormat = models.TokenFormatOpaque
+	cfg.EnableTokenRotation = rotation
+	s := setupTestStore(t)
+	return createTestTokenService(t, s, cfg), s
+}
+
+func TestExchangeDeviceCode_OpaqueFormat(t *testing.T) {
+	svc, s := newOpaqueTokenService(t, false)
+	client := createTestClientWithProfile(t, svc, models.TokenProfileShort)
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+
+	access, refresh, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+
+	assert.True(t, token.IsOpaqueToken(access.RawToken), "access token should be opaque")
+	assert.Equal(t, 2, strings.Count(refresh.RawToken, "."), "refresh token stays a JWT")
+	// Opaque tokens still honor the client's token profile lifetime.
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), access.ExpiresAt, 5*time.Second)
+}
+
+func TestValidateToken_Opaque(t *testing.T) {
+	svc, s := newOpaqueTokenService(t, false)
+	client := createTestClientWithProfile(t, svc, "")
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+
+	access, _, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+
+	result, err := svc.ValidateToken(context.Background(), access.RawToken)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, access.UserID, result.UserID)
+	assert.Equal(t, client.ClientID, result.ClientID)
+	assert.Equal(t, "read write", result.Scopes)
+	assert.Equal(t, "read write", result.Claims["scope"])
+
+	// Revocation takes effect immediately — there is no self-contained copy.
+	require.NoError(t, svc.RevokeToken(access.RawToken))
+	_, err = svc.ValidateToken(context.Background(), access.RawToken)
+	require.Error(t, err)
+}
+
+func TestValidateToken_UnknownOpaqueToken(t *testing.T) {
+	svc, _ := newOpaqueTokenService(t, false)
+
+	forged, err := token.GenerateOpaqueToken(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = svc.ValidateToken(context.Background(), forged.TokenString)
+	require.Error(t, err)
+}
+
+func TestIntrospectToken_Opaque(t *testing.T) {
+	svc, s := newOpaqueTokenService(t, false)
+	client := createTestClientWithProfile(t, svc, "")
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+
+	access, _, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+
+	tok, active := svc.IntrospectToken(context.Background(), access.RawToken, client.ClientID)
+	require.True(t, active)
+	assert.Equal(t, access.ID, tok.ID)
+
+	require.NoError(t, svc.RevokeToken(access.RawToken))
+	_, active = svc.IntrospectToken(context.Background(), access.RawToken, client.ClientID)
+	assert.False(t, active)
+}
+
+func TestRefreshAccessToken_OpaqueWithRotation(t *testing.T) {
+	svc, s := newOpaqueTokenService(t, true)
+	client := createTestClientWithProfile(t, svc, "")
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+
+	_, refresh, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+
+	newAccess, newRefresh, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+	assert.True(t, token.IsOpaqueToken(newAccess.RawToken))
+	require.NotNil(t, newRefresh)
+	assert.False(t, token.IsOpaqueToken(newRefresh.RawToken))
+
+	_, err = svc.ValidateToken(context.Background(), newAccess.RawToken)
+	require.NoError(t, err)
+
+	// Replaying the rotated-out refresh token revokes the whole family,
+	// including the opaque access token minted from it.
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrInvalidRefreshToken)
+	_, err = svc.ValidateToken(context.Background(), newAccess.RawToken)
+	require.Error(t, err)
+}
+
+func TestIssueClientCredentialsToken_Opaque(t *testing.T) {
+	svc, s := newCCTokenService(t)
+	svc.config.TokenFormat = models.TokenFormatOpaque
+	client, secret := createConfidentialClientWithCCFlow(t, s, true)
+
+	tok, err := svc.IssueClientCredentialsToken(
+		context.Background(), client.ClientID, secret, "", nil, nil,
+	)
+	require.NoError(t, err)
+	assert.True(t, token.IsOpaqueToken(tok.RawToken))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), tok.ExpiresAt, 5*time.Second)
+}
+
+func TestTokenFormat_ClientOverridesGlobal(t *testing.T) {
+	svc, s := newOpaqueTokenService(t, false)
+	client := createTestClientWithProfile(t, svc, "")
+	client.TokenFormat = models.TokenFormatJWT
+	require.NoError(t, s.UpdateClient(client))
+
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+	access, _, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+	assert.False(t, token.IsOpaqueToken(access.RawToken), "client jwt override wins")
+
+	result, err := svc.ValidateToken(context.Background(), access.RawToken)
+	require.NoError(t, err)
+	assert.Equal(t, client.ClientID, result.ClientID)
+}
+
+func TestTokenFormatForClient(t *testing.T) {
+	svc, _ := newOpaqueTokenService(t, false)
+
+	assert.Equal(t, models.TokenFormatOpaque, svc.tokenFormatForClient(nil))
+	assert.Equal(t, models.TokenFormatOpaque,
+		svc.tokenFormatForClient(&models.OAuthApplication{}))
+	assert.Equal(t, models.TokenFormatJWT,
+		svc.tokenFormatForClient(&models.OAuthApplication{TokenFormat: models.TokenFormatJWT}))
+	assert.Equal(t, models.TokenFormatOpaque,
+		svc.tokenFormatForClient(&models.OAuthApplication{TokenFormat: "bogus"}),
+		"unknown client value falls back to the global default")
+
+	svc.config.TokenFormat = ""
+	assert.Equal(t, models.TokenFormatJWT, svc.tokenFormatForClient(nil))
+}
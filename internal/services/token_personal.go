@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxPersonalAccessTokenNameLength matches the AccessToken.Name column size.
+const maxPersonalAccessTokenNameLength = 100
+
+// personalAccessTokenLastUsedResolution throttles last_used_at writes so a
+// script hammering an API with one token does not turn every request into
+// a database write.
+const personalAccessTokenLastUsedResolution = time.Minute
+
+var (
+	ErrPersonalAccessTokensDisabled = errors.New("personal access tokens are disabled")
+	ErrPersonalAccessTokenName      = errors.New(
+		"token name is required and must be at most 100 characters",
+	)
+	ErrPersonalAccessTokenScope = errors.New(
+		"requested scope is not available for personal access tokens",
+	)
+	ErrPersonalAccessTokenExpiry = errors.New(
+		"token expiry must be positive and within the allowed maximum lifetime",
+	)
+)
+
+// CreatePersonalAccessTokenRequest holds the user's choices for a new
+// personal access token.
+type CreatePersonalAccessTokenRequest struct {
+	Name      string
+	Scopes    string        // space-separated; each must be in PERSONAL_ACCESS_TOKEN_SCOPES
+	ExpiresIn time.Duration // must not exceed PERSONAL_ACCESS_TOKEN_MAX_LIFETIME
+	Resource  []string      // optional RFC 8707 audience binding
+}
+
+// PersonalAccessTokensEnabled reports whether users may create personal
+// access tokens. Existing tokens stay valid and revocable when disabled.
+func (s *TokenService) PersonalAccessTokensEnabled() bool {
+	return s.config.EnablePersonalAccessTokens
+}
+
+// PersonalAccessTokenScopes returns the scopes users may grant to a personal
+// access token.
+func (s *TokenService) PersonalAccessTokenScopes() []string {
+	return s.config.PersonalAccessTokenScopes
+}
+
+// PersonalAccessTokenMaxLifetime returns the longest expiry a user may pick.
+func (s *TokenService) PersonalAccessTokenMaxLifetime() time.Duration {
+	return s.config.PersonalAccessTokenMaxLifetime
+}
+
+// CreatePersonalAccessToken mints a personal access token for userID. The
+// returned record carries RawToken, which is shown to the user once and
+// never stored — only its hash is persisted.
+func (s *TokenService) CreatePersonalAccessToken(
+	ctx context.Context,
+	userID string,
+	req CreatePersonalAccessTokenRequest,
+) (*models.AccessToken, error) {
+	if !s.config.EnablePersonalAccessTokens {
+		return nil, ErrPersonalAccessTokensDisabled
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxPersonalAccessTokenNameLength {
+		return nil, ErrPersonalAccessTokenName
+	}
+
+	scopes := strings.Fields(req.Scopes)
+	if len(scopes) == 0 {
+		return nil, ErrPersonalAccessTokenScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(s.config.PersonalAccessTokenScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrPersonalAccessTokenScope, scope)
+		}
+	}
+
+	if req.ExpiresIn <= 0 || req.ExpiresIn > s.config.PersonalAccessTokenMaxLifetime {
+		return nil, ErrPersonalAccessTokenExpiry
+	}
+
+	resource, err := util.ValidateResourceIndicators(req.Resource)
+	if err != nil {
+		return nil, ErrInvalidTarget
+	}
+
+	start := time.Now()
+	result, err := token.GeneratePersonalAccessToken(start.Add(req.ExpiresIn))
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
+
+	pat := &models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     util.SHA256Hex(result.TokenString),
+		RawToken:      result.TokenString,
+		TokenType:     result.TokenType,
+		TokenCategory: models.TokenCategoryPersonal,
+		Status:        models.TokenStatusActive,
+		UserID:        userID,
+		Scopes:        strings.Join(scopes, " "),
+		ExpiresAt:     result.ExpiresAt,
+		Name:          name,
+		Resource:      models.StringArray(resource),
+	}
+	if err := s.store.CreateAccessToken(pat); err != nil {
+		return nil, fmt.Errorf("failed to save personal access token: %w", err)
+	}
+
+	s.metrics.RecordTokenIssued(
+		models.TokenCategoryPersonal,
+		"personal_access_token",
+		time.Since(start),
+		models.TokenFormatOpaque,
+	)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventPersonalAccessTokenCreated,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   pat.ID,
+		Action:       "Personal access token created",
+		Details: models.AuditDetails{
+			"name":       name,
+			"scopes":     pat.Scopes,
+			"expires_at": pat.ExpiresAt,
+			"resource":   resource,
+		},
+		Success: true,
+	})
+
+	return pat, nil
+}
+
+// ListPersonalAccessTokens returns the user's personal access tokens, newest
+// first.
+func (s *TokenService) ListPersonalAccessTokens(
+	userID string,
+	params store.PaginationParams,
+) ([]models.AccessToken, store.PaginationResult, error) {
+	params.Search = ""
+	params.CategoryFilter = models.TokenCategoryPersonal
+	return s.store.GetTokensByUserIDPaginated(userID, params)
+}
+
+// IsPersonalAccessTokenOwnedByUser reports whether tokenID is a personal
+// access token belonging to userID. A missing token returns (false, nil).
+func (s *TokenService) IsPersonalAccessTokenOwnedByUser(tokenID, userID string) (bool, error) {
+	tok, err := s.store.GetAccessTokenByID(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return tok.IsPersonalAccessToken() && tok.UserID == userID, nil
+}
+
+// touchPersonalAccessToken records use of a personal access token, at most
+// once per personalAccessTokenLastUsedResolution. The cached copy is dropped
+// so the next lookup sees the new timestamp instead of re-triggering a write.
+func (s *TokenService) touchPersonalAccessToken(ctx context.Context, tok *models.AccessToken) {
+	now := time.Now()
+	if tok.LastUsedAt != nil && now.Sub(*tok.LastUsedAt) < personalAccessTokenLastUsedResolution {
+		return
+	}
+	if err := s.store.UpdateTokenLastUsedAt(tok.ID, now); err != nil {
+		return
+	}
+	s.invalidateTokenCache(ctx, tok.TokenHash)
+}
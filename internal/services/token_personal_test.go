@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPersonalTokenService builds a TokenService with personal access tokens
+// enabled for "read write" and a 90-day maximum lifetime.
+func newPersonalTokenService(t *testing.T) (*TokenService, *store.Store) {
+	t.Helper()
+	cfg := configWithTokenProfiles()
+	cfg.EnablePersonalAccessTokens = true
+	cfg.PersonalAccessTokenMaxLifetime = 90 * 24 * time.Hour
+	cfg.PersonalAccessTokenScopes = []string{"read", "write"}
+	s := setupTestStore(t)
+	return createTestTokenService(t, s, cfg), s
+}
+
+func createPAT(
+	t *testing.T,
+	svc *TokenService,
+	userID string,
+	req CreatePersonalAccessTokenRequest,
+) *models.AccessToken {
+	t.Helper()
+	pat, err := svc.CreatePersonalAccessToken(context.Background(), userID, req)
+	require.NoError(t, err)
+	return pat
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	userID := uuid.New().String()
+
+	pat := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name:      "  deploy-script ",
+		Scopes:    "read",
+		ExpiresIn: 30 * 24 * time.Hour,
+		Resource:  []string{"https://api.example.com"},
+	})
+
+	assert.True(t, token.IsPersonalAccessToken(pat.RawToken))
+	assert.Equal(t, "deploy-script", pat.Name)
+	assert.Equal(t, models.TokenCategoryPersonal, pat.TokenCategory)
+	assert.Empty(t, pat.ClientID)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), pat.ExpiresAt, 5*time.Second)
+
+	// Only the hash is stored.
+	stored, err := s.GetAccessTokenByID(pat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, util.SHA256Hex(pat.RawToken), stored.TokenHash)
+	assert.Equal(t, models.StringArray{"https://api.example.com"}, stored.Resource)
+}
+
+func TestCreatePersonalAccessToken_Validation(t *testing.T) {
+	svc, _ := newPersonalTokenService(t)
+	valid := CreatePersonalAccessTokenRequest{
+		Name: "ci", Scopes: "read", ExpiresIn: 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*CreatePersonalAccessTokenRequest)
+		want   error
+	}{
+		{"empty name", func(r *CreatePersonalAccessTokenRequest) { r.Name = "  " }, ErrPersonalAccessTokenName},
+		{"no scopes", func(r *CreatePersonalAccessTokenRequest) { r.Scopes = "" }, ErrPersonalAccessTokenScope},
+		{"scope not offered", func(r *CreatePersonalAccessTokenRequest) { r.Scopes = "read admin" }, ErrPersonalAccessTokenScope},
+		{"zero expiry", func(r *CreatePersonalAccessTokenRequest) { r.ExpiresIn = 0 }, ErrPersonalAccessTokenExpiry},
+		{"beyond max lifetime", func(r *CreatePersonalAccessTokenRequest) { r.ExpiresIn = 91 * 24 * time.Hour }, ErrPersonalAccessTokenExpiry},
+		{"bad resource", func(r *CreatePersonalAccessTokenRequest) { r.Resource = []string{"not-a-uri"} }, ErrInvalidTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.mutate(&req)
+			_, err := svc.CreatePersonalAccessToken(context.Background(), "user", req)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	svc.config.EnablePersonalAccessTokens = false
+	_, err := svc.CreatePersonalAccessToken(context.Background(), "user", valid)
+	require.ErrorIs(t, err, ErrPersonalAccessTokensDisabled)
+}
+
+func TestValidateToken_PersonalAccessToken(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	userID := uuid.New().String()
+	pat := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "script", Scopes: "read write", ExpiresIn: 24 * time.Hour,
+	})
+
+	result, err := svc.ValidateToken(context.Background(), pat.RawToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, result.UserID)
+	assert.Equal(t, "read write", result.Scopes)
+
+	// Use is recorded for the listing page.
+	stored, err := s.GetAccessTokenByID(pat.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.WithinDuration(t, time.Now(), *stored.LastUsedAt, 5*time.Second)
+
+	require.NoError(t, svc.RevokeTokenByID(context.Background(), pat.ID, userID))
+	_, err = svc.ValidateToken(context.Background(), pat.RawToken)
+	require.Error(t, err)
+}
+
+func TestValidateToken_ExpiredPersonalAccessToken(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	pat := createPAT(t, svc, "user", CreatePersonalAccessTokenRequest{
+		Name: "script", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	require.NoError(t, s.UpdateTokenExpiresAt(pat.ID, time.Now().Add(-time.Minute)))
+
+	_, err := svc.ValidateToken(context.Background(), pat.RawToken)
+	require.Error(t, err)
+}
+
+func TestListPersonalAccessTokens(t *testing.T) {
+	svc, s := newPersonalTokenService(t)
+	userID := uuid.New().String()
+	pat := createPAT(t, svc, userID, CreatePersonalAccessTokenRequest{
+		Name: "mine", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	createPAT(t, svc, uuid.New().String(), CreatePersonalAccessTokenRequest{
+		Name: "someone else", Scopes: "read", ExpiresIn: time.Hour,
+	})
+	// OAuth-issued tokens are not listed.
+	require.NoError(t, s.CreateAccessToken(&models.AccessToken{
+		ID:            uuid.New().String(),
+		TokenHash:     util.SHA256Hex(uuid.New().String()),
+		TokenCategory: models.TokenCategoryAccess,
+		Status:        models.TokenStatusActive,
+		UserID:        userID,
+		ClientID:      "client",
+		Scopes:        "read",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}))
+
+	tokens, pagination, err := svc.ListPersonalAccessTokens(
+		userID, store.NewPaginationParams(1, 10, ""),
+	)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, pat.ID, tokens[0].ID)
+	assert.Equal(t, int64(1), pagination.Total)
+
+	owned, err := svc.IsPersonalAccessTokenOwnedByUser(pat.ID, userID)
+	require.NoError(t, err)
+	assert.True(t, owned)
+	owned, err = svc.IsPersonalAccessTokenOwnedByUser(pat.ID, "intruder")
+	require.NoError(t, err)
+	assert.False(t, owned)
+}
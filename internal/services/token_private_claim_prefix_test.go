@@ -388,6 +388,7 @@ func minimalValidConfig() *config.Config {
 			},
 		},
 		RateLimitStore:        config.RateLimitStoreMemory,
+		DeviceNotifyStore:     config.DeviceNotifyStoreMemory,
 		MetricsCacheType:      config.CacheTypeMemory,
 		UserCacheType:         config.CacheTypeMemory,
 		UserCacheTTL:          5 * time.Minute,
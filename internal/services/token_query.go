@@ -42,7 +42,10 @@ func (s *TokenService) enrichTokensWithClients(
 	result := make([]TokenWithClient, 0, len(tokens))
 	for _, tok := range tokens {
 		clientName := tok.ClientID // Default to ClientID if not found
-		if client, ok := clientMap[tok.ClientID]; ok && client != nil {
+		if tok.IsPersonalAccessToken() {
+			// Personal access tokens have no client; show the user's label.
+			clientName = tok.Name
+		} else if client, ok := clientMap[tok.ClientID]; ok && client != nil {
 			clientName = client.ClientName
 		}
 		result = append(result, TokenWithClient{AccessToken: tok, ClientName: clientName})
@@ -87,11 +90,13 @@ type TokenWithUser struct {
 	Username string
 }
 
-// ListAllTokensPaginated returns paginated tokens across all users with client and user info.
+// ListAllTokensPaginated returns paginated tokens across all users matching
+// filter, with client and user info.
 func (s *TokenService) ListAllTokensPaginated(
 	params store.PaginationParams,
+	filter store.TokenFilter,
 ) ([]TokenWithUser, store.PaginationResult, error) {
-	tokens, pagination, err := s.store.GetTokensPaginated(params)
+	tokens, pagination, err := s.store.SearchTokensPaginated(params, filter)
 	if err != nil {
 		return nil, store.PaginationResult{}, err
 	}
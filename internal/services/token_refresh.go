@@ -71,6 +71,89 @@ func (s *TokenService) revokeTokenFamilyWithAudit(
 	})
 }
 
+// idleRefreshTokenRevokedAction is the audit action of revokeIdleRefreshToken;
+// the family timeline uses it to tell idle expiry from manual revocation.
+const idleRefreshTokenRevokedAction = "Idle refresh token revoked"
+
+// revokeIdleRefreshToken revokes a refresh token that exceeded its profile's
+// idle timeout. In rotation mode the whole family is revoked so the access
+// tokens derived from it die with it; in fixed mode only the refresh token
+// itself is revoked.
+func (s *TokenService) revokeIdleRefreshToken(
+	ctx context.Context, idleToken *models.AccessToken, idleTimeout time.Duration,
+) {
+	var (
+		revokedCount int64
+		err          error
+	)
+	if familyID := idleToken.TokenFamilyID; familyID != "" {
+		hashes, hashErr := s.store.GetActiveTokenHashesByFamilyID(familyID)
+		if hashErr != nil {
+			log.Printf(
+				"[TokenCache] failed to collect family hashes for invalidation family=%s: %v",
+				familyID, hashErr,
+			)
+		}
+		revokedCount, err = s.store.RevokeTokenFamily(familyID)
+		if err == nil {
+			s.invalidateTokenCacheByHashes(ctx, hashes)
+		}
+	} else {
+		err = s.store.UpdateTokenStatus(idleToken.ID, models.TokenStatusRevoked)
+		if err == nil {
+			revokedCount = 1
+			s.invalidateTokenCache(ctx, idleToken.TokenHash)
+		}
+	}
+	if err != nil {
+		log.Printf("[Token] Failed to revoke idle refresh token %s: %v", idleToken.ID, err)
+		return
+	}
+
+	if revokedCount > 0 {
+		s.metrics.RecordTokenRevoked(models.TokenCategoryRefresh, "idle_timeout")
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTokenRevoked,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  idleToken.UserID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   idleToken.ID,
+		Action:       idleRefreshTokenRevokedAction,
+		Details: models.AuditDetails{
+			"client_id":      idleToken.ClientID,
+			"family_id":      idleToken.TokenFamilyID,
+			"idle_timeout":   idleTimeout.String(),
+			"tokens_revoked": revokedCount,
+		},
+		Success: true,
+	})
+}
+
+// consentLapsed reports whether the consent a refresh token was issued under
+// has passed its ExpiresAt. Such a consent is expired on the spot, revoking
+// its tokens. Tokens without a consent record are not affected, nor are
+// failed lookups, which are logged.
+func (s *TokenService) consentLapsed(ctx context.Context, refreshToken *models.AccessToken) bool {
+	if refreshToken.AuthorizationID == nil {
+		return false
+	}
+	auth, err := s.store.GetUserAuthorizationByID(*refreshToken.AuthorizationID)
+	if err != nil {
+		log.Printf("[Token] Refresh consent lookup failed authorization=%d: %v",
+			*refreshToken.AuthorizationID, err)
+		return false
+	}
+	if !auth.IsExpired() {
+		return false
+	}
+	if _, err := expireUserAuthorization(ctx, s.store, s.auditService, s, auth); err != nil {
+		log.Printf("[Token] %v", err)
+	}
+	return true
+}
+
 // RefreshAccessToken generates new access token (and optionally new refresh token in rotation mode).
 // callerExtra (optional) is freshly applied to the new token(s) and merged
 // with the client's system-managed claims (project, service_account); reserved
@@ -109,7 +192,16 @@ func (s *TokenService) RefreshAccessToken(
 	}
 	if !refreshToken.IsActive() {
 		// In rotation mode, a non-active refresh token being reused indicates
-		// potential token theft (RFC 6819 §4.14.2). Revoke the entire token family.
+		// potential token theft (RFC 6819 §4.14.2). Revoke the entire token family,
+		// unless this is a retry of a rotation that happened within the reuse
+		// grace window, which gets the already-minted successor pair back.
+		if s.config.EnableTokenRotation && refreshToken.IsRevoked() {
+			if access, refresh, ok := s.replayRotationSuccessor(
+				ctx, refreshToken, refreshTokenString, clientID,
+			); ok {
+				return access, refresh, nil
+			}
+		}
 		if s.config.EnableTokenRotation && (refreshToken.IsRevoked() || refreshToken.IsDisabled()) {
 			s.revokeTokenFamilyWithAudit(ctx, refreshToken)
 		}
@@ -129,6 +221,13 @@ func (s *TokenService) RefreshAccessToken(
 		return nil, nil, ErrAccessDenied
 	}
 
+	// 4b. The grant ends with the consent it was issued under, even when the
+	// consent expiry job has not run yet (or is disabled).
+	if s.consentLapsed(ctx, refreshToken) {
+		s.metrics.RecordTokenRefresh(false)
+		return nil, nil, token.ErrExpiredRefreshToken
+	}
+
 	// 5. Verify scope (cannot upgrade)
 	if !util.IsScopeSubset(refreshToken.Scopes, requestedScopes) {
 		s.metrics.RecordTokenRefresh(false)
@@ -219,7 +318,40 @@ func (s *TokenService) RefreshAccessToken(
 		s.metrics.RecordTokenRefresh(false)
 		return nil, nil, ErrInvalidTarget
 	}
-	extraClaims := s.composeIssuanceClaims(client, refreshToken.UserID, callerExtra)
+
+	// 6a. Idle timeout: a refresh token unused for longer than its profile's
+	// RefreshIdleTimeout is revoked even though its absolute expiry has not
+	// passed. With a nil client the standard profile's timeout applies.
+	if idleTimeout := s.refreshIdleTimeoutForClient(client); refreshToken.IsIdle(idleTimeout) {
+		s.revokeIdleRefreshToken(ctx, refreshToken, idleTimeout)
+		s.metrics.RecordTokenRefresh(false)
+		return nil, nil, token.ErrExpiredRefreshToken
+	}
+
+	// 6b. Sliding expiration: renewal never extends past the family's
+	// absolute cap. Rotation mode shortens the next refresh token's TTL to
+	// the time remaining; fixed mode moves the existing row's ExpiresAt
+	// forward after the new access token is persisted (step 7).
+	var absoluteExpiresAt *time.Time
+	if s.slidingRefreshEnabled() {
+		abs := refreshToken.CreatedAt.Add(s.config.RefreshTokenExpirationMax)
+		if refreshToken.AbsoluteExpiresAt != nil {
+			abs = *refreshToken.AbsoluteExpiresAt
+		}
+		remaining := time.Until(abs)
+		if remaining <= 0 {
+			s.metrics.RecordTokenRefresh(false)
+			return nil, nil, token.ErrExpiredRefreshToken
+		}
+		absoluteExpiresAt = &abs
+		if s.config.EnableTokenRotation && remaining < s.refreshWindow(refreshTTL) {
+			refreshTTL = remaining
+		}
+	}
+	extraClaims := withSessionClaim(
+		s.composeIssuanceClaims(client, refreshToken.UserID, callerExtra),
+		refreshToken.SessionID,
+	)
 	// Access token's `aud` = effectiveResource (possibly narrowed).
 	// Refresh token's `aud` override = nil → provider falls back to the
 	// static JWTAudience config; the refresh JWT must not carry the
@@ -240,6 +372,14 @@ func (s *TokenService) RefreshAccessToken(
 		s.metrics.RecordTokenRefresh(false)
 		return nil, nil, providerErr
 	}
+	// Refresh tokens stay JWTs in every mode; only the access token follows
+	// the client's TokenFormat. The format is re-resolved here so switching a
+	// client to (or from) opaque takes effect on its next refresh.
+	refreshResult.AccessToken, err = s.applyTokenFormat(client, refreshResult.AccessToken)
+	if err != nil {
+		s.metrics.RecordTokenRefresh(false)
+		return nil, nil, fmt.Errorf("token generation failed: %w", err)
+	}
 
 	// 7. Save new tokens in transaction
 	// 7.1 Create new access token. Resource is the audience the JWT was
@@ -260,6 +400,7 @@ func (s *TokenService) RefreshAccessToken(
 		ExpiresAt:     refreshResult.AccessToken.ExpiresAt,
 		ParentTokenID: refreshToken.ID,
 		TokenFamilyID: refreshToken.TokenFamilyID, // Inherit family ID
+		SessionID:     refreshToken.SessionID,
 		Resource: models.StringArray(
 			effectiveAudience(effectiveResource, s.config.JWTAudience),
 		),
@@ -287,11 +428,27 @@ func (s *TokenService) RefreshAccessToken(
 			ExpiresAt:     refreshResult.RefreshToken.ExpiresAt,
 			ParentTokenID: refreshToken.ID,
 			TokenFamilyID: refreshToken.TokenFamilyID, // Inherit family ID
+			SessionID:     refreshToken.SessionID,
 			Resource:      models.StringArray(originalResource),
+			// Inherit the family's sliding cap so rotation cannot reset it.
+			AbsoluteExpiresAt: absoluteExpiresAt,
 		}
 	}
 
 	rotated := s.config.EnableTokenRotation && newRefreshToken != nil
+	now := time.Now()
+	// Fixed-mode sliding renewal: the refresh JWT was signed out to the
+	// absolute cap at issuance, so only the row's ExpiresAt needs to move.
+	var slidExpiresAt *time.Time
+	if !rotated && absoluteExpiresAt != nil {
+		next := now.Add(s.refreshWindow(refreshTTL))
+		if next.After(*absoluteExpiresAt) {
+			next = *absoluteExpiresAt
+		}
+		if next.After(refreshToken.ExpiresAt) {
+			slidExpiresAt = &next
+		}
+	}
 	if err := s.store.RunInTransaction(func(tx core.Store) error {
 		if err := tx.CreateAccessToken(newAccessToken); err != nil {
 			return fmt.Errorf("failed to save new access token: %w", err)
@@ -307,9 +464,14 @@ func (s *TokenService) RefreshAccessToken(
 			}
 		} else {
 			// Fixed mode: update refresh token's last_used_at
-			if err := tx.UpdateTokenLastUsedAt(refreshToken.ID, time.Now()); err != nil {
+			if err := tx.UpdateTokenLastUsedAt(refreshToken.ID, now); err != nil {
 				return fmt.Errorf("failed to update refresh token last_used_at: %w", err)
 			}
+			if slidExpiresAt != nil {
+				if err := tx.UpdateTokenExpiresAt(refreshToken.ID, *slidExpiresAt); err != nil {
+					return fmt.Errorf("failed to extend refresh token expiry: %w", err)
+				}
+			}
 		}
 		return nil
 	}); err != nil {
@@ -320,11 +482,18 @@ func (s *TokenService) RefreshAccessToken(
 	// Invalidate cache after transaction commits successfully
 	if rotated {
 		s.invalidateTokenCache(ctx, refreshToken.TokenHash)
+		s.rememberRotationSuccessor(
+			ctx, refreshTokenString, refreshToken.TokenHash, newAccessToken, newRefreshToken,
+		)
 	}
 
 	// Fixed mode: return original refresh token with RawToken restored
 	if newRefreshToken == nil {
 		refreshToken.RawToken = refreshTokenString
+		refreshToken.LastUsedAt = &now
+		if slidExpiresAt != nil {
+			refreshToken.ExpiresAt = *slidExpiresAt
+		}
 		newRefreshToken = refreshToken
 	}
 
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+// RotationSuccessor records the token pair minted when a refresh token was
+// rotated, so a client retrying the same refresh within the reuse grace
+// window receives that pair again instead of tripping replay detection.
+//
+// The raw tokens are sealed under a key derived from JWT_SECRET and the
+// rotated-out refresh token itself: only a caller presenting that token to
+// this server can recover them, so neither a cache dump nor the stored token
+// hashes yield usable credentials.
+type RotationSuccessor struct {
+	AccessTokenID  string `json:"access_token_id"`
+	RefreshTokenID string `json:"refresh_token_id"`
+	Sealed         []byte `json:"sealed"`
+}
+
+// rotationSuccessorLabel separates the rotation successor key from any other
+// key derived from the same refresh token.
+const rotationSuccessorLabel = "authgate rotation successor v1"
+
+// rotationSuccessorKey derives the key that seals the successor pair of the
+// given rotated-out refresh token.
+func (s *TokenService) rotationSuccessorKey(refreshTokenString string) ([]byte, error) {
+	return util.DeriveSealKey(s.config.JWTSecret, rotationSuccessorLabel, refreshTokenString)
+}
+
+// rememberRotationSuccessor parks the successor pair of a just-rotated
+// refresh token for RefreshTokenReuseGracePeriod. Failures only cost the
+// retry its grace, so they are logged rather than surfaced.
+func (s *TokenService) rememberRotationSuccessor(
+	ctx context.Context,
+	oldRefreshTokenString, oldRefreshTokenHash string,
+	newAccess, newRefresh *models.AccessToken,
+) {
+	grace := s.config.RefreshTokenReuseGracePeriod
+	if grace <= 0 || s.rotationGraceCache == nil {
+		return
+	}
+	key, err := s.rotationSuccessorKey(oldRefreshTokenString)
+	if err != nil {
+		log.Printf("[Token] Failed to derive rotation successor key: %v", err)
+		return
+	}
+	sealed, err := util.SealWithKey(key, []byte(newAccess.RawToken+"\n"+newRefresh.RawToken))
+	if err != nil {
+		log.Printf("[Token] Failed to seal rotation successor: %v", err)
+		return
+	}
+	if err := s.rotationGraceCache.Set(ctx, oldRefreshTokenHash, RotationSuccessor{
+		AccessTokenID:  newAccess.ID,
+		RefreshTokenID: newRefresh.ID,
+		Sealed:         sealed,
+	}, grace); err != nil {
+		log.Printf("[Token] Failed to cache rotation successor: %v", err)
+	}
+}
+
+// replayRotationSuccessor answers a retried refresh of an already-rotated
+// token with the pair that rotation produced, provided the retry lands
+// within the grace window and that pair is still live. It returns ok=false
+// whenever the retry cannot be answered, leaving the caller to treat the
+// request as refresh token reuse.
+func (s *TokenService) replayRotationSuccessor(
+	ctx context.Context,
+	reused *models.AccessToken,
+	refreshTokenString, clientID string,
+) (*models.AccessToken, *models.AccessToken, bool) {
+	if s.config.RefreshTokenReuseGracePeriod <= 0 || s.rotationGraceCache == nil {
+		return nil, nil, false
+	}
+	if reused.ClientID != clientID {
+		return nil, nil, false
+	}
+	successor, err := s.rotationGraceCache.Get(ctx, reused.TokenHash)
+	if err != nil {
+		return nil, nil, false
+	}
+	key, err := s.rotationSuccessorKey(refreshTokenString)
+	if err != nil {
+		return nil, nil, false
+	}
+	plaintext, err := util.OpenWithKey(key, successor.Sealed)
+	if err != nil {
+		return nil, nil, false
+	}
+	rawAccess, rawRefresh, found := strings.Cut(string(plaintext), "\n")
+	if !found {
+		return nil, nil, false
+	}
+
+	access := s.liveSuccessorToken(successor.AccessTokenID, rawAccess)
+	refresh := s.liveSuccessorToken(successor.RefreshTokenID, rawRefresh)
+	if access == nil || refresh == nil {
+		return nil, nil, false
+	}
+
+	s.metrics.RecordTokenRefresh(true)
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTokenRefreshed,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  access.UserID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   access.ID,
+		Action:       "Refresh retry answered within reuse grace window",
+		Details: models.AuditDetails{
+			"client_id":            clientID,
+			"old_refresh_token_id": reused.ID,
+			"new_access_token_id":  access.ID,
+			"new_refresh_token_id": refresh.ID,
+			"grace_period":         s.config.RefreshTokenReuseGracePeriod.String(),
+		},
+		Success: true,
+	})
+	return access, refresh, true
+}
+
+// liveSuccessorToken loads a successor token and returns it with RawToken
+// restored, or nil if it has since been revoked, expired, or rotated.
+func (s *TokenService) liveSuccessorToken(id, raw string) *models.AccessToken {
+	tok, err := s.store.GetAccessTokenByID(id)
+	if err != nil || !tok.IsActive() || tok.IsExpired() {
+		return nil
+	}
+	if tok.TokenHash != util.SHA256Hex(raw) {
+		return nil
+	}
+	tok.RawToken = raw
+	return tok
+}
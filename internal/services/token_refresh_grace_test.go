@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
+	"github.com/go-authgate/authgate/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGraceTokenService builds a rotation-mode TokenService with the given
+// refresh-token reuse grace period.
+func newGraceTokenService(t *testing.T, grace time.Duration) (*TokenService, *store.Store) {
+	t.Helper()
+	cfg := configWithTokenProfiles()
+	cfg.EnableTokenRotation = true
+	cfg.RefreshTokenReuseGracePeriod = grace
+	s := setupTestStore(t)
+	return createTestTokenService(t, s, cfg), s
+}
+
+func assertTokenStatus(t *testing.T, s *store.Store, id, want string) {
+	t.Helper()
+	stored, err := s.GetAccessTokenByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, want, stored.Status)
+}
+
+func TestRefreshAccessToken_RetryWithinGraceWindow(t *testing.T) {
+	svc, s := newGraceTokenService(t, 30*time.Second)
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	access, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	// The response was lost; the client retries with the old refresh token.
+	retryAccess, retryRefresh, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, access.ID, retryAccess.ID)
+	assert.Equal(t, access.RawToken, retryAccess.RawToken)
+	assert.Equal(t, rotated.ID, retryRefresh.ID)
+	assert.Equal(t, rotated.RawToken, retryRefresh.RawToken)
+
+	// The family stays intact and the successor keeps working.
+	assertTokenStatus(t, s, access.ID, models.TokenStatusActive)
+	assertTokenStatus(t, s, rotated.ID, models.TokenStatusActive)
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), rotated.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+}
+
+func TestRefreshAccessToken_ReuseWithoutGraceRevokesFamily(t *testing.T) {
+	svc, s := newGraceTokenService(t, 0)
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	access, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrInvalidRefreshToken)
+	assertTokenStatus(t, s, access.ID, models.TokenStatusRevoked)
+	assertTokenStatus(t, s, rotated.ID, models.TokenStatusRevoked)
+}
+
+func TestRefreshAccessToken_ReuseAfterGraceWindowRevokesFamily(t *testing.T) {
+	svc, s := newGraceTokenService(t, 30*time.Second)
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	_, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	// Simulate the grace entry expiring.
+	require.NoError(t, svc.rotationGraceCache.Delete(context.Background(), refresh.TokenHash))
+
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrInvalidRefreshToken)
+	assertTokenStatus(t, s, rotated.ID, models.TokenStatusRevoked)
+}
+
+func TestRefreshAccessToken_GraceDoesNotResurrectRotatedSuccessor(t *testing.T) {
+	svc, s := newGraceTokenService(t, 30*time.Second)
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	_, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+	// The successor has itself been rotated, so the first response clearly
+	// reached the client; presenting the original token again is replay.
+	_, latest, err := svc.RefreshAccessToken(
+		context.Background(), rotated.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrInvalidRefreshToken)
+	assertTokenStatus(t, s, latest.ID, models.TokenStatusRevoked)
+}
+
+func TestRefreshAccessToken_GraceRequiresSameClient(t *testing.T) {
+	svc, s := newGraceTokenService(t, 30*time.Second)
+	client, refresh := issueRefreshToken(t, svc, s)
+	other := createTestClientWithProfile(t, svc, models.TokenProfileStandard)
+
+	_, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, other.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrInvalidRefreshToken)
+	assertTokenStatus(t, s, rotated.ID, models.TokenStatusRevoked)
+}
+
+func TestNewTokenService_RotationGraceCacheOption(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := &config.Config{RefreshTokenReuseGracePeriod: 0}
+	svc := NewTokenService(s, cfg, nil, nil, nil, nil, nil, nil)
+	assert.Nil(t, svc.rotationGraceCache, "no cache when the grace window is off")
+
+	cfg.RefreshTokenReuseGracePeriod = time.Second
+	svc = NewTokenService(s, cfg, nil, nil, nil, nil, nil, nil)
+	assert.NotNil(t, svc.rotationGraceCache, "defaults to an in-memory cache")
+}
+
+func TestRefreshAccessToken_GraceSealKeyIsNotTheStoredHash(t *testing.T) {
+	svc, s := newGraceTokenService(t, 30*time.Second)
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	_, _, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	stored, err := s.GetAccessTokenByID(refresh.ID)
+	require.NoError(t, err)
+	successor, err := svc.rotationGraceCache.Get(context.Background(), stored.TokenHash)
+	require.NoError(t, err)
+
+	key, err := svc.rotationSuccessorKey(refresh.RawToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, stored.TokenHash, hex.EncodeToString(key))
+
+	// The cache key and DB hash alone do not open the sealed pair.
+	hashKey, err := hex.DecodeString(stored.TokenHash)
+	require.NoError(t, err)
+	_, err = util.OpenWithKey(hashKey, successor.Sealed)
+	require.ErrorIs(t, err, util.ErrSealedDataInvalid)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configWithRefreshLifecycle returns a profile config where the standard
+// profile has a 1h refresh idle timeout and a 24h refresh TTL, with a 72h
+// absolute cap for sliding renewal.
+func configWithRefreshLifecycle(rotation, sliding bool) *config.Config {
+	cfg := configWithTokenProfiles()
+	cfg.JWTExpirationMax = 24 * time.Hour
+	cfg.RefreshTokenExpirationMax = 72 * time.Hour
+	cfg.TokenProfiles[models.TokenProfileStandard] = config.TokenProfile{
+		AccessTokenTTL:     time.Hour,
+		RefreshTokenTTL:    24 * time.Hour,
+		RefreshIdleTimeout: time.Hour,
+	}
+	cfg.EnableTokenRotation = rotation
+	cfg.RefreshTokenSlidingExpiration = sliding
+	return cfg
+}
+
+// issueRefreshToken runs the device flow for a fresh standard-profile client
+// and returns the issued refresh token.
+func issueRefreshToken(
+	t *testing.T,
+	svc *TokenService,
+	s *store.Store,
+) (*models.OAuthApplication, *models.AccessToken) {
+	t.Helper()
+	client := createTestClientWithProfile(t, svc, models.TokenProfileStandard)
+	dc := createAuthorizedDeviceCode(t, s, client.ClientID)
+	_, refresh, err := svc.ExchangeDeviceCode(
+		context.Background(), dc.DeviceCode, client.ClientID, nil, nil,
+	)
+	require.NoError(t, err)
+	return client, refresh
+}
+
+// backdateTokenUse makes a token look as if it was last used at the given time.
+func backdateTokenUse(t *testing.T, s *store.Store, tokenID string, at time.Time) {
+	t.Helper()
+	require.NoError(t, s.DB().Model(&models.AccessToken{}).
+		Where("id = ?", tokenID).
+		Updates(map[string]any{"created_at": at, "last_used_at": at}).Error)
+}
+
+func TestRefreshAccessToken_IdleTimeoutFixedMode(t *testing.T) {
+	s := setupTestStore(t)
+	svc := createTestTokenService(t, s, configWithRefreshLifecycle(false, false))
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	backdateTokenUse(t, s, refresh.ID, time.Now().Add(-2*time.Hour))
+
+	_, _, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrExpiredRefreshToken)
+
+	stored, err := s.GetAccessTokenByID(refresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, stored.Status)
+}
+
+func TestRefreshAccessToken_IdleTimeoutRotationRevokesFamily(t *testing.T) {
+	s := setupTestStore(t)
+	svc := createTestTokenService(t, s, configWithRefreshLifecycle(true, false))
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	access, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+
+	backdateTokenUse(t, s, rotated.ID, time.Now().Add(-2*time.Hour))
+
+	_, _, err = svc.RefreshAccessToken(
+		context.Background(), rotated.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.ErrorIs(t, err, token.ErrExpiredRefreshToken)
+
+	stored, err := s.GetAccessTokenByID(access.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusRevoked, stored.Status, "family access token revoked")
+}
+
+func TestRefreshAccessToken_RecentUseIsNotIdle(t *testing.T) {
+	s := setupTestStore(t)
+	svc := createTestTokenService(t, s, configWithRefreshLifecycle(false, false))
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	// Created long ago but used 30m ago — within the 1h idle window.
+	require.NoError(t, s.DB().Model(&models.AccessToken{}).
+		Where("id = ?", refresh.ID).
+		Update("created_at", time.Now().Add(-10*time.Hour)).Error)
+	require.NoError(t, s.UpdateTokenLastUsedAt(refresh.ID, time.Now().Add(-30*time.Minute)))
+
+	_, _, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+}
+
+func TestRefreshAccessToken_SlidingFixedMode(t *testing.T) {
+	s := setupTestStore(t)
+	svc := createTestTokenService(t, s, configWithRefreshLifecycle(false, true))
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	// The row carries the 24h sliding window; the family cap is 72h out.
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), refresh.ExpiresAt, 5*time.Second)
+	require.NotNil(t, refresh.AbsoluteExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), *refresh.AbsoluteExpiresAt, 5*time.Second)
+
+	// Pull the window in so the slide is observable.
+	require.NoError(t, s.UpdateTokenExpiresAt(refresh.ID, time.Now().Add(time.Hour)))
+
+	_, same, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, refresh.ID, same.ID)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), same.ExpiresAt, 5*time.Second)
+
+	stored, err := s.GetAccessTokenByID(refresh.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, 5*time.Second)
+}
+
+func TestRefreshAccessToken_SlidingCappedByAbsoluteExpiry(t *testing.T) {
+	s := setupTestStore(t)
+	svc := createTestTokenService(t, s, configWithRefreshLifecycle(true, true))
+	client, refresh := issueRefreshToken(t, svc, s)
+
+	// Only 3h remain before the family's absolute cap.
+	capAt := time.Now().Add(3 * time.Hour)
+	require.NoError(t, s.DB().Model(&models.AccessToken{}).
+		Where("id = ?", refresh.ID).
+		Update("absolute_expires_at", capAt).Error)
+
+	_, rotated, err := svc.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "read write", nil, nil,
+	)
+	require.NoError(t, err)
+	assert.WithinDuration(t, capAt, rotated.ExpiresAt, 5*time.Second)
+	require.NotNil(t, rotated.AbsoluteExpiresAt)
+	assert.WithinDuration(t, capAt, *rotated.AbsoluteExpiresAt, time.Second)
+}
@@ -350,7 +350,7 @@ func TestDeviceCode_LinksAuthorizationIDForCascadeRevoke(t *testing.T) {
 	ua, err := authzService.SaveUserAuthorization(
 		context.Background(),
 		dc.UserID, client.ID, client.ClientID,
-		dc.Scopes, nil,
+		dc.Scopes, "", nil,
 	)
 	require.NoError(t, err)
 	require.NotNil(t, ua)
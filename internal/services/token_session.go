@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+)
+
+// RevokeSessionTokens revokes every active or disabled token userID was
+// issued from the login session sessionID. Tokens inherit the session ID
+// across refreshes and rotations, so this covers each token family that
+// started in that session. reason is recorded in metrics and the audit event
+// (e.g. "logout", "user_request"). Returns the number of tokens revoked.
+func (s *TokenService) RevokeSessionTokens(
+	ctx context.Context,
+	userID, sessionID, reason string,
+) (int64, error) {
+	if userID == "" || sessionID == "" {
+		return 0, nil
+	}
+
+	revoked, err := s.store.UpdateTokenStatusByFilter(
+		store.TokenFilter{UserID: userID, SessionID: sessionID},
+		[]string{models.TokenStatusActive, models.TokenStatusDisabled},
+		models.TokenStatusRevoked,
+	)
+	if err != nil {
+		s.auditService.Log(ctx, core.AuditLogEntry{
+			EventType:    models.EventSessionTokensRevoked,
+			Severity:     models.SeverityError,
+			ActorUserID:  userID,
+			ResourceType: models.ResourceToken,
+			ResourceID:   sessionID,
+			Action:       "Session token revocation failed",
+			Details:      models.AuditDetails{"session_id": sessionID, "reason": reason},
+			Success:      false,
+			ErrorMessage: err.Error(),
+		})
+		return 0, err
+	}
+	if len(revoked) == 0 {
+		return 0, nil
+	}
+
+	hashes := make([]string, 0, len(revoked))
+	for _, tok := range revoked {
+		hashes = append(hashes, tok.TokenHash)
+		s.metrics.RecordTokenRevoked(tok.TokenCategory, "session_"+reason)
+	}
+	s.InvalidateTokenCacheByHashes(ctx, hashes)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventSessionTokensRevoked,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceToken,
+		ResourceID:   sessionID,
+		Action:       "Tokens issued from login session revoked",
+		Details: models.AuditDetails{
+			"session_id":    sessionID,
+			"reason":        reason,
+			"revoked_count": len(revoked),
+		},
+		Success: true,
+	})
+
+	return int64(len(revoked)), nil
+}
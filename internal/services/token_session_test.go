@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exchangeSessionBoundCode issues a token pair from an authorization code
+// created during the login session sessionID.
+func exchangeSessionBoundCode(
+	t *testing.T,
+	s *store.Store,
+	tokenService *TokenService,
+	client *models.OAuthApplication,
+	userID, sessionID string,
+) (*models.AccessToken, *models.AccessToken) {
+	t.Helper()
+	authCode := &models.AuthorizationCode{
+		UUID:          "test-uuid-" + uuid.New().String(),
+		CodeHash:      "hash-" + uuid.New().String(),
+		CodePrefix:    "sidpfx01",
+		ApplicationID: client.ID,
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   "https://app.example.com/callback",
+		Scopes:        "read write",
+		SessionID:     sessionID,
+		ExpiresAt:     time.Now().Add(10 * time.Minute),
+	}
+	require.NoError(t, s.CreateAuthorizationCode(authCode))
+
+	access, refresh, _, err := tokenService.ExchangeAuthorizationCode(
+		context.Background(), authCode, nil, nil, nil,
+	)
+	require.NoError(t, err)
+	return access, refresh
+}
+
+func sessionTestConfig() *config.Config {
+	return &config.Config{
+		JWTExpiration:          1 * time.Hour,
+		JWTSecret:              "test-secret-sid",
+		BaseURL:                "http://localhost:8080",
+		EnableRefreshTokens:    true,
+		RefreshTokenExpiration: 30 * 24 * time.Hour,
+	}
+}
+
+func TestAuthCodeFlow_StampsSessionID(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(t, s, sessionTestConfig())
+	client := createTestClient(t, s, true)
+
+	access, refresh := exchangeSessionBoundCode(
+		t, s, tokenService, client, uuid.New().String(), "login-session-1",
+	)
+
+	assert.Equal(t, "login-session-1", decodeJWTClaims(t, access.RawToken)["sid"])
+	assert.Equal(t, "login-session-1", access.SessionID)
+	assert.Equal(t, "login-session-1", refresh.SessionID)
+
+	t.Run("RefreshInheritsSessionID", func(t *testing.T) {
+		newAccess, newRefresh, err := tokenService.RefreshAccessToken(
+			context.Background(), refresh.RawToken, client.ClientID, "", nil, nil,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, "login-session-1", decodeJWTClaims(t, newAccess.RawToken)["sid"])
+		assert.Equal(t, "login-session-1", newAccess.SessionID)
+		if newRefresh != nil {
+			assert.Equal(t, "login-session-1", newRefresh.SessionID)
+		}
+	})
+}
+
+func TestAuthCodeFlow_NoSessionID_OmitsClaim(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(t, s, sessionTestConfig())
+	client := createTestClient(t, s, true)
+
+	access, _ := exchangeSessionBoundCode(t, s, tokenService, client, uuid.New().String(), "")
+
+	_, hasSID := decodeJWTClaims(t, access.RawToken)["sid"]
+	assert.False(t, hasSID, "sid must be absent when the code has no login session")
+}
+
+func TestRevokeSessionTokens(t *testing.T) {
+	s := setupTestStore(t)
+	tokenService := createTestTokenService(t, s, sessionTestConfig())
+	client := createTestClient(t, s, true)
+	userID := uuid.New().String()
+
+	access, refresh := exchangeSessionBoundCode(t, s, tokenService, client, userID, "session-a")
+	newAccess, _, err := tokenService.RefreshAccessToken(
+		context.Background(), refresh.RawToken, client.ClientID, "", nil, nil,
+	)
+	require.NoError(t, err)
+	otherAccess, otherRefresh := exchangeSessionBoundCode(
+		t, s, tokenService, client, userID, "session-b",
+	)
+
+	t.Run("OtherUserMatchesNothing", func(t *testing.T) {
+		n, err := tokenService.RevokeSessionTokens(
+			context.Background(), uuid.New().String(), "session-a", "user_request",
+		)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assertTokenStatus(t, s, access.ID, models.TokenStatusActive)
+	})
+
+	t.Run("RevokesWholeFamily", func(t *testing.T) {
+		n, err := tokenService.RevokeSessionTokens(
+			context.Background(), userID, "session-a", "logout",
+		)
+		require.NoError(t, err)
+		assert.Positive(t, n)
+
+		assertTokenStatus(t, s, access.ID, models.TokenStatusRevoked)
+		assertTokenStatus(t, s, newAccess.ID, models.TokenStatusRevoked)
+		tokens, err := s.GetTokensByUserID(userID)
+		require.NoError(t, err)
+		for _, tok := range tokens {
+			if tok.SessionID == "session-a" {
+				assert.Equal(t, models.TokenStatusRevoked, tok.Status, tok.ID)
+			}
+		}
+
+		assertTokenStatus(t, s, otherAccess.ID, models.TokenStatusActive)
+		assertTokenStatus(t, s, otherRefresh.ID, models.TokenStatusActive)
+	})
+
+	t.Run("EmptySessionIDIsNoop", func(t *testing.T) {
+		n, err := tokenService.RevokeSessionTokens(context.Background(), userID, "", "logout")
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assertTokenStatus(t, s, otherAccess.ID, models.TokenStatusActive)
+	})
+}
@@ -6,8 +6,10 @@ import (
 
 	"github.com/go-authgate/authgate/internal/models"
 	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/token"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -159,3 +161,42 @@ func TestUidClaim_OmittedWhenUserLookupFails(t *testing.T) {
 	assertPrivateClaim(t, cfg, access.RawToken, "uid", "")
 	assertPrivateClaim(t, cfg, refresh.RawToken, "uid", "")
 }
+
+// TestAuthCodeFlow_EmitsGroupsClaims covers the role, groups and attributes
+// an external auth backend stored on the user: they reach the profile-scoped
+// ID token, while the access token leaves `<prefix>_groups` to the caller.
+func TestAuthCodeFlow_EmitsGroupsClaims(t *testing.T) {
+	s := setupTestStore(t)
+	cfg := domainTestConfig("")
+	svc := createTestTokenService(t, s, cfg)
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "alice",
+		Email:        "alice@example.com",
+		IsActive:     true,
+		ExternalRole: "editor",
+		Groups:       models.StringArray{"staff", "ops"},
+		Attributes:   models.UserAttributes{"department": "R&D"},
+	}
+	require.NoError(t, s.CreateUser(user))
+	client := createTestClient(t, s, true)
+	authCode := createTestAuthCodeRecord(t, s, client, user.ID)
+	authCode.Scopes = "openid profile"
+
+	groupsKey := token.EmittedName(cfg.JWTPrivateClaimPrefix, "groups")
+	access, _, idToken, err := svc.ExchangeAuthorizationCode(
+		context.Background(), authCode, nil,
+		map[string]any{groupsKey: "caller-set"}, nil,
+	)
+	require.NoError(t, err)
+
+	// `<prefix>_groups` is not reserved, so a caller's value passes through.
+	assert.Equal(t, "caller-set", decodeJWTClaims(t, access.RawToken)[groupsKey])
+
+	require.NotEmpty(t, idToken)
+	claims := decodeJWTClaims(t, idToken)
+	assert.Equal(t, []any{"editor"}, claims["roles"])
+	assert.Equal(t, []any{"staff", "ops"}, claims["groups"])
+	assert.Equal(t, map[string]any{"department": "R&D"}, claims["attributes"])
+}
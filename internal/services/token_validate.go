@@ -13,9 +13,10 @@ import (
 )
 
 // validateAccessTokenRecord checks that a database token record is an active,
-// non-expired access token. Returns a descriptive error if any check fails.
+// non-expired access token or personal access token. Returns a descriptive
+// error if any check fails.
 func validateAccessTokenRecord(tok *models.AccessToken) error {
-	if !tok.IsAccessToken() {
+	if !tok.IsAccessToken() && !tok.IsPersonalAccessToken() {
 		return errors.New("token is not an access token")
 	}
 	if !tok.IsActive() {
@@ -27,14 +28,48 @@ func validateAccessTokenRecord(tok *models.AccessToken) error {
 	return nil
 }
 
-// ValidateToken validates a JWT token using the configured provider
+// opaqueValidationResult builds a ValidationResult from the stored record of
+// an opaque access token. The synthesized claims mirror the subset of JWT
+// claims that handlers read (scope, client_id, user_id, exp, aud) so callers
+// need not care which format the bearer presented.
+func opaqueValidationResult(tok *models.AccessToken) *token.ValidationResult {
+	claims := map[string]any{
+		"type":      models.TokenCategoryAccess,
+		"scope":     tok.Scopes,
+		"client_id": tok.ClientID,
+		"user_id":   tok.UserID,
+		"sub":       tok.UserID,
+		"exp":       tok.ExpiresAt.Unix(),
+		"iat":       tok.CreatedAt.Unix(),
+	}
+	if aud := util.AudienceClaim(tok.Resource); aud != nil {
+		claims["aud"] = aud
+	}
+	return &token.ValidationResult{
+		Valid:     true,
+		UserID:    tok.UserID,
+		ClientID:  tok.ClientID,
+		Scopes:    tok.Scopes,
+		ExpiresAt: tok.ExpiresAt,
+		Claims:    claims,
+	}
+}
+
+// ValidateToken validates an access token. JWTs are verified by the
+// configured provider; opaque reference tokens and personal access tokens
+// carry no signature and are resolved purely from the database record.
 func (s *TokenService) ValidateToken(
 	ctx context.Context,
 	tokenString string,
 ) (*token.ValidationResult, error) {
-	result, err := s.tokenProvider.ValidateToken(ctx, tokenString)
-	if err != nil {
-		return nil, err
+	opaque := token.IsOpaqueToken(tokenString) || token.IsPersonalAccessToken(tokenString)
+	var result *token.ValidationResult
+	if !opaque {
+		var err error
+		result, err = s.tokenProvider.ValidateToken(ctx, tokenString)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Check token exists in database (or cache) and validate its state (revocation, expiry, category)
@@ -49,6 +84,12 @@ func (s *TokenService) ValidateToken(
 	if err := validateAccessTokenRecord(tok); err != nil {
 		return nil, err
 	}
+	if tok.IsPersonalAccessToken() {
+		s.touchPersonalAccessToken(ctx, tok)
+	}
+	if opaque {
+		return opaqueValidationResult(tok), nil
+	}
 
 	return result, nil
 }
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/ulule/limiter/v3"
+	"github.com/ulule/limiter/v3/drivers/store/memory"
+)
+
+var ErrTwoFactorLocked = errors.New(
+	"too many wrong authentication codes; two-factor verification is locked for a while",
+)
+
+// TwoFactorGuard counts wrong second-factor codes per account. A six-digit
+// code is guessable given enough tries, and a pending login or a session is
+// cheap to start again, so the count is kept server-side and shared by the
+// login step and the account security page. Once an account reaches the
+// limit its second factor is refused, even with a correct code, until the
+// window passes.
+type TwoFactorGuard struct {
+	user         *limiter.Limiter // nil when the lockout is disabled
+	window       time.Duration
+	auditService core.AuditLogger
+}
+
+// NewTwoFactorGuard creates a guard whose counters live in store; pass a
+// Redis-backed store to share them across replicas. A nil store uses an
+// in-memory store when the lockout is enabled.
+func NewTwoFactorGuard(
+	store limiter.Store,
+	cfg *config.Config,
+	auditService core.AuditLogger,
+) *TwoFactorGuard {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	g := &TwoFactorGuard{window: cfg.TwoFactorFailureWindow, auditService: auditService}
+	if g.window <= 0 || cfg.TwoFactorMaxFailures <= 0 {
+		return g
+	}
+	if store == nil {
+		store = memory.NewStoreWithOptions(limiter.StoreOptions{
+			Prefix:          "twofactor",
+			CleanUpInterval: g.window,
+		})
+	}
+	g.user = limiter.New(store, limiter.Rate{
+		Period: g.window,
+		Limit:  int64(cfg.TwoFactorMaxFailures),
+	})
+	return g
+}
+
+// Locked reports whether the account has used up its wrong codes for the
+// current window. A nil guard never locks.
+func (g *TwoFactorGuard) Locked(ctx context.Context, userID string) bool {
+	if g == nil || g.user == nil {
+		return false
+	}
+	lc, err := g.user.Peek(ctx, "user:"+userID)
+	if err != nil {
+		log.Printf("[TwoFactorGuard] Failed to read failures for %s: %v", userID, err)
+		return false
+	}
+	return lc.Remaining == 0
+}
+
+// RecordFailure counts a wrong code against the account and audits the
+// failure that locks it. Successful codes do not reset the count, so an
+// attacker cannot interleave guesses with codes of their own.
+func (g *TwoFactorGuard) RecordFailure(ctx context.Context, user *models.User) {
+	if g == nil || g.user == nil {
+		return
+	}
+	lc, err := g.user.Get(ctx, "user:"+user.ID)
+	if err != nil {
+		log.Printf("[TwoFactorGuard] Failed to count failure for %s: %v", user.ID, err)
+		return
+	}
+	if lc.Remaining != 0 || lc.Reached {
+		return
+	}
+	g.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventSuspiciousActivity,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  user.ID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Username,
+		Action:       "Too many wrong authentication codes — two-factor verification locked",
+		Details: models.AuditDetails{
+			"failures": lc.Limit,
+			"window":   g.window.String(),
+		},
+		Success:      false,
+		ErrorMessage: "two-factor brute-force threshold reached",
+	})
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorGuard_Disabled(t *testing.T) {
+	g := NewTwoFactorGuard(nil, &config.Config{TwoFactorFailureWindow: time.Minute}, nil)
+	user := &models.User{ID: "u1"}
+	for range 20 {
+		g.RecordFailure(context.Background(), user)
+	}
+	assert.False(t, g.Locked(context.Background(), user.ID))
+
+	var nilGuard *TwoFactorGuard
+	nilGuard.RecordFailure(context.Background(), user)
+	assert.False(t, nilGuard.Locked(context.Background(), user.ID))
+}
+
+func TestTwoFactorGuard_LocksAcrossEntryPoints(t *testing.T) {
+	ctx := context.Background()
+	svc, db := newTwoFactorTestService(t)
+	svc.twoFactorGuard = NewTwoFactorGuard(nil, &config.Config{
+		TwoFactorFailureWindow: 15 * time.Minute,
+		TwoFactorMaxFailures:   3,
+	}, nil)
+	user := makeTestUser(t, db)
+	other := makeTestUser(t, db)
+	secret, _ := enrollTOTP(t, svc, user.ID)
+	otherSecret, _ := enrollTOTP(t, svc, other.ID)
+
+	// Wrong codes on the login step and the security page share one count.
+	_, err := svc.VerifyTwoFactor(ctx, user.ID, "000000")
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	_, err = svc.RegenerateRecoveryCodes(ctx, user.ID, "000000")
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	require.ErrorIs(t, svc.DisableTOTP(ctx, user.ID, "000000"), ErrInvalidTwoFactorCode)
+
+	// A correct code is refused too until the window passes.
+	_, err = svc.VerifyTwoFactor(ctx, user.ID, nextTOTPCode(t, secret))
+	require.ErrorIs(t, err, ErrTwoFactorLocked)
+	require.ErrorIs(t, svc.DisableTOTP(ctx, user.ID, nextTOTPCode(t, secret)), ErrTwoFactorLocked)
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.TOTPEnabled)
+
+	// Other accounts are unaffected.
+	_, err = svc.VerifyTwoFactor(ctx, other.ID, nextTOTPCode(t, otherSecret))
+	assert.NoError(t, err)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,7 @@ import (
 const (
 	AuthModeLocal   = "local"
 	AuthModeHTTPAPI = "http_api"
+	AuthModeLDAP    = "ldap"
 )
 
 var (
@@ -46,6 +49,9 @@ var (
 	ErrEmailRequired           = errors.New("email is required")
 	ErrEmailConflict           = errors.New("email already in use by another user")
 	ErrAccountDisabled         = errors.New("account is disabled")
+	ErrEmailNotVerified        = errors.New("email address has not been verified")
+	ErrSignupPendingApproval   = errors.New("account is awaiting admin approval")
+	ErrSignupIncomplete        = errors.New("user has not completed signup")
 	ErrUsernameRequired        = errors.New("username is required")
 	ErrCannotChangeOwnStatus   = errors.New("cannot change your own active status")
 	ErrUserAlreadyActive       = errors.New("user is already active")
@@ -58,40 +64,49 @@ var (
 
 type UserService struct {
 	store             core.Store
-	localProvider     core.AuthProvider
-	httpAPIProvider   core.AuthProvider
-	authMode          string
+	authChain         []AuthChainEntry
 	oauthAutoRegister bool
 	auditService      core.AuditLogger
 	userCache         core.Cache[models.User]
 	userCacheTTL      time.Duration
+	twoFactorGuard    *TwoFactorGuard // nil disables the per-account lockout
 }
 
+// NewUserService creates a UserService. The password authentication chain
+// follows authMode (the external backend, then local accounts) unless
+// WithAuthChain replaces it.
 func NewUserService(
 	s core.Store,
 	localProvider core.AuthProvider,
-	httpAPIProvider core.AuthProvider,
+	externalProvider core.AuthProvider,
 	authMode string,
 	oauthAutoRegister bool,
 	auditService core.AuditLogger,
 	userCache core.Cache[models.User],
 	userCacheTTL time.Duration,
+	opts ...UserServiceOption,
 ) *UserService {
 	if auditService == nil {
 		auditService = NewNoopAuditService()
 	}
-	return &UserService{
+	svc := &UserService{
 		store:             s,
-		localProvider:     localProvider,
-		httpAPIProvider:   httpAPIProvider,
-		authMode:          authMode,
+		authChain:         defaultAuthChain(localProvider, externalProvider, authMode),
 		oauthAutoRegister: oauthAutoRegister,
 		auditService:      auditService,
 		userCache:         userCache,
 		userCacheTTL:      userCacheTTL,
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
+// Authenticate verifies a username and password against the authentication
+// chain. Existing users are only offered to backends that accept their auth
+// source; unknown usernames go to backends that accept new users, and the
+// first one to succeed creates the account.
 func (s *UserService) Authenticate(
 	ctx context.Context,
 	username, password string,
@@ -99,23 +114,102 @@ func (s *UserService) Authenticate(
 	// First, try to find existing user
 	existingUser, err := s.store.GetUserByUsername(username)
 
-	// If user exists, check active status then authenticate based on auth_source
+	// If user exists, check active status before trying any backend
+	source := AuthChainSourceNew
 	if err == nil {
+		if existingUser.IsSignupIncomplete() {
+			return nil, incompleteSignupError(existingUser, password)
+		}
 		if !existingUser.IsActive {
 			return nil, ErrAccountDisabled
 		}
-		return s.authenticateExistingUser(ctx, existingUser, password)
+		source = existingUser.AuthSource
+		if source == "" {
+			source = AuthModeLocal
+		}
+	} else {
+		existingUser = nil
 	}
 
-	// User doesn't exist - try to create via external auth if configured
-	if s.authMode == AuthModeHTTPAPI {
-		return s.authenticateAndCreateExternalUser(ctx, username, password)
+	var (
+		tried   *AuthChainEntry
+		lastErr error
+	)
+	for i := range s.authChain {
+		entry := &s.authChain[i]
+		if !entry.appliesTo(username, source) {
+			continue
+		}
+		if entry.Provider == nil {
+			return nil, fmt.Errorf(
+				"%w: %s provider not configured", ErrAuthProviderFailed, entry.Source,
+			)
+		}
+		tried = entry
+
+		authResult, err := entry.Provider.Authenticate(ctx, username, password)
+		if err == nil && existingUser != nil &&
+			!entry.vouchesFor(existingUser, source, authResult) {
+			// The backend knows someone else by this username.
+			err = fmt.Errorf("%w: external ID does not match the existing account",
+				auth.ErrUserNotFound)
+		}
+		if err == nil {
+			if existingUser == nil {
+				return s.authenticateAndCreateExternalUser(ctx, entry, authResult, password)
+			}
+			return s.authenticateExistingUser(ctx, entry, existingUser, authResult, password)
+		}
+		lastErr = err
+		log.Printf("[Auth] Failed for user=%s provider=%s: %v",
+			username, externalProviderLabel(entry.Source), err)
+		if !entry.continueAfter(err) {
+			break
+		}
 	}
 
-	// No existing user and not in external auth mode
+	switch {
+	case tried == nil && existingUser != nil:
+		// Users keep the source they were created with; once no backend in
+		// the chain accepts it they can no longer sign in.
+		return nil, fmt.Errorf(
+			"%w: %s provider not configured", ErrAuthProviderFailed, existingUser.AuthSource,
+		)
+	case tried == nil:
+		// No backend creates accounts for unknown usernames
+		return nil, ErrInvalidCredentials
+	case existingUser != nil:
+		s.logAuthFailure(ctx, existingUser, externalProviderLabel(tried.Source))
+	default:
+		// Log failed authentication attempt
+		s.auditService.Log(ctx, core.AuditLogEntry{
+			EventType:     models.EventAuthenticationFailure,
+			Severity:      models.SeverityWarning,
+			ActorUsername: username,
+			Action:        "External user login attempt failed",
+			Details: models.AuditDetails{
+				"auth_source": tried.Source,
+				"reason":      "external_auth_error",
+			},
+			Success:      false,
+			ErrorMessage: lastErr.Error(),
+		})
+	}
 	return nil, ErrInvalidCredentials
 }
 
+// incompleteSignupError explains why a self-registered user cannot sign in
+// yet. The reason is only revealed to someone who knows the password.
+func incompleteSignupError(user *models.User, password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	if user.SignupStatus == models.SignupStatusPending {
+		return ErrSignupPendingApproval
+	}
+	return ErrEmailNotVerified
+}
+
 // logAuthFailure logs a failed authentication audit event.
 func (s *UserService) logAuthFailure(ctx context.Context, user *models.User, providerName string) {
 	s.auditService.Log(ctx, core.AuditLogEntry{
@@ -135,50 +229,30 @@ func (s *UserService) logAuthFailure(ctx context.Context, user *models.User, pro
 	})
 }
 
-// authenticateExistingUser authenticates based on user's auth_source
+// authenticateExistingUser completes a login that entry verified for an
+// existing user
 func (s *UserService) authenticateExistingUser(
 	ctx context.Context,
+	entry *AuthChainEntry,
 	user *models.User,
+	authResult *core.AuthResult,
 	password string,
 ) (*models.User, error) {
-	var authResult *core.AuthResult
-	var err error
-	var providerName string
-
-	// Route based on user's auth_source field
-	switch user.AuthSource {
-	case AuthModeHTTPAPI:
-		if s.httpAPIProvider == nil {
-			return nil, fmt.Errorf("%w: HTTP API provider not configured", ErrAuthProviderFailed)
-		}
-		providerName = "HTTP API"
-		authResult, err = s.httpAPIProvider.Authenticate(ctx, user.Username, password)
-
-		// Sync user data on successful external auth
-		if err == nil {
-			updatedUser, syncErr := s.syncExternalUser(authResult, AuthModeHTTPAPI)
-			if syncErr != nil {
-				log.Printf("[Auth] Sync failed for user=%s: %v", user.Username, syncErr)
-			} else {
-				user = updatedUser
-			}
-		}
-
-	case AuthModeLocal:
-		fallthrough
-	default:
-		if s.localProvider == nil {
-			return nil, fmt.Errorf("%w: local provider not configured", ErrAuthProviderFailed)
+	providerName := externalProviderLabel(entry.Source)
+
+	// Sync user data on successful external auth. A backend may also vouch
+	// for users of another source (e.g. local accounts awaiting migration);
+	// their profile is not the backend's to overwrite.
+	if entry.Source != AuthModeLocal && entry.Source == user.AuthSource {
+		updatedUser, syncErr := s.syncExternalUser(authResult, user.AuthSource)
+		if syncErr != nil {
+			log.Printf("[Auth] Sync failed for user=%s: %v", user.Username, syncErr)
+		} else {
+			user = updatedUser
 		}
-		providerName = AuthModeLocal
-		_, err = s.localProvider.Authenticate(ctx, user.Username, password)
 	}
-
-	// Handle authentication failure
-	if err != nil {
-		log.Printf("[Auth] Failed for user=%s provider=%s: %v", user.Username, providerName, err)
-		s.logAuthFailure(ctx, user, providerName)
-		return nil, ErrInvalidCredentials
+	if entry.MigratePassword {
+		s.migratePassword(ctx, user, password, entry.Source)
 	}
 
 	// Log successful authentication
@@ -200,36 +274,18 @@ func (s *UserService) authenticateExistingUser(
 	return user, nil
 }
 
-// authenticateAndCreateExternalUser tries external auth and creates new user
+// authenticateAndCreateExternalUser creates the local account of a new user
+// that entry verified
 func (s *UserService) authenticateAndCreateExternalUser(
 	ctx context.Context,
-	username, password string,
+	entry *AuthChainEntry,
+	authResult *core.AuthResult,
+	password string,
 ) (*models.User, error) {
-	if s.httpAPIProvider == nil {
-		return nil, fmt.Errorf("%w: HTTP API provider not configured", ErrAuthProviderFailed)
-	}
-
-	// Try external authentication
-	authResult, err := s.httpAPIProvider.Authenticate(ctx, username, password)
-	if err != nil {
-		// Log failed authentication attempt
-		s.auditService.Log(ctx, core.AuditLogEntry{
-			EventType:     models.EventAuthenticationFailure,
-			Severity:      models.SeverityWarning,
-			ActorUsername: username,
-			Action:        "External user login attempt failed",
-			Details: models.AuditDetails{
-				"auth_source": AuthModeHTTPAPI,
-				"reason":      "external_auth_error",
-			},
-			Success:      false,
-			ErrorMessage: err.Error(),
-		})
-		return nil, ErrInvalidCredentials
-	}
+	username := authResult.Username
 
 	// Create new user in local database (or fetch existing one matched by external_id)
-	user, err := s.syncExternalUser(authResult, AuthModeHTTPAPI)
+	user, err := s.syncExternalUser(authResult, entry.Source)
 	if err != nil {
 		log.Printf("[Auth] Failed to create user=%s: %v", username, err)
 
@@ -239,7 +295,7 @@ func (s *UserService) authenticateAndCreateExternalUser(
 			Severity:      models.SeverityError,
 			ActorUsername: username,
 			Action:        "Failed to create external user",
-			Details:       models.AuditDetails{"auth_source": AuthModeHTTPAPI},
+			Details:       models.AuditDetails{"auth_source": entry.Source},
 			Success:       false,
 			ErrorMessage:  err.Error(),
 		})
@@ -255,6 +311,9 @@ func (s *UserService) authenticateAndCreateExternalUser(
 	}
 
 	log.Printf("[Auth] New external user created: %s", username)
+	if entry.MigratePassword {
+		s.migratePassword(ctx, user, password, entry.Source)
+	}
 
 	// Log successful authentication and user creation
 	s.auditService.Log(ctx, core.AuditLogEntry{
@@ -295,10 +354,59 @@ func (s *UserService) syncExternalUser(
 		return nil, fmt.Errorf("failed to upsert external user: %w", err)
 	}
 
+	changed := false
+	// Providers that manage roles (e.g. LDAP group mapping) are authoritative
+	// and override changes made in the admin UI.
+	if result.Role != "" && result.Role != user.Role {
+		log.Printf("[Auth] Role of user=%s synced from %s: %s -> %s",
+			user.Username, authSource, user.Role, result.Role)
+		user.Role = result.Role
+		changed = true
+	}
+	if user.ExternalRole != result.ExternalRole {
+		user.ExternalRole = result.ExternalRole
+		changed = true
+	}
+	if !slices.Equal(user.Groups, result.Groups) {
+		user.Groups = result.Groups
+		changed = true
+	}
+	if !equalAttributes(user.Attributes, result.Attributes) {
+		user.Attributes = result.Attributes
+		changed = true
+	}
+	if changed {
+		if err := s.store.UpdateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to sync external user: %w", err)
+		}
+	}
+
 	s.InvalidateUserCache(user.ID)
 	return user, nil
 }
 
+// equalAttributes reports whether two attribute sets hold the same values,
+// treating nil and empty alike.
+func equalAttributes(a models.UserAttributes, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(map[string]any(a), b)
+}
+
+// externalProviderLabel names an external auth source in logs and audit
+// details.
+func externalProviderLabel(authSource string) string {
+	switch authSource {
+	case AuthModeHTTPAPI:
+		return "HTTP API"
+	case AuthModeLDAP:
+		return "LDAP"
+	default:
+		return authSource
+	}
+}
+
 func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	cacheKey := "user:" + id
 	fetchFn := func(ctx context.Context, key string) (models.User, error) {
@@ -312,8 +420,10 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User,
 		// Strip credential material before caching: PasswordHash must never be
 		// written to a shared cache backend (Redis) where it could be read if
 		// the cache is compromised. GetUserByID callers only need identity/role
-		// data, so this field is safe to omit from the cached copy.
+		// data, so this field is safe to omit from the cached copy. The TOTP
+		// secret is stripped for the same reason.
 		u.PasswordHash = ""
+		u.TOTPSecret = ""
 		return *u, nil
 	}
 
@@ -345,6 +455,9 @@ func (s *UserService) AuthenticateWithOAuth(
 	oauthUserInfo.Email = strings.TrimSpace(oauthUserInfo.Email)
 	oauthUserInfo.Username = strings.TrimSpace(oauthUserInfo.Username)
 	oauthUserInfo.FullName = strings.TrimSpace(oauthUserInfo.FullName)
+	if oauthUserInfo.ConnectionType == "" {
+		oauthUserInfo.ConnectionType = models.ConnectionTypeOAuth
+	}
 
 	// Validate required fields
 	if oauthUserInfo.Email == "" {
@@ -366,6 +479,16 @@ func (s *UserService) AuthenticateWithOAuth(
 	// between duplicate whitespace variants blocks auto-link instead of
 	// letting the provider bind to a non-deterministic row.
 	user, err := s.store.FindUserByNormalizedEmail(oauthUserInfo.Email)
+	if err == nil && user.SignupStatus == models.SignupStatusUnverified &&
+		oauthUserInfo.EmailVerified {
+		// An unverified signup only claims the address; the provider has
+		// proven who owns it, so the claim must not lock the owner out.
+		if delErr := s.store.DeleteUnverifiedSignup(user.ID); delErr != nil {
+			return nil, fmt.Errorf("failed to remove unverified signup: %w", delErr)
+		}
+		s.InvalidateUserCache(user.ID)
+		err = gorm.ErrRecordNotFound
+	}
 	if err == nil {
 		if !user.IsActive {
 			return nil, ErrAccountDisabled
@@ -433,6 +556,7 @@ func (s *UserService) updateOAuthConnectionAndGetUser(
 	connection.ProviderUsername = oauthUserInfo.Username
 	connection.ProviderEmail = oauthUserInfo.Email
 	connection.AvatarURL = oauthUserInfo.AvatarURL
+	connection.ConnectionType = oauthUserInfo.ConnectionType
 	connection.LastUsedAt = time.Now()
 
 	if err := s.store.UpdateOAuthConnection(connection); err != nil {
@@ -604,6 +728,7 @@ func (s *UserService) linkOAuthToExistingUser(
 		UserID:           user.ID,
 		Provider:         provider,
 		ProviderUserID:   oauthUserInfo.ProviderUserID,
+		ConnectionType:   oauthUserInfo.ConnectionType,
 		ProviderUsername: oauthUserInfo.Username,
 		ProviderEmail:    oauthUserInfo.Email,
 		AvatarURL:        oauthUserInfo.AvatarURL,
@@ -697,6 +822,7 @@ func (s *UserService) createUserWithOAuth(
 		UserID:           user.ID,
 		Provider:         provider,
 		ProviderUserID:   oauthUserInfo.ProviderUserID,
+		ConnectionType:   oauthUserInfo.ConnectionType,
 		ProviderUsername: rawUsername,
 		ProviderEmail:    email,
 		AvatarURL:        oauthUserInfo.AvatarURL,
@@ -1070,6 +1196,12 @@ func (s *UserService) DeleteUserAdmin(
 		if err := tx.RevokeAllUserAuthorizationsByUserID(userID); err != nil {
 			return fmt.Errorf("revoke authorizations: %w", err)
 		}
+		if err := tx.DeleteRecoveryCodesByUserID(userID); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		if err := tx.DeleteWebAuthnCredentialsByUserID(userID); err != nil {
+			return fmt.Errorf("delete passkeys: %w", err)
+		}
 		if err := tx.DeleteUser(userID); err != nil {
 			return fmt.Errorf("delete user: %w", err)
 		}
@@ -1106,6 +1238,18 @@ func (s *UserService) CountUsersByRole(role string) (int64, error) {
 	return s.store.CountUsersByRole(role)
 }
 
+// ListPendingSignups returns self-registered users awaiting admin approval,
+// oldest first.
+func (s *UserService) ListPendingSignups() ([]models.User, error) {
+	return s.store.ListUsersBySignupStatus(models.SignupStatusPending)
+}
+
+// CountPendingSignups returns the number of self-registered users awaiting
+// admin approval.
+func (s *UserService) CountPendingSignups() (int64, error) {
+	return s.store.CountUsersBySignupStatus(models.SignupStatusPending)
+}
+
 // ── Admin Create User ─────────────────────────────────────────────────
 
 // CreateUserRequest carries the fields for admin user creation.
@@ -1284,6 +1428,9 @@ func (s *UserService) ValidateSetUserActiveStatus(
 	if !isActive && !user.IsActive {
 		return ErrUserAlreadyDisabled
 	}
+	if isActive && user.IsSignupIncomplete() {
+		return ErrSignupIncomplete
+	}
 
 	if !isActive && user.Role == models.UserRoleAdmin {
 		adminCount, countErr := s.store.CountUsersByRole(models.UserRoleAdmin)
@@ -1323,6 +1470,9 @@ func (s *UserService) SetUserActiveStatus(
 	if !isActive && !user.IsActive {
 		return ErrUserAlreadyDisabled
 	}
+	if isActive && user.IsSignupIncomplete() {
+		return ErrSignupIncomplete
+	}
 
 	if !isActive && user.Role == models.UserRoleAdmin {
 		adminCount, countErr := s.store.CountUsersByRole(models.UserRoleAdmin)
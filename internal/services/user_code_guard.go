@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/ulule/limiter/v3"
+	"github.com/ulule/limiter/v3/drivers/store/memory"
+)
+
+var (
+	ErrUserCodeSessionLocked = errors.New("too many wrong user codes in this session")
+	ErrUserCodeUserLocked    = errors.New("too many wrong user codes for this account")
+)
+
+// UserCodeAttempt identifies who submitted a user code, for brute-force
+// accounting. SessionID (the login's sid) and UserID are empty for visitors
+// who are not signed in.
+type UserCodeAttempt struct {
+	UserCode  string
+	SessionID string
+	UserID    string
+	Username  string
+}
+
+// UserCodeGuard counts wrong user codes submitted at /device and
+// /device/verify. User codes are short enough to guess, and per-IP rate
+// limits do not stop an attacker spreading guesses across addresses, so
+// failures are also counted per login session, per account and across the
+// whole deployment. The session and account thresholds lock the guesser out;
+// the deployment-wide one only raises an alert, since locking everyone out
+// would hand an attacker a denial of service. Every threshold trip is
+// audited as suspicious activity.
+type UserCodeGuard struct {
+	session      *limiter.Limiter // nil when the threshold is disabled
+	user         *limiter.Limiter
+	global       *limiter.Limiter
+	window       time.Duration
+	auditService core.AuditLogger
+}
+
+// NewUserCodeGuard creates a guard whose counters live in store; pass a
+// Redis-backed store to share them across replicas. A nil store uses an
+// in-memory store when any threshold is enabled.
+func NewUserCodeGuard(
+	store limiter.Store,
+	cfg *config.Config,
+	auditService core.AuditLogger,
+) *UserCodeGuard {
+	if auditService == nil {
+		auditService = NewNoopAuditService()
+	}
+	g := &UserCodeGuard{window: cfg.DeviceVerifyFailureWindow, auditService: auditService}
+	if g.window <= 0 {
+		return g
+	}
+	newLimiter := func(limit int) *limiter.Limiter {
+		if limit <= 0 {
+			return nil
+		}
+		if store == nil {
+			store = memory.NewStoreWithOptions(limiter.StoreOptions{
+				Prefix:          "usercode",
+				CleanUpInterval: g.window,
+			})
+		}
+		return limiter.New(store, limiter.Rate{Period: g.window, Limit: int64(limit)})
+	}
+	g.session = newLimiter(cfg.DeviceVerifyMaxSessionFailures)
+	g.user = newLimiter(cfg.DeviceVerifyMaxUserFailures)
+	g.global = newLimiter(cfg.DeviceVerifyMaxGlobalFailures)
+	return g
+}
+
+// Check refuses a lookup from an account or session that has already used
+// up its wrong codes for the current window.
+func (g *UserCodeGuard) Check(ctx context.Context, a UserCodeAttempt) error {
+	if a.UserID != "" && g.exhausted(ctx, g.user, "user:"+a.UserID) {
+		return ErrUserCodeUserLocked
+	}
+	if a.SessionID != "" && g.exhausted(ctx, g.session, "session:"+a.SessionID) {
+		return ErrUserCodeSessionLocked
+	}
+	return nil
+}
+
+// RecordFailure counts a wrong user code and returns the lockout it
+// triggered, if any. Successful lookups do not reset the counters: an
+// attacker could otherwise interleave codes from their own devices.
+func (g *UserCodeGuard) RecordFailure(ctx context.Context, a UserCodeAttempt) error {
+	if count, tripped, _ := g.hit(ctx, g.global, "global"); tripped {
+		g.audit(ctx, a, models.SeverityCritical, "global", count,
+			"Device user code enumeration suspected across all users")
+	}
+
+	var lockErr error
+	if a.UserID != "" {
+		count, tripped, exhausted := g.hit(ctx, g.user, "user:"+a.UserID)
+		if tripped {
+			g.audit(ctx, a, models.SeverityWarning, "user", count,
+				"Too many wrong device user codes — account locked out of device verification")
+		}
+		if exhausted {
+			lockErr = ErrUserCodeUserLocked
+		}
+	}
+	if a.SessionID != "" {
+		count, tripped, exhausted := g.hit(ctx, g.session, "session:"+a.SessionID)
+		if tripped {
+			g.audit(ctx, a, models.SeverityWarning, "session", count,
+				"Too many wrong device user codes — session must sign in again")
+		}
+		if exhausted && lockErr == nil {
+			lockErr = ErrUserCodeSessionLocked
+		}
+	}
+	return lockErr
+}
+
+// hit increments the counter at key. It returns the count after the
+// increment, whether this increment is the one that reached the limit (so
+// each trip is audited once per window), and whether the limit has been
+// reached. A disabled limiter or a store error (failing open) reports zero.
+func (g *UserCodeGuard) hit(
+	ctx context.Context,
+	l *limiter.Limiter,
+	key string,
+) (count int64, tripped, exhausted bool) {
+	if l == nil {
+		return 0, false, false
+	}
+	lc, err := l.Get(ctx, key)
+	if err != nil {
+		log.Printf("[UserCodeGuard] Failed to count failure for %s: %v", key, err)
+		return 0, false, false
+	}
+	count = lc.Limit - lc.Remaining
+	if lc.Reached {
+		count = lc.Limit + 1
+	}
+	return count, lc.Remaining == 0 && !lc.Reached, lc.Remaining == 0
+}
+
+// exhausted reports whether the counter at key has reached its limit.
+func (g *UserCodeGuard) exhausted(ctx context.Context, l *limiter.Limiter, key string) bool {
+	if l == nil {
+		return false
+	}
+	lc, err := l.Peek(ctx, key)
+	if err != nil {
+		log.Printf("[UserCodeGuard] Failed to read failures for %s: %v", key, err)
+		return false
+	}
+	return lc.Remaining == 0
+}
+
+// audit records a threshold trip as suspicious activity, with the details
+// needed to spot enumeration: which counter tripped, how many wrong codes
+// it saw in how long, and the code that tripped it.
+func (g *UserCodeGuard) audit(
+	ctx context.Context,
+	a UserCodeAttempt,
+	severity models.EventSeverity,
+	scope string,
+	count int64,
+	action string,
+) {
+	details := models.AuditDetails{
+		"scope":     scope,
+		"failures":  count,
+		"window":    g.window.String(),
+		"user_code": NormalizeUserCode(a.UserCode),
+	}
+	if a.SessionID != "" {
+		details["session_id"] = a.SessionID
+	}
+	g.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:     models.EventSuspiciousActivity,
+		Severity:      severity,
+		ActorUserID:   a.UserID,
+		ActorUsername: a.Username,
+		ResourceType:  models.ResourceDeviceCode,
+		Action:        action,
+		Details:       details,
+		Success:       false,
+		ErrorMessage:  "user code brute-force threshold reached",
+	})
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/config"
+	"github.com/go-authgate/authgate/internal/metrics"
+	"github.com/go-authgate/authgate/internal/models"
+	storetypes "github.com/go-authgate/authgate/internal/store/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func guardTestConfig() *config.Config {
+	return &config.Config{
+		DeviceCodeExpiration:           30 * time.Minute,
+		PollingInterval:                5,
+		DeviceVerifyFailureWindow:      15 * time.Minute,
+		DeviceVerifyMaxSessionFailures: 3,
+		DeviceVerifyMaxUserFailures:    5,
+		DeviceVerifyMaxGlobalFailures:  4,
+	}
+}
+
+func TestUserCodeGuard_DisabledByDefault(t *testing.T) {
+	g := NewUserCodeGuard(nil, &config.Config{}, nil)
+	a := UserCodeAttempt{UserCode: "WRONG-CODE", SessionID: "sid", UserID: "u1"}
+	for range 20 {
+		require.NoError(t, g.RecordFailure(context.Background(), a))
+	}
+	assert.NoError(t, g.Check(context.Background(), a))
+}
+
+func TestUserCodeGuard_SessionThenUserLockout(t *testing.T) {
+	ctx := context.Background()
+	g := NewUserCodeGuard(nil, guardTestConfig(), nil)
+	first := UserCodeAttempt{UserCode: "AAAA-AAAA", SessionID: "sid-1", UserID: "u1"}
+
+	require.NoError(t, g.RecordFailure(ctx, first))
+	require.NoError(t, g.RecordFailure(ctx, first))
+	require.ErrorIs(t, g.RecordFailure(ctx, first), ErrUserCodeSessionLocked)
+	require.ErrorIs(t, g.Check(ctx, first), ErrUserCodeSessionLocked)
+
+	// Signing in again gives a fresh session, but the account keeps counting.
+	second := first
+	second.SessionID = "sid-2"
+	require.NoError(t, g.Check(ctx, second))
+	require.NoError(t, g.RecordFailure(ctx, second))
+	require.ErrorIs(t, g.RecordFailure(ctx, second), ErrUserCodeUserLocked)
+	require.ErrorIs(t, g.Check(ctx, second), ErrUserCodeUserLocked)
+
+	// Other accounts are unaffected.
+	other := UserCodeAttempt{UserCode: "BBBB-BBBB", SessionID: "sid-3", UserID: "u2"}
+	assert.NoError(t, g.Check(ctx, other))
+}
+
+func TestUserCodeGuard_AuditsEachTripOnce(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestStore(t)
+	auditService := NewAuditService(s, 100)
+	g := NewUserCodeGuard(nil, guardTestConfig(), auditService)
+
+	// Anonymous lookups only feed the global counter.
+	for range 6 {
+		require.NoError(t, g.RecordFailure(ctx, UserCodeAttempt{UserCode: "cccc-cccc"}))
+	}
+	require.NoError(t, auditService.Shutdown(ctx))
+
+	logs, _, err := s.GetAuditLogsPaginated(
+		storetypes.PaginationParams{Page: 1, PageSize: 10},
+		storetypes.AuditLogFilters{EventType: models.EventSuspiciousActivity},
+	)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, models.SeverityCritical, logs[0].Severity)
+	assert.Equal(t, "global", logs[0].Details["scope"])
+	assert.Equal(t, "CCCCCCCC", logs[0].Details["user_code"])
+	assert.EqualValues(t, 4, logs[0].Details["failures"])
+}
+
+func TestLookupUserCode_CountsOnlyUnknownCodes(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestStore(t)
+	deviceService := NewDeviceService(
+		s,
+		guardTestConfig(),
+		NewNoopAuditService(),
+		metrics.NewNoopMetrics(),
+		NewClientService(s, NewNoopAuditService(), nil, 0, nil, 0),
+	)
+	client := createTestClient(t, s, true)
+	dc, err := deviceService.GenerateDeviceCode(ctx, client.ClientID, "read", nil)
+	require.NoError(t, err)
+
+	// Looking up a real code repeatedly never locks the session.
+	valid := UserCodeAttempt{UserCode: dc.UserCode, SessionID: "sid", UserID: "u1"}
+	for range 5 {
+		_, _, err := deviceService.LookupUserCode(ctx, valid)
+		require.NoError(t, err)
+	}
+
+	wrong := valid
+	wrong.UserCode = "ZZZZ-ZZZZ"
+	_, _, err = deviceService.LookupUserCode(ctx, wrong)
+	require.ErrorIs(t, err, ErrUserCodeNotFound)
+	_, _, err = deviceService.LookupUserCode(ctx, wrong)
+	require.ErrorIs(t, err, ErrUserCodeNotFound)
+	_, _, err = deviceService.LookupUserCode(ctx, wrong)
+	require.ErrorIs(t, err, ErrUserCodeSessionLocked)
+
+	// Once locked, even the real code is refused.
+	_, _, err = deviceService.LookupUserCode(ctx, valid)
+	require.ErrorIs(t, err, ErrUserCodeSessionLocked)
+}
@@ -49,6 +49,15 @@ func makeTestUser(t *testing.T, db *store.Store) *models.User {
 	return u
 }
 
+// makeTestAdmin creates a local user with the admin role.
+func makeTestAdmin(t *testing.T, db *store.Store) *models.User {
+	t.Helper()
+	u := makeTestUser(t, db)
+	u.Role = models.UserRoleAdmin
+	require.NoError(t, db.UpdateUser(u))
+	return u
+}
+
 // callFetchFn is a DoAndReturn helper that invokes the cache fetch function,
 // simulating a cache miss where the real DB fetch is executed.
 func callFetchFn[T any](
@@ -286,6 +295,51 @@ func TestAuthenticate_HTTPAPIExistingUser(t *testing.T) {
 	assert.Equal(t, u.Username, result.Username)
 }
 
+func TestAuthenticate_HTTPAPISyncsGroupsAndAttributes(t *testing.T) {
+	db := setupTestStore(t)
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[models.User](ctrl)
+	mockHTTPAPIProvider := mocks.NewMockAuthProvider(ctrl)
+
+	u := makeTestHTTPAPIUser(t, db)
+	mockCache.EXPECT().Delete(gomock.Any(), "user:"+u.ID).Return(nil).Times(2)
+	svc := newUserServiceForAuth(db, nil, mockHTTPAPIProvider, AuthModeHTTPAPI, mockCache)
+
+	mockHTTPAPIProvider.EXPECT().
+		Authenticate(gomock.Any(), u.Username, "pass").
+		Return(&core.AuthResult{
+			Username:     u.Username,
+			ExternalID:   u.ExternalID,
+			Role:         models.UserRoleAdmin,
+			ExternalRole: "editor",
+			Groups:       []string{"staff", "ops"},
+			Attributes:   map[string]any{"department": "R&D"},
+		}, nil)
+	_, err := svc.Authenticate(context.Background(), u.Username, "pass")
+	require.NoError(t, err)
+
+	stored, err := db.GetUserByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleAdmin, stored.Role)
+	assert.Equal(t, "editor", stored.ExternalRole)
+	assert.Equal(t, models.StringArray{"staff", "ops"}, stored.Groups)
+	assert.Equal(t, models.UserAttributes{"department": "R&D"}, stored.Attributes)
+
+	// The backend's answer is authoritative: groups it no longer reports go.
+	mockHTTPAPIProvider.EXPECT().
+		Authenticate(gomock.Any(), u.Username, "pass").
+		Return(&core.AuthResult{Username: u.Username, ExternalID: u.ExternalID}, nil)
+	_, err = svc.Authenticate(context.Background(), u.Username, "pass")
+	require.NoError(t, err)
+
+	stored, err = db.GetUserByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleAdmin, stored.Role)
+	assert.Empty(t, stored.ExternalRole)
+	assert.Empty(t, stored.Groups)
+	assert.Empty(t, stored.Attributes)
+}
+
 func TestAuthenticate_HTTPAPIProviderNil(t *testing.T) {
 	db := setupTestStore(t)
 	ctrl := gomock.NewController(t)
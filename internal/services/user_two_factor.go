@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/core"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/totp"
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes are issued at a time.
+	recoveryCodeCount = 10
+	// recoveryCodeBytes is the entropy of one recovery code (80 bits, 16
+	// base32 characters).
+	recoveryCodeBytes = 10
+	// totpSkew is how many 30-second steps of clock drift are accepted.
+	totpSkew = 1
+)
+
+var (
+	ErrTwoFactorNotAllowed     = errors.New("two-factor authentication is only available for local users")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotStarted     = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+)
+
+// TwoFactorMethod identifies which second factor a user presented.
+type TwoFactorMethod string
+
+const (
+	TwoFactorMethodTOTP         TwoFactorMethod = "totp"
+	TwoFactorMethodRecoveryCode TwoFactorMethod = "recovery_code"
+)
+
+// WithTwoFactorGuard sets the guard that locks an account's second factor
+// after too many wrong codes.
+func WithTwoFactorGuard(g *TwoFactorGuard) UserServiceOption {
+	return func(s *UserService) {
+		s.twoFactorGuard = g
+	}
+}
+
+// BeginTOTPEnrollment returns the pending TOTP secret for the user, creating
+// one if enrollment has not started yet. The secret only takes effect once
+// EnableTOTP confirms that the user's authenticator produces valid codes.
+func (s *UserService) BeginTOTPEnrollment(userID string) (string, error) {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user.IsExternal() {
+		return "", ErrTwoFactorNotAllowed
+	}
+	if user.TOTPEnabled {
+		return "", ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret != "" {
+		return user.TOTPSecret, nil
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	if err := s.store.SetUserTOTPSecret(userID, secret); err != nil {
+		return "", fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// EnableTOTP confirms enrollment with a code from the user's authenticator
+// and turns on two-factor authentication. It returns the plaintext recovery
+// codes, which are shown to the user once and only stored hashed.
+func (s *UserService) EnableTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotStarted
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.EnableUserTOTP(userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	s.InvalidateUserCache(userID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTwoFactorEnabled,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: user.Username,
+		Action:       "Two-factor authentication enabled",
+		Success:      true,
+	})
+
+	return codes, nil
+}
+
+// VerifyTwoFactor checks a TOTP code or recovery code for a user with 2FA
+// enabled. A TOTP code is accepted at most once, and a recovery code is
+// consumed on use.
+func (s *UserService) VerifyTwoFactor(
+	ctx context.Context,
+	userID, code string,
+) (TwoFactorMethod, error) {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.TOTPEnabled {
+		return "", ErrTwoFactorNotEnabled
+	}
+
+	var method TwoFactorMethod
+	err = s.guardSecondFactor(ctx, user, func() (err error) {
+		method, err = s.checkSecondFactor(user, code)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.auditService.Log(ctx, core.AuditLogEntry{
+				EventType:    models.EventTwoFactorFailure,
+				Severity:     models.SeverityWarning,
+				ActorUserID:  userID,
+				ResourceType: models.ResourceUser,
+				ResourceID:   userID,
+				ResourceName: user.Username,
+				Action:       "Two-factor verification failed",
+				Success:      false,
+			})
+		}
+		return "", err
+	}
+
+	if method == TwoFactorMethodRecoveryCode {
+		remaining, _ := s.store.CountUnusedRecoveryCodes(userID)
+		s.auditService.Log(ctx, core.AuditLogEntry{
+			EventType:    models.EventRecoveryCodeUsed,
+			Severity:     models.SeverityWarning,
+			ActorUserID:  userID,
+			ResourceType: models.ResourceUser,
+			ResourceID:   userID,
+			ResourceName: user.Username,
+			Action:       "Recovery code used to sign in",
+			Details:      models.AuditDetails{"remaining_codes": remaining},
+			Success:      true,
+		})
+	}
+	return method, nil
+}
+
+// DisableTOTP turns off two-factor authentication for the user after checking
+// a current TOTP code or recovery code.
+func (s *UserService) DisableTOTP(ctx context.Context, userID, code string) error {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.guardSecondFactor(ctx, user, func() error {
+		_, err := s.checkSecondFactor(user, code)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := s.store.DisableUserTOTP(userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	s.InvalidateUserCache(userID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTwoFactorDisabled,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: user.Username,
+		Action:       "Two-factor authentication disabled",
+		Success:      true,
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// current TOTP code, returning the new plaintext codes.
+func (s *UserService) RegenerateRecoveryCodes(
+	ctx context.Context,
+	userID, code string,
+) ([]string, error) {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.guardSecondFactor(ctx, user, func() error {
+		return s.checkTOTPCode(user, code)
+	}); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventRecoveryCodesRegenerated,
+		Severity:     models.SeverityInfo,
+		ActorUserID:  userID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: user.Username,
+		Action:       "Recovery codes regenerated",
+		Success:      true,
+	})
+	return codes, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has.
+func (s *UserService) CountRecoveryCodes(userID string) (int64, error) {
+	return s.store.CountUnusedRecoveryCodes(userID)
+}
+
+// AdminResetTwoFactor removes a user's TOTP enrollment, recovery codes and
+// passkeys so they can sign in with their password alone and enroll again.
+func (s *UserService) AdminResetTwoFactor(
+	ctx context.Context,
+	userID, actorUserID string,
+) error {
+	user, err := s.AdminGetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled && user.TOTPSecret == "" && !user.WebAuthnEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := s.store.RunInTransaction(func(tx core.Store) error {
+		if err := tx.DisableUserTOTP(userID); err != nil {
+			return err
+		}
+		return tx.DeleteWebAuthnCredentialsByUserID(userID)
+	}); err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+
+	s.InvalidateUserCache(userID)
+
+	s.auditService.Log(ctx, core.AuditLogEntry{
+		EventType:    models.EventTwoFactorReset,
+		Severity:     models.SeverityWarning,
+		ActorUserID:  actorUserID,
+		ResourceType: models.ResourceUser,
+		ResourceID:   userID,
+		ResourceName: user.Username,
+		Action:       "Two-factor authentication reset by admin",
+		Success:      true,
+	})
+	return nil
+}
+
+// guardSecondFactor runs check unless the user's second factor is locked
+// out, and counts a wrong code against the account. Every check of an
+// enabled second factor goes through here, so guesses made on the login
+// step and on the security page share one limit.
+func (s *UserService) guardSecondFactor(
+	ctx context.Context,
+	user *models.User,
+	check func() error,
+) error {
+	if s.twoFactorGuard.Locked(ctx, user.ID) {
+		return ErrTwoFactorLocked
+	}
+	err := check()
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		s.twoFactorGuard.RecordFailure(ctx, user)
+	}
+	return err
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code.
+func (s *UserService) checkSecondFactor(user *models.User, code string) (TwoFactorMethod, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		if err := s.checkTOTPCode(user, code); err != nil {
+			return "", err
+		}
+		return TwoFactorMethodTOTP, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return "", ErrInvalidTwoFactorCode
+	}
+	ok, err := s.store.ConsumeRecoveryCode(user.ID, util.SHA256Hex(normalized), time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to check recovery code: %w", err)
+	}
+	if !ok {
+		return "", ErrInvalidTwoFactorCode
+	}
+	return TwoFactorMethodRecoveryCode, nil
+}
+
+// checkTOTPCode validates a TOTP code and records its time step so the same
+// code cannot be used twice.
+func (s *UserService) checkTOTPCode(user *models.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	consumed, err := s.store.ConsumeTOTPStep(user.ID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns recoveryCodeCount codes formatted as
+// xxxx-xxxx-xxxx-xxxx along with their SHA-256 hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := util.CryptoRandomBytes(recoveryCodeBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		normalized := strings.ToLower(enc.EncodeToString(raw))
+		codes[i] = normalized[0:4] + "-" + normalized[4:8] + "-" +
+			normalized[8:12] + "-" + normalized[12:16]
+		hashes[i] = util.SHA256Hex(normalized)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode strips separators and case so users can type a code
+// with or without dashes.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) != 16 {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/cache"
+	"github.com/go-authgate/authgate/internal/models"
+	"github.com/go-authgate/authgate/internal/store"
+	"github.com/go-authgate/authgate/internal/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enrollTOTP walks a user through enrollment and returns the secret and the
+// issued recovery codes.
+func enrollTOTP(t *testing.T, svc *UserService, userID string) (string, []string) {
+	t.Helper()
+	secret, err := svc.BeginTOTPEnrollment(userID)
+	require.NoError(t, err)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	codes, err := svc.EnableTOTP(context.Background(), userID, code)
+	require.NoError(t, err)
+	return secret, codes
+}
+
+// nextTOTPCode returns the code for the following time step, which is still
+// inside the accepted skew but has not been consumed by enrollment.
+func nextTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, time.Now().Add(totp.Period))
+	require.NoError(t, err)
+	return code
+}
+
+func newTwoFactorTestService(t *testing.T) (*UserService, *store.Store) {
+	t.Helper()
+	db := setupTestStore(t)
+	return newUserServiceWithStore(db, cache.NewNoopCache[models.User]()), db
+}
+
+func TestBeginTOTPEnrollment_ReusesPendingSecret(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+
+	first, err := svc.BeginTOTPEnrollment(user.ID)
+	require.NoError(t, err)
+	second, err := svc.BeginTOTPEnrollment(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first, stored.TOTPSecret)
+	assert.False(t, stored.TOTPEnabled, "secret must not take effect before confirmation")
+}
+
+func TestBeginTOTPEnrollment_ExternalUserRejected(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestHTTPAPIUser(t, db)
+
+	_, err := svc.BeginTOTPEnrollment(user.ID)
+	assert.ErrorIs(t, err, ErrTwoFactorNotAllowed)
+}
+
+func TestEnableTOTP(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+
+	t.Run("not started", func(t *testing.T) {
+		_, err := svc.EnableTOTP(context.Background(), user.ID, "123456")
+		assert.ErrorIs(t, err, ErrTwoFactorNotStarted)
+	})
+
+	secret, err := svc.BeginTOTPEnrollment(user.ID)
+	require.NoError(t, err)
+
+	t.Run("wrong code", func(t *testing.T) {
+		code, err := totp.Code(secret, time.Now().Add(-10*totp.Period))
+		require.NoError(t, err)
+		_, err = svc.EnableTOTP(context.Background(), user.ID, code)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+		codes, err := svc.EnableTOTP(context.Background(), user.ID, code)
+		require.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, codes[0])
+
+		stored, err := db.GetUserByID(user.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.TOTPEnabled)
+
+		left, err := svc.CountRecoveryCodes(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(recoveryCodeCount), left)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		_, err := svc.EnableTOTP(context.Background(), user.ID, "123456")
+		assert.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+	})
+}
+
+func TestVerifyTwoFactor_TOTPCodeRejectedOnReplay(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	secret, _ := enrollTOTP(t, svc, user.ID)
+
+	code := nextTOTPCode(t, secret)
+	method, err := svc.VerifyTwoFactor(context.Background(), user.ID, code)
+	require.NoError(t, err)
+	assert.Equal(t, TwoFactorMethodTOTP, method)
+
+	_, err = svc.VerifyTwoFactor(context.Background(), user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestVerifyTwoFactor_EnrollmentCodeCannotBeReused(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+
+	secret, err := svc.BeginTOTPEnrollment(user.ID)
+	require.NoError(t, err)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	_, err = svc.EnableTOTP(context.Background(), user.ID, code)
+	require.NoError(t, err)
+
+	_, err = svc.VerifyTwoFactor(context.Background(), user.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestVerifyTwoFactor_RecoveryCodeSingleUse(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	_, codes := enrollTOTP(t, svc, user.ID)
+
+	// Codes are accepted regardless of case and separators.
+	typed := "  " + codes[0][:9] + codes[0][10:] + "  "
+	method, err := svc.VerifyTwoFactor(context.Background(), user.ID, typed)
+	require.NoError(t, err)
+	assert.Equal(t, TwoFactorMethodRecoveryCode, method)
+
+	left, err := svc.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(recoveryCodeCount-1), left)
+
+	_, err = svc.VerifyTwoFactor(context.Background(), user.ID, codes[0])
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestVerifyTwoFactor_NotEnabled(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+
+	_, err := svc.VerifyTwoFactor(context.Background(), user.ID, "123456")
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnabled)
+}
+
+func TestRegenerateRecoveryCodes_InvalidatesOldCodes(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	secret, oldCodes := enrollTOTP(t, svc, user.ID)
+
+	_, err := svc.RegenerateRecoveryCodes(context.Background(), user.ID, oldCodes[0])
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode, "recovery codes cannot authorize regeneration")
+
+	newCodes, err := svc.RegenerateRecoveryCodes(
+		context.Background(), user.ID, nextTOTPCode(t, secret),
+	)
+	require.NoError(t, err)
+	assert.Len(t, newCodes, recoveryCodeCount)
+
+	_, err = svc.VerifyTwoFactor(context.Background(), user.ID, oldCodes[1])
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	_, err = svc.VerifyTwoFactor(context.Background(), user.ID, newCodes[0])
+	assert.NoError(t, err)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	_, codes := enrollTOTP(t, svc, user.ID)
+
+	err := svc.DisableTOTP(context.Background(), user.ID, "000000")
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	require.NoError(t, svc.DisableTOTP(context.Background(), user.ID, codes[0]))
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.TOTPEnabled)
+	assert.Empty(t, stored.TOTPSecret)
+
+	left, err := svc.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
+
+func TestAdminResetTwoFactor(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	admin := makeTestUser(t, db)
+
+	err := svc.AdminResetTwoFactor(context.Background(), user.ID, admin.ID)
+	require.ErrorIs(t, err, ErrTwoFactorNotEnabled)
+
+	enrollTOTP(t, svc, user.ID)
+	require.NoError(t, svc.AdminResetTwoFactor(context.Background(), user.ID, admin.ID))
+
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.TOTPEnabled)
+	assert.Empty(t, stored.TOTPSecret)
+
+	// The user can enroll again with a fresh secret.
+	_, err = svc.BeginTOTPEnrollment(user.ID)
+	assert.NoError(t, err)
+}
+
+func TestGetUserByID_StripsTOTPSecret(t *testing.T) {
+	svc, db := newTwoFactorTestService(t)
+	user := makeTestUser(t, db)
+	enrollTOTP(t, svc, user.ID)
+
+	got, err := svc.GetUserByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, got.TOTPEnabled)
+	assert.Empty(t, got.TOTPSecret)
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/models"
@@ -89,6 +91,60 @@ func (s *Store) GetAuditLogsPaginated(
 	return logs, pagination, nil
 }
 
+// GetTokenAuditLogs returns token audit events whose resource ID is one of
+// resourceIDs or whose details mention one of the values in mentions (matched
+// as a quoted JSON string), oldest first and capped at limit entries. It backs
+// the token family timeline, where family-wide revocations are recorded
+// against a single token or a client but name the family in their details.
+func (s *Store) GetTokenAuditLogs(
+	resourceIDs, mentions []string,
+	limit int,
+) ([]models.AuditLog, error) {
+	seen := make(map[string]bool)
+	var logs []models.AuditLog
+	collect := func(query *gorm.DB) error {
+		var batch []models.AuditLog
+		if err := query.Where("resource_type = ?", models.ResourceToken).
+			Order("event_time ASC").
+			Limit(limit).
+			Find(&batch).Error; err != nil {
+			return err
+		}
+		for _, l := range batch {
+			if !seen[l.ID] {
+				seen[l.ID] = true
+				logs = append(logs, l)
+			}
+		}
+		return nil
+	}
+
+	for chunk := range slices.Chunk(resourceIDs, bulkUpdateChunkSize) {
+		if err := collect(s.db.Where("resource_id IN ?", chunk)); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range mentions {
+		if m == "" {
+			continue
+		}
+		query := s.db.Where(
+			"CAST(details AS TEXT) LIKE ? ESCAPE '\\'", `%"`+escapeLike(m)+`"%`,
+		)
+		if err := collect(query); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].EventTime.Before(logs[j].EventTime)
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
 // DeleteOldAuditLogs deletes audit logs older than the specified time
 func (s *Store) DeleteOldAuditLogs(olderThan time.Time) (int64, error) {
 	result := s.db.Where("created_at < ?", olderThan).Delete(&models.AuditLog{})
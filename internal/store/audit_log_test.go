@@ -300,3 +300,52 @@ func TestGetAuditLogStats(t *testing.T) {
 		assert.Equal(t, int64(1), stats.FailureCount)
 	})
 }
+
+func TestGetTokenAuditLogs(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	familyID := uuid.New().String()
+	tokenID := uuid.New().String()
+	base := time.Now().Add(-time.Hour)
+
+	newLog := func(
+		offset time.Duration,
+		resourceType models.ResourceType,
+		resourceID string,
+		details models.AuditDetails,
+	) *models.AuditLog {
+		log := &models.AuditLog{
+			ID:           uuid.New().String(),
+			EventType:    models.EventTokenRevoked,
+			EventTime:    base.Add(offset),
+			Severity:     models.SeverityInfo,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Action:       "test",
+			Details:      details,
+			Success:      true,
+			CreatedAt:    time.Now(),
+		}
+		require.NoError(t, store.CreateAuditLog(log))
+		return log
+	}
+
+	byID := newLog(2*time.Minute, models.ResourceToken, tokenID, nil)
+	byMention := newLog(time.Minute, models.ResourceToken, uuid.New().String(),
+		models.AuditDetails{"family_ids": []string{familyID}})
+	both := newLog(3*time.Minute, models.ResourceToken, tokenID,
+		models.AuditDetails{"family_id": familyID})
+	newLog(4*time.Minute, models.ResourceUser, tokenID, nil)
+	newLog(5*time.Minute, models.ResourceToken, uuid.New().String(),
+		models.AuditDetails{"family_id": familyID + "x"})
+
+	logs, err := store.GetTokenAuditLogs([]string{tokenID}, []string{familyID}, 100)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	assert.Equal(t, byMention.ID, logs[0].ID, "oldest first")
+	assert.Equal(t, byID.ID, logs[1].ID)
+	assert.Equal(t, both.ID, logs[2].ID, "matched twice, returned once")
+
+	limited, err := store.GetTokenAuditLogs([]string{tokenID}, []string{familyID}, 2)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+}
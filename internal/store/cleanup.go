@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/go-authgate/authgate/internal/models"
+
+	"gorm.io/gorm"
 )
 
 // Cleanup and Metrics operations (implements core.CleanupStore + core.MetricsStore)
@@ -12,10 +14,114 @@ func (s *Store) DeleteExpiredTokens() error {
 	return s.db.Where("expires_at < ?", time.Now()).Delete(&models.AccessToken{}).Error
 }
 
+// DeleteIdleRefreshTokens deletes active refresh tokens that have not been
+// used within their client's token-profile idle timeout, together with every
+// other row in the same rotation family. idleTimeouts maps every configured
+// token profile name to its timeout; a zero timeout disables the check.
+// Clients with an empty or unconfigured token_profile fall back to
+// "standard", matching how the refresh grant resolves their profile.
+func (s *Store) DeleteIdleRefreshTokens(idleTimeouts map[string]time.Duration) (int64, error) {
+	var total int64
+	now := time.Now()
+	for profile, timeout := range idleTimeouts {
+		if timeout <= 0 {
+			continue
+		}
+		clients := s.db.Model(&models.OAuthApplication{}).Select("client_id")
+		if profile == models.TokenProfileStandard {
+			others := make([]string, 0, len(idleTimeouts))
+			for name := range idleTimeouts {
+				if name != models.TokenProfileStandard {
+					others = append(others, name)
+				}
+			}
+			if len(others) > 0 {
+				clients = clients.Where("token_profile NOT IN ?", others)
+			}
+		} else {
+			clients = clients.Where("token_profile = ?", profile)
+		}
+		cutoff := now.Add(-timeout)
+
+		var idle []models.AccessToken
+		err := s.db.Model(&models.AccessToken{}).
+			Select("id", "token_family_id").
+			Where("token_category = ? AND status = ?",
+				models.TokenCategoryRefresh, models.TokenStatusActive).
+			Where("COALESCE(last_used_at, created_at) < ?", cutoff).
+			Where("client_id IN (?)", clients).
+			Find(&idle).Error
+		if err != nil {
+			return total, err
+		}
+		if len(idle) == 0 {
+			continue
+		}
+
+		ids := make([]string, 0, len(idle))
+		families := make([]string, 0, len(idle))
+		for _, tok := range idle {
+			ids = append(ids, tok.ID)
+			if tok.TokenFamilyID != "" {
+				families = append(families, tok.TokenFamilyID)
+			}
+		}
+		query := s.db.Where("id IN ?", ids)
+		if len(families) > 0 {
+			query = query.Or("token_family_id IN ?", families)
+		}
+		result := query.Delete(&models.AccessToken{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+	return total, nil
+}
+
 func (s *Store) DeleteExpiredDeviceCodes() error {
 	return s.db.Where("expires_at < ?", time.Now()).Delete(&models.DeviceCode{}).Error
 }
 
+// DeleteExpiredPasswordResetTokens deletes expired reset tokens once they
+// are old enough not to count towards the per-hour request limit.
+func (s *Store) DeleteExpiredPasswordResetTokens() error {
+	now := time.Now()
+	return s.db.
+		Where("expires_at < ? AND created_at < ?", now, now.Add(-time.Hour)).
+		Delete(&models.PasswordResetToken{}).Error
+}
+
+// DeleteExpiredEmailChangeTokens deletes expired email change tokens once
+// they are old enough not to count towards the per-hour request limit.
+func (s *Store) DeleteExpiredEmailChangeTokens() error {
+	now := time.Now()
+	return s.db.
+		Where("expires_at < ? AND created_at < ?", now, now.Add(-time.Hour)).
+		Delete(&models.EmailChangeToken{}).Error
+}
+
+// DeleteExpiredSignups deletes users who signed up before createdBefore and
+// never verified their email, together with their verification tokens, so
+// the username and address can be registered again.
+func (s *Store) DeleteExpiredSignups(createdBefore time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		const expired = "signup_status = ? AND created_at < ?"
+		users := tx.Model(&models.User{}).Select("id").
+			Where(expired, models.SignupStatusUnverified, createdBefore)
+		if err := tx.Where("user_id IN (?)", users).
+			Delete(&models.EmailVerificationToken{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where(expired, models.SignupStatusUnverified, createdBefore).
+			Delete(&models.User{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
 // CountActiveTokensByCategory counts active, non-expired tokens by category
 func (s *Store) CountActiveTokensByCategory(category string) (int64, error) {
 	var count int64
@@ -37,6 +37,18 @@ func (s *Store) UpdateDeviceCode(dc *models.DeviceCode) error {
 	return s.db.Save(dc).Error
 }
 
+// RecordDeviceCodePoll stores the time of a pending device code's latest
+// token request and its (possibly raised) polling interval. It updates only
+// those columns so it cannot overwrite a concurrent authorization.
+func (s *Store) RecordDeviceCodePoll(id int64, polledAt time.Time, interval int) error {
+	return s.db.Model(&models.DeviceCode{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"last_polled_at": polledAt,
+			"interval":       interval,
+		}).Error
+}
+
 // AuthorizeDeviceCode atomically marks a device code as authorized by a user.
 // It uses a WHERE clause to ensure only one concurrent request wins; the loser
 // receives ErrDeviceCodeAlreadyAuthorized (0 rows updated).
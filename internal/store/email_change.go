@@ -0,0 +1,49 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+)
+
+// CreateEmailChangeToken stores a newly issued email change token.
+func (s *Store) CreateEmailChangeToken(token *models.EmailChangeToken) error {
+	return s.db.Create(token).Error
+}
+
+// GetEmailChangeTokenByHash finds an email change token by the hash of the
+// value sent in the email.
+func (s *Store) GetEmailChangeTokenByHash(tokenHash string) (*models.EmailChangeToken, error) {
+	var token models.EmailChangeToken
+	if err := s.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CountEmailChangeTokensSince returns how many email change tokens were
+// issued to the user after since, used or not.
+func (s *Store) CountEmailChangeTokensSince(userID string, since time.Time) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.EmailChangeToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ConsumeEmailChangeToken atomically marks an unused, unexpired token as
+// used. It returns false when the token was already used or has expired.
+func (s *Store) ConsumeEmailChangeToken(id uint, now time.Time) (bool, error) {
+	result := s.db.Model(&models.EmailChangeToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteEmailChangeTokensByUserID deletes all email change tokens for a user.
+func (s *Store) DeleteEmailChangeTokensByUserID(userID string) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.EmailChangeToken{}).Error
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+)
+
+// CreatePasswordResetToken stores a newly issued reset token.
+func (s *Store) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return s.db.Create(token).Error
+}
+
+// GetPasswordResetTokenByHash finds a reset token by the hash of the value
+// sent in the email.
+func (s *Store) GetPasswordResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := s.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CountPasswordResetTokensSince returns how many reset tokens were issued to
+// the user after since, used or not.
+func (s *Store) CountPasswordResetTokensSince(userID string, since time.Time) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ConsumePasswordResetToken atomically marks an unused, unexpired token as
+// used. It returns false when the token was already used or has expired, so
+// a link works at most once even under concurrent submissions.
+func (s *Store) ConsumePasswordResetToken(id uint, now time.Time) (bool, error) {
+	result := s.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeletePasswordResetTokensByUserID deletes all reset tokens for a user.
+func (s *Store) DeletePasswordResetTokensByUserID(userID string) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.PasswordResetToken{}).Error
+}
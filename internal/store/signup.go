@@ -0,0 +1,89 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateSignupUser stores a self-registered user together with its email
+// verification token. The user is always stored inactive: IsActive has a
+// database default of true, which a plain Create would apply to false.
+func (s *Store) CreateSignupUser(
+	user *models.User,
+	token *models.EmailVerificationToken,
+) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		token.UserID = user.ID
+		return tx.Create(token).Error
+	})
+}
+
+// GetEmailVerificationTokenByHash finds a verification token by the hash of
+// the value sent in the email.
+func (s *Store) GetEmailVerificationTokenByHash(
+	tokenHash string,
+) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	if err := s.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumeEmailVerificationToken atomically marks an unused, unexpired token
+// as used. It returns false when the token was already used or has expired.
+func (s *Store) ConsumeEmailVerificationToken(id uint, now time.Time) (bool, error) {
+	result := s.db.Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteEmailVerificationTokensByUserID deletes all verification tokens for a user.
+func (s *Store) DeleteEmailVerificationTokensByUserID(userID string) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.EmailVerificationToken{}).Error
+}
+
+// ListUsersBySignupStatus returns users whose signup is in the given state,
+// oldest first.
+func (s *Store) ListUsersBySignupStatus(status string) ([]models.User, error) {
+	var users []models.User
+	err := s.db.Where("signup_status = ?", status).
+		Order("created_at ASC").
+		Omit("password_hash").
+		Find(&users).Error
+	return users, err
+}
+
+// CountUsersBySignupStatus returns the number of users whose signup is in
+// the given state.
+func (s *Store) CountUsersBySignupStatus(status string) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.User{}).Where("signup_status = ?", status).Count(&count).Error
+	return count, err
+}
+
+// DeleteUnverifiedSignup deletes a user and its verification tokens, but
+// only while the user has still not verified their email.
+func (s *Store) DeleteUnverifiedSignup(userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND signup_status = ?", userID, models.SignupStatusUnverified).
+			Delete(&models.User{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.EmailVerificationToken{}).Error
+	})
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createTestSignup(t *testing.T, s *Store, status string) *models.User {
+	t.Helper()
+	u := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "signup-" + uuid.New().String()[:8],
+		Email:        uuid.New().String()[:8] + "@example.com",
+		PasswordHash: "hashed",
+		Role:         models.UserRoleUser,
+		AuthSource:   models.AuthSourceLocal,
+		SignupStatus: status,
+	}
+	require.NoError(t, s.CreateSignupUser(u, &models.EmailVerificationToken{
+		TokenHash: uuid.New().String(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	return u
+}
+
+func TestCreateSignupUser_StoredInactive(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	u := createTestSignup(t, s, models.SignupStatusUnverified)
+
+	stored, err := s.GetUserByID(u.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.IsActive)
+	assert.Equal(t, models.SignupStatusUnverified, stored.SignupStatus)
+}
+
+func TestCountAndListUsersBySignupStatus(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	createTestSignup(t, s, models.SignupStatusUnverified)
+	first := createTestSignup(t, s, models.SignupStatusPending)
+	second := createTestSignup(t, s, models.SignupStatusPending)
+	createTestUser(t, s, nil)
+
+	count, err := s.CountUsersBySignupStatus(models.SignupStatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	users, err := s.ListUsersBySignupStatus(models.SignupStatusPending)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, []string{users[0].ID, users[1].ID})
+	assert.Empty(t, users[0].PasswordHash)
+}
+
+func TestDeleteExpiredSignups(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	unverified := createTestSignup(t, s, models.SignupStatusUnverified)
+	pending := createTestSignup(t, s, models.SignupStatusPending)
+	active := createTestUser(t, s, nil)
+
+	// Nothing is old enough yet.
+	deleted, err := s.DeleteExpiredSignups(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	deleted, err = s.DeleteExpiredSignups(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = s.GetUserByID(unverified.ID)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	var tokens int64
+	require.NoError(t, s.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ?", unverified.ID).Count(&tokens).Error)
+	assert.Zero(t, tokens)
+
+	for _, id := range []string{pending.ID, active.ID} {
+		_, err = s.GetUserByID(id)
+		assert.NoError(t, err)
+	}
+}
+
+func TestDeleteUnverifiedSignup_KeepsVerifiedUsers(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	pending := createTestSignup(t, s, models.SignupStatusPending)
+
+	require.NoError(t, s.DeleteUnverifiedSignup(pending.ID))
+	_, err := s.GetUserByID(pending.ID)
+	assert.NoError(t, err)
+}
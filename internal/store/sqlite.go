@@ -66,6 +66,11 @@ func New(ctx context.Context, driver, dsn string, cfg *config.Config) (*Store, e
 		&models.AuditLog{},
 		&models.AuthorizationCode{},
 		&models.UserAuthorization{},
+		&models.UserRecoveryCode{},
+		&models.WebAuthnCredential{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.EmailChangeToken{},
 	); err != nil {
 		return nil, err
 	}
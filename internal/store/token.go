@@ -1,6 +1,8 @@
 package store
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-authgate/authgate/internal/models"
@@ -101,7 +103,72 @@ func (s *Store) GetTokensByUserIDPaginated(
 func (s *Store) GetTokensPaginated(
 	params PaginationParams,
 ) ([]models.AccessToken, PaginationResult, error) {
-	query := s.db.Model(&models.AccessToken{})
+	return s.SearchTokensPaginated(params, TokenFilter{})
+}
+
+// applyTokenSearchFilter adds the WHERE clauses for every criterion set on f.
+func applyTokenSearchFilter(query *gorm.DB, f TokenFilter) *gorm.DB {
+	if f.UserID != "" {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.ClientID != "" {
+		query = query.Where("client_id = ?", f.ClientID)
+	}
+	if f.Category != "" {
+		query = query.Where("token_category = ?", f.Category)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Scope != "" {
+		// Pad both sides so "read" matches "openid read" but not "read:org".
+		query = query.Where(
+			"(' ' || scopes || ' ') LIKE ? ESCAPE '\\'",
+			"% "+escapeLike(f.Scope)+" %",
+		)
+	}
+	if f.Resource != "" {
+		// Resource is a JSON array; match the quoted element exactly.
+		query = query.Where(
+			"CAST(resource AS TEXT) LIKE ? ESCAPE '\\'",
+			`%"`+escapeLike(f.Resource)+`"%`,
+		)
+	}
+	if f.FamilyID != "" {
+		query = query.Where("token_family_id = ?", f.FamilyID)
+	}
+	if f.SessionID != "" {
+		query = query.Where("session_id = ?", f.SessionID)
+	}
+	if f.IssuedAfter != nil {
+		query = query.Where("created_at >= ?", *f.IssuedAfter)
+	}
+	if f.IssuedBefore != nil {
+		query = query.Where("created_at < ?", *f.IssuedBefore)
+	}
+	if f.ExpiresAfter != nil {
+		query = query.Where("expires_at >= ?", *f.ExpiresAfter)
+	}
+	if f.ExpiresBefore != nil {
+		query = query.Where("expires_at < ?", *f.ExpiresBefore)
+	}
+	return query
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchTokensPaginated returns paginated tokens across all users matching
+// filter, combined with the free-text search and status/category tabs of params.
+func (s *Store) SearchTokensPaginated(
+	params PaginationParams,
+	filter TokenFilter,
+) ([]models.AccessToken, PaginationResult, error) {
+	query := applyTokenSearchFilter(s.db.Model(&models.AccessToken{}), filter)
 
 	if params.Search != "" {
 		searchPattern := "%" + params.Search + "%"
@@ -120,6 +187,68 @@ func (s *Store) GetTokensPaginated(
 	return paginateTokens(applyTokenFilters(query, params), params)
 }
 
+// CountTokensByFilter counts tokens matching filter.
+func (s *Store) CountTokensByFilter(filter TokenFilter) (int64, error) {
+	var count int64
+	err := applyTokenSearchFilter(s.db.Model(&models.AccessToken{}), filter).
+		Count(&count).Error
+	return count, err
+}
+
+// bulkUpdateChunkSize keeps IN lists well below SQLite's bound-parameter limit.
+const bulkUpdateChunkSize = 500
+
+// UpdateTokenStatusByFilter moves every token matching filter whose current
+// status is one of fromStatuses to newStatus. Rows are kept (not deleted) so
+// bulk incident response leaves a forensic trail. Returns the updated tokens
+// with only ID, TokenHash, TokenCategory, TokenFamilyID and ParentTokenID
+// loaded, for cache invalidation, metrics and audit.
+func (s *Store) UpdateTokenStatusByFilter(
+	filter TokenFilter,
+	fromStatuses []string,
+	newStatus string,
+) ([]models.AccessToken, error) {
+	var matched []models.AccessToken
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := applyTokenSearchFilter(tx.Model(&models.AccessToken{}), filter).
+			Where("status IN ?", fromStatuses).
+			Select("id", "token_hash", "token_category", "token_family_id", "parent_token_id").
+			Find(&matched).Error; err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(matched))
+		for _, t := range matched {
+			ids = append(ids, t.ID)
+		}
+		for chunk := range slices.Chunk(ids, bulkUpdateChunkSize) {
+			if err := tx.Model(&models.AccessToken{}).
+				Where("id IN ?", chunk).
+				Update("status", newStatus).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// LockUserTokenGrants serializes token issuance for userID until the
+// surrounding transaction ends, so a count-then-insert check such as the
+// refresh family limit cannot be raced by a concurrent grant. It rewrites the
+// user's updated_at in place: PostgreSQL then holds that row lock, and SQLite
+// takes its database write lock. The lock needs the user's row — on
+// PostgreSQL an update that matches no row locks nothing — so it only
+// serializes grants for users that exist. Call it first inside
+// RunInTransaction.
+func (s *Store) LockUserTokenGrants(userID string) error {
+	return s.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("updated_at", gorm.Expr("updated_at")).Error
+}
+
 func (s *Store) RevokeToken(tokenID string) error {
 	return s.db.Where("id = ?", tokenID).Delete(&models.AccessToken{}).Error
 }
@@ -146,6 +275,14 @@ func (s *Store) UpdateTokenLastUsedAt(tokenID string, t time.Time) error {
 		Update("last_used_at", &t).Error
 }
 
+// UpdateTokenExpiresAt moves a token's expiry, used by sliding refresh-token
+// renewal in fixed (non-rotation) mode.
+func (s *Store) UpdateTokenExpiresAt(tokenID string, t time.Time) error {
+	return s.db.Model(&models.AccessToken{}).
+		Where("id = ?", tokenID).
+		Update("expires_at", t).Error
+}
+
 // RevokeTokenFamily revokes all active tokens that share the same TokenFamilyID.
 // This is used for refresh token rotation replay detection: when a revoked refresh token
 // is reused, all tokens in the family must be invalidated to prevent stolen token abuse.
@@ -209,6 +346,35 @@ func (s *Store) GetTokensByCategoryAndStatus(
 	return tokens, err
 }
 
+// GetActiveRefreshTokens returns the active, unexpired refresh tokens userID
+// holds, oldest first. With rotation each token family keeps exactly one
+// active refresh token, so every row stands for one family. An empty clientID
+// matches every client.
+func (s *Store) GetActiveRefreshTokens(userID, clientID string) ([]models.AccessToken, error) {
+	query := s.db.Where(
+		"user_id = ? AND token_category = ? AND status = ? AND expires_at > ?",
+		userID, models.TokenCategoryRefresh, models.TokenStatusActive, time.Now(),
+	)
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	var tokens []models.AccessToken
+	err := query.Order("created_at ASC").Order("id ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// GetTokenFamilyTokens returns every token belonging to the family rooted at
+// rootID, oldest first: tokens carrying it as their family ID, the root itself,
+// and tokens minted from it (covering fixed-mode refresh tokens, which have no
+// family ID). Tokens of every status are included.
+func (s *Store) GetTokenFamilyTokens(rootID string) ([]models.AccessToken, error) {
+	var tokens []models.AccessToken
+	err := s.db.Where(
+		"token_family_id = ? OR id = ? OR parent_token_id = ?", rootID, rootID, rootID,
+	).Order("created_at ASC").Order("id ASC").Find(&tokens).Error
+	return tokens, err
+}
+
 // RevokeTokensByAuthorizationID revokes all active tokens linked to a specific UserAuthorization
 func (s *Store) RevokeTokensByAuthorizationID(authorizationID uint) error {
 	return s.db.Model(&models.AccessToken{}).
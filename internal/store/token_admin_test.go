@@ -203,3 +203,97 @@ func TestGetDashboardCounts(t *testing.T) {
 	assert.Equal(t, int64(1), counts.PendingClients)     // new pending client
 	assert.Equal(t, int64(1), counts.ActiveAccessTokens) // new access token
 }
+
+func TestSearchTokensPaginated_Filters(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	clientA, clientB := uuid.New().String(), uuid.New().String()
+	userID := uuid.New().String()
+
+	old := createTestToken(userID, clientA)
+	old.Scopes = "openid write"
+	old.Resource = models.StringArray{"https://api.example.com"}
+	old.TokenFamilyID = "family-1"
+	require.NoError(t, s.CreateAccessToken(old))
+	require.NoError(t, s.db.Model(old).Update("created_at", time.Now().Add(-48*time.Hour)).Error)
+
+	recent := createTestToken(userID, clientA)
+	recent.Scopes = "write:org"
+	require.NoError(t, s.CreateAccessToken(recent))
+
+	other := createTestToken(uuid.New().String(), clientB)
+	other.Scopes = "write"
+	require.NoError(t, s.CreateAccessToken(other))
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	tests := []struct {
+		name   string
+		filter TokenFilter
+		want   []string
+	}{
+		{"scope matches whole words only", TokenFilter{Scope: "write"}, []string{old.ID, other.ID}},
+		{"client and scope", TokenFilter{ClientID: clientA, Scope: "write"}, []string{old.ID}},
+		{"user", TokenFilter{UserID: userID}, []string{old.ID, recent.ID}},
+		{"issued before", TokenFilter{IssuedBefore: &cutoff}, []string{old.ID}},
+		{"issued after", TokenFilter{ClientID: clientA, IssuedAfter: &cutoff}, []string{recent.ID}},
+		{"resource", TokenFilter{Resource: "https://api.example.com"}, []string{old.ID}},
+		{"family", TokenFilter{FamilyID: "family-1"}, []string{old.ID}},
+		{"like wildcards are literal", TokenFilter{Scope: "wr_te"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, pagination, err := s.SearchTokensPaginated(NewPaginationParams(1, 50, ""), tt.filter)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(tokens))
+			for _, tok := range tokens {
+				ids = append(ids, tok.ID)
+			}
+			assert.ElementsMatch(t, tt.want, ids)
+			assert.Equal(t, int64(len(tt.want)), pagination.Total)
+
+			count, err := s.CountTokensByFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+}
+
+func TestUpdateTokenStatusByFilter(t *testing.T) {
+	s := createFreshStore(t, "sqlite", nil)
+	clientID := uuid.New().String()
+
+	active := createTestToken(uuid.New().String(), clientID)
+	require.NoError(t, s.CreateAccessToken(active))
+	disabled := createTestToken(uuid.New().String(), clientID)
+	disabled.Status = models.TokenStatusDisabled
+	require.NoError(t, s.CreateAccessToken(disabled))
+	untouched := createTestToken(uuid.New().String(), uuid.New().String())
+	require.NoError(t, s.CreateAccessToken(untouched))
+
+	updated, err := s.UpdateTokenStatusByFilter(
+		TokenFilter{ClientID: clientID},
+		[]string{models.TokenStatusActive, models.TokenStatusDisabled},
+		models.TokenStatusRevoked,
+	)
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	hashes := []string{updated[0].TokenHash, updated[1].TokenHash}
+	assert.ElementsMatch(t, []string{active.TokenHash, disabled.TokenHash}, hashes)
+
+	for _, id := range []string{active.ID, disabled.ID} {
+		tok, err := s.GetAccessTokenByID(id)
+		require.NoError(t, err, "bulk revoke keeps rows for forensics")
+		assert.Equal(t, models.TokenStatusRevoked, tok.Status)
+	}
+	tok, err := s.GetAccessTokenByID(untouched.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TokenStatusActive, tok.Status)
+
+	// Already-revoked tokens are not eligible again.
+	updated, err = s.UpdateTokenStatusByFilter(
+		TokenFilter{ClientID: clientID},
+		[]string{models.TokenStatusActive},
+		models.TokenStatusDisabled,
+	)
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+}
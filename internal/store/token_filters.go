@@ -0,0 +1,6 @@
+package store
+
+import "github.com/go-authgate/authgate/internal/store/types"
+
+// Re-export types from store/types for backward compatibility.
+type TokenFilter = types.TokenFilter
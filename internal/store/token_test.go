@@ -410,6 +410,79 @@ func TestRevokeToken(t *testing.T) {
 	})
 }
 
+func TestGetActiveRefreshTokens(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	userID := uuid.New().String()
+	clientID := uuid.New().String()
+
+	newRefresh := func(client string, mutate func(*models.AccessToken)) *models.AccessToken {
+		tok := createTestToken(userID, client)
+		tok.TokenCategory = models.TokenCategoryRefresh
+		if mutate != nil {
+			mutate(tok)
+		}
+		require.NoError(t, store.CreateAccessToken(tok))
+		return tok
+	}
+
+	older := newRefresh(clientID, func(tok *models.AccessToken) {
+		tok.CreatedAt = time.Now().Add(-time.Hour)
+	})
+	newer := newRefresh(clientID, nil)
+	other := newRefresh(uuid.New().String(), nil)
+	newRefresh(clientID, func(tok *models.AccessToken) { tok.Status = models.TokenStatusRevoked })
+	newRefresh(clientID, func(tok *models.AccessToken) { tok.ExpiresAt = time.Now().Add(-time.Minute) })
+	access := createTestToken(userID, clientID)
+	require.NoError(t, store.CreateAccessToken(access))
+
+	got, err := store.GetActiveRefreshTokens(userID, clientID)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, older.ID, got[0].ID, "oldest first")
+	assert.Equal(t, newer.ID, got[1].ID)
+
+	all, err := store.GetActiveRefreshTokens(userID, "")
+	require.NoError(t, err)
+	ids := make([]string, 0, len(all))
+	for _, tok := range all {
+		ids = append(ids, tok.ID)
+	}
+	assert.ElementsMatch(t, []string{older.ID, newer.ID, other.ID}, ids)
+}
+
+func TestGetTokenFamilyTokens(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	userID := uuid.New().String()
+	clientID := uuid.New().String()
+
+	root := createTestToken(userID, clientID)
+	root.TokenCategory = models.TokenCategoryRefresh
+	root.TokenFamilyID = root.ID
+	root.CreatedAt = time.Now().Add(-time.Hour)
+	require.NoError(t, store.CreateAccessToken(root))
+
+	rotated := createTestToken(userID, clientID)
+	rotated.TokenCategory = models.TokenCategoryRefresh
+	rotated.TokenFamilyID = root.ID
+	rotated.ParentTokenID = root.ID
+	rotated.Status = models.TokenStatusRevoked
+	require.NoError(t, store.CreateAccessToken(rotated))
+
+	// Fixed-mode child: linked only by its parent.
+	child := createTestToken(userID, clientID)
+	child.ParentTokenID = root.ID
+	require.NoError(t, store.CreateAccessToken(child))
+
+	require.NoError(t, store.CreateAccessToken(createTestToken(userID, clientID)))
+
+	got, err := store.GetTokenFamilyTokens(root.ID)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, root.ID, got[0].ID, "oldest first")
+	ids := []string{got[1].ID, got[2].ID}
+	assert.ElementsMatch(t, []string{rotated.ID, child.ID}, ids)
+}
+
 func TestRevokeTokensByAuthorizationID(t *testing.T) {
 	t.Run("RevokesMatchingTokens", func(t *testing.T) {
 		store := createFreshStore(t, "sqlite", nil)
@@ -538,3 +611,91 @@ func TestRevokeAllActiveTokensByClientID(t *testing.T) {
 		assert.Equal(t, int64(0), count)
 	})
 }
+
+func TestDeleteIdleRefreshTokens(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	userID := uuid.New().String()
+	clientID := uuid.New().String()
+	createTestClient(t, store, clientID, "Idle App")
+
+	stale := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	// Rotation family whose active refresh token has been idle for 48h.
+	idleFamily := uuid.New().String()
+	idleRefresh := createTestToken(userID, clientID)
+	idleRefresh.TokenCategory = models.TokenCategoryRefresh
+	idleRefresh.TokenFamilyID = idleFamily
+	idleRefresh.ExpiresAt = time.Now().Add(30 * 24 * time.Hour)
+	idleRefresh.CreatedAt = stale
+	rotatedOut := createTestToken(userID, clientID)
+	rotatedOut.TokenCategory = models.TokenCategoryRefresh
+	rotatedOut.Status = models.TokenStatusRevoked
+	rotatedOut.TokenFamilyID = idleFamily
+	rotatedOut.ExpiresAt = idleRefresh.ExpiresAt
+	familyAccess := createTestToken(userID, clientID)
+	familyAccess.TokenFamilyID = idleFamily
+
+	// Fixed-mode refresh token created long ago but used recently.
+	usedRefresh := createTestToken(userID, clientID)
+	usedRefresh.TokenCategory = models.TokenCategoryRefresh
+	usedRefresh.ExpiresAt = idleRefresh.ExpiresAt
+	usedRefresh.CreatedAt = stale
+	usedRefresh.LastUsedAt = &recent
+
+	for _, tok := range []*models.AccessToken{idleRefresh, rotatedOut, familyAccess, usedRefresh} {
+		require.NoError(t, store.CreateAccessToken(tok))
+	}
+
+	// Profiles without a configured timeout are ignored.
+	deleted, err := store.DeleteIdleRefreshTokens(map[string]time.Duration{
+		models.TokenProfileShort: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = store.DeleteIdleRefreshTokens(map[string]time.Duration{
+		models.TokenProfileStandard: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted, "whole idle family is removed")
+
+	for _, tok := range []*models.AccessToken{idleRefresh, rotatedOut, familyAccess} {
+		_, err := store.GetAccessTokenByID(tok.ID)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	}
+	_, err = store.GetAccessTokenByID(usedRefresh.ID)
+	require.NoError(t, err, "recently used refresh token is kept")
+}
+
+func TestDeleteIdleRefreshTokens_UnconfiguredProfileFallsBackToStandard(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	legacy := createTestClientWithOpts(t, store, nil)
+	short := createTestClientWithOpts(t, store, nil)
+	legacy.TokenProfile = "legacy"
+	short.TokenProfile = models.TokenProfileShort
+
+	stale := time.Now().Add(-48 * time.Hour)
+	tokens := make(map[string]*models.AccessToken)
+	for _, client := range []*models.OAuthApplication{legacy, short} {
+		require.NoError(t, store.UpdateClient(client))
+		tok := createTestToken(uuid.New().String(), client.ClientID)
+		tok.TokenCategory = models.TokenCategoryRefresh
+		tok.ExpiresAt = time.Now().Add(30 * 24 * time.Hour)
+		tok.CreatedAt = stale
+		require.NoError(t, store.CreateAccessToken(tok))
+		tokens[client.ClientID] = tok
+	}
+
+	deleted, err := store.DeleteIdleRefreshTokens(map[string]time.Duration{
+		models.TokenProfileStandard: 24 * time.Hour,
+		models.TokenProfileShort:    0,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = store.GetAccessTokenByID(tokens[legacy.ClientID].ID)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound, "unknown profile uses standard timeout")
+	_, err = store.GetAccessTokenByID(tokens[short.ClientID].ID)
+	require.NoError(t, err, "configured profile with the check disabled is kept")
+}
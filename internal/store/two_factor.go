@@ -0,0 +1,114 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SetUserTOTPSecret stores a pending TOTP secret and leaves 2FA disabled until
+// the user confirms it with EnableUserTOTP.
+func (s *Store) SetUserTOTPSecret(userID, secret string) error {
+	return s.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{
+			"totp_secret":    secret,
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error
+}
+
+// EnableUserTOTP turns on 2FA for the user, records the time step of the code
+// that confirmed enrollment, and replaces any recovery codes with codeHashes.
+func (s *Store) EnableUserTOTP(userID string, step int64, codeHashes []string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ? AND totp_secret <> ''", userID).
+			Updates(map[string]any{
+				"totp_enabled":   true,
+				"totp_last_step": step,
+			}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// DisableUserTOTP clears the user's TOTP secret and deletes their recovery codes.
+func (s *Store) DisableUserTOTP(userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{
+				"totp_secret":    "",
+				"totp_enabled":   false,
+				"totp_last_step": 0,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error
+	})
+}
+
+// ConsumeTOTPStep atomically records step as the user's last accepted TOTP
+// step. It returns false when the step is not newer than the stored one, so a
+// code can be used at most once even under concurrent logins.
+func (s *Store) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND totp_enabled = ? AND totp_last_step < ?", userID, true, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes deletes the user's recovery codes and stores codeHashes
+// in their place.
+func (s *Store) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// ConsumeRecoveryCode marks the matching unused recovery code as used. It
+// returns false when no unused code matches.
+func (s *Store) ConsumeRecoveryCode(userID, codeHash string, now time.Time) (bool, error) {
+	result := s.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes the user has left.
+func (s *Store) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteRecoveryCodesByUserID deletes all recovery codes for a user.
+func (s *Store) DeleteRecoveryCodesByUserID(userID string) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	codes := make([]models.UserRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.UserRecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// TokenFilter narrows admin token searches and bulk actions. Zero-valued
+// fields are ignored; set fields are ANDed together.
+type TokenFilter struct {
+	UserID        string
+	ClientID      string
+	Category      string
+	Status        string
+	Scope         string // matches one whole scope within the space-separated list
+	Resource      string // matches one RFC 8707 resource URI exactly
+	FamilyID      string
+	SessionID     string // login session (sid) the tokens were issued from
+	IssuedAfter   *time.Time
+	IssuedBefore  *time.Time
+	ExpiresAfter  *time.Time
+	ExpiresBefore *time.Time
+}
+
+// IsScoped reports whether the filter narrows tokens to a user, client,
+// rotation family, login session or time range. Status, category, scope and
+// resource alone still match tokens server-wide.
+func (f TokenFilter) IsScoped() bool {
+	return f.UserID != "" || f.ClientID != "" || f.FamilyID != "" || f.SessionID != "" ||
+		f.IssuedAfter != nil || f.IssuedBefore != nil ||
+		f.ExpiresAfter != nil || f.ExpiresBefore != nil
+}
@@ -37,6 +37,16 @@ func (s *Store) GetUserAuthorizationByUUID(
 	return &auth, nil
 }
 
+// GetUserAuthorizationByID retrieves an authorization by its primary key,
+// active or not
+func (s *Store) GetUserAuthorizationByID(id uint) (*models.UserAuthorization, error) {
+	var auth models.UserAuthorization
+	if err := s.db.First(&auth, id).Error; err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
 // UpsertUserAuthorization creates a new consent record or re-activates and updates an existing one.
 // Uses a single atomic INSERT ... ON CONFLICT DO UPDATE to avoid the race condition that arises
 // from a non-atomic SELECT-then-INSERT/UPDATE pattern.
@@ -51,8 +61,8 @@ func (s *Store) UpsertUserAuthorization(auth *models.UserAuthorization) error {
 			{Name: "application_id"},
 		},
 		DoUpdates: clause.AssignmentColumns([]string{
-			"uuid", "client_id", "scopes", "resource",
-			"granted_at", "revoked_at", "is_active", "updated_at",
+			"uuid", "client_id", "scopes", "declined_scopes", "resource",
+			"granted_at", "expires_at", "revoked_at", "is_active", "updated_at",
 		}),
 	}).Create(auth).Error
 }
@@ -110,6 +120,39 @@ func (s *Store) RevokeAllUserAuthorizationsByUserID(userID string) error {
 		}).Error
 }
 
+// ListExpiredUserAuthorizations returns up to limit active consent records
+// whose ExpiresAt is at or before now, oldest first.
+func (s *Store) ListExpiredUserAuthorizations(
+	now time.Time,
+	limit int,
+) ([]models.UserAuthorization, error) {
+	var auths []models.UserAuthorization
+	if err := s.db.Where("is_active = ? AND expires_at IS NOT NULL AND expires_at <= ?", true, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&auths).Error; err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+// ExpireUserAuthorization deactivates a consent record that has reached its
+// ExpiresAt. It reports false when the record was already inactive or has
+// since been renewed, so concurrent expirers (another replica, or a request
+// that found the record expired) act on it only once.
+func (s *Store) ExpireUserAuthorization(id uint, now time.Time) (bool, error) {
+	result := s.db.Model(&models.UserAuthorization{}).
+		Where("id = ? AND is_active = ? AND expires_at IS NOT NULL AND expires_at <= ?", id, true, now).
+		Updates(map[string]any{
+			"is_active":  false,
+			"revoked_at": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // GetClientAuthorizations returns all active consent records for a client, ordered by grant date
 func (s *Store) GetClientAuthorizations(clientID string) ([]models.UserAuthorization, error) {
 	var auths []models.UserAuthorization
@@ -73,6 +73,32 @@ func TestGetUserAuthorizationByUUID(t *testing.T) {
 	})
 }
 
+func TestGetUserAuthorizationByID(t *testing.T) {
+	store := createFreshStore(t, "sqlite", nil)
+	app := createTestApp(t, store)
+	userID := uuid.New().String()
+
+	auth := &models.UserAuthorization{
+		UUID:          uuid.New().String(),
+		UserID:        userID,
+		ApplicationID: app.ID,
+		ClientID:      app.ClientID,
+		Scopes:        "read",
+	}
+	require.NoError(t, store.UpsertUserAuthorization(auth))
+	_, err := store.RevokeUserAuthorization(auth.UUID, userID)
+	require.NoError(t, err)
+
+	// Revoked records are still found by ID.
+	retrieved, err := store.GetUserAuthorizationByID(auth.ID)
+	require.NoError(t, err)
+	assert.Equal(t, auth.UUID, retrieved.UUID)
+	assert.False(t, retrieved.IsActive)
+
+	_, err = store.GetUserAuthorizationByID(auth.ID + 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
 func TestListUserAuthorizations(t *testing.T) {
 	t.Run("returns_only_active", func(t *testing.T) {
 		store := createFreshStore(t, "sqlite", nil)
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-authgate/authgate/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateWebAuthnCredential stores a newly registered passkey and marks the
+// user as having one.
+func (s *Store) CreateWebAuthnCredential(cred *models.WebAuthnCredential) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cred).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ?", cred.UserID).
+			Update("webauthn_enabled", true).Error
+	})
+}
+
+// ListWebAuthnCredentials returns the user's passkeys, oldest first.
+func (s *Store) ListWebAuthnCredentials(userID string) ([]models.WebAuthnCredential, error) {
+	var creds []models.WebAuthnCredential
+	err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&creds).Error
+	return creds, err
+}
+
+// GetWebAuthnCredentialByCredentialID finds a passkey by the credential ID
+// the authenticator presented.
+func (s *Store) GetWebAuthnCredentialByCredentialID(
+	credentialID string,
+) (*models.WebAuthnCredential, error) {
+	var cred models.WebAuthnCredential
+	if err := s.db.Where("credential_id = ?", credentialID).First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// UpdateWebAuthnSignCount records a successful use of a passkey. The update
+// only applies while the stored counter still equals oldCount, so two
+// concurrent assertions cannot both advance it; it returns false otherwise.
+func (s *Store) UpdateWebAuthnSignCount(
+	id string,
+	oldCount, newCount int64,
+	usedAt time.Time,
+) (bool, error) {
+	result := s.db.Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND sign_count = ?", id, oldCount).
+		Updates(map[string]any{
+			"sign_count":   newCount,
+			"last_used_at": usedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's passkeys and clears the
+// user's passkey flag when it was the last one. It returns false when no
+// passkey with that ID belongs to the user.
+func (s *Store) DeleteWebAuthnCredential(userID, id string) (bool, error) {
+	var deleted bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).
+			Delete(&models.WebAuthnCredential{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected > 0
+		return syncWebAuthnEnabled(tx, userID)
+	})
+	return deleted, err
+}
+
+// DeleteWebAuthnCredentialsByUserID removes all of the user's passkeys.
+func (s *Store) DeleteWebAuthnCredentialsByUserID(userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).
+			Delete(&models.WebAuthnCredential{}).Error; err != nil {
+			return err
+		}
+		return syncWebAuthnEnabled(tx, userID)
+	})
+}
+
+// syncWebAuthnEnabled sets the user's passkey flag from the credentials left.
+func syncWebAuthnEnabled(tx *gorm.DB, userID string) error {
+	var remaining int64
+	if err := tx.Model(&models.WebAuthnCredential{}).
+		Where("user_id = ?", userID).
+		Count(&remaining).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("webauthn_enabled", remaining > 0).Error
+}
@@ -47,6 +47,7 @@ type NavbarProps struct {
 	IsAdmin             bool
 	ActiveLink          string      // e.g. "device", "sessions", "clients", "audit", "docs-<slug>"
 	PendingClientsCount int         // Badge count for admin → OAuth Clients link
+	PendingSignupsCount int         // Badge count for admin → Users link
 	DocsNavEntries      []DocsEntry // Docs dropdown entries, localized per the user's docs_lang cookie
 	SwaggerEnabled      bool        // Whether /swagger is registered; gates API dropdown / footer link
 }
@@ -102,7 +103,62 @@ type LoginPageProps struct {
 	Redirect          string
 	OAuthProviders    []OAuthProvider
 	RememberMeEnabled bool
-	RememberMeDays    int // Display label: "Remember me for N days"
+	RememberMeDays    int  // Display label: "Remember me for N days"
+	PasskeyEnabled    bool // WEBAUTHN_ENABLED: offer "Sign in with a passkey"
+	PasswordReset     bool // PASSWORD_RESET_ENABLED: show "Forgot password?"
+	SignupEnabled     bool // SIGNUP_ENABLED: show "Create an account"
+	Notice            string
+}
+
+// SignupPageProps contains properties for the self-service registration page
+type SignupPageProps struct {
+	BaseProps
+	NavbarProps
+	Username       string
+	Email          string
+	FullName       string
+	AllowedDomains []string // empty = any domain
+	MinLength      int
+	Sent           bool // signup accepted; show the "check your inbox" message
+	Error          string
+}
+
+// SAMLPostPageProps contains properties for the page that auto-submits a
+// SAML message with the HTTP-POST binding
+type SAMLPostPageProps struct {
+	Message    string // Shown while the form submits
+	Action     string // Form target
+	FieldName  string // "SAMLRequest" or "SAMLResponse"
+	FieldValue string
+	RelayState string
+	Resume     bool // Adds resumed=1 so the ACS does not bounce the response again
+}
+
+// PasswordForgotPageProps contains properties for the "forgot password" page
+type PasswordForgotPageProps struct {
+	BaseProps
+	NavbarProps
+	Sent  bool // request accepted; show the "check your inbox" message
+	Error string
+}
+
+// PasswordResetPageProps contains properties for the "choose a new password" page
+type PasswordResetPageProps struct {
+	BaseProps
+	NavbarProps
+	Token     string
+	Invalid   bool // the link is unknown, used or expired; offer a new one
+	MinLength int
+	Error     string
+}
+
+// LoginTwoFactorPageProps contains properties for the second login step
+type LoginTwoFactorPageProps struct {
+	BaseProps
+	NavbarProps
+	CodeEnabled    bool // user has an authenticator app; show the code form
+	PasskeyEnabled bool // user has a passkey; offer to use it
+	Error          string
 }
 
 // DevicePageProps contains properties for the device authorization page
@@ -118,7 +174,11 @@ type DevicePageProps struct {
 	// authorize access to — without this surface, the audience binding
 	// would not be user-consented in any meaningful way.
 	Resource []string
-	Error    string
+	// Prefilled is set when UserCode came from verification_uri_complete and
+	// was found. The page then asks the user to confirm that it matches the
+	// code on their device instead of offering a free-text input.
+	Prefilled bool
+	Error     string
 }
 
 // DeviceConfirmPageProps contains properties for the device confirmation
@@ -146,6 +206,12 @@ type SessionsPageProps struct {
 	PageSize       int
 	StatusFilter   string
 	CategoryFilter string
+	// CurrentSessionID is the login session ID of the browser viewing the page,
+	// used to mark tokens issued from this sign-in.
+	CurrentSessionID string
+	// FamilyUsage lists clients with an active sign-in (refresh token family)
+	// limit on which the user holds refresh tokens.
+	FamilyUsage []services.RefreshFamilyUsage
 }
 
 // HasActiveFilters returns true if any search or filter is applied.
@@ -153,6 +219,80 @@ func (p SessionsPageProps) HasActiveFilters() bool {
 	return p.Search != "" || p.StatusFilter != "" || p.CategoryFilter != ""
 }
 
+// PersonalTokenExpiryOption is one choice in the personal access token
+// expiry dropdown. Value is a Go duration string posted back as expires_in.
+type PersonalTokenExpiryOption struct {
+	Value string
+	Label string
+}
+
+// PersonalTokensPageProps contains properties for the personal access tokens page
+type PersonalTokensPageProps struct {
+	BaseProps
+	NavbarProps
+	Enabled         bool
+	Tokens          []models.AccessToken
+	Pagination      store.PaginationResult
+	PageSize        int
+	AvailableScopes []string
+	ExpiryOptions   []PersonalTokenExpiryOption
+	NewToken        *models.AccessToken // set only on the response to creation; RawToken is shown once
+	Error           string
+	Success         string
+}
+
+// TwoFactorSetup holds the enrollment details shown while setting up TOTP
+type TwoFactorSetup struct {
+	Secret    string // Base32 secret for manual entry
+	URI       string // otpauth:// provisioning URI
+	QRCodeSVG string // QR code of URI; empty if it could not be encoded
+}
+
+// PasskeyItem is one registered passkey on the account security page
+type PasskeyItem struct {
+	ID         string
+	Name       string
+	Synced     bool   // backup-eligible, e.g. kept in a password manager or cloud keychain
+	CreatedAt  string // formatted
+	LastUsedAt string // formatted; empty if never used
+}
+
+// SecurityPageProps contains properties for the account security page
+type SecurityPageProps struct {
+	BaseProps
+	NavbarProps
+	TwoFactorEnabled  bool
+	TwoFactorAllowed  bool // false for external users, who authenticate elsewhere
+	TwoFactorRequired bool // TOTP_REQUIRED applies to this user
+	HasSecondFactor   bool // any enrolled factor satisfies TOTP_REQUIRED
+	TOTPRemovable     bool // turning TOTP off would not break TOTP_REQUIRED
+	RecoveryCodesLeft int64
+	Setup             *TwoFactorSetup // set while enrolling
+	RecoveryCodes     []string        // set only right after they are generated; shown once
+	PasskeysEnabled   bool            // WEBAUTHN_ENABLED
+	Passkeys          []PasskeyItem
+	PasskeyRemovable  bool // removing a passkey would not break TOTP_REQUIRED
+	Error             string
+	Success           string
+}
+
+// ProfilePageProps contains properties for the account profile page
+type ProfilePageProps struct {
+	BaseProps
+	NavbarProps
+	User               *models.User
+	FullName           string // form value; differs from User.FullName after a failed edit
+	AvatarURL          string // form value; differs from User.AvatarURL after a failed edit
+	Editable           bool   // false for external users, whose provider owns the profile
+	HasPassword        bool   // false for accounts created through OAuth
+	EmailChangeEnabled bool   // EMAIL_CHANGE_ENABLED
+	MinLength          int
+	Connections        []models.OAuthConnection
+	CanUnlink          bool // unlinking one connection leaves a way to sign in
+	Error              string
+	Success            string
+}
+
 // ClientsPageProps contains properties for the admin clients page
 type ClientsPageProps struct {
 	BaseProps
@@ -174,6 +314,7 @@ type ClientDisplay struct {
 	Description                 string
 	UserID                      string
 	Scopes                      string
+	RequiredScopes              string // Scopes users cannot deselect on the consent page
 	GrantTypes                  string
 	RedirectURIs                string // Comma-separated string
 	AllowedResources            string // Comma-separated RFC 8707 allowlist (admin-managed)
@@ -183,6 +324,15 @@ type ClientDisplay struct {
 	EnableClientCredentialsFlow bool
 	Status                      string // "pending", "active", "inactive"
 	TokenProfile                string // "short", "standard", or "long"
+	TokenFormat                 string // "jwt", "opaque", or empty (inherit global TOKEN_FORMAT)
+	MaxRefreshFamilies          int    // Active refresh token families per user; 0 inherits profile / global limit
+	RefreshFamilyPolicy         string // "evict_oldest", "reject", or empty (inherit REFRESH_FAMILY_LIMIT_POLICY)
+	DeviceUserCodeCharset       string // "alphanumeric", "digits", "letters", or empty (alphanumeric)
+	DeviceUserCodeLength        int    // User code length; 0 uses the default (8)
+	DeviceCodeLifetime          int    // Seconds; 0 inherits DEVICE_CODE_EXPIRATION
+	DevicePollingInterval       int    // Seconds; 0 inherits POLLING_INTERVAL
+	ConsentLifetimeDays         int    // Days before user consent expires; 0 inherits CONSENT_LIFETIME
+	Trusted                     bool   // First-party client that skips the consent page
 	Project                     string // Optional; emitted as JWT "project" claim
 	ServiceAccount              string // Optional; emitted as JWT "service_account" claim
 	CreatedAt                   time.Time
@@ -236,8 +386,8 @@ type AuthorizePageProps struct {
 	ClientName          string
 	ClientDescription   string
 	RedirectURI         string
-	Scopes              string   // Space-separated scope string
-	ScopeList           []string // Pre-split scope list for template iteration
+	Scopes              string         // Space-separated scope string
+	ScopeList           []ConsentScope // Requested scopes in request order
 	State               string
 	Nonce               string
 	CodeChallenge       string
@@ -249,6 +399,13 @@ type AuthorizePageProps struct {
 	Error    string
 }
 
+// ConsentScope is one requested scope on the consent page
+type ConsentScope struct {
+	Name     string
+	Required bool // Essential or client-required; always granted, no checkbox
+	Checked  bool // Initial state of an optional scope's checkbox
+}
+
 // AuthorizationDisplay is a view model for a single user authorization entry
 type AuthorizationDisplay struct {
 	UUID       string
@@ -262,6 +419,7 @@ type AuthorizationDisplay struct {
 	// access to.
 	Resource  []string
 	GrantedAt time.Time
+	ExpiresAt *time.Time // Nil when the consent never expires
 	IsActive  bool
 }
 
@@ -399,8 +557,9 @@ type UsersPageProps struct {
 	Search           string
 	PageSize         int
 	Success          string
-	RoleFilter       string // "admin", "user", or "" for all
-	AuthSourceFilter string // "local", "http_api", or "" for all
+	RoleFilter       string        // "admin", "user", or "" for all
+	AuthSourceFilter string        // "local", "http_api", or "" for all
+	PendingSignups   []models.User // self-registered users awaiting approval
 }
 
 // UserDetailPageProps contains properties for the admin user detail page
@@ -440,19 +599,37 @@ type DashboardPageProps struct {
 	Stats services.DashboardStats
 }
 
+// TokenFamilyPageProps contains properties for the admin token family timeline
+type TokenFamilyPageProps struct {
+	BaseProps
+	NavbarProps
+	Lineage *services.TokenFamilyLineage
+	TokenID string // the token the page was opened from, highlighted in the timeline
+}
+
 // TokensPageProps contains properties for the admin tokens page
 type TokensPageProps struct {
 	BaseProps
 	NavbarProps
-	Tokens         []services.TokenWithUser
-	Pagination     store.PaginationResult
-	Search         string
-	PageSize       int
-	StatusFilter   string
-	CategoryFilter string
-	Success        string
-	Warning        string
-	Now            time.Time
+	Tokens          []services.TokenWithUser
+	Pagination      store.PaginationResult
+	Search          string
+	PageSize        int
+	StatusFilter    string
+	CategoryFilter  string
+	AdvancedFilters map[string]string // raw advanced search inputs, keyed by query param
+	BulkAvailable   bool              // at least one filter is set, so bulk actions are allowed
+	BulkPreview     *TokenBulkPreview // dry-run result awaiting confirmation
+	Success         string
+	Warning         string
+	Error           string
+	Now             time.Time
+}
+
+// TokenBulkPreview is the dry-run count shown before a bulk token action is confirmed.
+type TokenBulkPreview struct {
+	Action string // "revoke" or "disable"
+	Count  int64
 }
 
 // UserOAuthConnectionsPageProps contains properties for the admin user OAuth connections page
@@ -1,19 +1,21 @@
 /**
  * Device Code Auto-Formatting
- * Formats user code input to XXXX-XXXX pattern
+ * Formats user code input into dash-separated groups of four (XXXX-XXXX).
+ * Codes are 6–12 characters depending on the client; the server ignores
+ * dashes, so the grouping is only a typing aid.
  */
 document.addEventListener('DOMContentLoaded', function() {
   const codeInput = document.getElementById('user_code');
+  const maxCodeLength = 12;
 
   if (codeInput) {
     codeInput.addEventListener('input', function(e) {
-      let value = e.target.value.toUpperCase().replace(/[^A-Z0-9]/g, '');
+      const value = e.target.value
+        .toUpperCase()
+        .replace(/[^A-Z0-9]/g, '')
+        .slice(0, maxCodeLength);
 
-      if (value.length > 4) {
-        value = value.slice(0, 4) + '-' + value.slice(4, 8);
-      }
-
-      e.target.value = value;
+      e.target.value = value.match(/.{1,4}/g)?.join('-') ?? '';
     });
 
     // Auto-focus on page load
@@ -10,6 +10,7 @@ import {
   toggleFilters
 } from './utils.js';
 import './code-formatter.js';
+import './webauthn.js';
 
 // Expose functions globally so HTML onclick attributes can access them
 window.toggleMenu = toggleMenu;
@@ -0,0 +1,185 @@
+/**
+ * Passkeys (WebAuthn)
+ * Drives the passkey buttons marked with data-passkey-action:
+ *   register - add a passkey on the account security page
+ *   login    - passwordless sign-in on the login page
+ *   verify   - second factor on the two-factor page
+ * Elements marked data-passkey-supported are shown only when the browser
+ * supports WebAuthn; data-passkey-unsupported elements only when it does not.
+ * The server exchanges binary fields as unpadded base64url strings.
+ */
+import { showNotification } from './utils.js';
+
+var PASSKEY_ENDPOINTS = {
+  register: { options: '/account/security/passkeys/options', finish: '/account/security/passkeys' },
+  login: { options: '/login/passkey/options', finish: '/login/passkey' },
+  verify: { options: '/login/2fa/passkey/options', finish: '/login/2fa/passkey' }
+};
+
+function passkeysSupported() {
+  return !!(window.PublicKeyCredential && navigator.credentials && navigator.credentials.create);
+}
+
+function base64urlToBuffer(value) {
+  var base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  while (base64.length % 4) {
+    base64 += '=';
+  }
+  var binary = atob(base64);
+  var bytes = new Uint8Array(binary.length);
+  for (var i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+function bufferToBase64url(buffer) {
+  var bytes = new Uint8Array(buffer);
+  var binary = '';
+  for (var i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+/**
+ * POST a JSON body and resolve with the parsed JSON response. Non-2xx
+ * responses reject with the server's {error, redirect} body.
+ */
+function postJSON(url, body, csrfToken) {
+  var headers = { 'Content-Type': 'application/json', 'Accept': 'application/json' };
+  if (csrfToken) {
+    headers['X-CSRF-Token'] = csrfToken;
+  }
+  return fetch(url, {
+    method: 'POST',
+    credentials: 'same-origin',
+    headers: headers,
+    body: JSON.stringify(body || {})
+  }).then(function(response) {
+    return response.json().catch(function() {
+      return {};
+    }).then(function(data) {
+      if (!response.ok) {
+        throw { message: data.error || 'Request failed. Please try again.', redirect: data.redirect };
+      }
+      return data;
+    });
+  });
+}
+
+function creationOptionsFromJSON(options) {
+  var publicKey = Object.assign({}, options);
+  publicKey.challenge = base64urlToBuffer(options.challenge);
+  publicKey.user = Object.assign({}, options.user, { id: base64urlToBuffer(options.user.id) });
+  publicKey.excludeCredentials = (options.excludeCredentials || []).map(function(cred) {
+    return Object.assign({}, cred, { id: base64urlToBuffer(cred.id) });
+  });
+  return publicKey;
+}
+
+function requestOptionsFromJSON(options) {
+  var publicKey = Object.assign({}, options);
+  publicKey.challenge = base64urlToBuffer(options.challenge);
+  publicKey.allowCredentials = (options.allowCredentials || []).map(function(cred) {
+    return Object.assign({}, cred, { id: base64urlToBuffer(cred.id) });
+  });
+  return publicKey;
+}
+
+function registrationToJSON(credential) {
+  var response = credential.response;
+  return {
+    id: credential.id,
+    type: credential.type,
+    response: {
+      clientDataJSON: bufferToBase64url(response.clientDataJSON),
+      attestationObject: bufferToBase64url(response.attestationObject),
+      transports: typeof response.getTransports === 'function' ? response.getTransports() : []
+    }
+  };
+}
+
+function assertionToJSON(credential) {
+  var response = credential.response;
+  return {
+    id: credential.id,
+    type: credential.type,
+    response: {
+      clientDataJSON: bufferToBase64url(response.clientDataJSON),
+      authenticatorData: bufferToBase64url(response.authenticatorData),
+      signature: bufferToBase64url(response.signature),
+      userHandle: response.userHandle ? bufferToBase64url(response.userHandle) : ''
+    }
+  };
+}
+
+/**
+ * Run one passkey ceremony for the clicked button and follow the redirect
+ * the server returns.
+ */
+function runPasskeyAction(button) {
+  var action = button.getAttribute('data-passkey-action');
+  var endpoints = PASSKEY_ENDPOINTS[action];
+  if (!endpoints) return;
+  var csrfToken = button.getAttribute('data-csrf-token');
+
+  button.disabled = true;
+  postJSON(endpoints.options, {}, csrfToken).then(function(options) {
+    if (action === 'register') {
+      return navigator.credentials.create({ publicKey: creationOptionsFromJSON(options) })
+        .then(function(credential) {
+          var nameInput = document.getElementById(button.getAttribute('data-passkey-name'));
+          return postJSON(endpoints.finish, {
+            name: nameInput ? nameInput.value : '',
+            credential: registrationToJSON(credential)
+          }, csrfToken);
+        });
+    }
+    return navigator.credentials.get({ publicKey: requestOptionsFromJSON(options) })
+      .then(function(credential) {
+        var rememberMe = document.getElementById('remember_me');
+        return postJSON(endpoints.finish, {
+          credential: assertionToJSON(credential),
+          redirect: button.getAttribute('data-passkey-redirect') || '',
+          remember_me: !!(rememberMe && rememberMe.checked)
+        }, csrfToken);
+      });
+  }).then(function(result) {
+    window.location.href = result.redirect || '/';
+  }).catch(function(err) {
+    button.disabled = false;
+    if (err && err.redirect) {
+      window.location.href = err.redirect;
+      return;
+    }
+    // The user dismissed the browser prompt or it timed out.
+    if (err && err.name === 'NotAllowedError') {
+      showNotification('Passkey request was cancelled.', 'warning');
+      return;
+    }
+    if (err && err.name === 'InvalidStateError') {
+      showNotification('This passkey is already registered.', 'warning');
+      return;
+    }
+    showNotification((err && err.message) || 'Passkey request failed.', 'error');
+  });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+  var supported = passkeysSupported();
+  document.querySelectorAll('[data-passkey-supported]').forEach(function(el) {
+    el.hidden = !supported;
+  });
+  document.querySelectorAll('[data-passkey-unsupported]').forEach(function(el) {
+    el.hidden = supported;
+  });
+  if (!supported) return;
+
+  document.addEventListener('click', function(e) {
+    var button = e.target.closest('[data-passkey-action]');
+    if (!button || button.disabled) return;
+    e.preventDefault();
+    runPasskeyAction(button);
+  });
+});
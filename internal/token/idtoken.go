@@ -47,6 +47,12 @@ func (p *LocalTokenProvider) GenerateIDToken(params IDTokenParams) (string, erro
 	if params.AtHash != "" {
 		claims["at_hash"] = params.AtHash
 	}
+	if params.SessionID != "" {
+		claims["sid"] = params.SessionID
+	}
+	if len(params.AuthMethods) > 0 {
+		claims["amr"] = params.AuthMethods
+	}
 
 	// Profile claims
 	if params.Name != "" {
@@ -61,6 +67,15 @@ func (p *LocalTokenProvider) GenerateIDToken(params IDTokenParams) (string, erro
 	if params.UpdatedAt != nil {
 		claims["updated_at"] = params.UpdatedAt.Unix()
 	}
+	if len(params.Roles) > 0 {
+		claims["roles"] = params.Roles
+	}
+	if len(params.Groups) > 0 {
+		claims["groups"] = params.Groups
+	}
+	if len(params.Attributes) > 0 {
+		claims["attributes"] = params.Attributes
+	}
 
 	// Email claims
 	if params.Email != "" {
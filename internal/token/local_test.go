@@ -564,6 +564,50 @@ func TestGenerateIDToken_WithoutNonce_NoClaim(t *testing.T) {
 	assert.False(t, hasNonce, "nonce claim must be absent when not provided")
 }
 
+func TestGenerateIDToken_WithSessionID(t *testing.T) {
+	provider, _ := testIDTokenProvider(t)
+
+	idTokenStr, err := provider.GenerateIDToken(IDTokenParams{
+		Issuer:    "http://localhost:8080",
+		Subject:   "user-abc",
+		Audience:  "client-xyz",
+		AuthTime:  time.Now(),
+		SessionID: "login-session-1",
+	})
+
+	require.NoError(t, err)
+
+	claims := parseIDTokenClaims(t, provider, idTokenStr)
+	assert.Equal(t, "login-session-1", claims["sid"])
+}
+
+func TestGenerateIDToken_AuthMethods(t *testing.T) {
+	provider, _ := testIDTokenProvider(t)
+
+	idTokenStr, err := provider.GenerateIDToken(IDTokenParams{
+		Issuer:      "http://localhost:8080",
+		Subject:     "user-abc",
+		Audience:    "client-xyz",
+		AuthTime:    time.Now(),
+		AuthMethods: []string{"pwd", "otp", "mfa"},
+	})
+	require.NoError(t, err)
+
+	claims := parseIDTokenClaims(t, provider, idTokenStr)
+	assert.Equal(t, []any{"pwd", "otp", "mfa"}, claims["amr"])
+
+	// Omitted when the login recorded no methods
+	idTokenStr, err = provider.GenerateIDToken(IDTokenParams{
+		Issuer:   "http://localhost:8080",
+		Subject:  "user-abc",
+		Audience: "client-xyz",
+		AuthTime: time.Now(),
+	})
+	require.NoError(t, err)
+	_, hasAMR := parseIDTokenClaims(t, provider, idTokenStr)["amr"]
+	assert.False(t, hasAMR)
+}
+
 func TestGenerateIDToken_WithAtHash(t *testing.T) {
 	provider, _ := testIDTokenProvider(t)
 	accessToken := "some.access.token.string"
@@ -596,6 +640,9 @@ func TestGenerateIDToken_ProfileClaims(t *testing.T) {
 		PreferredUsername: "janedoe",
 		Picture:           "https://example.com/avatar.jpg",
 		UpdatedAt:         &updatedAt,
+		Roles:             []string{"editor"},
+		Groups:            []string{"staff", "ops"},
+		Attributes:        map[string]any{"department": "R&D"},
 	})
 
 	require.NoError(t, err)
@@ -605,6 +652,9 @@ func TestGenerateIDToken_ProfileClaims(t *testing.T) {
 	assert.Equal(t, "janedoe", claims["preferred_username"])
 	assert.Equal(t, "https://example.com/avatar.jpg", claims["picture"])
 	assert.InDelta(t, float64(updatedAt.Unix()), claims["updated_at"].(float64), 1)
+	assert.Equal(t, []any{"editor"}, claims["roles"])
+	assert.Equal(t, []any{"staff", "ops"}, claims["groups"])
+	assert.Equal(t, map[string]any{"department": "R&D"}, claims["attributes"])
 }
 
 func TestGenerateIDToken_EmailClaims(t *testing.T) {
@@ -643,9 +693,13 @@ func TestGenerateIDToken_NoProfileClaims_WhenEmpty(t *testing.T) {
 	_, hasName := claims["name"]
 	_, hasEmail := claims["email"]
 	_, hasPicture := claims["picture"]
+	_, hasRoles := claims["roles"]
+	_, hasGroups := claims["groups"]
 	assert.False(t, hasName)
 	assert.False(t, hasEmail)
 	assert.False(t, hasPicture)
+	assert.False(t, hasRoles)
+	assert.False(t, hasGroups)
 }
 
 // ============================================================
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-authgate/authgate/internal/util"
+)
+
+// OpaqueTokenPrefix marks AuthGate-issued opaque (reference) access tokens.
+// JWTs always start with "eyJ" (base64url of `{"`), so the prefix is an
+// unambiguous discriminator and also makes leaked tokens easy for secret
+// scanners to recognise.
+const OpaqueTokenPrefix = "agat_"
+
+// PersonalAccessTokenPrefix marks user-created personal access tokens. They
+// share the opaque token shape but carry their own prefix so secret scanners
+// and log filters can tell a long-lived personal credential from a
+// short-lived OAuth access token.
+const PersonalAccessTokenPrefix = "agpat_"
+
+// opaqueTokenBytes is the amount of entropy in an opaque token (256 bits).
+const opaqueTokenBytes = 32
+
+// opaqueEncoding is lowercased base32 without padding, matching the shape of
+// generated client secrets.
+var opaqueEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").
+	WithPadding(base32.NoPadding)
+
+// GenerateOpaqueToken creates a random reference access token that carries
+// no claims. The caller persists only its SHA-256 hash; resource servers
+// resolve it through /oauth/introspect.
+func GenerateOpaqueToken(expiresAt time.Time) (*Result, error) {
+	return generatePrefixedToken(OpaqueTokenPrefix, expiresAt)
+}
+
+// GeneratePersonalAccessToken creates a random personal access token. Like
+// opaque access tokens it carries no claims and is resolved from its stored
+// hash.
+func GeneratePersonalAccessToken(expiresAt time.Time) (*Result, error) {
+	return generatePrefixedToken(PersonalAccessTokenPrefix, expiresAt)
+}
+
+func generatePrefixedToken(prefix string, expiresAt time.Time) (*Result, error) {
+	b, err := util.CryptoRandomBytes(opaqueTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenGeneration, err)
+	}
+	return &Result{
+		TokenString: prefix + opaqueEncoding.EncodeToString(b),
+		TokenType:   TokenTypeBearer,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// IsOpaqueToken reports whether tokenString has the opaque token shape.
+func IsOpaqueToken(tokenString string) bool {
+	return strings.HasPrefix(tokenString, OpaqueTokenPrefix)
+}
+
+// IsPersonalAccessToken reports whether tokenString has the personal access
+// token shape.
+func IsPersonalAccessToken(tokenString string) bool {
+	return strings.HasPrefix(tokenString, PersonalAccessTokenPrefix)
+}
@@ -0,0 +1,52 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOpaqueToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	first, err := GenerateOpaqueToken(expiresAt)
+	require.NoError(t, err)
+	second, err := GenerateOpaqueToken(expiresAt)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first.TokenString, OpaqueTokenPrefix))
+	assert.NotEqual(t, first.TokenString, second.TokenString, "tokens must be random")
+	assert.Equal(t, TokenTypeBearer, first.TokenType)
+	assert.Equal(t, expiresAt, first.ExpiresAt)
+	assert.Empty(t, first.Claims, "opaque tokens carry no claims")
+	assert.True(t, IsOpaqueToken(first.TokenString))
+}
+
+func TestIsOpaqueToken_RejectsJWT(t *testing.T) {
+	p, err := NewLocalTokenProvider(extraClaimsTestConfig())
+	require.NoError(t, err)
+
+	res, err := p.GenerateToken(t.Context(), "user", "client", "read", 0, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, IsOpaqueToken(res.TokenString))
+	assert.False(t, IsOpaqueToken(""))
+}
+
+func TestGeneratePersonalAccessToken(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	res, err := GeneratePersonalAccessToken(expiresAt)
+	require.NoError(t, err)
+
+	assert.True(t, IsPersonalAccessToken(res.TokenString))
+	assert.False(t, IsOpaqueToken(res.TokenString), "distinct prefix from OAuth opaque tokens")
+	assert.Equal(t, TokenTypeBearer, res.TokenType)
+	assert.Equal(t, expiresAt, res.ExpiresAt)
+
+	opaque, err := GenerateOpaqueToken(expiresAt)
+	require.NoError(t, err)
+	assert.False(t, IsPersonalAccessToken(opaque.TokenString))
+}
@@ -13,6 +13,7 @@ func TestBuildReservedClaimKeys_DefaultPrefix(t *testing.T) {
 		"iss", "sub", "aud", "exp", "nbf", "iat", "jti",
 		"type", "scope", "user_id", "client_id",
 		"azp", "amr", "acr", "auth_time", "nonce", "at_hash",
+		"sid",
 	}
 	for _, k := range staticKeys {
 		if _, ok := got[k]; !ok {
@@ -1,10 +1,14 @@
 package util
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -85,3 +89,57 @@ func SHA256Hex(s string) string {
 	sum := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(sum[:])
 }
+
+// ErrSealedDataInvalid is returned by OpenWithKey when the ciphertext is
+// truncated, tampered with, or was sealed under a different key.
+var ErrSealedDataInvalid = errors.New("sealed data is invalid")
+
+// DeriveSealKey derives a 256-bit AES key with HKDF-SHA256 from a per-item
+// secret (e.g. a bearer token), a server-side secret used as salt, and a
+// label that separates one use of the key from another. Both secrets are
+// required to recompute the key, so it never matches the SHA256Hex lookup
+// hash of the per-item secret that sits next to the sealed data.
+func DeriveSealKey(serverSecret, label, secret string) ([]byte, error) {
+	return hkdf.Key(sha256.New, []byte(secret), []byte(serverSecret), label, 32)
+}
+
+// sealCipher builds an AES-256-GCM AEAD from a key from DeriveSealKey.
+func sealCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealWithKey encrypts plaintext under key, returning nonce||ciphertext.
+// Only a holder of the same key can open the result, which lets short-lived
+// data be parked in shared caches without exposing it.
+func SealWithKey(key, plaintext []byte) ([]byte, error) {
+	aead, err := sealCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := CryptoRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// OpenWithKey reverses SealWithKey.
+func OpenWithKey(key, sealed []byte) ([]byte, error) {
+	aead, err := sealCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrSealedDataInvalid
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrSealedDataInvalid
+	}
+	return plaintext, nil
+}
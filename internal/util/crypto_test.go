@@ -1,6 +1,7 @@
 package util
 
 import (
+	"encoding/hex"
 	"os"
 	"path/filepath"
 	"testing"
@@ -220,3 +221,64 @@ func TestHashToken(t *testing.T) {
 		}
 	})
 }
+
+func TestDeriveSealKey(t *testing.T) {
+	const token = "refresh-token-secret"
+	key, err := DeriveSealKey("server-secret", "label", token)
+	require.NoError(t, err)
+	require.Len(t, key, 32)
+
+	// The key must not be recoverable from the token's stored lookup hash.
+	assert.NotEqual(t, SHA256Hex(token), hex.EncodeToString(key))
+
+	again, err := DeriveSealKey("server-secret", "label", token)
+	require.NoError(t, err)
+	assert.Equal(t, key, again, "deterministic")
+
+	for name, args := range map[string][3]string{
+		"server secret": {"other-server-secret", "label", token},
+		"label":         {"server-secret", "other-label", token},
+		"token":         {"server-secret", "label", "other-token"},
+	} {
+		other, err := DeriveSealKey(args[0], args[1], args[2])
+		require.NoError(t, err)
+		assert.NotEqualf(t, key, other, "different %s", name)
+	}
+}
+
+func TestSealWithKey_RoundTrip(t *testing.T) {
+	key, err := DeriveSealKey("server-secret", "label", "refresh-token-secret")
+	require.NoError(t, err)
+	sealed, err := SealWithKey(key, []byte("payload"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "payload")
+
+	plain, err := OpenWithKey(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(plain))
+
+	// Sealing twice yields different ciphertexts (random nonce).
+	again, err := SealWithKey(key, []byte("payload"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+}
+
+func TestOpenWithKey_Rejects(t *testing.T) {
+	keyA, err := DeriveSealKey("server-secret", "label", "secret-a")
+	require.NoError(t, err)
+	keyB, err := DeriveSealKey("server-secret", "label", "secret-b")
+	require.NoError(t, err)
+	sealed, err := SealWithKey(keyA, []byte("payload"))
+	require.NoError(t, err)
+
+	_, err = OpenWithKey(keyB, sealed)
+	require.ErrorIs(t, err, ErrSealedDataInvalid, "wrong key")
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = OpenWithKey(keyA, tampered)
+	require.ErrorIs(t, err, ErrSealedDataInvalid, "tampered ciphertext")
+
+	_, err = OpenWithKey(keyA, []byte("short"))
+	require.ErrorIs(t, err, ErrSealedDataInvalid, "truncated input")
+}
@@ -0,0 +1,368 @@
+// Package webauthn implements the relying party side of WebAuthn registration
+// and authentication ceremonies for passkeys and security keys.
+//
+// The package builds the ceremony options the browser script consumes and
+// hands the browser's responses to github.com/go-webauthn/webauthn/protocol,
+// which parses the CBOR attestation objects and COSE keys and verifies
+// client data, authenticator data and signatures.
+//
+// Attestation is not requested ("none" conveyance), so credentials are
+// trusted on first use, the same as most consumer passkey deployments.
+// Binary fields travel between browser and server as unpadded base64url.
+package webauthn
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// Timeout is how long the browser waits for the user, advertised in the
+// ceremony options.
+const Timeout = 2 * time.Minute
+
+// User verification requirements for ceremony options.
+const (
+	UserVerificationRequired  = "required"
+	UserVerificationPreferred = "preferred"
+)
+
+var (
+	// ErrInvalidResponse is returned when a browser response is malformed or
+	// fails verification.
+	ErrInvalidResponse = errors.New("webauthn: invalid response")
+	// ErrUserNotVerified is returned when user verification was required but
+	// the authenticator did not perform it.
+	ErrUserNotVerified = errors.New("webauthn: user verification required")
+	// ErrUnsupportedKey is returned for credential public keys that cannot be
+	// used to verify assertions.
+	ErrUnsupportedKey = errors.New("webauthn: unsupported public key")
+)
+
+var b64 = base64.RawURLEncoding
+
+// credentialParameters lists the algorithms offered to authenticators, in
+// order of preference. Registrations using any other algorithm are refused.
+var credentialParameters = []protocol.CredentialParameter{
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgES256},
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgEdDSA},
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgRS256},
+}
+
+// RelyingParty identifies AuthGate to authenticators. ID is the domain
+// credentials are scoped to; Origins lists the exact origins
+// (scheme://host[:port]) allowed to run ceremonies.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// NewChallenge returns a random base64url challenge.
+func NewChallenge() (string, error) {
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		return "", err
+	}
+	return challenge.String(), nil
+}
+
+// EncodeID returns the base64url form of a credential or user ID.
+func EncodeID(id []byte) string {
+	return b64.EncodeToString(id)
+}
+
+// UserEntity describes the account a credential is created for.
+type UserEntity struct {
+	ID          string `json:"id"` // base64url user handle
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialDescriptor names an existing credential.
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url credential ID
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor returns a public-key descriptor for a stored
+// credential.
+func NewCredentialDescriptor(id string, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{
+		Type:       string(protocol.PublicKeyCredentialType),
+		ID:         id,
+		Transports: transports,
+	}
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	RequireResident  bool   `json:"requireResidentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is PublicKeyCredentialCreationOptions as JSON.
+type CreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     rpEntity                       `json:"rp"`
+	User                   UserEntity                     `json:"user"`
+	PubKeyCredParams       []protocol.CredentialParameter `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor         `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection         `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// RequestOptions is PublicKeyCredentialRequestOptions as JSON.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions returns the options for registering a new discoverable
+// credential (passkey) for user. exclude lists the user's existing
+// credentials so the same authenticator is not registered twice.
+func (rp *RelyingParty) CreationOptions(
+	challenge string,
+	user UserEntity,
+	exclude []CredentialDescriptor,
+) CreationOptions {
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return CreationOptions{
+		Challenge:          challenge,
+		RP:                 rpEntity{ID: rp.ID, Name: rp.Name},
+		User:               user,
+		PubKeyCredParams:   credentialParameters,
+		Timeout:            Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: UserVerificationPreferred,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the options for an authentication ceremony. An
+// empty allow list lets the user pick any discoverable credential for this
+// relying party.
+func (rp *RelyingParty) RequestOptions(
+	challenge string,
+	allow []CredentialDescriptor,
+	userVerification string,
+) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          Timeout.Milliseconds(),
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}
+}
+
+// RegistrationResponse is the JSON form of the PublicKeyCredential returned
+// by navigator.credentials.create().
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential returned by
+// navigator.credentials.get().
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a newly registered credential.
+type Credential struct {
+	ID             string // base64url credential ID
+	PublicKey      []byte // COSE_Key
+	SignCount      uint32
+	Transports     []string
+	AAGUID         []byte
+	UserVerified   bool
+	BackupEligible bool
+}
+
+// Assertion is the verified result of an authentication ceremony.
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackupState  bool
+}
+
+// VerifyRegistration verifies the response to a registration ceremony
+// started with challenge and returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(
+	challenge string,
+	resp *RegistrationResponse,
+	requireUserVerification bool,
+) (*Credential, error) {
+	if challenge == "" {
+		return nil, fmt.Errorf("%w: no challenge", ErrInvalidResponse)
+	}
+	raw := protocol.CredentialCreationResponse{}
+	raw.ID, raw.Type = resp.ID, resp.Type
+	att, fields := &raw.AttestationResponse, resp.Response
+	var d fieldDecoder
+	raw.RawID = d.decode("id", resp.ID)
+	att.ClientDataJSON = d.decode("clientDataJSON", fields.ClientDataJSON)
+	att.AttestationObject = d.decode("attestationObject", fields.AttestationObject)
+	att.Transports = fields.Transports
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	parsed, err := raw.Parse()
+	if err != nil {
+		return nil, invalidResponse(err)
+	}
+	// User verification is checked below so that its absence can be told
+	// apart from a malformed response.
+	if _, err := parsed.Verify(
+		challenge, rp.ID, rp.Origins, nil, nil,
+		protocol.TopOriginExplicitVerificationMode,
+		false, false, true,
+		nil, credentialParameters,
+		protocol.AttestationPolicy{}, protocol.SignaturePolicy{},
+	); err != nil {
+		return nil, invalidResponse(err)
+	}
+
+	authData := parsed.Response.AttestationObject.AuthData
+	if requireUserVerification && !authData.Flags.HasUserVerified() {
+		return nil, ErrUserNotVerified
+	}
+	if _, err := webauthncose.ParsePublicKey(authData.AttData.CredentialPublicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKey, err)
+	}
+
+	return &Credential{
+		ID:             EncodeID(authData.AttData.CredentialID),
+		PublicKey:      authData.AttData.CredentialPublicKey,
+		SignCount:      authData.Counter,
+		Transports:     resp.Response.Transports,
+		AAGUID:         authData.AttData.AAGUID,
+		UserVerified:   authData.Flags.HasUserVerified(),
+		BackupEligible: authData.Flags.HasBackupEligible(),
+	}, nil
+}
+
+// VerifyAssertion verifies the response to an authentication ceremony
+// started with challenge against the stored COSE public key of the
+// credential named in resp.ID.
+func (rp *RelyingParty) VerifyAssertion(
+	challenge string,
+	resp *AssertionResponse,
+	publicKey []byte,
+	requireUserVerification bool,
+) (*Assertion, error) {
+	if challenge == "" {
+		return nil, fmt.Errorf("%w: no challenge", ErrInvalidResponse)
+	}
+	raw := protocol.CredentialAssertionResponse{}
+	raw.ID, raw.Type = resp.ID, resp.Type
+	assertion, fields := &raw.AssertionResponse, resp.Response
+	var d fieldDecoder
+	raw.RawID = d.decode("id", resp.ID)
+	assertion.ClientDataJSON = d.decode("clientDataJSON", fields.ClientDataJSON)
+	assertion.AuthenticatorData = d.decode("authenticatorData", fields.AuthenticatorData)
+	assertion.Signature = d.decode("signature", fields.Signature)
+	assertion.UserHandle = d.decode("userHandle", fields.UserHandle)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	parsed, err := raw.Parse()
+	if err != nil {
+		return nil, invalidResponse(err)
+	}
+	if err := parsed.Verify(
+		challenge, rp.ID, "", rp.Origins, nil, nil,
+		protocol.TopOriginExplicitVerificationMode,
+		false, false, true,
+		publicKey, protocol.SignaturePolicy{},
+	); err != nil {
+		return nil, invalidResponse(err)
+	}
+
+	flags := parsed.Response.AuthenticatorData.Flags
+	if requireUserVerification && !flags.HasUserVerified() {
+		return nil, ErrUserNotVerified
+	}
+	return &Assertion{
+		SignCount:    parsed.Response.AuthenticatorData.Counter,
+		UserVerified: flags.HasUserVerified(),
+		BackupState:  flags.HasBackupState(),
+	}, nil
+}
+
+// UserHandle returns the decoded user handle of a discoverable credential
+// assertion, or nil when the authenticator did not return one.
+func (r *AssertionResponse) UserHandle() ([]byte, error) {
+	if r.Response.UserHandle == "" {
+		return nil, nil //nolint:nilnil // a missing user handle is not an error
+	}
+	h, err := b64.DecodeString(r.Response.UserHandle)
+	if err != nil {
+		return nil, fmt.Errorf("%w: userHandle is not base64url", ErrInvalidResponse)
+	}
+	return h, nil
+}
+
+// fieldDecoder decodes the base64url members of a browser response,
+// keeping the first failure.
+type fieldDecoder struct {
+	err error
+}
+
+func (d *fieldDecoder) decode(name, value string) []byte {
+	if d.err != nil {
+		return nil
+	}
+	raw, err := b64.DecodeString(value)
+	if err != nil {
+		d.err = fmt.Errorf("%w: %s is not base64url", ErrInvalidResponse, name)
+	}
+	return raw
+}
+
+// invalidResponse wraps a verification failure from the protocol package.
+// Its error text is a generic summary, so the detail is kept for logs.
+func invalidResponse(err error) error {
+	var perr *protocol.Error
+	if errors.As(err, &perr) && perr.DevInfo != "" {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidResponse, perr.Details, perr.DevInfo)
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+}
@@ -0,0 +1,210 @@
+package webauthn_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-authgate/authgate/internal/webauthn"
+	"github.com/go-authgate/authgate/internal/webauthn/webauthntest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRP = &webauthn.RelyingParty{
+	ID:      "auth.example.com",
+	Name:    "AuthGate",
+	Origins: []string{"https://auth.example.com"},
+}
+
+var b64 = base64.RawURLEncoding
+
+func newTestAuthenticator() *webauthntest.Authenticator {
+	return webauthntest.New(testRP.ID, testRP.Origins[0], []byte("user-1"))
+}
+
+func newChallenge(t *testing.T) string {
+	t.Helper()
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	return challenge
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	a := newTestAuthenticator()
+
+	challenge := newChallenge(t)
+	cred, err := testRP.VerifyRegistration(challenge, a.Create(challenge), true)
+	require.NoError(t, err)
+	assert.Equal(t, a.ID(), cred.ID)
+	assert.Equal(t, []string{"internal"}, cred.Transports)
+	assert.True(t, cred.UserVerified)
+
+	challenge = newChallenge(t)
+	resp := a.Get(challenge)
+	assertion, err := testRP.VerifyAssertion(challenge, resp, cred.PublicKey, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), assertion.SignCount)
+	assert.True(t, assertion.UserVerified)
+
+	handle, err := resp.UserHandle()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("user-1"), handle)
+}
+
+func TestVerifyRegistration_Rejects(t *testing.T) {
+	type authenticator = webauthntest.Authenticator
+	tests := []struct {
+		name   string
+		mutate func(a *authenticator)
+		resp   func(a *authenticator, challenge string) *webauthn.RegistrationResponse
+		want   error
+	}{
+		{
+			name:   "wrong origin",
+			mutate: func(a *authenticator) { a.Origin = "https://evil.example.com" },
+			want:   webauthn.ErrInvalidResponse,
+		},
+		{
+			name:   "wrong RP ID",
+			mutate: func(a *authenticator) { a.RPID = "evil.example.com" },
+			want:   webauthn.ErrInvalidResponse,
+		},
+		{
+			name:   "cross origin",
+			mutate: func(a *authenticator) { a.CrossOrigin = true },
+			want:   webauthn.ErrInvalidResponse,
+		},
+		{
+			name:   "user not verified",
+			mutate: func(a *authenticator) { a.Flags = webauthntest.FlagUserPresent },
+			want:   webauthn.ErrUserNotVerified,
+		},
+		{
+			name:   "user not present",
+			mutate: func(a *authenticator) { a.Flags = webauthntest.FlagUserVerified },
+			want:   webauthn.ErrInvalidResponse,
+		},
+		{
+			name: "wrong challenge",
+			resp: func(a *authenticator, _ string) *webauthn.RegistrationResponse {
+				return a.Create("some-other-challenge")
+			},
+			want: webauthn.ErrInvalidResponse,
+		},
+		{
+			name: "mismatched credential ID",
+			resp: func(a *authenticator, challenge string) *webauthn.RegistrationResponse {
+				r := a.Create(challenge)
+				r.ID = webauthn.EncodeID([]byte("another-id"))
+				return r
+			},
+			want: webauthn.ErrInvalidResponse,
+		},
+		{
+			name: "malformed attestation object",
+			resp: func(a *authenticator, challenge string) *webauthn.RegistrationResponse {
+				r := a.Create(challenge)
+				r.Response.AttestationObject = b64.EncodeToString([]byte{0xa1, 0x63})
+				return r
+			},
+			want: webauthn.ErrInvalidResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthenticator()
+			if tt.mutate != nil {
+				tt.mutate(a)
+			}
+			challenge := newChallenge(t)
+			var resp *webauthn.RegistrationResponse
+			if tt.resp != nil {
+				resp = tt.resp(a, challenge)
+			} else {
+				resp = a.Create(challenge)
+			}
+			_, err := testRP.VerifyRegistration(challenge, resp, true)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestVerifyRegistration_EmptyChallenge(t *testing.T) {
+	a := newTestAuthenticator()
+	_, err := testRP.VerifyRegistration("", a.Create(""), false)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+}
+
+func TestVerifyAssertion_Rejects(t *testing.T) {
+	a := newTestAuthenticator()
+	challenge := newChallenge(t)
+	cred, err := testRP.VerifyRegistration(challenge, a.Create(challenge), true)
+	require.NoError(t, err)
+
+	t.Run("tampered signature", func(t *testing.T) {
+		resp := a.Get(challenge)
+		sig, _ := b64.DecodeString(resp.Response.Signature)
+		sig[len(sig)-1] ^= 0xff
+		resp.Response.Signature = b64.EncodeToString(sig)
+		_, err := testRP.VerifyAssertion(challenge, resp, cred.PublicKey, false)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		other := newTestAuthenticator()
+		other.CredID = a.CredID
+		_, err := testRP.VerifyAssertion(challenge, other.Get(challenge), cred.PublicKey, false)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	})
+
+	t.Run("registration client data", func(t *testing.T) {
+		resp := a.Get(challenge)
+		resp.Response.ClientDataJSON = a.Create(challenge).Response.ClientDataJSON
+		_, err := testRP.VerifyAssertion(challenge, resp, cred.PublicKey, false)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	})
+
+	t.Run("signature not base64url", func(t *testing.T) {
+		resp := a.Get(challenge)
+		resp.Response.Signature = "not base64url!"
+		_, err := testRP.VerifyAssertion(challenge, resp, cred.PublicKey, false)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+	})
+
+	t.Run("user verification required", func(t *testing.T) {
+		a.Flags = webauthntest.FlagUserPresent
+		defer func() { a.Flags = webauthntest.FlagUserPresent | webauthntest.FlagUserVerified }()
+		_, err := testRP.VerifyAssertion(challenge, a.Get(challenge), cred.PublicKey, true)
+		assert.ErrorIs(t, err, webauthn.ErrUserNotVerified)
+
+		_, err = testRP.VerifyAssertion(challenge, a.Get(challenge), cred.PublicKey, false)
+		assert.NoError(t, err, "presence alone is enough when verification is not required")
+	})
+}
+
+func TestCreationOptions(t *testing.T) {
+	user := webauthn.UserEntity{ID: "dXNlcg", Name: "alice"}
+	opts := testRP.CreationOptions("challenge", user, nil)
+	raw, err := json.Marshal(opts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"challenge": "challenge",
+		"rp": {"id": "auth.example.com", "name": "AuthGate"},
+		"user": {"id": "dXNlcg", "name": "alice", "displayName": ""},
+		"pubKeyCredParams": [
+			{"type": "public-key", "alg": -7},
+			{"type": "public-key", "alg": -8},
+			{"type": "public-key", "alg": -257}
+		],
+		"timeout": 120000,
+		"excludeCredentials": [],
+		"authenticatorSelection": {
+			"residentKey": "preferred",
+			"requireResidentKey": false,
+			"userVerification": "preferred"
+		},
+		"attestation": "none"
+	}`, string(raw))
+}
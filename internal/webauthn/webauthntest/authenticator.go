@@ -0,0 +1,154 @@
+// Package webauthntest provides a software authenticator for testing code
+// that registers and verifies passkeys through package webauthn.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/go-authgate/authgate/internal/webauthn"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// Authenticator flag bits (WebAuthn §6.1).
+const (
+	FlagUserPresent  byte = 0x01
+	FlagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// Authenticator simulates a P-256 platform authenticator holding a single
+// passkey. Fields may be changed between ceremonies to produce invalid
+// responses.
+type Authenticator struct {
+	Key         *ecdsa.PrivateKey
+	CredID      []byte
+	UserHandle  []byte // returned by Get; nil omits it
+	SignCount   uint32 // incremented by each Get
+	Flags       byte
+	RPID        string
+	Origin      string
+	CrossOrigin bool
+}
+
+// New returns an authenticator for the relying party rpID at origin, with
+// user presence and verification set.
+func New(rpID, origin string, userHandle []byte) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	credID := make([]byte, 16)
+	if _, err := rand.Read(credID); err != nil {
+		panic(err)
+	}
+	return &Authenticator{
+		Key:        key,
+		CredID:     credID,
+		UserHandle: userHandle,
+		Flags:      FlagUserPresent | FlagUserVerified,
+		RPID:       rpID,
+		Origin:     origin,
+	}
+}
+
+// ID returns the credential ID as sent by the browser.
+func (a *Authenticator) ID() string {
+	return webauthn.EncodeID(a.CredID)
+}
+
+// Create answers a registration ceremony for challenge.
+func (a *Authenticator) Create(challenge string) *webauthn.RegistrationResponse {
+	resp := &webauthn.RegistrationResponse{ID: a.ID(), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64.EncodeToString(a.clientData("webauthn.create", challenge))
+	resp.Response.AttestationObject = b64.EncodeToString(marshalCBOR(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(true),
+	}))
+	resp.Response.Transports = []string{"internal"}
+	return resp
+}
+
+// Get answers an authentication ceremony for challenge, advancing the
+// signature counter.
+func (a *Authenticator) Get(challenge string) *webauthn.AssertionResponse {
+	a.SignCount++
+	authData := a.authData(false)
+	clientData := a.clientData("webauthn.get", challenge)
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	resp := &webauthn.AssertionResponse{ID: a.ID(), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64.EncodeToString(clientData)
+	resp.Response.AuthenticatorData = b64.EncodeToString(authData)
+	resp.Response.Signature = b64.EncodeToString(sig)
+	if a.UserHandle != nil {
+		resp.Response.UserHandle = b64.EncodeToString(a.UserHandle)
+	}
+	return resp
+}
+
+func (a *Authenticator) coseKey() []byte {
+	return marshalCBOR(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.Key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.Key.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+func (a *Authenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	out := append([]byte(nil), rpIDHash[:]...)
+	flags := a.Flags
+	if attested {
+		flags |= flagAttestedData
+	}
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.SignCount)
+	if attested {
+		out = append(out, make([]byte, 16)...) // zero AAGUID
+		out = binary.BigEndian.AppendUint16(out, uint16(len(a.CredID)))
+		out = append(out, a.CredID...)
+		out = append(out, a.coseKey()...)
+	}
+	return out
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) []byte {
+	raw, err := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": a.CrossOrigin,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func marshalCBOR(v any) []byte {
+	raw, err := webauthncbor.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}